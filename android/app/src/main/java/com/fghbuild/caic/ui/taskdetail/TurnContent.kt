@@ -35,6 +35,13 @@ import com.fghbuild.caic.util.imageDataToBitmap
 import kotlinx.serialization.json.JsonElement
 import com.mikepenz.markdown.m3.Markdown
 
+/** Labels of the system events reporting agent or relay failures seen on stderr. */
+private val stderrFailureLabels = mapOf(
+    "auth_error" to "Authentication failed",
+    "agent_crash" to "Agent crashed",
+    "relay_traceback" to "Relay error",
+)
+
 /** Renders a single [MessageGroup]. Used both in [TurnContent] and the flat list. */
 @Composable
 fun MessageGroupContent(
@@ -150,6 +157,31 @@ fun MessageGroupContent(
                             .padding(horizontal = 8.dp, vertical = 4.dp),
                     )
                 }
+                event?.kind == EventKinds.System && event.system?.subtype in stderrFailureLabels -> {
+                    val system = event.system!!
+                    Surface(
+                        modifier = Modifier.fillMaxWidth(),
+                        shape = MaterialTheme.shapes.small,
+                        color = MaterialTheme.colorScheme.errorContainer,
+                    ) {
+                        Column(modifier = Modifier.padding(horizontal = 8.dp, vertical = 4.dp)) {
+                            Text(
+                                text = stderrFailureLabels.getValue(system.subtype),
+                                style = MaterialTheme.typography.labelSmall,
+                                color = MaterialTheme.colorScheme.onErrorContainer,
+                            )
+                            val detail = system.detail
+                            if (!detail.isNullOrBlank()) {
+                                Text(
+                                    text = detail,
+                                    style = MaterialTheme.typography.bodySmall,
+                                    color = MaterialTheme.colorScheme.onErrorContainer,
+                                    fontFamily = androidx.compose.ui.text.font.FontFamily.Monospace,
+                                )
+                            }
+                        }
+                    }
+                }
                 event?.kind == EventKinds.System && event.system?.subtype == "step_start" -> {
                     // suppress: no useful content to display
                 }
@@ -183,6 +215,17 @@ fun MessageGroupContent(
                         )
                     }
                 }
+                event?.kind == EventKinds.Stderr -> {
+                    val stderr = event.stderr
+                    if (stderr != null) {
+                        Text(
+                            text = "${stderr.source}: ${stderr.line}",
+                            style = MaterialTheme.typography.bodySmall,
+                            color = MaterialTheme.colorScheme.onSurfaceVariant,
+                            fontFamily = androidx.compose.ui.text.font.FontFamily.Monospace,
+                        )
+                    }
+                }
                 event?.kind == EventKinds.Result -> {
                     val result = event.result
                     if (result != null) {
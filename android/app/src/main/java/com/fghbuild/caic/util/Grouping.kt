@@ -233,6 +233,7 @@ fun groupMessages(msgs: List<EventMessage>): List<MessageGroup> {
             }
             EventKinds.Todo -> { /* Rendered by ProgressPanel directly; skip to avoid splitting tool groups. */ }
             EventKinds.DiffStat -> { /* Metadata-only; skip. */ }
+            EventKinds.StateChange -> { /* Metadata-only; the task list shows the state. */ }
             EventKinds.Thinking -> {
                 val last = lastGroup()
                 if (last != null && last.kind == GroupKind.ACTION && last.toolCalls.isEmpty() &&
@@ -154,6 +154,10 @@ func (*fakeContainer) Fetch(_ context.Context, _ []md.Repo) error            { r
 func (*fakeContainer) Stop(_ context.Context, _ string) error                { return nil }
 func (*fakeContainer) Purge(_ context.Context, _ string, _ []md.Repo) error  { return nil }
 func (*fakeContainer) Revive(_ context.Context, _ string, _ []md.Repo) error { return nil }
+func (*fakeContainer) SparseCheckout(_ context.Context, _ string, _ md.Repo, _ []string) error {
+	return nil
+}
+func (*fakeContainer) Run(_ context.Context, _, _ string) error { return nil }
 
 // fakeBackend implements agent.Backend with a shell process that emits
 // streaming text deltas followed by complete messages, simulating
@@ -206,4 +210,10 @@ func (*fakeBackend) Models() []string { return []string{"fake-model"} }
 
 func (*fakeBackend) SupportsImages() bool { return true }
 
+func (*fakeBackend) SupportsReadOnly() bool { return true }
+
+func (*fakeBackend) IsMutatingTool(name string) bool {
+	return agent.MatchTool([]string{"Bash", "Edit", "Write"}, name)
+}
+
 func (*fakeBackend) ContextWindowLimit(string) int { return 180_000 }
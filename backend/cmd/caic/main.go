@@ -76,11 +76,15 @@ Flags:
 Environment variables (flags take precedence when set):
 
   Core:
-    CAIC_HTTP                   HTTP listen address (e.g. :8080)
+    CAIC_HTTP                   HTTP listen address (e.g. :8080) or Unix socket (e.g. unix:/run/caic/caic.sock)
     CAIC_ROOT                   Parent directory containing git repos
     CAIC_LOG_LEVEL              Log level: debug, info, warn, error (default: info)
     CAIC_EXTERNAL_URL           Public base URL; required for OAuth login and webhooks
 
+  Reverse proxy (optional):
+    CAIC_BASE_PATH              URL prefix caic is served under (e.g. /caic)
+    CAIC_TRUSTED_PROXIES        Comma-separated proxy IPs/CIDRs whose X-Forwarded-For/Proto/Host headers are honored
+
   LLM features (title generation, commit descriptions):
     CAIC_LLM_PROVIDER           Provider: anthropic, gemini, openaichat, etc.
     CAIC_LLM_MODEL              Model name (e.g. claude-haiku-4-5-20251001)
@@ -106,6 +110,38 @@ Environment variables (flags take precedence when set):
   Agents:
     GEMINI_API_KEY              Gemini API key for the Gemini Live voice agent
     TAILSCALE_API_KEY           Tailscale API key for Tailscale ephemeral node
+    ANTHROPIC_ADMIN_KEY         Anthropic Admin API key; adds organization API spend to usage
+
+  Tracing (optional):
+    OTEL_EXPORTER_OTLP_ENDPOINT OTLP/HTTP collector URL (e.g. http://localhost:4318); enables OpenTelemetry tracing
+    OTEL_EXPORTER_OTLP_TRACES_ENDPOINT  Traces-only OTLP/HTTP URL; overrides OTEL_EXPORTER_OTLP_ENDPOINT for spans
+
+  Parser diagnostics (optional):
+    CAIC_RECORD_DIR             Directory receiving a fixture bundle (wire lines + normalized events) per finished task
+    CAIC_STRICT_PARSE           Set to 1 to keep the agent output lines the parsers drop in a dead-letter file per task
+
+  Fault injection (testing and staging only):
+    CAIC_CHAOS                  Faults injected into containers and agents (e.g. fail=0.1,delay=2s,malformed=0.05,drop=0.01,seed=1)
+
+  Log encryption (optional):
+    CAIC_LOG_KEY_FILE           File holding a 32 bytes base64 or hex key (relative to ~/.config/caic/); encrypts task logs and derived stores at rest
+
+  LAN discovery (optional):
+    CAIC_MDNS                   Set to 1 to advertise the server as _caic._tcp over mDNS; requires a non-loopback CAIC_HTTP
+
+  SSH (optional):
+    CAIC_SSH_MULTIPLEX          Set to 1 to share one ssh process connection per container across diffs and fetches
+    CAIC_SSH_EXEC               Set to 1 to run agents and probes through the ssh binary instead of native SSH connections
+
+  Tailscale (optional):
+    CAIC_HOST_TAILSCALE_SERVE   Tailnet HTTPS port (e.g. 443) to expose caic on via the host's "tailscale serve"
+
+  TLS (optional; serves HTTPS with HTTP/2 on CAIC_HTTP):
+    CAIC_TLS_CERT               Path to a PEM certificate chain (relative to ~/.config/caic/); requires CAIC_TLS_KEY
+    CAIC_TLS_KEY                Path to the PEM private key (relative to ~/.config/caic/)
+    CAIC_ACME_HOSTS             Comma-separated hostnames for automatic Let's Encrypt certificates; mutually exclusive with CAIC_TLS_CERT
+    CAIC_ACME_EMAIL             Contact email for Let's Encrypt expiry notices
+    CAIC_HTTP_REDIRECT          Plain HTTP address redirecting to https and answering ACME challenges (e.g. :80)
 
   IP geolocation (optional):
     CAIC_IPGEO_DB               Path to a MaxMind MMDB file; relative paths resolve against ~/.config/caic/ (e.g. GeoLite2-Country.mmdb)
@@ -115,20 +151,36 @@ See contrib/caic.env for a template with all variables and documentation.
 `)
 	}
 
-	addr := flag.String("http", os.Getenv("CAIC_HTTP"), "start web UI on this address (e.g. :8080)")
+	addr := flag.String("http", os.Getenv("CAIC_HTTP"), "start web UI on this address (e.g. :8080 or unix:/run/caic/caic.sock)")
 	root := flag.String("root", os.Getenv("CAIC_ROOT"), "parent directory containing git repos")
 	logLevel := flag.String("log-level", envDefault("CAIC_LOG_LEVEL", "info"), "log level (debug, info, warn, error)")
+	mockMode := flag.Bool("mock", false, "serve synthetic tasks and scripted agent streams for client development; needs no containers, agents or credentials")
 	flag.Parse()
 	if args := flag.Args(); len(args) > 0 {
 		return fmt.Errorf("unexpected arguments: %v", args)
 	}
 	*root = expandTilde(*root)
 
-	initLogging(*logLevel)
+	logRing := server.NewLogRing(0)
+	initLogging(*logLevel, logRing)
+	shutdownTracing, err := initTracing(ctx)
+	if err != nil {
+		return fmt.Errorf("init tracing: %w", err)
+	}
+	defer func() {
+		// Use a fresh context: ctx is already cancelled on shutdown.
+		sctx, scancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer scancel()
+		if err := shutdownTracing(sctx); err != nil {
+			slog.Warn("flush traces", "err", err)
+		}
+	}()
 
 	cfg := &server.Config{
+		LogRing:                 logRing,
 		GeminiAPIKey:            os.Getenv("GEMINI_API_KEY"),
 		TailscaleAPIKey:         os.Getenv("TAILSCALE_API_KEY"),
+		AnthropicAdminKey:       os.Getenv("ANTHROPIC_ADMIN_KEY"),
 		LLMProvider:             os.Getenv("CAIC_LLM_PROVIDER"),
 		LLMModel:                os.Getenv("CAIC_LLM_MODEL"),
 		ConfigDir:               configDir(),
@@ -136,6 +188,8 @@ See contrib/caic.env for a template with all variables and documentation.
 		GitHubToken:             resolveGitHubToken(),
 		GitLabToken:             os.Getenv("GITLAB_TOKEN"),
 		ExternalURL:             os.Getenv("CAIC_EXTERNAL_URL"),
+		BasePath:                os.Getenv("CAIC_BASE_PATH"),
+		TrustedProxies:          os.Getenv("CAIC_TRUSTED_PROXIES"),
 		GitHubOAuthClientID:     os.Getenv("GITHUB_OAUTH_CLIENT_ID"),
 		GitHubOAuthClientSecret: os.Getenv("GITHUB_OAUTH_CLIENT_SECRET"),
 		GitLabOAuthClientID:     os.Getenv("GITLAB_OAUTH_CLIENT_ID"),
@@ -148,11 +202,25 @@ See contrib/caic.env for a template with all variables and documentation.
 		GitHubAppPrivateKeyPEM:  []byte(readFileFromEnv("GITHUB_APP_PRIVATE_KEY_PEM")),
 		GitHubAppAllowedOwners:  os.Getenv("GITHUB_APP_ALLOWED_OWNERS"),
 		GitLabWebhookSecret:     []byte(os.Getenv("GITLAB_WEBHOOK_SECRET")),
+		TLSCertFile:             resolvePathFromEnv("CAIC_TLS_CERT"),
+		TLSKeyFile:              resolvePathFromEnv("CAIC_TLS_KEY"),
+		ACMEHosts:               os.Getenv("CAIC_ACME_HOSTS"),
+		ACMEEmail:               os.Getenv("CAIC_ACME_EMAIL"),
+		HTTPRedirectAddr:        os.Getenv("CAIC_HTTP_REDIRECT"),
+		HostTailscaleServe:      os.Getenv("CAIC_HOST_TAILSCALE_SERVE"),
+		MDNS:                    os.Getenv("CAIC_MDNS") == "1",
+		SSHMultiplex:            os.Getenv("CAIC_SSH_MULTIPLEX") == "1",
+		ExecSSH:                 os.Getenv("CAIC_SSH_EXEC") == "1",
 		IPGeoDB:                 resolvePathFromEnv("CAIC_IPGEO_DB"),
 		IPGeoAllowlist:          os.Getenv("CAIC_IPGEO_ALLOWLIST"),
+		RecordDir:               expandTilde(os.Getenv("CAIC_RECORD_DIR")),
+		StrictParse:             os.Getenv("CAIC_STRICT_PARSE") == "1",
+		LogKeyFile:              resolvePathFromEnv("CAIC_LOG_KEY_FILE"),
+		Chaos:                   os.Getenv("CAIC_CHAOS"),
 	}
 
 	slog.Info("gemini", "apikey", maskedToken(cfg.GeminiAPIKey))                                            //nolint:gosec // G706: value from env, not user input
+	slog.Info("anthropic", "adminkey", maskedToken(cfg.AnthropicAdminKey))                                  //nolint:gosec // G706: value from env, not user input
 	slog.Info("tailscale", "apikey", maskedToken(cfg.TailscaleAPIKey))                                      //nolint:gosec // G706: value from env, not user input
 	slog.Info("LLM", "provider", cfg.LLMProvider, "model", cfg.LLMModel)                                    //nolint:gosec // G706: value from env, not user input
 	slog.Info("github", "pat", maskedToken(cfg.GitHubToken), "oauth", maskedToken(cfg.GitHubOAuthClientID)) //nolint:gosec // G706: value from env, not user input
@@ -164,10 +232,16 @@ See contrib/caic.env for a template with all variables and documentation.
 	if isFakeMode {
 		return serveFake(ctx, *addr, *root, cfg)
 	}
+	if *mockMode {
+		return serveMock(ctx, *addr, cfg)
+	}
 	if *addr == "" {
 		return errors.New("HTTP address is required: set -http flag or CAIC_HTTP env var")
 	}
 	*addr = localizeAddr(*addr)
+	if p, ok := strings.CutPrefix(*addr, "unix:"); ok {
+		*addr = "unix:" + expandTilde(p)
+	}
 	if *root == "" {
 		return errors.New("root directory is required: set -root flag or CAIC_ROOT env var")
 	}
@@ -194,8 +268,9 @@ func roundDur(d time.Duration) time.Duration {
 
 // initLogging configures slog with tint for colored, concise output.
 // Timestamps are omitted under systemd (JOURNAL_STREAM), and zero-value
-// attributes are dropped.
-func initLogging(level string) {
+// attributes are dropped. Records are also written as JSON to ring, when
+// non-nil, so they can be streamed to the web UI.
+func initLogging(level string, ring *server.LogRing) {
 	ll := &slog.LevelVar{}
 	switch level {
 	case "debug":
@@ -210,7 +285,7 @@ func initLogging(level string) {
 	// Skip timestamps when running under systemd (it adds its own).
 	underSystemd := os.Getenv("JOURNAL_STREAM") != ""
 	homeDir, _ := os.UserHomeDir()
-	slog.SetDefault(slog.New(tint.NewHandler(colorable.NewColorable(os.Stderr), &tint.Options{
+	var h slog.Handler = tint.NewHandler(colorable.NewColorable(os.Stderr), &tint.Options{
 		Level:      ll,
 		TimeFormat: "15:04:05.000",
 		NoColor:    !isatty.IsTerminal(os.Stderr.Fd()),
@@ -249,7 +324,51 @@ func initLogging(level string) {
 			}
 			return a
 		},
-	})))
+	})
+	if ring != nil {
+		h = teeHandler{h, slog.NewJSONHandler(ring, &slog.HandlerOptions{Level: ll})}
+	}
+	slog.SetDefault(slog.New(h))
+}
+
+// teeHandler forwards each record to every handler that has it enabled.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, l) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, r.Level) {
+			if err := h.Handle(ctx, r.Clone()); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithAttrs(attrs)
+	}
+	return out
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	out := make(teeHandler, len(t))
+	for i, h := range t {
+		out[i] = h.WithGroup(name)
+	}
+	return out
 }
 
 func serveHTTP(ctx context.Context, addr, rootDir string, cfg *server.Config) error {
@@ -0,0 +1,55 @@
+// Mock mode: the full API over synthetic repos, tasks and scripted agents.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/mock"
+	"github.com/caic-xyz/caic/backend/internal/server"
+)
+
+// serveMock starts the HTTP server for client development. Repos, settings
+// and task logs live in a temporary directory removed on exit, the task list
+// is seeded with the bundled fixtures and new tasks replay them. No container
+// runtime, agent or credential is needed.
+func serveMock(ctx context.Context, addr string, cfg *server.Config) error {
+	if addr == "" {
+		addr = "localhost:8090"
+	} else {
+		addr = localizeAddr(addr)
+	}
+	tmpDir, err := os.MkdirTemp("", "caic-mock-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+	rootDir, err := mock.InitRepos(tmpDir)
+	if err != nil {
+		return fmt.Errorf("init mock repos: %w", err)
+	}
+	cfg.ConfigDir = filepath.Join(tmpDir, "config")
+	cfg.CacheDir = filepath.Join(tmpDir, "cache")
+	if err := mock.Seed(cfg.CacheDir, "acme/webapp", time.Now()); err != nil {
+		return fmt.Errorf("seed mock tasks: %w", err)
+	}
+	srv, err := server.New(ctx, rootDir, cfg)
+	if err != nil {
+		return fmt.Errorf("new server: %w", err)
+	}
+	b := mock.NewBackend()
+	srv.SetRunnerOps(&mock.Container{}, map[agent.Harness]agent.Backend{b.Harness(): b})
+	slog.Info("mock mode", "addr", addr, "dir", tmpDir)
+	err = srv.ListenAndServe(ctx, addr)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -40,6 +40,11 @@ import (
 	"time"
 
 	"github.com/caic-xyz/caic/backend/internal/agent/relay"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ImageData carries a single base64-encoded image for multi-modal input.
@@ -62,6 +67,9 @@ type Options struct {
 	InitialPrompt   Prompt // Initial prompt; never mutated after creation.
 	ResumeSessionID string
 	RelayOffset     int64 // Byte offset into relay output.jsonl for AttachRelay.
+	// ReadOnly restricts the agent to exploring: it may read files and run
+	// read-only commands but not edit. Only for backends that SupportsReadOnly.
+	ReadOnly bool
 }
 
 // WireFormat defines the wire protocol for a backend's stdin/stdout
@@ -77,9 +85,14 @@ type WireFormat interface {
 	ParseMessage(line []byte) ([]Message, error)
 }
 
+// Process is the command a Session reads from, usually a *sshconn.Cmd.
+type Process interface {
+	Wait() error
+}
+
 // Session manages a running agent process.
 type Session struct {
-	cmd       *exec.Cmd
+	cmd       Process
 	stdin     io.WriteCloser
 	logW      io.Writer
 	wire      WireFormat
@@ -91,8 +104,9 @@ type Session struct {
 	err       error
 }
 
-// NewSession creates a Session from an already-started command. Messages read
-// from stdout are parsed and sent to msgCh. logW receives raw NDJSON lines
+// NewSession creates a Session from an already-started command, or from
+// in-process pipes when cmd is nil. Messages read from stdout are parsed and
+// sent to msgCh. logW receives raw NDJSON lines
 // (may be nil). wire defines the backend's wire protocol.
 //
 // A background goroutine reads stdout until EOF, then waits for the process to
@@ -103,7 +117,7 @@ type Session struct {
 // parse error indicates corrupted output while the process may still exit 0.
 // If neither parse nor wait errors occur but no ResultMessage was seen, the
 // session reports "agent exited without a result message".
-func NewSession(cmd *exec.Cmd, stdin io.WriteCloser, stdout io.Reader, msgCh chan<- Message, logW io.Writer, wire WireFormat, log *slog.Logger) *Session {
+func NewSession(cmd Process, stdin io.WriteCloser, stdout io.Reader, msgCh chan<- Message, logW io.Writer, wire WireFormat, log *slog.Logger) *Session {
 	if log == nil {
 		log = slog.Default()
 	}
@@ -119,7 +133,10 @@ func NewSession(cmd *exec.Cmd, stdin io.WriteCloser, stdout io.Reader, msgCh cha
 	go func() {
 		defer close(s.done)
 		result, parseErr := readMessages(stdout, msgCh, logW, wire.ParseMessage)
-		waitErr := cmd.Wait()
+		var waitErr error
+		if cmd != nil {
+			waitErr = cmd.Wait()
+		}
 		// Store the result and first non-nil error.
 		s.result = result
 		switch {
@@ -179,6 +196,13 @@ func (s *Session) Close() {
 	})
 }
 
+// Drop closes stdin without the sentinel, like an SSH drop: the relay daemon
+// keeps the agent running and the session ends once the attach client exits.
+// It is used to inject faults. Close after Drop is a no-op.
+func (s *Session) Drop() {
+	s.closeOnce.Do(func() { _ = s.stdin.Close() })
+}
+
 // Done returns a channel that is closed when the agent process exits.
 func (s *Session) Done() <-chan struct{} {
 	return s.done
@@ -236,26 +260,92 @@ func readMessages(r io.Reader, msgCh chan<- Message, logW io.Writer, parseFn fun
 
 // Relay paths inside the container.
 const (
-	RelayDir        = "/tmp/caic-relay"
-	RelayScriptPath = RelayDir + "/relay.py"
-	RelaySockPath   = RelayDir + "/relay.sock"
-	RelayOutputPath = RelayDir + "/output.jsonl"
-	RelayLogPath    = RelayDir + "/relay.log"
+	RelayDir         = "/tmp/caic-relay"
+	RelayScriptPath  = RelayDir + "/relay.py"
+	RelaySockPath    = RelayDir + "/relay.sock"
+	RelayOutputPath  = RelayDir + "/output.jsonl"
+	RelayLogPath     = RelayDir + "/relay.log"
+	RelayVersionPath = RelayDir + "/version"
 )
 
-// DeployRelay uploads the relay script into the container. Idempotent.
+// Tracer creates spans for agent startup. It is a no-op until the process
+// installs a global TracerProvider.
+var Tracer = otel.Tracer("github.com/caic-xyz/caic/backend/internal/agent")
+
+// EndSpan records err on span, if any, and ends it.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// relayDeployScript replaces the relay script in the container unless it is
+// already identical, then prints the hash of the deployed copy. The new
+// script is renamed into place so that a concurrent reader never sees it
+// truncated.
+var relayDeployScript = fmt.Sprintf(
+	`mkdir -p %[1]s && `+
+		`if [ "$(sha256sum 2>/dev/null < %[2]s | cut -d' ' -f1)" = %[3]s ]; then cat > /dev/null; `+
+		`else cat > %[2]s.tmp && mv %[2]s.tmp %[2]s; fi && `+
+		`sha256sum < %[2]s`,
+	RelayDir, RelayScriptPath, relay.Hash)
+
+// DeployRelay uploads the relay script into the container, unless the copy
+// there is already up to date, and verifies the deployed copy. Idempotent.
 func DeployRelay(ctx context.Context, container string) error {
 	// SSH concatenates remote args with spaces and passes them to the login
 	// shell, so a single string works correctly as a shell command.
-	cmd := exec.CommandContext(ctx, "ssh", container, //nolint:gosec // container is not user-controlled
-		"mkdir -p "+RelayDir+" && cat > "+RelayScriptPath)
-	cmd.Stdin = bytes.NewReader(relay.Script)
-	if out, err := cmd.CombinedOutput(); err != nil {
+	var out []byte
+	err := RetrySSH(ctx, func() error {
+		cmd := sshconn.Command(ctx, container, relayDeployScript)
+		cmd.Stdin = bytes.NewReader(relay.Script)
+		var err error
+		out, err = cmd.CombinedOutput()
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("deploy relay: %w: %s", err, out)
 	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if f := strings.Fields(lines[len(lines)-1]); len(f) == 0 || f[0] != relay.Hash {
+		return fmt.Errorf("deploy relay: deployed copy doesn't match sha256 %s: %s", relay.Hash, out)
+	}
 	return nil
 }
 
+// RelayVersionError is returned when attaching to a relay daemon speaking
+// another protocol version, typically one started by an older caic before an
+// upgrade. The agent it runs must be resumed in a new relay instead.
+type RelayVersionError struct {
+	Got  string // Version the daemon recorded; empty if it predates versioning.
+	Want int
+}
+
+func (e *RelayVersionError) Error() string {
+	got := e.Got
+	if got == "" {
+		got = "unversioned"
+	}
+	return fmt.Sprintf("relay protocol version %s is incompatible with %d", got, e.Want)
+}
+
+// RelayVersion returns the protocol version recorded by the relay daemon
+// running in the container, or "" if none is recorded.
+func RelayVersion(ctx context.Context, container string) (string, error) {
+	var out []byte
+	err := RetrySSH(ctx, func() error {
+		var err error
+		out, err = sshconn.Command(ctx, container, "cat "+RelayVersionPath+" 2>/dev/null; true").Output()
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("read relay version: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // WidgetPluginDir is the container path where the widget plugin is deployed.
 const WidgetPluginDir = RelayDir + "/widget-plugin"
 
@@ -290,10 +380,16 @@ func DeployEmbeddedDir(ctx context.Context, container string, fsys fs.FS, target
 	if err := tw.Close(); err != nil {
 		return fmt.Errorf("close tar: %w", err)
 	}
-	cmd := exec.CommandContext(ctx, "ssh", container, //nolint:gosec // container is not user-controlled
-		"mkdir -p "+targetDir+" && tar xf - -C "+targetDir)
-	cmd.Stdin = &buf
-	if out, err := cmd.CombinedOutput(); err != nil {
+	var out []byte
+	err := RetrySSH(ctx, func() error {
+		cmd := sshconn.Command(ctx, container,
+			"mkdir -p "+targetDir+" && tar xf - -C "+targetDir)
+		cmd.Stdin = bytes.NewReader(buf.Bytes())
+		var err error
+		out, err = cmd.CombinedOutput()
+		return err
+	})
+	if err != nil {
 		return fmt.Errorf("deploy %s: %w: %s", targetDir, err, out)
 	}
 	return nil
@@ -302,10 +398,12 @@ func DeployEmbeddedDir(ctx context.Context, container string, fsys fs.FS, target
 // HasRelayDir checks whether the caic relay directory exists in the container.
 // Its presence proves caic deployed the relay at some point.
 func HasRelayDir(ctx context.Context, container string) (bool, error) {
-	cmd := exec.CommandContext(ctx, "ssh", container, "test", "-d", RelayDir) //nolint:gosec // container is not user-controlled
-	if err := cmd.Run(); err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+	err := RetrySSH(ctx, func() error {
+		return sshconn.Command(ctx, container, "test", "-d", RelayDir).Run()
+	})
+	if err != nil {
+		var exitErr *sshconn.ExitError
+		if errors.As(err, &exitErr) && exitErr.Code == 1 {
 			return false, nil
 		}
 		return false, fmt.Errorf("test relay dir: %w", err)
@@ -320,15 +418,21 @@ func RelayStatus(ctx context.Context, container string) (alive bool, detail stri
 		`sock=0; [ -S %[1]s ] && sock=1; `+
 			`pid=""; [ -f %[2]s ] && pid=$(cat %[2]s 2>/dev/null); `+
 			`killok=0; if [ -n "$pid" ] && kill -0 "$pid" 2>/dev/null; then killok=1; fi; `+
-			`echo "sock=$sock pid=$pid kill=$killok"; `+
+			`ver=$(cat %[3]s 2>/dev/null); `+
+			`echo "sock=$sock pid=$pid kill=$killok ver=$ver"; `+
 			`[ "$sock" -eq 1 ] && [ "$killok" -eq 1 ]`,
-		RelaySockPath, pidPath)
-	cmd := exec.CommandContext(ctx, "ssh", container, "sh", "-c", check) //nolint:gosec // container is not user-controlled
-	out, err := cmd.CombinedOutput()
+		RelaySockPath, pidPath, RelayVersionPath)
+	var out []byte
+	err = RetrySSH(ctx, func() error {
+		var err error
+		out, err = sshconn.Command(ctx, container, "sh", "-c", check).CombinedOutput()
+		return err
+	})
 	detail = strings.TrimSpace(string(out))
 	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) && exitErr.ExitCode() != 0 {
+		// A dead connection says nothing about the relay.
+		var exitErr *sshconn.ExitError
+		if errors.As(err, &exitErr) {
 			return false, detail, nil
 		}
 		return false, detail, fmt.Errorf("test relay: %w", err)
@@ -342,13 +446,51 @@ func IsRelayRunning(ctx context.Context, container string) (bool, error) {
 	return alive, err
 }
 
+// killAgentScript sends SIGTERM to the children of the relay daemon, i.e. the
+// agent, then waits up to 10 seconds for the daemon to clean up and remove
+// its PID file like after any agent exit.
+var killAgentScript = fmt.Sprintf(`import os, signal, time
+try:
+    ppid = open(%[1]q).read().strip()
+except OSError:
+    raise SystemExit(0)
+for p in os.listdir("/proc"):
+    try:
+        if p.isdigit() and open("/proc/" + p + "/stat").read().rsplit(")", 1)[1].split()[1] == ppid:
+            os.kill(int(p), signal.SIGTERM)
+    except (OSError, IndexError):
+        pass
+for _ in range(100):
+    if not os.path.exists(%[1]q):
+        raise SystemExit(0)
+    time.sleep(0.1)
+raise SystemExit("relay daemon still running")
+`, RelayDir+"/pid")
+
+// KillAgent terminates the agent run by the relay daemon of container, e.g.
+// when it hangs, and returns once the daemon exited. It is a no-op when no
+// daemon runs.
+func KillAgent(ctx context.Context, container string) error {
+	cmd := sshconn.Command(ctx, container, "python3", "-")
+	cmd.Stdin = strings.NewReader(killAgentScript)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("kill agent: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
 // ReadRelayLog reads the last maxBytes of the relay daemon's log file from the
 // container. Returns empty string on any error (missing file, SSH failure).
 func ReadRelayLog(ctx context.Context, container string, maxBytes int) string {
 	// Use tail -c to cap the output; the log can be large after long sessions.
 	arg := fmt.Sprintf("tail -c %d %s 2>/dev/null", maxBytes, RelayLogPath)
-	cmd := exec.CommandContext(ctx, "ssh", container, arg) //nolint:gosec // container is not user-controlled
-	out, err := cmd.Output()
+	var out []byte
+	err := RetrySSH(ctx, func() error {
+		var err error
+		out, err = sshconn.Command(ctx, container, arg).Output()
+		return err
+	})
 	if err != nil {
 		return ""
 	}
@@ -362,12 +504,16 @@ func ReadPlan(ctx context.Context, container, planFile string) (string, error) {
 	if container == "" {
 		return "", errors.New("read plan: container is required")
 	}
-	args := []string{container, "python3", RelayScriptPath, "read-plan"}
+	args := []string{"python3", RelayScriptPath, "read-plan"}
 	if planFile != "" {
 		args = append(args, planFile)
 	}
-	cmd := exec.CommandContext(ctx, "ssh", args...) //nolint:gosec // args are not user-controlled.
-	out, err := cmd.Output()
+	var out []byte
+	err := RetrySSH(ctx, func() error {
+		var err error
+		out, err = sshconn.Command(ctx, container, args...).Output()
+		return err
+	})
 	if err != nil {
 		return "", fmt.Errorf("read plan: %w", err)
 	}
@@ -375,11 +521,16 @@ func ReadPlan(ctx context.Context, container, planFile string) (string, error) {
 }
 
 // SlogWriter is an io.Writer that logs each line via slog.Warn. It is used
-// as cmd.Stderr for SSH relay subprocesses across all backends.
+// as cmd.Stderr for SSH relay subprocesses across all backends. When MsgCh is
+// set, the lines are also sent to it so that they show up in the task's
+// history: authentication failures, crashes and relay tracebacks as a
+// SystemMessage, other lines as a StderrMessage.
 type SlogWriter struct {
 	Prefix    string
 	Container string
+	MsgCh     chan<- Message
 	buf       []byte
+	cls       stderrClassifier
 }
 
 func (w *SlogWriter) Write(p []byte) (int, error) {
@@ -389,11 +540,17 @@ func (w *SlogWriter) Write(p []byte) (int, error) {
 		if i < 0 {
 			break
 		}
-		line := string(bytes.TrimSpace(w.buf[:i]))
+		raw := string(bytes.TrimRight(w.buf[:i], " \t\r"))
 		w.buf = w.buf[i+1:]
-		if line != "" {
+		if line := strings.TrimSpace(raw); line != "" {
 			slog.Warn("stderr", "src", w.Prefix, "ctr", w.Container, "line", line)
 		}
+		if w.MsgCh != nil {
+			w.cls.source = w.Prefix
+			if m := w.cls.add(raw); m != nil {
+				w.MsgCh <- m
+			}
+		}
 	}
 	return len(p), nil
 }
@@ -401,22 +558,24 @@ func (w *SlogWriter) Write(p []byte) (int, error) {
 // StartRelay deploys the relay, launches an agent via relay serve-attach with
 // the given CLI args, and sends the initial prompt. Used by backends that
 // follow the standard relay protocol (claude, gemini, kilo).
-func StartRelay(ctx context.Context, opts *Options, agentArgs []string, msgCh chan<- Message, logW io.Writer, wire WireFormat) (*Session, error) {
+func StartRelay(ctx context.Context, opts *Options, agentArgs []string, msgCh chan<- Message, logW io.Writer, wire WireFormat) (_ *Session, err error) {
 	if opts.Dir == "" {
 		return nil, errors.New("opts.Dir is required")
 	}
+	ctx, span := Tracer.Start(ctx, "agent.StartRelay", trace.WithAttributes(attribute.String("caic.container", opts.Container)))
+	defer func() { EndSpan(span, err) }()
 	tStart := time.Now()
 	if err := DeployRelay(ctx, opts.Container); err != nil {
 		return nil, err
 	}
 	slog.Debug("startup", "phase", "deploy_relay", "ctr", opts.Container, "dur", time.Since(tStart))
 
-	sshArgs := make([]string, 0, 7+len(agentArgs))
-	sshArgs = append(sshArgs, opts.Container, "python3", RelayScriptPath, "serve-attach", "--dir", opts.Dir, "--")
+	sshArgs := make([]string, 0, 6+len(agentArgs))
+	sshArgs = append(sshArgs, "python3", RelayScriptPath, "serve-attach", "--dir", opts.Dir, "--")
 	sshArgs = append(sshArgs, agentArgs...)
 
 	slog.Debug("relay", "msg", "launch", "ctr", opts.Container, "args", agentArgs)
-	cmd := exec.CommandContext(ctx, "ssh", sshArgs...) //nolint:gosec // args are not user-controlled.
+	cmd := sshconn.Command(ctx, opts.Container, sshArgs...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, fmt.Errorf("stdin pipe: %w", err)
@@ -425,7 +584,7 @@ func StartRelay(ctx context.Context, opts *Options, agentArgs []string, msgCh ch
 	if err != nil {
 		return nil, fmt.Errorf("stdout pipe: %w", err)
 	}
-	cmd.Stderr = &SlogWriter{Prefix: "relay serve-attach", Container: opts.Container}
+	cmd.Stderr = &SlogWriter{Prefix: "relay serve-attach", Container: opts.Container, MsgCh: msgCh}
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("start relay: %w", err)
 	}
@@ -445,7 +604,7 @@ func StartRelay(ctx context.Context, opts *Options, agentArgs []string, msgCh ch
 // ReadRelayOutput reads the complete output.jsonl from the container's relay
 // and parses each line using parseFn.
 func ReadRelayOutput(ctx context.Context, container string, parseFn func([]byte) ([]Message, error)) (msgs []Message, size int64, err error) {
-	cmd := exec.CommandContext(ctx, "ssh", container, "cat", RelayOutputPath) //nolint:gosec // args are not user-controlled.
+	cmd := sshconn.Command(ctx, container, "cat", RelayOutputPath)
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, 0, fmt.Errorf("read relay output: %w", err)
@@ -473,12 +632,20 @@ func ReadRelayOutput(ctx context.Context, container string, parseFn func([]byte)
 // and returns a new Session. It waits briefly for the attach process to
 // confirm connectivity; if the process exits immediately (e.g. relay socket
 // is stale), an error is returned so the caller can fall back to --resume.
-func AttachRelaySession(ctx context.Context, container string, offset int64, msgCh chan<- Message, logW io.Writer, wire WireFormat) (*Session, error) {
-	sshArgs := []string{
-		container, "python3", RelayScriptPath, "attach",
-		"--offset", strconv.FormatInt(offset, 10),
+// A relay speaking another protocol version is refused with a
+// *RelayVersionError.
+func AttachRelaySession(ctx context.Context, container string, offset int64, msgCh chan<- Message, logW io.Writer, wire WireFormat) (_ *Session, err error) {
+	ctx, span := Tracer.Start(ctx, "agent.AttachRelay", trace.WithAttributes(attribute.String("caic.container", container)))
+	defer func() { EndSpan(span, err) }()
+	v, err := RelayVersion(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+	if v != strconv.Itoa(relay.ProtocolVersion) {
+		return nil, &RelayVersionError{Got: v, Want: relay.ProtocolVersion}
 	}
-	cmd := exec.CommandContext(ctx, "ssh", sshArgs...) //nolint:gosec // args are not user-controlled.
+	cmd := sshconn.Command(ctx, container, "python3", RelayScriptPath, "attach",
+		"--offset", strconv.FormatInt(offset, 10))
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, fmt.Errorf("stdin pipe: %w", err)
@@ -487,7 +654,7 @@ func AttachRelaySession(ctx context.Context, container string, offset int64, msg
 	if err != nil {
 		return nil, fmt.Errorf("stdout pipe: %w", err)
 	}
-	cmd.Stderr = &SlogWriter{Prefix: "relay attach", Container: container}
+	cmd.Stderr = &SlogWriter{Prefix: "relay attach", Container: container, MsgCh: msgCh}
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("attach relay: %w", err)
 	}
@@ -517,6 +684,10 @@ func PlainTextWritePrompt(w io.Writer, p Prompt, logW io.Writer) error {
 // isSignalExit reports whether err indicates the process was killed by a
 // signal (e.g. SIGKILL from container purge).
 func isSignalExit(err error) bool {
+	var sshErr *sshconn.ExitError
+	if errors.As(err, &sshErr) {
+		return sshErr.Signal != ""
+	}
 	var exitErr *exec.ExitError
 	if !errors.As(err, &exitErr) {
 		return false
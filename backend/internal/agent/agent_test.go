@@ -349,3 +349,47 @@ func TestReadMessages(t *testing.T) {
 		}
 	})
 }
+
+func TestSlogWriter(t *testing.T) {
+	ch := make(chan Message, 4)
+	w := &SlogWriter{Prefix: "relay attach", Container: "md-x", MsgCh: ch}
+	if _, err := io.WriteString(w, "first\n\n  sec"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "ond  \n"); err != nil {
+		t.Fatal(err)
+	}
+	close(ch)
+	var got []string
+	for m := range ch {
+		sm, ok := m.(*StderrMessage)
+		if !ok || sm.Source != "relay attach" {
+			t.Fatalf("got %#v", m)
+		}
+		got = append(got, sm.Line)
+	}
+	if strings.Join(got, "|") != "first|second" {
+		t.Errorf("lines = %q", got)
+	}
+}
+
+func TestMatchTool(t *testing.T) {
+	patterns := []string{"Edit", "mcp__*__write_*"}
+	for name, want := range map[string]bool{
+		"Edit":                   true,
+		"EditNotebook":           false,
+		"mcp__fs__write_file":    true,
+		"mcp__fs__read_file":     false,
+		"mcp__fs__write_file_v2": true,
+	} {
+		if got := MatchTool(patterns, name); got != want {
+			t.Errorf("MatchTool(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if err := ValidateToolPatterns(patterns); err != nil {
+		t.Error(err)
+	}
+	if err := ValidateToolPatterns([]string{"Edit", "fs_["}); err == nil {
+		t.Error("ValidateToolPatterns accepted a malformed pattern")
+	}
+}
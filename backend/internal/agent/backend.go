@@ -2,7 +2,9 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"path"
 )
 
 // Backend launches and communicates with a coding agent process.
@@ -41,11 +43,39 @@ type Backend interface {
 	// SupportsImages reports whether this backend accepts image content blocks.
 	SupportsImages() bool
 
+	// SupportsReadOnly reports whether this backend honors Options.ReadOnly.
+	SupportsReadOnly() bool
+
+	// IsMutatingTool reports whether a call to the tool name, as reported in
+	// ToolUseMessage.Name, may change files in the container.
+	IsMutatingTool(name string) bool
+
 	// ContextWindowLimit returns the API prompt token limit for the given model.
 	// The model parameter is the model name reported by the agent at runtime.
 	ContextWindowLimit(model string) int
 }
 
+// MatchTool reports whether the tool name matches one of patterns. Patterns
+// use path.Match syntax, e.g. "Edit" or "mcp__*__write_*".
+func MatchTool(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateToolPatterns returns an error if one of patterns is malformed.
+func ValidateToolPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("tool pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
 // Base provides default implementations for most Backend methods. Embed it in
 // backend-specific types to inherit the boilerplate. Each backend must provide
 // its own Start method.
@@ -53,6 +83,8 @@ type Base struct {
 	HarnessID     Harness
 	ModelList     []string
 	Images        bool
+	ReadOnly      bool     // Honors Options.ReadOnly.
+	MutatingTools []string // Patterns of the tools that may change files; see MatchTool.
 	ContextWindow int
 	Wire          WireFormat                      // Used by StartRelay and AttachRelay.
 	Parse         func([]byte) ([]Message, error) // Used by ParseMessage and ReadRelayOutput.
@@ -67,6 +99,12 @@ func (b *Base) Models() []string { return b.ModelList }
 // SupportsImages implements Backend.
 func (b *Base) SupportsImages() bool { return b.Images }
 
+// SupportsReadOnly implements Backend.
+func (b *Base) SupportsReadOnly() bool { return b.ReadOnly }
+
+// IsMutatingTool implements Backend.
+func (b *Base) IsMutatingTool(name string) bool { return MatchTool(b.MutatingTools, name) }
+
 // ContextWindowLimit implements Backend.
 func (b *Base) ContextWindowLimit(string) int { return b.ContextWindow }
 
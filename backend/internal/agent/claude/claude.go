@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"strings"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
 )
@@ -33,6 +34,8 @@ func New() *Backend {
 		HarnessID:     agent.Claude,
 		ModelList:     []string{"opus", "sonnet", "haiku"},
 		Images:        true,
+		ReadOnly:      true,
+		MutatingTools: []string{"Bash", "Edit", "MultiEdit", "Write", "NotebookEdit"},
 		ContextWindow: 180_000,
 		Parse:         b.parseMessage,
 	}
@@ -122,6 +125,13 @@ func (*Backend) WritePrompt(w io.Writer, p agent.Prompt, logW io.Writer) error {
 	return nil
 }
 
+// readOnlyTools are the tools allowed to read-only sessions.
+var readOnlyTools = []string{
+	"Read", "Grep", "Glob", "LS", "WebFetch", "WebSearch", "TodoWrite", "Task",
+	"Bash(ls:*)", "Bash(cat:*)", "Bash(head:*)", "Bash(tail:*)", "Bash(wc:*)", "Bash(find:*)", "Bash(grep:*)", "Bash(rg:*)", "Bash(tree:*)",
+	"Bash(git log:*)", "Bash(git show:*)", "Bash(git diff:*)", "Bash(git status:*)", "Bash(git ls-files:*)", "Bash(git grep:*)", "Bash(git blame:*)",
+}
+
 // buildArgs constructs the Claude Code CLI arguments.
 func buildArgs(opts *agent.Options) []string {
 	args := []string{
@@ -129,10 +139,18 @@ func buildArgs(opts *agent.Options) []string {
 		"--input-format", "stream-json",
 		"--output-format", "stream-json",
 		"--verbose",
-		"--dangerously-skip-permissions",
+	}
+	if opts.ReadOnly {
+		// Without --dangerously-skip-permissions, tools outside the allow list
+		// are denied since nobody can approve them in -p mode.
+		args = append(args, "--allowedTools", strings.Join(readOnlyTools, ","), "--disallowedTools", "Edit,MultiEdit,Write,NotebookEdit")
+	} else {
+		args = append(args, "--dangerously-skip-permissions")
+	}
+	args = append(args,
 		"--include-partial-messages",
 		"--plugin-dir", agent.WidgetPluginDir,
-	}
+	)
 	if opts.Model != "" {
 		args = append(args, "--model", opts.Model)
 	}
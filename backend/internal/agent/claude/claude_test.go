@@ -102,3 +102,14 @@ func TestWritePrompt(t *testing.T) {
 		}
 	})
 }
+
+func TestBuildArgs(t *testing.T) {
+	args := strings.Join(buildArgs(&agent.Options{Model: "opus"}), " ")
+	if !strings.Contains(args, "--dangerously-skip-permissions") || strings.Contains(args, "--allowedTools") {
+		t.Errorf("args = %s", args)
+	}
+	args = strings.Join(buildArgs(&agent.Options{ReadOnly: true}), " ")
+	if strings.Contains(args, "--dangerously-skip-permissions") || !strings.Contains(args, "--disallowedTools Edit,MultiEdit,Write,NotebookEdit") {
+		t.Errorf("read-only args = %s", args)
+	}
+}
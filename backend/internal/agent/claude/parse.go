@@ -6,8 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/jsonutil"
 )
 
 // parseEnvelope is a local alias for typeProbe used by ParseMessage.
@@ -113,6 +115,7 @@ func (wt *WidgetTracker) handleStreamEvent(w *streamEventWire) ([]agent.Message,
 //   - UserInputMessage     — user message without parent_tool_use_id
 //   - UsageMessage         — assistant message usage counters
 //   - ResultMessage        — result record
+//   - RateLimitMessage     — rate_limit_event record
 //   - DiffStatMessage      — caic_diff_stat injection
 //   - RawMessage           — unrecognised wire types (preserved verbatim)
 //
@@ -158,6 +161,16 @@ func ParseMessageWithTracker(line []byte, wt *WidgetTracker) ([]agent.Message, e
 		}}, nil
 	case "stream_event":
 		return parseStreamEvent(line, wt)
+	case "rate_limit_event":
+		var w rateLimitWire
+		if err := json.Unmarshal(line, &w); err != nil {
+			return nil, err
+		}
+		m := &agent.RateLimitMessage{Limited: w.RateLimitInfo.Status == "rejected"}
+		if w.RateLimitInfo.ResetsAt > 0 {
+			m.ResetsAt = time.Unix(w.RateLimitInfo.ResetsAt, 0).UTC()
+		}
+		return []agent.Message{m}, nil
 	case "caic_diff_stat":
 		var m agent.DiffStatMessage
 		if err := json.Unmarshal(line, &m); err != nil {
@@ -165,7 +178,8 @@ func ParseMessageWithTracker(line []byte, wt *WidgetTracker) ([]agent.Message, e
 		}
 		return []agent.Message{&m}, nil
 	default:
-		return []agent.Message{&agent.RawMessage{MessageType: env.Type, Raw: append([]byte(nil), line...)}}, nil
+		jsonutil.CountUnknownType("claude", env.Type)
+		return []agent.Message{&agent.RawMessage{MessageType: env.Type, Raw: append([]byte(nil), line...), Unknown: true}}, nil
 	}
 }
 
@@ -345,12 +359,13 @@ func parseUserMessage(raw json.RawMessage) []agent.Message {
 // toolResultFromBlock converts an inline tool_result content block to a ToolResultMessage.
 func toolResultFromBlock(b *userContentBlock) *agent.ToolResultMessage {
 	m := &agent.ToolResultMessage{ToolUseID: b.ToolUseID}
-	if b.IsError {
-		for _, c := range b.Content {
-			if c.Type == "text" && c.Text != "" {
+	for _, c := range b.Content {
+		if c.Type == "text" && c.Text != "" {
+			if b.IsError {
 				m.Error = c.Text
-				return m
 			}
+			m.Output = agent.TailOutput(c.Text)
+			return m
 		}
 	}
 	return m
@@ -364,12 +379,16 @@ func extractToolResult(toolUseID string, raw json.RawMessage) *agent.ToolResultM
 		return m
 	}
 	var msg toolResultWire
-	if json.Unmarshal(raw, &msg) == nil && msg.IsError {
-		for _, c := range msg.Content {
-			if c.Type == "text" && c.Text != "" {
+	if json.Unmarshal(raw, &msg) != nil {
+		return m
+	}
+	for _, c := range msg.Content {
+		if c.Type == "text" && c.Text != "" {
+			if msg.IsError {
 				m.Error = c.Text
-				return m
 			}
+			m.Output = agent.TailOutput(c.Text)
+			return m
 		}
 	}
 	return m
@@ -418,7 +437,8 @@ func parseStreamEvent(line []byte, wt *WidgetTracker) ([]agent.Message, error) {
 			Subtype:     "api_error",
 		}}, nil
 	default:
-		return []agent.Message{&agent.RawMessage{MessageType: "stream_event", Raw: append([]byte(nil), line...)}}, nil
+		jsonutil.CountUnknownType("claude", "stream_event("+w.Event.Type+")")
+		return []agent.Message{&agent.RawMessage{MessageType: "stream_event", Raw: append([]byte(nil), line...), Unknown: true}}, nil
 	}
 }
 
@@ -309,6 +309,23 @@ func TestParseMessage(t *testing.T) {
 			}
 		}
 	})
+	t.Run("RateLimitEvent", func(t *testing.T) {
+		line := `{"type":"rate_limit_event","rate_limit_info":{"status":"rejected","resetsAt":1767243600,"rateLimitType":"five_hour"},"session_id":"s1","uuid":"u1"}`
+		msgs, err := ParseMessage([]byte(line))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(msgs) != 1 {
+			t.Fatalf("got %d messages, want 1", len(msgs))
+		}
+		rl, ok := msgs[0].(*agent.RateLimitMessage)
+		if !ok {
+			t.Fatalf("got %T, want *agent.RateLimitMessage", msgs[0])
+		}
+		if !rl.Limited || rl.ResetsAt.Unix() != 1767243600 {
+			t.Errorf("got %+v", rl)
+		}
+	})
 	t.Run("SystemUsefulSubtypes", func(t *testing.T) {
 		for _, subtype := range []string{"compact_boundary", "context_cleared", "api_error"} {
 			line := `{"type":"system","subtype":"` + subtype + `","session_id":"s1","uuid":"u1"}`
@@ -48,7 +48,7 @@ var initWireKnown = jsonutil.KnownFields(initWire{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (w *initWire) UnmarshalJSON(data []byte) error {
 	type Alias initWire
-	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, initWireKnown, "initWire")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, initWireKnown, "claude", "initWire")
 }
 
 // ---------- system (non-init) ----------
@@ -81,7 +81,7 @@ var systemWireKnown = jsonutil.KnownFields(systemWire{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (w *systemWire) UnmarshalJSON(data []byte) error {
 	type Alias systemWire
-	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, systemWireKnown, "systemWire")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, systemWireKnown, "claude", "systemWire")
 }
 
 // ---------- assistant ----------
@@ -102,7 +102,7 @@ var assistantWireKnown = jsonutil.KnownFields(assistantWire{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (w *assistantWire) UnmarshalJSON(data []byte) error {
 	type Alias assistantWire
-	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, assistantWireKnown, "assistantWire")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, assistantWireKnown, "claude", "assistantWire")
 }
 
 // assistantMessageBody is the inner message object within an assistant record.
@@ -127,7 +127,7 @@ var assistantMessageBodyKnown = jsonutil.KnownFields(assistantMessageBody{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (w *assistantMessageBody) UnmarshalJSON(data []byte) error {
 	type Alias assistantMessageBody
-	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, assistantMessageBodyKnown, "assistantMessageBody")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, assistantMessageBodyKnown, "claude", "assistantMessageBody")
 }
 
 // contentBlockStartWire is the content_block field in a content_block_start streaming event.
@@ -167,7 +167,7 @@ var userWireKnown = jsonutil.KnownFields(userWire{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (w *userWire) UnmarshalJSON(data []byte) error {
 	type Alias userWire
-	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, userWireKnown, "userWire")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, userWireKnown, "claude", "userWire")
 }
 
 // ---------- result ----------
@@ -200,7 +200,33 @@ var resultWireKnown = jsonutil.KnownFields(resultWire{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (w *resultWire) UnmarshalJSON(data []byte) error {
 	type Alias resultWire
-	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, resultWireKnown, "resultWire")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, resultWireKnown, "claude", "resultWire")
+}
+
+// ---------- rate_limit_event ----------
+
+// rateLimitWire is the wire representation of a rate_limit_event record.
+type rateLimitWire struct {
+	Type          string        `json:"type"`
+	RateLimitInfo rateLimitInfo `json:"rate_limit_info"`
+	SessionID     string        `json:"session_id"`
+	UUID          string        `json:"uuid"`
+	jsonutil.Overflow
+}
+
+var rateLimitWireKnown = jsonutil.KnownFields(rateLimitWire{})
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (w *rateLimitWire) UnmarshalJSON(data []byte) error {
+	type Alias rateLimitWire
+	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, rateLimitWireKnown, "claude", "rateLimitWire")
+}
+
+// rateLimitInfo is the state of the subscription rate limit.
+type rateLimitInfo struct {
+	Status        string `json:"status"`   // "allowed", "allowed_warning" or "rejected".
+	ResetsAt      int64  `json:"resetsAt"` // Unix seconds.
+	RateLimitType string `json:"rateLimitType"`
 }
 
 // ---------- stream_event ----------
@@ -220,7 +246,7 @@ var streamEventWireKnown = jsonutil.KnownFields(streamEventWire{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (w *streamEventWire) UnmarshalJSON(data []byte) error {
 	type Alias streamEventWire
-	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, streamEventWireKnown, "streamEventWire")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(w), &w.Overflow, streamEventWireKnown, "claude", "streamEventWire")
 }
 
 // streamEventData is the nested event body inside a stream_event record.
@@ -10,18 +10,24 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"os/exec"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TODO: re-enable once widget plugin is fixed for codex
 // widgetMCPServerPath is the container path for the widget MCP server script.
 // var widgetMCPServerPath = agent.WidgetPluginDir + "/mcp_server.py"
 
+// mutatingTools are the commands and file changes, named by the parser, and
+// the MCP and dynamic tools whose names suggest they write files.
+var mutatingTools = []string{"Bash", "Edit", "Write", "write_*", "edit_*", "create_*", "move_*", "delete_*", "apply_patch"}
+
 // Backend implements agent.Backend for Codex CLI using the app-server
 // JSON-RPC 2.0 protocol.
 type Backend struct {
@@ -39,6 +45,8 @@ func New() *Backend {
 		HarnessID:     agent.Codex,
 		ModelList:     []string{"gpt-5.4"},
 		Images:        true,
+		ReadOnly:      true,
+		MutatingTools: mutatingTools,
 		ContextWindow: 200_000,
 		Parse:         ParseMessage,
 	}}
@@ -68,12 +76,12 @@ func (b *Backend) Start(ctx context.Context, opts *agent.Options, msgCh chan<- a
 
 	codexArgs := buildArgs(opts)
 
-	sshArgs := make([]string, 0, 8+len(codexArgs))
-	sshArgs = append(sshArgs, opts.Container, "python3", agent.RelayScriptPath, "serve-attach", "--dir", opts.Dir, "--no-log-stdin", "--")
+	sshArgs := make([]string, 0, 7+len(codexArgs))
+	sshArgs = append(sshArgs, "python3", agent.RelayScriptPath, "serve-attach", "--dir", opts.Dir, "--no-log-stdin", "--")
 	sshArgs = append(sshArgs, codexArgs...)
 
 	slog.Debug("relay", "msg", "launch", "ctr", opts.Container, "args", codexArgs)
-	cmd := exec.CommandContext(ctx, "ssh", sshArgs...) //nolint:gosec // args are not user-controlled.
+	cmd := sshconn.Command(ctx, opts.Container, sshArgs...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return nil, fmt.Errorf("stdin pipe: %w", err)
@@ -82,7 +90,7 @@ func (b *Backend) Start(ctx context.Context, opts *agent.Options, msgCh chan<- a
 	if err != nil {
 		return nil, fmt.Errorf("stdout pipe: %w", err)
 	}
-	cmd.Stderr = &agent.SlogWriter{Prefix: "relay serve-attach", Container: opts.Container}
+	cmd.Stderr = &agent.SlogWriter{Prefix: "relay serve-attach", Container: opts.Container, MsgCh: msgCh}
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("start relay: %w", err)
 	}
@@ -91,10 +99,12 @@ func (b *Backend) Start(ctx context.Context, opts *agent.Options, msgCh chan<- a
 	// without losing buffered bytes for the session's readMessages goroutine.
 	br := bufio.NewReaderSize(stdout, 1<<16)
 
-	wire, models, err := handshake(ctx, stdin, br, opts)
+	hsCtx, span := agent.Tracer.Start(ctx, "codex.handshake", trace.WithAttributes(attribute.String("caic.container", opts.Container)))
+	wire, models, err := handshake(hsCtx, stdin, br, opts)
+	agent.EndSpan(span, err)
 	if err != nil {
 		// Kill the process on handshake failure.
-		_ = cmd.Process.Kill()
+		_ = cmd.Kill()
 		_ = cmd.Wait()
 		return nil, fmt.Errorf("codex handshake: %w", err)
 	}
@@ -159,8 +169,23 @@ func (w *wireFormat) WritePrompt(wr io.Writer, p agent.Prompt, logW io.Writer) e
 		Method:  "turn/start",
 		Params:  turnStartParams{ThreadID: w.threadID, Input: input},
 	}
-	// Don't log to logW — stdin is not logged with --no-log-stdin.
-	return writeJSON(wr, req)
+	// Don't log the request — stdin is not logged with --no-log-stdin. Echo
+	// the user's message as a caic_user_input record instead, which the relay
+	// logs but doesn't forward to codex.
+	if err := writeJSON(wr, req); err != nil {
+		return err
+	}
+	rec := userInputRecord{Type: "caic_user_input", Text: p.Text}
+	for _, img := range p.Images {
+		rec.Images = append(rec.Images, userInputImage{MediaType: img.MediaType, Data: img.Data})
+	}
+	if err := writeJSON(wr, rec); err != nil {
+		return err
+	}
+	if logW != nil {
+		_ = writeJSON(logW, rec)
+	}
+	return nil
 }
 
 // ParseMessage wraps the package-level ParseMessage with two interceptions:
@@ -317,7 +342,7 @@ func handshake(ctx context.Context, stdin io.Writer, stdout *bufio.Reader, opts
 			JSONRPC: "2.0",
 			ID:      w.nextID.Add(1),
 			Method:  "thread/start",
-			Params:  threadStartParams{Model: opts.Model},
+			Params:  newThreadStartParams(opts),
 		}
 	}
 	if err := writeJSON(stdin, threadReq); err != nil {
@@ -402,7 +427,7 @@ func readJSONRPCResponse(ctx context.Context, r *bufio.Reader) (*JSONRPCMessage,
 // deployWidgetMCP writes the widget MCP server script to the container so
 // that codex can launch it as a stdio MCP server.
 // func deployWidgetMCP(ctx context.Context, container string) error {
-// 	cmd := exec.CommandContext(ctx, "ssh", container, //nolint:gosec // container is not user-controlled
+// 	cmd := sshconn.Command(ctx, container,
 // 		"mkdir -p "+agent.WidgetPluginDir+" && cat > "+widgetMCPServerPath)
 // 	cmd.Stdin = bytes.NewReader(agent.WidgetMCPServerScript)
 // 	if out, err := cmd.CombinedOutput(); err != nil {
@@ -411,6 +436,15 @@ func readJSONRPCResponse(ctx context.Context, r *bufio.Reader) (*JSONRPCMessage,
 // 	return nil
 // }
 
+// newThreadStartParams returns the thread/start params for opts.
+func newThreadStartParams(opts *agent.Options) threadStartParams {
+	p := threadStartParams{Model: opts.Model}
+	if opts.ReadOnly {
+		p.Sandbox = "read-only"
+	}
+	return p
+}
+
 // buildArgs constructs the Codex CLI app-server arguments.
 func buildArgs(_ *agent.Options) []string {
 	// TODO: re-enable widget MCP plugin once it's fixed for codex
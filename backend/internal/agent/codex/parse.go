@@ -6,13 +6,15 @@ import (
 	"strings"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/jsonutil"
 )
 
 // ParseMessage decodes a single line from the codex app-server output into one
 // or more typed agent.Messages.
 //
 // The line is one of:
-//   - A caic-injected JSON object with a "type" field (e.g. caic_diff_stat).
+//   - A caic-injected JSON object with a "type" field (e.g. caic_diff_stat,
+//     caic_user_input).
 //   - A JSON-RPC 2.0 notification (has "method", no "id").
 //   - A JSON-RPC 2.0 response (has "id").
 //
@@ -28,6 +30,7 @@ import (
 //   - SystemMessage        — thread/status/changed, model/rerouted, item/completed contextCompaction
 //   - ResultMessage        — turn/completed, error notification
 //   - DiffStatMessage      — caic_diff_stat injection
+//   - UserInputMessage     — caic_user_input echo of a prompt
 //   - RawMessage           — unrecognised wire types (preserved verbatim)
 func ParseMessage(line []byte) ([]agent.Message, error) {
 	// Fast probe: check for "type" (caic-injected) vs "method"/"id" (JSON-RPC).
@@ -45,8 +48,19 @@ func ParseMessage(line []byte) ([]agent.Message, error) {
 				return nil, err
 			}
 			return []agent.Message{&m}, nil
+		case "caic_user_input":
+			var r userInputRecord
+			if err := json.Unmarshal(line, &r); err != nil {
+				return nil, err
+			}
+			m := &agent.UserInputMessage{Text: r.Text}
+			for _, img := range r.Images {
+				m.Images = append(m.Images, agent.ImageData{MediaType: img.MediaType, Data: img.Data})
+			}
+			return []agent.Message{m}, nil
 		default:
-			return []agent.Message{&agent.RawMessage{MessageType: probe.Type, Raw: append([]byte(nil), line...)}}, nil
+			jsonutil.CountUnknownType("codex", probe.Type)
+			return []agent.Message{&agent.RawMessage{MessageType: probe.Type, Raw: append([]byte(nil), line...), Unknown: true}}, nil
 		}
 	}
 
@@ -179,7 +193,8 @@ func ParseMessage(line []byte) ([]agent.Message, error) {
 		}}, nil
 
 	default:
-		return []agent.Message{&agent.RawMessage{MessageType: msg.Method, Raw: append([]byte(nil), line...)}}, nil
+		jsonutil.CountUnknownType("codex", msg.Method)
+		return []agent.Message{&agent.RawMessage{MessageType: msg.Method, Raw: append([]byte(nil), line...), Unknown: true}}, nil
 	}
 }
 
@@ -260,7 +275,8 @@ func parseItemStarted(msg *JSONRPCMessage) ([]agent.Message, error) {
 		}}, nil
 
 	default:
-		return []agent.Message{&agent.RawMessage{MessageType: msg.Method, Raw: append(msg.Params[:0:0], msg.Params...)}}, nil
+		jsonutil.CountUnknownType("codex", msg.Method+"("+h.Type+")")
+		return []agent.Message{&agent.RawMessage{MessageType: msg.Method, Raw: append(msg.Params[:0:0], msg.Params...), Unknown: true}}, nil
 	}
 }
 
@@ -298,7 +314,18 @@ func parseItemCompleted(msg *JSONRPCMessage) ([]agent.Message, error) {
 		return []agent.Message{&agent.TextMessage{Text: item.Text}}, nil
 
 	case ItemTypeCommandExecution:
-		return []agent.Message{&agent.ToolResultMessage{ToolUseID: h.ID}}, nil
+		var item CommandExecutionItem
+		if err := json.Unmarshal(p.Item, &item); err != nil {
+			return nil, fmt.Errorf("item/completed commandExecution: %w", err)
+		}
+		m := &agent.ToolResultMessage{ToolUseID: h.ID, ExitCode: item.ExitCode}
+		if item.AggregatedOutput != nil {
+			m.Output = agent.TailOutput(*item.AggregatedOutput)
+		}
+		if item.DurationMs != nil {
+			m.DurationMs = *item.DurationMs
+		}
+		return []agent.Message{m}, nil
 
 	case ItemTypeFileChange:
 		var item FileChangeItem
@@ -358,7 +385,8 @@ func parseItemCompleted(msg *JSONRPCMessage) ([]agent.Message, error) {
 		}, nil
 
 	default:
-		return []agent.Message{&agent.RawMessage{MessageType: msg.Method, Raw: append(msg.Params[:0:0], msg.Params...)}}, nil
+		jsonutil.CountUnknownType("codex", msg.Method+"("+h.Type+")")
+		return []agent.Message{&agent.RawMessage{MessageType: msg.Method, Raw: append(msg.Params[:0:0], msg.Params...), Unknown: true}}, nil
 	}
 }
 
@@ -3,6 +3,7 @@ package codex
 import (
 	"bytes"
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
@@ -125,6 +126,9 @@ func TestParseMessage(t *testing.T) {
 		if tr.ToolUseID != "item_1" {
 			t.Errorf("ToolUseID = %q", tr.ToolUseID)
 		}
+		if tr.ExitCode == nil || *tr.ExitCode != 0 || tr.Output != "docs\nsrc\n" {
+			t.Errorf("ExitCode = %v, Output = %q", tr.ExitCode, tr.Output)
+		}
 	})
 	t.Run("ItemCompletedAgentMessage", func(t *testing.T) {
 		const input = `{"jsonrpc":"2.0","method":"item/completed","params":{"item":{"id":"item_3","type":"agentMessage","text":"Done.","status":"completed"},"threadId":"t1","turnId":"turn_1"}}`
@@ -666,6 +670,14 @@ func TestBuildArgs(t *testing.T) {
 			t.Errorf("args[:2] = %v, want [codex app-server ...]", args)
 		}
 	})
+	t.Run("ReadOnly", func(t *testing.T) {
+		if p := newThreadStartParams(&agent.Options{Model: "m"}); p.Sandbox != "" {
+			t.Errorf("sandbox = %q", p.Sandbox)
+		}
+		if p := newThreadStartParams(&agent.Options{ReadOnly: true}); p.Sandbox != "read-only" {
+			t.Errorf("sandbox = %q, want read-only", p.Sandbox)
+		}
+	})
 	t.Run("WidgetMCPConfig", func(t *testing.T) {
 		// Widget MCP is disabled for codex; buildArgs should return only
 		// the base command without any -c flags.
@@ -687,7 +699,7 @@ func TestWireFormat(t *testing.T) {
 			t.Fatal(err)
 		}
 		var req map[string]any
-		if err := json.Unmarshal(buf.Bytes(), &req); err != nil {
+		if err := json.NewDecoder(&buf).Decode(&req); err != nil {
 			t.Fatal(err)
 		}
 		if req["method"] != "turn/start" {
@@ -715,6 +727,33 @@ func TestWireFormat(t *testing.T) {
 			t.Errorf("input[0].text = %v", elem["text"])
 		}
 	})
+	t.Run("WritePromptEchoesUserInput", func(t *testing.T) {
+		w := &wireFormat{threadID: "t1"}
+		var buf, logBuf bytes.Buffer
+		p := agent.Prompt{Text: "look", Images: []agent.ImageData{{MediaType: "image/png", Data: "AAAA"}}}
+		if err := w.WritePrompt(&buf, p, &logBuf); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("got %d lines, want turn/start then caic_user_input: %q", len(lines), buf.String())
+		}
+		const want = `{"type":"caic_user_input","text":"look","images":[{"media_type":"image/png","data":"AAAA"}]}`
+		if lines[1] != want {
+			t.Errorf("stdin record = %s", lines[1])
+		}
+		if logBuf.String() != want+"\n" {
+			t.Errorf("log = %q, want only the caic_user_input record", logBuf.String())
+		}
+		msgs, err := ParseMessage([]byte(lines[1]))
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("ParseMessage = %v, %v", msgs, err)
+		}
+		u, ok := msgs[0].(*agent.UserInputMessage)
+		if !ok || u.Text != "look" || len(u.Images) != 1 || u.Images[0].Data != "AAAA" {
+			t.Errorf("got %#v", msgs[0])
+		}
+	})
 	t.Run("WritePromptNoThreadID", func(t *testing.T) {
 		w := &wireFormat{}
 		var buf bytes.Buffer
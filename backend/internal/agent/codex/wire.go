@@ -84,6 +84,20 @@ type messageProbe struct {
 	ID     *json.RawMessage `json:"id,omitzero"`
 }
 
+// userInputRecord is the caic_user_input line caic writes to the relay and
+// the log after each turn/start. The relay logs stdin with --no-log-stdin
+// only for caic records, so it is how replays recover the user's messages.
+type userInputRecord struct {
+	Type   string           `json:"type"`
+	Text   string           `json:"text,omitzero"`
+	Images []userInputImage `json:"images,omitzero"`
+}
+
+type userInputImage struct {
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"` // base64
+}
+
 // methodProbe extracts the method field from a JSON-RPC message.
 type methodProbe struct {
 	Method string `json:"method,omitzero"`
@@ -114,7 +128,7 @@ var threadStartedParamsKnown = jsonutil.KnownFields(ThreadStartedParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ThreadStartedParams) UnmarshalJSON(data []byte) error {
 	type Alias ThreadStartedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, threadStartedParamsKnown, "ThreadStartedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, threadStartedParamsKnown, "codex", "ThreadStartedParams")
 }
 
 // ThreadInfo describes a thread in thread/started params.
@@ -150,7 +164,7 @@ var threadInfoKnown = jsonutil.KnownFields(ThreadInfo{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (t *ThreadInfo) UnmarshalJSON(data []byte) error {
 	type Alias ThreadInfo
-	return jsonutil.UnmarshalRecord(data, (*Alias)(t), &t.Overflow, threadInfoKnown, "ThreadInfo")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(t), &t.Overflow, threadInfoKnown, "codex", "ThreadInfo")
 }
 
 // ---------- Turn lifecycle ----------
@@ -167,7 +181,7 @@ var turnStartedParamsKnown = jsonutil.KnownFields(TurnStartedParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *TurnStartedParams) UnmarshalJSON(data []byte) error {
 	type Alias TurnStartedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, turnStartedParamsKnown, "TurnStartedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, turnStartedParamsKnown, "codex", "TurnStartedParams")
 }
 
 // TurnCompletedParams holds the params for turn/completed notifications.
@@ -182,7 +196,7 @@ var turnCompletedParamsKnown = jsonutil.KnownFields(TurnCompletedParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *TurnCompletedParams) UnmarshalJSON(data []byte) error {
 	type Alias TurnCompletedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, turnCompletedParamsKnown, "TurnCompletedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, turnCompletedParamsKnown, "codex", "TurnCompletedParams")
 }
 
 // TurnInfo describes a turn in turn/started and turn/completed params.
@@ -199,7 +213,7 @@ var turnInfoKnown = jsonutil.KnownFields(TurnInfo{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (t *TurnInfo) UnmarshalJSON(data []byte) error {
 	type Alias TurnInfo
-	return jsonutil.UnmarshalRecord(data, (*Alias)(t), &t.Overflow, turnInfoKnown, "TurnInfo")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(t), &t.Overflow, turnInfoKnown, "codex", "TurnInfo")
 }
 
 // TurnError describes a turn failure.
@@ -215,7 +229,7 @@ var turnErrorKnown = jsonutil.KnownFields(TurnError{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (e *TurnError) UnmarshalJSON(data []byte) error {
 	type Alias TurnError
-	return jsonutil.UnmarshalRecord(data, (*Alias)(e), &e.Overflow, turnErrorKnown, "TurnError")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(e), &e.Overflow, turnErrorKnown, "codex", "TurnError")
 }
 
 // ---------- Item envelope ----------
@@ -234,7 +248,7 @@ var itemParamsKnown = jsonutil.KnownFields(ItemParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ItemParams) UnmarshalJSON(data []byte) error {
 	type Alias ItemParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, itemParamsKnown, "ItemParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, itemParamsKnown, "codex", "ItemParams")
 }
 
 // ItemHeader extracts the discriminant fields from a raw item for dispatch.
@@ -257,7 +271,7 @@ var itemDeltaParamsKnown = jsonutil.KnownFields(ItemDeltaParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ItemDeltaParams) UnmarshalJSON(data []byte) error {
 	type Alias ItemDeltaParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, itemDeltaParamsKnown, "ItemDeltaParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, itemDeltaParamsKnown, "codex", "ItemDeltaParams")
 }
 
 // ---------- Per-item-type structs ----------
@@ -277,7 +291,7 @@ var agentMessageItemKnown = jsonutil.KnownFields(AgentMessageItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *AgentMessageItem) UnmarshalJSON(data []byte) error {
 	type Alias AgentMessageItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, agentMessageItemKnown, "AgentMessageItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, agentMessageItemKnown, "codex", "AgentMessageItem")
 }
 
 // PlanItem is an agent plan item.
@@ -294,7 +308,7 @@ var planItemKnown = jsonutil.KnownFields(PlanItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *PlanItem) UnmarshalJSON(data []byte) error {
 	type Alias PlanItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, planItemKnown, "PlanItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, planItemKnown, "codex", "PlanItem")
 }
 
 // ReasoningItem is an agent reasoning/thinking item.
@@ -312,7 +326,7 @@ var reasoningItemKnown = jsonutil.KnownFields(ReasoningItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *ReasoningItem) UnmarshalJSON(data []byte) error {
 	type Alias ReasoningItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, reasoningItemKnown, "ReasoningItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, reasoningItemKnown, "codex", "ReasoningItem")
 }
 
 // CommandExecutionItem is a shell command execution item.
@@ -335,7 +349,7 @@ var commandExecutionItemKnown = jsonutil.KnownFields(CommandExecutionItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *CommandExecutionItem) UnmarshalJSON(data []byte) error {
 	type Alias CommandExecutionItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, commandExecutionItemKnown, "CommandExecutionItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, commandExecutionItemKnown, "codex", "CommandExecutionItem")
 }
 
 // FileChangeItem is a file creation/modification/deletion item.
@@ -352,7 +366,7 @@ var fileChangeItemKnown = jsonutil.KnownFields(FileChangeItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *FileChangeItem) UnmarshalJSON(data []byte) error {
 	type Alias FileChangeItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, fileChangeItemKnown, "FileChangeItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, fileChangeItemKnown, "codex", "FileChangeItem")
 }
 
 // McpToolCallItem is an MCP tool call item.
@@ -374,7 +388,7 @@ var mcpToolCallItemKnown = jsonutil.KnownFields(McpToolCallItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *McpToolCallItem) UnmarshalJSON(data []byte) error {
 	type Alias McpToolCallItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, mcpToolCallItemKnown, "McpToolCallItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, mcpToolCallItemKnown, "codex", "McpToolCallItem")
 }
 
 // DynamicToolCallItem is a dynamically registered tool call item.
@@ -395,7 +409,7 @@ var dynamicToolCallItemKnown = jsonutil.KnownFields(DynamicToolCallItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *DynamicToolCallItem) UnmarshalJSON(data []byte) error {
 	type Alias DynamicToolCallItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, dynamicToolCallItemKnown, "DynamicToolCallItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, dynamicToolCallItemKnown, "codex", "DynamicToolCallItem")
 }
 
 // CollabAgentToolCallItem is a collaborative multi-agent tool call item.
@@ -416,7 +430,7 @@ var collabAgentToolCallItemKnown = jsonutil.KnownFields(CollabAgentToolCallItem{
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *CollabAgentToolCallItem) UnmarshalJSON(data []byte) error {
 	type Alias CollabAgentToolCallItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, collabAgentToolCallItemKnown, "CollabAgentToolCallItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, collabAgentToolCallItemKnown, "codex", "CollabAgentToolCallItem")
 }
 
 // WebSearchAction is the action object within a webSearch item.
@@ -432,7 +446,7 @@ var webSearchActionKnown = jsonutil.KnownFields(WebSearchAction{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (a *WebSearchAction) UnmarshalJSON(data []byte) error {
 	type Alias WebSearchAction
-	return jsonutil.UnmarshalRecord(data, (*Alias)(a), &a.Overflow, webSearchActionKnown, "WebSearchAction")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(a), &a.Overflow, webSearchActionKnown, "codex", "WebSearchAction")
 }
 
 // WebSearchItem is a web search item.
@@ -450,7 +464,7 @@ var webSearchItemKnown = jsonutil.KnownFields(WebSearchItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *WebSearchItem) UnmarshalJSON(data []byte) error {
 	type Alias WebSearchItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, webSearchItemKnown, "WebSearchItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, webSearchItemKnown, "codex", "WebSearchItem")
 }
 
 // ImageViewItem is an image viewing item.
@@ -467,7 +481,7 @@ var imageViewItemKnown = jsonutil.KnownFields(ImageViewItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *ImageViewItem) UnmarshalJSON(data []byte) error {
 	type Alias ImageViewItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, imageViewItemKnown, "ImageViewItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, imageViewItemKnown, "codex", "ImageViewItem")
 }
 
 // EnteredReviewModeItem signals the agent entered review mode.
@@ -483,7 +497,7 @@ var enteredReviewModeItemKnown = jsonutil.KnownFields(EnteredReviewModeItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *EnteredReviewModeItem) UnmarshalJSON(data []byte) error {
 	type Alias EnteredReviewModeItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, enteredReviewModeItemKnown, "EnteredReviewModeItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, enteredReviewModeItemKnown, "codex", "EnteredReviewModeItem")
 }
 
 // ExitedReviewModeItem signals the agent exited review mode.
@@ -499,7 +513,7 @@ var exitedReviewModeItemKnown = jsonutil.KnownFields(ExitedReviewModeItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *ExitedReviewModeItem) UnmarshalJSON(data []byte) error {
 	type Alias ExitedReviewModeItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, exitedReviewModeItemKnown, "ExitedReviewModeItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, exitedReviewModeItemKnown, "codex", "ExitedReviewModeItem")
 }
 
 // ContextCompactionItem signals a context window compaction.
@@ -514,7 +528,7 @@ var contextCompactionItemKnown = jsonutil.KnownFields(ContextCompactionItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *ContextCompactionItem) UnmarshalJSON(data []byte) error {
 	type Alias ContextCompactionItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, contextCompactionItemKnown, "ContextCompactionItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, contextCompactionItemKnown, "codex", "ContextCompactionItem")
 }
 
 // UserMessageItem is a user-submitted message item.
@@ -531,7 +545,7 @@ var userMessageItemKnown = jsonutil.KnownFields(UserMessageItem{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (item *UserMessageItem) UnmarshalJSON(data []byte) error {
 	type Alias UserMessageItem
-	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, userMessageItemKnown, "UserMessageItem")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(item), &item.Overflow, userMessageItemKnown, "codex", "UserMessageItem")
 }
 
 // ---------- Item field types ----------
@@ -575,7 +589,7 @@ var tokenUsageUpdatedParamsKnown = jsonutil.KnownFields(TokenUsageUpdatedParams{
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *TokenUsageUpdatedParams) UnmarshalJSON(data []byte) error {
 	type Alias TokenUsageUpdatedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, tokenUsageUpdatedParamsKnown, "TokenUsageUpdatedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, tokenUsageUpdatedParamsKnown, "codex", "TokenUsageUpdatedParams")
 }
 
 // ThreadTokenUsage holds cumulative and per-turn token usage for a thread.
@@ -610,7 +624,7 @@ var commandOutputDeltaParamsKnown = jsonutil.KnownFields(CommandOutputDeltaParam
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *CommandOutputDeltaParams) UnmarshalJSON(data []byte) error {
 	type Alias CommandOutputDeltaParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, commandOutputDeltaParamsKnown, "CommandOutputDeltaParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, commandOutputDeltaParamsKnown, "codex", "CommandOutputDeltaParams")
 }
 
 // TerminalInteractionParams holds params for item/commandExecution/terminalInteraction.
@@ -628,7 +642,7 @@ var terminalInteractionParamsKnown = jsonutil.KnownFields(TerminalInteractionPar
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *TerminalInteractionParams) UnmarshalJSON(data []byte) error {
 	type Alias TerminalInteractionParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, terminalInteractionParamsKnown, "TerminalInteractionParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, terminalInteractionParamsKnown, "codex", "TerminalInteractionParams")
 }
 
 // FileChangeOutputDeltaParams holds params for item/fileChange/outputDelta.
@@ -645,7 +659,7 @@ var fileChangeOutputDeltaParamsKnown = jsonutil.KnownFields(FileChangeOutputDelt
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *FileChangeOutputDeltaParams) UnmarshalJSON(data []byte) error {
 	type Alias FileChangeOutputDeltaParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, fileChangeOutputDeltaParamsKnown, "FileChangeOutputDeltaParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, fileChangeOutputDeltaParamsKnown, "codex", "FileChangeOutputDeltaParams")
 }
 
 // ReasoningSummaryTextDeltaParams holds params for item/reasoning/summaryTextDelta.
@@ -663,7 +677,7 @@ var reasoningSummaryTextDeltaParamsKnown = jsonutil.KnownFields(ReasoningSummary
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ReasoningSummaryTextDeltaParams) UnmarshalJSON(data []byte) error {
 	type Alias ReasoningSummaryTextDeltaParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, reasoningSummaryTextDeltaParamsKnown, "ReasoningSummaryTextDeltaParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, reasoningSummaryTextDeltaParamsKnown, "codex", "ReasoningSummaryTextDeltaParams")
 }
 
 // ReasoningSummaryPartAddedParams holds params for item/reasoning/summaryPartAdded.
@@ -680,7 +694,7 @@ var reasoningSummaryPartAddedParamsKnown = jsonutil.KnownFields(ReasoningSummary
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ReasoningSummaryPartAddedParams) UnmarshalJSON(data []byte) error {
 	type Alias ReasoningSummaryPartAddedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, reasoningSummaryPartAddedParamsKnown, "ReasoningSummaryPartAddedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, reasoningSummaryPartAddedParamsKnown, "codex", "ReasoningSummaryPartAddedParams")
 }
 
 // ReasoningTextDeltaParams holds params for item/reasoning/textDelta.
@@ -698,7 +712,7 @@ var reasoningTextDeltaParamsKnown = jsonutil.KnownFields(ReasoningTextDeltaParam
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ReasoningTextDeltaParams) UnmarshalJSON(data []byte) error {
 	type Alias ReasoningTextDeltaParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, reasoningTextDeltaParamsKnown, "ReasoningTextDeltaParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, reasoningTextDeltaParamsKnown, "codex", "ReasoningTextDeltaParams")
 }
 
 // PlanDeltaParams holds params for item/plan/delta.
@@ -715,7 +729,7 @@ var planDeltaParamsKnown = jsonutil.KnownFields(PlanDeltaParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *PlanDeltaParams) UnmarshalJSON(data []byte) error {
 	type Alias PlanDeltaParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, planDeltaParamsKnown, "PlanDeltaParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, planDeltaParamsKnown, "codex", "PlanDeltaParams")
 }
 
 // McpToolCallProgressParams holds params for item/mcpToolCall/progress.
@@ -732,7 +746,7 @@ var mcpToolCallProgressParamsKnown = jsonutil.KnownFields(McpToolCallProgressPar
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *McpToolCallProgressParams) UnmarshalJSON(data []byte) error {
 	type Alias McpToolCallProgressParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, mcpToolCallProgressParamsKnown, "McpToolCallProgressParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, mcpToolCallProgressParamsKnown, "codex", "McpToolCallProgressParams")
 }
 
 // ---------- Other notification params ----------
@@ -750,7 +764,7 @@ var turnDiffUpdatedParamsKnown = jsonutil.KnownFields(TurnDiffUpdatedParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *TurnDiffUpdatedParams) UnmarshalJSON(data []byte) error {
 	type Alias TurnDiffUpdatedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, turnDiffUpdatedParamsKnown, "TurnDiffUpdatedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, turnDiffUpdatedParamsKnown, "codex", "TurnDiffUpdatedParams")
 }
 
 // TurnPlanUpdatedParams holds params for turn/plan/updated.
@@ -767,7 +781,7 @@ var turnPlanUpdatedParamsKnown = jsonutil.KnownFields(TurnPlanUpdatedParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *TurnPlanUpdatedParams) UnmarshalJSON(data []byte) error {
 	type Alias TurnPlanUpdatedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, turnPlanUpdatedParamsKnown, "TurnPlanUpdatedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, turnPlanUpdatedParamsKnown, "codex", "TurnPlanUpdatedParams")
 }
 
 // ThreadStatusChangedParams holds params for thread/status/changed.
@@ -782,7 +796,7 @@ var threadStatusChangedParamsKnown = jsonutil.KnownFields(ThreadStatusChangedPar
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ThreadStatusChangedParams) UnmarshalJSON(data []byte) error {
 	type Alias ThreadStatusChangedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, threadStatusChangedParamsKnown, "ThreadStatusChangedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, threadStatusChangedParamsKnown, "codex", "ThreadStatusChangedParams")
 }
 
 // ThreadNameUpdatedParams holds params for thread/name/updated.
@@ -797,7 +811,7 @@ var threadNameUpdatedParamsKnown = jsonutil.KnownFields(ThreadNameUpdatedParams{
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ThreadNameUpdatedParams) UnmarshalJSON(data []byte) error {
 	type Alias ThreadNameUpdatedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, threadNameUpdatedParamsKnown, "ThreadNameUpdatedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, threadNameUpdatedParamsKnown, "codex", "ThreadNameUpdatedParams")
 }
 
 // ModelReroutedParams holds params for model/rerouted.
@@ -815,7 +829,7 @@ var modelReroutedParamsKnown = jsonutil.KnownFields(ModelReroutedParams{})
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ModelReroutedParams) UnmarshalJSON(data []byte) error {
 	type Alias ModelReroutedParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, modelReroutedParamsKnown, "ModelReroutedParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, modelReroutedParamsKnown, "codex", "ModelReroutedParams")
 }
 
 // ---------- Outbound request types ----------
@@ -852,7 +866,8 @@ type capabilities struct {
 
 // threadStartParams holds the params for thread/start.
 type threadStartParams struct {
-	Model string `json:"model,omitzero"`
+	Model   string `json:"model,omitzero"`
+	Sandbox string `json:"sandbox,omitzero"` // "read-only", "workspace-write" or "danger-full-access".
 }
 
 // threadResumeParams holds the params for thread/resume.
@@ -902,5 +917,5 @@ var errorNotificationParamsKnown = jsonutil.KnownFields(ErrorNotificationParams{
 // UnmarshalJSON implements json.Unmarshaler.
 func (p *ErrorNotificationParams) UnmarshalJSON(data []byte) error {
 	type Alias ErrorNotificationParams
-	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, errorNotificationParamsKnown, "ErrorNotificationParams")
+	return jsonutil.UnmarshalRecord(data, (*Alias)(p), &p.Overflow, errorNotificationParamsKnown, "codex", "ErrorNotificationParams")
 }
@@ -0,0 +1,252 @@
+// Package external implements agent.Backend for harnesses provided as a
+// separate executable speaking caic's stdio protocol, so that agents can be
+// added without changing caic. See docs/EXTERNAL_BACKENDS.md.
+//
+// The executable runs inside the container under the relay, like the
+// built-in harnesses. caic writes a start record then one prompt record per
+// user message to its stdin; the executable writes message records to its
+// stdout. All records are JSON objects on one line, discriminated by "type".
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/jsonutil"
+)
+
+// ProtocolVersion is sent in the start record. It is incremented on
+// incompatible protocol changes.
+const ProtocolVersion = 1
+
+// Config registers an external harness.
+type Config struct {
+	Name          agent.Harness
+	Command       []string // Executable and arguments, run in the container's working directory.
+	Models        []string // Model names offered in the UI; the first is the default.
+	Images        bool     // Whether prompt records may carry images.
+	ContextWindow int      // Prompt token limit, used for the context gauge.
+	// MutatingTools are the patterns of the tools that may change files; see
+	// agent.MatchTool. Defaults to DefaultMutatingTools.
+	MutatingTools []string
+}
+
+// DefaultMutatingTools are the mutating tools of an external harness that
+// doesn't declare them, named like the built-in harnesses' tools.
+var DefaultMutatingTools = []string{"Bash", "Edit", "MultiEdit", "Write", "NotebookEdit"}
+
+// Backend implements agent.Backend for an external harness.
+type Backend struct {
+	agent.Base
+	command []string
+}
+
+var _ agent.Backend = (*Backend)(nil)
+
+// builtin lists the harnesses an external one can't replace.
+var builtin = []agent.Harness{agent.Claude, agent.Codex, agent.Gemini, agent.Kilo}
+
+var registry struct {
+	mu sync.RWMutex
+	m  map[agent.Harness]*Backend
+}
+
+// Register validates c and returns its backend. The harness parser is then
+// available through Lookup, e.g. to load the logs of its tasks.
+func Register(c *Config) (*Backend, error) {
+	if c.Name == "" {
+		return nil, errors.New("external harness: name is required")
+	}
+	if slices.Contains(builtin, c.Name) || c.Name == "caic" {
+		return nil, fmt.Errorf("external harness %q: name is reserved", c.Name)
+	}
+	if len(c.Command) == 0 {
+		return nil, fmt.Errorf("external harness %q: command is required", c.Name)
+	}
+	mutating := c.MutatingTools
+	if mutating == nil {
+		mutating = DefaultMutatingTools
+	}
+	if err := agent.ValidateToolPatterns(mutating); err != nil {
+		return nil, fmt.Errorf("external harness %q: %w", c.Name, err)
+	}
+	b := &Backend{command: slices.Clone(c.Command)}
+	b.Base = agent.Base{
+		HarnessID:     c.Name,
+		ModelList:     c.Models,
+		Images:        c.Images,
+		MutatingTools: slices.Clone(mutating),
+		ContextWindow: c.ContextWindow,
+		Wire:          &wire{b: b, started: true},
+		Parse:         b.parse,
+	}
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.m == nil {
+		registry.m = map[agent.Harness]*Backend{}
+	}
+	registry.m[c.Name] = b
+	return b, nil
+}
+
+// Lookup returns the registered external harness h, or nil.
+func Lookup(h agent.Harness) *Backend {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return registry.m[h]
+}
+
+// Start launches the executable via the relay daemon and sends the start
+// record, followed by the initial prompt if any.
+func (b *Backend) Start(ctx context.Context, opts *agent.Options, msgCh chan<- agent.Message, logW io.Writer) (*agent.Session, error) {
+	w := &wire{b: b, start: startRecord{
+		Type:            "start",
+		Version:         ProtocolVersion,
+		Model:           opts.Model,
+		ResumeSessionID: opts.ResumeSessionID,
+	}}
+	s, err := agent.StartRelay(ctx, opts, b.command, msgCh, logW, w)
+	if err != nil {
+		return nil, err
+	}
+	if opts.InitialPrompt.Text == "" && len(opts.InitialPrompt.Images) == 0 {
+		// Resuming without a prompt; only send the start record.
+		if err := s.Send(agent.Prompt{}); err != nil {
+			s.Close()
+			return nil, fmt.Errorf("write start: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// startRecord is the first record written to the executable's stdin.
+type startRecord struct {
+	Type            string `json:"type"`
+	Version         int    `json:"version"`
+	Model           string `json:"model,omitempty"`
+	ResumeSessionID string `json:"resume_session_id,omitempty"`
+}
+
+// promptRecord carries a user message to the executable.
+type promptRecord struct {
+	Type   string        `json:"type"`
+	Text   string        `json:"text"`
+	Images []imageRecord `json:"images,omitempty"`
+}
+
+type imageRecord struct {
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"` // base64
+}
+
+// wire writes the start record before the first prompt of a session. A wire
+// for a session reattached through the relay is created started.
+type wire struct {
+	b       *Backend
+	mu      sync.Mutex
+	start   startRecord
+	started bool
+}
+
+// WritePrompt implements agent.WireFormat. An empty prompt writes nothing
+// past the start record.
+func (w *wire) WritePrompt(dst io.Writer, p agent.Prompt, logW io.Writer) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.started {
+		if err := writeRecord(dst, logW, &w.start); err != nil {
+			return err
+		}
+		w.started = true
+	}
+	if p.Text == "" && len(p.Images) == 0 {
+		return nil
+	}
+	r := promptRecord{Type: "prompt", Text: p.Text}
+	for _, img := range p.Images {
+		r.Images = append(r.Images, imageRecord{MediaType: img.MediaType, Data: img.Data})
+	}
+	return writeRecord(dst, logW, &r)
+}
+
+// ParseMessage implements agent.WireFormat.
+func (w *wire) ParseMessage(line []byte) ([]agent.Message, error) {
+	return w.b.parse(line)
+}
+
+func writeRecord(dst, logW io.Writer, r any) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if _, err := dst.Write(data); err != nil {
+		return err
+	}
+	if logW != nil {
+		_, _ = logW.Write(data)
+	}
+	return nil
+}
+
+// parse decodes one record written by the executable, or one of caic's own
+// records echoed in the relay output.
+func (b *Backend) parse(line []byte) ([]agent.Message, error) {
+	var probe struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(line, &probe); err != nil {
+		return nil, err
+	}
+	var m agent.Message
+	switch probe.Type {
+	case "start":
+		return nil, nil
+	case "prompt":
+		var r promptRecord
+		if err := json.Unmarshal(line, &r); err != nil {
+			return nil, err
+		}
+		u := &agent.UserInputMessage{Text: r.Text}
+		for _, img := range r.Images {
+			u.Images = append(u.Images, agent.ImageData{MediaType: img.MediaType, Data: img.Data})
+		}
+		return []agent.Message{u}, nil
+	case "init":
+		m = &agent.InitMessage{}
+	case "text":
+		m = &agent.TextMessage{}
+	case "text_delta":
+		m = &agent.TextDeltaMessage{}
+	case "thinking":
+		m = &agent.ThinkingMessage{}
+	case "tool_use":
+		m = &agent.ToolUseMessage{}
+	case "tool_result":
+		m = &agent.ToolResultMessage{}
+	case "ask":
+		m = &agent.AskMessage{}
+	case "todo":
+		m = &agent.TodoMessage{}
+	case "usage":
+		m = &agent.UsageMessage{}
+	case "result":
+		m = &agent.ResultMessage{}
+	default:
+		jsonutil.CountUnknownType(string(b.HarnessID), probe.Type)
+		return []agent.Message{&agent.RawMessage{MessageType: probe.Type, Raw: append([]byte(nil), line...), Unknown: true}}, nil
+	}
+	if err := json.Unmarshal(line, m); err != nil {
+		return nil, err
+	}
+	if tr, ok := m.(*agent.ToolResultMessage); ok {
+		tr.Output = agent.TailOutput(tr.Output)
+	}
+	return []agent.Message{m}, nil
+}
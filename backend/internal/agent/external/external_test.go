@@ -0,0 +1,116 @@
+package external
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestRegister(t *testing.T) {
+	for _, c := range []Config{
+		{Command: []string{"x"}},
+		{Name: agent.Claude, Command: []string{"x"}},
+		{Name: "caic", Command: []string{"x"}},
+		{Name: "mine"},
+		{Name: "mine", Command: []string{"x"}, MutatingTools: []string{"fs_["}},
+	} {
+		if _, err := Register(&c); err == nil {
+			t.Errorf("Register(%+v) succeeded", c)
+		}
+	}
+	b, err := Register(&Config{Name: "mine", Command: []string{"mine"}, Models: []string{"m1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Lookup("mine") != b || Lookup("other") != nil {
+		t.Error("Lookup mismatch")
+	}
+	if got := b.Models(); len(got) != 1 || got[0] != "m1" {
+		t.Errorf("Models() = %v", got)
+	}
+	if !b.IsMutatingTool("Edit") || b.IsMutatingTool("fs_write_file") {
+		t.Error("IsMutatingTool mismatch with the default tools")
+	}
+	b, err = Register(&Config{Name: "mine", Command: []string{"mine"}, MutatingTools: []string{"fs_write_*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.IsMutatingTool("Edit") || !b.IsMutatingTool("fs_write_file") {
+		t.Error("IsMutatingTool mismatch with the declared tools")
+	}
+}
+
+func TestWire(t *testing.T) {
+	b, err := Register(&Config{Name: "wire", Command: []string{"wire"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &wire{b: b, start: startRecord{Type: "start", Version: ProtocolVersion, Model: "m1"}}
+	var dst, log bytes.Buffer
+	if err := w.WritePrompt(&dst, agent.Prompt{}, &log); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WritePrompt(&dst, agent.Prompt{Text: "hi"}, &log); err != nil {
+		t.Fatal(err)
+	}
+	const want = `{"type":"start","version":1,"model":"m1"}` + "\n" + `{"type":"prompt","text":"hi"}` + "\n"
+	if dst.String() != want || log.String() != want {
+		t.Errorf("got %q, log %q", dst.String(), log.String())
+	}
+}
+
+func TestParse(t *testing.T) {
+	b, err := Register(&Config{Name: "parse", Command: []string{"parse"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("Start", func(t *testing.T) {
+		msgs, err := b.ParseMessage([]byte(`{"type":"start","version":1}`))
+		if err != nil || len(msgs) != 0 {
+			t.Errorf("got %v, %v", msgs, err)
+		}
+	})
+	t.Run("Prompt", func(t *testing.T) {
+		msgs, err := b.ParseMessage([]byte(`{"type":"prompt","text":"hi"}`))
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("got %v, %v", msgs, err)
+		}
+		if u, ok := msgs[0].(*agent.UserInputMessage); !ok || u.Text != "hi" {
+			t.Errorf("got %#v", msgs[0])
+		}
+	})
+	t.Run("ToolResult", func(t *testing.T) {
+		msgs, err := b.ParseMessage([]byte(`{"type":"tool_result","tool_use_id":"t1","output":"ok","exit_code":0}`))
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("got %v, %v", msgs, err)
+		}
+		tr, ok := msgs[0].(*agent.ToolResultMessage)
+		if !ok || tr.ToolUseID != "t1" || tr.Output != "ok" || tr.ExitCode == nil {
+			t.Errorf("got %#v", msgs[0])
+		}
+	})
+	t.Run("Result", func(t *testing.T) {
+		msgs, err := b.ParseMessage([]byte(`{"type":"result","subtype":"success","result":"done","num_turns":2}`))
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("got %v, %v", msgs, err)
+		}
+		if r, ok := msgs[0].(*agent.ResultMessage); !ok || r.Result != "done" || r.NumTurns != 2 {
+			t.Errorf("got %#v", msgs[0])
+		}
+	})
+	t.Run("Unknown", func(t *testing.T) {
+		msgs, err := b.ParseMessage([]byte(`{"type":"custom","x":1}`))
+		if err != nil || len(msgs) != 1 {
+			t.Fatalf("got %v, %v", msgs, err)
+		}
+		if r, ok := msgs[0].(*agent.RawMessage); !ok || !r.Unknown || r.MessageType != "custom" {
+			t.Errorf("got %#v", msgs[0])
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		if _, err := b.ParseMessage([]byte(`not json`)); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
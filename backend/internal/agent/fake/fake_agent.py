@@ -3,8 +3,12 @@
 # Reads NDJSON from stdin (one prompt per line), responds with streaming text
 # deltas followed by complete assistant and result messages. Exits on EOF.
 # Used by the caic -tags e2e server for e2e testing.
+#
+# "FAKE_SOAK <count> [<rate>]" emits count numbered "soak <i>" messages, rate
+# per second or as fast as possible, for the soak test in backend/internal/soak.
 
 import json
+import re
 import sys
 import time
 
@@ -581,6 +585,32 @@ def emit_demo_turn(turns: int) -> None:
     emit_result(turns, scenario["result"], scenario.get("cost", 0.01), scenario.get("duration", 500))
 
 
+SOAK_RE = re.compile(r"FAKE_SOAK (\d+)(?: (\d+))?")
+
+
+def emit_soak_turn(turns: int, count: int, rate: int) -> None:
+    """Emit count numbered text messages, each preceded by its delta, + result."""
+    start = time.monotonic()
+    for i in range(1, count + 1):
+        text = f"soak {i}"
+        emit(
+            {
+                "type": "stream_event",
+                "event": {
+                    "type": "content_block_delta",
+                    "index": 0,
+                    "delta": {"type": "text_delta", "text": text},
+                },
+            }
+        )
+        emit({"type": "assistant", "message": {"role": "assistant", "content": [{"type": "text", "text": text}]}})
+        if rate:
+            delay = start + i / rate - time.monotonic()
+            if delay > 0:
+                time.sleep(delay)
+    emit_result(turns, f"soak {count}")
+
+
 def main() -> None:
     # System init before first prompt.
     emit(
@@ -611,6 +641,10 @@ def main() -> None:
         if "FAKE_DEMO" in line:
             emit_demo_turn(turns)
             continue
+        m = SOAK_RE.search(line)
+        if m:
+            emit_soak_turn(turns, int(m.group(1)), int(m.group(2) or 0))
+            continue
 
         # Natural prompt detection (for screenshots with clean prompts).
         lower = line.lower()
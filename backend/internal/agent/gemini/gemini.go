@@ -21,6 +21,8 @@ func New() *Backend {
 	b.Base = agent.Base{
 		HarnessID:     agent.Gemini,
 		ModelList:     []string{"gemini-3.1-pro", "gemini-3-flash"},
+		ReadOnly:      true,
+		MutatingTools: []string{"Bash", "Edit", "Write", "smart_edit", "*__write_*", "*__edit_*"},
 		ContextWindow: 1_000_000,
 		Parse:         ParseMessage,
 	}
@@ -44,7 +46,13 @@ func buildArgs(opts *agent.Options) []string {
 	args := []string{
 		"gemini", "-p",
 		"--output-format", "stream-json",
-		"--yolo",
+	}
+	if opts.ReadOnly {
+		// Tools outside the allow list need an approval nobody can give in -p
+		// mode, so they are denied.
+		args = append(args, "--allowed-tools", "read_file,read_many_files,glob,search_file_content,list_directory,web_fetch,google_web_search")
+	} else {
+		args = append(args, "--yolo")
 	}
 	if opts.Model != "" {
 		args = append(args, "-m", opts.Model)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/jsonutil"
 )
 
 // toolNameMap maps Gemini CLI tool names to normalized (Claude Code) names
@@ -75,7 +76,8 @@ func ParseMessage(line []byte) ([]agent.Message, error) {
 		case "user":
 			return []agent.Message{&agent.UserInputMessage{Text: r.Content}}, nil
 		default:
-			return []agent.Message{&agent.RawMessage{MessageType: rec.Type, Raw: append([]byte(nil), line...)}}, nil
+			jsonutil.CountUnknownType("gemini", rec.Type+"("+r.Role+")")
+			return []agent.Message{&agent.RawMessage{MessageType: rec.Type, Raw: append([]byte(nil), line...), Unknown: true}}, nil
 		}
 
 	case TypeToolUse:
@@ -125,7 +127,8 @@ func ParseMessage(line []byte) ([]agent.Message, error) {
 		return []agent.Message{&m}, nil
 
 	default:
-		return []agent.Message{&agent.RawMessage{MessageType: rec.Type, Raw: append([]byte(nil), line...)}}, nil
+		jsonutil.CountUnknownType("gemini", rec.Type)
+		return []agent.Message{&agent.RawMessage{MessageType: rec.Type, Raw: append([]byte(nil), line...), Unknown: true}}, nil
 	}
 }
 
@@ -0,0 +1,80 @@
+// Handing sessions off to the interactive harness CLIs and reading back their transcripts.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+// ResumeCommand returns the interactive command line of the harness CLI
+// resuming the session sessionID.
+func ResumeCommand(h Harness, sessionID string) ([]string, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("%s: no session to resume", h)
+	}
+	switch h {
+	case Claude, Gemini:
+		return []string{string(h), "--resume", sessionID}, nil
+	case Codex:
+		return []string{"codex", "resume", sessionID}, nil
+	default:
+		return nil, fmt.Errorf("resuming a session interactively is not supported for %s", h)
+	}
+}
+
+// sessionIDRe matches the session IDs that can be used in a path unquoted.
+var sessionIDRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// SessionTranscript returns the path, relative to the home directory, of the
+// file where the harness CLI records the session sessionID started in dir.
+// It's appended to by both caic's and interactive sessions.
+func SessionTranscript(h Harness, dir, sessionID string) (string, error) {
+	if !sessionIDRe.MatchString(sessionID) {
+		return "", fmt.Errorf("%s: invalid session ID %q", h, sessionID)
+	}
+	switch h {
+	case Claude:
+		// Claude Code names the project directory after the working
+		// directory with every other character replaced by a dash.
+		slug := strings.Map(func(r rune) rune {
+			if r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return r
+			}
+			return '-'
+		}, dir)
+		return ".claude/projects/" + slug + "/" + sessionID + ".jsonl", nil
+	default:
+		return "", fmt.Errorf("importing a session is not supported for %s", h)
+	}
+}
+
+// TranscriptSize returns the size of the session transcript at path, as
+// returned by SessionTranscript, in the container. It is 0 when the file
+// doesn't exist yet.
+func TranscriptSize(ctx context.Context, container, path string) (int64, error) {
+	out, err := sshconn.Command(ctx, container, "wc -c < ~/"+path+" 2>/dev/null || echo 0").Output()
+	if err != nil {
+		return 0, fmt.Errorf("transcript size: %w", err)
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("transcript size: %w", err)
+	}
+	return n, nil
+}
+
+// ReadTranscript returns the session transcript at path in the container,
+// from byte offset on.
+func ReadTranscript(ctx context.Context, container, path string, offset int64) ([]byte, error) {
+	out, err := sshconn.Command(ctx, container, "tail -c +"+strconv.FormatInt(offset+1, 10)+" ~/"+path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("read transcript: %w", err)
+	}
+	return out, nil
+}
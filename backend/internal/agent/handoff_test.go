@@ -0,0 +1,23 @@
+package agent
+
+import "testing"
+
+func TestSessionTranscript(t *testing.T) {
+	got, err := SessionTranscript(Claude, "/home/user/src/my.repo", "0b1c-2d")
+	if want := ".claude/projects/-home-user-src-my-repo/0b1c-2d.jsonl"; err != nil || got != want {
+		t.Errorf("SessionTranscript() = %q, %v; want %q", got, err, want)
+	}
+	for _, tc := range []struct {
+		h  Harness
+		id string
+	}{
+		{Claude, ""},
+		{Claude, "../x"},
+		{Claude, "a; rm -rf ~"},
+		{Gemini, "abc"},
+	} {
+		if _, err := SessionTranscript(tc.h, "/home/user", tc.id); err == nil {
+			t.Errorf("SessionTranscript(%s, %q) succeeded", tc.h, tc.id)
+		}
+	}
+}
@@ -17,10 +17,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os/exec"
 	"sync"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
 )
 
 const bridgeScriptPath = agent.RelayDir + "/kilo_bridge.py"
@@ -49,6 +49,7 @@ func New() *Backend {
 	b.Base = agent.Base{
 		HarnessID:     agent.Kilo,
 		ModelList:     defaultModels,
+		MutatingTools: []string{"Bash", "Edit", "Write", "multiedit", "patch", "apply_patch"},
 		ContextWindow: 200_000,
 		Parse:         ParseMessage,
 	}
@@ -99,7 +100,7 @@ func (*Backend) WritePrompt(w io.Writer, p agent.Prompt, logW io.Writer) error {
 
 // deployBridge uploads the bridge script into the container. Idempotent.
 func deployBridge(ctx context.Context, container string) error {
-	cmd := exec.CommandContext(ctx, "ssh", container, //nolint:gosec // container is not user-controlled
+	cmd := sshconn.Command(ctx, container,
 		"mkdir -p "+agent.RelayDir+" && cat > "+bridgeScriptPath)
 	cmd.Stdin = bytes.NewReader(BridgeScript)
 	if out, err := cmd.CombinedOutput(); err != nil {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/jsonutil"
 )
 
 // toolNameMap maps kilo lowercase tool names to normalized (PascalCase) names
@@ -71,7 +72,8 @@ func ParseMessage(line []byte) ([]agent.Message, error) {
 		}
 		return []agent.Message{&m}, nil
 	default:
-		return []agent.Message{&agent.RawMessage{MessageType: rec.Type, Raw: append([]byte(nil), line...)}}, nil
+		jsonutil.CountUnknownType("kilo", rec.Type)
+		return []agent.Message{&agent.RawMessage{MessageType: rec.Type, Raw: append([]byte(nil), line...), Unknown: true}}, nil
 	}
 }
 
@@ -121,9 +123,11 @@ func parsePartUpdated(rec *Record) ([]agent.Message, error) {
 		}}, nil
 
 	default:
+		jsonutil.CountUnknownType("kilo", TypePartUpdated+"("+part.Type+")")
 		return []agent.Message{&agent.RawMessage{
 			MessageType: TypePartUpdated,
 			Raw:         append([]byte(nil), rec.Raw()...),
+			Unknown:     true,
 		}}, nil
 	}
 }
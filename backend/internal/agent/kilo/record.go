@@ -113,7 +113,7 @@ func (r *InitRecord) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("InitRecord: %w", err)
 	}
 	r.Extra = jsonutil.CollectUnknown(raw, initRecordKnown)
-	jsonutil.WarnUnknown("InitRecord", r.Extra)
+	jsonutil.WarnUnknown("kilo", "InitRecord", r.Extra)
 	return nil
 }
 
@@ -143,7 +143,7 @@ func (r *PartUpdatedRecord) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("PartUpdatedRecord: %w", err)
 	}
 	r.Extra = jsonutil.CollectUnknown(raw, partUpdatedRecordKnown)
-	jsonutil.WarnUnknown("PartUpdatedRecord", r.Extra)
+	jsonutil.WarnUnknown("kilo", "PartUpdatedRecord", r.Extra)
 	return nil
 }
 
@@ -195,7 +195,7 @@ func (p *Part) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("Part: %w", err)
 	}
 	p.Extra = jsonutil.CollectUnknown(raw, partKnown)
-	jsonutil.WarnUnknown("Part("+p.Type+")", p.Extra)
+	jsonutil.WarnUnknown("kilo", "Part("+p.Type+")", p.Extra)
 	return nil
 }
 
@@ -232,7 +232,7 @@ func (s *ToolState) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("ToolState: %w", err)
 	}
 	s.Extra = jsonutil.CollectUnknown(raw, toolStateKnown)
-	jsonutil.WarnUnknown("ToolState("+s.Status+")", s.Extra)
+	jsonutil.WarnUnknown("kilo", "ToolState("+s.Status+")", s.Extra)
 	return nil
 }
 
@@ -261,7 +261,7 @@ func (t *StepTokens) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("StepTokens: %w", err)
 	}
 	t.Extra = jsonutil.CollectUnknown(raw, stepTokensKnown)
-	jsonutil.WarnUnknown("StepTokens", t.Extra)
+	jsonutil.WarnUnknown("kilo", "StepTokens", t.Extra)
 	return nil
 }
 
@@ -287,7 +287,7 @@ func (c *TokenCache) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("TokenCache: %w", err)
 	}
 	c.Extra = jsonutil.CollectUnknown(raw, tokenCacheKnown)
-	jsonutil.WarnUnknown("TokenCache", c.Extra)
+	jsonutil.WarnUnknown("kilo", "TokenCache", c.Extra)
 	return nil
 }
 
@@ -317,7 +317,7 @@ func (r *PartDeltaRecord) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("PartDeltaRecord: %w", err)
 	}
 	r.Extra = jsonutil.CollectUnknown(raw, partDeltaRecordKnown)
-	jsonutil.WarnUnknown("PartDeltaRecord", r.Extra)
+	jsonutil.WarnUnknown("kilo", "PartDeltaRecord", r.Extra)
 	return nil
 }
 
@@ -395,7 +395,7 @@ func (r *TurnCloseRecord) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("TurnCloseRecord: %w", err)
 	}
 	r.Extra = jsonutil.CollectUnknown(raw, turnCloseRecordKnown)
-	jsonutil.WarnUnknown("TurnCloseRecord", r.Extra)
+	jsonutil.WarnUnknown("kilo", "TurnCloseRecord", r.Extra)
 	return nil
 }
 
@@ -0,0 +1,176 @@
+// Token pricing table for computing costs of harnesses that report none.
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModelPrice is the price of a model in USD per million tokens.
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheWrite float64 `json:"cacheWrite,omitempty"`
+	CacheRead  float64 `json:"cacheRead,omitempty"`
+	// InputIncludesCacheRead is set for providers (OpenAI) that report
+	// cached tokens as a subset of input tokens instead of disjointly.
+	InputIncludesCacheRead bool `json:"inputIncludesCacheRead,omitempty"`
+}
+
+// Cost returns the USD cost of u at this price.
+func (p *ModelPrice) Cost(u Usage) float64 {
+	in := u.InputTokens
+	if p.InputIncludesCacheRead {
+		in = max(in-u.CacheReadInputTokens, 0)
+	}
+	return (float64(in)*p.Input +
+		float64(u.OutputTokens)*p.Output +
+		float64(u.CacheCreationInputTokens)*p.CacheWrite +
+		float64(u.CacheReadInputTokens)*p.CacheRead) / 1e6
+}
+
+// defaultPrices are list prices keyed by model ID prefix. Lookup picks the
+// longest matching prefix so dated snapshots (e.g. "claude-sonnet-4-5-20250929")
+// resolve to their family.
+var defaultPrices = map[string]ModelPrice{
+	"claude-opus-4":     {Input: 15, Output: 75, CacheWrite: 18.75, CacheRead: 1.50},
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+	"claude-opus-4-6":   {Input: 5, Output: 25, CacheWrite: 6.25, CacheRead: 0.50},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheWrite: 3.75, CacheRead: 0.30},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheWrite: 1.25, CacheRead: 0.10},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4, CacheWrite: 1, CacheRead: 0.08},
+	"gpt-5":             {Input: 1.25, Output: 10, CacheRead: 0.125, InputIncludesCacheRead: true},
+	"gpt-5-mini":        {Input: 0.25, Output: 2, CacheRead: 0.025, InputIncludesCacheRead: true},
+	"gpt-5-nano":        {Input: 0.05, Output: 0.40, CacheRead: 0.005, InputIncludesCacheRead: true},
+	"codex-mini-latest": {Input: 1.50, Output: 6, CacheRead: 0.375, InputIncludesCacheRead: true},
+	"gemini-2.5-pro":    {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gemini-2.5-flash":  {Input: 0.30, Output: 2.50, CacheRead: 0.03},
+}
+
+// Pricing maps model IDs to token prices. The zero value is not usable; use
+// NewPricing.
+type Pricing struct {
+	mu      sync.RWMutex
+	prices  map[string]ModelPrice
+	path    string    // optional override file
+	modTime time.Time // modification time of path when last loaded
+}
+
+// NewPricing returns a Pricing holding the built-in list prices.
+func NewPricing() *Pricing {
+	p := &Pricing{}
+	p.prices = p.merge(nil)
+	return p
+}
+
+// DefaultPricing is the process-wide pricing table used by tasks.
+var DefaultPricing = NewPricing()
+
+// pricingFile is the on-disk format of the override file.
+type pricingFile struct {
+	Models map[string]ModelPrice `json:"models"`
+}
+
+// Load sets path as the override file and reads it. Entries in the file
+// replace or extend the built-in prices. A missing file is not an error and
+// leaves the built-in table in effect.
+func (p *Pricing) Load(path string) error {
+	p.mu.Lock()
+	p.path = path
+	p.modTime = time.Time{}
+	p.mu.Unlock()
+	_, err := p.Refresh()
+	return err
+}
+
+// Refresh reloads the override file if it changed since the last load. It
+// reports whether the table was updated.
+func (p *Pricing) Refresh() (bool, error) {
+	p.mu.RLock()
+	path, last := p.path, p.modTime
+	p.mu.RUnlock()
+	if path == "" {
+		return false, nil
+	}
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		p.mu.Lock()
+		changed := !p.modTime.IsZero()
+		if changed {
+			p.prices = p.merge(nil)
+			p.modTime = time.Time{}
+		}
+		p.mu.Unlock()
+		return changed, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if fi.ModTime().Equal(last) {
+		return false, nil
+	}
+	data, err := os.ReadFile(path) //nolint:gosec // G304: internal config path
+	if err != nil {
+		return false, err
+	}
+	var f pricingFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	for name, mp := range f.Models {
+		if mp.Input < 0 || mp.Output < 0 || mp.CacheWrite < 0 || mp.CacheRead < 0 {
+			return false, fmt.Errorf("%s: negative price for %q", path, name)
+		}
+	}
+	p.mu.Lock()
+	p.prices = p.merge(f.Models)
+	p.modTime = fi.ModTime()
+	p.mu.Unlock()
+	return true, nil
+}
+
+// merge returns the built-in prices overlaid with overrides.
+func (p *Pricing) merge(overrides map[string]ModelPrice) map[string]ModelPrice {
+	out := make(map[string]ModelPrice, len(defaultPrices)+len(overrides))
+	maps.Copy(out, defaultPrices)
+	maps.Copy(out, overrides)
+	return out
+}
+
+// Lookup returns the price for model: an exact match, else the longest
+// matching prefix.
+func (p *Pricing) Lookup(model string) (ModelPrice, bool) {
+	if model == "" {
+		return ModelPrice{}, false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if mp, ok := p.prices[model]; ok {
+		return mp, true
+	}
+	best := ""
+	for k := range p.prices {
+		if len(k) > len(best) && strings.HasPrefix(model, k) {
+			best = k
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return p.prices[best], true
+}
+
+// Cost returns the USD cost of u for model. ok is false when the model has no
+// known price.
+func (p *Pricing) Cost(model string, u Usage) (cost float64, ok bool) {
+	mp, ok := p.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return mp.Cost(u), true
+}
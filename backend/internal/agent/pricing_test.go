@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPricing(t *testing.T) {
+	t.Run("Lookup", func(t *testing.T) {
+		p := NewPricing()
+		for _, tc := range []struct {
+			model string
+			want  float64
+			ok    bool
+		}{
+			{"claude-opus-4-6", 5, true},
+			{"claude-opus-4-1-20250805", 15, true},
+			{"claude-sonnet-4-5-20250929", 3, true},
+			{"gpt-5-mini-2025-08-07", 0.25, true},
+			{"gpt-5-codex", 1.25, true},
+			{"unknown", 0, false},
+			{"", 0, false},
+		} {
+			mp, ok := p.Lookup(tc.model)
+			if ok != tc.ok || mp.Input != tc.want {
+				t.Errorf("Lookup(%q) = %v, %v; want input %v, %v", tc.model, mp.Input, ok, tc.want, tc.ok)
+			}
+		}
+	})
+	t.Run("Cost", func(t *testing.T) {
+		u := Usage{InputTokens: 1_000_000, CacheReadInputTokens: 400_000, OutputTokens: 100_000}
+		// Disjoint: input and cache reads are billed separately.
+		mp := ModelPrice{Input: 3, Output: 15, CacheRead: 0.30}
+		if got, want := mp.Cost(u), 3+0.12+1.5; math.Abs(got-want) > 1e-9 {
+			t.Errorf("Cost = %v, want %v", got, want)
+		}
+		// Subset: cached tokens are already counted in input.
+		mp.InputIncludesCacheRead = true
+		if got, want := mp.Cost(u), 1.8+0.12+1.5; math.Abs(got-want) > 1e-9 {
+			t.Errorf("Cost = %v, want %v", got, want)
+		}
+	})
+	t.Run("LoadRefresh", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "pricing.json")
+		p := NewPricing()
+		if err := p.Load(path); err != nil {
+			t.Fatalf("missing file: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(`{"models":{"my-model":{"input":2,"output":4},"gpt-5":{"input":9,"output":9}}}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if changed, err := p.Refresh(); err != nil || !changed {
+			t.Fatalf("Refresh() = %v, %v", changed, err)
+		}
+		if mp, ok := p.Lookup("my-model-v2"); !ok || mp.Input != 2 {
+			t.Errorf("override: %+v, %v", mp, ok)
+		}
+		if mp, _ := p.Lookup("gpt-5"); mp.Input != 9 {
+			t.Errorf("replaced default: %+v", mp)
+		}
+		if mp, _ := p.Lookup("claude-sonnet-4"); mp.Input != 3 {
+			t.Errorf("kept default: %+v", mp)
+		}
+		if changed, err := p.Refresh(); err != nil || changed {
+			t.Errorf("unchanged Refresh() = %v, %v", changed, err)
+		}
+		if err := os.WriteFile(path, []byte(`{"models":{"bad":{"input":-1}}}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		future := time.Now().Add(time.Hour)
+		if err := os.Chtimes(path, future, future); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := p.Refresh(); err == nil {
+			t.Error("negative price accepted")
+		}
+		if mp, _ := p.Lookup("gpt-5"); mp.Input != 9 {
+			t.Errorf("bad file must keep previous table: %+v", mp)
+		}
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+		if changed, err := p.Refresh(); err != nil || !changed {
+			t.Fatalf("Refresh() after remove = %v, %v", changed, err)
+		}
+		if _, ok := p.Lookup("my-model"); ok {
+			t.Error("override survived file removal")
+		}
+	})
+}
@@ -1,9 +1,25 @@
 // Package relay embeds the Python relay script used inside containers.
 package relay
 
-import _ "embed"
+import (
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+)
 
 // Script is the Python relay that keeps claude alive across SSH disconnects.
 //
 //go:embed relay.py
 var Script []byte
+
+// ProtocolVersion is the PROTOCOL_VERSION of Script. Bump both on any change
+// that an older relay daemon, still running in a container started by a
+// previous caic, couldn't handle.
+const ProtocolVersion = 2
+
+// Hash is the hex encoded SHA-256 of Script, checked against the copy
+// deployed in a container.
+var Hash = func() string {
+	h := sha256.Sum256(Script)
+	return hex.EncodeToString(h[:])
+}()
@@ -0,0 +1,17 @@
+package relay
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestProtocolVersion(t *testing.T) {
+	m := regexp.MustCompile(`(?m)^PROTOCOL_VERSION = (\d+)$`).FindSubmatch(Script)
+	if m == nil {
+		t.Fatal("PROTOCOL_VERSION not found in relay.py")
+	}
+	if v, _ := strconv.Atoi(string(m[1])); v != ProtocolVersion {
+		t.Errorf("relay.py PROTOCOL_VERSION = %d, ProtocolVersion = %d", v, ProtocolVersion)
+	}
+}
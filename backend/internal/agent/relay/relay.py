@@ -5,6 +5,7 @@
 #   serve-attach --dir <path> -- <cmd...>   Start relay daemon + attach as first client.
 #   attach [--offset N]                     Reconnect to a running relay daemon.
 #   read-plan [path]                        Read a plan file from the container.
+#   version                                 Print PROTOCOL_VERSION.
 #
 # The relay daemon owns the subprocess stdin/stdout, logs all I/O to
 # output.jsonl, and accepts one client at a time via a Unix socket.
@@ -47,6 +48,19 @@ RELAY_DIR = os.environ.get("CAIC_RELAY_DIR", "/tmp/caic-relay")
 SOCK_PATH = os.path.join(RELAY_DIR, "relay.sock")
 OUTPUT_PATH = os.path.join(RELAY_DIR, "output.jsonl")
 PID_PATH = os.path.join(RELAY_DIR, "pid")
+VERSION_PATH = os.path.join(RELAY_DIR, "version")
+
+# Version of the protocol between caic and the relay: the command line, the
+# socket handshake and the files in RELAY_DIR. The daemon records it in
+# VERSION_PATH and caic refuses to attach to another version. Bump it along
+# with relay.ProtocolVersion in embed.go on any incompatible change.
+PROTOCOL_VERSION = 2
+
+# Client stdin lines starting with this prefix are records caic injects into
+# output.jsonl, e.g. caic_user_input for harnesses whose stdin isn't logged.
+# They are logged even with --no-log-stdin and never forwarded to the
+# subprocess.
+CAIC_RECORD_PREFIX = b'{"type":"caic_'
 
 # Max size of a single read from subprocess stdout.
 BUF_SIZE = 65536
@@ -104,6 +118,7 @@ def serve(cmd_args, work_dir, log_stdin=True):
       log_stdin: When False, client_reader forwards stdin to the subprocess
         but does NOT write it to output.jsonl. This keeps the log clean for
         protocols like JSON-RPC where stdin contains handshake/request noise.
+        Lines starting with CAIC_RECORD_PREFIX are logged regardless.
 
     Failure modes handled:
       - SSH drops: client disconnects, subprocess keeps running. Next
@@ -156,9 +171,11 @@ def serve(cmd_args, work_dir, log_stdin=True):
     os.dup2(log_fd, 2)
     os.close(log_fd)
 
-    # Write PID file.
+    # Write PID and version files.
     with open(PID_PATH, "w") as f:
         f.write(str(os.getpid()))
+    with open(VERSION_PATH, "w") as f:
+        f.write(str(PROTOCOL_VERSION))
 
     logging.info("relay daemon started pid=%d cmd=%s cwd=%s", os.getpid(), cmd_args, work_dir)
     _start_time = time.monotonic()
@@ -399,12 +416,26 @@ def serve(cmd_args, work_dir, log_stdin=True):
             #
             # User input is NDJSON: each message is a single JSON line ending
             # with \n.  Large messages (e.g. base64 images) may span multiple
-            # recv() calls.  We buffer incoming data and only write complete
-            # lines to output_file (under output_lock) so that concurrent
-            # subprocess stdout writes can't interleave mid-line and corrupt
-            # the replay log.  Data is forwarded to proc.stdin immediately
-            # (the subprocess handles its own framing).
+            # recv() calls.  We buffer incoming data and only handle complete
+            # lines so that concurrent subprocess stdout writes can't
+            # interleave mid-line in output_file (under output_lock) and
+            # corrupt the replay log, and so that caic records can be told
+            # apart from subprocess input.
             def client_reader(c, cid=cid):
+                def log_line(line):
+                    with output_lock:
+                        output_file.write(line)
+                        output_file.flush()
+
+                def handle_line(line):
+                    if line.startswith(CAIC_RECORD_PREFIX):
+                        log_line(line)
+                        return
+                    proc.stdin.write(line)
+                    proc.stdin.flush()
+                    if log_stdin:
+                        log_line(line)
+
                 close_stdin = False
                 line_buf = b""
                 try:
@@ -418,30 +449,17 @@ def serve(cmd_args, work_dir, log_stdin=True):
                         if b"\x00" in data:
                             data = data.replace(b"\x00", b"")
                             close_stdin = True
-                            if data:
-                                proc.stdin.write(data)
-                                proc.stdin.flush()
-                                if log_stdin:
-                                    line_buf += data
+                        line_buf += data
+                        while b"\n" in line_buf:
+                            line, line_buf = line_buf.split(b"\n", 1)
+                            handle_line(line + b"\n")
+                        if close_stdin:
                             break
-                        proc.stdin.write(data)
-                        proc.stdin.flush()
-                        # Buffer data and write complete lines to output_file.
-                        if log_stdin:
-                            line_buf += data
-                            while b"\n" in line_buf:
-                                line, line_buf = line_buf.split(b"\n", 1)
-                                line += b"\n"
-                                with output_lock:
-                                    output_file.write(line)
-                                    output_file.flush()
+                    # Forward any remaining buffered data (incomplete line).
+                    if line_buf:
+                        handle_line(line_buf)
                 except (OSError, BrokenPipeError, ValueError) as e:
                     logging.info("client #%d reader error: %s", cid, e)
-                # Flush any remaining buffered data (incomplete line).
-                if log_stdin and line_buf:
-                    with output_lock:
-                        output_file.write(line_buf)
-                        output_file.flush()
                 if close_stdin:
                     if stdin_closed[0]:
                         logging.warning("client #%d requested stdin close but stdin already closed", cid)
@@ -483,10 +501,11 @@ def serve(cmd_args, work_dir, log_stdin=True):
         os.unlink(SOCK_PATH)
     except FileNotFoundError:
         pass
-    try:
-        os.unlink(PID_PATH)
-    except FileNotFoundError:
-        pass
+    for path in (PID_PATH, VERSION_PATH):
+        try:
+            os.unlink(path)
+        except FileNotFoundError:
+            pass
 
 
 def attach_client(offset):
@@ -601,6 +620,7 @@ def main():
         print("usage: relay.py serve-attach --dir <path> -- <cmd...>", file=sys.stderr)
         print("       relay.py attach [--offset N]", file=sys.stderr)
         print("       relay.py read-plan [path]", file=sys.stderr)
+        print("       relay.py version", file=sys.stderr)
         sys.exit(1)
 
     mode = sys.argv[1]
@@ -640,6 +660,9 @@ def main():
     elif mode == "read-plan":
         read_plan(sys.argv[2] if len(sys.argv) > 2 else None)
 
+    elif mode == "version":
+        print(PROTOCOL_VERSION)
+
     else:
         print(f"relay.py: unknown mode {mode!r}", file=sys.stderr)
         sys.exit(1)
@@ -243,6 +243,82 @@ def test_ssh_drop_keeps_subprocess():
         _cleanup(relay_dir)
 
 
+def test_version_file():
+    """The daemon records PROTOCOL_VERSION while running and removes it on exit."""
+    relay_dir = tempfile.mkdtemp(prefix="caic-relay-test-")
+    version_path = os.path.join(relay_dir, "version")
+    env = _make_env(relay_dir)
+    want = subprocess.run([sys.executable, RELAY_PY, "version"], capture_output=True, check=True, env=env).stdout.strip()
+
+    try:
+        proc = subprocess.Popen(
+            [sys.executable, RELAY_PY, "serve-attach", "--dir", relay_dir, "--", "cat"],
+            stdin=subprocess.PIPE,
+            stdout=subprocess.PIPE,
+            stderr=subprocess.PIPE,
+            env=env,
+        )
+        _wait_for_socket(os.path.join(relay_dir, "relay.sock"))
+        with open(version_path, "rb") as f:
+            got = f.read()
+        assert got == want, f"version file {got!r}, want {want!r}"
+
+        proc.stdin.write(b"\x00")
+        proc.stdin.flush()
+        proc.stdin.close()
+        proc.wait(timeout=10)
+        deadline = time.monotonic() + 5
+        while os.path.exists(version_path):
+            if time.monotonic() > deadline:
+                raise AssertionError("version file not removed after the daemon exited")
+            time.sleep(0.05)
+    finally:
+        try:
+            proc.kill()
+        except OSError:
+            pass
+        _cleanup(relay_dir)
+
+
+def test_caic_records_logged_not_forwarded():
+    """With --no-log-stdin, caic records on stdin are logged to output.jsonl
+    and not forwarded to the subprocess; other lines are only forwarded."""
+    relay_dir = tempfile.mkdtemp(prefix="caic-relay-test-")
+    out_path = os.path.join(relay_dir, "subprocess-out")
+    output_path = os.path.join(relay_dir, "output.jsonl")
+    env = _make_env(relay_dir)
+    record = b'{"type":"caic_user_input","text":"hi"}\n'
+
+    try:
+        proc = subprocess.Popen(
+            [sys.executable, RELAY_PY, "serve-attach", "--dir", relay_dir, "--no-log-stdin", "--", "/bin/sh", "-c", f"cat > {out_path}"],
+            stdin=subprocess.PIPE,
+            stdout=subprocess.PIPE,
+            stderr=subprocess.PIPE,
+            env=env,
+        )
+        proc.stdin.write(b'{"jsonrpc":"2.0"}\n' + record)
+        proc.stdin.flush()
+        time.sleep(0.3)
+        proc.stdin.write(b"\x00")
+        proc.stdin.flush()
+        proc.stdin.close()
+        proc.wait(timeout=10)
+
+        with open(out_path, "rb") as f:
+            forwarded = f.read()
+        assert forwarded == b'{"jsonrpc":"2.0"}\n', f"subprocess got {forwarded!r}"
+        with open(output_path, "rb") as f:
+            logged = f.read()
+        assert logged == record, f"output.jsonl is {logged!r}"
+    finally:
+        try:
+            proc.kill()
+        except OSError:
+            pass
+        _cleanup(relay_dir)
+
+
 def test_parse_numstat():
     """Test _parse_numstat parses git diff --numstat output correctly."""
     # Import the module under test.
@@ -292,4 +368,12 @@ if __name__ == "__main__":
     test_ssh_drop_keeps_subprocess()
     print("OK")
 
+    print("test_version_file...", end=" ", flush=True)
+    test_version_file()
+    print("OK")
+
+    print("test_caic_records_logged_not_forwarded...", end=" ", flush=True)
+    test_caic_records_logged_not_forwarded()
+    print("OK")
+
     print("All tests passed.")
@@ -0,0 +1,45 @@
+// Retries of idempotent SSH commands on transient connection failures.
+package agent
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+// sshRetries is the number of attempts RetrySSH makes after the first one.
+const sshRetries = 2
+
+// sshRetryDelay is the delay before the first retry; it doubles every attempt.
+var sshRetryDelay = 250 * time.Millisecond
+
+// IsSSHConnError reports whether err is SSH failing on its own, e.g. the
+// connection was refused, reset or its multiplexing master went away, as
+// opposed to the remote command failing.
+func IsSSHConnError(err error) bool {
+	var connErr *sshconn.ConnError
+	return errors.As(err, &connErr)
+}
+
+// RetrySSH calls run, which must start a fresh remote command each time, until
+// it succeeds, fails for another reason than the connection or the attempts
+// are exhausted. Only use it for remote commands that are safe to repeat.
+func RetrySSH(ctx context.Context, run func() error) error {
+	delay := sshRetryDelay
+	for i := 0; ; i++ {
+		err := run()
+		if err == nil || i == sshRetries || !IsSSHConnError(err) {
+			return err
+		}
+		slog.Debug("ssh retry", "attempt", i+1, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
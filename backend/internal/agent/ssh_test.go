@@ -0,0 +1,44 @@
+package agent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+func TestRetrySSH(t *testing.T) {
+	old := sshRetryDelay
+	sshRetryDelay = time.Millisecond
+	t.Cleanup(func() { sshRetryDelay = old })
+	for _, tc := range []struct {
+		name  string
+		codes []int // Exit codes of the successive attempts; 255 is a connection error.
+		calls int
+		fail  bool
+	}{
+		{"OK", []int{0}, 1, false},
+		{"Transient", []int{255, 0}, 2, false},
+		{"Exhausted", []int{255, 255, 255, 0}, 3, true},
+		{"RemoteFailure", []int{1, 0}, 1, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			err := RetrySSH(t.Context(), func() error {
+				code := tc.codes[calls]
+				calls++
+				if code == 0 {
+					return nil
+				}
+				if code == 255 {
+					return &sshconn.ConnError{Container: "ctr", Err: errors.New("connection reset")}
+				}
+				return &sshconn.ExitError{Code: code}
+			})
+			if calls != tc.calls || (err != nil) != tc.fail {
+				t.Errorf("%d calls, err %v", calls, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Classification of agent and relay stderr output into system messages.
+package agent
+
+import "strings"
+
+// SystemMessage subtypes emitted for noteworthy stderr output.
+const (
+	SubtypeAuthError      = "auth_error"      // The agent CLI or SSH rejected the credentials.
+	SubtypeAgentCrash     = "agent_crash"     // The agent CLI crashed.
+	SubtypeRelayTraceback = "relay_traceback" // The Python relay raised an exception.
+)
+
+// stderrPatterns maps lowercase substrings of a stderr line to the subtype
+// they indicate. The first match wins.
+var stderrPatterns = []struct {
+	substr, subtype string
+}{
+	{"invalid api key", SubtypeAuthError},
+	{"authentication_error", SubtypeAuthError},
+	{"authentication failed", SubtypeAuthError},
+	{"unauthorized", SubtypeAuthError},
+	{"not logged in", SubtypeAuthError},
+	{"please run /login", SubtypeAuthError},
+	{"oauth token has expired", SubtypeAuthError},
+	{"permission denied (publickey", SubtypeAuthError},
+	{"panic:", SubtypeAgentCrash},
+	{"fatal error:", SubtypeAgentCrash},
+	{"segmentation fault", SubtypeAgentCrash},
+	{"(core dumped)", SubtypeAgentCrash},
+	{"heap out of memory", SubtypeAgentCrash},
+	{"uncaught exception", SubtypeAgentCrash},
+	{"unhandled promise rejection", SubtypeAgentCrash},
+	{"unhandledpromiserejection", SubtypeAgentCrash},
+}
+
+// classifyStderr returns the SystemMessage subtype line indicates, or "".
+func classifyStderr(line string) string {
+	l := strings.ToLower(line)
+	for _, p := range stderrPatterns {
+		if strings.Contains(l, p.substr) {
+			return p.subtype
+		}
+	}
+	return ""
+}
+
+// maxTracebackLines bounds the lines collected for one traceback.
+const maxTracebackLines = 64
+
+// stderrClassifier turns stderr lines into messages: noteworthy lines become
+// SystemMessage, Python tracebacks are folded into a single SystemMessage and
+// anything else is passed through as StderrMessage.
+type stderrClassifier struct {
+	source string
+	tb     []string // Traceback being collected.
+}
+
+// add processes one line, with its indentation, and returns the message to
+// emit, if any.
+func (c *stderrClassifier) add(raw string) Message {
+	line := strings.TrimSpace(raw)
+	if c.tb != nil {
+		c.tb = append(c.tb, raw)
+		// Frames are indented; the unindented line is the exception.
+		if line == "" || raw[0] == ' ' || raw[0] == '\t' {
+			if len(c.tb) < maxTracebackLines {
+				return nil
+			}
+		}
+		detail := strings.Join(c.tb, "\n")
+		c.tb = nil
+		return &SystemMessage{MessageType: "system", Subtype: SubtypeRelayTraceback, Detail: detail}
+	}
+	if line == "" {
+		return nil
+	}
+	if line == "Traceback (most recent call last):" {
+		c.tb = []string{line}
+		return nil
+	}
+	if st := classifyStderr(line); st != "" {
+		return &SystemMessage{MessageType: "system", Subtype: st, Detail: line}
+	}
+	return &StderrMessage{Source: c.source, Line: line}
+}
@@ -0,0 +1,55 @@
+package agent
+
+import "testing"
+
+func TestStderrClassifier(t *testing.T) {
+	t.Run("Lines", func(t *testing.T) {
+		for _, tc := range []struct {
+			line, subtype string
+		}{
+			{"Invalid API key · Please run /login", SubtypeAuthError},
+			{"git@github.com: Permission denied (publickey).", SubtypeAuthError},
+			{"FATAL ERROR: Reached heap limit Allocation failed - JavaScript heap out of memory", SubtypeAgentCrash},
+			{"panic: runtime error: index out of range", SubtypeAgentCrash},
+			{"Warning: no stdin data received in 3s", ""},
+		} {
+			c := stderrClassifier{source: "relay"}
+			switch m := c.add(tc.line).(type) {
+			case *SystemMessage:
+				if m.Subtype != tc.subtype || m.Detail != tc.line {
+					t.Errorf("%q: got %s %q", tc.line, m.Subtype, m.Detail)
+				}
+			case *StderrMessage:
+				if tc.subtype != "" || m.Source != "relay" || m.Line != tc.line {
+					t.Errorf("%q: got stderr %+v", tc.line, m)
+				}
+			default:
+				t.Errorf("%q: got %#v", tc.line, m)
+			}
+		}
+	})
+	t.Run("Traceback", func(t *testing.T) {
+		c := stderrClassifier{source: "relay"}
+		lines := []string{
+			"Traceback (most recent call last):",
+			`  File "/tmp/caic-relay/relay.py", line 10, in <module>`,
+			"    main()",
+			"ConnectionResetError: [Errno 104] Connection reset by peer",
+		}
+		for _, l := range lines[:3] {
+			if m := c.add(l); m != nil {
+				t.Fatalf("%q: got %#v before the exception line", l, m)
+			}
+		}
+		m, ok := c.add(lines[3]).(*SystemMessage)
+		if !ok || m.Subtype != SubtypeRelayTraceback {
+			t.Fatalf("got %#v", m)
+		}
+		if want := lines[0] + "\n" + lines[1] + "\n" + lines[2] + "\n" + lines[3]; m.Detail != want {
+			t.Errorf("detail = %q", m.Detail)
+		}
+		if _, ok := c.add("next").(*StderrMessage); !ok {
+			t.Error("classifier stuck in traceback mode")
+		}
+	})
+}
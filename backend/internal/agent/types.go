@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"time"
+	"unicode/utf8"
 )
 
 // Harness identifies the coding agent harness (e.g. Claude Code CLI, Gemini CLI).
@@ -24,6 +25,16 @@ type DiffFileStat struct {
 	Added   int    `json:"added"`
 	Deleted int    `json:"deleted"`
 	Binary  bool   `json:"binary,omitempty"`
+	LFS     bool   `json:"lfs,omitempty"` // Git LFS pointer; Added and Deleted count the pointer's lines.
+	// Submodule is set when the file is a submodule whose commit changed.
+	Submodule SubmoduleBump `json:"submodule,omitzero"`
+}
+
+// SubmoduleBump is the change of the commit a submodule points to. From is
+// empty for an added submodule and To for a removed one.
+type SubmoduleBump struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
 }
 
 // DiffStat summarises the changes in a branch relative to its base.
@@ -60,6 +71,16 @@ type SystemMessage struct {
 // Type implements Message.
 func (m *SystemMessage) Type() string { return "system" }
 
+// RateLimitMessage is emitted when the harness reports the state of the
+// provider's rate limit.
+type RateLimitMessage struct {
+	Limited  bool      // Requests are rejected until ResetsAt.
+	ResetsAt time.Time // Zero when unknown.
+}
+
+// Type implements Message.
+func (m *RateLimitMessage) Type() string { return "rate_limit" }
+
 // TextMessage is emitted when the agent produces text output.
 type TextMessage struct {
 	Text  string `json:"text"`
@@ -114,11 +135,34 @@ func (m *UserInputMessage) Type() string { return "user_input" }
 type ToolResultMessage struct {
 	ToolUseID string `json:"tool_use_id"`
 	Error     string `json:"error,omitempty"` // Non-empty when the tool reported an error.
+	// Output is the tail of the tool's text output, see TailOutput. Empty
+	// when the harness does not report it.
+	Output     string `json:"output,omitempty"`
+	ExitCode   *int   `json:"exit_code,omitempty"`   // Shell exit code, when reported by the harness.
+	DurationMs int64  `json:"duration_ms,omitempty"` // Execution time, when reported by the harness.
 }
 
 // Type implements Message.
 func (m *ToolResultMessage) Type() string { return "tool_result" }
 
+// MaxToolOutput bounds ToolResultMessage.Output.
+const MaxToolOutput = 2048
+
+// TailOutput returns the last MaxToolOutput bytes of s, cut on a rune
+// boundary.
+func TailOutput(s string) string {
+	if len(s) <= MaxToolOutput {
+		return s
+	}
+	s = s[len(s)-MaxToolOutput:]
+	for i := range min(len(s), utf8.UTFMax) {
+		if utf8.RuneStart(s[i]) {
+			return s[i:]
+		}
+	}
+	return s
+}
+
 // UsageMessage reports token consumption for a single API call.
 type UsageMessage struct {
 	Usage         Usage  `json:"usage"`
@@ -312,6 +356,7 @@ func (m *WidgetDeltaMessage) Type() string { return "widget_delta" }
 type RawMessage struct {
 	MessageType string
 	Raw         []byte
+	Unknown     bool // The parser doesn't know MessageType; see jsonutil.CountUnknownType.
 }
 
 // Type implements Message.
@@ -327,6 +372,16 @@ type ParseErrorMessage struct {
 // Type implements Message.
 func (m *ParseErrorMessage) Type() string { return "parse_error" }
 
+// StderrMessage is a line the agent or its relay wrote to stderr. It is not
+// part of the wire protocol and is not logged.
+type StderrMessage struct {
+	Source string // Process that wrote the line, e.g. "relay serve-attach".
+	Line   string
+}
+
+// Type implements Message.
+func (m *StderrMessage) Type() string { return "stderr" }
+
 // LogMessage is a provisioning/startup log line from the container backend.
 type LogMessage struct {
 	Line string
@@ -336,20 +391,50 @@ type LogMessage struct {
 func (m *LogMessage) Type() string { return "log" }
 
 // DiffStatMessage is emitted periodically by the relay's diff watcher thread
-// with the current in-container git diff stats.
+// with the current in-container git diff stats. caic also emits one after
+// each mutating tool result, recording the tool call and the branch HEAD it
+// produced.
 type DiffStatMessage struct {
 	MessageType string   `json:"type"`
 	DiffStat    DiffStat `json:"diff_stat"`
+	ToolUseID   string   `json:"tool_use_id,omitempty"` // Mutating tool call that triggered it; empty from the relay.
+	HeadSHA     string   `json:"head_sha,omitempty"`    // Task branch commit after the tool call; empty from the relay.
 }
 
 // Type implements Message.
 func (m *DiffStatMessage) Type() string { return "caic_diff_stat" }
 
+// WarningMessage is emitted by caic after a turn whose outcome looks
+// suspicious, e.g. tests deleted, so the user looks closer before syncing.
+// It is not persisted; the warnings are recomputed when the log is loaded.
+type WarningMessage struct {
+	Kind   string // See task.WarningKind.
+	Detail string
+}
+
+// Type implements Message.
+func (m *WarningMessage) Type() string { return "caic_warning" }
+
+// StateMessage is emitted by caic when a task changes state. It is only sent
+// to live subscribers; it is neither persisted nor kept in the history.
+type StateMessage struct {
+	From string // See task.State.
+	To   string
+	At   time.Time
+}
+
+// Type implements Message.
+func (m *StateMessage) Type() string { return "caic_state" }
+
 // MetaRepo describes one repository entry in a MetaMessage.
 type MetaRepo struct {
 	Name       string `json:"name"`
 	BaseBranch string `json:"base_branch,omitempty"`
 	Branch     string `json:"branch"`
+	BaseSHA    string `json:"base_sha,omitempty"` // Commit the branch was created from.
+	// SparsePaths lists the directories of a sparse checkout; empty means the
+	// full tree.
+	SparsePaths []string `json:"sparse_paths,omitempty"`
 }
 
 // MetaMessage is written as the first line of a JSONL log file. It captures
@@ -364,6 +449,20 @@ type MetaMessage struct {
 	Model       string     `json:"model,omitempty"`
 	StartedAt   time.Time  `json:"started_at"`
 	ForgeIssue  int        `json:"forge_issue,omitempty"` // Originating issue/PR number for bot comment callbacks.
+	ReplayOf    string     `json:"replay_of,omitempty"`   // ID of the task this one replays.
+	Kind        string     `json:"kind,omitempty"`        // Task kind; empty for coding tasks.
+	AutoResume  bool       `json:"auto_resume,omitempty"` // Continue turns interrupted by a relay loss.
+	// AskPolicy handles questions left unanswered; nil waits forever.
+	AskPolicy  *MetaAskPolicy `json:"ask_policy,omitempty"`
+	Automation string         `json:"automation,omitempty"` // Empty follows the working hours of the repo.
+	Owner      string         `json:"owner,omitempty"`      // Internal user ID of the creator; empty in no-auth mode.
+}
+
+// MetaAskPolicy is the policy applied to questions left unanswered.
+type MetaAskPolicy struct {
+	Timeout float64 `json:"timeout"` // Seconds.
+	Action  string  `json:"action"`
+	Answer  string  `json:"answer,omitempty"`
 }
 
 // Type implements Message.
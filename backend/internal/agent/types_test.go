@@ -0,0 +1,19 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTailOutput(t *testing.T) {
+	if got := TailOutput("short"); got != "short" {
+		t.Errorf("TailOutput(short) = %q", got)
+	}
+	// A multi-byte rune straddles the cut.
+	s := "é" + strings.Repeat("x", MaxToolOutput-1) + "end"
+	got := TailOutput(s)
+	if !utf8.ValidString(got) || !strings.HasSuffix(got, "end") || len(got) > MaxToolOutput {
+		t.Errorf("TailOutput = %d bytes, valid=%v", len(got), utf8.ValidString(got))
+	}
+}
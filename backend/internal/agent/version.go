@@ -0,0 +1,49 @@
+// Harness CLI packages and version pinning inside md containers.
+package agent
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Packages maps each harness to the npm package publishing its CLI. It is
+// used to look up the latest release.
+var Packages = map[Harness]string{
+	Claude: "@anthropic-ai/claude-code",
+	Codex:  "@openai/codex",
+	Gemini: "@google/gemini-cli",
+	Kilo:   "@kilocode/cli",
+}
+
+// versionRe matches a semver release like "1.0.98" or "0.41.0-alpha.3".
+var versionRe = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(?:[-+][0-9A-Za-z.-]+)?$`)
+
+// ValidVersion reports whether v is a harness CLI version that can be pinned.
+func ValidVersion(v string) bool {
+	return len(v) <= 64 && versionRe.MatchString(v)
+}
+
+// InstallScript returns a shell script that installs version of the harness
+// CLI in an md container unless it is already the active one. Claude Code is
+// installed by its native installer, the others with pnpm like the md image
+// does.
+func InstallScript(h Harness, version string) (string, error) {
+	if !ValidVersion(version) {
+		return "", fmt.Errorf("invalid %s version %q", h, version)
+	}
+	var bin, install string
+	switch h {
+	case Claude:
+		bin = "claude"
+		install = "claude install " + version
+	case Codex, Gemini, Kilo:
+		bin = string(h)
+		install = `PNPM_HOME="$HOME/.local/share/pnpm" pnpm add -g ` + Packages[h] + "@" + version
+	default:
+		return "", fmt.Errorf("version pinning is not supported for %s", h)
+	}
+	// The version is validated so it needs no quoting.
+	return ". ~/.profile >/dev/null 2>&1; " +
+		bin + " --version 2>/dev/null | grep -qwF -- " + version + " && exit 0; " +
+		install + " && " + bin + " --version", nil
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInstallScript(t *testing.T) {
+	t.Run("Claude", func(t *testing.T) {
+		got, err := InstallScript(Claude, "1.0.98")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(got, "claude install 1.0.98") {
+			t.Errorf("script = %q", got)
+		}
+	})
+	t.Run("Codex", func(t *testing.T) {
+		got, err := InstallScript(Codex, "0.41.0-alpha.3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(got, "pnpm add -g @openai/codex@0.41.0-alpha.3") {
+			t.Errorf("script = %q", got)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for _, v := range []string{"", "latest", "1.0", "1.0.0; rm -rf ~", "1.0.0 2.0.0"} {
+			if _, err := InstallScript(Claude, v); err == nil {
+				t.Errorf("InstallScript(%q) succeeded", v)
+			}
+		}
+	})
+	t.Run("UnknownHarness", func(t *testing.T) {
+		if _, err := InstallScript("fake", "1.0.0"); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
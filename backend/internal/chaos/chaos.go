@@ -0,0 +1,280 @@
+// Package chaos injects faults into the container and agent backends, so that
+// the recovery paths of task.Runner (reconnection, backoff, adoption, backups)
+// can be exercised on demand instead of waiting for production flakes.
+//
+// Faults are described by a spec like "fail=0.1,delay=2s,malformed=0.05,drop=0.01".
+// It is meant for tests and staging servers, never for production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/caic-xyz/md"
+)
+
+// ErrInjected is wrapped by the errors of the operations failed on purpose.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// malformedLine is the wire line reported for an injected malformed line.
+const malformedLine = `{"type":"assistant","message":{"content":[`
+
+// Config describes the faults to inject.
+type Config struct {
+	Delay     time.Duration // Maximum random delay added to each operation.
+	Fail      float64       // Probability that an operation fails with ErrInjected.
+	Malformed float64       // Probability that a malformed line precedes an agent message.
+	Drop      float64       // Probability that the relay connection drops after an agent message.
+	Seed      uint64        // Seed of the faults; 0 picks a random one.
+}
+
+// Parse parses a comma separated list of key=value faults: delay is a
+// duration, fail, malformed and drop are probabilities between 0 and 1, and
+// seed is an integer. It returns nil for an empty spec.
+func Parse(spec string) (*Config, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, nil
+	}
+	c := &Config{}
+	for item := range strings.SplitSeq(spec, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("chaos: %q is not key=value", item)
+		}
+		var err error
+		switch k {
+		case "delay":
+			c.Delay, err = time.ParseDuration(v)
+			if err == nil && c.Delay < 0 {
+				err = errors.New("negative")
+			}
+		case "fail":
+			c.Fail, err = parseProbability(v)
+		case "malformed":
+			c.Malformed, err = parseProbability(v)
+		case "drop":
+			c.Drop, err = parseProbability(v)
+		case "seed":
+			c.Seed, err = strconv.ParseUint(v, 10, 64)
+		default:
+			err = errors.New("unknown fault")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("chaos: %s=%s: %w", k, v, err)
+		}
+	}
+	return c, nil
+}
+
+func parseProbability(v string) (float64, error) {
+	p, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, err
+	}
+	if p < 0 || p > 1 {
+		return 0, errors.New("not between 0 and 1")
+	}
+	return p, nil
+}
+
+// Injector decides which faults to inject. It is safe for concurrent use.
+type Injector struct {
+	cfg Config
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New returns an Injector injecting the faults of cfg.
+func New(cfg *Config) *Injector {
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	slog.Warn("chaos", "delay", cfg.Delay, "fail", cfg.Fail, "malformed", cfg.Malformed, "drop", cfg.Drop, "seed", seed)
+	return &Injector{cfg: *cfg, rnd: rand.New(rand.NewPCG(seed, seed))} //nolint:gosec // Faults need to be reproducible, not unpredictable.
+}
+
+// Container wraps c to inject faults into its operations.
+func (i *Injector) Container(c task.ContainerBackend) task.ContainerBackend {
+	return &container{inner: c, inj: i}
+}
+
+// Backends returns a copy of m with each backend wrapped to inject faults
+// into its sessions.
+func (i *Injector) Backends(m map[agent.Harness]agent.Backend) map[agent.Harness]agent.Backend {
+	out := make(map[agent.Harness]agent.Backend, len(m))
+	for h, b := range m {
+		out[h] = &backend{Backend: b, inj: i}
+	}
+	return out
+}
+
+func (i *Injector) chance(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rnd.Float64() < p
+}
+
+// fault delays the operation op, then fails it at random.
+func (i *Injector) fault(ctx context.Context, op string) error {
+	if i.cfg.Delay > 0 {
+		i.mu.Lock()
+		d := time.Duration(i.rnd.Int64N(int64(i.cfg.Delay)))
+		i.mu.Unlock()
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+	if i.chance(i.cfg.Fail) {
+		slog.Info("chaos", "op", op, "fault", "fail")
+		return fmt.Errorf("%s: %w", op, ErrInjected)
+	}
+	return nil
+}
+
+// relay forwards the messages of s from in to out, injecting malformed lines
+// and dropping the connection at random, until s ends.
+func (i *Injector) relay(s *agent.Session, in <-chan agent.Message, out chan<- agent.Message) {
+	for {
+		select {
+		case msg := <-in:
+			if i.chance(i.cfg.Malformed) {
+				slog.Info("chaos", "op", "relay", "fault", "malformed")
+				out <- &agent.ParseErrorMessage{Err: ErrInjected.Error(), Line: malformedLine}
+			}
+			out <- msg
+			if i.chance(i.cfg.Drop) {
+				slog.Info("chaos", "op", "relay", "fault", "drop")
+				s.Drop()
+			}
+		case <-s.Done():
+			return
+		}
+	}
+}
+
+// container injects faults into a task.ContainerBackend.
+type container struct {
+	inner task.ContainerBackend
+	inj   *Injector
+}
+
+func (c *container) Launch(ctx context.Context, repos []md.Repo, labels []string, opts *task.StartOptions) error {
+	if err := c.inj.fault(ctx, "launch"); err != nil {
+		return err
+	}
+	return c.inner.Launch(ctx, repos, labels, opts)
+}
+
+func (c *container) Connect(ctx context.Context, repos []md.Repo, opts *task.StartOptions) (name, tailscaleFQDN string, err error) {
+	if err := c.inj.fault(ctx, "connect"); err != nil {
+		return "", "", err
+	}
+	return c.inner.Connect(ctx, repos, opts)
+}
+
+func (c *container) Diff(ctx context.Context, repo md.Repo, args ...string) (string, error) {
+	if err := c.inj.fault(ctx, "diff"); err != nil {
+		return "", err
+	}
+	return c.inner.Diff(ctx, repo, args...)
+}
+
+func (c *container) Fetch(ctx context.Context, repos []md.Repo) error {
+	if err := c.inj.fault(ctx, "fetch"); err != nil {
+		return err
+	}
+	return c.inner.Fetch(ctx, repos)
+}
+
+func (c *container) Stop(ctx context.Context, name string) error {
+	if err := c.inj.fault(ctx, "stop"); err != nil {
+		return err
+	}
+	return c.inner.Stop(ctx, name)
+}
+
+func (c *container) Purge(ctx context.Context, name string, repos []md.Repo) error {
+	if err := c.inj.fault(ctx, "purge"); err != nil {
+		return err
+	}
+	return c.inner.Purge(ctx, name, repos)
+}
+
+func (c *container) Revive(ctx context.Context, name string, repos []md.Repo) error {
+	if err := c.inj.fault(ctx, "revive"); err != nil {
+		return err
+	}
+	return c.inner.Revive(ctx, name, repos)
+}
+
+func (c *container) SparseCheckout(ctx context.Context, name string, repo md.Repo, paths []string) error {
+	if err := c.inj.fault(ctx, "sparse checkout"); err != nil {
+		return err
+	}
+	return c.inner.SparseCheckout(ctx, name, repo, paths)
+}
+
+func (c *container) Run(ctx context.Context, name, command string) error {
+	if err := c.inj.fault(ctx, "run"); err != nil {
+		return err
+	}
+	return c.inner.Run(ctx, name, command)
+}
+
+// backend injects faults into the sessions of an agent.Backend.
+type backend struct {
+	agent.Backend
+	inj *Injector
+}
+
+func (b *backend) Start(ctx context.Context, opts *agent.Options, msgCh chan<- agent.Message, logW io.Writer) (*agent.Session, error) {
+	if err := b.inj.fault(ctx, "start"); err != nil {
+		return nil, err
+	}
+	in := make(chan agent.Message)
+	s, err := b.Backend.Start(ctx, opts, in, logW)
+	if err != nil {
+		return nil, err
+	}
+	go b.inj.relay(s, in, msgCh)
+	return s, nil
+}
+
+func (b *backend) AttachRelay(ctx context.Context, opts *agent.Options, msgCh chan<- agent.Message, logW io.Writer) (*agent.Session, error) {
+	if err := b.inj.fault(ctx, "attach"); err != nil {
+		return nil, err
+	}
+	in := make(chan agent.Message)
+	s, err := b.Backend.AttachRelay(ctx, opts, in, logW)
+	if err != nil {
+		return nil, err
+	}
+	go b.inj.relay(s, in, msgCh)
+	return s, nil
+}
+
+func (b *backend) ReadRelayOutput(ctx context.Context, name string) ([]agent.Message, int64, error) {
+	if err := b.inj.fault(ctx, "read relay output"); err != nil {
+		return nil, 0, err
+	}
+	return b.Backend.ReadRelayOutput(ctx, name)
+}
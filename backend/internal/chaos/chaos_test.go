@@ -0,0 +1,181 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/caic-xyz/md"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		got, err := Parse("fail=0.1, delay=2s,malformed=0.05,drop=1,seed=42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Config{Delay: 2 * time.Second, Fail: 0.1, Malformed: 0.05, Drop: 1, Seed: 42}
+		if *got != want {
+			t.Errorf("got %+v, want %+v", *got, want)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		got, err := Parse(" ")
+		if got != nil || err != nil {
+			t.Errorf("got %v, %v", got, err)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for _, spec := range []string{"fail", "fail=2", "fail=x", "delay=-1s", "delay=1", "seed=-1", "boom=1"} {
+			if _, err := Parse(spec); err == nil {
+				t.Errorf("Parse(%q) succeeded", spec)
+			}
+		}
+	})
+}
+
+func TestContainer(t *testing.T) {
+	t.Run("Fail", func(t *testing.T) {
+		inner := &recordContainer{}
+		c := New(&Config{Fail: 1, Seed: 1}).Container(inner)
+		if err := c.Fetch(t.Context(), nil); !errors.Is(err, ErrInjected) {
+			t.Errorf("Fetch() = %v, want ErrInjected", err)
+		}
+		if _, _, err := c.Connect(t.Context(), nil, nil); !errors.Is(err, ErrInjected) {
+			t.Errorf("Connect() = %v, want ErrInjected", err)
+		}
+		if inner.calls != 0 {
+			t.Errorf("inner called %d times", inner.calls)
+		}
+	})
+	t.Run("PassThrough", func(t *testing.T) {
+		inner := &recordContainer{}
+		c := New(&Config{Delay: time.Millisecond, Seed: 1}).Container(inner)
+		if err := c.Fetch(t.Context(), nil); err != nil {
+			t.Fatal(err)
+		}
+		if name, _, err := c.Connect(t.Context(), nil, nil); err != nil || name != "ctr" {
+			t.Fatalf("Connect() = %q, %v", name, err)
+		}
+		if inner.calls != 2 {
+			t.Errorf("inner called %d times, want 2", inner.calls)
+		}
+	})
+	t.Run("Cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		c := New(&Config{Delay: time.Hour, Seed: 1}).Container(&recordContainer{})
+		if err := c.Stop(ctx, "ctr"); !errors.Is(err, context.Canceled) {
+			t.Errorf("Stop() = %v, want context.Canceled", err)
+		}
+	})
+}
+
+func TestBackend(t *testing.T) {
+	t.Run("Malformed", func(t *testing.T) {
+		inner := &pipeBackend{}
+		b := New(&Config{Malformed: 1, Seed: 1}).Backends(map[agent.Harness]agent.Backend{"pipe": inner})["pipe"]
+		msgCh := make(chan agent.Message, 4)
+		s, err := b.Start(t.Context(), &agent.Options{}, msgCh, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(inner.stdoutW, "hello\n"); err != nil {
+			t.Fatal(err)
+		}
+		if m, ok := (<-msgCh).(*agent.ParseErrorMessage); !ok || m.Line != malformedLine {
+			t.Errorf("first message = %#v, want the malformed line", m)
+		}
+		if m, ok := (<-msgCh).(*agent.TextMessage); !ok || m.Text != "hello" {
+			t.Errorf("second message = %#v, want hello", m)
+		}
+		_ = inner.stdoutW.Close()
+		<-s.Done()
+	})
+	t.Run("Drop", func(t *testing.T) {
+		inner := &pipeBackend{}
+		b := New(&Config{Drop: 1, Seed: 1}).Backends(map[agent.Harness]agent.Backend{"pipe": inner})["pipe"]
+		msgCh := make(chan agent.Message, 4)
+		s, err := b.Start(t.Context(), &agent.Options{}, msgCh, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(inner.stdoutW, "hello\n"); err != nil {
+			t.Fatal(err)
+		}
+		if m, ok := (<-msgCh).(*agent.TextMessage); !ok || m.Text != "hello" {
+			t.Errorf("message = %#v, want hello", m)
+		}
+		// Like the attach client, exit once stdin is closed.
+		stdin, err := io.ReadAll(inner.stdinR)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stdin) != 0 {
+			t.Errorf("stdin = %q, want no sentinel", stdin)
+		}
+		_ = inner.stdoutW.Close()
+		if _, err := s.Wait(); err == nil {
+			t.Error("session ended with a result")
+		}
+	})
+	t.Run("AttachFail", func(t *testing.T) {
+		b := New(&Config{Fail: 1, Seed: 1}).Backends(map[agent.Harness]agent.Backend{"pipe": &pipeBackend{}})["pipe"]
+		if _, err := b.AttachRelay(t.Context(), &agent.Options{}, nil, nil); !errors.Is(err, ErrInjected) {
+			t.Errorf("AttachRelay() = %v, want ErrInjected", err)
+		}
+	})
+}
+
+// recordContainer counts the calls of the task.ContainerBackend methods
+// used by the tests.
+type recordContainer struct {
+	task.ContainerBackend
+	calls int
+}
+
+func (c *recordContainer) Connect(context.Context, []md.Repo, *task.StartOptions) (name, tailscaleFQDN string, err error) {
+	c.calls++
+	return "ctr", "", nil
+}
+
+func (c *recordContainer) Fetch(context.Context, []md.Repo) error {
+	c.calls++
+	return nil
+}
+
+func (c *recordContainer) Stop(context.Context, string) error {
+	c.calls++
+	return nil
+}
+
+// pipeBackend starts sessions over in-process pipes; each stdout line is a
+// text message.
+type pipeBackend struct {
+	agent.Backend
+	stdinR  *io.PipeReader
+	stdoutW *io.PipeWriter
+}
+
+func (b *pipeBackend) Start(_ context.Context, _ *agent.Options, msgCh chan<- agent.Message, logW io.Writer) (*agent.Session, error) {
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	b.stdinR, b.stdoutW = stdinR, stdoutW
+	return agent.NewSession(nil, stdinW, stdoutR, msgCh, logW, lineWire{}, nil), nil
+}
+
+func (b *pipeBackend) AttachRelay(ctx context.Context, opts *agent.Options, msgCh chan<- agent.Message, logW io.Writer) (*agent.Session, error) {
+	return b.Start(ctx, opts, msgCh, logW)
+}
+
+type lineWire struct{}
+
+func (lineWire) WritePrompt(io.Writer, agent.Prompt, io.Writer) error { return nil }
+
+func (lineWire) ParseMessage(line []byte) ([]agent.Message, error) {
+	return []agent.Message{&agent.TextMessage{Text: string(line)}}, nil
+}
@@ -1,4 +1,4 @@
-// Generates typed TypeScript and Kotlin API clients plus API.md from the Go route declarations.
+// Generates typed TypeScript, Kotlin and Swift API clients plus API.md from the Go route declarations.
 package main
 
 import (
@@ -22,6 +22,7 @@ const (
 	sdkDir    = "../../../../../sdk"
 	tsDir     = sdkDir + "/ts/v1"
 	kotlinDir = sdkDir + "/kotlin/src/main/kotlin/com/caic/sdk/v1"
+	swiftDir  = sdkDir + "/swift/Sources/CaicSDK"
 )
 
 var pathParamRe = regexp.MustCompile(`\{(\w+)\}`)
@@ -47,6 +48,9 @@ func run() error {
 	if err := generateKotlin(kotlinDir); err != nil {
 		return err
 	}
+	if err := generateSwift(swiftDir); err != nil {
+		return err
+	}
 	return generateDoc(sdkDir)
 }
 
@@ -155,11 +159,14 @@ func writeTSJSONMethod(b *strings.Builder, r *v1.Route, params []string) {
 }
 
 func writeTSSSEMethod(b *strings.Builder, r *v1.Route, params []string) {
-	args := make([]string, 0, len(params)+1)
+	args := make([]string, 0, len(params)+len(r.QueryParams)+1)
 	for _, p := range params {
 		args = append(args, p+": string")
 	}
-	tsPath := buildTSPath(r.Path, params, nil)
+	for _, q := range r.QueryParams {
+		args = append(args, q+": string")
+	}
+	tsPath := buildTSPath(r.Path, params, r.QueryParams)
 	respName := r.RespName()
 	args = append(args, "onMessage: (event: "+respName+") => void")
 	fmt.Fprintf(b, "    %s: (%s): EventSource => {\n", r.Name, strings.Join(args, ", "))
@@ -222,31 +229,17 @@ var kotlinAliases = []kotlinTypeAlias{
 			{"Gemini", string(v1.HarnessGemini)},
 		},
 	},
-	{
-		name: "EventKind",
-		constants: []kotlinConstant{
-			{"Init", string(v1.EventKindInit)},
-			{"Text", string(v1.EventKindText)},
-			{"TextDelta", string(v1.EventKindTextDelta)},
-			{"ToolUse", string(v1.EventKindToolUse)},
-			{"ToolResult", string(v1.EventKindToolResult)},
-			{"Ask", string(v1.EventKindAsk)},
-			{"Usage", string(v1.EventKindUsage)},
-			{"Result", string(v1.EventKindResult)},
-			{"System", string(v1.EventKindSystem)},
-			{"UserInput", string(v1.EventKindUserInput)},
-			{"Todo", string(v1.EventKindTodo)},
-			{"DiffStat", string(v1.EventKindDiffStat)},
-			{"Thinking", string(v1.EventKindThinking)},
-			{"ThinkingDelta", string(v1.EventKindThinkingDelta)},
-			{"SubagentStart", string(v1.EventKindSubagentStart)},
-			{"SubagentEnd", string(v1.EventKindSubagentEnd)},
-			{"Log", string(v1.EventKindLog)},
-			{"ToolOutputDelta", string(v1.EventKindToolOutputDelta)},
-			{"Widget", string(v1.EventKindWidget)},
-			{"WidgetDelta", string(v1.EventKindWidgetDelta)},
-		},
-	},
+	{name: "EventKind", constants: eventKindConstants()},
+}
+
+// eventKindConstants returns the EventKind constants from the v1.EventKinds
+// registry.
+func eventKindConstants() []kotlinConstant {
+	out := make([]kotlinConstant, len(v1.EventKinds))
+	for i, k := range v1.EventKinds {
+		out[i] = kotlinConstant{strings.ToUpper(string(k.Kind[:1])) + string(k.Kind[1:]), string(k.Kind)}
+	}
+	return out
 }
 
 // Standalone type alias without constants.
@@ -260,6 +253,8 @@ var kotlinErrorCodes = []kotlinConstant{
 	{"BadRequest", string(dto.CodeBadRequest)},
 	{"NotFound", string(dto.CodeNotFound)},
 	{"Conflict", string(dto.CodeConflict)},
+	{"RateLimited", string(dto.CodeRateLimited)},
+	{"PolicyViolation", string(dto.CodePolicyViolation)},
 	{"InternalError", string(dto.CodeInternalError)},
 }
 
@@ -777,11 +772,14 @@ func writeKotlinJSONFunc(b *strings.Builder, r *v1.Route, params []string) {
 }
 
 func writeKotlinSSEFunc(b *strings.Builder, r *v1.Route, params []string) {
-	args := make([]string, 0, len(params))
+	args := make([]string, 0, len(params)+len(r.QueryParams))
 	for _, p := range params {
 		args = append(args, p+": String")
 	}
-	ktPath := buildKotlinPath(r.Path, nil)
+	for _, q := range r.QueryParams {
+		args = append(args, q+": String")
+	}
+	ktPath := buildKotlinPath(r.Path, r.QueryParams)
 	respName := r.RespName()
 	fmt.Fprintf(b, "    fun %s(%s): Flow<%s> = sseFlow<%s>(%s)\n", r.Name, strings.Join(args, ", "), respName, respName, ktPath)
 }
@@ -833,8 +831,11 @@ func extractPathParams(path string) []string {
 }
 
 // buildTSPath returns either a quoted string or a template literal for paths
-// with path params and/or query params.
+// with path params and/or query params. The path is relative so that it
+// resolves against the document's <base href>, which carries the server's
+// base path.
 func buildTSPath(path string, params, queryParams []string) string {
+	path = strings.TrimPrefix(path, "/")
 	if len(params) == 0 && len(queryParams) == 0 {
 		return fmt.Sprintf("%q", path)
 	}
@@ -901,8 +902,10 @@ func generateDoc(outDir string) error {
 	b.WriteString("| HTTP | Code |\n")
 	b.WriteString("|------|------|\n")
 	b.WriteString("| 400 | `BAD_REQUEST` |\n")
+	b.WriteString("| 403 | `POLICY_VIOLATION` |\n")
 	b.WriteString("| 404 | `NOT_FOUND` |\n")
 	b.WriteString("| 409 | `CONFLICT` |\n")
+	b.WriteString("| 429 | `RATE_LIMITED` |\n")
 	b.WriteString("| 500 | `INTERNAL_ERROR` |\n\n")
 
 	// Types section.
@@ -0,0 +1,475 @@
+// Swift client generation: Codable types and an async/await ApiClient with SSE streams.
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// swiftField holds parsed information about a single struct field for Swift
+// code generation.
+type swiftField struct {
+	name     string // Property and init parameter name, backquoted when it is a keyword.
+	swType   string // Swift type (e.g. "String", "[Foo]").
+	nullable bool   // Whether the property is Optional.
+}
+
+// swiftKeywords are the JSON names that must be backquoted as Swift
+// identifiers.
+var swiftKeywords = map[string]bool{
+	"as": true, "associatedtype": true, "break": true, "case": true, "catch": true, "class": true,
+	"continue": true, "default": true, "defer": true, "deinit": true, "do": true, "else": true,
+	"enum": true, "extension": true, "fallthrough": true, "false": true, "fileprivate": true,
+	"for": true, "func": true, "guard": true, "if": true, "import": true, "in": true, "init": true,
+	"inout": true, "internal": true, "is": true, "let": true, "nil": true, "open": true,
+	"operator": true, "private": true, "protocol": true, "public": true, "repeat": true,
+	"rethrows": true, "return": true, "self": true, "static": true, "struct": true, "subscript": true,
+	"super": true, "switch": true, "throw": true, "throws": true, "true": true, "try": true,
+	"typealias": true, "var": true, "where": true, "while": true,
+}
+
+// swiftIdent returns name as a Swift identifier.
+func swiftIdent(name string) string {
+	if swiftKeywords[name] {
+		return "`" + name + "`"
+	}
+	return name
+}
+
+// swiftConstName lowercases the first letter of a Kotlin constant name, e.g.
+// "TextDelta" → "textDelta".
+func swiftConstName(name string) string {
+	return strings.ToLower(name[:1]) + name[1:]
+}
+
+// generateSwift generates Types.swift and ApiClient.swift in outDir.
+func generateSwift(outDir string) error {
+	if err := os.MkdirAll(outDir, 0o750); err != nil {
+		return err
+	}
+	if err := writeSwiftTypes(outDir); err != nil {
+		return err
+	}
+	return writeSwiftClient(outDir)
+}
+
+// goTypeToSwift maps a Go reflect.Type to its Swift type string.
+func goTypeToSwift(t reflect.Type) string {
+	// Unwrap pointer — nullability is handled by the caller.
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	// Special cases.
+	switch t {
+	case jsonRawMessageType:
+		return "JSONValue"
+	case ksidIDType:
+		return "String"
+	case timeType:
+		return "String" // Go marshals time.Time as ISO 8601 string.
+	case diffStatType:
+		return "[DiffFileStat]"
+	case mapStringAnyType:
+		return "[String: JSONValue]"
+	}
+
+	// Named string aliases (Harness, EventKind).
+	if name, ok := kotlinAliasNames[t]; ok {
+		return name
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "String"
+	case reflect.Int:
+		return "Int"
+	case reflect.Int64:
+		return "Int64"
+	case reflect.Float64:
+		return "Double"
+	case reflect.Bool:
+		return "Bool"
+	case reflect.Slice:
+		return "[" + goTypeToSwift(t.Elem()) + "]"
+	case reflect.Map:
+		return "[" + goTypeToSwift(t.Key()) + ": " + goTypeToSwift(t.Elem()) + "]"
+	case reflect.Struct:
+		return t.Name()
+	default:
+		return t.Name()
+	}
+}
+
+// parseSwiftFields extracts swiftField entries from a reflect.Type. The
+// nullability rules match the Kotlin client.
+func parseSwiftFields(t reflect.Type) []swiftField {
+	fields := make([]swiftField, 0, t.NumField())
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		jsonName, opts := parseJSONTag(tag)
+		if jsonName == "" {
+			jsonName = sf.Name
+		}
+		omit := opts.contains("omitempty") || opts.contains("omitzero")
+		fields = append(fields, swiftField{
+			name:     swiftIdent(jsonName),
+			swType:   goTypeToSwift(sf.Type),
+			nullable: sf.Type.Kind() == reflect.Ptr || omit,
+		})
+	}
+	return fields
+}
+
+// emitSwiftStruct writes a Codable struct with a public memberwise init to b.
+func emitSwiftStruct(b *strings.Builder, t reflect.Type) {
+	fields := parseSwiftFields(t)
+	fmt.Fprintf(b, "public struct %s: Codable, Sendable {\n", t.Name())
+	for _, f := range fields {
+		if f.nullable {
+			fmt.Fprintf(b, "    public var %s: %s?\n", f.name, f.swType)
+		} else {
+			fmt.Fprintf(b, "    public var %s: %s\n", f.name, f.swType)
+		}
+	}
+	if len(fields) > 0 {
+		b.WriteString("\n")
+	}
+	args := make([]string, len(fields))
+	for i, f := range fields {
+		if f.nullable {
+			args[i] = f.name + ": " + f.swType + "? = nil"
+		} else {
+			args[i] = f.name + ": " + f.swType
+		}
+	}
+	fmt.Fprintf(b, "    public init(%s) {\n", strings.Join(args, ", "))
+	for _, f := range fields {
+		fmt.Fprintf(b, "        self.%s = %s\n", f.name, f.name)
+	}
+	b.WriteString("    }\n")
+	b.WriteString("}\n")
+}
+
+// swiftJSONValue is an arbitrary JSON value, used for json.RawMessage and
+// map[string]any fields.
+const swiftJSONValue = `public enum JSONValue: Codable, Sendable, Equatable {
+    case null
+    case bool(Bool)
+    case number(Double)
+    case string(String)
+    case array([JSONValue])
+    case object([String: JSONValue])
+
+    public init(from decoder: Decoder) throws {
+        let c = try decoder.singleValueContainer()
+        if c.decodeNil() {
+            self = .null
+        } else if let v = try? c.decode(Bool.self) {
+            self = .bool(v)
+        } else if let v = try? c.decode(Double.self) {
+            self = .number(v)
+        } else if let v = try? c.decode(String.self) {
+            self = .string(v)
+        } else if let v = try? c.decode([JSONValue].self) {
+            self = .array(v)
+        } else {
+            self = .object(try c.decode([String: JSONValue].self))
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var c = encoder.singleValueContainer()
+        switch self {
+        case .null: try c.encodeNil()
+        case .bool(let v): try c.encode(v)
+        case .number(let v): try c.encode(v)
+        case .string(let v): try c.encode(v)
+        case .array(let v): try c.encode(v)
+        case .object(let v): try c.encode(v)
+        }
+    }
+}
+
+`
+
+func writeSwiftTypes(outDir string) error {
+	var b strings.Builder
+	b.WriteString("// Code generated by gen-api-sdk. DO NOT EDIT.\n")
+	b.WriteString("import Foundation\n\n")
+	b.WriteString(swiftJSONValue)
+
+	// Type aliases with namespaces holding the constants.
+	for _, a := range kotlinAliases {
+		fmt.Fprintf(&b, "public typealias %s = String\n\n", a.name)
+		fmt.Fprintf(&b, "public enum %s {\n", kotlinPlural(a.name))
+		for _, c := range a.constants {
+			fmt.Fprintf(&b, "    public static let %s: %s = %q\n", swiftConstName(c.name), a.name, c.value)
+		}
+		b.WriteString("}\n\n")
+	}
+
+	// Error codes.
+	b.WriteString("public enum ErrorCodes {\n")
+	for _, c := range kotlinErrorCodes {
+		fmt.Fprintf(&b, "    public static let %s = %q\n", swiftConstName(c.name), c.value)
+	}
+	b.WriteString("}\n\n")
+
+	// Structs: the same set and order as the Kotlin client.
+	for _, ks := range discoverKotlinStructs() {
+		if ks.comment != "" {
+			fmt.Fprintf(&b, "// %s\n\n", ks.comment)
+		}
+		emitSwiftStruct(&b, ks.t)
+		b.WriteString("\n")
+	}
+
+	return os.WriteFile(filepath.Join(outDir, "Types.swift"), []byte(b.String()), 0o600)
+}
+
+func writeSwiftClient(outDir string) error {
+	var b strings.Builder
+
+	// Static header. The DTO named Task shadows the concurrency Task, hence
+	// the explicit _Concurrency module.
+	b.WriteString(`// Code generated by gen-api-sdk. DO NOT EDIT.
+import Foundation
+
+public struct APIError: Error, Sendable {
+    public let statusCode: Int
+    public let code: String
+    public let message: String
+    public let details: [String: JSONValue]?
+}
+
+public final class ApiClient: Sendable {
+    private let baseURL: String
+    private let session: URLSession
+    private let tokenProvider: (@Sendable () -> String?)?
+
+    public init(baseURL: String, session: URLSession = .shared, tokenProvider: (@Sendable () -> String?)? = nil) {
+        var url = baseURL
+        while url.hasSuffix("/") {
+            url.removeLast()
+        }
+        self.baseURL = url
+        self.session = session
+        self.tokenProvider = tokenProvider
+    }
+
+    private static let unreserved = CharacterSet(charactersIn: "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-._~")
+
+    private static func escape(_ s: String) -> String {
+        s.addingPercentEncoding(withAllowedCharacters: unreserved) ?? s
+    }
+
+    private func makeRequest(_ method: String, _ path: String) throws -> URLRequest {
+        guard let url = URL(string: baseURL + path) else {
+            throw URLError(.badURL)
+        }
+        var req = URLRequest(url: url)
+        req.httpMethod = method
+        req.setValue("application/json", forHTTPHeaderField: "Content-Type")
+        if let token = tokenProvider?() {
+            req.setValue("Bearer \(token)", forHTTPHeaderField: "Authorization")
+        }
+        return req
+    }
+
+    private func request<T: Decodable>(_ method: String, _ path: String) async throws -> T {
+        try await send(try makeRequest(method, path))
+    }
+
+    private func request<T: Decodable, B: Encodable>(_ method: String, _ path: String, body: B) async throws -> T {
+        var req = try makeRequest(method, path)
+        req.httpBody = try JSONEncoder().encode(body)
+        return try await send(req)
+    }
+
+    private func send<T: Decodable>(_ req: URLRequest) async throws -> T {
+        let (data, resp) = try await session.data(for: req)
+        let status = (resp as? HTTPURLResponse)?.statusCode ?? 0
+        guard (200..<300).contains(status) else {
+            if let err = try? JSONDecoder().decode(ErrorResponse.self, from: data) {
+                throw APIError(statusCode: status, code: err.error.code, message: err.error.message, details: err.details)
+            }
+            throw APIError(statusCode: status, code: "UNKNOWN", message: String(decoding: data, as: UTF8.self), details: nil)
+        }
+        return try JSONDecoder().decode(T.self, from: data)
+    }
+
+`)
+
+	b.WriteString("    // JSON endpoints\n")
+	for i := range v1.Routes {
+		r := &v1.Routes[i]
+		if r.IsSSE {
+			continue
+		}
+		writeSwiftJSONFunc(&b, r, extractPathParams(r.Path))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("    // SSE endpoints\n")
+	for i := range v1.Routes {
+		r := &v1.Routes[i]
+		if !r.IsSSE {
+			continue
+		}
+		writeSwiftSSEFunc(&b, r, extractPathParams(r.Path))
+	}
+	b.WriteString("\n")
+
+	// sseStream helper. The server sends each event as a single data line.
+	b.WriteString(`    private func sseStream<T: Decodable & Sendable>(_ path: String) -> AsyncThrowingStream<T, Error> {
+        AsyncThrowingStream { continuation in
+            let task = _Concurrency.Task {
+                do {
+                    var req = try makeRequest("GET", path)
+                    req.setValue("text/event-stream", forHTTPHeaderField: "Accept")
+                    let (bytes, resp) = try await session.bytes(for: req)
+                    let status = (resp as? HTTPURLResponse)?.statusCode ?? 0
+                    guard (200..<300).contains(status) else {
+                        throw APIError(statusCode: status, code: "UNKNOWN", message: "SSE connection failed", details: nil)
+                    }
+                    let decoder = JSONDecoder()
+                    for try await line in bytes.lines {
+                        guard line.hasPrefix("data:") else {
+                            continue
+                        }
+                        var data = line.dropFirst(5)
+                        if data.hasPrefix(" ") {
+                            data = data.dropFirst()
+                        }
+                        // Skip malformed events.
+                        if let event = try? decoder.decode(T.self, from: Data(data.utf8)) {
+                            continuation.yield(event)
+                        }
+                    }
+                    continuation.finish()
+                } catch {
+                    continuation.finish(throwing: error)
+                }
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+
+    // Reconnecting SSE wrappers with exponential backoff.
+`)
+
+	for i := range v1.Routes {
+		r := &v1.Routes[i]
+		if !r.IsSSE {
+			continue
+		}
+		writeSwiftReconnectingFunc(&b, r, extractPathParams(r.Path))
+	}
+	b.WriteString("\n")
+
+	b.WriteString(`    private func reconnectingStream<T: Sendable>(_ connect: @escaping @Sendable () -> AsyncThrowingStream<T, Error>) -> AsyncStream<T> {
+        AsyncStream { continuation in
+            let task = _Concurrency.Task {
+                var delayMs: UInt64 = 500
+                while !_Concurrency.Task.isCancelled {
+                    do {
+                        for try await event in connect() {
+                            delayMs = 500
+                            continuation.yield(event)
+                        }
+                    } catch {
+                        // Reconnect below.
+                    }
+                    try? await _Concurrency.Task.sleep(nanoseconds: delayMs * 1_000_000)
+                    delayMs = min(delayMs * 3 / 2, 4000)
+                }
+                continuation.finish()
+            }
+            continuation.onTermination = { _ in task.cancel() }
+        }
+    }
+}
+`)
+
+	return os.WriteFile(filepath.Join(outDir, "ApiClient.swift"), []byte(b.String()), 0o600)
+}
+
+// swiftArgs returns the function parameters for the path and query
+// parameters.
+func swiftArgs(params, queryParams []string) []string {
+	args := make([]string, 0, len(params)+len(queryParams)+1)
+	for _, p := range params {
+		args = append(args, p+": String")
+	}
+	for _, q := range queryParams {
+		args = append(args, q+": String")
+	}
+	return args
+}
+
+func writeSwiftJSONFunc(b *strings.Builder, r *v1.Route, params []string) {
+	respType := r.RespName()
+	if r.IsArray {
+		respType = "[" + respType + "]"
+	}
+	args := swiftArgs(params, r.QueryParams)
+	path := buildSwiftPath(r.Path, r.QueryParams)
+	if r.Req != nil {
+		args = append(args, "_ req: "+r.ReqName())
+		fmt.Fprintf(b, "    public func %s(%s) async throws -> %s { try await request(%q, %s, body: req) }\n", r.Name, strings.Join(args, ", "), respType, r.Method, path)
+	} else {
+		fmt.Fprintf(b, "    public func %s(%s) async throws -> %s { try await request(%q, %s) }\n", r.Name, strings.Join(args, ", "), respType, r.Method, path)
+	}
+}
+
+func writeSwiftSSEFunc(b *strings.Builder, r *v1.Route, params []string) {
+	args := swiftArgs(params, r.QueryParams)
+	path := buildSwiftPath(r.Path, r.QueryParams)
+	fmt.Fprintf(b, "    public func %s(%s) -> AsyncThrowingStream<%s, Error> { sseStream(%s) }\n", r.Name, strings.Join(args, ", "), r.RespName(), path)
+}
+
+func writeSwiftReconnectingFunc(b *strings.Builder, r *v1.Route, params []string) {
+	allParams := append(params, r.QueryParams...) //nolint:gocritic // concat is intentional
+	callArgs := make([]string, len(allParams))
+	for i, p := range allParams {
+		callArgs[i] = p + ": " + p
+	}
+	fmt.Fprintf(b, "    public func %sReconnecting(%s) -> AsyncStream<%s> { reconnectingStream { [self] in self.%s(%s) } }\n",
+		r.Name, strings.Join(swiftArgs(params, r.QueryParams), ", "), r.RespName(), r.Name, strings.Join(callArgs, ", "))
+}
+
+// buildSwiftPath returns a Swift string literal for the path, interpolating
+// the escaped path and query parameters.
+func buildSwiftPath(path string, queryParams []string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	b.WriteString(pathParamRe.ReplaceAllStringFunc(path, func(match string) string {
+		return `\(Self.escape(` + match[1:len(match)-1] + `))`
+	}))
+	for i, q := range queryParams {
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(q)
+		b.WriteString(`=\(Self.escape(`)
+		b.WriteString(q)
+		b.WriteString("))")
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -8,7 +8,10 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/caic-xyz/md"
 )
@@ -24,21 +27,205 @@ func New(tailscaleAPIKey string) (*md.Client, error) {
 	return c, nil
 }
 
-// LabelValue returns the value of a Docker label on a running container.
-//
-// Returns empty string if the label is not set.
-func LabelValue(ctx context.Context, containerName, label string) (string, error) {
-	format := fmt.Sprintf("{{index .Config.Labels %q}}", label)
-	cmd := exec.CommandContext(ctx, "docker", "inspect", containerName, "--format", format) //nolint:gosec // containerName and format are not user-controlled.
+// Docker labels caic sets on the containers of its tasks, next to the "md.*"
+// labels md sets.
+const (
+	LabelTask      = "caic"           // Task ID; proves caic started the container.
+	LabelHarness   = "harness"        // Harness name.
+	LabelGPU       = "gpu"            // "1" when the task uses a GPU.
+	LabelPriority  = "priority"       // Scheduling priority; omitted when normal.
+	LabelRepo      = "caic.repo"      // Primary repo; omitted for tasks without one.
+	LabelBranch    = "caic.branch"    // Branch of the primary repo.
+	LabelOwner     = "caic.owner"     // Internal user ID of the creator; omitted in no-auth mode.
+	LabelCreatedAt = "caic.createdAt" // Task creation time, RFC 3339.
+)
+
+// Meta is the task metadata stored in the labels of a container.
+type Meta struct {
+	TaskID    string
+	Harness   string
+	GPU       bool
+	Priority  string
+	Repo      string
+	Branch    string
+	Owner     string
+	CreatedAt time.Time
+}
+
+// Labels returns m as "key=value" Docker labels, skipping the empty fields.
+func (m *Meta) Labels() []string {
+	var out []string
+	add := func(k, v string) {
+		if v != "" {
+			out = append(out, k+"="+v)
+		}
+	}
+	add(LabelTask, m.TaskID)
+	add(LabelHarness, m.Harness)
+	if m.GPU {
+		add(LabelGPU, "1")
+	}
+	add(LabelPriority, m.Priority)
+	add(LabelRepo, m.Repo)
+	add(LabelBranch, m.Branch)
+	add(LabelOwner, m.Owner)
+	if !m.CreatedAt.IsZero() {
+		add(LabelCreatedAt, m.CreatedAt.UTC().Format(time.RFC3339))
+	}
+	return out
+}
+
+// ParseMeta returns the task metadata in the Docker labels of a container.
+// Containers started before a label was introduced lack it; its field is
+// left empty.
+func ParseMeta(labels map[string]string) Meta {
+	m := Meta{
+		TaskID:   labels[LabelTask],
+		Harness:  labels[LabelHarness],
+		GPU:      labels[LabelGPU] == "1",
+		Priority: labels[LabelPriority],
+		Repo:     labels[LabelRepo],
+		Branch:   labels[LabelBranch],
+		Owner:    labels[LabelOwner],
+	}
+	if t, err := time.Parse(time.RFC3339, labels[LabelCreatedAt]); err == nil {
+		m.CreatedAt = t
+	}
+	return m
+}
+
+// Labels returns the Docker labels of a container.
+func Labels(ctx context.Context, containerName string) (map[string]string, error) {
+	cmd := exec.CommandContext(ctx, "docker", "inspect", containerName, "--format", "{{json .Config.Labels}}") //nolint:gosec // containerName is not user-controlled.
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker inspect labels on %s: %w", containerName, err)
+	}
+	var labels map[string]string
+	if err := json.Unmarshal(out, &labels); err != nil {
+		return nil, fmt.Errorf("docker inspect labels on %s: %w", containerName, err)
+	}
+	return labels, nil
+}
+
+// Info is a container started by caic.
+type Info struct {
+	Name      string
+	State     string    // Docker state, e.g. "running" or "exited".
+	CreatedAt time.Time // Container creation, which may postdate the task's on revival.
+	Meta      Meta
+}
+
+// List returns the containers carrying the caic label, running or not.
+func List(ctx context.Context) ([]Info, error) {
+	out, err := exec.CommandContext(ctx, "docker", "ps", "--all", "--quiet", "--no-trunc", "--filter", "label="+LabelTask).Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker ps: %w", err)
+	}
+	ids := strings.Fields(string(out))
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	if out, err = exec.CommandContext(ctx, "docker", append([]string{"inspect"}, ids...)...).Output(); err != nil { //nolint:gosec // ids come from docker.
+		return nil, fmt.Errorf("docker inspect: %w", err)
+	}
+	return parseInspect(out)
+}
+
+// parseInspect parses the output of the docker inspect call of List.
+func parseInspect(out []byte) ([]Info, error) {
+	var raw []struct {
+		Name    string
+		Created time.Time
+		State   struct{ Status string }
+		Config  struct{ Labels map[string]string }
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("docker inspect: %w", err)
+	}
+	infos := make([]Info, 0, len(raw))
+	for _, r := range raw {
+		infos = append(infos, Info{
+			Name:      strings.TrimPrefix(r.Name, "/"),
+			State:     r.State.Status,
+			CreatedAt: r.Created,
+			Meta:      ParseMeta(r.Config.Labels),
+		})
+	}
+	return infos, nil
+}
+
+// Image returns the image reference a container was created from and the
+// image ID, a content digest like "sha256:...".
+func Image(ctx context.Context, containerName string) (ref, id string, err error) {
+	cmd := exec.CommandContext(ctx, "docker", "inspect", containerName, "--format", "{{.Config.Image}} {{.Image}}") //nolint:gosec // containerName is not user-controlled.
 	out, err := cmd.Output()
 	if err != nil {
-		return "", fmt.Errorf("docker inspect label %q on %s: %w", label, containerName, err)
+		return "", "", fmt.Errorf("docker inspect image of %s: %w", containerName, err)
+	}
+	ref, id, _ = strings.Cut(strings.TrimSpace(string(out)), " ")
+	return ref, id, nil
+}
+
+// ImageAvailable returns nil if the image ref is present locally or can be
+// pulled from its registry.
+func ImageAvailable(ctx context.Context, ref string) error {
+	if err := exec.CommandContext(ctx, "docker", "image", "inspect", "--format", "{{.Id}}", ref).Run(); err == nil { //nolint:gosec // ref is validated by the caller.
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, imageCheckTimeout)
+	defer cancel()
+	if out, err := exec.CommandContext(ctx, "docker", "manifest", "inspect", ref).CombinedOutput(); err != nil { //nolint:gosec // ref is validated by the caller.
+		return fmt.Errorf("docker manifest inspect %s: %w: %s", ref, err, strings.TrimSpace(string(out)))
 	}
-	v := strings.TrimSpace(string(out))
-	if v == "<no value>" {
-		return "", nil
+	return nil
+}
+
+// imageCheckTimeout bounds the registry lookup of ImageAvailable.
+const imageCheckTimeout = 30 * time.Second
+
+// LocalImage is an image present in the local docker image store.
+type LocalImage struct {
+	ID   string
+	Tags []string // e.g. "ghcr.io/caic-xyz/md-root:latest"
+	Size int64    // Bytes, including layers shared with other images.
+}
+
+// LocalImages lists the images in the local docker image store.
+func LocalImages(ctx context.Context) ([]LocalImage, error) {
+	out, err := exec.CommandContext(ctx, "docker", "images", "--quiet", "--no-trunc").Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker images: %w", err)
+	}
+	ids := strings.Fields(string(out))
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := append([]string{"image", "inspect", "--format", "{{.Id}} {{.Size}} {{join .RepoTags \" \"}}"}, ids...)
+	if out, err = exec.CommandContext(ctx, "docker", args...).Output(); err != nil { //nolint:gosec // ids come from docker.
+		return nil, fmt.Errorf("docker image inspect: %w", err)
+	}
+	return parseLocalImages(string(out)), nil
+}
+
+// parseLocalImages parses the output of the docker image inspect call of
+// LocalImages.
+func parseLocalImages(out string) []LocalImage {
+	var images []LocalImage
+	for line := range strings.Lines(out) {
+		f := strings.Fields(line)
+		if len(f) < 2 {
+			continue
+		}
+		size, err := strconv.ParseInt(f[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		images = append(images, LocalImage{ID: f[0], Size: size, Tags: f[2:]})
 	}
-	return v, nil
+	return images
 }
 
 // Event represents a Docker container lifecycle event.
@@ -2,7 +2,10 @@ package container
 
 import (
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNew(t *testing.T) {
@@ -83,3 +86,100 @@ func TestBranchFromContainer(t *testing.T) {
 		})
 	}
 }
+
+func TestParseLocalImages(t *testing.T) {
+	out := "sha256:aa 1024 ghcr.io/caic-xyz/md-root:latest ghcr.io/caic-xyz/md-root:v1\n" +
+		"sha256:bb 2048 \n" +
+		"garbage\n"
+	got := parseLocalImages(out)
+	if len(got) != 2 {
+		t.Fatalf("got %d images, want 2: %+v", len(got), got)
+	}
+	if got[0].ID != "sha256:aa" || got[0].Size != 1024 || len(got[0].Tags) != 2 || got[0].Tags[1] != "ghcr.io/caic-xyz/md-root:v1" {
+		t.Errorf("image 0 = %+v", got[0])
+	}
+	if got[1].Size != 2048 || len(got[1].Tags) != 0 {
+		t.Errorf("image 1 = %+v", got[1])
+	}
+}
+
+func TestMeta(t *testing.T) {
+	m := Meta{TaskID: "abc", Harness: "codex", GPU: true, Repo: "org/repo", Branch: "caic-3", CreatedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	labels := m.Labels()
+	want := []string{"caic=abc", "harness=codex", "gpu=1", "caic.repo=org/repo", "caic.branch=caic-3", "caic.createdAt=2026-03-01T12:00:00Z"}
+	if !slices.Equal(labels, want) {
+		t.Fatalf("Labels() = %q, want %q", labels, want)
+	}
+	parsed := map[string]string{}
+	for _, l := range labels {
+		k, v, _ := strings.Cut(l, "=")
+		parsed[k] = v
+	}
+	if got := ParseMeta(parsed); got != m {
+		t.Errorf("ParseMeta() = %+v, want %+v", got, m)
+	}
+	// Containers of older versions only have the caic and harness labels.
+	if got := ParseMeta(map[string]string{"caic": "abc", "harness": "claude"}); got != (Meta{TaskID: "abc", Harness: "claude"}) {
+		t.Errorf("ParseMeta(old) = %+v", got)
+	}
+}
+
+func TestParseInspect(t *testing.T) {
+	out := `[{"Name":"/md-caic-caic-3","Created":"2026-03-01T12:00:01.5Z","State":{"Status":"exited"},` +
+		`"Config":{"Labels":{"caic":"abc","caic.branch":"caic-3","md.usb":"1"}}}]`
+	got, err := parseInspect([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("got %d containers", len(got))
+	}
+	c := got[0]
+	if c.Name != "md-caic-caic-3" || c.State != "exited" || c.CreatedAt.UnixMilli() != time.Date(2026, 3, 1, 12, 0, 1, 5e8, time.UTC).UnixMilli() {
+		t.Errorf("container = %+v", c)
+	}
+	if c.Meta.TaskID != "abc" || c.Meta.Branch != "caic-3" {
+		t.Errorf("meta = %+v", c.Meta)
+	}
+	if _, err := parseInspect([]byte("not json")); err == nil {
+		t.Error("parseInspect succeeded on garbage")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+		ok   bool
+	}{
+		{"24.0.7", "23.0", 1, true},
+		{"23.0.0", "23.0", 0, true},
+		{"20.10.5+dfsg1", "20.10", 1, true},
+		{"20.10", "20.10.0", 0, true},
+		{"20.10.5+dfsg1", "23.0", -1, true},
+		{"v4.9.3", "4.2", 1, true},
+		{"dev", "23.0", 0, false},
+	} {
+		got, ok := compareVersions(tc.a, tc.b)
+		if ok != tc.ok || (got > 0) != (tc.want > 0) || (got < 0) != (tc.want < 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, %v", tc.a, tc.b, got, ok)
+		}
+	}
+	old := &Capabilities{Runtime: "docker", Version: "20.10.24"}
+	if err := old.Check(FeatureRegistryCheck); err != nil {
+		t.Error(err)
+	}
+	err := old.Check(FeatureBuild)
+	if err == nil || err.Error() != "docker >= 23.0 required for image builds, found 20.10.24; upgrade docker" {
+		t.Errorf("Check(FeatureBuild) = %v", err)
+	}
+	if got := old.Missing(); !slices.Equal(got, []Feature{FeatureBuild}) {
+		t.Errorf("Missing() = %v", got)
+	}
+	// Unknown runtimes, versions and capabilities gate nothing.
+	for _, c := range []*Capabilities{nil, {Runtime: "docker", Version: "dev"}, {Runtime: "nerdctl", Version: "1.0"}} {
+		if err := c.Check(FeatureBuild); err != nil || len(c.Missing()) != 0 {
+			t.Errorf("%+v: Check() = %v, Missing() = %v", c, err, c.Missing())
+		}
+	}
+}
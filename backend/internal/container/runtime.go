@@ -0,0 +1,142 @@
+// Container runtime version detection and the features that depend on it.
+package container
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feature is a capability of the container runtime that depends on its
+// version.
+type Feature string
+
+// Features gated on the runtime version.
+const (
+	FeatureBuild         Feature = "image builds"    // md builds task images with "build --build-context".
+	FeatureRegistryCheck Feature = "registry checks" // "manifest inspect" outside experimental mode.
+)
+
+// minVersions are the oldest runtime versions supporting each feature, per
+// runtime.
+var minVersions = map[string]map[Feature]string{
+	"docker": {FeatureBuild: "23.0", FeatureRegistryCheck: "20.10"},
+	"podman": {FeatureBuild: "4.2", FeatureRegistryCheck: "1.0"},
+}
+
+// Capabilities describes the container runtime md drives.
+type Capabilities struct {
+	Runtime string // "docker" or "podman".
+	Version string // Server version, e.g. "27.3.1"; empty if unknown.
+}
+
+// DetectCapabilities queries the version of the runtime rt.
+func DetectCapabilities(ctx context.Context, rt string) (*Capabilities, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	format := "{{.Server.Version}}"
+	if rt == "podman" {
+		format = "{{.Version}}"
+	}
+	out, err := exec.CommandContext(ctx, rt, "version", "--format", format).Output() //nolint:gosec // rt is detected by md.
+	if err != nil {
+		return nil, fmt.Errorf("%s version: %w", rt, err)
+	}
+	return &Capabilities{Runtime: rt, Version: strings.TrimSpace(string(out))}, nil
+}
+
+// Check returns an actionable error if the runtime is too old for f. An
+// unknown runtime or version passes: the runtime then reports its own errors.
+func (c *Capabilities) Check(f Feature) error {
+	if c == nil {
+		return nil
+	}
+	minVersion := minVersions[c.Runtime][f]
+	if minVersion == "" {
+		return nil
+	}
+	if cmp, ok := compareVersions(c.Version, minVersion); !ok || cmp >= 0 {
+		return nil
+	}
+	return fmt.Errorf("%s >= %s required for %s, found %s; upgrade %s", c.Runtime, minVersion, f, c.Version, c.Runtime)
+}
+
+// Missing returns the features the runtime is too old for, sorted.
+func (c *Capabilities) Missing() []Feature {
+	if c == nil {
+		return nil
+	}
+	var out []Feature
+	for f := range minVersions[c.Runtime] {
+		if c.Check(f) != nil {
+			out = append(out, f)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// compareVersions compares the leading numeric components of two versions
+// like "24.0.7" or "20.10.5+dfsg1". It returns false if a is not a version.
+func compareVersions(a, b string) (int, bool) {
+	pa, pb := versionParts(a), versionParts(b)
+	if len(pa) == 0 {
+		return 0, false
+	}
+	for i := range max(len(pa), len(pb)) {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			return x - y, true
+		}
+	}
+	return 0, true
+}
+
+// versionParts returns the numeric components of v up to the first
+// non-numeric suffix.
+func versionParts(v string) []int {
+	var out []int
+	for p := range strings.SplitSeq(strings.TrimPrefix(v, "v"), ".") {
+		end := strings.IndexFunc(p, func(r rune) bool { return r < '0' || r > '9' })
+		if end == -1 {
+			end = len(p)
+		}
+		n, err := strconv.Atoi(p[:end])
+		if err != nil {
+			break
+		}
+		out = append(out, n)
+		if end != len(p) {
+			break
+		}
+	}
+	return out
+}
+
+// MDVersion returns the version of the md module caic is built with.
+func MDVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	for _, d := range bi.Deps {
+		if d.Path == "github.com/caic-xyz/md" {
+			if d.Replace != nil {
+				return d.Replace.Version
+			}
+			return d.Version
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,251 @@
+// Package fixture records agent sessions as fixture bundles and replays them.
+//
+// A bundle is a directory holding two files:
+//   - wire.jsonl is a caic task log without its trailer: the caic_meta header,
+//     then the harness wire lines in the order they were logged, prompts
+//     included.
+//   - events.jsonl holds the v1 events caic derives from the wire lines, one
+//     per line, with timestamps and durations zeroed so that they are stable.
+//
+// The bundles under bundles/ are replayed by the mock server and checked
+// against the current parsers in tests, so a change in a harness's stream
+// format or in caic's conversion shows up as a diff.
+package fixture
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/logcrypt"
+)
+
+// File names within a bundle directory.
+const (
+	WireFile   = "wire.jsonl"
+	EventsFile = "events.jsonl"
+)
+
+// ParseFunc decodes one wire line, like agent.Backend.ParseMessage.
+type ParseFunc func(line []byte) ([]agent.Message, error)
+
+// Bundle is a recorded agent session.
+type Bundle struct {
+	Name   string            // Directory name.
+	Meta   agent.MetaMessage // Task metadata from the log header.
+	Wire   [][]byte          // Wire lines after the header.
+	Events [][]byte          // Normalized v1 events; nil when not derived yet.
+}
+
+//go:embed bundles
+var bundlesFS embed.FS
+
+// Bundled returns the bundles shipped with caic, sorted by name.
+var Bundled = sync.OnceValues(func() ([]*Bundle, error) {
+	sub, err := fs.Sub(bundlesFS, "bundles")
+	if err != nil {
+		return nil, err
+	}
+	return LoadAll(sub)
+})
+
+// LoadAll loads every bundle directory at the root of fsys, sorted by name.
+func LoadAll(fsys fs.FS) ([]*Bundle, error) {
+	matches, err := fs.Glob(fsys, "*/"+WireFile)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*Bundle, 0, len(matches))
+	for _, m := range matches {
+		b, err := Load(fsys, path.Dir(m))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// Load loads the bundle in directory name of fsys. A missing events file is
+// not an error.
+func Load(fsys fs.FS, name string) (*Bundle, error) {
+	raw, err := fs.ReadFile(fsys, path.Join(name, WireFile))
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseLog(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	b.Name = name
+	if raw, err = fs.ReadFile(fsys, path.Join(name, EventsFile)); err == nil {
+		b.Events = splitLines(raw)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return b, nil
+}
+
+// FromLog reads the caic task log at p, decrypting it if needed. The
+// caic_result and caic_pr trailers are dropped: they are written by caic, not
+// by the harness.
+func FromLog(p string) (*Bundle, error) {
+	raw, err := logcrypt.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	b, err := parseLog(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(p), err)
+	}
+	b.Wire = slices.DeleteFunc(b.Wire, func(line []byte) bool {
+		t := lineType(line)
+		return t == "caic_result" || t == "caic_pr"
+	})
+	return b, nil
+}
+
+func parseLog(raw []byte) (*Bundle, error) {
+	header, rest, _ := bytes.Cut(raw, []byte("\n"))
+	b := &Bundle{}
+	if err := json.Unmarshal(header, &b.Meta); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	if err := b.Meta.Validate(); err != nil {
+		return nil, fmt.Errorf("header: %w", err)
+	}
+	b.Wire = splitLines(rest)
+	return b, nil
+}
+
+// Write writes the bundle into dir, creating it if needed. Events are
+// written only when set.
+func (b *Bundle) Write(dir string) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	header, err := json.Marshal(&b.Meta)
+	if err != nil {
+		return err
+	}
+	if err := writeLines(filepath.Join(dir, WireFile), append([][]byte{header}, b.Wire...)); err != nil {
+		return err
+	}
+	if b.Events == nil {
+		return nil
+	}
+	return writeLines(filepath.Join(dir, EventsFile), b.Events)
+}
+
+// Turns splits the wire lines into the agent's output following each prompt.
+// A prompt is a line parse decodes to a single user input; lines logged
+// before the first prompt belong to the first turn.
+func (b *Bundle) Turns(parse ParseFunc) [][][]byte {
+	var turns [][][]byte
+	var pre [][]byte
+	for _, line := range b.Wire {
+		if msgs, err := parse(line); err == nil && len(msgs) == 1 {
+			if _, ok := msgs[0].(*agent.UserInputMessage); ok {
+				turns = append(turns, pre)
+				pre = nil
+				continue
+			}
+		}
+		if len(turns) == 0 {
+			pre = append(pre, line)
+		} else {
+			turns[len(turns)-1] = append(turns[len(turns)-1], line)
+		}
+	}
+	if len(turns) == 0 && len(pre) != 0 {
+		turns = append(turns, pre)
+	}
+	return turns
+}
+
+// Delay returns the pause before replaying a line decoding to msgs: text
+// streams in quickly, the model thinks before calling a tool and tools take a
+// while to run.
+func Delay(msgs []agent.Message) time.Duration {
+	d := 300 * time.Millisecond
+	for _, m := range msgs {
+		switch m.(type) {
+		case *agent.TextDeltaMessage, *agent.ThinkingDeltaMessage, *agent.ToolOutputDeltaMessage:
+			return 40 * time.Millisecond
+		case *agent.ToolUseMessage:
+			d = max(d, 700*time.Millisecond)
+		case *agent.ToolResultMessage:
+			d = max(d, 1200*time.Millisecond)
+		case *agent.DiffStatMessage:
+			d = 100 * time.Millisecond
+		}
+	}
+	return d
+}
+
+// Replay writes lines to w, each followed by a newline and preceded by its
+// Delay divided by speed; a speed of 0 means real time. It returns early with
+// a nil error when quit is closed.
+func Replay(w io.Writer, lines [][]byte, parse ParseFunc, speed float64, quit <-chan struct{}) error {
+	for _, line := range lines {
+		msgs, _ := parse(line)
+		d := Delay(msgs)
+		if speed > 0 {
+			d = time.Duration(float64(d) / speed)
+		}
+		t := time.NewTimer(d)
+		select {
+		case <-quit:
+			t.Stop()
+			return nil
+		case <-t.C:
+		}
+		if _, err := w.Write(append(line[:len(line):len(line)], '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitLines(raw []byte) [][]byte {
+	s := bufio.NewScanner(bytes.NewReader(raw))
+	// 32 MiB max line: user input with base64 images can produce very long NDJSON lines.
+	s.Buffer(make([]byte, 0, 64<<10), 32<<20)
+	var out [][]byte
+	for s.Scan() {
+		if line := bytes.TrimSpace(s.Bytes()); len(line) != 0 {
+			out = append(out, bytes.Clone(line))
+		}
+	}
+	return out
+}
+
+func writeLines(p string, lines [][]byte) error {
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	return os.WriteFile(p, buf.Bytes(), 0o600)
+}
+
+// lineType returns the "type" field of a wire line.
+func lineType(line []byte) string {
+	var env struct {
+		Type string `json:"type"`
+	}
+	_ = json.Unmarshal(line, &env)
+	return env.Type
+}
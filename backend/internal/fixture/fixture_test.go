@@ -0,0 +1,94 @@
+package fixture
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/agent/claude"
+)
+
+func TestFixture(t *testing.T) {
+	t.Run("Bundled", func(t *testing.T) {
+		bundles, err := Bundled()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range bundles {
+			if b.Meta.Harness != agent.Claude || len(b.Events) == 0 {
+				t.Errorf("%s: harness %q, %d events", b.Name, b.Meta.Harness, len(b.Events))
+			}
+			turns := b.Turns(claude.ParseMessage)
+			if len(turns) != 1 {
+				t.Fatalf("%s: %d turns, want 1", b.Name, len(turns))
+			}
+			for _, line := range turns[0] {
+				msgs, err := claude.ParseMessage(line)
+				if err != nil {
+					t.Fatalf("%s: %v", b.Name, err)
+				}
+				for _, m := range msgs {
+					switch m.(type) {
+					case *agent.RawMessage, *agent.UserInputMessage:
+						t.Errorf("%s: unexpected %T in turn: %s", b.Name, m, line)
+					}
+				}
+			}
+		}
+	})
+	t.Run("FromLog", func(t *testing.T) {
+		dir := t.TempDir()
+		p := filepath.Join(dir, "log.jsonl")
+		log := `{"type":"caic_meta","version":1,"prompt":"hi","repos":[],"harness":"claude","started_at":"2026-01-01T00:00:00Z"}
+{"type":"user","message":{"role":"user","content":"hi"}}
+{"type":"system","subtype":"init","session_id":"s"}
+{"type":"result","subtype":"success","result":"ok","num_turns":1}
+{"type":"caic_pr","forge_pr":1}
+{"type":"user","message":{"role":"user","content":"again"}}
+{"type":"result","subtype":"success","result":"ok","num_turns":2}
+{"type":"caic_result","state":"purged"}
+`
+		if err := os.WriteFile(p, []byte(log), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		b, err := FromLog(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(b.Wire) != 5 {
+			t.Fatalf("wire = %q", b.Wire)
+		}
+		if turns := b.Turns(claude.ParseMessage); len(turns) != 2 || len(turns[0]) != 2 || len(turns[1]) != 1 {
+			t.Errorf("turns = %q", turns)
+		}
+		b.Events = [][]byte{[]byte(`{"kind":"init"}`)}
+		if err := b.Write(filepath.Join(dir, "b")); err != nil {
+			t.Fatal(err)
+		}
+		got, err := Load(os.DirFS(dir), "b")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Meta.Prompt != "hi" || len(got.Wire) != len(b.Wire) || len(got.Events) != 1 {
+			t.Errorf("round trip: %+v", got)
+		}
+	})
+	t.Run("Replay", func(t *testing.T) {
+		lines := [][]byte{[]byte(`{"type":"system","subtype":"init"}`), []byte(`{"type":"result"}`)}
+		var buf bytes.Buffer
+		if err := Replay(&buf, lines, claude.ParseMessage, 1e6, nil); err != nil {
+			t.Fatal(err)
+		}
+		if want := string(lines[0]) + "\n" + string(lines[1]) + "\n"; buf.String() != want {
+			t.Errorf("got %q", buf.String())
+		}
+		quit := make(chan struct{})
+		close(quit)
+		buf.Reset()
+		if err := Replay(&buf, lines, claude.ParseMessage, 0, quit); err != nil || buf.Len() != 0 {
+			t.Errorf("after quit: %q, %v", buf.String(), err)
+		}
+	})
+}
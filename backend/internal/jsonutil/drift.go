@@ -0,0 +1,71 @@
+// Process-wide counters of unknown fields and record types met while decoding.
+
+package jsonutil
+
+import (
+	"cmp"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Drift counts how often a decoder met something its schema doesn't know. A
+// growing count usually means the upstream CLI changed its output format.
+type Drift struct {
+	Source string    // Who produced the data, e.g. a harness name.
+	Type   string    // Record type or method; for unknown fields, the record decoded.
+	Field  string    // Unknown field; empty when Type itself is unknown.
+	Count  int64     // Occurrences since the process started.
+	Last   time.Time // Last occurrence.
+}
+
+type driftKey struct {
+	source, typ, field string
+}
+
+var drifts struct {
+	mu sync.Mutex
+	m  map[driftKey]*Drift
+}
+
+// countDrift increments the counter for k and reports whether it is new.
+func countDrift(k driftKey, now time.Time) bool {
+	drifts.mu.Lock()
+	defer drifts.mu.Unlock()
+	d := drifts.m[k]
+	isNew := d == nil
+	if isNew {
+		if drifts.m == nil {
+			drifts.m = make(map[driftKey]*Drift)
+		}
+		d = &Drift{Source: k.source, Type: k.typ, Field: k.field}
+		drifts.m[k] = d
+	}
+	d.Count++
+	d.Last = now
+	return isNew
+}
+
+// CountUnknownType records that source emitted a record type or method typ
+// that the decoder doesn't handle. Unlike WarnUnknown it logs only the first
+// occurrence, since the record is preserved verbatim and may be frequent.
+func CountUnknownType(source, typ string) {
+	if countDrift(driftKey{source: source, typ: typ}, time.Now()) {
+		slog.Warn("unknown record type", "source", source, "type", typ)
+	}
+}
+
+// Drifts returns a snapshot of the counters, sorted by source, type and field.
+func Drifts() []Drift {
+	drifts.mu.Lock()
+	out := make([]Drift, 0, len(drifts.m))
+	for _, d := range drifts.m {
+		out = append(out, *d)
+	}
+	drifts.mu.Unlock()
+	slices.SortFunc(out, func(a, b Drift) int {
+		return cmp.Or(cmp.Compare(a.Source, b.Source), cmp.Compare(a.Type, b.Type), cmp.Compare(a.Field, b.Field))
+	})
+	return out
+}
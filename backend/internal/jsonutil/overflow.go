@@ -9,6 +9,7 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Overflow holds JSON fields that were not mapped to a struct field.
@@ -19,8 +20,9 @@ type Overflow struct {
 	Extra map[string]json.RawMessage `json:"-"`
 }
 
-// WarnUnknown logs a warning for each key in extra, identified by context.
-func WarnUnknown(context string, extra map[string]json.RawMessage) {
+// WarnUnknown logs a warning for each key in extra, identified by source and
+// context, and counts them in Drifts.
+func WarnUnknown(source, context string, extra map[string]json.RawMessage) {
 	if len(extra) == 0 {
 		return
 	}
@@ -29,7 +31,11 @@ func WarnUnknown(context string, extra map[string]json.RawMessage) {
 		keys = append(keys, k)
 	}
 	sort.Strings(keys)
-	slog.Warn("unknown fields in record", "context", context, "fields", keys)
+	now := time.Now()
+	for _, k := range keys {
+		countDrift(driftKey{source: source, typ: context, field: k}, now)
+	}
+	slog.Warn("unknown fields in record", "source", source, "context", context, "fields", keys)
 }
 
 // KnownFields builds a set of JSON field names by reflecting on v's struct
@@ -76,8 +82,8 @@ func CollectUnknown(raw map[string]json.RawMessage, known map[string]struct{}) m
 
 // UnmarshalRecord decodes data into dest (which must be a type-alias pointer
 // to break recursive UnmarshalJSON), collects unknown fields into overflow,
-// and logs a warning for each unknown key.
-func UnmarshalRecord(data []byte, dest any, overflow *Overflow, known map[string]struct{}, name string) error {
+// and logs a warning for each unknown key, attributed to source.
+func UnmarshalRecord(data []byte, dest any, overflow *Overflow, known map[string]struct{}, source, name string) error {
 	var raw map[string]json.RawMessage
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return fmt.Errorf("%s: %w", name, err)
@@ -86,6 +92,6 @@ func UnmarshalRecord(data []byte, dest any, overflow *Overflow, known map[string
 		return fmt.Errorf("%s: %w", name, err)
 	}
 	overflow.Extra = CollectUnknown(raw, known)
-	WarnUnknown(name, overflow.Extra)
+	WarnUnknown(source, name, overflow.Extra)
 	return nil
 }
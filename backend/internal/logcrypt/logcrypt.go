@@ -0,0 +1,220 @@
+// Package logcrypt encrypts the task logs at rest, one line at a time.
+//
+// Each JSONL line is sealed with NaCl secretbox under a 32 bytes key and
+// written as Prefix followed by the base64 of the nonce and the box. The logs
+// thus stay appendable and line oriented, and plaintext lines, e.g. of logs
+// written before encryption was enabled, are still read as is.
+//
+// Small files derived from the logs, e.g. summaries, are sealed whole as a
+// single line with SealFile.
+package logcrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// Prefix starts every encrypted line.
+const Prefix = "caicenc1:"
+
+// ErrNoKey is returned when decrypting a line without a key set.
+var ErrNoKey = errors.New("logcrypt: encrypted log line and no key set")
+
+// Key is a secretbox key.
+type Key [32]byte
+
+// ParseKey decodes a key encoded as base64 or hex, e.g. the output of
+// "head -c 32 /dev/urandom | base64".
+func ParseKey(s string) (*Key, error) {
+	s = strings.TrimSpace(s)
+	var raw []byte
+	if b, err := hex.DecodeString(s); err == nil {
+		raw = b
+	} else if b, err := base64.StdEncoding.DecodeString(s); err == nil {
+		raw = b
+	} else {
+		return nil, errors.New("logcrypt: key is neither base64 nor hex")
+	}
+	if len(raw) != len(Key{}) {
+		return nil, fmt.Errorf("logcrypt: key is %d bytes, want %d", len(raw), len(Key{}))
+	}
+	k := &Key{}
+	copy(k[:], raw)
+	return k, nil
+}
+
+// ReadKeyFile reads a key in the format of ParseKey from path.
+func ReadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	k, err := ParseKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return k, nil
+}
+
+var current atomic.Pointer[Key]
+
+// SetKey sets the key new logs are encrypted with and encrypted lines are
+// decrypted with. nil disables encryption; existing encrypted logs can then
+// no longer be read.
+func SetKey(k *Key) {
+	current.Store(k)
+}
+
+// Seal returns line, without its trailing newline, encrypted with the current
+// key. It returns line unchanged when no key is set.
+func Seal(line []byte) []byte {
+	k := current.Load()
+	if k == nil {
+		return line
+	}
+	return seal(k, line)
+}
+
+func seal(k *Key, line []byte) []byte {
+	var nonce [24]byte
+	_, _ = rand.Read(nonce[:])
+	box := secretbox.Seal(nonce[:], line, &nonce, (*[32]byte)(k))
+	out := make([]byte, len(Prefix)+base64.StdEncoding.EncodedLen(len(box)))
+	copy(out, Prefix)
+	base64.StdEncoding.Encode(out[len(Prefix):], box)
+	return out
+}
+
+// Open returns the plaintext of line, without its trailing newline. Lines
+// that aren't encrypted are returned unchanged.
+func Open(line []byte) ([]byte, error) {
+	rest, ok := bytes.CutPrefix(line, []byte(Prefix))
+	if !ok {
+		return line, nil
+	}
+	k := current.Load()
+	if k == nil {
+		return nil, ErrNoKey
+	}
+	box := make([]byte, base64.StdEncoding.DecodedLen(len(rest)))
+	n, err := base64.StdEncoding.Decode(box, rest)
+	if err != nil {
+		return nil, fmt.Errorf("logcrypt: %w", err)
+	}
+	box = box[:n]
+	if len(box) < 24+secretbox.Overhead {
+		return nil, errors.New("logcrypt: line too short")
+	}
+	var nonce [24]byte
+	copy(nonce[:], box)
+	out, ok := secretbox.Open(nil, box[24:], &nonce, (*[32]byte)(k))
+	if !ok {
+		return nil, errors.New("logcrypt: decryption failed; wrong key?")
+	}
+	return out, nil
+}
+
+// SealFile returns data, the whole content of a file, encrypted as a single
+// line with the current key. It returns data unchanged when no key is set.
+// OpenFile reverses it.
+func SealFile(data []byte) []byte {
+	k := current.Load()
+	if k == nil {
+		return data
+	}
+	return append(seal(k, data), '\n')
+}
+
+// OpenFile returns the plaintext of raw, the content of a file written with
+// SealFile. Files that aren't encrypted are returned unchanged.
+func OpenFile(raw []byte) ([]byte, error) {
+	line, ok := bytes.CutSuffix(raw, []byte("\n"))
+	if !ok || !bytes.HasPrefix(line, []byte(Prefix)) || bytes.IndexByte(line, '\n') != -1 {
+		return raw, nil
+	}
+	return Open(line)
+}
+
+// ReadFile returns the plaintext content of the log at path.
+func ReadFile(path string) ([]byte, error) {
+	raw, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Contains(raw, []byte(Prefix)) {
+		return raw, nil
+	}
+	var out bytes.Buffer
+	for line := range bytes.Lines(raw) {
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		plain, err := Open(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+		out.Write(plain)
+		out.WriteByte('\n')
+	}
+	return out.Bytes(), nil
+}
+
+// NewWriter returns w encrypting each line written to it with the current
+// key, or w itself when no key is set. Writes are buffered until a newline;
+// Close writes the incomplete last line, if any.
+func NewWriter(w io.WriteCloser) io.WriteCloser {
+	k := current.Load()
+	if k == nil {
+		return w
+	}
+	return &writer{w: w, k: k}
+}
+
+type writer struct {
+	w   io.WriteCloser
+	k   *Key
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.flush(w.buf[:i]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+func (w *writer) flush(line []byte) error {
+	_, err := w.w.Write(append(seal(w.k, line), '\n'))
+	return err
+}
+
+func (w *writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if len(w.buf) > 0 {
+		err = w.flush(w.buf)
+		w.buf = nil
+	}
+	return errors.Join(err, w.w.Close())
+}
@@ -0,0 +1,110 @@
+package logcrypt
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseKey(t *testing.T) {
+	for _, s := range []string{
+		strings.Repeat("ab", 32),
+		"AQIDBAUGBwgJCgsMDQ4PEBESExQVFhcYGRobHB0eHyA=\n",
+	} {
+		if _, err := ParseKey(s); err != nil {
+			t.Errorf("ParseKey(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "ab", "not a key", strings.Repeat("ab", 16)} {
+		if _, err := ParseKey(s); err == nil {
+			t.Errorf("ParseKey(%q) succeeded", s)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	const plain = `{"type":"caic_meta"}`
+	if got := Seal([]byte(plain)); string(got) != plain {
+		t.Errorf("Seal without key = %q", got)
+	}
+	SetKey(&Key{1})
+	t.Cleanup(func() { SetKey(nil) })
+
+	sealed := Seal([]byte(plain))
+	if !bytes.HasPrefix(sealed, []byte(Prefix)) || bytes.Contains(sealed, []byte("caic_meta")) {
+		t.Fatalf("Seal = %q", sealed)
+	}
+	if got, err := Open(sealed); err != nil || string(got) != plain {
+		t.Errorf("Open = %q, %v", got, err)
+	}
+	if got, err := Open([]byte(plain)); err != nil || string(got) != plain {
+		t.Errorf("Open plaintext = %q, %v", got, err)
+	}
+
+	SetKey(&Key{2})
+	if _, err := Open(sealed); err == nil {
+		t.Error("Open with the wrong key succeeded")
+	}
+	SetKey(nil)
+	if _, err := Open(sealed); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Open without key: %v", err)
+	}
+}
+
+func TestSealFile(t *testing.T) {
+	const plain = "# Notes\n\n- one\n- two\n"
+	if got := SealFile([]byte(plain)); string(got) != plain {
+		t.Errorf("SealFile without key = %q", got)
+	}
+	SetKey(&Key{1})
+	t.Cleanup(func() { SetKey(nil) })
+	sealed := SealFile([]byte(plain))
+	if bytes.Count(sealed, []byte("\n")) != 1 || bytes.Contains(sealed, []byte("Notes")) {
+		t.Fatalf("SealFile = %q", sealed)
+	}
+	if got, err := OpenFile(sealed); err != nil || string(got) != plain {
+		t.Errorf("OpenFile = %q, %v", got, err)
+	}
+	if got, err := OpenFile([]byte(plain)); err != nil || string(got) != plain {
+		t.Errorf("OpenFile plaintext = %q, %v", got, err)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	SetKey(&Key{1})
+	t.Cleanup(func() { SetKey(nil) })
+	p := filepath.Join(t.TempDir(), "log.jsonl")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWriter(f)
+	// Lines split across writes, several lines in a write and an incomplete
+	// last line.
+	for _, s := range []string{`{"a":`, "1}\n", "{\"b\":2}\n{\"c\":3}\n", `{"d":4}`} {
+		if _, err := w.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(raw, []byte(Prefix)); n != 4 {
+		t.Errorf("%d encrypted lines, want 4:\n%s", n, raw)
+	}
+	got, err := ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "{\"a\":1}\n{\"b\":2}\n{\"c\":3}\n{\"d\":4}\n"
+	if string(got) != want {
+		t.Errorf("ReadFile = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+// Mock agent and container backends replaying the fixtures in-process.
+package mock
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/agent/claude"
+	"github.com/caic-xyz/caic/backend/internal/fixture"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/caic-xyz/md"
+)
+
+// Backend is a Claude Code look-alike that answers each prompt with the next
+// turn of the bundled fixtures, round-robin.
+type Backend struct {
+	*claude.Backend
+	// Speed scales the pacing; 0 means real time. Tests use a large value.
+	Speed float64
+
+	mu   sync.Mutex
+	next int
+}
+
+var _ agent.Backend = (*Backend)(nil)
+
+// NewBackend returns a Backend replaying the bundled fixtures.
+func NewBackend() *Backend {
+	return &Backend{Backend: claude.New()}
+}
+
+// Start implements agent.Backend. The session runs in-process: prompts
+// written to stdin trigger a replay on stdout until stdin is closed.
+func (b *Backend) Start(_ context.Context, opts *agent.Options, msgCh chan<- agent.Message, logW io.Writer) (*agent.Session, error) {
+	bundles, err := fixture.Bundled()
+	if err != nil {
+		return nil, err
+	}
+	var turns [][][]byte
+	for _, fb := range bundles {
+		turns = append(turns, fb.Turns(b.ParseMessage)...)
+	}
+	if len(turns) == 0 {
+		return nil, errors.New("no fixture to replay")
+	}
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	go b.play(turns, stdinR, stdoutW)
+	s := agent.NewSession(nil, stdinW, stdoutR, msgCh, logW, b.Backend, nil)
+	if opts.InitialPrompt.Text != "" {
+		if err := s.Send(opts.InitialPrompt); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// AttachRelay implements agent.Backend. Mock sessions do not outlive the
+// server, so there is never a relay to attach to.
+func (*Backend) AttachRelay(context.Context, *agent.Options, chan<- agent.Message, io.Writer) (*agent.Session, error) {
+	return nil, errors.New("mock backend does not support relay")
+}
+
+// ReadRelayOutput implements agent.Backend.
+func (*Backend) ReadRelayOutput(context.Context, string) ([]agent.Message, int64, error) {
+	return nil, 0, errors.New("mock backend does not support relay")
+}
+
+// play replays one turn per prompt read from stdin. It closes stdout once
+// stdin is closed.
+func (b *Backend) play(turns [][][]byte, stdin io.Reader, stdout *io.PipeWriter) {
+	// Buffered so that the reader never blocks Session.Close while a turn is
+	// being replayed.
+	prompts := make(chan struct{}, 64)
+	quit := make(chan struct{})
+	go func() {
+		defer close(quit)
+		s := bufio.NewScanner(stdin)
+		// Prompts with base64 images can produce very long lines.
+		s.Buffer(make([]byte, 0, 64<<10), 32<<20)
+		for s.Scan() {
+			// Session.Close sends a null byte before closing stdin.
+			if len(bytes.Trim(s.Bytes(), "\x00")) != 0 {
+				select {
+				case prompts <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	defer stdout.Close()
+	initSent := false
+	for {
+		select {
+		case <-quit:
+			return
+		case <-prompts:
+		}
+		// Every fixture starts with an init; a session sends only one.
+		lines := slices.DeleteFunc(slices.Clone(b.pick(turns)), func(line []byte) bool {
+			msgs, _ := b.ParseMessage(line)
+			if len(msgs) == 1 {
+				if _, ok := msgs[0].(*agent.InitMessage); ok {
+					if initSent {
+						return true
+					}
+					initSent = true
+				}
+			}
+			return false
+		})
+		if err := fixture.Replay(stdout, lines, b.ParseMessage, b.Speed, quit); err != nil {
+			return
+		}
+	}
+}
+
+// pick returns the next turn.
+func (b *Backend) pick(turns [][][]byte) [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	t := turns[b.next%len(turns)]
+	b.next++
+	return t
+}
+
+// Container implements task.ContainerBackend without containers.
+type Container struct{}
+
+var _ task.ContainerBackend = (*Container)(nil)
+
+// Launch implements task.ContainerBackend.
+func (*Container) Launch(context.Context, []md.Repo, []string, *task.StartOptions) error {
+	return nil
+}
+
+// Connect implements task.ContainerBackend.
+func (*Container) Connect(_ context.Context, repos []md.Repo, _ *task.StartOptions) (_, _ string, _ error) {
+	if len(repos) == 0 {
+		return "md-mock-no-repo", "", nil
+	}
+	return "md-mock-" + strings.ReplaceAll(repos[0].Branch, "/", "-"), "", nil
+}
+
+// Diff implements task.ContainerBackend.
+func (*Container) Diff(context.Context, md.Repo, ...string) (string, error) {
+	return "", nil
+}
+
+// Fetch implements task.ContainerBackend.
+func (*Container) Fetch(context.Context, []md.Repo) error { return nil }
+
+// Stop implements task.ContainerBackend.
+func (*Container) Stop(context.Context, string) error { return nil }
+
+// Purge implements task.ContainerBackend.
+func (*Container) Purge(context.Context, string, []md.Repo) error { return nil }
+
+// Revive implements task.ContainerBackend.
+func (*Container) Revive(context.Context, string, []md.Repo) error { return nil }
+
+// SparseCheckout implements task.ContainerBackend.
+func (*Container) SparseCheckout(context.Context, string, md.Repo, []string) error {
+	return nil
+}
+
+// Run implements task.ContainerBackend.
+func (*Container) Run(context.Context, string, string) error { return nil }
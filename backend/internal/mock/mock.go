@@ -0,0 +1,138 @@
+// Package mock serves the caic API with synthetic tasks and scripted agent
+// streams, without containers, agents or credentials.
+//
+// The scripts are the Claude Code sessions bundled in package fixture. They
+// seed the task history and are replayed at a realistic pace to every task
+// started in mock mode.
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/agent/claude"
+	"github.com/caic-xyz/caic/backend/internal/fixture"
+	"github.com/maruel/ksid"
+)
+
+// InitRepos creates the git repositories served in mock mode under dir and
+// returns the root directory to serve. Each repo has a local bare origin.
+func InitRepos(dir string) (string, error) {
+	root := filepath.Join(dir, "src")
+	for _, name := range []string{"acme/webapp", "acme/api"} {
+		clone := filepath.Join(root, name)
+		bare := filepath.Join(dir, "remotes", name+".git")
+		files := map[string]string{
+			"README.md": "# " + path.Base(name) + "\n\nMock repository served by caic -mock.\n",
+			"go.mod":    "module example.com/" + path.Base(name) + "\n\ngo 1.25\n",
+			"main.go":   "package main\n\nfunc main() {}\n",
+		}
+		if err := initRepo(clone, bare, files); err != nil {
+			return "", fmt.Errorf("init %s: %w", name, err)
+		}
+	}
+	return root, nil
+}
+
+func initRepo(clone, bare string, files map[string]string) error {
+	if err := os.MkdirAll(clone, 0o750); err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(clone, name), []byte(content), 0o600); err != nil {
+			return err
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--bare", bare},
+		{"-C", clone, "init"},
+		{"-C", clone, "checkout", "-b", "main"},
+		{"-C", clone, "add", "."},
+		{"-C", clone, "-c", "user.name=caic", "-c", "user.email=caic@localhost", "commit", "-m", "Initial commit"},
+		{"-C", clone, "remote", "add", "origin", bare},
+		{"-C", clone, "push", "-u", "origin", "main"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil { //nolint:gosec // args are hardcoded git subcommands
+			return fmt.Errorf("git %v: %w\n%s", args, err, out)
+		}
+	}
+	return nil
+}
+
+// Seed writes one terminated task log per fixture into logDir, for repo, so
+// the task list has history. The tasks are spread over the hours before now.
+func Seed(logDir, repo string, now time.Time) error {
+	bundles, err := fixture.Bundled()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(logDir, 0o750); err != nil {
+		return err
+	}
+	for i, b := range bundles {
+		if err := seedOne(logDir, repo, b, i, now.Add(-time.Duration(len(bundles)-i)*3*time.Hour)); err != nil {
+			return fmt.Errorf("seed %s: %w", b.Name, err)
+		}
+	}
+	return nil
+}
+
+func seedOne(logDir, repo string, b *fixture.Bundle, i int, started time.Time) error {
+	meta := b.Meta
+	branch := "caic-mock-" + strconv.Itoa(i)
+	meta.Repos = []agent.MetaRepo{{Name: repo, BaseBranch: "main", Branch: branch}}
+	meta.StartedAt = started.UTC()
+	var buf bytes.Buffer
+	header, err := json.Marshal(&meta)
+	if err != nil {
+		return err
+	}
+	buf.Write(header)
+	buf.WriteByte('\n')
+	mr := agent.MetaResultMessage{MessageType: "caic_result", State: "purged", Title: meta.Title}
+	for _, line := range b.Wire {
+		buf.Write(line)
+		buf.WriteByte('\n')
+		msgs, err := claude.ParseMessage(line)
+		if err != nil {
+			return err
+		}
+		for _, m := range msgs {
+			switch m := m.(type) {
+			case *agent.ResultMessage:
+				mr.CostUSD = m.TotalCostUSD
+				mr.Duration = float64(m.DurationMs) / 1000
+				mr.NumTurns = m.NumTurns
+				mr.InputTokens = m.Usage.InputTokens
+				mr.OutputTokens = m.Usage.OutputTokens
+				mr.CacheCreationInputTokens = m.Usage.CacheCreationInputTokens
+				mr.CacheReadInputTokens = m.Usage.CacheReadInputTokens
+				mr.AgentResult = m.Result
+			case *agent.DiffStatMessage:
+				mr.DiffStat = m.DiffStat
+			}
+		}
+	}
+	trailer, err := json.Marshal(&mr)
+	if err != nil {
+		return err
+	}
+	buf.Write(trailer)
+	buf.WriteByte('\n')
+	p := filepath.Join(logDir, ksid.NewID().String()+"-"+strings.ReplaceAll(repo, "/", "-")+"-"+branch+".jsonl")
+	if err := os.WriteFile(p, buf.Bytes(), 0o600); err != nil {
+		return err
+	}
+	// The loader uses the mtime as the time the task last changed.
+	end := started.Add(time.Duration(mr.Duration * float64(time.Second)))
+	return os.Chtimes(p, end, end)
+}
@@ -0,0 +1,84 @@
+package mock
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/fixture"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestSeed(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	if err := Seed(dir, "acme/webapp", now); err != nil {
+		t.Fatal(err)
+	}
+	bundles, err := fixture.Bundled()
+	if err != nil {
+		t.Fatal(err)
+	}
+	logs, err := task.LoadLogs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != len(bundles) {
+		t.Fatalf("loaded %d logs, want %d", len(logs), len(bundles))
+	}
+	for _, lt := range logs {
+		if lt.State != task.StatePurged || lt.Result == nil || lt.Result.NumTurns == 0 {
+			t.Errorf("%s: state %s, result %+v", lt.Title, lt.State, lt.Result)
+		}
+		if p := lt.Primary(); p == nil || p.Name != "acme/webapp" {
+			t.Errorf("%s: repos %+v", lt.Title, lt.Repos)
+		}
+		if d := now.Sub(lt.LastStateUpdateAt); d < 0 || d > 24*time.Hour {
+			t.Errorf("%s: updated %s ago", lt.Title, d)
+		}
+		if err := lt.LoadMessages(); err != nil || len(lt.Msgs) == 0 {
+			t.Errorf("%s: %d messages, err %v", lt.Title, len(lt.Msgs), err)
+		}
+	}
+}
+
+func TestBackend(t *testing.T) {
+	b := NewBackend()
+	b.Speed = 1000
+	msgCh := make(chan agent.Message, 1024)
+	s, err := b.Start(t.Context(), &agent.Options{InitialPrompt: agent.Prompt{Text: "hi"}}, msgCh, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() *agent.ResultMessage {
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Second)
+		defer cancel()
+		for {
+			select {
+			case m := <-msgCh:
+				if rm, ok := m.(*agent.ResultMessage); ok {
+					return rm
+				}
+			case <-ctx.Done():
+				t.Fatal("no result message")
+			}
+		}
+	}
+	first := next()
+	if err := s.Send(agent.Prompt{Text: "again"}); err != nil {
+		t.Fatal(err)
+	}
+	if second := next(); second.Result == first.Result {
+		t.Errorf("replayed the same turn twice: %q", first.Result)
+	}
+	s.Close()
+	select {
+	case <-s.Done():
+	case <-time.After(10 * time.Second):
+		t.Fatal("session did not end after Close")
+	}
+	if rm, _ := s.Wait(); rm == nil {
+		t.Error("Wait returned no result")
+	}
+}
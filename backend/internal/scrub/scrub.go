@@ -0,0 +1,218 @@
+// Package scrub redacts personal data, like email and IP addresses, from the
+// prompts sent to the harnesses and from the task logs.
+package scrub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"sync"
+)
+
+// Builtin are the rules enabled by name in Config.Builtin.
+var Builtin = map[string]string{
+	"email": `[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`,
+	"ipv4":  `\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`,
+	// Full or with "::" after at least two groups, so that C++ scopes like
+	// "a::b" don't match.
+	"ipv6": `\b(?:(?:[0-9A-Fa-f]{1,4}:){7}[0-9A-Fa-f]{1,4}|(?:[0-9A-Fa-f]{1,4}:){2,6}(?::[0-9A-Fa-f]{1,4}){1,5})\b`,
+}
+
+// Rule is a custom scrubbing rule.
+type Rule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`               // RE2 regexp.
+	Replacement string `json:"replacement,omitempty"` // Defaults to "[scrubbed:<name>]".
+}
+
+// Config configures a Scrubber.
+type Config struct {
+	Builtin []string `json:"builtin,omitempty"` // Names of Builtin rules to enable.
+	Rules   []Rule   `json:"rules,omitempty"`
+	// Allow lists regexps; a match of any rule that also matches one of them
+	// is kept, e.g. "^127\\.0\\.0\\.1$" or "@example\\.com$".
+	Allow []string `json:"allow,omitempty"`
+}
+
+// IsZero reports whether c enables no rule.
+func (c *Config) IsZero() bool {
+	return len(c.Builtin) == 0 && len(c.Rules) == 0
+}
+
+// Report counts the scrubbed matches per rule name.
+type Report map[string]int
+
+// Add adds the counts of o to r.
+func (r Report) Add(o Report) {
+	for k, v := range o {
+		r[k] += v
+	}
+}
+
+// Scrubber applies the rules of a Config.
+type Scrubber struct {
+	rules []rule
+	allow []*regexp.Regexp
+}
+
+type rule struct {
+	name        string
+	re          *regexp.Regexp
+	replacement string
+}
+
+// New returns the Scrubber for c, or nil if c enables no rule.
+func New(c *Config) (*Scrubber, error) {
+	if c.IsZero() {
+		return nil, nil
+	}
+	s := &Scrubber{}
+	seen := map[string]bool{}
+	add := func(name, pattern, replacement string) error {
+		if name == "" {
+			return errors.New("scrub: rule name is required")
+		}
+		if seen[name] {
+			return fmt.Errorf("scrub: duplicate rule %q", name)
+		}
+		seen[name] = true
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("scrub: rule %q: %w", name, err)
+		}
+		if replacement == "" {
+			replacement = "[scrubbed:" + name + "]"
+		}
+		s.rules = append(s.rules, rule{name: name, re: re, replacement: replacement})
+		return nil
+	}
+	for _, name := range c.Builtin {
+		pattern, ok := Builtin[name]
+		if !ok {
+			return nil, fmt.Errorf("scrub: unknown builtin rule %q; known: %v", name, slices.Sorted(maps.Keys(Builtin)))
+		}
+		if err := add(name, pattern, ""); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range c.Rules {
+		if err := add(r.Name, r.Pattern, r.Replacement); err != nil {
+			return nil, err
+		}
+	}
+	for _, a := range c.Allow {
+		re, err := regexp.Compile(a)
+		if err != nil {
+			return nil, fmt.Errorf("scrub: allow %q: %w", a, err)
+		}
+		s.allow = append(s.allow, re)
+	}
+	return s, nil
+}
+
+// String returns in with the matches of every rule replaced, and what was
+// replaced. The report is nil when nothing was.
+func (s *Scrubber) String(in string) (string, Report) {
+	var rep Report
+	for _, r := range s.rules {
+		in = r.re.ReplaceAllStringFunc(in, func(m string) string {
+			for _, a := range s.allow {
+				if a.MatchString(m) {
+					return m
+				}
+			}
+			if rep == nil {
+				rep = Report{}
+			}
+			rep[r.name]++
+			return r.replacement
+		})
+	}
+	return in, rep
+}
+
+// jsonString matches a JSON string literal.
+var jsonString = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// JSON returns the JSON document line with the string values and keys
+// scrubbed, and what was replaced. Strings are decoded first so that escape
+// sequences can't hide or be split by a match; the others are left as is.
+func (s *Scrubber) JSON(line []byte) ([]byte, Report) {
+	var rep Report
+	out := jsonString.ReplaceAllFunc(line, func(lit []byte) []byte {
+		var v string
+		if json.Unmarshal(lit, &v) != nil {
+			return lit
+		}
+		scrubbed, r := s.String(v)
+		if r == nil {
+			return lit
+		}
+		if rep == nil {
+			rep = Report{}
+		}
+		rep.Add(r)
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(scrubbed)
+		return bytes.TrimSuffix(b.Bytes(), []byte("\n"))
+	})
+	return out, rep
+}
+
+// NewWriter returns w scrubbing each JSON line written to it and reporting
+// what was scrubbed to onScrub. Writes are buffered until a newline; Close
+// writes the incomplete last line, if any.
+func (s *Scrubber) NewWriter(w io.WriteCloser, onScrub func(Report)) io.WriteCloser {
+	return &writer{s: s, w: w, onScrub: onScrub}
+}
+
+type writer struct {
+	s       *Scrubber
+	w       io.WriteCloser
+	onScrub func(Report)
+	mu      sync.Mutex
+	buf     []byte
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if err := w.flush(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+func (w *writer) flush(line []byte) error {
+	out, rep := w.s.JSON(line)
+	if rep != nil && w.onScrub != nil {
+		w.onScrub(rep)
+	}
+	_, err := w.w.Write(out)
+	return err
+}
+
+func (w *writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var err error
+	if len(w.buf) > 0 {
+		err = w.flush(w.buf)
+		w.buf = nil
+	}
+	return errors.Join(err, w.w.Close())
+}
@@ -0,0 +1,101 @@
+package scrub
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type nopCloser struct{ *bytes.Buffer }
+
+func (nopCloser) Close() error { return nil }
+
+func TestNew(t *testing.T) {
+	if s, err := New(&Config{Allow: []string{"x"}}); s != nil || err != nil {
+		t.Errorf("New(empty) = %v, %v", s, err)
+	}
+	for _, c := range []Config{
+		{Builtin: []string{"phone"}},
+		{Builtin: []string{"email", "email"}},
+		{Rules: []Rule{{Pattern: "x"}}},
+		{Rules: []Rule{{Name: "bad", Pattern: "("}}},
+		{Builtin: []string{"email"}, Allow: []string{"("}},
+	} {
+		if _, err := New(&c); err == nil {
+			t.Errorf("New(%+v) succeeded", c)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	s, err := New(&Config{
+		Builtin: []string{"email", "ipv4", "ipv6"},
+		Rules:   []Rule{{Name: "ticket", Pattern: `TICKET-\d+`, Replacement: "TICKET-?"}},
+		Allow:   []string{`^127\.0\.0\.1$`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := "mail a.b@corp.example.com from 10.1.2.3 or fe80:0:0:0:0:0:0:1 and 2001:db8::1, not 127.0.0.1 nor std::string; TICKET-42"
+	got, rep := s.String(in)
+	const want = "mail [scrubbed:email] from [scrubbed:ipv4] or [scrubbed:ipv6] and [scrubbed:ipv6], not 127.0.0.1 nor std::string; TICKET-?"
+	if got != want {
+		t.Errorf("String() =\n%q\nwant\n%q", got, want)
+	}
+	if rep["email"] != 1 || rep["ipv4"] != 1 || rep["ipv6"] != 2 || rep["ticket"] != 1 || len(rep) != 4 {
+		t.Errorf("report = %v", rep)
+	}
+	if _, rep := s.String("nothing here"); rep != nil {
+		t.Errorf("report = %v, want nil", rep)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	s, err := New(&Config{Builtin: []string{"email"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The escaped newline right before the address must not end up in it.
+	line := []byte(`{"type":"user","text":"hi\nbob@example.com <x>","n":1}` + "\n")
+	got, rep := s.JSON(line)
+	var v struct{ Text string }
+	if err := json.Unmarshal(got, &v); err != nil {
+		t.Fatalf("%q: %v", got, err)
+	}
+	if v.Text != "hi\n[scrubbed:email] <x>" || !bytes.HasSuffix(got, []byte(`"n":1}`+"\n")) {
+		t.Errorf("JSON() = %q", got)
+	}
+	if rep["email"] != 1 {
+		t.Errorf("report = %v", rep)
+	}
+	if got, rep := s.JSON([]byte(`{"a":"b"}`)); string(got) != `{"a":"b"}` || rep != nil {
+		t.Errorf("JSON() = %q, %v", got, rep)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	s, err := New(&Config{Builtin: []string{"email"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	total := Report{}
+	w := s.NewWriter(nopCloser{&buf}, total.Add)
+	// An address split across writes and an incomplete last line.
+	for _, p := range []string{`{"a":"x@exa`, "mple.com\"}\n{\"b\":1}\n", `{"c":"y@example.org"}`} {
+		if _, err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	const want = "{\"a\":\"[scrubbed:email]\"}\n{\"b\":1}\n{\"c\":\"[scrubbed:email]\"}"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if total["email"] != 2 || strings.Contains(buf.String(), "@") {
+		t.Errorf("report = %v", total)
+	}
+}
@@ -0,0 +1,355 @@
+// Package search implements an in-memory full-text index over task
+// conversations: prompts, assistant text, tool commands and file paths.
+//
+// It is a small inverted index rather than SQLite FTS5 to keep the binary
+// free of cgo; queries are conjunctions of terms, with a trailing '*' for
+// prefix matching.
+package search
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// Kind identifies which part of a conversation a document comes from.
+type Kind string
+
+// Document kinds.
+const (
+	KindPrompt  Kind = "prompt"
+	KindText    Kind = "text"
+	KindCommand Kind = "command"
+	KindPath    Kind = "path"
+)
+
+const (
+	// maxDocBytes bounds the text kept per document.
+	maxDocBytes = 8 << 10
+	// snippetRunes is the number of runes kept on each side of a match.
+	snippetRunes = 60
+)
+
+// Doc is one searchable piece of a conversation.
+type Doc struct {
+	Msg  int // Index of the message in the conversation.
+	Kind Kind
+	Text string
+}
+
+// Meta describes the task a set of documents belongs to.
+type Meta struct {
+	Title     string
+	Repo      string
+	StartedAt time.Time
+	Owner     string // Internal user ID of the creator; empty when unknown.
+}
+
+// Hit is a matching document.
+type Hit struct {
+	TaskID  string
+	Meta    Meta
+	Msg     int
+	Kind    Kind
+	Snippet string
+	Score   float64
+}
+
+type ref struct {
+	task string
+	doc  int
+}
+
+type entry struct {
+	meta    Meta
+	version time.Time
+	docs    []Doc
+	tokens  []map[string]int // Per doc term frequencies.
+}
+
+// Index is a full-text index keyed by task ID. It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	tasks    map[string]*entry
+	postings map[string]map[ref]int
+}
+
+// New returns an empty index.
+func New() *Index {
+	return &Index{tasks: make(map[string]*entry), postings: make(map[string]map[ref]int)}
+}
+
+// Version returns the version passed to Set for id, or the zero time if id is
+// not indexed.
+func (x *Index) Version(id string) time.Time {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if e := x.tasks[id]; e != nil {
+		return e.version
+	}
+	return time.Time{}
+}
+
+// Len returns the number of indexed tasks.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.tasks)
+}
+
+// Set replaces the documents indexed for task id. version is an opaque
+// marker, usually the source's modification time, returned by Version.
+func (x *Index) Set(id string, meta Meta, version time.Time, docs []Doc) {
+	e := &entry{meta: meta, version: version, docs: make([]Doc, 0, len(docs))}
+	for _, d := range docs {
+		if len(d.Text) > maxDocBytes {
+			d.Text = truncateUTF8(d.Text, maxDocBytes)
+		}
+		tf := make(map[string]int)
+		for _, tok := range tokenize(d.Text) {
+			tf[tok]++
+		}
+		if len(tf) == 0 {
+			continue
+		}
+		e.docs = append(e.docs, d)
+		e.tokens = append(e.tokens, tf)
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(id)
+	x.tasks[id] = e
+	for i, tf := range e.tokens {
+		for tok, n := range tf {
+			p := x.postings[tok]
+			if p == nil {
+				p = make(map[ref]int)
+				x.postings[tok] = p
+			}
+			p[ref{id, i}] = n
+		}
+	}
+}
+
+// Remove drops task id from the index.
+func (x *Index) Remove(id string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.removeLocked(id)
+}
+
+// Prune drops every task for which keep returns false.
+func (x *Index) Prune(keep func(id string) bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for id := range x.tasks {
+		if !keep(id) {
+			x.removeLocked(id)
+		}
+	}
+}
+
+func (x *Index) removeLocked(id string) {
+	e := x.tasks[id]
+	if e == nil {
+		return
+	}
+	for i, tf := range e.tokens {
+		for tok := range tf {
+			p := x.postings[tok]
+			delete(p, ref{id, i})
+			if len(p) == 0 {
+				delete(x.postings, tok)
+			}
+		}
+	}
+	delete(x.tasks, id)
+}
+
+// Search returns up to limit documents matching every term of q, best first.
+// When keep is not nil, only the documents of the tasks it accepts count.
+func (x *Index) Search(q string, limit int, keep func(id string, m *Meta) bool) []Hit {
+	terms := parseQuery(q)
+	if len(terms) == 0 || limit <= 0 {
+		return nil
+	}
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	total := 0
+	for _, e := range x.tasks {
+		total += len(e.docs)
+	}
+	var scores map[ref]float64
+	var matched []string
+	for _, t := range terms {
+		cur := make(map[ref]float64)
+		for tok, p := range x.postings {
+			if tok != t.text && (!t.prefix || !strings.HasPrefix(tok, t.text)) {
+				continue
+			}
+			matched = append(matched, tok)
+			idf := math.Log(1 + float64(total)/float64(len(p)))
+			for r, n := range p {
+				if scores == nil || scores[r] > 0 {
+					cur[r] += float64(n) * idf
+				}
+			}
+		}
+		for r := range cur {
+			cur[r] += scores[r]
+		}
+		scores = cur
+		if len(scores) == 0 {
+			return nil
+		}
+	}
+	hits := make([]Hit, 0, len(scores))
+	kept := map[string]bool{}
+	for r, sc := range scores {
+		e := x.tasks[r.task]
+		if keep != nil {
+			k, ok := kept[r.task]
+			if !ok {
+				k = keep(r.task, &e.meta)
+				kept[r.task] = k
+			}
+			if !k {
+				continue
+			}
+		}
+		d := e.docs[r.doc]
+		hits = append(hits, Hit{TaskID: r.task, Meta: e.meta, Msg: d.Msg, Kind: d.Kind, Score: sc, Snippet: d.Text})
+	}
+	slices.SortFunc(hits, func(a, b Hit) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		if c := b.Meta.StartedAt.Compare(a.Meta.StartedAt); c != 0 {
+			return c
+		}
+		if a.TaskID != b.TaskID {
+			return strings.Compare(a.TaskID, b.TaskID)
+		}
+		return a.Msg - b.Msg
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	for i := range hits {
+		hits[i].Snippet = snippet(hits[i].Snippet, matched)
+	}
+	return hits
+}
+
+type term struct {
+	text   string
+	prefix bool
+}
+
+// parseQuery splits q into terms. A term ending with '*' matches any token
+// it prefixes.
+func parseQuery(q string) []term {
+	var out []term
+	for f := range strings.FieldsSeq(q) {
+		prefix := strings.HasSuffix(f, "*")
+		toks := tokenize(f)
+		for i, tok := range toks {
+			out = append(out, term{text: tok, prefix: prefix && i == len(toks)-1})
+		}
+	}
+	return out
+}
+
+// tokenize lowercases s and splits it on anything but letters and digits, so
+// "internal/server/auth.go" yields "internal", "server", "auth" and "go".
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// snippet returns the part of text around the first occurrence of any of
+// tokens, with whitespace collapsed.
+func snippet(text string, tokens []string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	lower := strings.ToLower(text)
+	pos := -1
+	for _, tok := range tokens {
+		if i := strings.Index(lower, tok); i >= 0 && (pos < 0 || i < pos) {
+			pos = i
+		}
+	}
+	if pos < 0 || len(lower) != len(text) {
+		// Lowercasing changed byte offsets; fall back to the head.
+		pos = 0
+	}
+	start := pos
+	for n := 0; start > 0 && n < snippetRunes; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	end := pos
+	for n := 0; end < len(text) && n < 2*snippetRunes; n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	out := text[start:end]
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out
+}
+
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Extract returns the searchable documents of a conversation: user prompts,
+// assistant text, shell commands and file paths passed to tools.
+func Extract(msgs []agent.Message) []Doc {
+	var docs []Doc
+	for i, m := range msgs {
+		switch v := m.(type) {
+		case *agent.UserInputMessage:
+			docs = append(docs, Doc{Msg: i, Kind: KindPrompt, Text: v.Text})
+		case *agent.TextMessage:
+			docs = append(docs, Doc{Msg: i, Kind: KindText, Text: v.Text})
+		case *agent.ToolUseMessage:
+			var in struct {
+				Command      json.RawMessage `json:"command"`
+				FilePath     string          `json:"file_path"`
+				Path         string          `json:"path"`
+				NotebookPath string          `json:"notebook_path"`
+			}
+			if json.Unmarshal(v.Input, &in) != nil {
+				continue
+			}
+			if cmd := task.CommandString(in.Command); cmd != "" {
+				docs = append(docs, Doc{Msg: i, Kind: KindCommand, Text: cmd})
+			}
+			for _, p := range []string{in.FilePath, in.Path, in.NotebookPath} {
+				if p != "" {
+					docs = append(docs, Doc{Msg: i, Kind: KindPath, Text: p})
+				}
+			}
+		}
+	}
+	return docs
+}
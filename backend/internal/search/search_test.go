@@ -0,0 +1,115 @@
+package search
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestIndex(t *testing.T) {
+	x := New()
+	x.Set("t1", Meta{Title: "Auth fix", StartedAt: time.Unix(100, 0)}, time.Unix(1, 0), []Doc{
+		{Msg: 0, Kind: KindPrompt, Text: "Fix the login flow"},
+		{Msg: 3, Kind: KindPath, Text: "backend/internal/auth/middleware.go"},
+		{Msg: 4, Kind: KindText, Text: "I updated the auth middleware to check the session expiry."},
+	})
+	x.Set("t2", Meta{Title: "Docs", StartedAt: time.Unix(200, 0)}, time.Unix(2, 0), []Doc{
+		{Msg: 0, Kind: KindPrompt, Text: "Document the middleware chain"},
+		{Msg: 2, Kind: KindCommand, Text: "go test ./..."},
+	})
+	t.Run("Conjunction", func(t *testing.T) {
+		hits := x.Search("auth middleware", 10, nil)
+		if len(hits) != 2 {
+			t.Fatalf("hits = %+v", hits)
+		}
+		for _, h := range hits {
+			if h.TaskID != "t1" {
+				t.Errorf("unexpected hit %+v", h)
+			}
+		}
+	})
+	t.Run("Prefix", func(t *testing.T) {
+		hits := x.Search("middle*", 10, nil)
+		if len(hits) != 3 {
+			t.Fatalf("hits = %d, want 3", len(hits))
+		}
+	})
+	t.Run("Limit", func(t *testing.T) {
+		if hits := x.Search("middleware", 1, nil); len(hits) != 1 {
+			t.Fatalf("hits = %d, want 1", len(hits))
+		}
+	})
+	t.Run("Keep", func(t *testing.T) {
+		// t2 ranks first by start time; filtering happens before the limit.
+		hits := x.Search("middleware", 1, func(id string, _ *Meta) bool { return id == "t1" })
+		if len(hits) != 1 || hits[0].TaskID != "t1" {
+			t.Fatalf("hits = %+v", hits)
+		}
+	})
+	t.Run("NoMatch", func(t *testing.T) {
+		if hits := x.Search("auth kubernetes", 10, nil); len(hits) != 0 {
+			t.Fatalf("hits = %+v", hits)
+		}
+		if hits := x.Search("  ", 10, nil); hits != nil {
+			t.Fatalf("hits = %+v", hits)
+		}
+	})
+	t.Run("Replace", func(t *testing.T) {
+		x.Set("t2", Meta{Title: "Docs"}, time.Unix(3, 0), []Doc{{Kind: KindPrompt, Text: "Something else"}})
+		if hits := x.Search("chain", 10, nil); len(hits) != 0 {
+			t.Fatalf("stale hits = %+v", hits)
+		}
+		if v := x.Version("t2"); !v.Equal(time.Unix(3, 0)) {
+			t.Errorf("Version = %v", v)
+		}
+		x.Prune(func(id string) bool { return id != "t2" })
+		if x.Len() != 1 || len(x.Search("something", 10, nil)) != 0 {
+			t.Errorf("prune failed; len = %d", x.Len())
+		}
+	})
+}
+
+func TestSnippet(t *testing.T) {
+	pad := strings.Repeat("word ", 50)
+	got := snippet(pad+"the\n\tneedle "+pad, []string{"needle"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "the needle") {
+		t.Errorf("snippet = %q", got)
+	}
+	if n := utf8.RuneCountInString(got); n > 3*snippetRunes+2 {
+		t.Errorf("snippet has %d runes", n)
+	}
+	if want := "short"; snippet(want, []string{"x"}) != want {
+		t.Errorf("snippet(%q) changed", want)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	msgs := []agent.Message{
+		&agent.UserInputMessage{Text: "fix auth"},
+		&agent.TextMessage{Text: "Looking"},
+		&agent.ToolUseMessage{Name: "Bash", Input: json.RawMessage(`{"command":"grep -r auth ."}`)},
+		&agent.ToolUseMessage{Name: "shell", Input: json.RawMessage(`{"command":["bash","-lc","ls"]}`)},
+		&agent.ToolUseMessage{Name: "Edit", Input: json.RawMessage(`{"file_path":"/src/auth.go","old_string":"a"}`)},
+		&agent.UsageMessage{},
+	}
+	docs := Extract(msgs)
+	want := []Doc{
+		{Msg: 0, Kind: KindPrompt, Text: "fix auth"},
+		{Msg: 1, Kind: KindText, Text: "Looking"},
+		{Msg: 2, Kind: KindCommand, Text: "grep -r auth ."},
+		{Msg: 3, Kind: KindCommand, Text: "bash -lc ls"},
+		{Msg: 4, Kind: KindPath, Text: "/src/auth.go"},
+	}
+	if len(docs) != len(want) {
+		t.Fatalf("docs = %+v", docs)
+	}
+	for i := range want {
+		if docs[i] != want[i] {
+			t.Errorf("docs[%d] = %+v, want %+v", i, docs[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Agent status line derived from the agent messages of a task event stream.
+package server
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// statusTracker derives what the agent is doing from its messages, for the
+// EventKindStatus events.
+type statusTracker struct {
+	status  v1.EventStatus
+	running []runningTool // Tool calls without a result, in call order.
+}
+
+// runningTool is a tool call without a result yet.
+type runningTool struct {
+	id, name string
+}
+
+// update records msg and reports whether the status changed.
+func (st *statusTracker) update(msg agent.Message) bool {
+	next, ok := st.next(msg)
+	if !ok || next == st.status {
+		return false
+	}
+	st.status = next
+	return true
+}
+
+// event returns the current status as an event.
+func (st *statusTracker) event(ts int64) v1.EventMessage {
+	s := st.status
+	return v1.EventMessage{Kind: v1.EventKindStatus, Ts: ts, Status: &s}
+}
+
+// next returns the status after msg. ok is false when msg doesn't tell.
+func (st *statusTracker) next(msg agent.Message) (v1.EventStatus, bool) {
+	switch m := msg.(type) {
+	case *agent.UserInputMessage:
+		if m.Text == "" && len(m.Images) == 0 {
+			return v1.EventStatus{}, false
+		}
+		return v1.EventStatus{Status: v1.AgentStatusThinking}, true
+	case *agent.ThinkingMessage, *agent.ThinkingDeltaMessage:
+		return v1.EventStatus{Status: v1.AgentStatusThinking}, true
+	case *agent.TextMessage, *agent.TextDeltaMessage:
+		// The agent moved on; see toolTimingTracker about inferred results.
+		st.running = slices.DeleteFunc(st.running, func(r runningTool) bool { return !asyncTools[strings.ToLower(r.name)] })
+		return v1.EventStatus{Status: v1.AgentStatusResponding}, true
+	case *agent.ToolUseMessage:
+		st.running = append(st.running, runningTool{id: m.ToolUseID, name: m.Name})
+		return st.toolStatus(), true
+	case *agent.ToolResultMessage:
+		st.running = slices.DeleteFunc(st.running, func(r runningTool) bool { return r.id == m.ToolUseID })
+		return st.toolStatus(), true
+	case *agent.AskMessage, *agent.ResultMessage:
+		st.running = st.running[:0]
+		return v1.EventStatus{Status: v1.AgentStatusWaiting}, true
+	case *agent.RateLimitMessage:
+		if m.Limited {
+			s := v1.EventStatus{Status: v1.AgentStatusRateLimited}
+			if !m.ResetsAt.IsZero() {
+				s.Until = float64(m.ResetsAt.UnixMilli()) / 1e3
+			}
+			return s, true
+		}
+		if st.status.Status == v1.AgentStatusRateLimited {
+			return v1.EventStatus{Status: v1.AgentStatusThinking}, true
+		}
+	}
+	return v1.EventStatus{}, false
+}
+
+// toolStatus returns the status while tools may be running: the most recent
+// running tool, or thinking when none is.
+func (st *statusTracker) toolStatus() v1.EventStatus {
+	if n := len(st.running); n > 0 {
+		return v1.EventStatus{Status: v1.AgentStatusTool, Tool: st.running[n-1].name}
+	}
+	return v1.EventStatus{Status: v1.AgentStatusThinking}
+}
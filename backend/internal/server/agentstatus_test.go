@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+func TestStatusTracker(t *testing.T) {
+	resets := time.Date(2026, 1, 1, 5, 0, 0, 0, time.UTC)
+	var st statusTracker
+	for i, step := range []struct {
+		msg  agent.Message
+		want v1.EventStatus // Zero when unchanged.
+	}{
+		{&agent.UserInputMessage{Text: "go"}, v1.EventStatus{Status: v1.AgentStatusThinking}},
+		{&agent.ThinkingDeltaMessage{Text: "h"}, v1.EventStatus{}},
+		{&agent.UsageMessage{}, v1.EventStatus{}},
+		{&agent.ToolUseMessage{ToolUseID: "b", Name: "Bash"}, v1.EventStatus{Status: v1.AgentStatusTool, Tool: "Bash"}},
+		{&agent.ToolUseMessage{ToolUseID: "r", Name: "Read"}, v1.EventStatus{Status: v1.AgentStatusTool, Tool: "Read"}},
+		{&agent.ToolResultMessage{ToolUseID: "r"}, v1.EventStatus{Status: v1.AgentStatusTool, Tool: "Bash"}},
+		{&agent.ToolResultMessage{ToolUseID: "b"}, v1.EventStatus{Status: v1.AgentStatusThinking}},
+		{&agent.RateLimitMessage{Limited: true, ResetsAt: resets}, v1.EventStatus{Status: v1.AgentStatusRateLimited, Until: float64(resets.Unix())}},
+		{&agent.RateLimitMessage{}, v1.EventStatus{Status: v1.AgentStatusThinking}},
+		{&agent.ToolUseMessage{ToolUseID: "g", Name: "Grep"}, v1.EventStatus{Status: v1.AgentStatusTool, Tool: "Grep"}},
+		{&agent.TextDeltaMessage{Text: "d"}, v1.EventStatus{Status: v1.AgentStatusResponding}},
+		{&agent.ResultMessage{}, v1.EventStatus{Status: v1.AgentStatusWaiting}},
+		{&agent.RateLimitMessage{}, v1.EventStatus{}},
+	} {
+		changed := st.update(step.msg)
+		if changed != (step.want != v1.EventStatus{}) || (changed && st.status != step.want) {
+			t.Errorf("#%d %T: changed %t, status %+v, want %+v", i, step.msg, changed, st.status, step.want)
+		}
+	}
+	if ev := st.event(5); ev.Kind != v1.EventKindStatus || ev.Ts != 5 || *ev.Status != st.status {
+		t.Errorf("event = %+v", ev)
+	}
+}
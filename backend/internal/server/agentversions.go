@@ -0,0 +1,140 @@
+// Harness CLI versions in use, pinned per repo, and their latest releases.
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// agentLatestTTL is how long a latest release lookup is reused.
+const agentLatestTTL = time.Hour
+
+// agentLatestCache holds the latest release of each harness CLI package.
+// The zero value is ready to use.
+type agentLatestCache struct {
+	mu        sync.Mutex
+	versions  map[string]string // keyed by npm package
+	checkedAt time.Time
+}
+
+// npmRegistry is the base URL of the npm registry.
+const npmRegistry = "https://registry.npmjs.org/"
+
+// npmLatestVersion returns the version tagged latest of the npm package pkg.
+func npmLatestVersion(ctx context.Context, pkg string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, npmRegistry+pkg+"/latest", http.NoBody)
+	if err != nil {
+		return "", err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("npm registry %s: %s", pkg, resp.Status)
+	}
+	var m struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return "", fmt.Errorf("npm registry %s: %w", pkg, err)
+	}
+	return m.Version, nil
+}
+
+// latestAgentVersions returns the latest release of each harness CLI package
+// and when it was looked up, refreshing the cache after agentLatestTTL. It
+// returns nil when update checks are disabled.
+func (s *Server) latestAgentVersions(ctx context.Context) (map[string]string, time.Time) {
+	if s.latestAgentVersion == nil {
+		return nil, time.Time{}
+	}
+	c := &s.agentLatest
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.versions != nil && time.Since(c.checkedAt) < agentLatestTTL {
+		return c.versions, c.checkedAt
+	}
+	versions := make(map[string]string, len(agent.Packages))
+	for _, pkg := range agent.Packages {
+		v, err := s.latestAgentVersion(ctx, pkg)
+		if err != nil {
+			slog.Warn("agent update check", "pkg", pkg, "err", err)
+			// Keep the previous answer rather than forgetting it.
+			v = c.versions[pkg]
+		}
+		if v != "" {
+			versions[pkg] = v
+		}
+	}
+	c.versions = versions
+	c.checkedAt = time.Now()
+	return c.versions, c.checkedAt
+}
+
+// listAgentVersions reports, per harness, the CLI versions reported by
+// current tasks, the versions pinned per repo and, when update checks are
+// enabled, the latest release.
+func (s *Server) listAgentVersions(ctx context.Context, _ *dto.EmptyReq) (*v1.AgentVersionsResp, error) {
+	byHarness := map[agent.Harness]*v1.AgentVersions{}
+	get := func(h agent.Harness) *v1.AgentVersions {
+		av := byHarness[h]
+		if av == nil {
+			av = &v1.AgentVersions{Harness: string(h), Package: agent.Packages[h], Running: []v1.AgentVersionCount{}, Pinned: []v1.RepoAgentVersion{}}
+			byHarness[h] = av
+		}
+		return av
+	}
+	for h := range agent.Packages {
+		get(h)
+	}
+	s.mu.Lock()
+	for _, e := range s.tasks {
+		snap := e.task.Snapshot()
+		if snap.AgentVersion == "" || snap.State == task.StatePurged {
+			continue
+		}
+		av := get(e.task.Harness)
+		i := slices.IndexFunc(av.Running, func(c v1.AgentVersionCount) bool { return c.Version == snap.AgentVersion })
+		if i < 0 {
+			av.Running = append(av.Running, v1.AgentVersionCount{Version: snap.AgentVersion})
+			i = len(av.Running) - 1
+		}
+		av.Running[i].Tasks++
+	}
+	s.mu.Unlock()
+	for repo, pins := range s.agentVersions {
+		for h, v := range pins {
+			av := get(h)
+			av.Pinned = append(av.Pinned, v1.RepoAgentVersion{Repo: repo, Version: v})
+		}
+	}
+	latest, checkedAt := s.latestAgentVersions(ctx)
+	resp := &v1.AgentVersionsResp{Harnesses: make([]v1.AgentVersions, 0, len(byHarness))}
+	for _, av := range byHarness {
+		slices.SortFunc(av.Running, func(a, b v1.AgentVersionCount) int { return cmp.Compare(a.Version, b.Version) })
+		slices.SortFunc(av.Pinned, func(a, b v1.RepoAgentVersion) int { return cmp.Compare(a.Repo, b.Repo) })
+		if v := latest[av.Package]; v != "" {
+			av.Latest = v
+			av.LatestCheckedAt = float64(checkedAt.UnixMilli()) / 1e3
+			av.UpdateAvailable = slices.ContainsFunc(av.Running, func(c v1.AgentVersionCount) bool { return c.Version != v })
+		}
+		resp.Harnesses = append(resp.Harnesses, *av)
+	}
+	slices.SortFunc(resp.Harnesses, func(a, b v1.AgentVersions) int { return cmp.Compare(a.Harness, b.Harness) })
+	return resp, nil
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestListAgentVersions(t *testing.T) {
+	s := newTestServer(t)
+	add := func(id string, h agent.Harness, version string, st task.State) {
+		tk := &task.Task{Harness: h}
+		tk.RestoreMessages([]agent.Message{&agent.InitMessage{SessionID: "s-" + id, Version: version}})
+		setState(t, tk, st)
+		s.tasks[id] = &taskEntry{task: tk, done: make(chan struct{})}
+	}
+	add("a", agent.Claude, "1.0.98", task.StateRunning)
+	add("b", agent.Claude, "1.0.98", task.StateWaiting)
+	add("c", agent.Claude, "1.0.97", task.StateRunning)
+	add("d", agent.Claude, "0.9.0", task.StatePurged)
+	add("e", agent.Codex, "0.41.0", task.StateRunning)
+	s.agentVersions = map[string]map[agent.Harness]string{"org/repo": {agent.Claude: "1.0.97"}}
+	calls := 0
+	s.latestAgentVersion = func(_ context.Context, pkg string) (string, error) {
+		calls++
+		if pkg == agent.Packages[agent.Claude] {
+			return "1.0.98", nil
+		}
+		return "0.41.0", nil
+	}
+
+	resp, err := s.listAgentVersions(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Harnesses) != len(agent.Packages) {
+		t.Fatalf("got %d harnesses, want %d", len(resp.Harnesses), len(agent.Packages))
+	}
+	c := resp.Harnesses[0]
+	if c.Harness != "claude" || c.Package != "@anthropic-ai/claude-code" {
+		t.Fatalf("first harness = %+v", c)
+	}
+	if len(c.Running) != 2 || c.Running[0].Version != "1.0.97" || c.Running[0].Tasks != 1 || c.Running[1].Tasks != 2 {
+		t.Errorf("running = %+v", c.Running)
+	}
+	if len(c.Pinned) != 1 || c.Pinned[0].Repo != "org/repo" || c.Pinned[0].Version != "1.0.97" {
+		t.Errorf("pinned = %+v", c.Pinned)
+	}
+	if c.Latest != "1.0.98" || !c.UpdateAvailable {
+		t.Errorf("latest = %q, updateAvailable = %v", c.Latest, c.UpdateAvailable)
+	}
+	for _, h := range resp.Harnesses {
+		if h.Harness == "codex" && h.UpdateAvailable {
+			t.Error("codex runs the latest version")
+		}
+	}
+
+	n := calls
+	if _, err := s.listAgentVersions(t.Context(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if calls != n {
+		t.Error("latest versions not cached")
+	}
+
+	s.latestAgentVersion = nil
+	s.agentLatest = agentLatestCache{}
+	resp, err = s.listAgentVersions(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Harnesses[0].Latest != "" {
+		t.Error("latest reported with update checks disabled")
+	}
+}
@@ -0,0 +1,184 @@
+// Message bookmarks and notes, stored next to the task logs.
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/maruel/ksid"
+)
+
+// maxAnnotations bounds the annotations of a single task.
+const maxAnnotations = 200
+
+// annotation is a bookmark as persisted.
+type annotation struct {
+	ID           ksid.ID   `json:"id"`
+	MessageIndex int       `json:"messageIndex"`
+	Note         string    `json:"note,omitempty"`
+	Author       string    `json:"author,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// annotationStore persists the annotations of each task as
+// <dir>/<task id>.annotations.json, next to the task's JSONL log so they are
+// kept and moved together. The zero value has no directory and reports no
+// annotation.
+type annotationStore struct {
+	dir string
+	mu  sync.Mutex // Serializes read-modify-write cycles.
+}
+
+func (st *annotationStore) path(id ksid.ID) string {
+	return filepath.Join(st.dir, id.String()+".annotations.json")
+}
+
+// load returns the annotations of task id, sorted by message index.
+func (st *annotationStore) load(id ksid.ID) ([]annotation, error) {
+	if st.dir == "" {
+		return nil, nil
+	}
+	data, err := readSealed(st.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var list []annotation
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// update applies fn to the annotations of task id and atomically writes the
+// result.
+func (st *annotationStore) update(id ksid.ID, fn func([]annotation) ([]annotation, error)) error {
+	if st.dir == "" {
+		return errors.New("no log directory")
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	list, err := st.load(id)
+	if err != nil {
+		return err
+	}
+	if list, err = fn(list); err != nil {
+		return err
+	}
+	slices.SortStableFunc(list, func(a, b annotation) int {
+		return cmp.Or(cmp.Compare(a.MessageIndex, b.MessageIndex), a.CreatedAt.Compare(b.CreatedAt))
+	})
+	p := st.path(id)
+	if len(list) == 0 {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	return writeSealedAtomic(p, data)
+}
+
+func toV1Annotation(a *annotation) v1.Annotation {
+	return v1.Annotation{
+		ID:           a.ID,
+		MessageIndex: a.MessageIndex,
+		Note:         a.Note,
+		Author:       a.Author,
+		CreatedAt:    float64(a.CreatedAt.UnixMilli()) / 1e3,
+	}
+}
+
+// handleListAnnotations returns the bookmarks of a task so clients can show
+// them as markers on the conversation.
+func (s *Server) handleListAnnotations(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	list, err := s.annotations.load(entry.task.ID)
+	if err != nil {
+		writeError(w, dto.InternalError("load annotations").Wrap(err))
+		return
+	}
+	resp := v1.TaskAnnotationsResp{Annotations: make([]v1.Annotation, len(list))}
+	for i := range list {
+		resp.Annotations[i] = toV1Annotation(&list[i])
+	}
+	writeJSONResponse(w, &resp, nil)
+}
+
+// annotateTask bookmarks a message of the task, optionally with a note.
+func (s *Server) annotateTask(ctx context.Context, entry *taskEntry, req *v1.AnnotateReq) (*v1.Annotation, error) {
+	if n := len(entry.task.Messages()); req.MessageIndex >= n {
+		return nil, dto.BadRequest("messageIndex out of range").WithDetail("messages", n)
+	}
+	a := annotation{
+		ID:           ksid.NewID(),
+		MessageIndex: req.MessageIndex,
+		Note:         req.Note,
+		Author:       s.draftOwner(ctx),
+		CreatedAt:    time.Now().UTC(),
+	}
+	err := s.annotations.update(entry.task.ID, func(list []annotation) ([]annotation, error) {
+		if len(list) >= maxAnnotations {
+			return nil, dto.Conflict("too many annotations").WithDetail("max", maxAnnotations)
+		}
+		return append(list, a), nil
+	})
+	if err != nil {
+		var apiErr *dto.APIError
+		if errors.As(err, &apiErr) {
+			return nil, err
+		}
+		return nil, dto.InternalError("save annotation").Wrap(err)
+	}
+	resp := toV1Annotation(&a)
+	return &resp, nil
+}
+
+// handleDeleteAnnotation removes a bookmark of a task.
+func (s *Server) handleDeleteAnnotation(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	id, err := ksid.Parse(r.PathValue("annotationID"))
+	if err != nil {
+		writeError(w, dto.NotFound("annotation"))
+		return
+	}
+	err = s.annotations.update(entry.task.ID, func(list []annotation) ([]annotation, error) {
+		i := slices.IndexFunc(list, func(a annotation) bool { return a.ID == id })
+		if i < 0 {
+			return nil, dto.NotFound("annotation")
+		}
+		return slices.Delete(list, i, i+1), nil
+	})
+	if err != nil {
+		var apiErr *dto.APIError
+		if !errors.As(err, &apiErr) {
+			err = dto.InternalError("save annotation").Wrap(err)
+		}
+		writeError(w, err)
+		return
+	}
+	writeJSONResponse(w, &v1.StatusResp{Status: "deleted"}, nil)
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestAnnotations(t *testing.T) {
+	s := newTestServer(t)
+	s.annotations.dir = t.TempDir()
+	tk := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "a"}}
+	tk.RestoreMessages([]agent.Message{
+		&agent.TextMessage{Text: "one"},
+		&agent.TextMessage{Text: "two"},
+		&agent.TextMessage{Text: "three"},
+	})
+	e := &taskEntry{task: tk, done: make(chan struct{})}
+	s.tasks[tk.ID.String()] = e
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/tasks/{id}/annotations", s.handleListAnnotations)
+	mux.HandleFunc("POST /api/v1/tasks/{id}/annotations", handleWithTask(s, s.annotateTask))
+	mux.HandleFunc("POST /api/v1/tasks/{id}/annotations/{annotationID}/delete", s.handleDeleteAnnotation)
+	do := func(method, path string, body any) *httptest.ResponseRecorder {
+		t.Helper()
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		req := httptest.NewRequest(method, "/api/v1/tasks/"+tk.ID.String()+path, &buf)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	var added []v1.Annotation
+	for _, req := range []v1.AnnotateReq{{MessageIndex: 2, Note: "went wrong here"}, {MessageIndex: 0}} {
+		w := do(http.MethodPost, "/annotations", &req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("annotate: status = %d: %s", w.Code, w.Body)
+		}
+		var a v1.Annotation
+		if err := json.NewDecoder(w.Body).Decode(&a); err != nil {
+			t.Fatal(err)
+		}
+		added = append(added, a)
+	}
+	if w := do(http.MethodPost, "/annotations", &v1.AnnotateReq{MessageIndex: 3}); w.Code != http.StatusBadRequest {
+		t.Errorf("out of range: status = %d", w.Code)
+	}
+
+	list := func() []v1.Annotation {
+		t.Helper()
+		w := do(http.MethodGet, "/annotations", nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("list: status = %d", w.Code)
+		}
+		var resp v1.TaskAnnotationsResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Annotations
+	}
+	got := list()
+	if len(got) != 2 || got[0].MessageIndex != 0 || got[1].Note != "went wrong here" {
+		t.Fatalf("annotations = %+v", got)
+	}
+
+	if w := do(http.MethodPost, "/annotations/"+added[0].ID.String()+"/delete", nil); w.Code != http.StatusOK {
+		t.Fatalf("delete: status = %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPost, "/annotations/"+added[0].ID.String()+"/delete", nil); w.Code != http.StatusNotFound {
+		t.Errorf("delete again: status = %d", w.Code)
+	}
+	if got := list(); len(got) != 1 || got[0].ID != added[1].ID {
+		t.Errorf("annotations = %+v", got)
+	}
+}
@@ -0,0 +1,100 @@
+// API version negotiation and the handlers of the v2 endpoints.
+package server
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v2 "github.com/caic-xyz/caic/backend/internal/server/dto/v2"
+)
+
+// apiVersions are the supported API versions, oldest first.
+var apiVersions = []string{"1", "2"}
+
+// apiVersionMiddleware selects the API version of each /api request.
+//
+// The version is in the path, so generated clients are pinned to the version
+// they were generated for. A client on /api/v1 paths may instead send
+// "Accept-Version: 2" to opt in to v2 one call at a time. A v2 request for an
+// endpoint without a v2 form is served by its v1 handler, as declared by
+// v2.Routes. The version served is returned in the API-Version header.
+//
+// v2Routes is the mux holding the v2 handlers; a request it does not route
+// falls back to v1.
+func apiVersionMiddleware(v2Routes *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/api/v")
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+		version, _, _ := strings.Cut(rest, "/")
+		if version == "1" {
+			switch accept := r.Header.Get("Accept-Version"); accept {
+			case "", "1":
+			case "2":
+				version = "2"
+			default:
+				writeError(w, dto.NotAcceptable("unsupported API version").WithDetail("supported", apiVersions))
+				return
+			}
+		}
+		switch version {
+		case "1":
+		case "2":
+			r = withAPIVersion(r, "2")
+			if _, pattern := v2Routes.Handler(r); pattern == "" {
+				r = withAPIVersion(r, "1")
+			}
+		default:
+			writeError(w, dto.NotFound("API version "+version).WithDetail("supported", apiVersions))
+			return
+		}
+		w.Header().Set("API-Version", version)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withAPIVersion returns a copy of r with the version in its /api/vN path
+// replaced.
+func withAPIVersion(r *http.Request, version string) *http.Request {
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = swapAPIVersion(r.URL.Path, version)
+	if r.URL.RawPath != "" {
+		r2.URL.RawPath = swapAPIVersion(r.URL.RawPath, version)
+	}
+	return r2
+}
+
+// swapAPIVersion replaces the version of an /api/vN path.
+func swapAPIVersion(p, version string) string {
+	rest := strings.TrimPrefix(p, "/api/v")
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		return "/api/v" + version + rest[i:]
+	}
+	return "/api/v" + version
+}
+
+// listTasksV2 is listTasks with structured initial prompts.
+func (s *Server) listTasksV2(ctx context.Context, req *dto.EmptyReq) (*[]v2.Task, error) {
+	tasks, err := s.listTasks(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]v2.Task, 0, len(*tasks))
+	for i := range *tasks {
+		t := &(*tasks)[i]
+		e, ok := s.tasks[t.ID.String()]
+		if !ok {
+			// Purged since listed.
+			continue
+		}
+		p := agentPromptToV1(e.task.InitialPrompt)
+		out = append(out, v2.TaskFromV1(t, &p))
+	}
+	return &out, nil
+}
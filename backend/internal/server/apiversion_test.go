@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v2 "github.com/caic-xyz/caic/backend/internal/server/dto/v2"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestAPIVersion(t *testing.T) {
+	s := newTestServer(t)
+	tk := &task.Task{
+		ID:            ksid.NewID(),
+		InitialPrompt: agent.Prompt{Text: "fix it", Images: []agent.ImageData{{MediaType: "image/png", Data: "aGVsbG8="}}},
+		Harness:       agent.Claude,
+	}
+	s.tasks[tk.ID.String()] = &taskEntry{task: tk, done: make(chan struct{})}
+	h, err := s.buildHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		path    string
+		accept  string
+		code    int
+		version string
+		v2Tasks bool
+	}{
+		{"V1", "/api/v1/tasks", "", http.StatusOK, "1", false},
+		{"V2Path", "/api/v2/tasks", "", http.StatusOK, "2", true},
+		{"AcceptVersion", "/api/v1/tasks", "2", http.StatusOK, "2", true},
+		{"V2FallsBackToV1", "/api/v2/server/repos", "", http.StatusOK, "2", false},
+		{"V2FallbackNotFound", "/api/v2/nope", "", http.StatusNotFound, "2", false},
+		{"UnsupportedAccept", "/api/v1/tasks", "3", http.StatusNotAcceptable, "", false},
+		{"UnknownVersion", "/api/v9/tasks", "", http.StatusNotFound, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, http.NoBody)
+			if tc.accept != "" {
+				req.Header.Set("Accept-Version", tc.accept)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			if w.Code != tc.code {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tc.code, w.Body.String())
+			}
+			if got := w.Header().Get("API-Version"); got != tc.version {
+				t.Errorf("API-Version = %q, want %q", got, tc.version)
+			}
+			if !tc.v2Tasks {
+				return
+			}
+			var tasks []v2.Task
+			if err := json.Unmarshal(w.Body.Bytes(), &tasks); err != nil {
+				t.Fatal(err)
+			}
+			want := v2.Prompt{Text: "fix it", Images: []v2.ImageMeta{{MediaType: "image/png", Size: 5}}}
+			if len(tasks) != 1 || tasks[0].ID != tk.ID || tasks[0].InitialPrompt.Text != want.Text ||
+				len(tasks[0].InitialPrompt.Images) != 1 || tasks[0].InitialPrompt.Images[0] != want.Images[0] {
+				t.Errorf("tasks = %+v", tasks)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Per-task policy for questions left unanswered: answer with a default,
+// escalate with an event or pause the task. Outside of working hours,
+// questions are answered and plans approved, see workHours.
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// askCheckInterval controls how often monitorAsks looks for questions past
+// their task's timeout.
+const askCheckInterval = 15 * time.Second
+
+// monitorAsks applies the ask policy of the tasks waiting on a question or
+// on the approval of a plan.
+func (s *Server) monitorAsks() {
+	ticker := time.NewTicker(askCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+		s.applyAskPolicies(s.ctx, time.Now())
+	}
+}
+
+// askDue is a question or plan whose policy is due.
+type askDue struct {
+	e        *taskEntry
+	p        *task.AskPolicy
+	plan     bool
+	offHours bool
+}
+
+// applyAskPolicies acts on each question or plan left unanswered past the
+// timeout of the policy applying to it, once per question.
+func (s *Server) applyAskPolicies(ctx context.Context, now time.Time) {
+	var due []askDue
+	s.mu.Lock()
+	for _, e := range s.tasks {
+		if e.result != nil {
+			continue
+		}
+		snap := e.task.Snapshot()
+		if snap.State != task.StateAsking && snap.State != task.StateHasPlan {
+			continue
+		}
+		d := askDue{e: e, plan: snap.State == task.StateHasPlan}
+		if w := s.offHours(e, now); w != nil {
+			d.offHours = true
+			d.p = &task.AskPolicy{Timeout: w.grace, Action: task.AskAnswer, Answer: w.answer}
+			if d.plan {
+				d.p.Answer = task.PlanApproval
+			}
+		} else if !d.plan {
+			d.p = e.task.AskPolicy
+		}
+		if d.p == nil || !d.p.Due(snap.StateUpdatedAt, now) {
+			continue
+		}
+		// A notification doesn't prevent answering the same question later,
+		// e.g. once the working hours are over.
+		handled := &e.askHandled
+		if d.p.Action == task.AskNotify {
+			handled = &e.askNotified
+		}
+		if snap.StateUpdatedAt.Equal(*handled) {
+			continue
+		}
+		*handled = snap.StateUpdatedAt
+		due = append(due, d)
+	}
+	s.mu.Unlock()
+	for _, d := range due {
+		s.applyAskPolicy(ctx, &d)
+	}
+	if len(due) > 0 {
+		s.notifyTaskChange()
+	}
+}
+
+func (s *Server) applyAskPolicy(ctx context.Context, d *askDue) {
+	t := d.e.task
+	detail := "question unanswered for " + d.p.Timeout.String()
+	if d.plan {
+		detail = "plan awaiting approval for " + d.p.Timeout.String()
+	}
+	if d.offHours {
+		detail += " outside of working hours"
+	}
+	slog.Info("ask policy", "task", t.ID, "action", d.p.Action, "timeout", d.p.Timeout, "offHours", d.offHours)
+	switch d.p.Action {
+	case task.AskAnswer:
+		if d.plan {
+			t.Notify(ctx, "ask_timeout", detail+"; approving it")
+		} else {
+			t.Notify(ctx, "ask_timeout", detail+"; answering with the default")
+		}
+		if err := t.SendInput(ctx, agent.Prompt{Text: d.p.Reply()}); err != nil {
+			slog.Warn("ask policy: answer failed", "task", t.ID, "err", err)
+		}
+	case task.AskNotify:
+		t.Notify(ctx, "ask_timeout", detail)
+	case task.AskPause:
+		t.Notify(ctx, "ask_timeout", detail+"; pausing")
+		name := ""
+		if r := t.Primary(); r != nil {
+			name = r.Name
+		}
+		if err := s.runners[name].PauseSession(t); err != nil {
+			slog.Warn("ask policy: pause failed", "task", t.ID, "err", err)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestApplyAskPolicies(t *testing.T) {
+	s := newTestServer(t)
+	asking := func(p *task.AskPolicy) *taskEntry {
+		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Harness: agent.Claude, AskPolicy: p}
+		setState(t, tk, task.StateAsking)
+		return &taskEntry{task: tk, done: make(chan struct{})}
+	}
+	notify := asking(&task.AskPolicy{Timeout: 10 * time.Minute, Action: task.AskNotify})
+	answer := asking(&task.AskPolicy{Timeout: time.Hour, Action: task.AskAnswer})
+	none := asking(nil)
+	s.tasks["notify"] = notify
+	s.tasks["answer"] = answer
+	s.tasks["none"] = none
+	count := func(e *taskEntry) int {
+		n := 0
+		for _, m := range e.task.Messages() {
+			if sm, ok := m.(*agent.SystemMessage); ok && sm.Subtype == "ask_timeout" {
+				n++
+			}
+		}
+		return n
+	}
+	now := time.Now()
+	s.applyAskPolicies(t.Context(), now.Add(5*time.Minute))
+	if n := count(notify); n != 0 {
+		t.Errorf("before the timeout: %d events", n)
+	}
+	s.applyAskPolicies(t.Context(), now.Add(15*time.Minute))
+	s.applyAskPolicies(t.Context(), now.Add(20*time.Minute))
+	if n := count(notify); n != 1 {
+		t.Errorf("notify: %d events, want 1", n)
+	}
+	if n := count(answer); n != 0 {
+		t.Errorf("answer: %d events before its timeout", n)
+	}
+	// Without a session the answer can't be sent but the timeout is still
+	// reported.
+	s.applyAskPolicies(t.Context(), now.Add(2*time.Hour))
+	if n := count(answer); n != 1 {
+		t.Errorf("answer: %d events, want 1", n)
+	}
+	if n := count(none); n != 0 {
+		t.Errorf("no policy: %d events", n)
+	}
+	// A new question is handled again.
+	setState(t, notify.task, task.StateRunning)
+	setState(t, notify.task, task.StateAsking)
+	s.applyAskPolicies(t.Context(), time.Now().Add(time.Hour))
+	if n := count(notify); n != 2 {
+		t.Errorf("second question: %d events, want 2", n)
+	}
+	// Outside of working hours, the question already notified about is
+	// answered and plans are approved.
+	notify.task.Automation = task.AutomationUnattended
+	plan := asking(nil)
+	plan.task.Automation = task.AutomationUnattended
+	setState(t, plan.task, task.StateHasPlan)
+	s.tasks["plan"] = plan
+	s.applyAskPolicies(t.Context(), time.Now().Add(2*time.Hour))
+	if n := count(notify); n != 3 {
+		t.Errorf("unattended: %d events, want 3", n)
+	}
+	msgs := plan.task.Messages()
+	if sm, ok := msgs[len(msgs)-1].(*agent.SystemMessage); !ok || !strings.HasSuffix(sm.Detail, "outside of working hours; approving it") {
+		t.Errorf("plan: %#v", msgs[len(msgs)-1])
+	}
+}
@@ -55,8 +55,8 @@ func (s *Server) handleAuthStart(provider string) http.HandlerFunc {
 			MaxAge:   600,
 			HttpOnly: true,
 			SameSite: http.SameSiteLaxMode,
-			Secure:   s.useSecureCookies(),
-			Path:     "/",
+			Secure:   s.useSecureCookies(r),
+			Path:     s.basePath + "/",
 		})
 		http.Redirect(w, r, cfg.AuthURL(fullState), http.StatusFound)
 	}
@@ -79,8 +79,8 @@ func (s *Server) handleAuthCallback(provider string) http.HandlerFunc {
 			MaxAge:   -1,
 			HttpOnly: true,
 			SameSite: http.SameSiteLaxMode,
-			Secure:   s.useSecureCookies(),
-			Path:     "/",
+			Secure:   s.useSecureCookies(r),
+			Path:     s.basePath + "/",
 		})
 
 		// Validate state cookie.
@@ -178,14 +178,14 @@ func (s *Server) handleAuthCallback(provider string) http.HandlerFunc {
 			MaxAge:   sessionMaxAge,
 			HttpOnly: true,
 			SameSite: http.SameSiteStrictMode,
-			Secure:   s.useSecureCookies(),
-			Path:     "/",
+			Secure:   s.useSecureCookies(r),
+			Path:     s.basePath + "/",
 		})
 
 		if redirectMode == "app" {
 			http.Redirect(w, r, "caic://auth?token="+url.QueryEscape(jwt), http.StatusFound)
 		} else {
-			http.Redirect(w, r, "/", http.StatusFound)
+			http.Redirect(w, r, s.basePath+"/", http.StatusFound)
 		}
 	}
 }
@@ -213,8 +213,8 @@ func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
 		MaxAge:   -1,
 		HttpOnly: true,
 		SameSite: http.SameSiteStrictMode,
-		Secure:   s.useSecureCookies(),
-		Path:     "/",
+		Secure:   s.useSecureCookies(r),
+		Path:     s.basePath + "/",
 	})
 	writeJSONResponse(w, &v1.StatusResp{Status: "ok"}, nil)
 }
@@ -242,8 +242,12 @@ func (s *Server) oauthConfigFor(provider string) *auth.ProviderConfig {
 }
 
 // useSecureCookies reports whether to set the Secure flag on cookies.
-// True when the external URL starts with "https://".
-func (s *Server) useSecureCookies() bool {
+// True when the request came over https, directly or through a trusted proxy,
+// or the external URL starts with "https://".
+func (s *Server) useSecureCookies(r *http.Request) bool {
+	if s.tls.enabled() || requestIsHTTPS(r) {
+		return true
+	}
 	if s.githubOAuth != nil {
 		ru := s.githubOAuth.RedirectURI
 		if idx := strings.Index(ru, "/api/v1/"); idx >= 0 {
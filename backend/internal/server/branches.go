@@ -0,0 +1,281 @@
+// Per-repo limits on running tasks and on the task branches kept on origin.
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// branchPruneInterval is the period of the scheduled branch pruning.
+const branchPruneInterval = 24 * time.Hour
+
+// repoLimits caps the tasks of a repository. Zero values are unlimited.
+type repoLimits struct {
+	maxTasks    int
+	maxBranches int
+	retention   time.Duration // Age after which an unused unmerged branch may be pruned; 0 never.
+}
+
+// taskActive reports whether entry counts as running against a repository's
+// task limit: it has a live, non-stopped container and wasn't preempted.
+func taskActive(e *taskEntry) bool {
+	if e.result != nil || e.preempted {
+		return false
+	}
+	switch e.task.GetState() {
+	case task.StateStopped, task.StateFailed, task.StatePurged:
+		return false
+	default:
+		return true
+	}
+}
+
+// mountsRepo reports whether t mounts the repository at relative path repo.
+func mountsRepo(t *task.Task, repo string) bool {
+	return slices.ContainsFunc(t.Repos, func(m task.RepoMount) bool { return m.Name == repo })
+}
+
+// checkRepoLimits returns a 429 error when a new task of priority prio on
+// repos would exceed one of their limits. A repository at its task limit
+// passes when preemption is enabled and a lower priority task of it can be
+// paused. A repository with as many task branches on origin as allowed passes
+// only if some can be pruned; makeBranchRoom deletes them once the task is
+// admitted. It reserves nothing: insertTask checks the task limit again.
+func (s *Server) checkRepoLimits(ctx context.Context, repos []string, prio task.Priority) error {
+	s.mu.Lock()
+	var err error
+	for _, repo := range repos {
+		err = s.checkRepoTasksLocked([]string{repo})
+		if err != nil && !(s.preempt && s.repoVictimLocked([]string{repo}, prio) != nil) {
+			break
+		}
+		err = nil
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		l := s.repoLimits[repo]
+		if l.maxBranches <= 0 {
+			continue
+		}
+		bs, err := s.pruneBranches(ctx, repo, l.maxBranches-1, true)
+		if err != nil {
+			return dto.InternalError("list branches").Wrap(err)
+		}
+		n := 0
+		for _, b := range bs {
+			if !b.Pruned {
+				n++
+			}
+		}
+		if n >= l.maxBranches {
+			return dto.TooManyRequests(fmt.Sprintf("repo %s has %d task branches on origin, the limit, and none can be pruned", repo, n)).
+				WithDetail("repo", repo).
+				WithDetail("limit", l.maxBranches)
+		}
+	}
+	return nil
+}
+
+// checkRepoTasksLocked returns a 429 error when one of repos already has as
+// many active tasks as its limit. Checking and inserting the new task in the
+// same critical section reserves its slot. s.mu must be held.
+func (s *Server) checkRepoTasksLocked(repos []string) error {
+	for _, repo := range repos {
+		l := s.repoLimits[repo]
+		if l.maxTasks <= 0 {
+			continue
+		}
+		active := 0
+		for _, e := range s.tasks {
+			if taskActive(e) && mountsRepo(e.task, repo) {
+				active++
+			}
+		}
+		if active >= l.maxTasks {
+			return dto.TooManyRequests(fmt.Sprintf("repo %s has %d running tasks, the limit", repo, active)).
+				WithDetail("repo", repo).
+				WithDetail("limit", l.maxTasks)
+		}
+	}
+	return nil
+}
+
+// insertTask adds entry to the tasks, taking a slot in the task limit of each
+// of repos. When one is full and preemption is enabled, a lower priority task
+// of it is paused to make room.
+func (s *Server) insertTask(ctx context.Context, entry *taskEntry, repos []string) error {
+	for attempt := 0; ; attempt++ {
+		s.mu.Lock()
+		// Concurrent creations all passed preflight; the first ones take
+		// the remaining slots.
+		err := s.checkRepoTasksLocked(repos)
+		if err == nil {
+			s.tasks[entry.task.ID.String()] = entry
+			s.taskChanged()
+			s.mu.Unlock()
+			return nil
+		}
+		var victim *taskEntry
+		if s.preempt && attempt < len(repos) {
+			victim = s.repoVictimLocked(repos, entry.task.Priority)
+		}
+		s.mu.Unlock()
+		if victim == nil {
+			return err
+		}
+		s.preemptTask(ctx, victim, entry.task, "free a repo task slot")
+	}
+}
+
+// repoVictimLocked returns the task to preempt in the first of repos that is
+// at its task limit, see victimLocked. s.mu must be held.
+func (s *Server) repoVictimLocked(repos []string, prio task.Priority) *taskEntry {
+	for _, repo := range repos {
+		if s.checkRepoTasksLocked([]string{repo}) == nil {
+			continue
+		}
+		return s.victimLocked(prio, func(e *taskEntry) bool { return taskActive(e) && mountsRepo(e.task, repo) })
+	}
+	return nil
+}
+
+// makeBranchRoom prunes the task branches of repos on origin in the
+// background so that each keeps room for one more below its maxBranches.
+func (s *Server) makeBranchRoom(repos []string) {
+	for _, repo := range repos {
+		l := s.repoLimits[repo]
+		if l.maxBranches <= 0 {
+			continue
+		}
+		go func() {
+			if _, err := s.pruneBranches(s.ctx, repo, l.maxBranches-1, false); err != nil {
+				slog.Warn("prune branches", "repo", repo, "err", err)
+			}
+		}()
+	}
+}
+
+// pruneBranches deletes prunable task branches of repo from origin until at
+// most keep remain or no more can be pruned. It returns all the task branches
+// on origin, oldest first, with the deleted ones marked. With dryRun, nothing
+// is deleted and the branches that would be are marked.
+func (s *Server) pruneBranches(ctx context.Context, repo string, keep int, dryRun bool) ([]v1.TaskBranch, error) {
+	r, ok := s.runners[repo]
+	if !ok || r.Dir == "" {
+		return nil, fmt.Errorf("unknown repo %s", repo)
+	}
+	if !dryRun {
+		// Concurrent prunes would pick the same candidates.
+		s.pruneMu.Lock()
+		defer s.pruneMu.Unlock()
+	}
+	bs, err := r.RemoteBranches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inUse := s.branchInUse(repo)
+	out := make([]v1.TaskBranch, len(bs))
+	idx := make(map[string]int, len(bs))
+	for i, b := range bs {
+		out[i] = v1.TaskBranch{Name: b.Name, CommittedAt: float64(b.CommitTime.Unix()), Merged: b.Merged, InUse: inUse(b.Name)}
+		idx[b.Name] = i
+	}
+	n := len(bs)
+	for _, b := range task.PruneCandidates(bs, inUse, s.repoLimits[repo].retention, time.Now()) {
+		if n <= keep {
+			break
+		}
+		if !dryRun {
+			if err := r.DeleteRemoteBranch(ctx, b.Name); err != nil {
+				slog.WarnContext(ctx, "prune branch", "repo", repo, "br", b.Name, "err", err)
+				continue
+			}
+			slog.InfoContext(ctx, "prune branch", "repo", repo, "br", b.Name, "merged", b.Merged, "age", time.Since(b.CommitTime).Round(time.Hour))
+		}
+		out[idx[b.Name]].Pruned = true
+		n--
+	}
+	return out, nil
+}
+
+// pruneRepoBranches handles POST /api/v1/server/repos/branches/prune: it
+// deletes the merged task branches of a repo from origin, and the unused ones
+// older than its branchRetention setting.
+func (s *Server) pruneRepoBranches(ctx context.Context, req *v1.PruneBranchesReq) (*v1.PruneBranchesResp, error) {
+	if s.authStore != nil && !req.DryRun {
+		u, ok := auth.UserFromContext(ctx)
+		if !ok || !slices.Contains(s.admins, u.Username) {
+			return nil, dto.Forbidden("branch pruning")
+		}
+	}
+	if r, ok := s.runners[req.Repo]; !ok || r.Dir == "" {
+		return nil, dto.NotFound("repo")
+	}
+	bs, err := s.pruneBranches(ctx, req.Repo, 0, req.DryRun)
+	if err != nil {
+		return nil, dto.InternalError("prune branches").Wrap(err)
+	}
+	resp := &v1.PruneBranchesResp{Repo: req.Repo, DryRun: req.DryRun, Branches: bs}
+	if d := s.repoLimits[req.Repo].retention; d > 0 {
+		resp.Retention = d.String()
+	}
+	return resp, nil
+}
+
+// monitorBranches periodically prunes the task branches of the repos with a
+// branchRetention setting until the server context is cancelled.
+func (s *Server) monitorBranches() {
+	var repos []string
+	for repo, l := range s.repoLimits {
+		if l.retention > 0 {
+			repos = append(repos, repo)
+		}
+	}
+	if len(repos) == 0 {
+		return
+	}
+	ticker := time.NewTicker(branchPruneInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+		for _, repo := range repos {
+			if _, err := s.pruneBranches(s.ctx, repo, 0, false); err != nil {
+				slog.Warn("prune branches", "repo", repo, "err", err)
+			}
+		}
+	}
+}
+
+// branchInUse returns whether a branch of repo belongs to a task that isn't
+// purged, and thus must not be pruned.
+func (s *Server) branchInUse(repo string) func(string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	used := map[string]bool{}
+	for _, e := range s.tasks {
+		if e.task.GetState() == task.StatePurged {
+			continue
+		}
+		for _, m := range e.task.Repos {
+			if m.Name == repo && m.Branch != "" {
+				used[m.Branch] = true
+			}
+		}
+	}
+	return func(name string) bool { return used[name] }
+}
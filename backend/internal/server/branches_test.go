@@ -0,0 +1,171 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestCheckRepoLimits(t *testing.T) {
+	s := newTestServer(t)
+	s.repoLimits = map[string]repoLimits{"a": {maxTasks: 1}}
+	running := &task.Task{Repos: []task.RepoMount{{Name: "a"}}}
+	setState(t, running, task.StateRunning)
+	stopped := &task.Task{Repos: []task.RepoMount{{Name: "a"}}}
+	setState(t, stopped, task.StateStopped)
+	s.tasks["s"] = &taskEntry{task: stopped}
+	if err := s.checkRepoLimits(t.Context(), []string{"a"}, task.PriorityNormal); err != nil {
+		t.Fatalf("stopped task counted: %v", err)
+	}
+	s.tasks["r"] = &taskEntry{task: running}
+	if err := s.checkRepoLimits(t.Context(), []string{"b"}, task.PriorityNormal); err != nil {
+		t.Errorf("unlimited repo: %v", err)
+	}
+	err := s.checkRepoLimits(t.Context(), []string{"b", "a"}, task.PriorityNormal)
+	var apiErr *dto.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusTooManyRequests {
+		t.Errorf("err = %v", err)
+	}
+	// A task just inserted by newTask, not started yet, holds its slot.
+	s.repoLimits["c"] = repoLimits{maxTasks: 1}
+	s.tasks["p"] = &taskEntry{task: &task.Task{Repos: []task.RepoMount{{Name: "c"}}}}
+	if err := s.checkRepoLimits(t.Context(), []string{"c"}, task.PriorityNormal); err == nil {
+		t.Error("pending task didn't reserve its slot")
+	}
+}
+
+func TestInsertTaskPreempt(t *testing.T) {
+	s := newTestServer(t)
+	s.runners["a"] = &task.Runner{}
+	s.preempt = true
+	s.repoLimits = map[string]repoLimits{"a": {maxTasks: 1}}
+	low := &task.Task{ID: ksid.NewID(), Repos: []task.RepoMount{{Name: "a"}}, Priority: task.PriorityLow}
+	setState(t, low, task.StateRunning)
+	lowEntry := &taskEntry{task: low, done: make(chan struct{})}
+	s.tasks[low.ID.String()] = lowEntry
+	if err := s.checkRepoLimits(t.Context(), []string{"a"}, task.PriorityLow); err == nil {
+		t.Error("equal priority task passed a full repo")
+	}
+	if err := s.checkRepoLimits(t.Context(), []string{"a"}, task.PriorityHigh); err != nil {
+		t.Fatalf("high priority task refused: %v", err)
+	}
+	high := &task.Task{ID: ksid.NewID(), Repos: []task.RepoMount{{Name: "a"}}, Priority: task.PriorityHigh}
+	if err := s.insertTask(t.Context(), &taskEntry{task: high, done: make(chan struct{})}, []string{"a"}); err != nil {
+		t.Fatal(err)
+	}
+	if st := low.GetState(); st != task.StatePaused {
+		t.Errorf("low priority task state = %v, want paused", st)
+	}
+	// The preempted task needs its slot back before resuming.
+	if _, err := s.resumeTask(t.Context(), lowEntry, nil); err == nil {
+		t.Error("preempted task resumed into a full repo")
+	}
+}
+
+func TestRepoLimits(t *testing.T) {
+	s := &serverSettings{Repos: map[string]repoSettings{
+		"a": {MaxTasks: 2, MaxBranches: 50, BranchRetention: "720h"},
+		"b": {Image: "x"},
+	}}
+	got, err := s.repoLimits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["a"].maxTasks != 2 || got["a"].maxBranches != 50 || got["a"].retention.Hours() != 720 {
+		t.Errorf("got %+v", got)
+	}
+	for _, rs := range []repoSettings{{MaxTasks: -1}, {BranchRetention: "soon"}, {BranchRetention: "-1h"}} {
+		s.Repos["c"] = rs
+		if _, err := s.repoLimits(); err == nil {
+			t.Errorf("%+v accepted", rs)
+		}
+	}
+}
+
+func TestPruneRepoBranches(t *testing.T) {
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...) //nolint:gosec // test helper with controlled args
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	dir := t.TempDir()
+	bare, clone := filepath.Join(dir, "remote.git"), filepath.Join(dir, "clone")
+	git(dir, "init", "-q", "--bare", bare)
+	git(dir, "clone", "-q", bare, clone)
+	git(clone, "-c", "user.name=T", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "init")
+	git(clone, "push", "-q", "origin", "HEAD:main", "HEAD:caic-1", "HEAD:caic-2")
+	git(clone, "-c", "user.name=T", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "wip")
+	git(clone, "push", "-q", "origin", "HEAD:caic-3")
+	git(clone, "fetch", "-q", "origin")
+
+	s := newTestServer(t)
+	s.runners["r"] = &task.Runner{BaseBranch: "main", Dir: clone}
+	s.repoLimits = map[string]repoLimits{"r": {maxBranches: 2}}
+	// caic-2 is merged but used by a live task.
+	live := &task.Task{Repos: []task.RepoMount{{Name: "r", Branch: "caic-2"}}}
+	setState(t, live, task.StateWaiting)
+	s.tasks["live"] = &taskEntry{task: live}
+
+	resp, err := s.pruneRepoBranches(t.Context(), &v1.PruneBranchesReq{Repo: "r", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pruned []string
+	for _, b := range resp.Branches {
+		if b.Pruned {
+			pruned = append(pruned, b.Name)
+		}
+	}
+	if len(resp.Branches) != 3 || len(pruned) != 1 || pruned[0] != "caic-1" {
+		t.Fatalf("dry run: %+v", resp.Branches)
+	}
+	if bs, _ := s.runners["r"].RemoteBranches(t.Context()); len(bs) != 3 {
+		t.Fatalf("dry run deleted branches: %+v", bs)
+	}
+
+	// Pruning caic-1 doesn't make room for a new task; the check deletes
+	// nothing itself.
+	if err := s.checkRepoLimits(t.Context(), []string{"r"}, task.PriorityNormal); err == nil {
+		t.Error("limit not enforced")
+	}
+	if bs, _ := s.runners["r"].RemoteBranches(t.Context()); len(bs) != 3 {
+		t.Errorf("check deleted branches: %+v", bs)
+	}
+	// With one more allowed, the task is admitted and makeBranchRoom prunes
+	// caic-1.
+	s.repoLimits["r"] = repoLimits{maxBranches: 3}
+	if err := s.checkRepoLimits(t.Context(), []string{"r"}, task.PriorityNormal); err != nil {
+		t.Error(err)
+	}
+	if _, err := s.pruneBranches(t.Context(), "r", 2, false); err != nil {
+		t.Fatal(err)
+	}
+	bs, _ := s.runners["r"].RemoteBranches(t.Context())
+	if len(bs) != 2 || bs[0].Name == "caic-1" || bs[1].Name == "caic-1" {
+		t.Errorf("after pruning: %+v", bs)
+	}
+
+	// With a retention, the stale unmerged caic-3 goes too.
+	s.repoLimits["r"] = repoLimits{retention: time.Nanosecond}
+	if resp, err = s.pruneRepoBranches(t.Context(), &v1.PruneBranchesReq{Repo: "r"}); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Retention != "1ns" || len(resp.Branches) != 2 || !resp.Branches[1].Pruned || resp.Branches[0].Pruned {
+		t.Errorf("got %+v", resp)
+	}
+	if _, err := s.pruneRepoBranches(t.Context(), &v1.PruneBranchesReq{Repo: "nope"}); err == nil {
+		t.Error("unknown repo accepted")
+	}
+}
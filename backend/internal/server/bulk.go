@@ -0,0 +1,99 @@
+// Bulk task operations with per-item results.
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// bulkTasks applies the action to each task in order. A failure does not
+// stop the remaining tasks; it is reported in the task's result.
+func (s *Server) bulkTasks(ctx context.Context, req *v1.BulkTasksReq) (*v1.BulkTasksResp, error) {
+	resp := &v1.BulkTasksResp{Results: make([]v1.BulkTaskResult, len(req.IDs))}
+	for i, id := range req.IDs {
+		res := &resp.Results[i]
+		res.ID = id
+		entry, err := s.lookupTask(ctx, id.String())
+		if err == nil {
+			err = s.bulkTask(ctx, req.Action, entry, res)
+		}
+		if err != nil {
+			res.Error = err.Error()
+			var apiErr *dto.APIError
+			if errors.As(err, &apiErr) {
+				res.Code = string(apiErr.Code())
+			}
+		}
+	}
+	s.notifyTaskChange()
+	return resp, nil
+}
+
+// bulkTask applies action to entry through the single-task handlers so the
+// state checks are identical.
+func (s *Server) bulkTask(ctx context.Context, action v1.BulkAction, entry *taskEntry, res *v1.BulkTaskResult) error {
+	var st *v1.StatusResp
+	var err error
+	switch action {
+	case v1.BulkTerminate:
+		st, err = s.purgeTask(ctx, entry, nil)
+	case v1.BulkArchive:
+		st, err = s.stopTask(ctx, entry, nil)
+	case v1.BulkRetry:
+		var cr *v1.CreateTaskResp
+		if cr, err = s.retryTask(ctx, entry); err == nil {
+			st = &v1.StatusResp{Status: cr.Status}
+			res.TaskID = cr.ID
+		}
+	default:
+		err = dto.BadRequest("unknown action: " + string(action))
+	}
+	if err != nil {
+		return err
+	}
+	res.Status = st.Status
+	return nil
+}
+
+// retryTask creates a new task with the parameters of a failed or purged
+// task. Branches are allocated anew.
+func (s *Server) retryTask(ctx context.Context, entry *taskEntry) (*v1.CreateTaskResp, error) {
+	t := entry.task
+	if state := t.GetState(); state != task.StateFailed && state != task.StatePurged {
+		return nil, dto.Conflict("task is not failed or purged")
+	}
+	req := taskCreateReq(t)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	return s.createTask(ctx, req)
+}
+
+// taskCreateReq returns the creation request reproducing t's parameters.
+func taskCreateReq(t *task.Task) *v1.CreateTaskReq {
+	req := &v1.CreateTaskReq{
+		InitialPrompt: v1.Prompt{Text: t.InitialPrompt.Text},
+		Model:         t.Model,
+		Harness:       toV1Harness(t.Harness),
+		Image:         t.DockerImage,
+		Tailscale:     t.Tailscale,
+		USB:           t.USB,
+		Display:       t.Display,
+		GPU:           t.GPU,
+		AutoResume:    t.AutoResume,
+		AskPolicy:     toV1AskPolicy(t.AskPolicy),
+		Automation:    v1.Automation(t.Automation),
+		Priority:      toV1Priority(t.Priority),
+	}
+	for _, img := range t.InitialPrompt.Images {
+		req.InitialPrompt.Images = append(req.InitialPrompt.Images, v1.ImageData{MediaType: img.MediaType, Data: img.Data})
+	}
+	for _, r := range t.Repos {
+		req.Repos = append(req.Repos, v1.RepoSpec{Name: r.Name, BaseBranch: r.BaseBranch, Paths: r.SparsePaths})
+	}
+	return req
+}
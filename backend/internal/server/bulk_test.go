@@ -0,0 +1,101 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestBulkTasks(t *testing.T) {
+	newServer := func(t *testing.T) (*Server, []ksid.ID) {
+		s := newTestServer(t)
+		s.runners["r"] = &task.Runner{
+			BaseBranch: "main",
+			Dir:        t.TempDir(),
+			Backends:   map[agent.Harness]agent.Backend{agent.Claude: stubBackend{}},
+		}
+		var ids []ksid.ID
+		for _, st := range []task.State{task.StateWaiting, task.StatePending, task.StateFailed} {
+			tk := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "fix it"}, Harness: agent.Claude, Repos: []task.RepoMount{{Name: "r", Branch: "caic-0"}}}
+			setState(t, tk, st)
+			s.tasks[tk.ID.String()] = &taskEntry{task: tk, done: make(chan struct{})}
+			ids = append(ids, tk.ID)
+		}
+		return s, append(ids, ksid.NewID())
+	}
+	run := func(t *testing.T, s *Server, action v1.BulkAction, ids []ksid.ID) []v1.BulkTaskResult {
+		body, _ := json.Marshal(v1.BulkTasksReq{Action: action, IDs: ids})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/bulk", strings.NewReader(string(body)))
+		w := httptest.NewRecorder()
+		handle(s.bulkTasks)(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp v1.BulkTasksResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Results) != len(ids) {
+			t.Fatalf("results = %+v", resp.Results)
+		}
+		for i, r := range resp.Results {
+			if r.ID != ids[i] {
+				t.Errorf("results[%d].id = %v, want %v", i, r.ID, ids[i])
+			}
+		}
+		return resp.Results
+	}
+
+	t.Run("Terminate", func(t *testing.T) {
+		s, ids := newServer(t)
+		res := run(t, s, v1.BulkTerminate, ids)
+		if res[0].Status != "purging" || res[0].Error != "" {
+			t.Errorf("waiting: %+v", res[0])
+		}
+		if res[1].Code != string(dto.CodeConflict) {
+			t.Errorf("pending: %+v", res[1])
+		}
+		if res[3].Code != string(dto.CodeNotFound) {
+			t.Errorf("missing: %+v", res[3])
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		s, ids := newServer(t)
+		res := run(t, s, v1.BulkRetry, ids)
+		if res[0].Code != string(dto.CodeConflict) {
+			t.Errorf("waiting: %+v", res[0])
+		}
+		if res[2].Status != "accepted" || res[2].TaskID == 0 {
+			t.Fatalf("failed: %+v", res[2])
+		}
+		s.mu.Lock()
+		e := s.tasks[res[2].TaskID.String()]
+		s.mu.Unlock()
+		if e == nil {
+			t.Fatal("retry task not created")
+		}
+		if e.task.InitialPrompt.Text != "fix it" || e.task.Repos[0].Name != "r" {
+			t.Errorf("retry task = %+v", e.task)
+		}
+	})
+
+	t.Run("InvalidAction", func(t *testing.T) {
+		s, ids := newServer(t)
+		body := `{"action":"explode","ids":["` + ids[0].String() + `"]}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/bulk", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		handle(s.bulkTasks)(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -180,7 +180,7 @@ func (s *Server) autoResync(ctx context.Context, entry *taskEntry, f forge.Forge
 	}
 
 	slog.Info("autoResync: syncing branch", "task", t.ID, "br", p.Branch)
-	if _, _, err := runner.SyncToOrigin(ctx, p.Branch, t.Container, false, t.ExtraMDRepos()); err != nil {
+	if _, _, err := runner.SyncToOrigin(ctx, p.Branch, t.Container, false, t.ExtraMDRepos(), s.syncOptions(runner, t)); err != nil {
 		slog.Warn("autoResync: sync failed", "task", t.ID, "err", err)
 		return
 	}
@@ -55,6 +55,12 @@ type compressWriter struct {
 }
 
 func (cw *compressWriter) WriteHeader(code int) {
+	// Responses without a body, e.g. a 304 answering a static file ETag
+	// revalidation, must not advertise an encoding.
+	if !cw.headerSent && (code == http.StatusNotModified || code == http.StatusNoContent) {
+		cw.headerSent = true
+		cw.skipCompress = true
+	}
 	cw.initOnce()
 	cw.ResponseWriter.WriteHeader(code)
 }
@@ -82,6 +88,11 @@ func (cw *compressWriter) initOnce() {
 		cw.skipCompress = true
 		return
 	}
+	// Skip payloads that are already compressed, e.g. task snapshots.
+	if h.Get("Content-Type") == "application/gzip" {
+		cw.skipCompress = true
+		return
+	}
 
 	// Compressed size differs from original; remove Content-Length.
 	h.Del("Content-Length")
@@ -121,6 +121,24 @@ func TestCompressMiddleware(t *testing.T) {
 		}
 	})
 
+	t.Run("SkipsGzipPayload", func(t *testing.T) {
+		h := compressMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/gzip")
+			_, _ = w.Write([]byte("archive"))
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		req.Header.Set("Accept-Encoding", "zstd")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != "" {
+			t.Errorf("Content-Encoding = %q, want none", got)
+		}
+		if w.Body.String() != "archive" {
+			t.Errorf("body = %q", w.Body.String())
+		}
+	})
+
 	t.Run("CompressesSSE", func(t *testing.T) {
 		h := compressMiddleware(sseHandler())
 		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
@@ -0,0 +1,51 @@
+// Listing of the containers caic started, with their task metadata.
+package server
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// listContainers returns the containers carrying the caic label, flagging
+// those no live task uses.
+func (s *Server) listContainers(ctx context.Context, _ *dto.EmptyReq) (*v1.ContainersResp, error) {
+	resp := &v1.ContainersResp{Containers: []v1.ContainerInfo{}}
+	if s.caicContainers == nil {
+		return resp, nil
+	}
+	infos, err := s.caicContainers(ctx)
+	if err != nil {
+		return nil, dto.InternalError("listing containers").Wrap(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range infos {
+		ci := v1.ContainerInfo{
+			Name:      c.Name,
+			State:     c.State,
+			CreatedAt: float64(c.CreatedAt.UnixMilli()) / 1e3,
+			TaskID:    c.Meta.TaskID,
+			Harness:   c.Meta.Harness,
+			Repo:      c.Meta.Repo,
+			Branch:    c.Meta.Branch,
+			Owner:     c.Meta.Owner,
+			Orphan:    true,
+		}
+		if !c.Meta.CreatedAt.IsZero() {
+			ci.TaskCreatedAt = float64(c.Meta.CreatedAt.UnixMilli()) / 1e3
+		}
+		if e := s.tasks[c.Meta.TaskID]; e != nil {
+			st := e.task.GetState()
+			ci.TaskState = st.String()
+			ci.Orphan = st == task.StatePurged || st == task.StateFailed || (e.task.Container != "" && e.task.Container != c.Name)
+		}
+		resp.Containers = append(resp.Containers, ci)
+	}
+	slices.SortFunc(resp.Containers, func(a, b v1.ContainerInfo) int { return strings.Compare(a.Name, b.Name) })
+	return resp, nil
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/container"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestListContainers(t *testing.T) {
+	s := newTestServer(t)
+	add := func(id, ctr string, st task.State) {
+		tk := &task.Task{Container: ctr}
+		setState(t, tk, st)
+		s.tasks[id] = &taskEntry{task: tk, done: make(chan struct{})}
+	}
+	add("live", "md-r-caic-1", task.StateRunning)
+	add("purged", "md-r-caic-2", task.StatePurged)
+	add("moved", "md-r-caic-9", task.StateWaiting)
+	created := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.caicContainers = func(context.Context) ([]container.Info, error) {
+		return []container.Info{
+			{Name: "md-r-caic-4", State: "exited", CreatedAt: created, Meta: container.Meta{TaskID: "unknown"}},
+			{Name: "md-r-caic-1", State: "running", CreatedAt: created, Meta: container.Meta{TaskID: "live", Repo: "r", Branch: "caic-1", Owner: "u1", CreatedAt: created}},
+			{Name: "md-r-caic-2", State: "running", CreatedAt: created, Meta: container.Meta{TaskID: "purged"}},
+			{Name: "md-r-caic-3", State: "running", CreatedAt: created, Meta: container.Meta{TaskID: "moved"}},
+		}, nil
+	}
+	resp, err := s.listContainers(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]bool{"md-r-caic-1": false, "md-r-caic-2": true, "md-r-caic-3": true, "md-r-caic-4": true}
+	if len(resp.Containers) != len(want) {
+		t.Fatalf("got %d containers", len(resp.Containers))
+	}
+	for i, c := range resp.Containers {
+		if i > 0 && resp.Containers[i-1].Name > c.Name {
+			t.Errorf("containers not sorted: %s after %s", c.Name, resp.Containers[i-1].Name)
+		}
+		if c.Orphan != want[c.Name] {
+			t.Errorf("%s: Orphan = %v, want %v", c.Name, c.Orphan, want[c.Name])
+		}
+	}
+	live := resp.Containers[0]
+	if live.TaskState != "running" || live.Repo != "r" || live.Branch != "caic-1" || live.Owner != "u1" || live.TaskCreatedAt != float64(created.Unix()) {
+		t.Errorf("live container = %+v", live)
+	}
+	if resp.Containers[3].TaskState != "" || resp.Containers[3].TaskCreatedAt != 0 {
+		t.Errorf("unknown container = %+v", resp.Containers[3])
+	}
+}
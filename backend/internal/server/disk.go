@@ -0,0 +1,140 @@
+// Container disk usage monitoring and cleanup.
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+const (
+	defaultDiskProbeInterval = 5 * time.Minute
+	defaultDiskWarnPercent   = 90
+	// diskProbeTimeout bounds a single probe.
+	diskProbeTimeout = 2 * time.Minute
+	// diskCleanTimeout bounds the cleanup command; caches can take a while
+	// to delete.
+	diskCleanTimeout = 10 * time.Minute
+)
+
+// diskConfig is the parsed form of diskSettings.
+type diskConfig struct {
+	interval     time.Duration
+	warnPercent  int
+	cleanCommand string
+}
+
+// monitorDisk periodically probes disk usage in every live container until
+// the server context is cancelled.
+func (s *Server) monitorDisk() {
+	interval := s.disk.interval
+	if interval <= 0 {
+		interval = defaultDiskProbeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+		s.mu.Lock()
+		entries := make([]*taskEntry, 0, len(s.tasks))
+		for _, e := range s.tasks {
+			if e.task.Container != "" && diskProbeable(e.task.GetState()) {
+				entries = append(entries, e)
+			}
+		}
+		s.mu.Unlock()
+		changed := false
+		for _, e := range entries {
+			if s.probeTaskDisk(s.ctx, e.task) == nil {
+				changed = true
+			}
+		}
+		if changed {
+			s.notifyTaskChange()
+		}
+	}
+}
+
+// diskProbeable reports whether a task in state has a running container
+// worth probing.
+func diskProbeable(st task.State) bool {
+	switch st {
+	case task.StateRunning, task.StateWaiting, task.StateAsking, task.StateHasPlan, task.StatePaused, task.StatePulling, task.StatePushing:
+		return true
+	default:
+		return false
+	}
+}
+
+// probeTaskDisk measures disk usage in t's container and records it.
+func (s *Server) probeTaskDisk(ctx context.Context, t *task.Task) error {
+	ctx, cancel := context.WithTimeout(ctx, diskProbeTimeout)
+	defer cancel()
+	du, err := task.ProbeDisk(ctx, t.Container)
+	if err != nil {
+		slog.Warn("disk probe failed", "task", t.ID, "ctr", t.Container, "err", err)
+		return err
+	}
+	t.SetDiskUsage(ctx, du, s.disk.warnPercent)
+	return nil
+}
+
+// cleanTask runs the configured cleanup command inside the task's container
+// and returns the refreshed disk usage.
+func (s *Server) cleanTask(_ context.Context, entry *taskEntry, _ *dto.EmptyReq) (*v1.CleanTaskResp, error) {
+	if s.disk.cleanCommand == "" {
+		return nil, dto.BadRequest("no cleanup command configured").WithDetail("setting", "disk.cleanCommand")
+	}
+	t := entry.task
+	if t.Container == "" || !diskProbeable(t.GetState()) {
+		return nil, dto.Conflict("task has no running container")
+	}
+	gitRoot := ""
+	if p := t.Primary(); p != nil {
+		gitRoot = p.GitRoot
+	}
+	// Use the server-lifetime context so a client disconnect does not kill
+	// the cleanup halfway through.
+	ctx, cancel := context.WithTimeout(s.ctx, diskCleanTimeout)
+	defer cancel()
+	slog.Info("disk clean", "task", t.ID, "ctr", t.Container)
+	out, err := task.CleanDisk(ctx, t.Container, gitRoot, s.disk.cleanCommand) //nolint:contextcheck // intentionally using server context
+	if err != nil {
+		return nil, dto.InternalError(err.Error()).WithDetail("output", out)
+	}
+	resp := &v1.CleanTaskResp{Output: out}
+	if s.probeTaskDisk(ctx, t) == nil { //nolint:contextcheck // intentionally using server context
+		du := t.DiskUsage()
+		resp.DiskUsage = toV1DiskUsage(&du)
+		s.notifyTaskChange()
+	}
+	return resp, nil
+}
+
+// toV1DiskUsage converts a probe result; nil when the container was never
+// probed.
+func toV1DiskUsage(du *task.DiskUsage) *v1.DiskUsage {
+	if du.CheckedAt.IsZero() {
+		return nil
+	}
+	out := &v1.DiskUsage{
+		UsedBytes:  du.UsedBytes,
+		TotalBytes: du.TotalBytes,
+		CheckedAt:  float64(du.CheckedAt.UnixMilli()) / 1e3,
+	}
+	if len(du.Dirs) > 0 {
+		out.Dirs = make([]v1.DirSize, len(du.Dirs))
+		for i, d := range du.Dirs {
+			out.Dirs[i] = v1.DirSize{Path: d.Path, Bytes: d.Bytes}
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestCleanTask(t *testing.T) {
+	t.Run("NotConfigured", func(t *testing.T) {
+		s := newTestServer(t)
+		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Container: "md-r-caic-0"}
+		setState(t, tk, task.StateWaiting)
+		s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/t1/clean", http.NoBody)
+		req.SetPathValue("id", "t1")
+		w := httptest.NewRecorder()
+		handleWithTask(s, s.cleanTask)(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+	t.Run("NotRunning", func(t *testing.T) {
+		s := newTestServer(t)
+		s.disk.cleanCommand = "true"
+		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Container: "md-r-caic-0"}
+		setState(t, tk, task.StateStopped)
+		s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/t1/clean", http.NoBody)
+		req.SetPathValue("id", "t1")
+		w := httptest.NewRecorder()
+		handleWithTask(s, s.cleanTask)(w, req)
+		if w.Code != http.StatusConflict {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusConflict)
+		}
+		if e := decodeError(t, w); e.Code != dto.CodeConflict {
+			t.Errorf("code = %q, want %q", e.Code, dto.CodeConflict)
+		}
+	})
+}
+
+func TestDiskConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		c, err := (&serverSettings{}).diskConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.interval != defaultDiskProbeInterval || c.warnPercent != defaultDiskWarnPercent {
+			t.Errorf("got %+v", c)
+		}
+	})
+	t.Run("Custom", func(t *testing.T) {
+		c, err := (&serverSettings{Disk: diskSettings{ProbeInterval: "30s", WarnPercent: 75, CleanCommand: "git clean -fdX"}}).diskConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.interval != 30*time.Second || c.warnPercent != 75 || c.cleanCommand != "git clean -fdX" {
+			t.Errorf("got %+v", c)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		for _, d := range []diskSettings{{ProbeInterval: "soon"}, {ProbeInterval: "-1m"}, {WarnPercent: 101}} {
+			if _, err := (&serverSettings{Disk: d}).diskConfig(); err == nil {
+				t.Errorf("diskConfig(%+v) succeeded", d)
+			}
+		}
+	})
+}
+
+func TestToV1DiskUsage(t *testing.T) {
+	if got := toV1DiskUsage(&task.DiskUsage{}); got != nil {
+		t.Errorf("unprobed = %+v, want nil", got)
+	}
+	du := task.DiskUsage{UsedBytes: 10, TotalBytes: 20, Dirs: []task.DirSize{{Path: "/home/user/.cache", Bytes: 5}}, CheckedAt: time.Unix(100, 0)}
+	got := toV1DiskUsage(&du)
+	if got == nil || got.UsedBytes != 10 || got.CheckedAt != 100 || len(got.Dirs) != 1 || got.Dirs[0].Bytes != 5 {
+		t.Errorf("toV1DiskUsage = %+v", got)
+	}
+}
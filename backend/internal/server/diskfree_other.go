@@ -0,0 +1,11 @@
+//go:build !unix
+
+// Free disk space where it isn't measured.
+package server
+
+import "errors"
+
+// freeDiskSpace is not implemented on this OS; the doctor skips the check.
+func freeDiskSpace(string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,167 @@
+// Self-check of the host dependencies tasks need, run at startup and served
+// as the doctor endpoint.
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/container"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+const (
+	// doctorDiskWarn and doctorDiskFail are the free space thresholds of the
+	// cache directory, where session logs are written.
+	doctorDiskWarn = 10 << 30
+	doctorDiskFail = 1 << 30
+	// doctorCmdTimeout bounds each command the doctor runs.
+	doctorCmdTimeout = 10 * time.Second
+)
+
+// doctor checks the tools, credentials and storage tasks depend on, so a
+// broken host shows up as an actionable list instead of tasks failing at
+// provisioning.
+func (s *Server) doctor(ctx context.Context) []v1.DoctorCheck {
+	var checks []v1.DoctorCheck
+	add := func(name string, st v1.DoctorStatus, msg string) {
+		checks = append(checks, v1.DoctorCheck{Name: name, Status: st, Message: msg})
+	}
+
+	if v, err := toolVersion(ctx, "git", "--version"); err != nil {
+		add("git", v1.DoctorFail, err.Error()+"; install git")
+	} else {
+		add("git", v1.DoctorPass, v)
+	}
+
+	switch c := s.runtimeCaps; {
+	case c == nil:
+		add("runtime", v1.DoctorFail, "no container runtime found; install docker or podman and make sure its daemon is running")
+	case len(c.Missing()) != 0:
+		var msgs []string
+		for _, f := range c.Missing() {
+			msgs = append(msgs, c.Check(f).Error())
+		}
+		add("runtime", v1.DoctorWarn, strings.Join(msgs, "; "))
+	default:
+		add("runtime", v1.DoctorPass, c.Runtime+" "+c.Version+", md "+container.MDVersion())
+	}
+
+	if p, err := exec.LookPath("ssh"); err != nil {
+		add("ssh", v1.DoctorFail, "ssh not found; md connects to containers over ssh, install an ssh client")
+	} else {
+		add("ssh", v1.DoctorPass, p)
+	}
+
+	for _, h := range s.doctorHarnesses() {
+		// The harness runs in the container; the host CLI is how the user
+		// logs in and creates the credentials md mounts.
+		if p, err := exec.LookPath(string(h)); err != nil {
+			add("cli:"+string(h), v1.DoctorWarn, string(h)+" not found; install it on the host to log in")
+		} else {
+			add("cli:"+string(h), v1.DoctorPass, p)
+		}
+		if found, paths, _ := findHarnessConfig(h); found == "" {
+			add("credentials:"+string(h), v1.DoctorWarn, missingConfigMessage(h, paths))
+		} else {
+			add("credentials:"+string(h), v1.DoctorPass, found)
+		}
+	}
+
+	if err := checkWritable(s.logDir); err != nil {
+		add("logDir", v1.DoctorFail, err.Error())
+	} else {
+		add("logDir", v1.DoctorPass, s.logDir)
+	}
+
+	if free, err := freeDiskSpace(s.logDir); err == nil {
+		msg := fmt.Sprintf("%.1f GiB free in %s", float64(free)/(1<<30), s.logDir)
+		switch {
+		case free < doctorDiskFail:
+			add("disk", v1.DoctorFail, msg+"; free up space")
+		case free < doctorDiskWarn:
+			add("disk", v1.DoctorWarn, msg)
+		default:
+			add("disk", v1.DoctorPass, msg)
+		}
+	}
+	return checks
+}
+
+// doctorHarnesses returns the built-in harnesses the server has backends
+// for, sorted.
+func (s *Server) doctorHarnesses() []agent.Harness {
+	r := s.runners[""]
+	if r == nil {
+		return nil
+	}
+	var out []agent.Harness
+	for h := range r.Backends {
+		if _, ok := mdHarnesses[h]; ok {
+			out = append(out, h)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// toolVersion runs a tool to print its version, reporting a missing or
+// broken tool.
+func toolVersion(ctx context.Context, name string, args ...string) (string, error) {
+	p, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s not found", name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, doctorCmdTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, p, args...).Output() //nolint:gosec // p resolved via LookPath
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// checkWritable verifies that files can be created in dir, creating it if
+// needed.
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	err = f.Close()
+	if err2 := os.Remove(name); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// logDoctor runs the doctor and logs the checks that didn't pass.
+func (s *Server) logDoctor(ctx context.Context) {
+	for _, c := range s.doctor(ctx) {
+		switch c.Status {
+		case v1.DoctorFail:
+			slog.Error("doctor", "check", c.Name, "msg", c.Message)
+		case v1.DoctorWarn:
+			slog.Warn("doctor", "check", c.Name, "msg", c.Message)
+		default:
+			slog.Debug("doctor", "check", c.Name, "msg", c.Message)
+		}
+	}
+}
+
+// getDoctor reports the outcome of the doctor checks.
+func (s *Server) getDoctor(ctx context.Context, _ *dto.EmptyReq) (*v1.DoctorResp, error) {
+	return &v1.DoctorResp{Checks: s.doctor(ctx)}, nil
+}
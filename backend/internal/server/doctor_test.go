@@ -0,0 +1,86 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/container"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestDoctor(t *testing.T) {
+	statuses := func(checks []v1.DoctorCheck) map[string]v1.DoctorStatus {
+		m := make(map[string]v1.DoctorStatus, len(checks))
+		for _, c := range checks {
+			m[c.Name] = c.Status
+		}
+		return m
+	}
+
+	t.Run("Healthy", func(t *testing.T) {
+		s := &Server{
+			logDir:      filepath.Join(t.TempDir(), "logs"),
+			runtimeCaps: &container.Capabilities{Runtime: "docker", Version: "27.1.1"},
+			runners: map[string]*task.Runner{
+				"": {Backends: map[agent.Harness]agent.Backend{"stub": stubBackend{}}},
+			},
+		}
+		got := statuses(s.doctor(t.Context()))
+		for _, name := range []string{"git", "runtime", "logDir"} {
+			if got[name] != v1.DoctorPass {
+				t.Errorf("%s = %q, want pass", name, got[name])
+			}
+		}
+		if _, ok := got["cli:stub"]; ok {
+			t.Error("checked the CLI of a harness md doesn't know")
+		}
+		if entries, err := os.ReadDir(s.logDir); err != nil || len(entries) != 0 {
+			t.Errorf("logDir left %v, %v", entries, err)
+		}
+	})
+
+	t.Run("Broken", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		s := &Server{
+			logDir:      filepath.Join(file, "logs"),
+			runtimeCaps: nil,
+			runners: map[string]*task.Runner{
+				"": {Backends: map[agent.Harness]agent.Backend{agent.Claude: stubBackend{}}},
+			},
+		}
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("XDG_CONFIG_HOME", "")
+		got := statuses(s.doctor(t.Context()))
+		want := map[string]v1.DoctorStatus{
+			"runtime":            v1.DoctorFail,
+			"logDir":             v1.DoctorFail,
+			"credentials:claude": v1.DoctorWarn,
+		}
+		for name, st := range want {
+			if got[name] != st {
+				t.Errorf("%s = %q, want %q", name, got[name], st)
+			}
+		}
+		if _, ok := got["cli:claude"]; !ok {
+			t.Error("missing cli:claude check")
+		}
+	})
+
+	t.Run("OldRuntime", func(t *testing.T) {
+		s := &Server{
+			logDir:      t.TempDir(),
+			runtimeCaps: &container.Capabilities{Runtime: "docker", Version: "20.10.5"},
+		}
+		for _, c := range s.doctor(t.Context()) {
+			if c.Name == "runtime" && c.Status != v1.DoctorWarn {
+				t.Errorf("runtime = %+v, want warn", c)
+			}
+		}
+	})
+}
@@ -0,0 +1,277 @@
+// Draft tasks: task parameters saved server-side and started later, alone or
+// in bulk.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+// draft is a task that was saved but not started. No branch nor container is
+// allocated until it is started.
+type draft struct {
+	ID        ksid.ID          `json:"id"`
+	OwnerID   string           `json:"ownerId,omitempty"`
+	Req       v1.CreateTaskReq `json:"req"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// draftStore persists all drafts in a single JSON file. An empty path keeps
+// drafts in memory only.
+type draftStore struct {
+	path string
+
+	mu     sync.Mutex
+	drafts map[ksid.ID]*draft
+}
+
+// openDraftStore loads the drafts stored at path, if any.
+func openDraftStore(path string) (*draftStore, error) {
+	d := &draftStore{path: path, drafts: map[ksid.ID]*draft{}}
+	if path == "" {
+		return d, nil
+	}
+	data, err := readSealed(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*draft
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, dr := range list {
+		d.drafts[dr.ID] = dr
+	}
+	return d, nil
+}
+
+// saveLocked atomically writes all drafts. Must be called with d.mu held.
+func (d *draftStore) saveLocked() error {
+	if d.path == "" {
+		return nil
+	}
+	list := d.listLocked()
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeSealedAtomic(d.path, data)
+}
+
+func (d *draftStore) listLocked() []*draft {
+	list := make([]*draft, 0, len(d.drafts))
+	for _, dr := range d.drafts {
+		list = append(list, dr)
+	}
+	slices.SortFunc(list, func(a, b *draft) int { return strings.Compare(a.ID.String(), b.ID.String()) })
+	return list
+}
+
+// list returns copies of the drafts visible to ownerID, oldest first.
+func (d *draftStore) list(ownerID string) []draft {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var out []draft
+	for _, dr := range d.listLocked() {
+		if draftVisible(dr, ownerID) {
+			out = append(out, *dr)
+		}
+	}
+	return out
+}
+
+// get returns a copy of the draft id if visible to ownerID.
+func (d *draftStore) get(id ksid.ID, ownerID string) (draft, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dr, ok := d.drafts[id]
+	if !ok {
+		return draft{}, dto.NotFound("draft")
+	}
+	if !draftVisible(dr, ownerID) {
+		return draft{}, dto.Forbidden("draft")
+	}
+	return *dr, nil
+}
+
+// put adds or replaces a draft.
+func (d *draftStore) put(dr *draft) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.drafts[dr.ID] = dr
+	return d.saveLocked()
+}
+
+// remove deletes the draft id. It is not an error if it is already gone.
+func (d *draftStore) remove(id ksid.ID) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.drafts[id]; !ok {
+		return nil
+	}
+	delete(d.drafts, id)
+	return d.saveLocked()
+}
+
+// draftVisible mirrors task ownership: drafts without an owner are shared.
+func draftVisible(dr *draft, ownerID string) bool {
+	return ownerID == "" || dr.OwnerID == "" || dr.OwnerID == ownerID
+}
+
+// draftOwner returns the user owning drafts created with ctx, or "" when
+// auth is disabled.
+func (s *Server) draftOwner(ctx context.Context) string {
+	if !s.authEnabled() {
+		return ""
+	}
+	if u, ok := auth.UserFromContext(ctx); ok {
+		return u.ID
+	}
+	return ""
+}
+
+// checkDraftRepos rejects repos unknown to the server so typos surface when
+// the draft is saved instead of when it is started.
+func (s *Server) checkDraftRepos(req *v1.CreateTaskReq) error {
+	for _, rs := range req.Repos {
+		if _, ok := s.runners[rs.Name]; !ok {
+			return dto.BadRequest("unknown repo: " + rs.Name)
+		}
+	}
+	return nil
+}
+
+func (s *Server) listDrafts(ctx context.Context, _ *dto.EmptyReq) (*[]v1.Draft, error) {
+	drafts := s.drafts.list(s.draftOwner(ctx))
+	out := make([]v1.Draft, len(drafts))
+	for i := range drafts {
+		out[i] = toV1Draft(&drafts[i])
+	}
+	return &out, nil
+}
+
+func (s *Server) createDraft(ctx context.Context, req *v1.CreateTaskReq) (*v1.Draft, error) {
+	if err := s.checkDraftRepos(req); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	dr := &draft{ID: ksid.NewID(), OwnerID: s.draftOwner(ctx), Req: *req, CreatedAt: now, UpdatedAt: now}
+	if err := s.drafts.put(dr); err != nil {
+		return nil, dto.InternalError("save draft: " + err.Error())
+	}
+	resp := toV1Draft(dr)
+	return &resp, nil
+}
+
+// updateDraft replaces the draft's task parameters.
+func (s *Server) updateDraft(_ context.Context, dr *draft, req *v1.CreateTaskReq) (*v1.Draft, error) {
+	if err := s.checkDraftRepos(req); err != nil {
+		return nil, err
+	}
+	dr.Req = *req
+	dr.UpdatedAt = time.Now().UTC()
+	if err := s.drafts.put(dr); err != nil {
+		return nil, dto.InternalError("save draft: " + err.Error())
+	}
+	resp := toV1Draft(dr)
+	return &resp, nil
+}
+
+func (s *Server) deleteDraft(_ context.Context, dr *draft, _ *dto.EmptyReq) (*v1.StatusResp, error) {
+	if err := s.drafts.remove(dr.ID); err != nil {
+		return nil, dto.InternalError("delete draft: " + err.Error())
+	}
+	return &v1.StatusResp{Status: "deleted"}, nil
+}
+
+// startDraft creates a task from the draft and deletes the draft. The draft
+// is kept when the task cannot be created, e.g. while spending is capped.
+func (s *Server) startDraft(ctx context.Context, dr *draft, _ *dto.EmptyReq) (*v1.CreateTaskResp, error) {
+	resp, err := s.createTask(ctx, &dr.Req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.drafts.remove(dr.ID); err != nil {
+		return nil, dto.InternalError("delete draft: " + err.Error())
+	}
+	return resp, nil
+}
+
+// startDrafts starts each draft in order. A failure does not stop the
+// remaining drafts; it is reported in the draft's result.
+func (s *Server) startDrafts(ctx context.Context, req *v1.StartDraftsReq) (*v1.StartDraftsResp, error) {
+	ownerID := s.draftOwner(ctx)
+	resp := &v1.StartDraftsResp{Results: make([]v1.StartDraftResult, len(req.IDs))}
+	for i, id := range req.IDs {
+		res := &resp.Results[i]
+		res.DraftID = id
+		dr, err := s.drafts.get(id, ownerID)
+		if err == nil {
+			var cr *v1.CreateTaskResp
+			if cr, err = s.startDraft(ctx, &dr, nil); err == nil {
+				res.TaskID = cr.ID
+				continue
+			}
+		}
+		res.Error = err.Error()
+	}
+	return resp, nil
+}
+
+// handleWithDraft wraps a typed handler that needs the draft named by {id}.
+// It mirrors handleWithTask.
+func handleWithDraft[In any, PtrIn interface {
+	*In
+	dto.Validatable
+}, Out any](s *Server, fn func(context.Context, *draft, PtrIn) (*Out, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := ksid.Parse(r.PathValue("id"))
+		if err != nil {
+			writeError(w, dto.NotFound("draft"))
+			return
+		}
+		dr, err := s.drafts.get(id, s.draftOwner(r.Context()))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		in := PtrIn(new(In))
+		if !readAndDecodeBody(w, r, in) {
+			return
+		}
+		populatePathParams(r, in)
+		if err := in.Validate(); err != nil {
+			writeError(w, err)
+			return
+		}
+		out, err := fn(r.Context(), &dr, in)
+		writeJSONResponse(w, out, err)
+	}
+}
+
+func toV1Draft(dr *draft) v1.Draft {
+	return v1.Draft{
+		ID:        dr.ID,
+		Title:     task.LocalTitle(dr.Req.InitialPrompt.Text),
+		Task:      dr.Req,
+		CreatedAt: float64(dr.CreatedAt.UnixMilli()) / 1e3,
+		UpdatedAt: float64(dr.UpdatedAt.UnixMilli()) / 1e3,
+	}
+}
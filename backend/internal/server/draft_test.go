@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestDrafts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drafts.json")
+	drafts, err := openDraftStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestServer(t)
+	s.runners["myrepo"] = &task.Runner{
+		BaseBranch: "main",
+		Dir:        t.TempDir(),
+		Backends:   map[agent.Harness]agent.Backend{agent.Claude: stubBackend{}},
+	}
+	s.drafts = drafts
+
+	post := func(h http.HandlerFunc, id, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w
+	}
+
+	w := post(handle(s.createDraft), "", `{"initialPrompt":{"text":"Fix the flaky test"},"repos":[{"name":"myrepo"}],"harness":"claude"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("create: status = %d: %s", w.Code, w.Body.String())
+	}
+	var d v1.Draft
+	if err := json.NewDecoder(w.Body).Decode(&d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Title != "Fix the flaky test" {
+		t.Errorf("title = %q", d.Title)
+	}
+	if len(s.tasks) != 0 {
+		t.Fatal("a draft must not start a task")
+	}
+
+	t.Run("UnknownRepo", func(t *testing.T) {
+		w := post(handle(s.createDraft), "", `{"initialPrompt":{"text":"x"},"repos":[{"name":"nope"}],"harness":"claude"}`)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		w := post(handleWithDraft(s, s.updateDraft), d.ID.String(), `{"initialPrompt":{"text":"Fix the flaky test in CI"},"repos":[{"name":"myrepo"}],"harness":"claude"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		// The update is persisted.
+		reloaded, err := openDraftStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := reloaded.list("")
+		if len(got) != 1 || got[0].Req.InitialPrompt.Text != "Fix the flaky test in CI" {
+			t.Errorf("reloaded = %+v", got)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		w := post(handleWithDraft(s, s.startDraft), ksid.NewID().String(), "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+		if e := decodeError(t, w); e.Code != dto.CodeNotFound {
+			t.Errorf("code = %q", e.Code)
+		}
+	})
+
+	t.Run("StartBulk", func(t *testing.T) {
+		missing := ksid.NewID()
+		body, _ := json.Marshal(v1.StartDraftsReq{IDs: []ksid.ID{d.ID, missing}})
+		w := post(handle(s.startDrafts), "", string(body))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp v1.StartDraftsResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Results) != 2 {
+			t.Fatalf("results = %+v", resp.Results)
+		}
+		if r := resp.Results[0]; r.DraftID != d.ID || r.TaskID == 0 || r.Error != "" {
+			t.Errorf("results[0] = %+v", r)
+		}
+		if r := resp.Results[1]; r.DraftID != missing || r.TaskID != 0 || r.Error == "" {
+			t.Errorf("results[1] = %+v", r)
+		}
+		s.mu.Lock()
+		_, ok := s.tasks[resp.Results[0].TaskID.String()]
+		s.mu.Unlock()
+		if !ok {
+			t.Error("task not created")
+		}
+		if got := s.drafts.list(""); len(got) != 0 {
+			t.Errorf("started draft not deleted: %+v", got)
+		}
+	})
+}
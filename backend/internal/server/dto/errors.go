@@ -15,8 +15,13 @@ const (
 	CodeUnauthorized  ErrorCode = "UNAUTHORIZED"
 	CodeForbidden     ErrorCode = "FORBIDDEN"
 	CodeNotFound      ErrorCode = "NOT_FOUND"
+	CodeNotAcceptable ErrorCode = "NOT_ACCEPTABLE"
 	CodeConflict      ErrorCode = "CONFLICT"
+	CodeRateLimited   ErrorCode = "RATE_LIMITED"
 	CodeInternalError ErrorCode = "INTERNAL_ERROR"
+	// CodePolicyViolation is returned when a repository's policy in
+	// settings.json forbids the requested harness or model.
+	CodePolicyViolation ErrorCode = "POLICY_VIOLATION"
 )
 
 // ErrorWithStatus is an error that carries an HTTP status code, error code,
@@ -97,11 +102,26 @@ func Forbidden(resource string) *APIError {
 	return &APIError{statusCode: http.StatusForbidden, code: CodeForbidden, message: resource + " access denied"}
 }
 
+// NotAcceptable creates a 406 error.
+func NotAcceptable(msg string) *APIError {
+	return &APIError{statusCode: http.StatusNotAcceptable, code: CodeNotAcceptable, message: msg}
+}
+
 // Conflict creates a 409 error.
 func Conflict(msg string) *APIError {
 	return &APIError{statusCode: http.StatusConflict, code: CodeConflict, message: msg}
 }
 
+// TooManyRequests creates a 429 error.
+func TooManyRequests(msg string) *APIError {
+	return &APIError{statusCode: http.StatusTooManyRequests, code: CodeRateLimited, message: msg}
+}
+
+// PolicyViolation creates a 403 error for a request forbidden by policy.
+func PolicyViolation(msg string) *APIError {
+	return &APIError{statusCode: http.StatusForbidden, code: CodePolicyViolation, message: msg}
+}
+
 // InternalError creates a 500 error.
 func InternalError(msg string) *APIError {
 	return &APIError{statusCode: http.StatusInternalServerError, code: CodeInternalError, message: msg}
@@ -113,6 +133,13 @@ type ErrorResponse struct {
 	Details map[string]any `json:"details,omitempty"`
 }
 
+// FieldError is a problem with one field of a request body. Field is the
+// path to it, e.g. "repos[0].name"; it is empty for the body itself.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
 // ErrorDetails holds the code and message within an error response.
 type ErrorDetails struct {
 	Code    ErrorCode `json:"code"`
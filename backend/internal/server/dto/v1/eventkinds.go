@@ -0,0 +1,74 @@
+// Registry of the event kinds, used by the converters' tests, the code
+// generator and GET /api/v1/events/schema.
+package v1
+
+import (
+	"reflect"
+	"strings"
+)
+
+// EventKindInfo ties an event kind to its payload type.
+type EventKindInfo struct {
+	Kind    EventKind
+	Payload reflect.Type // Type pointed to by the EventMessage field named Kind.
+	Since   int          // EventSchemaVersion that introduced Kind.
+}
+
+// EventKinds is the authoritative list of event kinds. Adding a kind requires
+// adding its payload field to EventMessage, bumping EventSchemaVersion and
+// setting Since to the new version.
+var EventKinds = []EventKindInfo{
+	{Kind: EventKindInit, Payload: reflect.TypeFor[EventInit](), Since: 1},
+	{Kind: EventKindText, Payload: reflect.TypeFor[EventText](), Since: 1},
+	{Kind: EventKindTextDelta, Payload: reflect.TypeFor[EventTextDelta](), Since: 1},
+	{Kind: EventKindToolUse, Payload: reflect.TypeFor[EventToolUse](), Since: 1},
+	{Kind: EventKindToolResult, Payload: reflect.TypeFor[EventToolResult](), Since: 1},
+	{Kind: EventKindAsk, Payload: reflect.TypeFor[EventAsk](), Since: 1},
+	{Kind: EventKindUsage, Payload: reflect.TypeFor[EventUsage](), Since: 1},
+	{Kind: EventKindResult, Payload: reflect.TypeFor[EventResult](), Since: 1},
+	{Kind: EventKindSystem, Payload: reflect.TypeFor[EventSystem](), Since: 1},
+	{Kind: EventKindUserInput, Payload: reflect.TypeFor[EventUserInput](), Since: 1},
+	{Kind: EventKindTodo, Payload: reflect.TypeFor[EventTodo](), Since: 1},
+	{Kind: EventKindDiffStat, Payload: reflect.TypeFor[EventDiffStat](), Since: 1},
+	{Kind: EventKindError, Payload: reflect.TypeFor[EventError](), Since: 1},
+	{Kind: EventKindThinking, Payload: reflect.TypeFor[EventThinking](), Since: 1},
+	{Kind: EventKindThinkingDelta, Payload: reflect.TypeFor[EventThinkingDelta](), Since: 1},
+	{Kind: EventKindSubagentStart, Payload: reflect.TypeFor[EventSubagentStart](), Since: 1},
+	{Kind: EventKindSubagentEnd, Payload: reflect.TypeFor[EventSubagentEnd](), Since: 1},
+	{Kind: EventKindLog, Payload: reflect.TypeFor[EventLog](), Since: 1},
+	{Kind: EventKindToolOutputDelta, Payload: reflect.TypeFor[EventToolOutputDelta](), Since: 1},
+	{Kind: EventKindWidget, Payload: reflect.TypeFor[EventWidget](), Since: 1},
+	{Kind: EventKindWidgetDelta, Payload: reflect.TypeFor[EventWidgetDelta](), Since: 1},
+	{Kind: EventKindStatus, Payload: reflect.TypeFor[EventStatus](), Since: 2},
+	{Kind: EventKindWarning, Payload: reflect.TypeFor[EventWarning](), Since: 3},
+	{Kind: EventKindStderr, Payload: reflect.TypeFor[EventStderr](), Since: 4},
+	{Kind: EventKindStateChange, Payload: reflect.TypeFor[EventStateChange](), Since: 5},
+}
+
+// EventSchema returns the registry as served by GET /api/v1/events/schema.
+func EventSchema() *EventSchemaResp {
+	resp := &EventSchemaResp{Version: EventSchemaVersion, Kinds: make([]EventKindSchema, len(EventKinds))}
+	for i, k := range EventKinds {
+		resp.Kinds[i] = EventKindSchema{Kind: k.Kind, Payload: k.Payload.Name(), Since: k.Since}
+	}
+	return resp
+}
+
+// Payload returns the kind and value of the single payload field set in e.
+// ok is false when none or more than one is set.
+func (e *EventMessage) Payload() (kind EventKind, payload any, ok bool) {
+	v := reflect.ValueOf(e).Elem()
+	t := v.Type()
+	for i := range t.NumField() {
+		f := v.Field(i)
+		if f.Kind() != reflect.Pointer || f.IsNil() {
+			continue
+		}
+		if payload != nil {
+			return "", nil, false
+		}
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		kind, payload = EventKind(name), f.Interface()
+	}
+	return kind, payload, payload != nil
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEventKinds(t *testing.T) {
+	registered := map[EventKind]EventKindInfo{}
+	latest := false
+	for _, k := range EventKinds {
+		if _, ok := registered[k.Kind]; ok {
+			t.Errorf("kind %q registered twice", k.Kind)
+		}
+		registered[k.Kind] = k
+		if k.Since < 1 || k.Since > EventSchemaVersion {
+			t.Errorf("kind %q: since %d out of [1, %d]", k.Kind, k.Since, EventSchemaVersion)
+		}
+		latest = latest || k.Since == EventSchemaVersion
+	}
+	if !latest {
+		t.Errorf("no kind introduced in version %d", EventSchemaVersion)
+	}
+	// Every payload field of EventMessage is registered with its type.
+	typ := reflect.TypeFor[EventMessage]()
+	fields := 0
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if f.Type.Kind() != reflect.Pointer {
+			continue
+		}
+		fields++
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		k, ok := registered[EventKind(name)]
+		if !ok {
+			t.Errorf("field %s: kind %q not registered", f.Name, name)
+		} else if k.Payload != f.Type.Elem() {
+			t.Errorf("kind %q: payload %s, field is %s", name, k.Payload, f.Type.Elem())
+		}
+	}
+	if fields != len(EventKinds) {
+		t.Errorf("%d payload fields, %d kinds registered", fields, len(EventKinds))
+	}
+}
+
+func TestEventSchema(t *testing.T) {
+	s := EventSchema()
+	if s.Version != EventSchemaVersion || len(s.Kinds) != len(EventKinds) {
+		t.Fatalf("got %+v", s)
+	}
+	if want := (EventKindSchema{Kind: EventKindToolUse, Payload: "EventToolUse", Since: 1}); s.Kinds[3] != want {
+		t.Errorf("got %+v, want %+v", s.Kinds[3], want)
+	}
+}
+
+func TestEventMessagePayload(t *testing.T) {
+	ev := EventMessage{Kind: EventKindLog, Log: &EventLog{Line: "x"}}
+	if k, p, ok := ev.Payload(); !ok || k != EventKindLog || p != ev.Log {
+		t.Errorf("got %q, %v, %t", k, p, ok)
+	}
+	ev.Text = &EventText{}
+	if _, _, ok := ev.Payload(); ok {
+		t.Error("two payloads accepted")
+	}
+	if _, _, ok := (&EventMessage{Kind: EventKindLog}).Payload(); ok {
+		t.Error("no payload accepted")
+	}
+}
@@ -36,8 +36,31 @@ const (
 	EventKindToolOutputDelta EventKind = "toolOutputDelta"
 	EventKindWidget          EventKind = "widget"
 	EventKindWidgetDelta     EventKind = "widgetDelta"
+	EventKindStatus          EventKind = "status"
+	EventKindWarning         EventKind = "warning"
+	EventKindStderr          EventKind = "stderr"
+	EventKindStateChange     EventKind = "stateChange"
 )
 
+// EventSchemaVersion is the version of the event stream schema. It is bumped
+// whenever a kind is added; see EventKinds.
+const EventSchemaVersion = 5
+
+// EventKindSchema describes an event kind. Its payload is in the EventMessage
+// field of the same name.
+type EventKindSchema struct {
+	Kind    EventKind `json:"kind"`
+	Payload string    `json:"payload"` // Payload type name, e.g. "EventToolUse".
+	Since   int       `json:"since"`   // EventSchemaVersion that introduced the kind.
+}
+
+// EventSchemaResp is the response for GET /api/v1/events/schema. Clients
+// ignore the events of kinds they don't know.
+type EventSchemaResp struct {
+	Version int               `json:"version"`
+	Kinds   []EventKindSchema `json:"kinds"`
+}
+
 // EventMessage is a single SSE event in the backend-neutral stream
 // (/api/v1/tasks/{id}/events). All backends produce these events.
 type EventMessage struct {
@@ -64,6 +87,10 @@ type EventMessage struct {
 	ToolOutputDelta *EventToolOutputDelta `json:"toolOutputDelta,omitempty"`
 	Widget          *EventWidget          `json:"widget,omitempty"`
 	WidgetDelta     *EventWidgetDelta     `json:"widgetDelta,omitempty"`
+	Status          *EventStatus          `json:"status,omitempty"`
+	Warning         *EventWarning         `json:"warning,omitempty"`
+	Stderr          *EventStderr          `json:"stderr,omitempty"`
+	StateChange     *EventStateChange     `json:"stateChange,omitempty"`
 }
 
 // EventInit is emitted once at the start of a session. It includes a Harness
@@ -101,6 +128,9 @@ type EventToolResult struct {
 	ToolUseID string  `json:"toolUseID"`
 	Duration  float64 `json:"duration"` // Seconds; server-computed; 0 if unknown.
 	Error     string  `json:"error,omitempty"`
+	// Inferred is set when the harness didn't report the result and the call
+	// was deemed complete because the agent moved on.
+	Inferred bool `json:"inferred,omitempty"`
 }
 
 // AskOption is a single option in an AskUserQuestion.
@@ -173,15 +203,32 @@ type EventTodo struct {
 	Todos     []TodoItem `json:"todos"`
 }
 
-// EventDiffStat is emitted when the relay reports updated diff statistics.
+// EventDiffStat is emitted when the relay reports updated diff statistics
+// and after each mutating tool call.
 type EventDiffStat struct {
-	DiffStat DiffStat `json:"diffStat,omitzero"`
+	DiffStat  DiffStat `json:"diffStat,omitzero"`
+	ToolUseID string   `json:"toolUseID,omitempty"` // Mutating tool call that produced HeadSHA.
+	HeadSHA   string   `json:"headSHA,omitempty"`   // Task branch commit after the tool call.
 }
 
-// EventError is emitted when the backend fails to parse an agent output line.
+// EventError reports an agent output line that failed to decode.
 type EventError struct {
 	Err  string `json:"err"`
-	Line string `json:"line"`
+	Line string `json:"line"` // The offending line.
+}
+
+// EventStderr is a line the agent or its relay wrote to stderr. It is
+// diagnostic output, not an error of the agent.
+type EventStderr struct {
+	Source string `json:"source"` // Process that wrote the line, e.g. "relay attach".
+	Line   string `json:"line"`
+}
+
+// EventStateChange is emitted when the task changes state. The event's Ts is
+// the time of the transition. It is only sent live, not replayed.
+type EventStateChange struct {
+	From string `json:"from"` // See Task.State.
+	To   string `json:"to"`
 }
 
 // EventThinking is an assistant thinking block.
@@ -231,3 +278,42 @@ type EventWidgetDelta struct {
 	ToolUseID string `json:"toolUseID"`
 	Delta     string `json:"delta"`
 }
+
+// AgentStatus is what the agent is doing, as summarized by EventStatus.
+type AgentStatus string
+
+// Agent statuses.
+const (
+	AgentStatusThinking    AgentStatus = "thinking"    // The model is working.
+	AgentStatusResponding  AgentStatus = "responding"  // The model is writing its answer.
+	AgentStatusTool        AgentStatus = "tool"        // A tool is running.
+	AgentStatusWaiting     AgentStatus = "waiting"     // The agent waits for user input.
+	AgentStatusRateLimited AgentStatus = "rateLimited" // The provider rejects requests.
+)
+
+// EventStatus is emitted when the agent's status changes, so clients can show
+// a status line without interpreting the other events.
+type EventStatus struct {
+	Status AgentStatus `json:"status"`
+	Tool   string      `json:"tool,omitempty"`  // Running tool, for AgentStatusTool.
+	Until  float64     `json:"until,omitempty"` // Unix epoch seconds the rate limit resets at; 0 if unknown.
+}
+
+// WarningKind identifies the heuristic that raised an EventWarning.
+type WarningKind string
+
+// Warning kinds.
+const (
+	WarningKindNoChanges     WarningKind = "noChanges"     // The prompt asked for a fix but the turn changed no file.
+	WarningKindTestsDeleted  WarningKind = "testsDeleted"  // Test files lost lines without gaining any.
+	WarningKindLargeDeletion WarningKind = "largeDeletion" // Large deletions in files no prompt mentions.
+	WarningKindStubsAdded    WarningKind = "stubsAdded"    // TODO/FIXME markers or stubs were added.
+)
+
+// EventWarning is emitted after a turn whose outcome looks suspicious, so the
+// user looks closer before syncing. Task.Warnings lists those of the last
+// turn.
+type EventWarning struct {
+	Kind   WarningKind `json:"kind"`
+	Detail string      `json:"detail"`
+}
@@ -15,7 +15,7 @@ type Route struct {
 	Resp        reflect.Type // Response body type.
 	IsArray     bool         // response is T[] not T
 	IsSSE       bool         // SSE stream, not JSON
-	QueryParams []string     // Query parameter names (GET endpoints only).
+	QueryParams []string     // Query parameter names (GET and SSE endpoints only).
 }
 
 // ReqName returns the request type name, or "" if Req is nil.
@@ -54,29 +54,90 @@ var Routes = []Route{
 	{Name: "logout", Method: "POST", Path: "/api/v1/auth/logout", Resp: reflect.TypeFor[StatusResp]()},
 	{Name: "getPreferences", Method: "GET", Path: "/api/v1/server/preferences", Resp: reflect.TypeFor[PreferencesResp]()},
 	{Name: "updatePreferences", Method: "POST", Path: "/api/v1/server/preferences", Req: reflect.TypeFor[UpdatePreferencesReq](), Resp: reflect.TypeFor[PreferencesResp]()},
+	{Name: "getSpending", Method: "GET", Path: "/api/v1/server/spending", Resp: reflect.TypeFor[SpendingResp]()},
+	{Name: "overrideSpending", Method: "POST", Path: "/api/v1/server/spending/override", Req: reflect.TypeFor[SpendingOverrideReq](), Resp: reflect.TypeFor[SpendingResp]()},
 	{Name: "listHarnesses", Method: "GET", Path: "/api/v1/server/harnesses", Resp: reflect.TypeFor[HarnessInfo](), IsArray: true},
+	{Name: "listAgentVersions", Method: "GET", Path: "/api/v1/server/harnesses/versions", Resp: reflect.TypeFor[AgentVersionsResp]()},
+	{Name: "getServerStatus", Method: "GET", Path: "/api/v1/server/status", Resp: reflect.TypeFor[ServerStatusResp]()},
+	{Name: "getDoctor", Method: "GET", Path: "/api/v1/server/doctor", Resp: reflect.TypeFor[DoctorResp]()},
 	{Name: "listCaches", Method: "GET", Path: "/api/v1/server/caches", Resp: reflect.TypeFor[WellKnownCachesResp]()},
+	{Name: "listImages", Method: "GET", Path: "/api/v1/server/images", Resp: reflect.TypeFor[ImagesResp]()},
+	{Name: "listContainers", Method: "GET", Path: "/api/v1/server/containers", Resp: reflect.TypeFor[ContainersResp]()},
 	{Name: "listRepos", Method: "GET", Path: "/api/v1/server/repos", Resp: reflect.TypeFor[Repo](), IsArray: true},
 	{Name: "cloneRepo", Method: "POST", Path: "/api/v1/server/repos", Req: reflect.TypeFor[CloneRepoReq](), Resp: reflect.TypeFor[Repo]()},
 	{Name: "listRepoBranches", Method: "GET", Path: "/api/v1/server/repos/branches", Resp: reflect.TypeFor[RepoBranchesResp](), QueryParams: []string{"repo"}},
+	{Name: "pruneRepoBranches", Method: "POST", Path: "/api/v1/server/repos/branches/prune", Req: reflect.TypeFor[PruneBranchesReq](), Resp: reflect.TypeFor[PruneBranchesResp]()},
+	{Name: "getCostReport", Method: "GET", Path: "/api/v1/server/costs", Resp: reflect.TypeFor[CostReportResp]()},
+	{Name: "getRepoHeatmap", Method: "GET", Path: "/api/v1/server/repos/heatmap", Resp: reflect.TypeFor[RepoHeatmapResp](), QueryParams: []string{"repo", "limit"}},
+	{Name: "getRepoTools", Method: "GET", Path: "/api/v1/server/repos/tools", Resp: reflect.TypeFor[RepoToolsResp](), QueryParams: []string{"repo"}},
+	{Name: "getRepoKnowledge", Method: "GET", Path: "/api/v1/server/repos/knowledge", Resp: reflect.TypeFor[RepoKnowledgeResp](), QueryParams: []string{"repo"}},
+	{Name: "updateRepoKnowledge", Method: "POST", Path: "/api/v1/server/repos/knowledge", Req: reflect.TypeFor[UpdateRepoKnowledgeReq](), Resp: reflect.TypeFor[RepoKnowledgeResp]()},
+	{Name: "getRepoOverview", Method: "GET", Path: "/api/v1/server/repos/overview", Resp: reflect.TypeFor[RepoOverviewResp](), QueryParams: []string{"repo"}},
+	{Name: "getRepoMap", Method: "GET", Path: "/api/v1/server/repos/map", Resp: reflect.TypeFor[RepoMapResp](), QueryParams: []string{"repo"}},
+	{Name: "refreshRepoMap", Method: "POST", Path: "/api/v1/server/repos/map/refresh", Req: reflect.TypeFor[RefreshRepoMapReq](), Resp: reflect.TypeFor[RepoMapResp]()},
 	{Name: "botFixCI", Method: "POST", Path: "/api/v1/bot/fix-ci", Req: reflect.TypeFor[BotFixCIReq](), Resp: reflect.TypeFor[CreateTaskResp]()},
 	{Name: "botFixPR", Method: "POST", Path: "/api/v1/bot/fix-pr", Req: reflect.TypeFor[BotFixPRReq](), Resp: reflect.TypeFor[StatusResp]()},
+	{Name: "listDrafts", Method: "GET", Path: "/api/v1/drafts", Resp: reflect.TypeFor[Draft](), IsArray: true},
+	{Name: "createDraft", Method: "POST", Path: "/api/v1/drafts", Req: reflect.TypeFor[CreateTaskReq](), Resp: reflect.TypeFor[Draft]()},
+	{Name: "startDrafts", Method: "POST", Path: "/api/v1/drafts/start", Req: reflect.TypeFor[StartDraftsReq](), Resp: reflect.TypeFor[StartDraftsResp]()},
+	{Name: "updateDraft", Method: "POST", Path: "/api/v1/drafts/{id}", Req: reflect.TypeFor[CreateTaskReq](), Resp: reflect.TypeFor[Draft]()},
+	{Name: "deleteDraft", Method: "POST", Path: "/api/v1/drafts/{id}/delete", Resp: reflect.TypeFor[StatusResp]()},
+	{Name: "startDraft", Method: "POST", Path: "/api/v1/drafts/{id}/start", Resp: reflect.TypeFor[CreateTaskResp]()},
+	{Name: "getEventSchema", Method: "GET", Path: "/api/v1/events/schema", Resp: reflect.TypeFor[EventSchemaResp]()},
+	{Name: "listEvals", Method: "GET", Path: "/api/v1/evals", Resp: reflect.TypeFor[EvalRun](), IsArray: true},
+	{Name: "createEval", Method: "POST", Path: "/api/v1/evals", Req: reflect.TypeFor[CreateEvalReq](), Resp: reflect.TypeFor[EvalRun]()},
+	{Name: "getEval", Method: "GET", Path: "/api/v1/evals/{id}", Resp: reflect.TypeFor[EvalRun]()},
 	{Name: "listTasks", Method: "GET", Path: "/api/v1/tasks", Resp: reflect.TypeFor[Task](), IsArray: true},
 	{Name: "createTask", Method: "POST", Path: "/api/v1/tasks", Req: reflect.TypeFor[CreateTaskReq](), Resp: reflect.TypeFor[CreateTaskResp]()},
+	{Name: "validateTask", Method: "POST", Path: "/api/v1/tasks/validate", Req: reflect.TypeFor[CreateTaskReq](), Resp: reflect.TypeFor[ValidateTaskResp]()},
+	{Name: "listLabeledTasks", Method: "GET", Path: "/api/v1/tasks/labeled", Resp: reflect.TypeFor[Task](), IsArray: true, QueryParams: []string{"outcome", "harness", "model"}},
+	{Name: "bulkTasks", Method: "POST", Path: "/api/v1/tasks/bulk", Req: reflect.TypeFor[BulkTasksReq](), Resp: reflect.TypeFor[BulkTasksResp]()},
 	{Name: "taskRawEvents", Method: "GET", Path: "/api/v1/tasks/{id}/raw_events", Resp: reflect.TypeFor[EventMessage](), IsSSE: true},
 	{Name: "taskEvents", Method: "GET", Path: "/api/v1/tasks/{id}/events", Resp: reflect.TypeFor[EventMessage](), IsSSE: true},
+	{Name: "getTaskEventRange", Method: "GET", Path: "/api/v1/tasks/{id}/events/range", Resp: reflect.TypeFor[TaskEventRangeResp](), QueryParams: []string{"from", "to"}},
 	{Name: "sendInput", Method: "POST", Path: "/api/v1/tasks/{id}/input", Req: reflect.TypeFor[InputReq](), Resp: reflect.TypeFor[StatusResp]()},
 	{Name: "restartTask", Method: "POST", Path: "/api/v1/tasks/{id}/restart", Req: reflect.TypeFor[RestartReq](), Resp: reflect.TypeFor[StatusResp]()},
 	{Name: "stopTask", Method: "POST", Path: "/api/v1/tasks/{id}/stop", Resp: reflect.TypeFor[StatusResp]()},
 	{Name: "purgeTask", Method: "POST", Path: "/api/v1/tasks/{id}/purge", Resp: reflect.TypeFor[StatusResp]()},
 	{Name: "reviveTask", Method: "POST", Path: "/api/v1/tasks/{id}/revive", Resp: reflect.TypeFor[StatusResp]()},
+	{Name: "pauseTask", Method: "POST", Path: "/api/v1/tasks/{id}/pause", Resp: reflect.TypeFor[StatusResp]()},
+	{Name: "resumeTask", Method: "POST", Path: "/api/v1/tasks/{id}/resume", Resp: reflect.TypeFor[StatusResp]()},
+	{Name: "handoffTask", Method: "POST", Path: "/api/v1/tasks/{id}/handoff", Resp: reflect.TypeFor[HandoffResp]()},
+	{Name: "importHandoff", Method: "POST", Path: "/api/v1/tasks/{id}/handoff/import", Resp: reflect.TypeFor[ImportHandoffResp]()},
+	{Name: "cleanTask", Method: "POST", Path: "/api/v1/tasks/{id}/clean", Resp: reflect.TypeFor[CleanTaskResp]()},
 	{Name: "getTaskCILog", Method: "GET", Path: "/api/v1/tasks/{id}/ci-log", Resp: reflect.TypeFor[CILogResp](), QueryParams: []string{"jobID"}},
 	{Name: "syncTask", Method: "POST", Path: "/api/v1/tasks/{id}/sync", Req: reflect.TypeFor[SyncReq](), Resp: reflect.TypeFor[SyncResp]()},
+	{Name: "replayTask", Method: "POST", Path: "/api/v1/tasks/{id}/replay", Req: reflect.TypeFor[ReplayTaskReq](), Resp: reflect.TypeFor[CreateTaskResp]()},
+	{Name: "labelTask", Method: "POST", Path: "/api/v1/tasks/{id}/label", Req: reflect.TypeFor[LabelTaskReq](), Resp: reflect.TypeFor[Task]()},
+	{Name: "shareTask", Method: "POST", Path: "/api/v1/tasks/{id}/share", Req: reflect.TypeFor[ShareTaskReq](), Resp: reflect.TypeFor[ShareTaskResp]()},
+	{Name: "getSharedTask", Method: "GET", Path: "/api/v1/shared/{token}", Resp: reflect.TypeFor[Task]()},
+	{Name: "sharedTaskEvents", Method: "GET", Path: "/api/v1/shared/{token}/events", Resp: reflect.TypeFor[EventMessage](), IsSSE: true},
+	{Name: "getSharedTaskEventRange", Method: "GET", Path: "/api/v1/shared/{token}/events/range", Resp: reflect.TypeFor[TaskEventRangeResp](), QueryParams: []string{"from", "to"}},
+	{Name: "getSharedTaskDiff", Method: "GET", Path: "/api/v1/shared/{token}/diff", Resp: reflect.TypeFor[DiffResp]()},
+	{Name: "applyTask", Method: "POST", Path: "/api/v1/tasks/{id}/apply", Req: reflect.TypeFor[ApplyTaskReq](), Resp: reflect.TypeFor[ApplyTaskResp]()},
 	{Name: "getTaskDiff", Method: "GET", Path: "/api/v1/tasks/{id}/diff", Resp: reflect.TypeFor[DiffResp]()},
+	{Name: "getTaskCommits", Method: "GET", Path: "/api/v1/tasks/{id}/commits", Resp: reflect.TypeFor[TaskCommitsResp]()},
+	{Name: "getTaskSummary", Method: "GET", Path: "/api/v1/tasks/{id}/summary", Resp: reflect.TypeFor[TaskSummaryResp]()},
+	{Name: "summarizeTask", Method: "POST", Path: "/api/v1/tasks/{id}/summary", Req: reflect.TypeFor[TaskSummaryReq](), Resp: reflect.TypeFor[TaskSummaryResp]()},
+	{Name: "getTaskUsage", Method: "GET", Path: "/api/v1/tasks/{id}/usage", Resp: reflect.TypeFor[TaskUsageResp]()},
+	{Name: "getTaskTools", Method: "GET", Path: "/api/v1/tasks/{id}/tools", Resp: reflect.TypeFor[TaskToolsResp]()},
+	{Name: "getTaskResources", Method: "GET", Path: "/api/v1/tasks/{id}/resources", Resp: reflect.TypeFor[TaskResourcesResp]()},
+	{Name: "getTaskEnv", Method: "GET", Path: "/api/v1/tasks/{id}/env", Resp: reflect.TypeFor[TaskEnvResp]()},
+	{Name: "listAnnotations", Method: "GET", Path: "/api/v1/tasks/{id}/annotations", Resp: reflect.TypeFor[TaskAnnotationsResp]()},
+	{Name: "annotateTask", Method: "POST", Path: "/api/v1/tasks/{id}/annotations", Req: reflect.TypeFor[AnnotateReq](), Resp: reflect.TypeFor[Annotation]()},
+	{Name: "deleteAnnotation", Method: "POST", Path: "/api/v1/tasks/{id}/annotations/{annotationID}/delete", Resp: reflect.TypeFor[StatusResp]()},
+	{Name: "getTaskCommands", Method: "GET", Path: "/api/v1/tasks/{id}/commands", Resp: reflect.TypeFor[TaskCommandsResp]()},
+	{Name: "getTaskTodos", Method: "GET", Path: "/api/v1/tasks/{id}/todos", Resp: reflect.TypeFor[TaskTodosResp]()},
+	{Name: "getTaskDeadLetters", Method: "GET", Path: "/api/v1/tasks/{id}/dead-letters", Resp: reflect.TypeFor[TaskDeadLettersResp]()},
 	{Name: "getTaskToolInput", Method: "GET", Path: "/api/v1/tasks/{id}/tool/{toolUseID}", Resp: reflect.TypeFor[TaskToolInputResp]()},
 	{Name: "globalTaskEvents", Method: "GET", Path: "/api/v1/server/tasks/events", Resp: reflect.TypeFor[TaskListEvent](), IsSSE: true},
+	{Name: "updateFeatures", Method: "POST", Path: "/api/v1/server/features", Req: reflect.TypeFor[FeatureFlags](), Resp: reflect.TypeFor[FeatureFlags]()},
+	{Name: "configEvents", Method: "GET", Path: "/api/v1/server/config/events", Resp: reflect.TypeFor[ConfigEvent](), IsSSE: true},
 	{Name: "globalUsageEvents", Method: "GET", Path: "/api/v1/server/usage/events", Resp: reflect.TypeFor[UsageResp](), IsSSE: true},
+	{Name: "serverLogEvents", Method: "GET", Path: "/api/v1/server/logs/events", Resp: reflect.TypeFor[ServerLogEntry](), IsSSE: true, QueryParams: []string{"level"}},
 	{Name: "getUsage", Method: "GET", Path: "/api/v1/usage", Resp: reflect.TypeFor[UsageResp]()},
+	{Name: "getUsageHistory", Method: "GET", Path: "/api/v1/usage/history", Resp: reflect.TypeFor[UsageHistoryResp](), QueryParams: []string{"window"}},
+	{Name: "search", Method: "GET", Path: "/api/v1/search", Resp: reflect.TypeFor[SearchResp](), QueryParams: []string{"q", "limit"}},
 	{Name: "getVoiceToken", Method: "GET", Path: "/api/v1/voice/token", Resp: reflect.TypeFor[VoiceTokenResp]()},
 	{Name: "webFetch", Method: "POST", Path: "/api/v1/web/fetch", Req: reflect.TypeFor[WebFetchReq](), Resp: reflect.TypeFor[WebFetchResp]()},
 }
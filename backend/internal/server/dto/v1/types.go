@@ -34,6 +34,76 @@ const (
 	HarnessKilo   Harness = "kilo"
 )
 
+// Priority orders tasks competing for scarce capacity.
+type Priority string
+
+// Task priorities.
+const (
+	PriorityLow    Priority = "low"
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+)
+
+// TaskKind is the type of work a task does.
+type TaskKind string
+
+// Task kinds.
+const (
+	TaskKindCode    TaskKind = "code"    // Edits the repository.
+	TaskKindExplain TaskKind = "explain" // Explores read-only and writes the repository overview.
+)
+
+// AskAction is what happens to a question left unanswered.
+type AskAction string
+
+// Ask actions.
+const (
+	AskActionAnswer AskAction = "answer" // Reply with AskPolicy.Answer.
+	AskActionNotify AskAction = "notify" // Emit an ask_timeout event.
+	AskActionPause  AskAction = "pause"  // Pause the task.
+)
+
+// Automation selects whether a task may proceed without a human when it
+// asks a question or proposes a plan.
+type Automation string
+
+// Automation modes. The default follows the working hours of the
+// repository: outside of them, questions are answered with a default and
+// plans are approved.
+const (
+	AutomationInteractive Automation = "interactive" // Always waits for a human.
+	AutomationUnattended  Automation = "unattended"  // Always proceeds on its own.
+)
+
+// AskPolicy is applied once when a question of the agent is left unanswered
+// for Timeout.
+type AskPolicy struct {
+	Timeout float64   `json:"timeout"` // Seconds.
+	Action  AskAction `json:"action"`
+	// Answer is the reply of the answer action. Defaults to "Use your best
+	// judgment.".
+	Answer string `json:"answer,omitempty"`
+}
+
+// TaskOutcome is how the work of a completed task was used.
+type TaskOutcome string
+
+// Task outcomes.
+const (
+	OutcomeAccepted TaskOutcome = "accepted"
+	OutcomePartial  TaskOutcome = "partial" // Partially used.
+	OutcomeRejected TaskOutcome = "rejected"
+)
+
+// LabelSource tells who labeled a task outcome.
+type LabelSource string
+
+// Label sources.
+const (
+	LabelSourceUser   LabelSource = "user"
+	LabelSourceVerify LabelSource = "verify" // Evaluation verification command.
+)
+
 // HarnessInfo is the JSON representation of an available harness.
 type HarnessInfo struct {
 	Name           string   `json:"name"`
@@ -41,6 +111,97 @@ type HarnessInfo struct {
 	SupportsImages bool     `json:"supportsImages"`
 }
 
+// AgentVersionsResp is the response for GET /api/v1/server/harnesses/versions.
+type AgentVersionsResp struct {
+	Harnesses []AgentVersions `json:"harnesses"`
+}
+
+// AgentVersions describes the CLI versions of a harness.
+type AgentVersions struct {
+	Harness string              `json:"harness"`
+	Package string              `json:"package,omitempty"` // npm package publishing the CLI.
+	Running []AgentVersionCount `json:"running"`           // Versions reported by current tasks.
+	Pinned  []RepoAgentVersion  `json:"pinned"`            // Versions pinned in settings.json.
+	// Latest is the latest release; set only when update checks are enabled.
+	Latest          string  `json:"latest,omitempty"`
+	LatestCheckedAt float64 `json:"latestCheckedAt,omitempty"` // Unix seconds.
+	UpdateAvailable bool    `json:"updateAvailable,omitempty"` // A current task runs another version than Latest.
+}
+
+// AgentVersionCount is the number of current tasks running a CLI version.
+type AgentVersionCount struct {
+	Version string `json:"version"`
+	Tasks   int    `json:"tasks"`
+}
+
+// RepoAgentVersion is a CLI version pinned for a repository.
+type RepoAgentVersion struct {
+	Repo    string `json:"repo"`
+	Version string `json:"version"`
+}
+
+// ServerStatusResp is the response for GET /api/v1/server/status.
+type ServerStatusResp struct {
+	// Drift counts the wire records the harness parsers didn't fully
+	// understand since the server started. A new entry usually means a
+	// harness CLI changed its stream format.
+	Drift []SchemaDrift `json:"drift"`
+	// Runtime is the container runtime tasks run in.
+	Runtime RuntimeStatus `json:"runtime"`
+	// Process is the resource usage of the server process.
+	Process ProcessStatus `json:"process"`
+}
+
+// ProcessStatus is the resource usage of the server process.
+type ProcessStatus struct {
+	HeapBytes  uint64 `json:"heapBytes"` // Bytes of allocated heap objects.
+	SysBytes   uint64 `json:"sysBytes"`  // Bytes of memory obtained from the OS.
+	Goroutines int    `json:"goroutines"`
+}
+
+// RuntimeStatus describes the container runtime and the md library driving
+// it.
+type RuntimeStatus struct {
+	Name    string `json:"name,omitempty"`    // "docker" or "podman"; empty if undetected.
+	Version string `json:"version,omitempty"` // Runtime server version.
+	MD      string `json:"md"`                // Version of the md library caic is built with.
+	// Unsupported lists why the runtime is too old for some features, e.g.
+	// "docker >= 23.0 required for image builds, found 20.10.5; upgrade docker".
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// SchemaDrift counts the unknown fields or the unknown record type met while
+// decoding one record type of a harness.
+type SchemaDrift struct {
+	Harness string  `json:"harness"`         // Harness name; "caic" for caic's own log records.
+	Type    string  `json:"type"`            // Record type or method.
+	Field   string  `json:"field,omitempty"` // Unknown field; empty when the record type itself is unknown.
+	Count   int64   `json:"count"`
+	LastAt  float64 `json:"lastAt"` // Unix seconds.
+}
+
+// DoctorStatus is the outcome of a doctor check.
+type DoctorStatus string
+
+// Doctor check outcomes.
+const (
+	DoctorPass DoctorStatus = "pass"
+	DoctorWarn DoctorStatus = "warn" // Some tasks may fail.
+	DoctorFail DoctorStatus = "fail" // Tasks will fail.
+)
+
+// DoctorResp is the response for GET /api/v1/server/doctor.
+type DoctorResp struct {
+	Checks []DoctorCheck `json:"checks"`
+}
+
+// DoctorCheck is the outcome of one check of the host the server runs on.
+type DoctorCheck struct {
+	Name    string       `json:"name"` // e.g. "git", "runtime", "cli:claude", "credentials:claude", "logDir", "disk".
+	Status  DoctorStatus `json:"status"`
+	Message string       `json:"message"` // What was found, or what to do about it.
+}
+
 // ImageData carries a single base64-encoded image.
 type ImageData struct {
 	MediaType string `json:"mediaType"` // e.g. "image/png", "image/jpeg"
@@ -55,11 +216,31 @@ type Prompt struct {
 
 // Config reports server capabilities to the frontend.
 type Config struct {
-	TailscaleAvailable bool     `json:"tailscaleAvailable"`
-	USBAvailable       bool     `json:"usbAvailable"`
-	DisplayAvailable   bool     `json:"displayAvailable"`
-	GitHubAppEnabled   bool     `json:"gitHubAppEnabled,omitempty"`
-	AuthProviders      []string `json:"authProviders,omitempty"` // e.g. ["github","gitlab"]
+	TailscaleAvailable bool         `json:"tailscaleAvailable"`
+	USBAvailable       bool         `json:"usbAvailable"`
+	DisplayAvailable   bool         `json:"displayAvailable"`
+	GPUCount           int          `json:"gpuCount,omitempty"` // Number of GPUs tasks can be scheduled on; 0 when none.
+	GitHubAppEnabled   bool         `json:"gitHubAppEnabled,omitempty"`
+	AuthProviders      []string     `json:"authProviders,omitempty"` // e.g. ["github","gitlab"]
+	Features           FeatureFlags `json:"features"`
+}
+
+// FeatureFlags gates experimental subsystems. Clients only show the UI of
+// the enabled ones. It is also the request and response body of
+// POST /api/v1/server/features.
+type FeatureFlags struct {
+	PlanMode      bool `json:"planMode,omitempty"`      // Plan approval before the agent edits files.
+	ReviewerStage bool `json:"reviewerStage,omitempty"` // Second agent reviewing a task's changes before a PR is opened.
+	FanOut        bool `json:"fanOut,omitempty"`        // One prompt started as parallel tasks.
+}
+
+// ConfigEvent is a snapshot of the server configuration streamed by
+// GET /api/v1/server/config/events. A new snapshot is sent whenever any part
+// of it changes, e.g. when a repo is cloned.
+type ConfigEvent struct {
+	Config    Config        `json:"config"`
+	Repos     []Repo        `json:"repos"`
+	Harnesses []HarnessInfo `json:"harnesses"`
 }
 
 // UserResp is returned by GET /api/v1/auth/me.
@@ -133,6 +314,10 @@ type Repo struct {
 type RepoSpec struct {
 	Name       string `json:"name"`
 	BaseBranch string `json:"baseBranch,omitempty"`
+	// Paths scopes the task to these directories, relative to the repository
+	// root. When set, the container clone is a sparse checkout holding only
+	// these directories plus the repository's configured shared directories.
+	Paths []string `json:"paths,omitempty"`
 }
 
 // TaskRepo describes a repository associated with a task in the API response.
@@ -140,8 +325,12 @@ type TaskRepo struct {
 	Name       string `json:"name"`
 	BaseBranch string `json:"baseBranch,omitempty"`
 	Branch     string `json:"branch"`
+	BaseSHA    string `json:"baseSHA,omitempty"` // Commit the branch was created from.
 	RemoteURL  string `json:"remoteURL,omitempty"`
 	Forge      Forge  `json:"forge,omitempty"` // "github", "gitlab", or empty if unknown.
+	// SparsePaths lists the directories checked out in the container; empty
+	// means the full tree.
+	SparsePaths []string `json:"sparsePaths,omitempty"`
 }
 
 // Task is the JSON representation sent to the frontend.
@@ -174,17 +363,126 @@ type Task struct {
 	CIChecks                           []ForgeCheck `json:"ciChecks,omitempty"`
 	Owner                              string       `json:"owner,omitempty"` // username of creator; omitted in no-auth mode
 	// Per-task harness/container metadata.
-	Harness       Harness `json:"harness"`
-	Model         string  `json:"model,omitempty"`
-	AgentVersion  string  `json:"agentVersion,omitempty"`
-	SessionID     string  `json:"sessionID,omitempty"`
-	StartedAt     float64 `json:"startedAt,omitempty"`     // Unix epoch seconds (ms precision) when the container started.
-	TurnStartedAt float64 `json:"turnStartedAt,omitempty"` // Unix epoch seconds; non-zero only while state is "running".
-	InPlanMode    bool    `json:"inPlanMode,omitempty"`
-	PlanContent   string  `json:"planContent,omitempty"`
-	Tailscale     string  `json:"tailscale,omitempty"` // Tailscale URL (https://fqdn) or "true" if enabled but FQDN unknown.
-	USB           bool    `json:"usb,omitempty"`
-	Display       bool    `json:"display,omitempty"`
+	Harness       Harness  `json:"harness"`
+	Model         string   `json:"model,omitempty"`
+	AgentVersion  string   `json:"agentVersion,omitempty"`
+	SessionID     string   `json:"sessionID,omitempty"`
+	StartedAt     float64  `json:"startedAt,omitempty"`     // Unix epoch seconds (ms precision) when the container started.
+	TurnStartedAt float64  `json:"turnStartedAt,omitempty"` // Unix epoch seconds; non-zero only while state is "running".
+	HandedOffAt   float64  `json:"handedOffAt,omitempty"`   // Unix epoch seconds; non-zero while the session is continued in a terminal.
+	Kind          TaskKind `json:"kind,omitempty"`          // Omitted for coding tasks.
+	InPlanMode    bool     `json:"inPlanMode,omitempty"`
+	PlanContent   string   `json:"planContent,omitempty"`
+	Tailscale     string   `json:"tailscale,omitempty"` // Tailscale URL (https://fqdn) or "true" if enabled but FQDN unknown.
+	USB           bool     `json:"usb,omitempty"`
+	Display       bool     `json:"display,omitempty"`
+	GPU           bool     `json:"gpu,omitempty"`
+	AutoResume    bool     `json:"autoResume,omitempty"`
+	// Priority is omitted for normal priority tasks.
+	Priority Priority `json:"priority,omitempty"`
+	// ReplayOf is the task this one replays from the same base commit.
+	ReplayOf ksid.ID `json:"replayOf,omitzero"`
+	// AskPolicy applies to unanswered questions; nil waits for the user.
+	AskPolicy *AskPolicy `json:"askPolicy,omitempty"`
+	// Automation is omitted when following the working hours of the
+	// repository.
+	Automation Automation `json:"automation,omitempty"`
+	// TodosOpen and TodosCompleted count the items of the agent's latest
+	// todo list, see GET /api/v1/tasks/{id}/todos.
+	TodosOpen      int `json:"todosOpen,omitempty"`
+	TodosCompleted int `json:"todosCompleted,omitempty"`
+	// LastActivityAt is when the agent last emitted a message, in Unix epoch
+	// seconds. Stuck is set while the running turn has been silent for
+	// longer than the server's timeout.
+	LastActivityAt float64 `json:"lastActivityAt,omitempty"`
+	Stuck          bool    `json:"stuck,omitempty"`
+	// Warnings are the suspicious outcomes of the last turn, to look at
+	// before syncing.
+	Warnings []EventWarning `json:"warnings,omitempty"`
+	// Label is the outcome of the task; nil until labeled.
+	Label *TaskLabel `json:"label,omitempty"`
+	// Image is the container image requested for the task; empty for the
+	// server default. ImageID is the digest of the image the container
+	// actually runs, known shortly after provisioning.
+	Image   string `json:"image,omitempty"`
+	ImageID string `json:"imageID,omitempty"`
+	// DiskUsage is the latest container disk probe; nil until the first probe.
+	DiskUsage *DiskUsage `json:"diskUsage,omitempty"`
+	// Scrubbed counts the matches redacted from the prompts and the log per
+	// scrubbing rule name, when the server scrubs them.
+	Scrubbed map[string]int `json:"scrubbed,omitempty"`
+	// AffectedTargets are the build targets affected by the branch, when
+	// the repository computes them; nil until first computed.
+	AffectedTargets *AffectedTargets `json:"affectedTargets,omitempty"`
+}
+
+// AffectedTool is how the affected build targets are computed.
+type AffectedTool string
+
+// AffectedTool values.
+const (
+	AffectedToolGo    AffectedTool = "go"    // Go packages depending on the changed ones.
+	AffectedToolBazel AffectedTool = "bazel" // Reverse dependencies of the changed files.
+)
+
+// AffectedTargets are the build targets a task's branch may break.
+type AffectedTargets struct {
+	Tool       AffectedTool `json:"tool"`
+	Targets    []string     `json:"targets,omitempty"`   // Go import paths or Bazel labels, sorted.
+	Truncated  bool         `json:"truncated,omitempty"` // Targets lists only the first ones.
+	ComputedAt float64      `json:"computedAt"`          // Unix epoch seconds (ms precision).
+}
+
+// DiskUsage reports disk consumption inside a task's container.
+type DiskUsage struct {
+	UsedBytes  int64     `json:"usedBytes"`
+	TotalBytes int64     `json:"totalBytes"`
+	Dirs       []DirSize `json:"dirs,omitempty"` // Largest space consumers, biggest first.
+	CheckedAt  float64   `json:"checkedAt"`      // Unix epoch seconds (ms precision) of the probe.
+}
+
+// DirSize is the size of a directory inside a task's container.
+type DirSize struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// HandoffResp is the response for POST /api/v1/tasks/{id}/handoff. The task
+// is paused; POST /api/v1/tasks/{id}/resume takes it back, importing what was
+// done in the terminal like POST /api/v1/tasks/{id}/handoff/import.
+type HandoffResp struct {
+	Container string   `json:"container"`
+	Dir       string   `json:"dir"` // Working directory in the container.
+	SessionID string   `json:"sessionID"`
+	Command   []string `json:"command"` // Harness CLI command line to run in dir.
+	SSH       string   `json:"ssh"`     // Shell command opening the harness CLI from the caic host.
+	Script    string   `json:"script"`  // Shell script running SSH.
+}
+
+// ImportHandoffResp is the response for POST
+// /api/v1/tasks/{id}/handoff/import.
+type ImportHandoffResp struct {
+	Messages int `json:"messages"` // Messages added to the task.
+}
+
+// CleanTaskResp is the response for POST /api/v1/tasks/{id}/clean.
+type CleanTaskResp struct {
+	Output    string     `json:"output,omitempty"` // Combined output of the cleanup command.
+	DiskUsage *DiskUsage `json:"diskUsage,omitempty"`
+}
+
+// TaskSummaryReq is the request for POST /api/v1/tasks/{id}/summary.
+type TaskSummaryReq struct {
+	Force bool `json:"force,omitempty"` // Regenerate even if the stored summary covers every message.
+}
+
+// TaskSummaryResp is a condensed narrative of a task's conversation.
+type TaskSummaryResp struct {
+	Text      string  `json:"text"`      // Markdown.
+	Messages  int     `json:"messages"`  // Number of messages summarized.
+	Chunks    int     `json:"chunks"`    // Number of transcript chunks summarized separately.
+	CreatedAt float64 `json:"createdAt"` // Unix epoch seconds (ms precision).
+	Stale     bool    `json:"stale"`     // The task has messages newer than the summary.
 }
 
 // TaskListEvent is a discriminated-union event for the task list SSE stream.
@@ -218,6 +516,55 @@ type StatusResp struct {
 type CreateTaskResp struct {
 	Status string  `json:"status"`
 	ID     ksid.ID `json:"id"`
+	// ContextCuts lists the attached context and repository knowledge that
+	// didn't fit in the model's token budget.
+	ContextCuts []ContextCut `json:"contextCuts,omitempty"`
+}
+
+// ContextCut is material truncated or dropped from the initial prompt.
+type ContextCut struct {
+	Name   string `json:"name"`   // "file:<path>", "task:<id>", "url:<url>", "knowledge" or "repomap".
+	Tokens int    `json:"tokens"` // Estimated tokens of the whole material.
+	Kept   int    `json:"kept"`   // Tokens kept when truncated; 0 when dropped.
+}
+
+// PreflightStatus is the outcome of a pre-flight check.
+type PreflightStatus string
+
+// Pre-flight check outcomes.
+const (
+	PreflightOK      PreflightStatus = "ok"
+	PreflightWarning PreflightStatus = "warning" // The task may still fail.
+	PreflightFailed  PreflightStatus = "failed"  // Creating the task would fail.
+	PreflightSkipped PreflightStatus = "skipped" // Not applicable, or depends on a failed check.
+)
+
+// Pre-flight check names.
+const (
+	PreflightRepos       = "repos"       // The repos are known.
+	PreflightBaseBranch  = "baseBranch"  // Origin is reachable and has the base branches.
+	PreflightHarness     = "harness"     // The harness, model and prompt are supported.
+	PreflightCredentials = "credentials" // The harness configuration exists on the host.
+	PreflightPolicy      = "policy"      // The repo policies allow the harness and model.
+	PreflightGPU         = "gpu"
+	PreflightRuntime     = "runtime" // The container runtime is recent enough.
+	PreflightImage       = "image"
+	PreflightSpending    = "spending"   // No spending limit is exceeded.
+	PreflightRepoLimits  = "repoLimits" // The repos are under their task and branch limits.
+)
+
+// ValidateTaskResp is the response for POST /api/v1/tasks/validate.
+type ValidateTaskResp struct {
+	Ready  bool             `json:"ready"` // No check failed.
+	Checks []PreflightCheck `json:"checks"`
+}
+
+// PreflightCheck is the outcome of one pre-flight check of a task creation.
+type PreflightCheck struct {
+	Name    string          `json:"name"`
+	Status  PreflightStatus `json:"status"`
+	Message string          `json:"message,omitempty"`
+	Code    string          `json:"code,omitempty"` // Error code creating the task would return.
 }
 
 // CILogResp is the response for GET /api/v1/tasks/{id}/ci-log.
@@ -237,6 +584,201 @@ type CreateTaskReq struct {
 	Tailscale     bool       `json:"tailscale,omitempty"`
 	USB           bool       `json:"usb,omitempty"`
 	Display       bool       `json:"display,omitempty"`
+	// GPU requests GPU passthrough. GPU tasks are queued while every GPU is
+	// in use by another task.
+	GPU bool `json:"gpu,omitempty"`
+	// Priority orders tasks competing for GPUs. Defaults to normal.
+	Priority Priority `json:"priority,omitempty"`
+	// Context is included with the initial prompt sent to the harness.
+	Context *PromptContext `json:"context,omitempty"`
+	// Kind defaults to code. Explain tasks need a repository and a harness
+	// supporting read-only sessions; their prompt defaults to the overview
+	// instructions and their answer is stored as the repository overview.
+	Kind TaskKind `json:"kind,omitempty"`
+	// AutoResume continues a turn interrupted by the loss of the connection
+	// to the agent, e.g. across a server restart, with a prompt recapping
+	// the last messages and the open todo items instead of waiting for
+	// input.
+	AutoResume bool `json:"autoResume,omitempty"`
+	// AskPolicy answers, escalates or pauses when a question of the agent
+	// stays unanswered. Nil waits for the user.
+	AskPolicy *AskPolicy `json:"askPolicy,omitempty"`
+	// Automation overrides the working hours of the repository. Defaults to
+	// following them.
+	Automation Automation `json:"automation,omitempty"`
+}
+
+// PromptContext references material the server includes with the initial
+// prompt of a task, each piece size-capped.
+type PromptContext struct {
+	Tasks []ksid.ID `json:"tasks,omitempty"` // Prior tasks, included as their summary.
+	URLs  []string  `json:"urls,omitempty"`  // Pages fetched by the server, included as text.
+	// Files are paths in the task's first repository, included with their
+	// content on the base branch.
+	Files []string `json:"files,omitempty"`
+}
+
+// CommandExecution is a shell command run by the agent.
+type CommandExecution struct {
+	MessageIndex int     `json:"messageIndex"` // Index of the tool use in the task's messages.
+	ToolUseID    string  `json:"toolUseID"`    // Full input via GET /api/v1/tasks/{id}/tool/{toolUseID}.
+	Command      string  `json:"command"`
+	Cwd          string  `json:"cwd,omitempty"`
+	Done         bool    `json:"done"`
+	ExitCode     *int    `json:"exitCode,omitempty"` // Omitted when unknown or not done.
+	Duration     float64 `json:"duration,omitempty"` // Seconds; 0 when not reported by the harness.
+	Output       string  `json:"output,omitempty"`   // Tail of the output, at most 2 KiB.
+	Error        string  `json:"error,omitempty"`
+}
+
+// TaskCommandsResp is the response for GET /api/v1/tasks/{id}/commands.
+type TaskCommandsResp struct {
+	Commands []CommandExecution `json:"commands"`
+}
+
+// TaskEventRangeResp is the response for GET
+// /api/v1/tasks/{id}/events/range?from=&to=: the events of the history with
+// a sequence number in [From, To), at most 1000. Sequence numbers are the SSE
+// ids of the history replayed by GET /api/v1/tasks/{id}/events.
+type TaskEventRangeResp struct {
+	From   int            `json:"from"`
+	To     int            `json:"to"`
+	Total  int            `json:"total"` // Events in the whole history.
+	Events []EventMessage `json:"events"`
+}
+
+// TaskTodosResp is the response for GET /api/v1/tasks/{id}/todos.
+type TaskTodosResp struct {
+	Todos []TodoItem `json:"todos"` // Latest todo list of the agent, in its order.
+}
+
+// TaskDeadLettersResp is the response for GET /api/v1/tasks/{id}/dead-letters.
+type TaskDeadLettersResp struct {
+	Enabled     bool         `json:"enabled"`     // Strict parsing is on; otherwise nothing is captured.
+	DeadLetters []DeadLetter `json:"deadLetters"` // Oldest first.
+}
+
+// DeadLetter is a wire line the harness parser failed to decode or didn't
+// know, so it produced no event.
+type DeadLetter struct {
+	Ts   float64 `json:"ts"`             // Unix epoch seconds (ms precision).
+	Type string  `json:"type,omitempty"` // Record type or method; empty when the line didn't decode.
+	Err  string  `json:"err,omitempty"`  // Decoding error; empty for an unknown record type.
+	Line string  `json:"line"`
+}
+
+// Annotation is a bookmark on a message of a task, with an optional note.
+type Annotation struct {
+	ID           ksid.ID `json:"id"`
+	MessageIndex int     `json:"messageIndex"` // Index of the message in the task's messages.
+	Note         string  `json:"note,omitempty"`
+	Author       string  `json:"author,omitempty"` // User ID; empty when auth is disabled.
+	CreatedAt    float64 `json:"createdAt"`        // Unix epoch seconds (ms precision).
+}
+
+// TaskAnnotationsResp is the response for GET /api/v1/tasks/{id}/annotations.
+type TaskAnnotationsResp struct {
+	Annotations []Annotation `json:"annotations"` // Sorted by message index.
+}
+
+// AnnotateReq is the request for POST /api/v1/tasks/{id}/annotations.
+type AnnotateReq struct {
+	MessageIndex int    `json:"messageIndex"`
+	Note         string `json:"note,omitempty"` // Empty for a plain bookmark.
+}
+
+// TaskEnvResp is the response for GET /api/v1/tasks/{id}/env: the task's
+// container environment captured after provisioning.
+type TaskEnvResp struct {
+	At       float64           `json:"at"` // Unix epoch seconds.
+	Image    string            `json:"image,omitempty"`
+	ImageID  string            `json:"imageID,omitempty"` // Content digest, "sha256:...".
+	Platform string            `json:"platform,omitempty"`
+	Tools    map[string]string `json:"tools"` // Tool name to version; missing tools are absent.
+	Env      map[string]string `json:"env"`   // Selected non-secret environment variables.
+}
+
+// ResourceSample is one CPU and memory measurement of a task's container.
+type ResourceSample struct {
+	At         float64     `json:"at"`         // Unix epoch seconds.
+	CPUPercent float64     `json:"cpuPercent"` // Container CPU since the previous sample, in percent of one core.
+	MemBytes   int64       `json:"memBytes"`   // Container memory per its cgroup.
+	Procs      []ProcUsage `json:"procs,omitempty"`
+}
+
+// ProcUsage is a process inside a task's container.
+type ProcUsage struct {
+	PID        int     `json:"pid"`
+	Command    string  `json:"command"`
+	CPUPercent float64 `json:"cpuPercent"` // CPU time over the process lifetime, as reported by ps.
+	RSSBytes   int64   `json:"rssBytes"`
+}
+
+// TaskResourcesResp is the response for GET /api/v1/tasks/{id}/resources.
+type TaskResourcesResp struct {
+	Samples []ResourceSample `json:"samples"` // Oldest first.
+}
+
+// BulkAction is an operation applied by POST /api/v1/tasks/bulk.
+type BulkAction string
+
+// Supported bulk actions.
+const (
+	// BulkTerminate purges the task: its container is deleted.
+	BulkTerminate BulkAction = "terminate"
+	// BulkArchive stops the task's container, keeping it for revival.
+	BulkArchive BulkAction = "archive"
+	// BulkRetry creates a new task with the same parameters as a failed or
+	// purged task.
+	BulkRetry BulkAction = "retry"
+)
+
+// BulkTasksReq is the request body for POST /api/v1/tasks/bulk.
+type BulkTasksReq struct {
+	Action BulkAction `json:"action"`
+	IDs    []ksid.ID  `json:"ids"`
+}
+
+// BulkTaskResult is the outcome of the action on one task.
+type BulkTaskResult struct {
+	ID     ksid.ID `json:"id"`
+	Status string  `json:"status,omitempty"` // Set on success, as returned by the single-task endpoint.
+	TaskID ksid.ID `json:"taskId,omitzero"`  // New task created by BulkRetry.
+	Error  string  `json:"error,omitempty"`  // Set on failure.
+	Code   string  `json:"code,omitempty"`   // Error code, e.g. "CONFLICT".
+}
+
+// BulkTasksResp is the response for POST /api/v1/tasks/bulk. Results are in
+// request order.
+type BulkTasksResp struct {
+	Results []BulkTaskResult `json:"results"`
+}
+
+// Draft is a task saved server-side without being started: no branch nor
+// container is allocated until it is started.
+type Draft struct {
+	ID        ksid.ID       `json:"id"`
+	Title     string        `json:"title"`
+	Task      CreateTaskReq `json:"task"`
+	CreatedAt float64       `json:"createdAt"` // Unix epoch seconds.
+	UpdatedAt float64       `json:"updatedAt"` // Unix epoch seconds.
+}
+
+// StartDraftsReq is the request body for POST /api/v1/drafts/start.
+type StartDraftsReq struct {
+	IDs []ksid.ID `json:"ids"`
+}
+
+// StartDraftResult is the outcome of starting one draft.
+type StartDraftResult struct {
+	DraftID ksid.ID `json:"draftId"`
+	TaskID  ksid.ID `json:"taskId,omitzero"` // Set on success.
+	Error   string  `json:"error,omitempty"` // Set on failure; the draft is kept.
+}
+
+// StartDraftsResp is the response for POST /api/v1/drafts/start.
+type StartDraftsResp struct {
+	Results []StartDraftResult `json:"results"`
 }
 
 // BotFixCIReq is the request body for POST /api/v1/bot/fix-ci.
@@ -267,6 +809,16 @@ type DiffFileStat struct {
 	Added   int    `json:"added"`
 	Deleted int    `json:"deleted"`
 	Binary  bool   `json:"binary,omitempty"`
+	LFS     bool   `json:"lfs,omitempty"` // Git LFS pointer; added and deleted count the pointer's lines.
+	// Submodule is set when the file is a submodule whose commit changed.
+	Submodule *SubmoduleBump `json:"submodule,omitempty"`
+}
+
+// SubmoduleBump is the change of the commit a submodule points to. From is
+// empty for an added submodule and To for a removed one.
+type SubmoduleBump struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
 }
 
 // DiffStat summarises the changes in a branch relative to its base.
@@ -275,7 +827,7 @@ type DiffStat []DiffFileStat
 // SafetyIssue describes a potential problem detected before pushing to origin.
 type SafetyIssue struct {
 	File   string `json:"file"`
-	Kind   string `json:"kind"`   // "large_binary" or "secret"
+	Kind   string `json:"kind"`   // "large_binary", "secret" or "test_deletion"
 	Detail string `json:"detail"` // Human-readable description.
 }
 
@@ -294,6 +846,138 @@ type SyncReq struct {
 	Target SyncTarget `json:"target,omitempty"`
 }
 
+// ReplayTaskReq is the request for POST /api/v1/tasks/{id}/replay.
+type ReplayTaskReq struct {
+	// Model overrides the original task's model, e.g. to evaluate a model
+	// upgrade against past work. Empty keeps the original model.
+	Model string `json:"model,omitempty"`
+}
+
+// EvalArm is one harness and model combination compared by an evaluation.
+type EvalArm struct {
+	Harness Harness `json:"harness"`
+	Model   string  `json:"model,omitempty"` // Empty uses the harness default.
+}
+
+// CreateEvalReq is the request for POST /api/v1/evals. Every prompt is run
+// once per arm, all tasks starting from the same commit of Repo.
+type CreateEvalReq struct {
+	Name       string `json:"name,omitempty"`
+	Repo       string `json:"repo"`
+	BaseBranch string `json:"baseBranch,omitempty"` // Empty uses the repo's default branch.
+	// Prompts is the suite. It is appended to the prompts read from
+	// SuitePath.
+	Prompts []string `json:"prompts,omitempty"`
+	// SuitePath is a file in Repo at the base commit holding prompts
+	// separated by lines containing only "---".
+	SuitePath string    `json:"suitePath,omitempty"`
+	Arms      []EvalArm `json:"arms"` // Exactly two.
+	// Verify is a shell command run in each task's checkout once the agent
+	// finished its first turn. Exit status 0 is a pass. When the repository
+	// computes affected targets, they are exported space separated as
+	// CAIC_AFFECTED_TARGETS, e.g. to run only their tests.
+	Verify string `json:"verify,omitempty"`
+}
+
+// EvalResult is the outcome of one prompt on one arm.
+type EvalResult struct {
+	TaskID   ksid.ID `json:"taskId,omitzero"`
+	Error    string  `json:"error,omitempty"` // Set when the task could not be created.
+	State    string  `json:"state,omitempty"` // Task state; empty until the task was created.
+	Finished bool    `json:"finished"`        // The agent completed its first turn or the task ended.
+	Files    int     `json:"files"`
+	Added    int     `json:"added"`
+	Deleted  int     `json:"deleted"`
+	CostUSD  float64 `json:"costUSD"`
+	Duration float64 `json:"duration"` // Agent time in seconds.
+	// Verify is "pass" or "fail" once the verification command ran, empty
+	// otherwise.
+	Verify       string `json:"verify,omitempty"`
+	VerifyOutput string `json:"verifyOutput,omitempty"` // Tail of the command output.
+}
+
+// EvalCase is one prompt of the suite and its result on each arm, in the
+// order of EvalRun.Arms.
+type EvalCase struct {
+	Prompt  string       `json:"prompt"`
+	Results []EvalResult `json:"results"`
+}
+
+// EvalArmSummary aggregates the results of one arm.
+type EvalArmSummary struct {
+	Harness      Harness `json:"harness"`
+	Model        string  `json:"model,omitempty"`
+	Tasks        int     `json:"tasks"` // Tasks created.
+	Finished     int     `json:"finished"`
+	Failed       int     `json:"failed"` // Tasks that ended in the failed state or could not be created.
+	VerifyPassed int     `json:"verifyPassed"`
+	Files        int     `json:"files"`
+	Added        int     `json:"added"`
+	Deleted      int     `json:"deleted"`
+	CostUSD      float64 `json:"costUSD"`
+	Duration     float64 `json:"duration"` // Total agent time in seconds.
+}
+
+// EvalRun is an evaluation comparing two arms over a suite of prompts.
+type EvalRun struct {
+	ID        ksid.ID          `json:"id"`
+	Name      string           `json:"name,omitempty"`
+	Repo      string           `json:"repo"`
+	BaseSHA   string           `json:"baseSHA"`
+	Verify    string           `json:"verify,omitempty"`
+	CreatedAt float64          `json:"createdAt"`
+	Arms      []EvalArm        `json:"arms"`
+	Summary   []EvalArmSummary `json:"summary"` // One per arm.
+	// Cases is omitted when listing runs.
+	Cases []EvalCase `json:"cases,omitempty"`
+}
+
+// TaskLabel is the outcome label of a task.
+type TaskLabel struct {
+	Outcome   TaskOutcome `json:"outcome"`
+	Reason    string      `json:"reason,omitempty"`
+	Source    LabelSource `json:"source"`
+	LabeledBy string      `json:"labeledBy,omitempty"` // User ID; empty when auth is disabled.
+	LabeledAt float64     `json:"labeledAt"`           // Unix epoch seconds (ms precision).
+}
+
+// LabelTaskReq is the request for POST /api/v1/tasks/{id}/label.
+type LabelTaskReq struct {
+	// Outcome of the task. Empty removes the label.
+	Outcome TaskOutcome `json:"outcome,omitempty"`
+	Reason  string      `json:"reason,omitempty"`
+}
+
+// ShareTaskReq is the request for POST /api/v1/tasks/{id}/share.
+type ShareTaskReq struct {
+	// TTL is the lifetime of the link in Go syntax (e.g. "72h"). Defaults to
+	// 24 hours; at most 30 days.
+	TTL string `json:"ttl,omitempty"`
+}
+
+// ShareTaskResp is the response for POST /api/v1/tasks/{id}/share. Anyone
+// holding the token can read the task, its events and its diff under Path
+// until ExpiresAt, without signing in.
+type ShareTaskResp struct {
+	Token     string  `json:"token"`
+	Path      string  `json:"path"`      // e.g. "/api/v1/shared/<token>", under the base path; append "/events", "/events/range", "/diff" or "/export.html".
+	ExpiresAt float64 `json:"expiresAt"` // Unix epoch seconds (ms precision).
+}
+
+// ApplyTaskReq is the request for POST /api/v1/tasks/{id}/apply.
+type ApplyTaskReq struct {
+	// Path is an absolute path on the server to a clean worktree of the
+	// task's repository, e.g. the user's checkout of a feature branch.
+	Path string `json:"path"`
+}
+
+// ApplyTaskResp is the response for POST /api/v1/tasks/{id}/apply.
+type ApplyTaskResp struct {
+	Status   string   `json:"status"` // "applied" or "empty"
+	Path     string   `json:"path"`   // Worktree the changes were applied to.
+	DiffStat DiffStat `json:"diffStat,omitzero"`
+}
+
 // SyncResp is the response for POST /api/v1/tasks/{id}/sync.
 type SyncResp struct {
 	Status       string        `json:"status"` // "synced", "blocked", or "empty"
@@ -322,11 +1006,143 @@ type ExtraUsage struct {
 	Utilization  float64 `json:"utilization"`
 }
 
+// OrgDailyCost is the organization's spend for one UTC day.
+type OrgDailyCost struct {
+	Date    string  `json:"date"` // YYYY-MM-DD
+	CostUSD float64 `json:"costUSD"`
+}
+
+// OrgUsage is organization-wide spend from the Anthropic Admin API. It covers
+// all API usage billed to the organization, not only caic tasks.
+type OrgUsage struct {
+	SevenDayUSD float64        `json:"sevenDayUSD"`
+	Days        []OrgDailyCost `json:"days"`
+}
+
 // UsageResp is the response for GET /api/v1/usage.
 type UsageResp struct {
 	FiveHour   UsageWindow `json:"fiveHour"`
 	SevenDay   UsageWindow `json:"sevenDay"`
 	ExtraUsage ExtraUsage  `json:"extraUsage"`
+	// Org is set when an Anthropic admin key is configured.
+	Org *OrgUsage `json:"org,omitempty"`
+}
+
+// TurnUsage is the token usage and cost of a single agent turn.
+type TurnUsage struct {
+	Ts                       float64 `json:"ts"`                  // Unix epoch seconds (ms precision) when the turn ended; 0 if unknown.
+	Estimated                bool    `json:"estimated,omitempty"` // Ts was reconstructed from turn durations (task loaded from logs).
+	Model                    string  `json:"model,omitempty"`
+	InputTokens              int     `json:"inputTokens"`
+	OutputTokens             int     `json:"outputTokens"`
+	CacheCreationInputTokens int     `json:"cacheCreationInputTokens"`
+	CacheReadInputTokens     int     `json:"cacheReadInputTokens"`
+	CostUSD                  float64 `json:"costUSD"`
+	Duration                 float64 `json:"duration"` // Seconds.
+	// Latency breakdown in seconds, measured live; 0 for turns loaded from
+	// logs.
+	FirstToken float64 `json:"firstToken,omitempty"` // Prompt sent → first agent output.
+	Completion float64 `json:"completion,omitempty"` // First agent output → turn complete.
+	ToolTime   float64 `json:"toolTime,omitempty"`   // Time spent in tool calls.
+	ModelTime  float64 `json:"modelTime,omitempty"`  // Wall time not spent in tool calls.
+}
+
+// TaskUsageResp is the response for GET /api/v1/tasks/{id}/usage.
+type TaskUsageResp struct {
+	Turns   []TurnUsage `json:"turns"`
+	CostUSD float64     `json:"costUSD"` // Sum of Turns[].CostUSD.
+}
+
+// ToolStats aggregates the calls of one tool.
+type ToolStats struct {
+	Name        string  `json:"name"`
+	Calls       int     `json:"calls"`
+	Failures    int     `json:"failures"`    // Calls that reported an error or a non-zero exit code.
+	FailureRate float64 `json:"failureRate"` // Failures / Calls.
+	// Duration is the time spent in the tool in seconds. Calls whose duration
+	// was neither reported by the harness nor measured count as zero.
+	Duration float64 `json:"duration"`
+	// Share is Duration relative to the agent's session time; 0 when the
+	// latter is unknown. Parallel calls may make the shares sum above 1.
+	Share float64 `json:"share"`
+}
+
+// TaskToolsResp is the response for GET /api/v1/tasks/{id}/tools.
+type TaskToolsResp struct {
+	Duration float64     `json:"duration"` // Agent session time in seconds.
+	Tools    []ToolStats `json:"tools"`    // Most time consuming first.
+}
+
+// RepoToolsResp is the response for GET /api/v1/server/repos/tools. It rolls
+// up the tasks of the repository known to the server.
+type RepoToolsResp struct {
+	Repo     string      `json:"repo"`
+	Tasks    int         `json:"tasks"`
+	Duration float64     `json:"duration"` // Sum of the tasks' session time in seconds.
+	Tools    []ToolStats `json:"tools"`    // Most time consuming first.
+}
+
+// SpendingLimit is the state of one configured spending limit.
+type SpendingLimit struct {
+	Harness  Harness `json:"harness,omitempty"` // Empty for the overall limit.
+	Window   string  `json:"window"`            // "daily" or "weekly" (rolling 24h / 7d).
+	LimitUSD float64 `json:"limitUSD"`
+	SpentUSD float64 `json:"spentUSD"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+// SpendingResp is the response for GET /api/v1/server/spending.
+type SpendingResp struct {
+	Limits        []SpendingLimit `json:"limits"`
+	OverrideUntil float64         `json:"overrideUntil,omitempty"` // Unix epoch seconds; limits are not enforced before then.
+}
+
+// SpendingOverrideReq is the request body for POST
+// /api/v1/server/spending/override.
+type SpendingOverrideReq struct {
+	// Duration during which limits are not enforced, in Go syntax (e.g.
+	// "2h"). "0s" cancels an active override.
+	Duration string `json:"duration"`
+}
+
+// UsageSampleTask is the spend of one task during a usage sample interval.
+type UsageSampleTask struct {
+	ID      string  `json:"id"`
+	Title   string  `json:"title,omitempty"` // Empty when the task is no longer loaded.
+	CostUSD float64 `json:"costUSD"`
+}
+
+// UsageSample is a point-in-time usage snapshot.
+type UsageSample struct {
+	Ts                  float64           `json:"ts"` // Unix epoch seconds (ms precision).
+	FiveHourUtilization float64           `json:"fiveHourUtilization"`
+	SevenDayUtilization float64           `json:"sevenDayUtilization"`
+	FiveHourCostUSD     float64           `json:"fiveHourCostUSD"`
+	SevenDayCostUSD     float64           `json:"sevenDayCostUSD"`
+	OrgSevenDayUSD      float64           `json:"orgSevenDayUSD,omitempty"`
+	Tasks               []UsageSampleTask `json:"tasks,omitempty"` // Tasks that spent since the previous sample.
+}
+
+// UsageHistoryResp is the response for GET /api/v1/usage/history.
+type UsageHistoryResp struct {
+	Samples []UsageSample `json:"samples"`
+}
+
+// SearchHit is a message matching a search query.
+type SearchHit struct {
+	TaskID       string  `json:"taskId"`
+	Title        string  `json:"title"`
+	Repo         string  `json:"repo,omitempty"`
+	StartedAt    float64 `json:"startedAt"`    // Unix epoch seconds (ms precision).
+	MessageIndex int     `json:"messageIndex"` // Index of the matching message in the conversation.
+	Kind         string  `json:"kind"`         // "prompt", "text", "command" or "path".
+	Snippet      string  `json:"snippet"`
+	Loaded       bool    `json:"loaded"` // Whether the task is loaded by the server and can be opened.
+}
+
+// SearchResp is the response for GET /api/v1/search.
+type SearchResp struct {
+	Hits []SearchHit `json:"hits"`
 }
 
 // VoiceTokenResp is the response for GET /api/v1/voice/token.
@@ -336,9 +1152,61 @@ type VoiceTokenResp struct {
 	Ephemeral bool   `json:"ephemeral"`
 }
 
-// DiffResp is the response for GET /api/v1/tasks/{id}/diff.
+// DiffResp is the response for GET /api/v1/tasks/{id}/diff. The optional
+// ?path= query restricts it to a file and ?base= diffs against any branch,
+// tag or commit instead of the base branch. ?summary=true adds Summary.
+// Secrets are masked in Diff unless an admin passes ?unmask=true.
 type DiffResp struct {
 	Diff string `json:"diff"`
+	// Masked is the number of secrets masked in Diff.
+	Masked int `json:"masked,omitempty"`
+	// Summary lists the declarations the branch changed per source file,
+	// for the supported languages. It ignores ?path=.
+	Summary []DiffFileSummary `json:"summary,omitempty"`
+}
+
+// DiffFileSummary is the structural summary of the changes to a source file.
+type DiffFileSummary struct {
+	Path    string       `json:"path"`
+	Changes []DeclChange `json:"changes"`
+}
+
+// DeclOp is what happened to a declaration.
+type DeclOp string
+
+// DeclOp values.
+const (
+	DeclOpAdded     DeclOp = "added"
+	DeclOpRemoved   DeclOp = "removed"
+	DeclOpSignature DeclOp = "signature" // The signature changed, and maybe the body.
+	DeclOpModified  DeclOp = "modified"  // Only the body changed.
+)
+
+// DeclChange is a change to a declaration, e.g. an added function.
+type DeclChange struct {
+	Op   DeclOp `json:"op"`
+	Kind string `json:"kind"` // function, method, type, class, interface, trait, const or var.
+	Name string `json:"name"` // Methods are qualified by their type, e.g. "Task.Run".
+}
+
+// TaskCommit is a commit the agent made on the task branch.
+type TaskCommit struct {
+	SHA         string   `json:"sha"`
+	Author      string   `json:"author"`
+	CommittedAt float64  `json:"committedAt"` // Unix epoch seconds.
+	Message     string   `json:"message"`
+	Files       DiffStat `json:"files,omitempty"`
+	Added       int      `json:"added"`
+	Deleted     int      `json:"deleted"`
+	// Turn is the 1-based agent turn that produced the commit, matching
+	// GET /api/v1/tasks/{id}/usage turns; best-effort for restored tasks.
+	Turn int `json:"turn"`
+}
+
+// TaskCommitsResp is the response for GET /api/v1/tasks/{id}/commits.
+// Commits are oldest first.
+type TaskCommitsResp struct {
+	Commits []TaskCommit `json:"commits"`
 }
 
 // RepoPrefsResp holds per-repository preferences.
@@ -396,6 +1264,9 @@ type CloneRepoReq struct {
 	URL   string `json:"url"`            // Git clone URL (HTTPS or SSH).
 	Path  string `json:"path,omitempty"` // Target subdirectory under rootDir; defaults to repo basename.
 	Depth int    `json:"depth,omitempty"`
+	// Filter is a partial clone filter, e.g. "blob:none". Later host fetches
+	// reuse it and missing objects are fetched lazily.
+	Filter string `json:"filter,omitempty"`
 }
 
 // WebFetchReq is the request body for POST /api/v1/web/fetch.
@@ -414,6 +1285,115 @@ type RepoBranchesResp struct {
 	Branches []string `json:"branches"`
 }
 
+// PruneBranchesReq is the request body for
+// POST /api/v1/server/repos/branches/prune.
+type PruneBranchesReq struct {
+	Repo   string `json:"repo"`
+	DryRun bool   `json:"dryRun,omitempty"` // Report what would be deleted without deleting.
+}
+
+// TaskBranch is a task branch on origin.
+type TaskBranch struct {
+	Name        string  `json:"name"`        // e.g. "caic-3".
+	CommittedAt float64 `json:"committedAt"` // Unix epoch seconds of its head commit.
+	Merged      bool    `json:"merged"`      // Its head is reachable from the default branch.
+	InUse       bool    `json:"inUse"`       // It belongs to a task that isn't purged.
+	Pruned      bool    `json:"pruned"`      // Deleted, or would be on a dry run.
+}
+
+// PruneBranchesResp is the response for
+// POST /api/v1/server/repos/branches/prune.
+type PruneBranchesResp struct {
+	Repo      string       `json:"repo"`
+	DryRun    bool         `json:"dryRun,omitempty"`
+	Retention string       `json:"retention,omitempty"` // Go duration from settings.json; empty prunes merged branches only.
+	Branches  []TaskBranch `json:"branches"`            // Oldest first.
+}
+
+// CostGroup is the spend and outcomes of the tasks using one harness and
+// model.
+type CostGroup struct {
+	Harness  Harness `json:"harness"`
+	Model    string  `json:"model,omitempty"`
+	Tasks    int     `json:"tasks"`
+	CostUSD  float64 `json:"costUSD"`
+	Labeled  int     `json:"labeled"`
+	Accepted int     `json:"accepted"`
+	Partial  int     `json:"partial"`
+	Rejected int     `json:"rejected"`
+	// AcceptanceRate is Accepted over Labeled; 0 when no task is labeled.
+	AcceptanceRate float64 `json:"acceptanceRate"`
+	// CostPerAccepted is CostUSD over Accepted; 0 when no task is accepted.
+	CostPerAccepted float64 `json:"costPerAccepted"`
+}
+
+// CostReportResp is the response for GET /api/v1/server/costs.
+type CostReportResp struct {
+	CostUSD float64     `json:"costUSD"`
+	Groups  []CostGroup `json:"groups"` // Sorted by harness then model.
+}
+
+// RepoHeatmapResp is the response for GET /api/v1/server/repos/heatmap.
+type RepoHeatmapResp struct {
+	Repo   string        `json:"repo"`
+	Tasks  int           `json:"tasks"`  // Finished tasks that modified at least one file.
+	Failed int           `json:"failed"` // Of which failed; the baseline for FailureRate.
+	Files  []HeatmapFile `json:"files"`
+}
+
+// HeatmapFile is how often agents modified a file and how often those tasks
+// failed.
+type HeatmapFile struct {
+	Path        string  `json:"path"`
+	Tasks       int     `json:"tasks"`  // Tasks that modified the file.
+	Failed      int     `json:"failed"` // Of which failed.
+	Added       int     `json:"added"`  // Lines added, summed over the tasks.
+	Deleted     int     `json:"deleted"`
+	FailureRate float64 `json:"failureRate"` // Failed / Tasks.
+}
+
+// RepoKnowledgeResp holds the notes about a repository injected into new
+// tasks.
+type RepoKnowledgeResp struct {
+	Repo     string `json:"repo"`
+	Content  string `json:"content"`  // Markdown; one learning per "- " line.
+	MaxBytes int    `json:"maxBytes"` // Size cap; the oldest learnings are dropped beyond it.
+}
+
+// UpdateRepoKnowledgeReq replaces the notes about a repository.
+type UpdateRepoKnowledgeReq struct {
+	Repo    string `json:"repo"`
+	Content string `json:"content"` // Empty deletes the notes.
+}
+
+// RepoOverviewResp holds the overview of a repository written by its last
+// explain task.
+type RepoOverviewResp struct {
+	Repo        string  `json:"repo"`
+	Content     string  `json:"content"`               // Markdown; empty when no explain task completed.
+	TaskID      ksid.ID `json:"taskID,omitzero"`       // Explain task that wrote it.
+	GeneratedAt float64 `json:"generatedAt,omitempty"` // Unix epoch seconds.
+}
+
+// RepoMapResp holds the map of the packages and symbols of a repository
+// injected into new tasks, and the state of its refresh.
+type RepoMapResp struct {
+	Repo        string  `json:"repo"`
+	Enabled     bool    `json:"enabled"`               // Set with repoMap in the repo's settings.
+	Indexing    bool    `json:"indexing"`              // A refresh is in progress.
+	Error       string  `json:"error,omitempty"`       // Of the last refresh.
+	SHA         string  `json:"sha,omitempty"`         // Base branch commit the map describes.
+	GeneratedAt float64 `json:"generatedAt,omitempty"` // Unix epoch seconds.
+	Files       int     `json:"files"`
+	Symbols     int     `json:"symbols"`
+	Content     string  `json:"content"`
+}
+
+// RefreshRepoMapReq rebuilds the map of a repository.
+type RefreshRepoMapReq struct {
+	Repo string `json:"repo"`
+}
+
 // WellKnownCache describes a single well-known cache.
 type WellKnownCache struct {
 	Name        string   `json:"name"`
@@ -427,5 +1407,57 @@ type WellKnownCachesResp struct {
 	WellKnown     []WellKnownCache `json:"wellKnown"`
 }
 
+// ImagesResp is the response for GET /api/v1/server/images.
+type ImagesResp struct {
+	// TotalBytes is the size of every image in the local store. Layers
+	// shared between images are counted once per image.
+	TotalBytes int64         `json:"totalBytes"`
+	Interval   float64       `json:"interval"` // Prefetch period in seconds.
+	Images     []CachedImage `json:"images"`
+}
+
+// CachedImage is a container image kept pulled by the background prefetcher.
+type CachedImage struct {
+	Ref           string  `json:"ref"`
+	Present       bool    `json:"present"`                 // Found in the local image store.
+	SizeBytes     int64   `json:"sizeBytes,omitempty"`     // Local size when present.
+	LastPulledAt  float64 `json:"lastPulledAt,omitempty"`  // Unix seconds of the last successful pull.
+	LastAttemptAt float64 `json:"lastAttemptAt,omitempty"` // Unix seconds of the last pull attempt.
+	LastDuration  float64 `json:"lastDuration,omitempty"`  // Seconds the last attempt took.
+	LastError     string  `json:"lastError,omitempty"`     // Error of the last attempt.
+}
+
+// ContainersResp is the response for GET /api/v1/server/containers.
+type ContainersResp struct {
+	Containers []ContainerInfo `json:"containers"`
+}
+
+// ContainerInfo is a container started by caic, with the task metadata read
+// from its labels. Containers started by older versions lack some labels.
+type ContainerInfo struct {
+	Name          string  `json:"name"`
+	State         string  `json:"state"`                   // Docker state, e.g. "running" or "exited".
+	CreatedAt     float64 `json:"createdAt"`               // Unix seconds of the container creation.
+	TaskID        string  `json:"taskID"`                  // Value of the caic label.
+	Harness       string  `json:"harness,omitempty"`       // Harness name.
+	Repo          string  `json:"repo,omitempty"`          // Primary repo.
+	Branch        string  `json:"branch,omitempty"`        // Branch of the primary repo.
+	Owner         string  `json:"owner,omitempty"`         // Internal user ID of the task creator.
+	TaskCreatedAt float64 `json:"taskCreatedAt,omitempty"` // Unix seconds of the task creation.
+	TaskState     string  `json:"taskState,omitempty"`     // State of the task, when it is loaded.
+	// Orphan is set when no live task uses the container: its task isn't
+	// loaded, is purged or failed, or uses another container.
+	Orphan bool `json:"orphan"`
+}
+
+// ServerLogEntry is a single server log record streamed by
+// GET /api/v1/server/logs/events.
+type ServerLogEntry struct {
+	Time  time.Time      `json:"time"`
+	Level string         `json:"level"` // "DEBUG", "INFO", "WARN" or "ERROR"
+	Msg   string         `json:"msg"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
 // EmptyReq is used for endpoints that take no request body.
 type EmptyReq = dto.EmptyReq
@@ -5,9 +5,13 @@ import (
 	"net/url"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
 )
 
 // Validate checks that prompt or images are provided.
@@ -21,6 +25,85 @@ func (r *InputReq) Validate() error {
 // Validate is a no-op; prompt is optional (read from container plan file if empty).
 func (r *RestartReq) Validate() error { return nil }
 
+// Validate is a no-op; every combination of flags is valid.
+func (r *FeatureFlags) Validate() error { return nil }
+
+// maxAnnotationNote bounds the note of an annotation.
+const maxAnnotationNote = 500
+
+// Validate checks the message index and the note length.
+func (r *AnnotateReq) Validate() error {
+	if r.MessageIndex < 0 {
+		return dto.BadRequest("messageIndex must not be negative")
+	}
+	if len(r.Note) > maxAnnotationNote {
+		return dto.BadRequest("note is too long").WithDetail("max", maxAnnotationNote)
+	}
+	return nil
+}
+
+// maxLabelReason bounds the reason of an outcome label.
+const maxLabelReason = 1000
+
+// Validate checks the outcome and that a reason comes with one.
+func (r *LabelTaskReq) Validate() error {
+	switch r.Outcome {
+	case "":
+		if r.Reason != "" {
+			return dto.BadRequest("reason requires an outcome")
+		}
+	case OutcomeAccepted, OutcomePartial, OutcomeRejected:
+	default:
+		return dto.BadRequest("unknown outcome: " + string(r.Outcome))
+	}
+	if len(r.Reason) > maxLabelReason {
+		return dto.BadRequest("reason is too long").WithDetail("max", maxLabelReason)
+	}
+	return nil
+}
+
+// Validate is a no-op; the TTL is checked when the link is minted.
+func (r *ShareTaskReq) Validate() error { return nil }
+
+// Validate is a no-op; the model is checked against the harness when the
+// replay is created.
+func (r *ReplayTaskReq) Validate() error { return nil }
+
+// MaxEvalPrompts bounds the prompts of an evaluation; each is run once per
+// arm.
+const MaxEvalPrompts = 50
+
+// Validate checks that two arms are compared on a repo with a suite.
+func (r *CreateEvalReq) Validate() error {
+	if r.Repo == "" {
+		return dto.BadRequest("repo is required")
+	}
+	if len(r.Arms) != 2 {
+		return dto.BadRequest("exactly two arms are required")
+	}
+	for _, a := range r.Arms {
+		if a.Harness == "" {
+			return dto.BadRequest("arms contains entry without harness")
+		}
+	}
+	if len(r.Prompts) == 0 && r.SuitePath == "" {
+		return dto.BadRequest("prompts or suitePath is required")
+	}
+	if len(r.Prompts) > MaxEvalPrompts {
+		return dto.BadRequest("too many prompts").WithDetail("max", MaxEvalPrompts)
+	}
+	if slices.Contains(r.Prompts, "") {
+		return dto.BadRequest("prompts contains an empty prompt")
+	}
+	if r.SuitePath != "" && !task.ValidRepoPath(r.SuitePath) {
+		return dto.BadRequest("invalid suitePath").WithDetail("suitePath", r.SuitePath)
+	}
+	if strings.HasPrefix(r.BaseBranch, "-") {
+		return dto.BadRequest("invalid baseBranch").WithDetail("baseBranch", r.BaseBranch)
+	}
+	return nil
+}
+
 // Validate checks that the sync target is valid.
 func (r SyncReq) Validate() error {
 	switch r.Target {
@@ -31,15 +114,52 @@ func (r SyncReq) Validate() error {
 	}
 }
 
+// Validate checks that the worktree path is absolute.
+func (r *ApplyTaskReq) Validate() error {
+	if r.Path == "" {
+		return dto.BadRequest("path is required")
+	}
+	if !filepath.IsAbs(r.Path) {
+		return dto.BadRequest("path must be absolute").WithDetail("path", r.Path)
+	}
+	return nil
+}
+
+// imageRefRe matches a Docker image reference: name, optional tag and digest.
+var imageRefRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/:@-]*$`)
+
+// ValidImageRef reports whether ref looks like a Docker image reference and
+// cannot be mistaken for a command line flag.
+func ValidImageRef(ref string) bool {
+	return len(ref) <= 255 && imageRefRe.MatchString(ref)
+}
+
 // Validate checks that prompt and harness are valid. Repos is optional (empty
 // means no git repository is associated with the task).
 func (r *CreateTaskReq) Validate() error {
-	if r.InitialPrompt.Text == "" && len(r.InitialPrompt.Images) == 0 {
-		return dto.BadRequest("prompt or images required")
+	switch r.Kind {
+	case "", TaskKindCode:
+		if r.InitialPrompt.Text == "" && len(r.InitialPrompt.Images) == 0 {
+			return dto.BadRequest("prompt or images required")
+		}
+	case TaskKindExplain:
+		if len(r.Repos) == 0 {
+			return dto.BadRequest("explain tasks need a repository")
+		}
+	default:
+		return dto.BadRequest("unknown kind: " + string(r.Kind))
 	}
 	if r.Harness == "" {
 		return dto.BadRequest("harness is required")
 	}
+	switch r.Priority {
+	case "", PriorityLow, PriorityNormal, PriorityHigh:
+	default:
+		return dto.BadRequest("unknown priority: " + string(r.Priority))
+	}
+	if r.Image != "" && !ValidImageRef(r.Image) {
+		return dto.BadRequest("invalid image").WithDetail("image", r.Image)
+	}
 	seen := make(map[string]struct{}, len(r.Repos))
 	for _, rs := range r.Repos {
 		if rs.Name == "" {
@@ -49,10 +169,88 @@ func (r *CreateTaskReq) Validate() error {
 			return dto.BadRequest("repos contains duplicate name: " + rs.Name)
 		}
 		seen[rs.Name] = struct{}{}
+		for _, p := range rs.Paths {
+			if !task.ValidRepoPath(p) {
+				return dto.BadRequest("repos[" + rs.Name + "].paths contains invalid path: " + p)
+			}
+		}
+	}
+	if r.Context != nil {
+		if err := r.Context.validate(len(r.Repos) > 0); err != nil {
+			return err
+		}
+	}
+	if r.AskPolicy != nil {
+		if err := r.AskPolicy.validate(); err != nil {
+			return err
+		}
+	}
+	switch r.Automation {
+	case "", AutomationInteractive, AutomationUnattended:
+	default:
+		return dto.BadRequest("unknown automation: " + string(r.Automation))
 	}
 	return validateImages(r.InitialPrompt.Images)
 }
 
+// minAskTimeout is the shortest AskPolicy.Timeout in seconds, leaving the
+// user a chance to answer.
+const minAskTimeout = 60
+
+func (p *AskPolicy) validate() error {
+	if p.Timeout < minAskTimeout {
+		return dto.BadRequest("askPolicy.timeout is too short").WithDetail("min", minAskTimeout)
+	}
+	switch p.Action {
+	case AskActionAnswer:
+	case AskActionNotify, AskActionPause:
+		if p.Answer != "" {
+			return dto.BadRequest("askPolicy.answer requires the answer action")
+		}
+	default:
+		return dto.BadRequest("unknown askPolicy.action: " + string(p.Action))
+	}
+	return nil
+}
+
+// Limits on the references of a PromptContext.
+const (
+	maxContextTasks = 5
+	maxContextURLs  = 5
+	maxContextFiles = 20
+)
+
+func (c *PromptContext) validate(hasRepo bool) error {
+	if len(c.Tasks) > maxContextTasks {
+		return dto.BadRequest("context.tasks has too many entries").WithDetail("max", maxContextTasks)
+	}
+	for _, id := range c.Tasks {
+		if id.IsZero() {
+			return dto.BadRequest("context.tasks contains an empty ID")
+		}
+	}
+	if len(c.URLs) > maxContextURLs {
+		return dto.BadRequest("context.urls has too many entries").WithDetail("max", maxContextURLs)
+	}
+	for _, s := range c.URLs {
+		if err := (&WebFetchReq{URL: s}).Validate(); err != nil {
+			return dto.BadRequest("context.urls contains an invalid url: " + s)
+		}
+	}
+	if len(c.Files) > maxContextFiles {
+		return dto.BadRequest("context.files has too many entries").WithDetail("max", maxContextFiles)
+	}
+	if len(c.Files) > 0 && !hasRepo {
+		return dto.BadRequest("context.files requires a repository")
+	}
+	for _, p := range c.Files {
+		if !task.ValidRepoPath(p) {
+			return dto.BadRequest("context.files contains invalid path: " + p)
+		}
+	}
+	return nil
+}
+
 // allowedImageTypes is the set of MIME types accepted for image uploads.
 var allowedImageTypes = map[string]bool{
 	"image/png":  true,
@@ -64,6 +262,9 @@ var allowedImageTypes = map[string]bool{
 // pathSegmentRe matches valid path segments: starts with alphanumeric, then alphanumeric, dots, hyphens, or underscores.
 var pathSegmentRe = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
 
+// cloneFilterRe matches the partial clone filters accepted by CloneRepoReq.
+var cloneFilterRe = regexp.MustCompile(`^(blob:none|tree:0|blob:limit=[0-9]+[kmg]?)$`)
+
 // Validate checks that the clone URL is provided and the optional path is safe.
 func (r *CloneRepoReq) Validate() error {
 	if r.URL == "" {
@@ -72,6 +273,9 @@ func (r *CloneRepoReq) Validate() error {
 	if r.Depth < 0 {
 		return dto.BadRequest("depth must be non-negative")
 	}
+	if r.Filter != "" && !cloneFilterRe.MatchString(r.Filter) {
+		return dto.BadRequest("filter must be blob:none, tree:0 or blob:limit=<size>")
+	}
 	if r.Path != "" {
 		if filepath.IsAbs(r.Path) {
 			return dto.BadRequest("path must be relative")
@@ -133,6 +337,51 @@ func (r *BotFixPRReq) Validate() error {
 // Validate is a no-op; all settings values are accepted.
 func (r *UpdatePreferencesReq) Validate() error { return nil }
 
+// Validate is a no-op; all fields are optional.
+func (r *TaskSummaryReq) Validate() error { return nil }
+
+// Validate checks that the repo is provided.
+func (r *UpdateRepoKnowledgeReq) Validate() error {
+	if r.Repo == "" {
+		return dto.BadRequest("repo is required")
+	}
+	return nil
+}
+
+// Validate checks that repo is set.
+func (r *RefreshRepoMapReq) Validate() error {
+	if r.Repo == "" {
+		return dto.BadRequest("repo is required")
+	}
+	return nil
+}
+
+// Validate checks that repo is set.
+func (r *PruneBranchesReq) Validate() error {
+	if r.Repo == "" {
+		return dto.BadRequest("repo is required")
+	}
+	return nil
+}
+
+// maxSpendingOverride bounds SpendingOverrideReq.Duration.
+const maxSpendingOverride = 7 * 24 * time.Hour
+
+// Validate checks that duration parses and is within [0, 7d].
+func (r *SpendingOverrideReq) Validate() error {
+	if r.Duration == "" {
+		return dto.BadRequest("duration is required")
+	}
+	d, err := time.ParseDuration(r.Duration)
+	if err != nil {
+		return dto.BadRequest("invalid duration: " + r.Duration)
+	}
+	if d < 0 || d > maxSpendingOverride {
+		return dto.BadRequest("duration must be between 0s and 168h")
+	}
+	return nil
+}
+
 // validateImages checks that each ImageData entry has a valid media type and non-empty data.
 func validateImages(images []ImageData) error {
 	for _, img := range images {
@@ -148,3 +397,41 @@ func validateImages(images []ImageData) error {
 	}
 	return nil
 }
+
+// maxBulkIDs bounds the number of IDs accepted by bulk endpoints.
+const maxBulkIDs = 100
+
+// Validate checks the action and that between 1 and maxBulkIDs unique IDs are
+// provided.
+func (r *BulkTasksReq) Validate() error {
+	switch r.Action {
+	case BulkTerminate, BulkArchive, BulkRetry:
+	case "":
+		return dto.BadRequest("action is required")
+	default:
+		return dto.BadRequest("unknown action: " + string(r.Action))
+	}
+	return validateBulkIDs(r.IDs)
+}
+
+// Validate checks that between 1 and maxBulkIDs unique IDs are provided.
+func (r *StartDraftsReq) Validate() error {
+	return validateBulkIDs(r.IDs)
+}
+
+func validateBulkIDs(ids []ksid.ID) error {
+	if len(ids) == 0 {
+		return dto.BadRequest("ids is required")
+	}
+	if len(ids) > maxBulkIDs {
+		return dto.BadRequest("too many ids").WithDetail("max", maxBulkIDs)
+	}
+	seen := make(map[ksid.ID]struct{}, len(ids))
+	for _, id := range ids {
+		if _, dup := seen[id]; dup {
+			return dto.BadRequest("duplicate id: " + id.String())
+		}
+		seen[id] = struct{}{}
+	}
+	return nil
+}
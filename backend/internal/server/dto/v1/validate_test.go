@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	"github.com/maruel/ksid"
 )
 
 func TestValidate(t *testing.T) {
@@ -59,6 +60,34 @@ func TestValidate(t *testing.T) {
 		})
 	})
 
+	t.Run("AnnotateReq", func(t *testing.T) {
+		if err := (&AnnotateReq{MessageIndex: 3, Note: "here"}).Validate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertBadRequest(t, (&AnnotateReq{MessageIndex: -1}).Validate(), "messageIndex must not be negative")
+		assertBadRequest(t, (&AnnotateReq{Note: strings.Repeat("x", maxAnnotationNote+1)}).Validate(), "note is too long")
+	})
+
+	t.Run("LabelTaskReq", func(t *testing.T) {
+		t.Run("Valid", func(t *testing.T) {
+			for _, r := range []LabelTaskReq{{}, {Outcome: OutcomeAccepted}, {Outcome: OutcomePartial, Reason: "kept the tests"}} {
+				if err := r.Validate(); err != nil {
+					t.Errorf("%+v: unexpected error: %v", r, err)
+				}
+			}
+		})
+		t.Run("UnknownOutcome", func(t *testing.T) {
+			assertBadRequest(t, (&LabelTaskReq{Outcome: "merged"}).Validate(), "unknown outcome: merged")
+		})
+		t.Run("ReasonWithoutOutcome", func(t *testing.T) {
+			assertBadRequest(t, (&LabelTaskReq{Reason: "x"}).Validate(), "reason requires an outcome")
+		})
+		t.Run("ReasonTooLong", func(t *testing.T) {
+			r := &LabelTaskReq{Outcome: OutcomeRejected, Reason: strings.Repeat("x", maxLabelReason+1)}
+			assertBadRequest(t, r.Validate(), "reason is too long")
+		})
+	})
+
 	t.Run("SyncReq", func(t *testing.T) {
 		t.Run("Empty", func(t *testing.T) {
 			if err := (SyncReq{}).Validate(); err != nil {
@@ -100,6 +129,18 @@ func TestValidate(t *testing.T) {
 			r := &CloneRepoReq{URL: "https://example.com/repo.git", Depth: -1}
 			assertBadRequest(t, r.Validate(), "depth must be non-negative")
 		})
+		t.Run("Valid_Filter", func(t *testing.T) {
+			for _, f := range []string{"blob:none", "tree:0", "blob:limit=1m"} {
+				r := &CloneRepoReq{URL: "https://example.com/repo.git", Filter: f}
+				if err := r.Validate(); err != nil {
+					t.Errorf("filter %q: unexpected error: %v", f, err)
+				}
+			}
+		})
+		t.Run("InvalidFilter", func(t *testing.T) {
+			r := &CloneRepoReq{URL: "https://example.com/repo.git", Filter: "sparse:oid=HEAD"}
+			assertBadRequest(t, r.Validate(), "filter must be blob:none, tree:0 or blob:limit=<size>")
+		})
 		t.Run("PathWithDotDot", func(t *testing.T) {
 			r := &CloneRepoReq{URL: "https://example.com/repo.git", Path: "foo/../bar"}
 			assertBadRequest(t, r.Validate(), "path must be clean (use filepath.Clean form)")
@@ -167,11 +208,86 @@ func TestValidate(t *testing.T) {
 			}
 			assertBadRequest(t, r.Validate(), "repos contains duplicate name: org/repo")
 		})
+		t.Run("Paths", func(t *testing.T) {
+			r := valid
+			r.Repos = []RepoSpec{{Name: "org/repo", Paths: []string{"services/api", ".github"}}}
+			if err := r.Validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, p := range []string{"", "/abs", "a/../b", "../up", "a//b", "a/", "a b"} {
+				r.Repos = []RepoSpec{{Name: "org/repo", Paths: []string{p}}}
+				assertBadRequest(t, r.Validate(), "repos[org/repo].paths contains invalid path: "+p)
+			}
+		})
 		t.Run("MissingHarness", func(t *testing.T) {
 			r := valid
 			r.Harness = ""
 			assertBadRequest(t, r.Validate(), "harness is required")
 		})
+		t.Run("Image", func(t *testing.T) {
+			r := valid
+			for _, img := range []string{"ghcr.io/org/go:1.26", "busybox", "img@sha256:0123abcd"} {
+				r.Image = img
+				if err := r.Validate(); err != nil {
+					t.Errorf("%q: unexpected error: %v", img, err)
+				}
+			}
+			for _, img := range []string{"--privileged", "a b", "img;rm"} {
+				r.Image = img
+				assertBadRequest(t, r.Validate(), "invalid image")
+			}
+		})
+		t.Run("Context", func(t *testing.T) {
+			r := valid
+			r.Context = &PromptContext{Tasks: []ksid.ID{ksid.NewID()}, URLs: []string{"https://example.com/doc"}, Files: []string{"docs/design.md"}}
+			if err := r.Validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			r.Context = &PromptContext{URLs: []string{"file:///etc/passwd"}}
+			assertBadRequest(t, r.Validate(), "context.urls contains an invalid url: file:///etc/passwd")
+			r.Context = &PromptContext{Files: []string{"../secret"}}
+			assertBadRequest(t, r.Validate(), "context.files contains invalid path: ../secret")
+			r.Context = &PromptContext{Tasks: make([]ksid.ID, 6)}
+			assertBadRequest(t, r.Validate(), "context.tasks has too many entries")
+			r = CreateTaskReq{InitialPrompt: Prompt{Text: "do stuff"}, Harness: HarnessClaude, Context: &PromptContext{Files: []string{"a.md"}}}
+			assertBadRequest(t, r.Validate(), "context.files requires a repository")
+		})
+		t.Run("Kind", func(t *testing.T) {
+			r := CreateTaskReq{Repos: []RepoSpec{{Name: "org/repo"}}, Harness: HarnessClaude, Kind: TaskKindExplain}
+			if err := r.Validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			r.Repos = nil
+			assertBadRequest(t, r.Validate(), "explain tasks need a repository")
+			r = valid
+			r.Kind = "review"
+			assertBadRequest(t, r.Validate(), "unknown kind: review")
+			r.Kind = TaskKindCode
+			r.InitialPrompt = Prompt{}
+			assertBadRequest(t, r.Validate(), "prompt or images required")
+		})
+		t.Run("AskPolicy", func(t *testing.T) {
+			r := valid
+			r.AskPolicy = &AskPolicy{Timeout: 600, Action: AskActionAnswer, Answer: "Pick the simplest option."}
+			if err := r.Validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			r.AskPolicy = &AskPolicy{Timeout: 10, Action: AskActionPause}
+			assertBadRequest(t, r.Validate(), "askPolicy.timeout is too short")
+			r.AskPolicy = &AskPolicy{Timeout: 600, Action: AskActionNotify, Answer: "yes"}
+			assertBadRequest(t, r.Validate(), "askPolicy.answer requires the answer action")
+			r.AskPolicy = &AskPolicy{Timeout: 600, Action: "stop"}
+			assertBadRequest(t, r.Validate(), "unknown askPolicy.action: stop")
+		})
+		t.Run("Automation", func(t *testing.T) {
+			r := valid
+			r.Automation = AutomationUnattended
+			if err := r.Validate(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			r.Automation = "night"
+			assertBadRequest(t, r.Validate(), "unknown automation: night")
+		})
 	})
 }
 
@@ -0,0 +1,27 @@
+// Conversions from the v1 types the server builds to their v2 form.
+package v2
+
+import (
+	"encoding/base64"
+	"strings"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// TaskFromV1 returns the v2 form of t, whose initial prompt was p.
+func TaskFromV1(t *v1.Task, p *v1.Prompt) Task {
+	return Task{Task: *t, InitialPrompt: PromptFromV1(p)}
+}
+
+// PromptFromV1 returns the v2 form of p.
+func PromptFromV1(p *v1.Prompt) Prompt {
+	out := Prompt{Text: p.Text}
+	if len(p.Images) > 0 {
+		out.Images = make([]ImageMeta, len(p.Images))
+		for i, img := range p.Images {
+			pad := len(img.Data) - len(strings.TrimRight(img.Data, "="))
+			out.Images[i] = ImageMeta{MediaType: img.MediaType, Size: base64.StdEncoding.DecodedLen(len(img.Data)) - pad}
+		}
+	}
+	return out
+}
@@ -0,0 +1,14 @@
+// API route declarations of the endpoints whose v2 form differs from v1.
+package v2
+
+import (
+	"reflect"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// Routes lists the endpoints with a v2 form. Any other /api/v2 path is served
+// by its v1 handler.
+var Routes = []v1.Route{
+	{Name: "listTasks", Method: "GET", Path: "/api/v2/tasks", Resp: reflect.TypeFor[Task](), IsArray: true},
+}
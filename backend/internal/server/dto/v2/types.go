@@ -0,0 +1,30 @@
+// Package v2 declares the API types that changed incompatibly since v1.
+//
+// Only the changed types live here. Every endpoint without a v2 form is
+// served unchanged under /api/v2 with its v1 type, so a client moves to v2 as
+// a whole while the server only implements the differences. Generated clients
+// stay pinned to v1 until v2 is complete.
+package v2
+
+import (
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// Task is v1.Task with a structured initial prompt.
+type Task struct {
+	v1.Task
+	InitialPrompt Prompt `json:"initialPrompt"`
+}
+
+// Prompt is a task's initial prompt. Images are described but their data is
+// not included, to keep task lists small.
+type Prompt struct {
+	Text   string      `json:"text"`
+	Images []ImageMeta `json:"images,omitempty"`
+}
+
+// ImageMeta describes an image attached to a prompt.
+type ImageMeta struct {
+	MediaType string `json:"mediaType"` // e.g. "image/png"
+	Size      int    `json:"size"`      // Decoded size in bytes.
+}
@@ -0,0 +1,76 @@
+// Container environment reports.
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/container"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// envProbeTimeout bounds the environment report collection.
+const envProbeTimeout = 30 * time.Second
+
+// captureEnv records the environment of t's freshly provisioned container.
+// Failures are logged; the report is then collected on first request.
+func (s *Server) captureEnv(ctx context.Context, t *task.Task) *task.EnvReport {
+	ctx, cancel := context.WithTimeout(ctx, envProbeTimeout)
+	defer cancel()
+	r, err := task.ProbeEnv(ctx, t.Container)
+	if err != nil {
+		slog.Warn("env probe failed", "task", t.ID, "ctr", t.Container, "err", err)
+		return nil
+	}
+	if r.Image, r.ImageID, err = container.Image(ctx, t.Container); err != nil {
+		slog.Warn("env probe failed", "task", t.ID, "ctr", t.Container, "err", err)
+	}
+	t.SetEnvReport(r)
+	return r
+}
+
+// checkImage verifies that a task's container image can be used before the
+// task is accepted.
+func (s *Server) checkImage(ctx context.Context, ref string) error {
+	// Without registry checks, an image missing locally can't be told apart
+	// from one the runtime would pull.
+	if s.imageAvailable == nil || s.runtimeCaps.Check(container.FeatureRegistryCheck) != nil {
+		return nil
+	}
+	return s.imageAvailable(ctx, ref)
+}
+
+// handleGetTaskEnv returns the toolchain versions, image and selected
+// environment variables of the task's container. Tasks adopted after a
+// restart are probed on first request.
+func (s *Server) handleGetTaskEnv(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t := entry.task
+	report := t.EnvReport()
+	if report == nil {
+		if t.Container == "" || !diskProbeable(t.GetState()) {
+			writeError(w, dto.NotFound("environment report"))
+			return
+		}
+		if report = s.captureEnv(r.Context(), t); report == nil {
+			writeError(w, dto.InternalError("environment probe failed"))
+			return
+		}
+	}
+	writeJSONResponse(w, &v1.TaskEnvResp{
+		At:       float64(report.At.UnixMilli()) / 1e3,
+		Image:    report.Image,
+		ImageID:  report.ImageID,
+		Platform: report.Platform,
+		Tools:    report.Tools,
+		Env:      report.Env,
+	}, nil)
+}
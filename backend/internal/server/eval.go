@@ -0,0 +1,422 @@
+// A/B evaluation runs: a suite of prompts run against two harness/model arms
+// from the same commit, with a comparison report.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+// evalVerifyTimeout bounds the verification command of an evaluation task.
+const evalVerifyTimeout = 30 * time.Minute
+
+// maxVerifyOutput is the number of trailing bytes of the verification
+// output kept in a result.
+const maxVerifyOutput = 4096
+
+// evalRun is an evaluation as persisted. Results are updated as tasks finish.
+type evalRun struct {
+	ID        ksid.ID       `json:"id"`
+	OwnerID   string        `json:"ownerId,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	Repo      string        `json:"repo"`
+	BaseSHA   string        `json:"baseSHA"`
+	Verify    string        `json:"verify,omitempty"`
+	Arms      []v1.EvalArm  `json:"arms"`
+	Cases     []v1.EvalCase `json:"cases"`
+	CreatedAt time.Time     `json:"createdAt"`
+}
+
+// clone returns a copy that shares no result with r.
+func (r *evalRun) clone() evalRun {
+	c := *r
+	c.Cases = make([]v1.EvalCase, len(r.Cases))
+	for i, ec := range r.Cases {
+		c.Cases[i] = v1.EvalCase{Prompt: ec.Prompt, Results: slices.Clone(ec.Results)}
+	}
+	return c
+}
+
+// evalStore persists all evaluations in a single JSON file, like draftStore.
+// An empty path keeps them in memory only.
+type evalStore struct {
+	path string
+
+	mu   sync.Mutex
+	runs map[ksid.ID]*evalRun
+}
+
+// openEvalStore loads the evaluations stored at path, if any.
+func openEvalStore(path string) (*evalStore, error) {
+	e := &evalStore{path: path, runs: map[ksid.ID]*evalRun{}}
+	if path == "" {
+		return e, nil
+	}
+	data, err := readSealed(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []*evalRun
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, r := range list {
+		e.runs[r.ID] = r
+	}
+	return e, nil
+}
+
+// saveLocked atomically writes all evaluations. Must be called with e.mu
+// held.
+func (e *evalStore) saveLocked() error {
+	if e.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(e.listLocked(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeSealedAtomic(e.path, data)
+}
+
+func (e *evalStore) listLocked() []*evalRun {
+	list := make([]*evalRun, 0, len(e.runs))
+	for _, r := range e.runs {
+		list = append(list, r)
+	}
+	slices.SortFunc(list, func(a, b *evalRun) int { return strings.Compare(a.ID.String(), b.ID.String()) })
+	return list
+}
+
+// list returns copies of the evaluations visible to ownerID, oldest first.
+func (e *evalStore) list(ownerID string) []evalRun {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var out []evalRun
+	for _, r := range e.listLocked() {
+		if ownerID == "" || r.OwnerID == "" || r.OwnerID == ownerID {
+			out = append(out, r.clone())
+		}
+	}
+	return out
+}
+
+// get returns a copy of the evaluation id if visible to ownerID.
+func (e *evalStore) get(id ksid.ID, ownerID string) (evalRun, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	r, ok := e.runs[id]
+	if !ok {
+		return evalRun{}, dto.NotFound("eval")
+	}
+	if ownerID != "" && r.OwnerID != "" && r.OwnerID != ownerID {
+		return evalRun{}, dto.Forbidden("eval")
+	}
+	return r.clone(), nil
+}
+
+// put adds or replaces an evaluation.
+func (e *evalStore) put(r *evalRun) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.runs[r.ID] = r
+	return e.saveLocked()
+}
+
+// setResult records the result of case ci on arm ai.
+func (e *evalStore) setResult(id ksid.ID, ci, ai int, res *v1.EvalResult) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	r, ok := e.runs[id]
+	if !ok || ci >= len(r.Cases) || ai >= len(r.Cases[ci].Results) {
+		return errors.New("eval result not found")
+	}
+	r.Cases[ci].Results[ai] = *res
+	return e.saveLocked()
+}
+
+// parseEvalSuite splits a suite file into prompts separated by lines
+// containing only "---". Blank prompts are skipped.
+func parseEvalSuite(data []byte) []string {
+	var prompts []string
+	var cur []string
+	flush := func() {
+		if p := strings.TrimSpace(strings.Join(cur, "\n")); p != "" {
+			prompts = append(prompts, p)
+		}
+		cur = cur[:0]
+	}
+	for line := range strings.SplitSeq(string(bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))), "\n") {
+		if strings.TrimSpace(line) == "---" {
+			flush()
+			continue
+		}
+		cur = append(cur, line)
+	}
+	flush()
+	return prompts
+}
+
+// createEval resolves the base commit once, then creates one task per prompt
+// and arm, all starting from that commit. A task that cannot be created is
+// recorded as a failed result instead of aborting the whole evaluation.
+func (s *Server) createEval(ctx context.Context, req *v1.CreateEvalReq) (*v1.EvalRun, error) {
+	r, ok := s.runners[req.Repo]
+	if !ok || req.Repo == "" {
+		return nil, dto.BadRequest("unknown repo: " + req.Repo)
+	}
+	for _, a := range req.Arms {
+		b, ok := r.Backends[toAgentHarness(a.Harness)]
+		if !ok {
+			return nil, dto.BadRequest("unknown harness: " + string(a.Harness))
+		}
+		if a.Model != "" && !slices.Contains(b.Models(), a.Model) {
+			return nil, dto.BadRequest("unsupported model for " + string(a.Harness) + ": " + a.Model)
+		}
+	}
+	sha, err := r.ResolveBase(ctx, req.BaseBranch)
+	if err != nil {
+		return nil, dto.InternalError("resolve base commit").Wrap(err)
+	}
+	var prompts []string
+	if req.SuitePath != "" {
+		data, err := r.ShowFile(ctx, sha, req.SuitePath)
+		if err != nil {
+			return nil, dto.BadRequest("suite not found").WithDetail("suitePath", req.SuitePath)
+		}
+		prompts = parseEvalSuite(data)
+	}
+	prompts = append(prompts, req.Prompts...)
+	if len(prompts) == 0 {
+		return nil, dto.BadRequest("suite has no prompt")
+	}
+	if len(prompts) > v1.MaxEvalPrompts {
+		return nil, dto.BadRequest("too many prompts").WithDetail("max", v1.MaxEvalPrompts)
+	}
+
+	run := &evalRun{
+		ID:        ksid.NewID(),
+		OwnerID:   s.draftOwner(ctx),
+		Name:      req.Name,
+		Repo:      req.Repo,
+		BaseSHA:   sha,
+		Verify:    req.Verify,
+		Arms:      req.Arms,
+		Cases:     make([]v1.EvalCase, len(prompts)),
+		CreatedAt: time.Now().UTC(),
+	}
+	var started []*taskEntry
+	for ci, p := range prompts {
+		run.Cases[ci] = v1.EvalCase{Prompt: p, Results: make([]v1.EvalResult, len(req.Arms))}
+		for ai, a := range req.Arms {
+			res := &run.Cases[ci].Results[ai]
+			cr := &v1.CreateTaskReq{
+				InitialPrompt: v1.Prompt{Text: p},
+				Repos:         []v1.RepoSpec{{Name: req.Repo, BaseBranch: req.BaseBranch}},
+				Harness:       a.Harness,
+				Model:         a.Model,
+			}
+			resp, err := s.newTask(ctx, cr, &replaySource{baseSHA: sha})
+			if err != nil {
+				res.Error = err.Error()
+				res.Finished = true
+				started = append(started, nil)
+				continue
+			}
+			res.TaskID = resp.ID
+			s.mu.Lock()
+			started = append(started, s.tasks[resp.ID.String()])
+			s.mu.Unlock()
+		}
+	}
+	if err := s.evals.put(run); err != nil {
+		return nil, dto.InternalError("save eval").Wrap(err)
+	}
+	// Watchers update the stored results, so report from a copy.
+	cp := run.clone()
+	for i, entry := range started {
+		if entry != nil {
+			go s.watchEvalTask(run.ID, run.Verify, i/len(req.Arms), i%len(req.Arms), entry)
+		}
+	}
+	resp := s.toV1Eval(&cp, true)
+	return &resp, nil
+}
+
+// watchEvalTask waits until the agent of an evaluation task completes its
+// first turn or the task ends, runs the verification command, and records
+// the result.
+func (s *Server) watchEvalTask(id ksid.ID, verify string, ci, ai int, entry *taskEntry) {
+	t := entry.task
+	ctx, cancel := context.WithCancel(s.ctx)
+	go func() {
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+	finished := s.waitForAgentResult(ctx, t)
+	cancel()
+	if s.ctx.Err() != nil {
+		return
+	}
+	res := evalTaskResult(t)
+	res.Finished = true
+	if st := t.GetState(); finished && verify != "" && (st == task.StateWaiting || st == task.StateAsking) {
+		gitRoot := ""
+		if p := t.Primary(); p != nil {
+			gitRoot = p.GitRoot
+		}
+		cmd := verify
+		if a := t.AffectedTargets(); a != nil {
+			cmd = a.Export() + verify
+		}
+		vctx, vcancel := context.WithTimeout(s.ctx, evalVerifyTimeout)
+		out, err := task.RunInCheckout(vctx, t.Container, gitRoot, cmd)
+		vcancel()
+		res.Verify = "pass"
+		if err != nil {
+			res.Verify = "fail"
+		}
+		s.labelFromVerify(t, err == nil, verify)
+		if len(out) > maxVerifyOutput {
+			out = out[len(out)-maxVerifyOutput:]
+		}
+		res.VerifyOutput = out
+	}
+	if err := s.evals.setResult(id, ci, ai, &res); err != nil {
+		slog.Warn("eval result", "eval", id, "task", t.ID, "err", err)
+	}
+}
+
+// evalTaskResult returns the current signals of an evaluation task.
+func evalTaskResult(t *task.Task) v1.EvalResult {
+	snap := t.Snapshot()
+	res := v1.EvalResult{
+		TaskID:   t.ID,
+		State:    snap.State.String(),
+		Files:    len(snap.DiffStat),
+		CostUSD:  snap.CostUSD,
+		Duration: snap.Duration.Seconds(),
+	}
+	for _, f := range snap.DiffStat {
+		res.Added += f.Added
+		res.Deleted += f.Deleted
+	}
+	return res
+}
+
+// toV1Eval converts an evaluation. Unfinished results are refreshed from
+// their task, so the report shows progress and survives a server restart
+// that lost the watcher.
+func (s *Server) toV1Eval(r *evalRun, withCases bool) v1.EvalRun {
+	cases := make([]v1.EvalCase, len(r.Cases))
+	for i, ec := range r.Cases {
+		cases[i] = v1.EvalCase{Prompt: ec.Prompt, Results: slices.Clone(ec.Results)}
+		for j := range cases[i].Results {
+			res := &cases[i].Results[j]
+			if res.Finished || res.TaskID.IsZero() {
+				continue
+			}
+			s.mu.Lock()
+			entry := s.tasks[res.TaskID.String()]
+			s.mu.Unlock()
+			if entry != nil {
+				*res = evalTaskResult(entry.task)
+			}
+		}
+	}
+	out := v1.EvalRun{
+		ID:        r.ID,
+		Name:      r.Name,
+		Repo:      r.Repo,
+		BaseSHA:   r.BaseSHA,
+		Verify:    r.Verify,
+		CreatedAt: float64(r.CreatedAt.UnixMilli()) / 1e3,
+		Arms:      r.Arms,
+		Summary:   summarizeEval(r.Arms, cases),
+	}
+	if withCases {
+		out.Cases = cases
+	}
+	return out
+}
+
+// summarizeEval aggregates the results of each arm.
+func summarizeEval(arms []v1.EvalArm, cases []v1.EvalCase) []v1.EvalArmSummary {
+	out := make([]v1.EvalArmSummary, len(arms))
+	for i, a := range arms {
+		sum := &out[i]
+		sum.Harness = a.Harness
+		sum.Model = a.Model
+		for _, ec := range cases {
+			if i >= len(ec.Results) {
+				continue
+			}
+			res := &ec.Results[i]
+			if !res.TaskID.IsZero() {
+				sum.Tasks++
+			}
+			if res.Finished {
+				sum.Finished++
+			}
+			if res.Error != "" || res.State == task.StateFailed.String() {
+				sum.Failed++
+			}
+			if res.Verify == "pass" {
+				sum.VerifyPassed++
+			}
+			sum.Files += res.Files
+			sum.Added += res.Added
+			sum.Deleted += res.Deleted
+			sum.CostUSD += res.CostUSD
+			sum.Duration += res.Duration
+		}
+	}
+	return out
+}
+
+// listEvals returns the evaluations with their summary but without per-case
+// results. Evaluations follow draft ownership.
+func (s *Server) listEvals(ctx context.Context, _ *dto.EmptyReq) (*[]v1.EvalRun, error) {
+	runs := s.evals.list(s.draftOwner(ctx))
+	out := make([]v1.EvalRun, len(runs))
+	for i := range runs {
+		out[i] = s.toV1Eval(&runs[i], false)
+	}
+	return &out, nil
+}
+
+// getEval returns the comparison report of an evaluation.
+func (s *Server) getEval(w http.ResponseWriter, r *http.Request) {
+	id, err := ksid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeError(w, dto.NotFound("eval"))
+		return
+	}
+	run, err := s.evals.get(id, s.draftOwner(r.Context()))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := s.toV1Eval(&run, true)
+	writeJSONResponse(w, &resp, nil)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestParseEvalSuite(t *testing.T) {
+	got := parseEvalSuite([]byte("fix the bug\r\nin foo\n---\n\n---\n  add tests  \n---"))
+	want := []string{"fix the bug\nin foo", "add tests"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestSummarizeEval(t *testing.T) {
+	arms := []v1.EvalArm{{Harness: v1.HarnessClaude, Model: "m1"}, {Harness: v1.HarnessCodex}}
+	id := ksid.NewID()
+	cases := []v1.EvalCase{
+		{Prompt: "a", Results: []v1.EvalResult{
+			{TaskID: id, Finished: true, State: "waiting", Files: 2, Added: 10, Deleted: 3, CostUSD: 0.5, Duration: 60, Verify: "pass"},
+			{TaskID: id, Finished: true, State: "failed", CostUSD: 0.25, Duration: 30},
+		}},
+		{Prompt: "b", Results: []v1.EvalResult{
+			{TaskID: id, State: "running", Files: 1, Added: 1, CostUSD: 0.1},
+			{Error: "spending cap reached", Finished: true},
+		}},
+	}
+	got := summarizeEval(arms, cases)
+	want := []v1.EvalArmSummary{
+		{Harness: v1.HarnessClaude, Model: "m1", Tasks: 2, Finished: 1, VerifyPassed: 1, Files: 3, Added: 11, Deleted: 3, CostUSD: 0.6, Duration: 60},
+		{Harness: v1.HarnessCodex, Tasks: 1, Finished: 2, Failed: 2, CostUSD: 0.25, Duration: 30},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestEvalStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evals.json")
+	e, err := openEvalStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := &evalRun{
+		ID:      ksid.NewID(),
+		OwnerID: "alice",
+		Repo:    "r",
+		Arms:    []v1.EvalArm{{Harness: v1.HarnessClaude}, {Harness: v1.HarnessCodex}},
+		Cases:   []v1.EvalCase{{Prompt: "a", Results: make([]v1.EvalResult, 2)}},
+	}
+	if err := e.put(run); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.setResult(run.ID, 0, 1, &v1.EvalResult{Finished: true, Verify: "fail"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.setResult(run.ID, 1, 0, &v1.EvalResult{}); err == nil {
+		t.Error("expected error for unknown case")
+	}
+	e, err = openEvalStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := e.get(run.ID, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := got.Cases[0].Results[1]; !res.Finished || res.Verify != "fail" {
+		t.Errorf("result = %+v", res)
+	}
+	if _, err := e.get(run.ID, "bob"); err == nil {
+		t.Error("expected bob to be denied")
+	}
+	if l := e.list("bob"); len(l) != 0 {
+		t.Errorf("list(bob) = %d runs", len(l))
+	}
+}
+
+func TestCreateEval(t *testing.T) {
+	s := newTestServer(t)
+	s.runners["r"] = &task.Runner{
+		BaseBranch: "main",
+		Dir:        t.TempDir(),
+		Backends:   map[agent.Harness]agent.Backend{agent.Claude: stubBackend{}},
+	}
+	for name, req := range map[string]*v1.CreateEvalReq{
+		"UnknownRepo":    {Repo: "nope", Prompts: []string{"a"}, Arms: []v1.EvalArm{{Harness: v1.HarnessClaude}, {Harness: v1.HarnessClaude}}},
+		"UnknownHarness": {Repo: "r", Prompts: []string{"a"}, Arms: []v1.EvalArm{{Harness: v1.HarnessClaude}, {Harness: v1.HarnessCodex}}},
+		"UnknownModel":   {Repo: "r", Prompts: []string{"a"}, Arms: []v1.EvalArm{{Harness: v1.HarnessClaude, Model: "m1"}, {Harness: v1.HarnessClaude, Model: "m9"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.createEval(t.Context(), req); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestToV1EvalRefreshesPending(t *testing.T) {
+	s := newTestServer(t)
+	tk := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "a"}}
+	setState(t, tk, task.StateRunning)
+	s.tasks[tk.ID.String()] = &taskEntry{task: tk, done: make(chan struct{})}
+	run := &evalRun{
+		ID:   ksid.NewID(),
+		Arms: []v1.EvalArm{{Harness: v1.HarnessClaude}, {Harness: v1.HarnessClaude, Model: "m2"}},
+		Cases: []v1.EvalCase{{Prompt: "a", Results: []v1.EvalResult{
+			{TaskID: tk.ID},
+			{Error: "boom", Finished: true},
+		}}},
+	}
+	got := s.toV1Eval(run, true)
+	if res := got.Cases[0].Results[0]; res.State != "running" || res.Finished {
+		t.Errorf("pending result = %+v", res)
+	}
+	if got.Summary[0].Tasks != 1 || got.Summary[1].Failed != 1 {
+		t.Errorf("summary = %+v", got.Summary)
+	}
+	if l := s.toV1Eval(run, false); l.Cases != nil {
+		t.Error("cases included when listing")
+	}
+}
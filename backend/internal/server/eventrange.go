@@ -0,0 +1,62 @@
+// Bounded slices of a task's event history, for a scrubber jumping to any
+// point of a long session without replaying it over SSE.
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// maxEventRange bounds the events returned by a single range request.
+const maxEventRange = 1000
+
+// handleTaskEventRange returns the events of the task's history from ?from=
+// up to, excluding, ?to=. Sequence numbers are the SSE ids the history replay
+// of handleTaskEvents assigns, so a client can switch between both.
+func (s *Server) handleTaskEventRange(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	q := r.URL.Query()
+	from, to := 0, -1
+	if v := q.Get("from"); v != "" {
+		if from, err = strconv.Atoi(v); err != nil || from < 0 {
+			writeError(w, dto.BadRequest("invalid from").WithDetail("from", v))
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if to, err = strconv.Atoi(v); err != nil || to < from {
+			writeError(w, dto.BadRequest("invalid to").WithDetail("to", v))
+			return
+		}
+	}
+	if to == -1 || to-from > maxEventRange {
+		to = from + maxEventRange
+	}
+
+	history, _, unsub := entry.task.Subscribe(r.Context())
+	unsub()
+	// Events depend on the ones before them, e.g. tool timings, so the
+	// history is converted from the start.
+	tracker := newToolTimingTracker(entry.task.Harness)
+	now := time.Now()
+	resp := v1.TaskEventRangeResp{Events: []v1.EventMessage{}}
+	for _, msg := range filterHistoryForReplay(history) {
+		for _, ev := range tracker.convertMessage(msg, now) {
+			if resp.Total >= from && resp.Total < to {
+				resp.Events = append(resp.Events, ev)
+			}
+			resp.Total++
+		}
+	}
+	resp.From = min(from, resp.Total)
+	resp.To = resp.From + len(resp.Events)
+	writeJSONResponse(w, &resp, nil)
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestHandleTaskEventRange(t *testing.T) {
+	s := newTestServer(t)
+	tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}}
+	var msgs []agent.Message
+	for i := range 5 {
+		msgs = append(msgs, &agent.TextMessage{Text: "m" + strconv.Itoa(i)})
+	}
+	tk.RestoreMessages(msgs)
+	s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+	get := func(t *testing.T, query string, wantCode int) v1.TaskEventRangeResp {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t1/events/range"+query, http.NoBody)
+		req.SetPathValue("id", "t1")
+		w := httptest.NewRecorder()
+		s.handleTaskEventRange(w, req)
+		if w.Code != wantCode {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+		var resp v1.TaskEventRangeResp
+		if wantCode == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp
+	}
+	texts := func(resp v1.TaskEventRangeResp) []string {
+		var out []string
+		for _, ev := range resp.Events {
+			if ev.Text != nil {
+				out = append(out, ev.Text.Text)
+			}
+		}
+		return out
+	}
+
+	t.Run("All", func(t *testing.T) {
+		resp := get(t, "", http.StatusOK)
+		if resp.From != 0 || resp.To != 5 || resp.Total != 5 || len(texts(resp)) != 5 {
+			t.Errorf("resp = %+v", resp)
+		}
+	})
+	t.Run("Slice", func(t *testing.T) {
+		resp := get(t, "?from=1&to=3", http.StatusOK)
+		if got := texts(resp); resp.From != 1 || resp.To != 3 || resp.Total != 5 || len(got) != 2 || got[0] != "m1" || got[1] != "m2" {
+			t.Errorf("resp = %+v, texts = %v", resp, got)
+		}
+	})
+	t.Run("PastEnd", func(t *testing.T) {
+		resp := get(t, "?from=9&to=12", http.StatusOK)
+		if resp.From != 5 || resp.To != 5 || resp.Total != 5 || len(resp.Events) != 0 {
+			t.Errorf("resp = %+v", resp)
+		}
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		get(t, "?from=-1", http.StatusBadRequest)
+		get(t, "?from=3&to=2", http.StatusBadRequest)
+		get(t, "?to=x", http.StatusBadRequest)
+	})
+}
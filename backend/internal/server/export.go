@@ -0,0 +1,274 @@
+// Self-contained static HTML rendering of a task for sharing outside caic.
+package server
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+//go:embed export.html
+var exportHTML string
+
+var exportTmpl = template.Must(template.New("export").Parse(exportHTML))
+
+const (
+	// maxExportBody bounds the text of a single message in the export.
+	maxExportBody = 16 << 10
+	// maxExportDiff bounds the diff embedded in the export.
+	maxExportDiff = 2 << 20
+	// exportDiffTimeout bounds computing the diff of an export.
+	exportDiffTimeout = 30 * time.Second
+)
+
+// exportMeta is a row of the summary table.
+type exportMeta struct {
+	Name, Value string
+}
+
+// exportEntry is a rendered conversation message.
+type exportEntry struct {
+	Kind      string // CSS class.
+	Label     string
+	Body      string
+	Collapsed bool
+}
+
+// exportDiffLine is a line of the diff with its CSS class.
+type exportDiffLine struct {
+	Class, Text string
+}
+
+// exportData is the template input.
+type exportData struct {
+	Title       string
+	Meta        []exportMeta
+	Prompt      string
+	Entries     []exportEntry
+	DiffStat    agent.DiffStat
+	Diff        []exportDiffLine
+	DiffNote    string
+	GeneratedAt string
+}
+
+// handleExportTask renders the task as a single HTML file without external
+// assets or scripts, suitable to attach to a PR or an incident report.
+func (s *Server) handleExportTask(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	unmask, err := s.unmask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	data := s.exportData(r.Context(), entry, unmask)
+	var buf bytes.Buffer
+	if err := exportTmpl.Execute(&buf, data); err != nil {
+		writeError(w, dto.InternalError("render export").Wrap(err))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="caic-`+entry.task.ID.String()+`.html"`)
+	// The file is meant to be opened offline; forbid anything it could load.
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// exportData gathers everything rendered by the export. The diff is best
+// effort: it comes from the container when it still runs, else from the host
+// repository. Its secrets are masked unless unmask is set.
+func (s *Server) exportData(ctx context.Context, entry *taskEntry, unmask bool) *exportData {
+	t := entry.task
+	j := s.toJSON(entry)
+	d := &exportData{
+		Title:       j.Title,
+		Prompt:      t.InitialPrompt.Text,
+		DiffStat:    t.Snapshot().DiffStat,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	if entry.result != nil && len(entry.result.DiffStat) > 0 {
+		d.DiffStat = entry.result.DiffStat
+	}
+	if d.Title == "" {
+		d.Title = task.LocalTitle(t.InitialPrompt.Text)
+	}
+	add := func(name, value string) {
+		if value != "" {
+			d.Meta = append(d.Meta, exportMeta{name, value})
+		}
+	}
+	if p := t.Primary(); p != nil {
+		add("Repository", p.Name)
+		add("Branch", p.Branch)
+		add("Base", strings.TrimSpace(p.BaseBranch+" "+p.BaseSHA))
+	}
+	add("Harness", string(t.Harness))
+	add("Model", j.Model)
+	add("State", j.State)
+	if !t.StartedAt.IsZero() {
+		add("Started", t.StartedAt.UTC().Format(time.RFC3339))
+	}
+	add("Cost", fmt.Sprintf("$%.2f", j.CostUSD))
+	add("Turns", strconv.Itoa(j.NumTurns))
+	add("Agent time", (time.Duration(j.Duration * float64(time.Second))).Round(time.Second).String())
+	if j.Error != "" {
+		add("Error", j.Error)
+	}
+	d.Entries = exportEntries(t.Messages())
+
+	diff, note := s.exportDiff(ctx, t)
+	d.DiffNote = note
+	if !unmask {
+		var n int
+		if diff, n = task.MaskSecrets(diff); n != 0 {
+			add("Masked secrets", strconv.Itoa(n))
+		}
+	}
+	if len(diff) > maxExportDiff {
+		diff = strings.ToValidUTF8(diff[:maxExportDiff], "")
+		d.DiffNote = "The diff is truncated."
+	}
+	for line := range strings.SplitSeq(strings.TrimSuffix(diff, "\n"), "\n") {
+		if diff == "" {
+			break
+		}
+		d.Diff = append(d.Diff, exportDiffLine{Class: diffLineClass(line), Text: line})
+	}
+	return d
+}
+
+// exportDiff returns the diff of the task's primary repo, or a note
+// explaining why it is missing.
+func (s *Server) exportDiff(ctx context.Context, t *task.Task) (diff, note string) {
+	p := t.Primary()
+	if p == nil {
+		return "", "No repository."
+	}
+	runner := s.runners[p.Name]
+	if runner == nil {
+		return "", "Unknown repository."
+	}
+	ctx, cancel := context.WithTimeout(ctx, exportDiffTimeout)
+	defer cancel()
+	var err error
+	// diskProbeable tells whether the container is up.
+	if t.Container != "" && diskProbeable(t.GetState()) {
+		diff, err = runner.DiffContent(ctx, p.Branch, "")
+	} else {
+		base := p.BaseSHA
+		if base == "" {
+			base = p.BaseBranch
+		}
+		diff, err = runner.HostDiff(ctx, p.Branch, t.Container, base)
+	}
+	if err != nil {
+		return "", "The diff is not available: " + err.Error()
+	}
+	if diff == "" {
+		return "", "No changes."
+	}
+	return diff, ""
+}
+
+func diffLineClass(line string) string {
+	switch {
+	case strings.HasPrefix(line, "diff --git "):
+		return "file"
+	case strings.HasPrefix(line, "@@"):
+		return "hunk"
+	case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		return "file"
+	case strings.HasPrefix(line, "+"):
+		return "add"
+	case strings.HasPrefix(line, "-"):
+		return "del"
+	default:
+		return ""
+	}
+}
+
+// exportEntries renders the messages worth reading; streaming fragments,
+// usage and bookkeeping messages are skipped.
+func exportEntries(msgs []agent.Message) []exportEntry {
+	var out []exportEntry
+	add := func(kind, label, body string, collapsed bool) {
+		if len(body) > maxExportBody {
+			body = strings.ToValidUTF8(body[:maxExportBody], "") + "\n…"
+		}
+		out = append(out, exportEntry{Kind: kind, Label: label, Body: body, Collapsed: collapsed})
+	}
+	for _, m := range msgs {
+		switch m := m.(type) {
+		case *agent.UserInputMessage:
+			body := m.Text
+			if n := len(m.Images); n > 0 {
+				body += fmt.Sprintf("\n[%d image(s)]", n)
+			}
+			add("user", "User", body, false)
+		case *agent.TextMessage:
+			add("assistant", "Agent", m.Text, false)
+		case *agent.ThinkingMessage:
+			add("assistant thinking", "Thinking", m.Text, true)
+		case *agent.ToolUseMessage:
+			add("tool", "Tool: "+m.Name, prettyJSON(m.Input), true)
+		case *agent.ToolResultMessage:
+			if m.Error != "" {
+				add("tool_result error", "Tool error", m.Error, false)
+			} else if m.Output != "" {
+				add("tool_result", "Tool output", m.Output, true)
+			}
+		case *agent.AskMessage:
+			var b strings.Builder
+			for _, q := range m.Questions {
+				b.WriteString(q.Question)
+				for _, o := range q.Options {
+					b.WriteString("\n  - " + o.Label)
+				}
+				b.WriteString("\n")
+			}
+			add("assistant", "Question", strings.TrimSpace(b.String()), false)
+		case *agent.TodoMessage:
+			var b strings.Builder
+			for _, td := range m.Todos {
+				fmt.Fprintf(&b, "[%s] %s\n", td.Status, td.Content)
+			}
+			add("tool", "Todo", strings.TrimSpace(b.String()), true)
+		case *agent.ResultMessage:
+			label := fmt.Sprintf("Turn result · $%.2f · %s", m.TotalCostUSD, (time.Duration(m.DurationMs) * time.Millisecond).Round(time.Second))
+			kind := "result"
+			if m.IsError {
+				kind = "tool_result error"
+			}
+			add(kind, label, m.Result, false)
+		case *agent.SystemMessage:
+			if m.Detail != "" {
+				add("system", m.Subtype, m.Detail, false)
+			}
+		}
+	}
+	return out
+}
+
+// prettyJSON indents raw for display, or returns it as-is if it is not
+// valid JSON.
+func prettyJSON(raw json.RawMessage) string {
+	var b bytes.Buffer
+	if err := json.Indent(&b, raw, "", "  "); err != nil {
+		return string(raw)
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestHandleExportTask(t *testing.T) {
+	s := newTestServer(t)
+	tk := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "fix <the> bug"}, Harness: agent.Claude}
+	setState(t, tk, task.StateWaiting)
+	tk.RestoreMessages([]agent.Message{
+		&agent.UserInputMessage{Text: "fix <the> bug"},
+		&agent.TextMessage{Text: "Looking at <script>alert(1)</script>"},
+		&agent.ToolUseMessage{ToolUseID: "t1", Name: "Bash", Input: []byte(`{"command":"go test ./..."}`)},
+		&agent.ToolResultMessage{ToolUseID: "t1", Error: "exit status 1"},
+		&agent.ResultMessage{Result: "Done", TotalCostUSD: 0.42, DurationMs: 61000},
+	})
+	s.tasks[tk.ID.String()] = &taskEntry{task: tk, done: make(chan struct{})}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/"+tk.ID.String()+"/export.html", http.NoBody)
+	req.SetPathValue("id", tk.ID.String())
+	w := httptest.NewRecorder()
+	s.handleExportTask(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"fix &lt;the&gt; bug",
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		"Tool: Bash",
+		"&#34;command&#34;: &#34;go test ./...&#34;",
+		"exit status 1",
+		"Turn result · $0.42 · 1m1s",
+		"No repository.",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("export lacks %q", want)
+		}
+	}
+	if strings.Contains(body, "<script") || strings.Contains(body, "src=") {
+		t.Error("export is not self-contained")
+	}
+}
+
+func TestDiffLineClass(t *testing.T) {
+	for line, want := range map[string]string{
+		"diff --git a/x b/x": "file",
+		"--- a/x":            "file",
+		"+++ b/x":            "file",
+		"@@ -1 +1 @@":        "hunk",
+		"+added":             "add",
+		"-removed":           "del",
+		" context":           "",
+	} {
+		if got := diffLineClass(line); got != want {
+			t.Errorf("diffLineClass(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
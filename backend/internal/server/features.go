@@ -0,0 +1,44 @@
+// Feature flags gating experimental subsystems, persisted in settings.json.
+package server
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// updateFeatures replaces the feature flags. They are written back to
+// settings.json so they survive a restart, and connected clients are notified
+// through the config event stream.
+func (s *Server) updateFeatures(ctx context.Context, req *v1.FeatureFlags) (*v1.FeatureFlags, error) {
+	user := ""
+	if s.authStore != nil {
+		u, ok := auth.UserFromContext(ctx)
+		if !ok || !slices.Contains(s.admins, u.Username) {
+			return nil, dto.Forbidden("feature flags")
+		}
+		user = u.Username
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.settingsPath != "" {
+		// Reload to keep hand edits made since startup.
+		settings, err := loadSettings(s.settingsPath)
+		if err != nil {
+			return nil, dto.InternalError("load settings: " + err.Error())
+		}
+		settings.Features = *req
+		if err := writeSettingsAtomic(s.settingsPath, settings); err != nil {
+			return nil, dto.InternalError("save settings: " + err.Error())
+		}
+	}
+	slog.Info("features", "msg", "updated", "old", s.features, "new", *req, "user", user)
+	s.features = *req
+	s.configChangedLocked()
+	out := s.features
+	return &out, nil
+}
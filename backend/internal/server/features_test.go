@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/md"
+)
+
+func TestUpdateFeatures(t *testing.T) {
+	t.Run("Persist", func(t *testing.T) {
+		s := newTestServer(t)
+		s.mdClient = &md.Client{}
+		s.settingsPath = filepath.Join(t.TempDir(), "settings.json")
+		if err := writeSettingsAtomic(s.settingsPath, &serverSettings{SessionSecret: "abcd", GPUs: 2}); err != nil {
+			t.Fatal(err)
+		}
+		ch := s.configChanged
+		want := v1.FeatureFlags{PlanMode: true, FanOut: true}
+		got, err := s.updateFeatures(t.Context(), &want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *got != want {
+			t.Errorf("got %+v, want %+v", *got, want)
+		}
+		select {
+		case <-ch:
+		default:
+			t.Error("config event streams not notified")
+		}
+		cfg, err := s.getConfig(t.Context(), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Features != want {
+			t.Errorf("config features = %+v", cfg.Features)
+		}
+		settings, err := loadSettings(s.settingsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if settings.Features != want || settings.SessionSecret != "abcd" || settings.GPUs != 2 {
+			t.Errorf("settings = %+v", settings)
+		}
+	})
+	t.Run("Admins", func(t *testing.T) {
+		s := newTestServer(t)
+		store, err := auth.Open(filepath.Join(t.TempDir(), "users.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.authStore = store
+		s.admins = []string{"root"}
+		req := &v1.FeatureFlags{ReviewerStage: true}
+		ctx := auth.NewContext(t.Context(), &auth.User{Username: "alice"})
+		_, err = s.updateFeatures(ctx, req)
+		if apiErr, ok := err.(*dto.APIError); !ok || apiErr.StatusCode() != http.StatusForbidden {
+			t.Fatalf("err = %v, want forbidden", err)
+		}
+		ctx = auth.NewContext(t.Context(), &auth.User{Username: "root"})
+		if _, err := s.updateFeatures(ctx, req); err != nil {
+			t.Fatal(err)
+		}
+		if !s.features.ReviewerStage {
+			t.Error("flag not set")
+		}
+	})
+}
@@ -5,6 +5,7 @@ package server
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
@@ -17,20 +18,69 @@ import (
 // GET /api/v1/tasks/{id}/tool/{toolUseID}.
 const inputTruncateThreshold = 4096
 
+// asyncTools are the tools (lowercased) that may still run after the agent
+// moved on, so their completion is never inferred.
+var asyncTools = map[string]bool{"bash": true, "task": true, "show_widget": true}
+
 // toolTimingTracker computes per-tool-call duration by recording the timestamp
 // when each tool_use is seen and computing the delta when the corresponding
 // ToolResultMessage arrives.
+//
+// Harnesses don't always report the result of synchronous tools. The tracker
+// infers it instead: when the agent produces text, thinking or its turn
+// result while a synchronous tool call is pending, the call completed and a
+// tool result is emitted for it first. A late result for such a call is
+// dropped so each call is paired with exactly one result.
 type toolTimingTracker struct {
 	harness agent.Harness
 	pending map[string]time.Time
+	sync    []string        // Pending synchronous tool calls, in call order.
+	settled map[string]bool // Tool calls whose result was inferred.
 }
 
 func newToolTimingTracker(harness agent.Harness) *toolTimingTracker {
-	return &toolTimingTracker{harness: harness, pending: make(map[string]time.Time)}
+	return &toolTimingTracker{harness: harness, pending: make(map[string]time.Time), settled: make(map[string]bool)}
 }
 
-// convertMessage converts an agent.Message into zero or more EventMessages.
+// convertMessage converts an agent.Message into zero or more EventMessages,
+// preceded by the inferred tool results.
 func (tt *toolTimingTracker) convertMessage(msg agent.Message, now time.Time) []v1.EventMessage {
+	var inferred []v1.EventMessage
+	switch m := msg.(type) {
+	case *agent.TextMessage, *agent.TextDeltaMessage, *agent.ThinkingMessage, *agent.ThinkingDeltaMessage, *agent.ResultMessage:
+		inferred = tt.inferResults(now)
+	case *agent.ToolResultMessage:
+		if tt.settled[m.ToolUseID] {
+			delete(tt.settled, m.ToolUseID)
+			return nil
+		}
+	}
+	return append(inferred, tt.convert(msg, now)...)
+}
+
+// inferResults returns a tool result for each pending synchronous tool call.
+func (tt *toolTimingTracker) inferResults(now time.Time) []v1.EventMessage {
+	var out []v1.EventMessage
+	for _, id := range tt.sync {
+		started, ok := tt.pending[id]
+		if !ok {
+			// The harness reported the result.
+			continue
+		}
+		delete(tt.pending, id)
+		tt.settled[id] = true
+		out = append(out, v1.EventMessage{
+			Kind:       v1.EventKindToolResult,
+			Ts:         now.UnixMilli(),
+			ToolResult: &v1.EventToolResult{ToolUseID: id, Duration: now.Sub(started).Seconds(), Inferred: true},
+		})
+	}
+	tt.sync = tt.sync[:0]
+	return out
+}
+
+// convert converts an agent.Message into zero or more EventMessages.
+func (tt *toolTimingTracker) convert(msg agent.Message, now time.Time) []v1.EventMessage {
 	ts := now.UnixMilli()
 	switch m := msg.(type) {
 	case *agent.InitMessage:
@@ -64,6 +114,9 @@ func (tt *toolTimingTracker) convertMessage(msg agent.Message, now time.Time) []
 		return nil
 	case *agent.ToolUseMessage:
 		tt.pending[m.ToolUseID] = now
+		if !asyncTools[strings.ToLower(m.Name)] {
+			tt.sync = append(tt.sync, m.ToolUseID)
+		}
 		input := m.Input
 		truncated := false
 		if len(input) > inputTruncateThreshold {
@@ -93,6 +146,7 @@ func (tt *toolTimingTracker) convertMessage(msg agent.Message, now time.Time) []
 		}}
 	case *agent.TodoMessage:
 		tt.pending[m.ToolUseID] = now
+		tt.sync = append(tt.sync, m.ToolUseID)
 		if todos := toV1TodoItems(m.Todos); len(todos) > 0 {
 			return []v1.EventMessage{{
 				Kind: v1.EventKindTodo,
@@ -213,7 +267,13 @@ func (tt *toolTimingTracker) convertMessage(msg agent.Message, now time.Time) []
 		return []v1.EventMessage{{
 			Kind:     v1.EventKindDiffStat,
 			Ts:       ts,
-			DiffStat: &v1.EventDiffStat{DiffStat: toV1DiffStat(m.DiffStat)},
+			DiffStat: &v1.EventDiffStat{DiffStat: toV1DiffStat(m.DiffStat), ToolUseID: m.ToolUseID, HeadSHA: m.HeadSHA},
+		}}
+	case *agent.WarningMessage:
+		return []v1.EventMessage{{
+			Kind:    v1.EventKindWarning,
+			Ts:      ts,
+			Warning: &v1.EventWarning{Kind: v1.WarningKind(m.Kind), Detail: m.Detail},
 		}}
 	case *agent.ParseErrorMessage:
 		return []v1.EventMessage{{
@@ -221,6 +281,18 @@ func (tt *toolTimingTracker) convertMessage(msg agent.Message, now time.Time) []
 			Ts:    ts,
 			Error: &v1.EventError{Err: m.Err, Line: m.Line},
 		}}
+	case *agent.StderrMessage:
+		return []v1.EventMessage{{
+			Kind:   v1.EventKindStderr,
+			Ts:     ts,
+			Stderr: &v1.EventStderr{Source: m.Source, Line: m.Line},
+		}}
+	case *agent.StateMessage:
+		return []v1.EventMessage{{
+			Kind:        v1.EventKindStateChange,
+			Ts:          m.At.UnixMilli(),
+			StateChange: &v1.EventStateChange{From: m.From, To: m.To},
+		}}
 	case *agent.LogMessage:
 		return []v1.EventMessage{{
 			Kind: v1.EventKindLog,
@@ -300,6 +372,17 @@ func toV1TodoItems(items []agent.TodoItem) []v1.TodoItem {
 	return out
 }
 
+func toV1Warnings(ws []task.Warning) []v1.EventWarning {
+	if len(ws) == 0 {
+		return nil
+	}
+	out := make([]v1.EventWarning, len(ws))
+	for i, w := range ws {
+		out[i] = v1.EventWarning{Kind: v1.WarningKind(w.Kind), Detail: w.Detail}
+	}
+	return out
+}
+
 // marshalEvent is a convenience wrapper for json.Marshal on EventMessage.
 func marshalEvent(ev *v1.EventMessage) ([]byte, error) {
 	return json.Marshal(ev)
@@ -317,6 +400,18 @@ func v1PromptToAgent(p v1.Prompt) agent.Prompt {
 	return agent.Prompt{Text: p.Text, Images: images}
 }
 
+// agentPromptToV1 converts agent.Prompt to v1.Prompt at the server boundary.
+func agentPromptToV1(p agent.Prompt) v1.Prompt {
+	var images []v1.ImageData
+	if len(p.Images) > 0 {
+		images = make([]v1.ImageData, len(p.Images))
+		for i, img := range p.Images {
+			images[i] = v1.ImageData{MediaType: img.MediaType, Data: img.Data}
+		}
+	}
+	return v1.Prompt{Text: p.Text, Images: images}
+}
+
 // toV1Harness converts agent.Harness to v1.Harness at the server boundary.
 func toV1Harness(h agent.Harness) v1.Harness {
 	return v1.Harness(h)
@@ -327,6 +422,56 @@ func toAgentHarness(h v1.Harness) agent.Harness {
 	return agent.Harness(h)
 }
 
+// toV1Priority converts task.Priority to v1.Priority at the server boundary.
+// Normal priority maps to "" so it is omitted.
+func toV1Priority(p task.Priority) v1.Priority {
+	if p == task.PriorityNormal {
+		return ""
+	}
+	return v1.Priority(p.String())
+}
+
+// toV1TaskKind converts task.Kind to v1.TaskKind at the server boundary.
+// Coding tasks map to "" so it is omitted.
+func toV1TaskKind(k task.Kind) v1.TaskKind {
+	if k == task.KindCode {
+		return ""
+	}
+	return v1.TaskKind(k)
+}
+
+// toTaskKind converts a validated v1.TaskKind to task.Kind at the server
+// boundary.
+func toTaskKind(k v1.TaskKind) task.Kind {
+	kind, _ := task.ParseKind(string(k))
+	return kind
+}
+
+// toTaskPriority converts a validated v1.Priority to task.Priority at the
+// server boundary.
+func toTaskPriority(p v1.Priority) task.Priority {
+	prio, _ := task.ParsePriority(string(p))
+	return prio
+}
+
+// toV1AskPolicy converts *task.AskPolicy to *v1.AskPolicy at the server
+// boundary.
+func toV1AskPolicy(p *task.AskPolicy) *v1.AskPolicy {
+	if p == nil {
+		return nil
+	}
+	return &v1.AskPolicy{Timeout: p.Timeout.Seconds(), Action: v1.AskAction(p.Action), Answer: p.Answer}
+}
+
+// toTaskAskPolicy converts a validated *v1.AskPolicy to *task.AskPolicy at
+// the server boundary.
+func toTaskAskPolicy(p *v1.AskPolicy) *task.AskPolicy {
+	if p == nil {
+		return nil
+	}
+	return &task.AskPolicy{Timeout: time.Duration(p.Timeout * float64(time.Second)), Action: task.AskAction(p.Action), Answer: p.Answer}
+}
+
 // toV1SafetyIssues converts []task.SafetyIssue to []v1.SafetyIssue at the
 // server boundary.
 func toV1SafetyIssues(issues []task.SafetyIssue) []v1.SafetyIssue {
@@ -391,7 +536,10 @@ func toV1DiffStat(ds agent.DiffStat) v1.DiffStat {
 	}
 	out := make(v1.DiffStat, len(ds))
 	for i, f := range ds {
-		out[i] = v1.DiffFileStat{Path: f.Path, Added: f.Added, Deleted: f.Deleted, Binary: f.Binary}
+		out[i] = v1.DiffFileStat{Path: f.Path, Added: f.Added, Deleted: f.Deleted, Binary: f.Binary, LFS: f.LFS}
+		if f.Submodule != (agent.SubmoduleBump{}) {
+			out[i].Submodule = &v1.SubmoduleBump{From: f.Submodule.From, To: f.Submodule.To}
+		}
 	}
 	return out
 }
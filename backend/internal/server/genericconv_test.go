@@ -129,6 +129,36 @@ func TestGenericToolTiming(t *testing.T) {
 	}
 }
 
+func TestGenericInferToolResult(t *testing.T) {
+	gt := newToolTimingTracker(agent.Claude)
+	t0 := time.Now()
+	t1 := t0.Add(250 * time.Millisecond)
+	gt.convertMessage(&agent.ToolUseMessage{ToolUseID: "read", Name: "Read"}, t0)
+	gt.convertMessage(&agent.ToolUseMessage{ToolUseID: "bash", Name: "Bash"}, t0)
+	gt.convertMessage(&agent.ToolUseMessage{ToolUseID: "grep", Name: "Grep"}, t0)
+	if events := gt.convertMessage(&agent.ToolResultMessage{ToolUseID: "grep"}, t0); len(events) != 1 {
+		t.Fatalf("got %+v", events)
+	}
+
+	events := gt.convertMessage(&agent.TextMessage{Text: "done"}, t1)
+	if len(events) != 2 || events[1].Kind != v1.EventKindText {
+		t.Fatalf("got %+v", events)
+	}
+	if r := events[0].ToolResult; r == nil || r.ToolUseID != "read" || !r.Inferred || r.Duration != 0.25 {
+		t.Errorf("inferred = %+v", r)
+	}
+	// The late result of an inferred call is dropped; async tools report theirs.
+	if events := gt.convertMessage(&agent.ToolResultMessage{ToolUseID: "read"}, t1); len(events) != 0 {
+		t.Errorf("late result: got %+v", events)
+	}
+	if events := gt.convertMessage(&agent.ToolResultMessage{ToolUseID: "bash"}, t1); len(events) != 1 || events[0].ToolResult.Inferred {
+		t.Errorf("async result: got %+v", events)
+	}
+	if events := gt.convertMessage(&agent.ResultMessage{}, t1); len(events) != 1 {
+		t.Errorf("nothing pending: got %+v", events)
+	}
+}
+
 func TestGenericConvertTextAndUsage(t *testing.T) {
 	gt := newToolTimingTracker(agent.Gemini)
 
@@ -475,3 +505,89 @@ func TestFilterHistoryForReplay(t *testing.T) {
 		}
 	})
 }
+
+func TestGenericConvertErrors(t *testing.T) {
+	gt := newToolTimingTracker(agent.Claude)
+	now := time.Now()
+	t.Run("Parse", func(t *testing.T) {
+		events := gt.convertMessage(&agent.ParseErrorMessage{Err: "bad", Line: "{"}, now)
+		if len(events) != 1 || events[0].Kind != v1.EventKindError {
+			t.Fatalf("got %+v", events)
+		}
+		if e := events[0].Error; e.Err != "bad" || e.Line != "{" {
+			t.Errorf("error = %+v", e)
+		}
+	})
+	t.Run("Stderr", func(t *testing.T) {
+		events := gt.convertMessage(&agent.StderrMessage{Source: "relay attach", Line: "Traceback (most recent call last):"}, now)
+		if len(events) != 1 || events[0].Kind != v1.EventKindStderr {
+			t.Fatalf("got %+v", events)
+		}
+		if e := events[0].Stderr; e.Source != "relay attach" || e.Line != "Traceback (most recent call last):" {
+			t.Errorf("stderr = %+v", e)
+		}
+	})
+}
+
+func TestGenericConvertStateChange(t *testing.T) {
+	gt := newToolTimingTracker(agent.Claude)
+	at := time.Now().Add(-time.Second)
+	events := gt.convertMessage(&agent.StateMessage{From: "running", To: "waiting", At: at}, time.Now())
+	if len(events) != 1 || events[0].Kind != v1.EventKindStateChange || events[0].Ts != at.UnixMilli() {
+		t.Fatalf("got %+v", events)
+	}
+	if sc := events[0].StateChange; sc.From != "running" || sc.To != "waiting" {
+		t.Errorf("stateChange = %+v", sc)
+	}
+}
+
+// TestGenericConvertCompleteness checks that the converter and the status
+// tracker emit every registered event kind, each with its matching payload.
+func TestGenericConvertCompleteness(t *testing.T) {
+	gt := newToolTimingTracker(agent.Claude)
+	msgs := []agent.Message{
+		&agent.InitMessage{SessionID: "s"},
+		&agent.SystemMessage{Subtype: "status"},
+		&agent.TextMessage{Text: "hi"},
+		&agent.TextDeltaMessage{Text: "h"},
+		&agent.ThinkingMessage{Text: "hmm"},
+		&agent.ThinkingDeltaMessage{Text: "h"},
+		&agent.ToolUseMessage{ToolUseID: "t1", Name: "Bash"},
+		&agent.ToolOutputDeltaMessage{ToolUseID: "t1", Delta: "out"},
+		&agent.ToolResultMessage{ToolUseID: "t1"},
+		&agent.AskMessage{ToolUseID: "t2"},
+		&agent.TodoMessage{ToolUseID: "t3", Todos: []agent.TodoItem{{Content: "x", Status: "pending"}}},
+		&agent.UserInputMessage{Text: "go"},
+		&agent.UsageMessage{},
+		&agent.ResultMessage{Subtype: "success"},
+		&agent.DiffStatMessage{},
+		&agent.WarningMessage{Kind: "noChanges", Detail: "d"},
+		&agent.ParseErrorMessage{Err: "bad"},
+		&agent.StderrMessage{Source: "relay attach", Line: "oops"},
+		&agent.StateMessage{From: "running", To: "waiting"},
+		&agent.SubagentStartMessage{TaskID: "a"},
+		&agent.SubagentEndMessage{TaskID: "a"},
+		&agent.LogMessage{Line: "pulling"},
+		&agent.WidgetDeltaMessage{ToolUseID: "w1", Delta: "<p"},
+		&agent.WidgetMessage{ToolUseID: "w1", HTML: "<p>"},
+	}
+	var st statusTracker
+	seen := map[v1.EventKind]bool{}
+	for _, msg := range msgs {
+		events := gt.convertMessage(msg, time.Now())
+		if st.update(msg) {
+			events = append(events, st.event(0))
+		}
+		for _, ev := range events {
+			if k, _, ok := ev.Payload(); !ok || k != ev.Kind {
+				t.Errorf("%T: kind %q, payload %q, %t", msg, ev.Kind, k, ok)
+			}
+			seen[ev.Kind] = true
+		}
+	}
+	for _, k := range v1.EventKinds {
+		if !seen[k.Kind] {
+			t.Errorf("kind %q not emitted", k.Kind)
+		}
+	}
+}
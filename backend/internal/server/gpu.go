@@ -0,0 +1,203 @@
+// GPU detection and scheduling of GPU tasks.
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os/exec"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// detectGPUs returns the number of NVIDIA GPUs reported by nvidia-smi, or 0
+// when the tool is missing or fails.
+func detectGPUs(ctx context.Context) int {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "nvidia-smi", "-L").Output()
+	if err != nil {
+		return 0
+	}
+	n := bytes.Count(out, []byte("GPU "))
+	if n > 0 {
+		slog.Info("gpu", "count", n)
+	}
+	return n
+}
+
+// gpuBusy reports whether entry occupies a GPU slot: it acquired one and its
+// container may still be running.
+func gpuBusy(e *taskEntry) bool {
+	if !e.gpuHeld || e.result != nil {
+		return false
+	}
+	switch e.task.GetState() {
+	case task.StateStopped, task.StateFailed, task.StatePurged:
+		return false
+	default:
+		return true
+	}
+}
+
+// gpuOutranks reports whether a, queued for a GPU, must be served before b.
+// Higher priority goes first, then the longest queued. A task that is not
+// queued (e.g. being revived) yields to queued tasks of equal priority.
+func gpuOutranks(a, b *taskEntry) bool {
+	if a.task.Priority != b.task.Priority {
+		return a.task.Priority > b.task.Priority
+	}
+	return b.gpuQueuedAt.IsZero() || a.gpuQueuedAt.Before(b.gpuQueuedAt)
+}
+
+// tryAcquireGPULocked reserves a GPU slot for entry if one is free and no
+// queued task outranks it. s.mu must be held.
+func (s *Server) tryAcquireGPULocked(entry *taskEntry) bool {
+	used := 0
+	for _, e := range s.tasks {
+		if e == entry {
+			continue
+		}
+		if gpuBusy(e) {
+			used++
+		} else if !e.gpuQueuedAt.IsZero() && gpuOutranks(e, entry) {
+			return false
+		}
+	}
+	if used >= s.gpus {
+		return false
+	}
+	entry.gpuHeld = true
+	entry.gpuQueuedAt = time.Time{}
+	return true
+}
+
+// tryAcquireGPU reserves a GPU slot for entry if one is free.
+func (s *Server) tryAcquireGPU(entry *taskEntry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tryAcquireGPULocked(entry)
+}
+
+// acquireGPU blocks until a GPU slot is free for entry or ctx is done. Slots
+// are released implicitly when the holding task stops, fails or is purged.
+// Queued tasks are served by priority, then in order. When preemption is
+// enabled, a lower priority task holding a slot is paused to make room.
+func (s *Server) acquireGPU(ctx context.Context, entry *taskEntry) error {
+	logged := false
+	s.mu.Lock()
+	entry.gpuQueuedAt = time.Now()
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		entry.gpuQueuedAt = time.Time{}
+		s.mu.Unlock()
+	}()
+	for {
+		s.mu.Lock()
+		ok := s.tryAcquireGPULocked(entry)
+		var victim *taskEntry
+		if !ok && s.preempt {
+			victim = s.gpuVictimLocked(entry)
+		}
+		ch := s.changed
+		s.mu.Unlock()
+		if ok {
+			return nil
+		}
+		if victim != nil {
+			s.preemptTask(ctx, victim, entry.task, "free a GPU")
+		}
+		if !logged {
+			slog.Info("gpu", "msg", "waiting for a free GPU", "task", entry.task.ID, "prio", entry.task.Priority, "gpus", s.gpus)
+			logged = true
+		}
+		// Not every state transition signals s.changed, so re-check
+		// periodically as well.
+		select {
+		case <-ch:
+		case <-time.After(30 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// gpuVictimLocked returns the task to preempt so entry can get a GPU, see
+// victimLocked. s.mu must be held.
+func (s *Server) gpuVictimLocked(entry *taskEntry) *taskEntry {
+	return s.victimLocked(entry.task.Priority, func(e *taskEntry) bool { return e != entry && gpuBusy(e) })
+}
+
+// victimLocked returns the task to preempt, among those match accepts, for a
+// task of priority prio: the most recently started of the lowest priority
+// running or paused tasks, if its priority is lower than prio. s.mu must be
+// held.
+func (s *Server) victimLocked(prio task.Priority, match func(*taskEntry) bool) *taskEntry {
+	var victim *taskEntry
+	for _, e := range s.tasks {
+		if e.preempted || !match(e) {
+			continue
+		}
+		switch e.task.GetState() {
+		case task.StateRunning, task.StateWaiting, task.StateAsking, task.StateHasPlan, task.StatePaused:
+		default:
+			continue
+		}
+		if e.task.Priority >= prio {
+			continue
+		}
+		if victim == nil || e.task.Priority < victim.task.Priority ||
+			(e.task.Priority == victim.task.Priority && e.task.StartedAt.After(victim.task.StartedAt)) {
+			victim = e
+		}
+	}
+	return victim
+}
+
+// reclaimSlots takes back the repo task slots and GPU a preempted task gave
+// up, before it is resumed. It is a no-op for other tasks.
+func (s *Server) reclaimSlots(entry *taskEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !entry.preempted {
+		return nil
+	}
+	repos := make([]string, len(entry.task.Repos))
+	for i, m := range entry.task.Repos {
+		repos[i] = m.Name
+	}
+	if err := s.checkRepoTasksLocked(repos); err != nil {
+		return err
+	}
+	if entry.task.GPU && !s.tryAcquireGPULocked(entry) {
+		return dto.Conflict("all GPUs are in use")
+	}
+	entry.preempted = false
+	return nil
+}
+
+// preemptTask pauses victim, keeping its container warm, and releases its GPU
+// and repo task slots until it is resumed. It records why in its event
+// stream.
+func (s *Server) preemptTask(ctx context.Context, victim *taskEntry, by *task.Task, reason string) {
+	detail := "paused to " + reason
+	if by != nil {
+		detail += " for higher priority task " + by.ID.String()
+	}
+	slog.Info("preempt", "task", victim.task.ID, "prio", victim.task.Priority, "reason", reason)
+	if victim.task.GetState() != task.StatePaused {
+		if _, err := s.pauseTask(ctx, victim, nil); err != nil {
+			slog.Warn("preempt", "task", victim.task.ID, "err", err)
+			return
+		}
+	}
+	victim.task.Notify(ctx, "preempted", detail)
+	s.mu.Lock()
+	victim.preempted = true
+	victim.gpuHeld = false
+	s.taskChanged()
+	s.mu.Unlock()
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestGPUScheduling(t *testing.T) {
+	newEntry := func(s *Server, id string) *taskEntry {
+		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "train"}, GPU: true}
+		e := &taskEntry{task: tk, done: make(chan struct{})}
+		s.tasks[id] = e
+		return e
+	}
+	t.Run("Exclusive", func(t *testing.T) {
+		s := newTestServer(t)
+		s.gpus = 1
+		a := newEntry(s, "a")
+		b := newEntry(s, "b")
+		if !s.tryAcquireGPU(a) {
+			t.Fatal("first task did not get a GPU")
+		}
+		setState(t, a.task, task.StateRunning)
+		if s.tryAcquireGPU(b) {
+			t.Fatal("second task got a GPU while the first is running")
+		}
+		setState(t, a.task, task.StateStopped)
+		if !s.tryAcquireGPU(b) {
+			t.Fatal("GPU not released by stopped task")
+		}
+	})
+	t.Run("Waits", func(t *testing.T) {
+		s := newTestServer(t)
+		s.gpus = 1
+		a := newEntry(s, "a")
+		b := newEntry(s, "b")
+		if !s.tryAcquireGPU(a) {
+			t.Fatal("first task did not get a GPU")
+		}
+		setState(t, a.task, task.StateRunning)
+		done := make(chan error, 1)
+		go func() { done <- s.acquireGPU(t.Context(), b) }()
+		select {
+		case err := <-done:
+			t.Fatalf("acquireGPU returned early: %v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		setState(t, a.task, task.StatePurged)
+		s.notifyTaskChange()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("acquireGPU did not return after release")
+		}
+	})
+	t.Run("Priority", func(t *testing.T) {
+		s := newTestServer(t)
+		s.gpus = 1
+		a := newEntry(s, "a")
+		low := newEntry(s, "low")
+		high := newEntry(s, "high")
+		low.task.Priority = task.PriorityLow
+		high.task.Priority = task.PriorityHigh
+		if !s.tryAcquireGPU(a) {
+			t.Fatal("first task did not get a GPU")
+		}
+		setState(t, a.task, task.StateRunning)
+		now := time.Now()
+		low.gpuQueuedAt = now
+		high.gpuQueuedAt = now.Add(time.Second)
+		setState(t, a.task, task.StateStopped)
+		if s.tryAcquireGPU(low) {
+			t.Fatal("low priority task got a GPU while a high priority one is queued")
+		}
+		if !s.tryAcquireGPU(high) {
+			t.Fatal("high priority task did not get the free GPU")
+		}
+	})
+	t.Run("Preempt", func(t *testing.T) {
+		s := newTestServer(t)
+		s.runners[""] = &task.Runner{}
+		s.gpus = 1
+		s.preempt = true
+		low := newEntry(s, "low")
+		low.task.Priority = task.PriorityLow
+		high := newEntry(s, "high")
+		high.task.Priority = task.PriorityHigh
+		if !s.tryAcquireGPU(low) {
+			t.Fatal("first task did not get a GPU")
+		}
+		setState(t, low.task, task.StateRunning)
+		done := make(chan error, 1)
+		go func() { done <- s.acquireGPU(t.Context(), high) }()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("acquireGPU did not preempt the low priority task")
+		}
+		if st := low.task.GetState(); st != task.StatePaused {
+			t.Errorf("low priority task state = %v, want paused", st)
+		}
+		found := false
+		for _, m := range low.task.Messages() {
+			if sm, ok := m.(*agent.SystemMessage); ok && sm.Subtype == "preempted" {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("no preempted event")
+		}
+	})
+	t.Run("NoPreemptSamePriority", func(t *testing.T) {
+		s := newTestServer(t)
+		s.gpus = 1
+		s.preempt = true
+		a := newEntry(s, "a")
+		b := newEntry(s, "b")
+		if !s.tryAcquireGPU(a) {
+			t.Fatal("first task did not get a GPU")
+		}
+		setState(t, a.task, task.StateRunning)
+		s.mu.Lock()
+		victim := s.gpuVictimLocked(b)
+		s.mu.Unlock()
+		if victim != nil {
+			t.Fatal("equal priority task must not be preempted")
+		}
+	})
+	t.Run("Cancelled", func(t *testing.T) {
+		s := newTestServer(t)
+		b := newEntry(s, "b")
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		if err := s.acquireGPU(ctx, b); err == nil {
+			t.Fatal("expected error with no GPUs and a cancelled context")
+		}
+	})
+}
@@ -67,8 +67,11 @@ func handleWithTask[In any, PtrIn interface {
 }
 
 // readAndDecodeBody reads the request body and decodes JSON into input. It
-// skips decoding for EmptyReq. Unknown JSON fields are rejected. Returns false
-// if an error was written to the response.
+// skips decoding for EmptyReq. Unknown JSON fields are rejected. For request
+// types declared in the Routes tables, the body is first checked against
+// their schema so that structural problems are reported field by field in
+// the "fields" error detail. Returns false if an error was written to the
+// response.
 func readAndDecodeBody[In any](w http.ResponseWriter, r *http.Request, input *In) bool {
 	if _, isEmpty := any(input).(*dto.EmptyReq); isEmpty {
 		return true
@@ -84,6 +87,12 @@ func readAndDecodeBody[In any](w http.ResponseWriter, r *http.Request, input *In
 	if len(body) == 0 {
 		return true
 	}
+	if sc := reqSchemas[reflect.TypeFor[In]()]; sc != nil {
+		if errs := checkSchema(sc, body); len(errs) > 0 {
+			writeError(w, dto.BadRequest("invalid request body").WithDetail("fields", errs))
+			return false
+		}
+	}
 	d := json.NewDecoder(bytes.NewReader(body))
 	d.DisallowUnknownFields()
 	if err := d.Decode(input); err != nil {
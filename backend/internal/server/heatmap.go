@@ -0,0 +1,90 @@
+// Per-repo file modification heatmap aggregated from the task history.
+package server
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+const (
+	defaultHeatmapLimit = 100
+	maxHeatmapLimit     = 1000
+)
+
+// handleGetRepoHeatmap reports which files of a repository agents modify
+// most often and how often those tasks failed.
+func (s *Server) handleGetRepoHeatmap(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		writeError(w, dto.BadRequest("repo is required"))
+		return
+	}
+	if _, ok := s.repoAbsPath(repo); !ok {
+		writeError(w, dto.NotFound("repo"))
+		return
+	}
+	limit := defaultHeatmapLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxHeatmapLimit {
+			writeError(w, dto.BadRequest("invalid limit").WithDetail("limit", v))
+			return
+		}
+		limit = n
+	}
+	resp := repoHeatmap(s.pastTasks(repo, s.draftOwner(r.Context())), repo, limit)
+	writeJSONResponse(w, &resp, nil)
+}
+
+// repoHeatmap aggregates the diff stats of the finished tasks of repo. Only
+// the primary repository of a task is considered since the diff stat covers
+// it alone. Files are sorted by the number of tasks that modified them, then
+// by lines changed.
+func repoHeatmap(past []pastTask, repo string, limit int) v1.RepoHeatmapResp {
+	resp := v1.RepoHeatmapResp{Repo: repo, Files: []v1.HeatmapFile{}}
+	files := map[string]*v1.HeatmapFile{}
+	for _, p := range past {
+		if p.repo != repo || len(p.result.DiffStat) == 0 {
+			continue
+		}
+		failed := p.result.State == task.StateFailed
+		resp.Tasks++
+		if failed {
+			resp.Failed++
+		}
+		for _, fs := range p.result.DiffStat {
+			f := files[fs.Path]
+			if f == nil {
+				f = &v1.HeatmapFile{Path: fs.Path}
+				files[fs.Path] = f
+			}
+			f.Tasks++
+			if failed {
+				f.Failed++
+			}
+			f.Added += fs.Added
+			f.Deleted += fs.Deleted
+		}
+	}
+	for _, f := range files {
+		f.FailureRate = float64(f.Failed) / float64(f.Tasks)
+		resp.Files = append(resp.Files, *f)
+	}
+	slices.SortFunc(resp.Files, func(a, b v1.HeatmapFile) int {
+		if c := cmp.Compare(b.Tasks, a.Tasks); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Added+b.Deleted, a.Added+a.Deleted); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Path, b.Path)
+	})
+	resp.Files = resp.Files[:min(limit, len(resp.Files))]
+	return resp
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestRepoHeatmap(t *testing.T) {
+	past := []pastTask{
+		heatmapTask("r", task.StateStopped, "a.go", "b.go"),
+		heatmapTask("r", task.StateFailed, "a.go"),
+		heatmapTask("r", task.StateFailed, "a.go", "c.go"),
+		heatmapTask("r", task.StateStopped),
+		heatmapTask("other", task.StateFailed, "a.go"),
+	}
+	resp := repoHeatmap(past, "r", 2)
+	if resp.Tasks != 3 || resp.Failed != 2 {
+		t.Fatalf("tasks=%d failed=%d, want 3 2", resp.Tasks, resp.Failed)
+	}
+	if len(resp.Files) != 2 {
+		t.Fatalf("files = %+v, want 2 entries", resp.Files)
+	}
+	a := resp.Files[0]
+	if a.Path != "a.go" || a.Tasks != 3 || a.Failed != 2 || a.Added != 6 || a.Deleted != 3 {
+		t.Errorf("files[0] = %+v", a)
+	}
+	if a.FailureRate < 0.66 || a.FailureRate > 0.67 {
+		t.Errorf("failure rate = %v", a.FailureRate)
+	}
+	// b.go and c.go tie on tasks and lines; sorted by path.
+	if b := resp.Files[1]; b.Path != "b.go" || b.Failed != 0 {
+		t.Errorf("files[1] = %+v", b)
+	}
+	if resp := repoHeatmap(nil, "r", 10); resp.Files == nil || len(resp.Files) != 0 {
+		t.Errorf("empty heatmap = %+v", resp)
+	}
+}
+
+func TestHandleGetRepoHeatmap(t *testing.T) {
+	s := newTestServer(t)
+	s.repos = []repoInfo{{RelPath: "r"}}
+	s.history = loadHistory([]*task.LoadedTask{
+		{TaskID: "t1", OwnerID: "a", Repos: []task.RepoMount{{Name: "r"}}, Result: &task.Result{State: task.StateStopped, DiffStat: agent.DiffStat{{Path: "a.go"}}}},
+		// Didn't finish.
+		{TaskID: "t2", OwnerID: "a", Repos: []task.RepoMount{{Name: "r"}}},
+	}, time.Now())
+	// Finished during this run.
+	tk := &task.Task{ID: ksid.NewID(), OwnerID: "b", Repos: []task.RepoMount{{Name: "r"}}}
+	s.mu.Lock()
+	s.recordHistoryLocked(tk, &task.Result{State: task.StateFailed, DiffStat: agent.DiffStat{{Path: "b.go"}}})
+	s.mu.Unlock()
+
+	get := func(t *testing.T, u *auth.User) v1.RepoHeatmapResp {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/server/repos/heatmap?repo=r", http.NoBody)
+		if u != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), u))
+		}
+		w := httptest.NewRecorder()
+		s.handleGetRepoHeatmap(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp v1.RepoHeatmapResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if resp := get(t, nil); resp.Tasks != 2 || resp.Failed != 1 {
+		t.Errorf("resp = %+v", resp)
+	}
+	t.Run("Owner", func(t *testing.T) {
+		store, err := auth.Open(filepath.Join(t.TempDir(), "users.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.authStore = store
+		resp := get(t, &auth.User{ID: "b"})
+		if resp.Tasks != 1 || len(resp.Files) != 1 || resp.Files[0].Path != "b.go" {
+			t.Errorf("resp = %+v", resp)
+		}
+	})
+}
+
+func heatmapTask(repo string, state task.State, paths ...string) pastTask {
+	var ds agent.DiffStat
+	for _, p := range paths {
+		ds = append(ds, agent.DiffFileStat{Path: p, Added: 2, Deleted: 1})
+	}
+	return pastTask{repo: repo, result: task.Result{State: state, DiffStat: ds}}
+}
@@ -17,6 +17,7 @@ import (
 	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
 	"github.com/caic-xyz/caic/backend/internal/task"
 	"github.com/caic-xyz/md/gitutil"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // relayStatus describes the state of the in-container relay daemon, probed
@@ -58,21 +59,42 @@ type responseWriter struct {
 	http.ResponseWriter
 	status int
 	size   int
+	// span is the request span until it ends, see endSpan.
+	span trace.Span
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.status = code
+	rw.startStream()
 	rw.ResponseWriter.WriteHeader(code)
 }
 
 func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.startStream()
 	n, err := rw.ResponseWriter.Write(b)
 	rw.size += n
 	return n, err
 }
 
+// startStream ends the request span as soon as the response turns out to be
+// an SSE stream, instead of when the client goes away hours later.
+func (rw *responseWriter) startStream() {
+	if rw.span != nil && rw.Header().Get("Content-Type") == "text/event-stream" {
+		rw.endSpan()
+	}
+}
+
+// endSpan ends the request span with the response status, once.
+func (rw *responseWriter) endSpan() {
+	if rw.span != nil {
+		endHTTPSpan(rw.span, rw.status)
+		rw.span = nil
+	}
+}
+
 // Flush implements http.Flusher so SSE handlers can flush through the wrapper.
 func (rw *responseWriter) Flush() {
+	rw.startStream()
 	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
 		f.Flush()
 	}
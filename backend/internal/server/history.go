@@ -0,0 +1,94 @@
+// In-memory history of finished tasks, for aggregates over the logs.
+package server
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// pastTask is what the server keeps of a task for the aggregates over its
+// history, whether or not the task is still listed in s.tasks.
+type pastTask struct {
+	ownerID string
+	repo    string // Primary repository; empty for no-repo tasks.
+	harness agent.Harness
+	result  task.Result // Zero if the task didn't finish.
+	// turns are the turns that ended within spendWindow, for the spending
+	// limits. Only set for tasks loaded from the logs at startup: the ones of
+	// this run stay in s.tasks.
+	turns []task.TurnUsage
+}
+
+// loadHistory builds the history from the logs scanned at startup. The
+// messages of the logs updated within spendWindow of now are loaded to
+// recover their turns; loadPurgedTasksFrom reuses them.
+func loadHistory(logs []*task.LoadedTask, now time.Time) map[string]pastTask {
+	h := make(map[string]pastTask, len(logs))
+	for _, lt := range logs {
+		if lt.TaskID == "" {
+			continue
+		}
+		p := pastTask{ownerID: lt.OwnerID, harness: lt.Harness}
+		if lt.Result != nil {
+			p.result = *lt.Result
+		}
+		if now.Sub(lt.LastStateUpdateAt) <= spendWindow {
+			p.turns = logTurns(lt, now)
+		}
+		if lt.Result == nil && len(p.turns) == 0 {
+			continue
+		}
+		if r := lt.Primary(); r != nil {
+			p.repo = r.Name
+		}
+		h[lt.TaskID] = p
+	}
+	return h
+}
+
+// logTurns returns the turns of lt that ended within spendWindow of now.
+func logTurns(lt *task.LoadedTask, now time.Time) []task.TurnUsage {
+	if err := lt.LoadMessages(); err != nil {
+		slog.Warn("load messages failed", "task", lt.TaskID, "err", err)
+		return nil
+	}
+	t := &task.Task{Harness: lt.Harness, StartedAt: lt.StartedAt}
+	t.RestoreMessages(lt.Msgs)
+	var out []task.TurnUsage
+	for _, tu := range t.TurnUsages() {
+		if tu.EndedAt.After(now.Add(-spendWindow)) {
+			out = append(out, tu)
+		}
+	}
+	return out
+}
+
+// recordHistoryLocked adds the result of a task that just finished. s.mu must
+// be held.
+func (s *Server) recordHistoryLocked(t *task.Task, result *task.Result) {
+	if s.history == nil {
+		s.history = map[string]pastTask{}
+	}
+	p := pastTask{ownerID: t.OwnerID, harness: t.Harness, result: *result}
+	if r := t.Primary(); r != nil {
+		p.repo = r.Name
+	}
+	s.history[t.ID.String()] = p
+}
+
+// pastTasks returns the history of repo visible to ownerID.
+func (s *Server) pastTasks(repo, ownerID string) []pastTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []pastTask
+	for _, p := range s.history {
+		if p.repo != repo || (ownerID != "" && p.ownerID != "" && p.ownerID != ownerID) {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
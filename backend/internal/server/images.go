@@ -0,0 +1,181 @@
+// Container image prefetching and local image cache status.
+package server
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/md"
+)
+
+// warmupInterval is the default period at which warmupImages re-checks for
+// new image versions. It also sets DigestCacheTTL so that container starts
+// between warmup cycles reuse the cached digest instead of hitting the
+// registry.
+const warmupInterval = 6 * time.Hour
+
+// prefetchConfig is the background image prefetch configuration.
+type prefetchConfig struct {
+	images   []string // extra images from settings.json
+	interval time.Duration
+}
+
+// imagePull is the outcome of the latest prefetch of an image.
+type imagePull struct {
+	pulledAt    time.Time // last successful pull
+	attemptedAt time.Time
+	duration    time.Duration // of the last attempt
+	err         string        // of the last attempt
+}
+
+// imagePulls records prefetch outcomes. The zero value is ready to use.
+type imagePulls struct {
+	mu    sync.Mutex
+	pulls map[string]imagePull
+}
+
+func (p *imagePulls) record(img string, start time.Time, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pulls == nil {
+		p.pulls = map[string]imagePull{}
+	}
+	ip := p.pulls[img]
+	ip.attemptedAt = start
+	ip.duration = time.Since(start)
+	ip.err = ""
+	if err != nil {
+		ip.err = err.Error()
+	} else {
+		ip.pulledAt = start
+	}
+	p.pulls[img] = ip
+}
+
+func (p *imagePulls) get(img string) imagePull {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.pulls[img]
+}
+
+// prefetchImages returns the images to keep pulled: the default base image,
+// the base images from user preferences, the per-repo images and the extra
+// images from settings.json.
+func (s *Server) prefetchImages() []string {
+	images := []string{md.DefaultBaseImage + ":latest"}
+	add := func(img string) {
+		if !slices.Contains(images, img) {
+			images = append(images, img)
+		}
+	}
+	if s.prefs != nil {
+		for _, img := range s.prefs.BaseImages() {
+			add(img)
+		}
+	}
+	repoImages := make([]string, 0, len(s.repoImages))
+	for _, img := range s.repoImages {
+		repoImages = append(repoImages, img)
+	}
+	slices.Sort(repoImages)
+	for _, img := range repoImages {
+		add(img)
+	}
+	for _, img := range s.prefetch.images {
+		add(img)
+	}
+	return images
+}
+
+// warmupImages periodically calls md.Client.Warmup for every image returned
+// by prefetchImages. This ensures the image is pulled and the md-user layer
+// is built before a task needs it, so the first task of the day doesn't
+// spend its start timeout pulling.
+func (s *Server) warmupImages() {
+	interval := s.prefetch.interval
+	if interval <= 0 {
+		interval = warmupInterval
+	}
+	// Run immediately on startup, then every interval.
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, img := range s.prefetchImages() {
+			start := time.Now()
+			built, err := s.mdClient.Warmup(s.ctx, &md.WarmupOpts{
+				BaseImage: img,
+				Quiet:     true,
+			})
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.pulls.record(img, start, err)
+			if err != nil {
+				slog.Warn("warmup", "image", img, "err", err)
+			} else if built {
+				slog.Info("warmup", "image", img, "built", true, "dur", time.Since(start).Round(time.Second))
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// listImages reports the prefetched images with their local size and last
+// pull, and the size of the whole local image store.
+func (s *Server) listImages(ctx context.Context, _ *dto.EmptyReq) (*v1.ImagesResp, error) {
+	interval := s.prefetch.interval
+	if interval <= 0 {
+		interval = warmupInterval
+	}
+	resp := &v1.ImagesResp{Interval: interval.Seconds(), Images: []v1.CachedImage{}}
+	sizes := map[string]int64{}
+	if s.localImages != nil {
+		local, err := s.localImages(ctx)
+		if err != nil {
+			return nil, dto.InternalError("listing local images").Wrap(err)
+		}
+		for _, li := range local {
+			resp.TotalBytes += li.Size
+			for _, tag := range li.Tags {
+				sizes[tag] = li.Size
+			}
+		}
+	}
+	for _, img := range s.prefetchImages() {
+		ci := v1.CachedImage{Ref: img}
+		ci.SizeBytes, ci.Present = sizes[normalizeImageTag(img)]
+		p := s.pulls.get(img)
+		if !p.pulledAt.IsZero() {
+			ci.LastPulledAt = float64(p.pulledAt.UnixMilli()) / 1e3
+		}
+		if !p.attemptedAt.IsZero() {
+			ci.LastAttemptAt = float64(p.attemptedAt.UnixMilli()) / 1e3
+			ci.LastDuration = p.duration.Seconds()
+		}
+		ci.LastError = p.err
+		resp.Images = append(resp.Images, ci)
+	}
+	return resp, nil
+}
+
+// normalizeImageTag returns ref the way docker lists it in RepoTags: an
+// untagged reference implies ":latest". Digest references are returned as-is.
+func normalizeImageTag(ref string) string {
+	if strings.Contains(ref, "@") {
+		return ref
+	}
+	if i := strings.LastIndexByte(ref, ':'); i > strings.LastIndexByte(ref, '/') {
+		return ref
+	}
+	return ref + ":latest"
+}
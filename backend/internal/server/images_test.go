@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/container"
+	"github.com/caic-xyz/md"
+)
+
+func TestListImages(t *testing.T) {
+	def := md.DefaultBaseImage + ":latest"
+	s := &Server{
+		repoImages: map[string]string{"org/a": "ghcr.io/org/a-env", "org/b": def},
+		prefetch:   prefetchConfig{images: []string{"ubuntu:24.04"}, interval: time.Hour},
+		localImages: func(context.Context) ([]container.LocalImage, error) {
+			return []container.LocalImage{
+				{ID: "sha256:1", Size: 100, Tags: []string{def}},
+				{ID: "sha256:2", Size: 30, Tags: []string{"ghcr.io/org/a-env:latest"}},
+				{ID: "sha256:3", Size: 5},
+			}, nil
+		},
+	}
+	start := time.Unix(1700000000, 0)
+	s.pulls.record(def, start, nil)
+	s.pulls.record("ubuntu:24.04", start, errors.New("manifest unknown"))
+
+	resp, err := s.listImages(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TotalBytes != 135 {
+		t.Errorf("TotalBytes = %d, want 135", resp.TotalBytes)
+	}
+	if resp.Interval != 3600 {
+		t.Errorf("Interval = %v, want 3600", resp.Interval)
+	}
+	want := []string{def, "ghcr.io/org/a-env", "ubuntu:24.04"}
+	if len(resp.Images) != len(want) {
+		t.Fatalf("images = %+v, want %v", resp.Images, want)
+	}
+	for i, ref := range want {
+		if resp.Images[i].Ref != ref {
+			t.Errorf("images[%d].Ref = %q, want %q", i, resp.Images[i].Ref, ref)
+		}
+	}
+	if img := resp.Images[0]; !img.Present || img.SizeBytes != 100 || img.LastPulledAt != 1700000000 || img.LastError != "" {
+		t.Errorf("default image = %+v", img)
+	}
+	if img := resp.Images[1]; !img.Present || img.SizeBytes != 30 || img.LastAttemptAt != 0 {
+		t.Errorf("repo image = %+v", img)
+	}
+	if img := resp.Images[2]; img.Present || img.LastPulledAt != 0 || img.LastAttemptAt != 1700000000 || img.LastError != "manifest unknown" {
+		t.Errorf("failed image = %+v", img)
+	}
+}
+
+func TestNormalizeImageTag(t *testing.T) {
+	for in, want := range map[string]string{
+		"ubuntu":                  "ubuntu:latest",
+		"ubuntu:24.04":            "ubuntu:24.04",
+		"localhost:5000/img":      "localhost:5000/img:latest",
+		"localhost:5000/img:v1":   "localhost:5000/img:v1",
+		"ghcr.io/a/b@sha256:abcd": "ghcr.io/a/b@sha256:abcd",
+	} {
+		if got := normalizeImageTag(in); got != want {
+			t.Errorf("normalizeImageTag(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Per-repo knowledge base: learnings extracted from finished tasks, capped in
+// size, editable through the API and injected into new tasks.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+const (
+	defaultKnowledgeMaxBytes = 8 << 10
+	// learningsTimeout bounds one extraction call.
+	learningsTimeout = 2 * time.Minute
+)
+
+// knowledgeStore holds one Markdown file per repo under dir. Each learning
+// is a "- " line; when a file outgrows maxBytes, the oldest learnings are
+// dropped first.
+type knowledgeStore struct {
+	dir      string
+	maxBytes int
+	extract  bool // Extract learnings from finished tasks.
+
+	mu sync.Mutex // Serializes read-modify-write cycles.
+}
+
+func (k *knowledgeStore) path(repo string) string {
+	return filepath.Join(k.dir, url.PathEscape(repo)+".md")
+}
+
+// load returns the knowledge of repo, or "" if there is none.
+func (k *knowledgeStore) load(repo string) (string, error) {
+	data, err := readSealed(k.path(repo))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return string(data), err
+}
+
+// save replaces the knowledge of repo. An empty content deletes the file.
+func (k *knowledgeStore) save(repo, content string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.saveLocked(repo, content)
+}
+
+func (k *knowledgeStore) saveLocked(repo, content string) error {
+	if strings.TrimSpace(content) == "" {
+		if err := os.Remove(k.path(repo)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	return writeSealedAtomic(k.path(repo), []byte(content))
+}
+
+// add appends the learnings not already present and trims the file to
+// maxBytes. It returns the number of learnings added.
+func (k *knowledgeStore) add(repo string, learnings []string) (int, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	content, err := k.load(repo)
+	if err != nil {
+		return 0, err
+	}
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+	seen := make(map[string]bool, len(lines))
+	for _, l := range lines {
+		seen[strings.ToLower(strings.TrimSpace(l))] = true
+	}
+	n := 0
+	for _, l := range learnings {
+		l = "- " + l
+		if key := strings.ToLower(l); !seen[key] {
+			seen[key] = true
+			lines = append(lines, l)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	return n, k.saveLocked(repo, capKnowledge(lines, k.maxBytes))
+}
+
+// capKnowledge joins lines, dropping the oldest learnings until the result
+// fits in maxBytes. Non-learning lines (headings, prose added by hand) are
+// kept.
+func capKnowledge(lines []string, maxBytes int) string {
+	size := 0
+	for _, l := range lines {
+		size += len(l) + 1
+	}
+	out := lines[:0:0]
+	for _, l := range lines {
+		if size > maxBytes && strings.HasPrefix(l, "- ") {
+			size -= len(l) + 1
+			continue
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// taskKnowledge returns the knowledge of the task's primary repo, logging
+// failures.
+func (s *Server) taskKnowledge(repos []task.RepoMount) string {
+	if s.knowledge == nil || len(repos) == 0 {
+		return ""
+	}
+	content, err := s.knowledge.load(repos[0].Name)
+	if err != nil {
+		slog.Warn("knowledge", "repo", repos[0].Name, "err", err)
+	}
+	return content
+}
+
+// learnFromTask extracts learnings from a finished task and appends them to
+// its primary repo's knowledge. It is a no-op unless extraction is enabled
+// and an LLM provider is configured.
+func (s *Server) learnFromTask(t *task.Task) {
+	if s.knowledge == nil || !s.knowledge.extract || s.provider == nil {
+		return
+	}
+	p := t.Primary()
+	if p == nil {
+		return
+	}
+	known, err := s.knowledge.load(p.Name)
+	if err != nil {
+		slog.Warn("knowledge", "repo", p.Name, "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, learningsTimeout)
+	defer cancel()
+	learnings, err := task.ExtractLearnings(ctx, s.provider, t, known)
+	if err != nil {
+		slog.Warn("knowledge", "task", t.ID, "repo", p.Name, "err", err)
+		return
+	}
+	n, err := s.knowledge.add(p.Name, learnings)
+	if err != nil {
+		slog.Warn("knowledge", "repo", p.Name, "err", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("knowledge", "task", t.ID, "repo", p.Name, "added", n)
+	}
+}
+
+// handleGetRepoKnowledge returns a repo's knowledge for review.
+func (s *Server) handleGetRepoKnowledge(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		writeError(w, dto.BadRequest("repo is required"))
+		return
+	}
+	if _, ok := s.repoAbsPath(repo); !ok {
+		writeError(w, dto.NotFound("repo"))
+		return
+	}
+	content, err := s.knowledge.load(repo)
+	if err != nil {
+		writeError(w, dto.InternalError(err.Error()))
+		return
+	}
+	writeJSONResponse(w, &v1.RepoKnowledgeResp{Repo: repo, Content: content, MaxBytes: s.knowledge.maxBytes}, nil)
+}
+
+// updateRepoKnowledge replaces a repo's knowledge with the edited content.
+func (s *Server) updateRepoKnowledge(_ context.Context, req *v1.UpdateRepoKnowledgeReq) (*v1.RepoKnowledgeResp, error) {
+	if _, ok := s.repoAbsPath(req.Repo); !ok {
+		return nil, dto.NotFound("repo")
+	}
+	if len(req.Content) > s.knowledge.maxBytes {
+		return nil, dto.BadRequest("content too large").WithDetail("maxBytes", s.knowledge.maxBytes)
+	}
+	if err := s.knowledge.save(req.Repo, req.Content); err != nil {
+		return nil, dto.InternalError(err.Error())
+	}
+	return &v1.RepoKnowledgeResp{Repo: req.Repo, Content: req.Content, MaxBytes: s.knowledge.maxBytes}, nil
+}
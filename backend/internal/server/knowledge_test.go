@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestKnowledgeStore(t *testing.T) {
+	k := &knowledgeStore{dir: t.TempDir(), maxBytes: 50}
+	if err := k.save("github/caic", "# Notes\n- Run make lint\n"); err != nil {
+		t.Fatal(err)
+	}
+	n, err := k.add("github/caic", []string{"run make lint", "Tests need docker", "Use gofmt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("added = %d, want 2", n)
+	}
+	got, err := k.load("github/caic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The oldest learning is dropped to fit; the heading is kept.
+	if want := "# Notes\n- Tests need docker\n- Use gofmt\n"; got != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+	if err := k.save("github/caic", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := k.load("github/caic"); err != nil || got != "" {
+		t.Errorf("after delete: %q, %v", got, err)
+	}
+}
+
+func TestRepoKnowledgeAPI(t *testing.T) {
+	s := newTestServer(t)
+	s.repos = []repoInfo{{RelPath: "caic", AbsPath: t.TempDir()}}
+	w := httptest.NewRecorder()
+	handle(s.updateRepoKnowledge)(w, httptest.NewRequest(http.MethodPost, "/api/v1/server/repos/knowledge", strings.NewReader(`{"repo":"caic","content":"- Use make\n"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	handle(s.updateRepoKnowledge)(w, httptest.NewRequest(http.MethodPost, "/api/v1/server/repos/knowledge", strings.NewReader(`{"repo":"other","content":"x"}`)))
+	if e := decodeError(t, w); e.Code != dto.CodeNotFound {
+		t.Errorf("code = %q, want %q", e.Code, dto.CodeNotFound)
+	}
+	w = httptest.NewRecorder()
+	s.handleGetRepoKnowledge(w, httptest.NewRequest(http.MethodGet, "/api/v1/server/repos/knowledge?repo=caic", http.NoBody))
+	var resp v1.RepoKnowledgeResp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != "- Use make\n" || resp.MaxBytes != defaultKnowledgeMaxBytes {
+		t.Errorf("resp = %+v", resp)
+	}
+	if got := s.taskKnowledge([]task.RepoMount{{Name: "caic"}}); got != "- Use make\n" {
+		t.Errorf("taskKnowledge = %q", got)
+	}
+}
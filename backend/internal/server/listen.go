@@ -0,0 +1,94 @@
+// Listeners beyond a TCP address: Unix domain socket and the host's Tailscale
+// serve.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unixPrefix marks a listen address as a Unix domain socket path, e.g.
+// "unix:/run/caic/caic.sock".
+const unixPrefix = "unix:"
+
+// listen opens the listener for addr: a Unix domain socket when addr starts
+// with unixPrefix, TCP otherwise.
+func listen(ctx context.Context, addr string) (net.Listener, error) {
+	var lc net.ListenConfig
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return lc.Listen(ctx, "tcp", addr)
+	}
+	if path == "" {
+		return nil, errors.New("empty unix socket path")
+	}
+	// Remove the socket left behind by a previous instance that did not shut
+	// down cleanly, but never anything else.
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	ln, err := lc.Listen(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Owner and group, so a reverse proxy in the group can connect. The
+	// listener unlinks the socket on close.
+	if err := os.Chmod(path, 0o660); err != nil {
+		_ = ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// hostTailscaleServe exposes the local listener ln on the tailnet through the
+// host's tailscaled with "tailscale serve", on the HTTPS port. Only tailnet
+// devices can reach it and no port is opened on the host. The returned
+// function removes the serve configuration.
+func hostTailscaleServe(ctx context.Context, ln net.Listener, port string, tlsEnabled bool) (func(), error) {
+	tcp, ok := ln.Addr().(*net.TCPAddr)
+	if !ok {
+		return nil, fmt.Errorf("tailscale serve requires a TCP listener, got %s", ln.Addr().Network())
+	}
+	scheme := "http"
+	if tlsEnabled {
+		// The certificate is for the public hostname, not localhost.
+		scheme = "https+insecure"
+	}
+	host := "127.0.0.1"
+	if ip := tcp.IP; ip != nil && !ip.IsUnspecified() {
+		host = ip.String()
+	}
+	target := scheme + "://" + net.JoinHostPort(host, strconv.Itoa(tcp.Port))
+	if out, err := exec.CommandContext(ctx, "tailscale", "serve", "--bg", "--yes", "--https="+port, target).CombinedOutput(); err != nil { //nolint:gosec // port is validated, target is built from the listener
+		return nil, fmt.Errorf("tailscale serve: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	slog.Info("tailscale serve", "https", port, "target", target)
+	return func() {
+		// Use Background because the parent ctx is already cancelled.
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if out, err := exec.CommandContext(ctx, "tailscale", "serve", "--https="+port, "off").CombinedOutput(); err != nil { //nolint:gosec // port is validated
+			slog.Warn("tailscale serve off", "err", err, "out", strings.TrimSpace(string(out)))
+		}
+	}, nil
+}
+
+// validPort reports whether s is a TCP port number.
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n < 65536 && strconv.Itoa(n) == s
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	// Keep the path short: sun_path is limited to ~100 bytes.
+	dir, err := os.MkdirTemp("", "caic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	path := filepath.Join(dir, "s.sock")
+
+	serve := func(t *testing.T) net.Listener {
+		t.Helper()
+		ln, err := listen(t.Context(), unixPrefix+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func() {
+			_ = http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { //nolint:gosec // test server
+				_, _ = io.WriteString(w, "ok")
+			}))
+		}()
+		return ln
+	}
+
+	t.Run("Serve", func(t *testing.T) {
+		ln := serve(t)
+		defer func() { _ = ln.Close() }()
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := fi.Mode().Perm(); perm != 0o660 {
+			t.Errorf("mode = %o, want 660", perm)
+		}
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}}
+		resp, err := client.Get("http://caic/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if string(body) != "ok" {
+			t.Errorf("body = %q", body)
+		}
+	})
+	t.Run("StaleSocket", func(t *testing.T) {
+		// Leave a socket file behind like a crashed instance would.
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ln.(*net.UnixListener).SetUnlinkOnClose(false)
+		_ = ln.Close()
+		if _, err := os.Stat(path); err != nil {
+			t.Fatal(err)
+		}
+		_ = serve(t).Close()
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("socket not removed on close: %v", err)
+		}
+	})
+	t.Run("NotASocket", func(t *testing.T) {
+		if err := os.WriteFile(path, []byte("data"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := listen(t.Context(), unixPrefix+path); err == nil {
+			t.Fatal("expected error")
+		}
+		if b, err := os.ReadFile(path); err != nil || string(b) != "data" {
+			t.Errorf("file clobbered: %q, %v", b, err)
+		}
+	})
+}
+
+func TestHostTailscaleServeRequiresTCP(t *testing.T) {
+	dir, err := os.MkdirTemp("", "caic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	ln, err := listen(t.Context(), unixPrefix+filepath.Join(dir, "s.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	if _, err := hostTailscaleServe(t.Context(), ln, "443", false); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestValidPort(t *testing.T) {
+	for s, want := range map[string]bool{"443": true, "8443": true, "0": false, "65536": false, "044": false, "x": false, "": false} {
+		if got := validPort(s); got != want {
+			t.Errorf("validPort(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
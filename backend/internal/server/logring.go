@@ -0,0 +1,123 @@
+// In-memory ring buffer of the server's own slog records, streamed over SSE.
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// defaultLogRingSize is the number of records kept when NewLogRing is called
+// with a non-positive size.
+const defaultLogRingSize = 2000
+
+// LogRing keeps the most recent server log records in memory.
+//
+// It is an io.Writer meant to be used as the output of slog.NewJSONHandler:
+// each Write call is expected to contain exactly one JSON encoded record.
+type LogRing struct {
+	mu      sync.Mutex
+	entries []v1.ServerLogEntry // circular; len(entries) <= size
+	next    int                 // index of the next slot to overwrite once full
+	size    int
+	total   uint64        // number of records ever appended
+	changed chan struct{} // closed on append; replaced under mu
+}
+
+// NewLogRing returns a LogRing holding up to size records.
+func NewLogRing(size int) *LogRing {
+	if size <= 0 {
+		size = defaultLogRingSize
+	}
+	return &LogRing{size: size, changed: make(chan struct{})}
+}
+
+// Write parses one JSON log record and appends it to the ring.
+//
+// Lines that fail to decode are kept verbatim as the message so nothing is
+// silently dropped.
+func (l *LogRing) Write(p []byte) (int, error) {
+	e := parseLogLine(p)
+	l.mu.Lock()
+	if len(l.entries) < l.size {
+		l.entries = append(l.entries, e)
+	} else {
+		l.entries[l.next] = e
+		l.next = (l.next + 1) % l.size
+	}
+	l.total++
+	close(l.changed)
+	l.changed = make(chan struct{})
+	l.mu.Unlock()
+	return len(p), nil
+}
+
+// since returns the buffered records appended after the first seen records,
+// in chronological order. It also returns the new total to pass on the next
+// call and a channel closed on the next append. Records that were already
+// evicted from the ring are skipped.
+func (l *LogRing) since(seen uint64) (out []v1.ServerLogEntry, total uint64, changed <-chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := min(l.total-seen, uint64(len(l.entries)))
+	out = make([]v1.ServerLogEntry, 0, n)
+	for i := uint64(len(l.entries)) - n; i < uint64(len(l.entries)); i++ {
+		out = append(out, l.entries[(uint64(l.next)+i)%uint64(len(l.entries))])
+	}
+	return out, l.total, l.changed
+}
+
+func parseLogLine(p []byte) v1.ServerLogEntry {
+	var m map[string]any
+	if err := json.Unmarshal(p, &m); err != nil {
+		return v1.ServerLogEntry{
+			Time:  time.Now(),
+			Level: slog.LevelInfo.String(),
+			Msg:   strings.TrimRight(string(p), "\n"),
+		}
+	}
+	e := v1.ServerLogEntry{}
+	if s, ok := m[slog.TimeKey].(string); ok {
+		e.Time, _ = time.Parse(time.RFC3339Nano, s)
+	}
+	if s, ok := m[slog.LevelKey].(string); ok {
+		e.Level = s
+	}
+	if s, ok := m[slog.MessageKey].(string); ok {
+		e.Msg = s
+	}
+	delete(m, slog.TimeKey)
+	delete(m, slog.LevelKey)
+	delete(m, slog.MessageKey)
+	if len(m) != 0 {
+		e.Attrs = m
+	}
+	return e
+}
+
+// parseLogLevel parses a level name as accepted by the "level" query
+// parameter. The empty string maps to debug, i.e. no filtering.
+func parseLogLevel(s string) (slog.Level, bool) {
+	if s == "" {
+		return slog.LevelDebug, true
+	}
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(s)); err != nil {
+		return 0, false
+	}
+	return lvl, true
+}
+
+// entryLevel returns the level of a buffered entry, defaulting to info when
+// the level string is unrecognized.
+func entryLevel(e *v1.ServerLogEntry) slog.Level {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(e.Level)); err != nil {
+		return slog.LevelInfo
+	}
+	return lvl
+}
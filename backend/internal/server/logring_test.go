@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+func TestLogRing(t *testing.T) {
+	t.Run("JSONHandler", func(t *testing.T) {
+		r := NewLogRing(10)
+		l := slog.New(slog.NewJSONHandler(r, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		l.Info("container started", "ctr", "md-foo", "n", 3)
+		entries, total, _ := r.since(0)
+		if total != 1 || len(entries) != 1 {
+			t.Fatalf("got total=%d len=%d, want 1, 1", total, len(entries))
+		}
+		e := entries[0]
+		if e.Level != "INFO" || e.Msg != "container started" {
+			t.Errorf("entry = %+v", e)
+		}
+		if e.Time.IsZero() {
+			t.Error("time not parsed")
+		}
+		if e.Attrs["ctr"] != "md-foo" || e.Attrs["n"] != float64(3) {
+			t.Errorf("attrs = %v", e.Attrs)
+		}
+	})
+	t.Run("Wraps", func(t *testing.T) {
+		r := NewLogRing(3)
+		for _, m := range []string{"a", "b", "c", "d", "e"} {
+			_, _ = r.Write([]byte(`{"level":"INFO","msg":"` + m + `"}` + "\n"))
+		}
+		entries, total, _ := r.since(0)
+		if total != 5 {
+			t.Errorf("total = %d, want 5", total)
+		}
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.Msg)
+		}
+		if strings.Join(got, "") != "cde" {
+			t.Errorf("msgs = %v, want [c d e]", got)
+		}
+		entries, _, _ = r.since(4)
+		if len(entries) != 1 || entries[0].Msg != "e" {
+			t.Errorf("since(4) = %+v, want [e]", entries)
+		}
+	})
+	t.Run("InvalidJSON", func(t *testing.T) {
+		r := NewLogRing(3)
+		_, _ = r.Write([]byte("not json\n"))
+		entries, _, _ := r.since(0)
+		if len(entries) != 1 || entries[0].Msg != "not json" || entries[0].Level != "INFO" {
+			t.Errorf("entries = %+v", entries)
+		}
+	})
+}
+
+func TestHandleServerLogEvents(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		s := newTestServer(t)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/server/logs/events", http.NoBody)
+		w := httptest.NewRecorder()
+		s.handleServerLogEvents(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+	t.Run("Admin", func(t *testing.T) {
+		s := newTestServer(t)
+		s.logRing = NewLogRing(10)
+		store, err := auth.Open(filepath.Join(t.TempDir(), "users.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.authStore = store
+		s.admins = []string{"alice"}
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/server/logs/events", http.NoBody)
+		req = req.WithContext(auth.NewContext(req.Context(), &auth.User{ID: "b", Username: "bob"}))
+		w := httptest.NewRecorder()
+		s.handleServerLogEvents(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+		}
+	})
+	t.Run("InvalidLevel", func(t *testing.T) {
+		s := newTestServer(t)
+		s.logRing = NewLogRing(10)
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/server/logs/events?level=loud", http.NoBody)
+		w := httptest.NewRecorder()
+		s.handleServerLogEvents(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+	t.Run("LevelFilter", func(t *testing.T) {
+		s := newTestServer(t)
+		s.logRing = NewLogRing(10)
+		l := slog.New(slog.NewJSONHandler(s.logRing, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		l.Debug("noise")
+		l.Info("relay attached")
+		l.Warn("container start slow")
+		l.Error("relay died")
+
+		ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+		defer cancel()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/server/logs/events?level=warn", http.NoBody).WithContext(ctx)
+		w := httptest.NewRecorder()
+		s.handleServerLogEvents(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var got []string
+		for line := range strings.SplitSeq(w.Body.String(), "\n") {
+			data, ok := strings.CutPrefix(line, "data: ")
+			if !ok {
+				continue
+			}
+			var e v1.ServerLogEntry
+			if err := json.Unmarshal([]byte(data), &e); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, e.Msg)
+		}
+		if strings.Join(got, ",") != "container start slow,relay died" {
+			t.Errorf("msgs = %v", got)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+// Secrets in the task content served to clients: masked unless an admin asks
+// otherwise, see task.MaskSecrets.
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+)
+
+// unmask reports whether the request asked with ?unmask=true for the secrets
+// of a task's content to be served as is. Only admins may, and never through
+// a share link.
+func (s *Server) unmask(r *http.Request) (bool, error) {
+	if r.URL.Query().Get("unmask") != "true" {
+		return false, nil
+	}
+	if r.PathValue("token") != "" {
+		return false, dto.Forbidden("unmasked secrets")
+	}
+	user := ""
+	if s.authStore != nil {
+		u, ok := auth.UserFromContext(r.Context())
+		if !ok || !slices.Contains(s.admins, u.Username) {
+			return false, dto.Forbidden("unmasked secrets")
+		}
+		user = u.Username
+	}
+	slog.InfoContext(r.Context(), "unmask", "path", r.URL.Path, "user", user)
+	return true, nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+)
+
+func TestUnmask(t *testing.T) {
+	s := newTestServer(t)
+	check := func(t *testing.T, r *http.Request, want bool, wantForbidden bool) {
+		t.Helper()
+		got, err := s.unmask(r)
+		if wantForbidden {
+			if apiErr, ok := err.(*dto.APIError); !ok || apiErr.StatusCode() != http.StatusForbidden {
+				t.Fatalf("err = %v, want forbidden", err)
+			}
+			return
+		}
+		if err != nil || got != want {
+			t.Fatalf("unmask() = %v, %v, want %v", got, err, want)
+		}
+	}
+	t.Run("Default", func(t *testing.T) {
+		check(t, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/x/diff", http.NoBody), false, false)
+	})
+	t.Run("NoAuth", func(t *testing.T) {
+		check(t, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/x/diff?unmask=true", http.NoBody), true, false)
+	})
+	t.Run("Shared", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/shared/tok/diff?unmask=true", http.NoBody)
+		r.SetPathValue("token", "tok")
+		check(t, r, false, true)
+	})
+	t.Run("Admins", func(t *testing.T) {
+		store, err := auth.Open(filepath.Join(t.TempDir(), "users.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.authStore = store
+		s.admins = []string{"root"}
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/x/diff?unmask=true", http.NoBody)
+		check(t, r.WithContext(auth.NewContext(r.Context(), &auth.User{Username: "alice"})), false, true)
+		check(t, r.WithContext(auth.NewContext(r.Context(), &auth.User{Username: "root"})), true, false)
+	})
+}
@@ -0,0 +1,293 @@
+// mDNS (DNS-SD) advertisement of the server as _caic._tcp on the LAN.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	mdnsService  = "_caic._tcp.local."
+	mdnsServices = "_services._dns-sd._udp.local."
+	mdnsTTL      = 120 // seconds, as recommended by RFC 6762 for service records
+)
+
+// mdnsGroup is the IPv4 mDNS multicast group.
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// mdnsAdvertiser answers DNS-SD queries for a single caic instance.
+type mdnsAdvertiser struct {
+	instance string // service instance label, e.g. "caic on myhost"
+	host     string // e.g. "myhost.local."
+	port     uint16
+	// txt returns the TXT record strings; called for every answer so that
+	// metadata like the repo count stays current.
+	txt func() []string
+	// addrs returns the IPv4 addresses to advertise for host.
+	addrs func() []net.IP
+}
+
+// newMDNSAdvertiser returns an advertiser for a server listening on port.
+func (s *Server) newMDNSAdvertiser(port int) (*mdnsAdvertiser, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	hostname, _, _ = strings.Cut(hostname, ".")
+	return &mdnsAdvertiser{
+		instance: "caic on " + hostname,
+		host:     hostname + ".local.",
+		port:     uint16(port), //nolint:gosec // port comes from a TCP listener
+		txt: func() []string {
+			txt := []string{
+				"version=" + serverVersion(),
+				"repos=" + strconv.Itoa(len(s.repos)),
+				"path=" + s.basePath + "/",
+			}
+			if s.tls.enabled() {
+				txt = append(txt, "tls=1")
+			}
+			return txt
+		},
+		addrs: lanIPv4Addrs,
+	}, nil
+}
+
+// advertise starts advertising ln on the LAN until ctx is cancelled.
+func (s *Server) advertise(ctx context.Context, ln net.Listener) {
+	tcp, ok := ln.Addr().(*net.TCPAddr)
+	if !ok || tcp.IP.IsLoopback() {
+		slog.Warn("mdns: listener is not reachable from the LAN, not advertising", "addr", ln.Addr().String())
+		return
+	}
+	a, err := s.newMDNSAdvertiser(tcp.Port)
+	if err != nil {
+		slog.Warn("mdns", "err", err)
+		return
+	}
+	go func() {
+		if err := a.run(ctx); err != nil {
+			slog.Warn("mdns", "err", err)
+		}
+	}()
+	slog.Info("mdns", "service", a.instanceName(), "port", tcp.Port)
+}
+
+// serverVersion returns the module version, or the VCS revision for a
+// development build.
+func serverVersion() string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := bi.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	for _, s := range bi.Settings {
+		if s.Key == "vcs.revision" && len(s.Value) >= 12 {
+			return s.Value[:12]
+		}
+	}
+	return "devel"
+}
+
+// lanIPv4Addrs returns the IPv4 addresses of the up, non-loopback interfaces.
+func lanIPv4Addrs() []net.IP {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var out []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if n, ok := a.(*net.IPNet); ok {
+				if ip4 := n.IP.To4(); ip4 != nil {
+					out = append(out, ip4)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// instanceName returns the fully qualified service instance name.
+func (a *mdnsAdvertiser) instanceName() string {
+	// Dots in the instance label would split it; RFC 6763 allows escaping
+	// but dnsmessage does not, so replace them.
+	return strings.ReplaceAll(a.instance, ".", "-") + "." + mdnsService
+}
+
+// run announces the service, answers queries until ctx is cancelled, then
+// sends a goodbye so that clients drop the instance right away.
+func (a *mdnsAdvertiser) run(ctx context.Context) error {
+	conn, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		if pkt, err := a.response(0, a.records(true, true, true, true, 0)); err == nil {
+			_, _ = conn.WriteToUDP(pkt, mdnsGroup)
+		}
+		_ = conn.Close()
+	}()
+	// RFC 6762 §8.3: announce at least twice, one second apart.
+	go func() {
+		for i := range 2 {
+			if i > 0 {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
+			if pkt, err := a.response(0, a.records(true, true, true, true, mdnsTTL)); err == nil {
+				_, _ = conn.WriteToUDP(pkt, mdnsGroup)
+			}
+		}
+	}()
+	buf := make([]byte, 9000)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		id, answers, unicast := a.answer(buf[:n])
+		if len(answers) == 0 {
+			continue
+		}
+		pkt, err := a.response(id, answers)
+		if err != nil {
+			slog.Warn("mdns", "err", err)
+			continue
+		}
+		dst := mdnsGroup
+		if unicast || src.Port != mdnsGroup.Port {
+			dst = src
+		}
+		_, _ = conn.WriteToUDP(pkt, dst)
+	}
+}
+
+// answer parses a query and returns the records answering it. unicast is
+// true when the reply must go back to the querier: a legacy resolver not
+// using port 5353, or a question with the unicast-response bit.
+func (a *mdnsAdvertiser) answer(pkt []byte) (id uint16, answers []dnsmessage.Resource, unicast bool) {
+	var p dnsmessage.Parser
+	h, err := p.Start(pkt)
+	if err != nil || h.Response {
+		return 0, nil, false
+	}
+	qs, err := p.AllQuestions()
+	if err != nil {
+		return 0, nil, false
+	}
+	var ptr, svc, txt, addr bool
+	for _, q := range qs {
+		if q.Class&(1<<15) != 0 {
+			unicast = true
+		}
+		name := strings.ToLower(q.Name.String())
+		all := q.Type == dnsmessage.TypeALL
+		switch name {
+		case mdnsServices:
+			if q.Type == dnsmessage.TypePTR || all {
+				answers = append(answers, a.servicesPTR())
+			}
+		case mdnsService:
+			if q.Type == dnsmessage.TypePTR || all {
+				ptr, svc, txt, addr = true, true, true, true
+			}
+		case strings.ToLower(a.instanceName()):
+			svc = svc || q.Type == dnsmessage.TypeSRV || all
+			txt = txt || q.Type == dnsmessage.TypeTXT || all
+			addr = addr || q.Type == dnsmessage.TypeSRV || all
+		case strings.ToLower(a.host):
+			addr = addr || q.Type == dnsmessage.TypeA || all
+		}
+	}
+	answers = append(answers, a.records(ptr, svc, txt, addr, mdnsTTL)...)
+	return h.ID, answers, unicast
+}
+
+// servicesPTR is the DNS-SD service type enumeration record.
+func (a *mdnsAdvertiser) servicesPTR() dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(mdnsServices), Class: dnsmessage.ClassINET, TTL: 4500},
+		Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(mdnsService)},
+	}
+}
+
+// records returns the requested records of the instance with ttl; a zero
+// ttl is a goodbye.
+func (a *mdnsAdvertiser) records(ptr, svc, txt, addr bool, ttl uint32) []dnsmessage.Resource {
+	inst, err := dnsmessage.NewName(a.instanceName())
+	if err != nil {
+		return nil
+	}
+	host, err := dnsmessage.NewName(a.host)
+	if err != nil {
+		return nil
+	}
+	// The cache-flush bit tells clients these unique records replace any
+	// cached ones.
+	const flush = dnsmessage.ClassINET | 1<<15
+	var out []dnsmessage.Resource
+	if ptr {
+		out = append(out, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(mdnsService), Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.PTRResource{PTR: inst},
+		})
+	}
+	if svc {
+		out = append(out, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: inst, Class: flush, TTL: ttl},
+			Body:   &dnsmessage.SRVResource{Port: a.port, Target: host},
+		})
+	}
+	if txt {
+		out = append(out, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: inst, Class: flush, TTL: ttl},
+			Body:   &dnsmessage.TXTResource{TXT: a.txt()},
+		})
+	}
+	if addr {
+		for _, ip := range a.addrs() {
+			var a4 [4]byte
+			copy(a4[:], ip.To4())
+			out = append(out, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: host, Class: flush, TTL: ttl},
+				Body:   &dnsmessage.AResource{A: a4},
+			})
+		}
+	}
+	return out
+}
+
+// response packs an authoritative mDNS response.
+func (a *mdnsAdvertiser) response(id uint16, answers []dnsmessage.Resource) ([]byte, error) {
+	msg := dnsmessage.Message{
+		Header:  dnsmessage.Header{ID: id, Response: true, Authoritative: true},
+		Answers: answers,
+	}
+	return msg.Pack()
+}
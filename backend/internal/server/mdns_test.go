@@ -0,0 +1,121 @@
+package server
+
+import (
+	"net"
+	"slices"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func testAdvertiser() *mdnsAdvertiser {
+	return &mdnsAdvertiser{
+		instance: "caic on my.host",
+		host:     "myhost.local.",
+		port:     8080,
+		txt:      func() []string { return []string{"version=v1", "repos=2"} },
+		addrs:    func() []net.IP { return []net.IP{net.IPv4(192, 168, 1, 10)} },
+	}
+}
+
+// query packs an mDNS query with one question per type for name.
+func query(t *testing.T, name string, unicast bool, types ...dnsmessage.Type) []byte {
+	t.Helper()
+	class := dnsmessage.ClassINET
+	if unicast {
+		class |= 1 << 15
+	}
+	msg := dnsmessage.Message{Header: dnsmessage.Header{ID: 7}}
+	for _, typ := range types {
+		msg.Questions = append(msg.Questions, dnsmessage.Question{Name: dnsmessage.MustNewName(name), Type: typ, Class: class})
+	}
+	pkt, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkt
+}
+
+func TestMDNSAnswer(t *testing.T) {
+	a := testAdvertiser()
+	if got := a.instanceName(); got != "caic on my-host._caic._tcp.local." {
+		t.Fatalf("instanceName() = %q", got)
+	}
+
+	t.Run("Browse", func(t *testing.T) {
+		id, answers, unicast := a.answer(query(t, mdnsService, false, dnsmessage.TypePTR))
+		if id != 7 || unicast {
+			t.Errorf("id = %d, unicast = %v", id, unicast)
+		}
+		pkt, err := a.response(id, answers)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg dnsmessage.Message
+		if err := msg.Unpack(pkt); err != nil {
+			t.Fatal(err)
+		}
+		if !msg.Response || !msg.Authoritative {
+			t.Errorf("header = %+v", msg.Header)
+		}
+		var types []dnsmessage.Type
+		for _, r := range msg.Answers {
+			types = append(types, r.Header.Type)
+			switch b := r.Body.(type) {
+			case *dnsmessage.PTRResource:
+				if b.PTR.String() != a.instanceName() {
+					t.Errorf("PTR = %s", b.PTR)
+				}
+			case *dnsmessage.SRVResource:
+				if b.Port != 8080 || b.Target.String() != "myhost.local." {
+					t.Errorf("SRV = %+v", b)
+				}
+			case *dnsmessage.TXTResource:
+				if !slices.Equal(b.TXT, []string{"version=v1", "repos=2"}) {
+					t.Errorf("TXT = %q", b.TXT)
+				}
+			case *dnsmessage.AResource:
+				if b.A != [4]byte{192, 168, 1, 10} {
+					t.Errorf("A = %v", b.A)
+				}
+			}
+		}
+		want := []dnsmessage.Type{dnsmessage.TypePTR, dnsmessage.TypeSRV, dnsmessage.TypeTXT, dnsmessage.TypeA}
+		if !slices.Equal(types, want) {
+			t.Errorf("answer types = %v, want %v", types, want)
+		}
+	})
+
+	t.Run("Services", func(t *testing.T) {
+		_, answers, _ := a.answer(query(t, mdnsServices, false, dnsmessage.TypePTR))
+		if len(answers) != 1 || answers[0].Body.(*dnsmessage.PTRResource).PTR.String() != mdnsService {
+			t.Errorf("answers = %+v", answers)
+		}
+	})
+
+	t.Run("HostUnicast", func(t *testing.T) {
+		_, answers, unicast := a.answer(query(t, "MyHost.local.", true, dnsmessage.TypeA))
+		if !unicast || len(answers) != 1 {
+			t.Fatalf("unicast = %v, answers = %+v", unicast, answers)
+		}
+		if _, ok := answers[0].Body.(*dnsmessage.AResource); !ok {
+			t.Errorf("answer = %+v, want an A record", answers[0])
+		}
+	})
+
+	t.Run("Unrelated", func(t *testing.T) {
+		if _, answers, _ := a.answer(query(t, "_http._tcp.local.", false, dnsmessage.TypePTR)); len(answers) != 0 {
+			t.Errorf("answers = %+v", answers)
+		}
+	})
+
+	t.Run("IgnoresResponses", func(t *testing.T) {
+		pkt, err := a.response(0, a.records(true, false, false, false, mdnsTTL))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, answers, _ := a.answer(pkt); len(answers) != 0 {
+			t.Errorf("answers = %+v", answers)
+		}
+	})
+}
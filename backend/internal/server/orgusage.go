@@ -0,0 +1,153 @@
+// Anthropic organization cost report fetcher, for teams on API billing.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+const (
+	orgCostAPIURL = "https://api.anthropic.com/v1/organizations/cost_report"
+	// orgCostMaxPages bounds pagination; 7 daily buckets fit in one page.
+	orgCostMaxPages = 5
+)
+
+// orgUsageFetcher fetches and caches the organization's daily cost over the
+// last 7 days with the Anthropic Admin API. It applies the same caching and
+// exponential backoff as usageFetcher.
+type orgUsageFetcher struct {
+	client *http.Client
+	apiKey string // sk-ant-admin...
+	apiURL string // overridden in tests
+
+	mu      sync.Mutex
+	cached  *v1.OrgUsage
+	fetchAt time.Time
+	backoff time.Duration
+	errorAt time.Time
+}
+
+// newOrgUsageFetcher returns nil when apiKey is empty.
+func newOrgUsageFetcher(apiKey string) *orgUsageFetcher {
+	if apiKey == "" {
+		return nil
+	}
+	return &orgUsageFetcher{
+		client: &http.Client{Timeout: 10 * time.Second},
+		apiKey: apiKey,
+		apiURL: orgCostAPIURL,
+	}
+}
+
+// get returns the cached organization cost, refreshing if stale.
+func (f *orgUsageFetcher) get() *v1.OrgUsage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.cached != nil && time.Since(f.fetchAt) < usageCacheTTL {
+		return f.cached
+	}
+	if f.backoff > 0 && time.Since(f.errorAt) < f.backoff {
+		return f.cached
+	}
+	resp, err := f.fetch(time.Now())
+	if err != nil {
+		slog.Warn("failed to fetch organization cost", "err", err)
+		f.errorAt = time.Now()
+		f.backoff = min(max(2*f.backoff, backoffMin), backoffMax)
+		return f.cached
+	}
+	f.backoff = 0
+	f.cached = resp
+	f.fetchAt = time.Now()
+	return resp
+}
+
+// orgCostReport is a page of the cost_report response.
+type orgCostReport struct {
+	Data []struct {
+		StartingAt string `json:"starting_at"`
+		Results    []struct {
+			// Amount is in the lowest currency unit (cents), as a decimal string.
+			Amount   string `json:"amount"`
+			Currency string `json:"currency"`
+		} `json:"results"`
+	} `json:"data"`
+	HasMore  bool   `json:"has_more"`
+	NextPage string `json:"next_page"`
+}
+
+// fetch retrieves daily cost buckets for the 7 days ending at now.
+func (f *orgUsageFetcher) fetch(now time.Time) (*v1.OrgUsage, error) {
+	start := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -6)
+	out := &v1.OrgUsage{Days: []v1.OrgDailyCost{}}
+	page := ""
+	for range orgCostMaxPages {
+		q := url.Values{}
+		q.Set("starting_at", start.Format(time.RFC3339))
+		q.Set("bucket_width", "1d")
+		if page != "" {
+			q.Set("page", page)
+		}
+		var rep orgCostReport
+		if err := f.do(f.apiURL+"?"+q.Encode(), &rep); err != nil {
+			return nil, err
+		}
+		for _, b := range rep.Data {
+			day := v1.OrgDailyCost{Date: b.StartingAt}
+			if t, err := time.Parse(time.RFC3339, b.StartingAt); err == nil {
+				day.Date = t.Format(time.DateOnly)
+			}
+			for _, r := range b.Results {
+				if r.Currency != "" && r.Currency != "USD" {
+					continue
+				}
+				cents, err := strconv.ParseFloat(r.Amount, 64)
+				if err != nil {
+					return nil, fmt.Errorf("decode cost amount %q: %w", r.Amount, err)
+				}
+				day.CostUSD += cents / 100
+			}
+			out.Days = append(out.Days, day)
+			out.SevenDayUSD += day.CostUSD
+		}
+		if !rep.HasMore || rep.NextPage == "" {
+			return out, nil
+		}
+		page = rep.NextPage
+	}
+	return nil, errors.New("cost report has too many pages")
+}
+
+func (f *orgUsageFetcher) do(u string, out any) error {
+	req, err := http.NewRequest(http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Api-Key", f.apiKey)
+	req.Header.Set("Anthropic-Version", "2023-06-01")
+	req.Header.Set("User-Agent", "caic")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cost report API returned %d: %s", resp.StatusCode, body)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode cost report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOrgUsageFetcher(t *testing.T) {
+	if newOrgUsageFetcher("") != nil {
+		t.Fatal("expected nil fetcher without key")
+	}
+	pages := map[string]string{
+		"":   `{"data":[{"starting_at":"2026-03-01T00:00:00Z","results":[{"amount":"1250.5","currency":"USD"},{"amount":"50","currency":"USD"}]}],"has_more":true,"next_page":"p2"}`,
+		"p2": `{"data":[{"starting_at":"2026-03-02T00:00:00Z","results":[]}],"has_more":false}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Api-Key") != "sk-ant-admin-test" || r.URL.Query().Get("bucket_width") != "1d" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, ok := pages[r.URL.Query().Get("page")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	f := newOrgUsageFetcher("sk-ant-admin-test")
+	f.apiURL = srv.URL
+	got, err := f.fetch(time.Date(2026, 3, 7, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Days) != 2 || got.Days[0].Date != "2026-03-01" {
+		t.Fatalf("Days = %+v", got.Days)
+	}
+	if math.Abs(got.SevenDayUSD-13.005) > 1e-9 {
+		t.Errorf("SevenDayUSD = %v, want 13.005", got.SevenDayUSD)
+	}
+
+	f.apiKey = "wrong"
+	if _, err := f.fetch(time.Now()); err == nil {
+		t.Error("expected error on HTTP 400")
+	}
+}
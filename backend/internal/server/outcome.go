@@ -0,0 +1,328 @@
+// Task outcome labels and the per harness/model cost and acceptance report.
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+// outcomeLabel is a task outcome label as persisted.
+type outcomeLabel struct {
+	TaskID    ksid.ID        `json:"taskId"`
+	Outcome   v1.TaskOutcome `json:"outcome"`
+	Reason    string         `json:"reason,omitempty"`
+	Source    v1.LabelSource `json:"source"`
+	LabeledBy string         `json:"labeledBy,omitempty"`
+	LabeledAt time.Time      `json:"labeledAt"`
+}
+
+// labelStore persists the outcome label of every task in a single JSON file,
+// like draftStore. Labels outlive the tasks shown in the UI so the cost
+// report covers the whole log history. An empty path keeps labels in memory
+// only.
+type labelStore struct {
+	path string
+
+	mu     sync.Mutex
+	labels map[ksid.ID]outcomeLabel
+}
+
+// openLabelStore loads the labels stored at path, if any.
+func openLabelStore(path string) (*labelStore, error) {
+	l := &labelStore{path: path, labels: map[ksid.ID]outcomeLabel{}}
+	if path == "" {
+		return l, nil
+	}
+	data, err := readSealed(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var list []outcomeLabel
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	for _, ol := range list {
+		l.labels[ol.TaskID] = ol
+	}
+	return l, nil
+}
+
+// saveLocked atomically writes all labels. Must be called with l.mu held.
+func (l *labelStore) saveLocked() error {
+	if l.path == "" {
+		return nil
+	}
+	list := make([]outcomeLabel, 0, len(l.labels))
+	for _, ol := range l.labels {
+		list = append(list, ol)
+	}
+	slices.SortFunc(list, func(a, b outcomeLabel) int { return strings.Compare(a.TaskID.String(), b.TaskID.String()) })
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeSealedAtomic(l.path, data)
+}
+
+// get returns the label of task id.
+func (l *labelStore) get(id ksid.ID) (outcomeLabel, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ol, ok := l.labels[id]
+	return ol, ok
+}
+
+// all returns a copy of every label.
+func (l *labelStore) all() map[ksid.ID]outcomeLabel {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[ksid.ID]outcomeLabel, len(l.labels))
+	for id, ol := range l.labels {
+		out[id] = ol
+	}
+	return out
+}
+
+// set stores ol, replacing any previous label of the task. When onlyIfUnset
+// is true, an existing label is kept and set reports false.
+func (l *labelStore) set(ol *outcomeLabel, onlyIfUnset bool) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.labels[ol.TaskID]; ok && onlyIfUnset {
+		return false, nil
+	}
+	l.labels[ol.TaskID] = *ol
+	return true, l.saveLocked()
+}
+
+// remove deletes the label of task id. It is not an error if there is none.
+func (l *labelStore) remove(id ksid.ID) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.labels[id]; !ok {
+		return nil
+	}
+	delete(l.labels, id)
+	return l.saveLocked()
+}
+
+// taskLabel returns the label of task id converted for the API, or nil.
+func (s *Server) taskLabel(id ksid.ID) *v1.TaskLabel {
+	if s.labels == nil {
+		return nil
+	}
+	ol, ok := s.labels.get(id)
+	if !ok {
+		return nil
+	}
+	return &v1.TaskLabel{
+		Outcome:   ol.Outcome,
+		Reason:    ol.Reason,
+		Source:    ol.Source,
+		LabeledBy: ol.LabeledBy,
+		LabeledAt: float64(ol.LabeledAt.UnixMilli()) / 1e3,
+	}
+}
+
+// labelable reports whether a task in state st is done with its work and can
+// be labeled.
+func labelable(st task.State) bool {
+	switch st {
+	case task.StateWaiting, task.StateAsking, task.StateHasPlan, task.StateStopped, task.StateFailed, task.StatePurged:
+		return true
+	default:
+		return false
+	}
+}
+
+// labelTask sets or, with an empty outcome, removes the outcome label of a
+// completed task. A user label replaces a label set by the verification
+// command.
+func (s *Server) labelTask(ctx context.Context, entry *taskEntry, req *v1.LabelTaskReq) (*v1.Task, error) {
+	t := entry.task
+	if req.Outcome == "" {
+		if err := s.labels.remove(t.ID); err != nil {
+			return nil, dto.InternalError("save labels").Wrap(err)
+		}
+	} else {
+		if !labelable(t.GetState()) {
+			return nil, dto.Conflict("task is not completed").WithDetail("state", t.GetState().String())
+		}
+		ol := &outcomeLabel{
+			TaskID:    t.ID,
+			Outcome:   req.Outcome,
+			Reason:    req.Reason,
+			Source:    v1.LabelSourceUser,
+			LabeledBy: s.draftOwner(ctx),
+			LabeledAt: time.Now().UTC(),
+		}
+		if _, err := s.labels.set(ol, false); err != nil {
+			return nil, dto.InternalError("save labels").Wrap(err)
+		}
+	}
+	s.notifyTaskChange()
+	j := s.toJSON(entry)
+	return &j, nil
+}
+
+// labelFromVerify labels a task from the outcome of the verification command
+// of an evaluation, unless it is already labeled.
+func (s *Server) labelFromVerify(t *task.Task, passed bool, command string) {
+	ol := &outcomeLabel{
+		TaskID:    t.ID,
+		Outcome:   v1.OutcomeRejected,
+		Reason:    "verification failed: " + command,
+		Source:    v1.LabelSourceVerify,
+		LabeledAt: time.Now().UTC(),
+	}
+	if passed {
+		ol.Outcome = v1.OutcomeAccepted
+		ol.Reason = "verification passed: " + command
+	}
+	if len(ol.Reason) > 200 {
+		ol.Reason = ol.Reason[:200]
+	}
+	changed, err := s.labels.set(ol, true)
+	if err != nil {
+		slog.Warn("label task", "task", t.ID, "err", err)
+	}
+	if changed {
+		s.notifyTaskChange()
+	}
+}
+
+// handleListLabeledTasks returns the loaded tasks that have a label,
+// optionally filtered by outcome, harness and model.
+func (s *Server) handleListLabeledTasks(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	outcome := v1.TaskOutcome(q.Get("outcome"))
+	switch outcome {
+	case "", v1.OutcomeAccepted, v1.OutcomePartial, v1.OutcomeRejected:
+	default:
+		writeError(w, dto.BadRequest("unknown outcome: "+string(outcome)))
+		return
+	}
+	harness, model := q.Get("harness"), q.Get("model")
+	ownerID := s.draftOwner(r.Context())
+	out := []v1.Task{}
+	s.mu.Lock()
+	for _, e := range s.tasks {
+		if ownerID != "" && e.task.OwnerID != "" && e.task.OwnerID != ownerID {
+			continue
+		}
+		if harness != "" && string(e.task.Harness) != harness {
+			continue
+		}
+		j := s.toJSON(e)
+		if j.Label == nil || (outcome != "" && j.Label.Outcome != outcome) || (model != "" && j.Model != model) {
+			continue
+		}
+		out = append(out, j)
+	}
+	s.mu.Unlock()
+	slices.SortFunc(out, func(a, b v1.Task) int { return a.ID.Compare(b.ID) })
+	writeJSONResponse(w, &out, nil)
+}
+
+// costRow is the harness, model and spend of one task.
+type costRow struct {
+	harness agent.Harness
+	model   string
+	costUSD float64
+}
+
+// getCostReport aggregates spend and outcome labels per harness and model
+// over every task in the logs, using live figures for loaded tasks.
+func (s *Server) getCostReport(_ context.Context, _ *dto.EmptyReq) (*v1.CostReportResp, error) {
+	rows := map[ksid.ID]costRow{}
+	if s.logDir != "" {
+		logs, err := task.LoadLogs(s.logDir)
+		if err != nil {
+			return nil, dto.InternalError("load logs").Wrap(err)
+		}
+		for _, lt := range logs {
+			id, err := ksid.Parse(lt.TaskID)
+			if err != nil {
+				continue
+			}
+			row := costRow{harness: lt.Harness, model: lt.Model}
+			if lt.Result != nil {
+				row.costUSD = lt.Result.CostUSD
+			}
+			rows[id] = row
+		}
+	}
+	s.mu.Lock()
+	for _, e := range s.tasks {
+		snap := e.task.Snapshot()
+		rows[e.task.ID] = costRow{harness: e.task.Harness, model: snap.Model, costUSD: snap.CostUSD}
+	}
+	s.mu.Unlock()
+	resp := costReport(rows, s.labels.all())
+	return &resp, nil
+}
+
+// costReport groups rows by harness and model and joins their labels.
+func costReport(rows map[ksid.ID]costRow, labels map[ksid.ID]outcomeLabel) v1.CostReportResp {
+	type key struct {
+		harness agent.Harness
+		model   string
+	}
+	groups := map[key]*v1.CostGroup{}
+	resp := v1.CostReportResp{Groups: []v1.CostGroup{}}
+	for id, row := range rows {
+		k := key{row.harness, row.model}
+		g := groups[k]
+		if g == nil {
+			g = &v1.CostGroup{Harness: toV1Harness(row.harness), Model: row.model}
+			groups[k] = g
+		}
+		g.Tasks++
+		g.CostUSD += row.costUSD
+		resp.CostUSD += row.costUSD
+		ol, ok := labels[id]
+		if !ok {
+			continue
+		}
+		g.Labeled++
+		switch ol.Outcome {
+		case v1.OutcomeAccepted:
+			g.Accepted++
+		case v1.OutcomePartial:
+			g.Partial++
+		case v1.OutcomeRejected:
+			g.Rejected++
+		}
+	}
+	for _, g := range groups {
+		if g.Labeled > 0 {
+			g.AcceptanceRate = float64(g.Accepted) / float64(g.Labeled)
+		}
+		if g.Accepted > 0 {
+			g.CostPerAccepted = g.CostUSD / float64(g.Accepted)
+		}
+		resp.Groups = append(resp.Groups, *g)
+	}
+	slices.SortFunc(resp.Groups, func(a, b v1.CostGroup) int {
+		return cmp.Or(cmp.Compare(a.Harness, b.Harness), cmp.Compare(a.Model, b.Model))
+	})
+	return resp
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestLabelTask(t *testing.T) {
+	s := newTestServer(t)
+	tk := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "a"}, Harness: agent.Claude}
+	setState(t, tk, task.StateRunning)
+	e := &taskEntry{task: tk, done: make(chan struct{})}
+	s.tasks[tk.ID.String()] = e
+
+	_, err := s.labelTask(t.Context(), e, &v1.LabelTaskReq{Outcome: v1.OutcomeAccepted})
+	var apiErr *dto.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code() != dto.CodeConflict {
+		t.Fatalf("err = %v, want conflict while running", err)
+	}
+
+	setState(t, tk, task.StateWaiting)
+	j, err := s.labelTask(t.Context(), e, &v1.LabelTaskReq{Outcome: v1.OutcomePartial, Reason: "kept the tests"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Label == nil || j.Label.Outcome != v1.OutcomePartial || j.Label.Source != v1.LabelSourceUser || j.Label.Reason != "kept the tests" {
+		t.Fatalf("label = %+v", j.Label)
+	}
+
+	// The verification command does not override a user label.
+	s.labelFromVerify(tk, false, "go test ./...")
+	if l := s.taskLabel(tk.ID); l.Outcome != v1.OutcomePartial {
+		t.Errorf("label overridden: %+v", l)
+	}
+
+	if j, err = s.labelTask(t.Context(), e, &v1.LabelTaskReq{}); err != nil {
+		t.Fatal(err)
+	}
+	if j.Label != nil {
+		t.Errorf("label not removed: %+v", j.Label)
+	}
+	s.labelFromVerify(tk, true, "go test ./...")
+	if l := s.taskLabel(tk.ID); l == nil || l.Outcome != v1.OutcomeAccepted || l.Source != v1.LabelSourceVerify {
+		t.Errorf("verify label = %+v", l)
+	}
+}
+
+func TestLabelStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.json")
+	l, err := openLabelStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := ksid.NewID()
+	if _, err := l.set(&outcomeLabel{TaskID: id, Outcome: v1.OutcomeRejected, Source: v1.LabelSourceUser}, false); err != nil {
+		t.Fatal(err)
+	}
+	if l, err = openLabelStore(path); err != nil {
+		t.Fatal(err)
+	}
+	if ol, ok := l.get(id); !ok || ol.Outcome != v1.OutcomeRejected {
+		t.Errorf("label = %+v, %v", ol, ok)
+	}
+}
+
+func TestHandleListLabeledTasks(t *testing.T) {
+	s := newTestServer(t)
+	add := func(h agent.Harness, outcome v1.TaskOutcome) ksid.ID {
+		tk := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "a"}, Harness: h}
+		setState(t, tk, task.StateWaiting)
+		s.tasks[tk.ID.String()] = &taskEntry{task: tk, done: make(chan struct{})}
+		if outcome != "" {
+			if _, err := s.labels.set(&outcomeLabel{TaskID: tk.ID, Outcome: outcome, Source: v1.LabelSourceUser}, false); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return tk.ID
+	}
+	accepted := add(agent.Claude, v1.OutcomeAccepted)
+	add(agent.Codex, v1.OutcomeAccepted)
+	add(agent.Claude, v1.OutcomeRejected)
+	add(agent.Claude, "")
+
+	for _, tc := range []struct {
+		query string
+		want  int
+	}{
+		{"", 3},
+		{"?outcome=accepted", 2},
+		{"?outcome=accepted&harness=claude", 1},
+	} {
+		w := httptest.NewRecorder()
+		s.handleListLabeledTasks(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/labeled"+tc.query, http.NoBody))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d", tc.query, w.Code)
+		}
+		var got []v1.Task
+		if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != tc.want {
+			t.Errorf("%s: %d tasks, want %d", tc.query, len(got), tc.want)
+		}
+		if tc.want == 1 && got[0].ID != accepted {
+			t.Errorf("%s: got %v", tc.query, got[0].ID)
+		}
+	}
+	w := httptest.NewRecorder()
+	s.handleListLabeledTasks(w, httptest.NewRequest(http.MethodGet, "/api/v1/tasks/labeled?outcome=merged", http.NoBody))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+}
+
+func TestCostReport(t *testing.T) {
+	a, b, c, d := ksid.NewID(), ksid.NewID(), ksid.NewID(), ksid.NewID()
+	rows := map[ksid.ID]costRow{
+		a: {harness: agent.Claude, model: "opus", costUSD: 2},
+		b: {harness: agent.Claude, model: "opus", costUSD: 1},
+		c: {harness: agent.Claude, model: "opus", costUSD: 1},
+		d: {harness: agent.Codex, costUSD: 0.5},
+	}
+	labels := map[ksid.ID]outcomeLabel{
+		a: {Outcome: v1.OutcomeAccepted},
+		b: {Outcome: v1.OutcomeRejected},
+	}
+	got := costReport(rows, labels)
+	if got.CostUSD != 4.5 || len(got.Groups) != 2 {
+		t.Fatalf("report = %+v", got)
+	}
+	want := v1.CostGroup{Harness: v1.HarnessClaude, Model: "opus", Tasks: 3, CostUSD: 4, Labeled: 2, Accepted: 1, Rejected: 1, AcceptanceRate: 0.5, CostPerAccepted: 4}
+	if got.Groups[0] != want {
+		t.Errorf("claude = %+v\nwant %+v", got.Groups[0], want)
+	}
+	if g := got.Groups[1]; g.Harness != v1.HarnessCodex || g.Tasks != 1 || g.Labeled != 0 || g.AcceptanceRate != 0 {
+		t.Errorf("codex = %+v", g)
+	}
+}
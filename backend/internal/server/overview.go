@@ -0,0 +1,126 @@
+// Per-repo overviews written by explain tasks, browsable through the API and
+// seeding the repo's knowledge and map.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+// overviewFile is an overview as stored on disk.
+type overviewFile struct {
+	TaskID      ksid.ID   `json:"taskID"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Content     string    `json:"content"`
+}
+
+// overviewStore holds one JSON file per repo under dir.
+type overviewStore struct {
+	dir string
+}
+
+func (o *overviewStore) path(repo string) string {
+	return filepath.Join(o.dir, url.PathEscape(repo)+".json")
+}
+
+// load returns the overview of repo, or nil if there is none.
+func (o *overviewStore) load(repo string) (*overviewFile, error) {
+	data, err := readSealed(o.path(repo))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f := &overviewFile{}
+	return f, json.Unmarshal(data, f)
+}
+
+func (o *overviewStore) save(repo string, f *overviewFile) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return writeSealedAtomic(o.path(repo), data)
+}
+
+// setTaskHooks sets the callbacks of t.
+func (s *Server) setTaskHooks(t *task.Task) {
+	t.OnResult = s.onTaskResult
+}
+
+// onTaskResult indexes the turn t just completed and, for an explain task,
+// saves its answer.
+func (s *Server) onTaskResult(t *task.Task, rm *agent.ResultMessage) {
+	if t.Kind == task.KindExplain {
+		s.saveOverview(t, rm)
+	}
+	s.indexTask(t)
+}
+
+// saveOverview stores the answer of an explain task as the overview of its
+// primary repo. The build and test commands it lists seed the repo's
+// knowledge when it has none yet.
+func (s *Server) saveOverview(t *task.Task, rm *agent.ResultMessage) {
+	p := t.Primary()
+	if p == nil || rm.IsError {
+		return
+	}
+	if task.OverviewSection(rm.Result, task.OverviewArchitecture) == "" {
+		slog.Warn("overview", "task", t.ID, "repo", p.Name, "err", "answer has no architecture section")
+		return
+	}
+	if err := s.overviews.save(p.Name, &overviewFile{TaskID: t.ID, GeneratedAt: time.Now().UTC(), Content: rm.Result}); err != nil {
+		slog.Warn("overview", "task", t.ID, "repo", p.Name, "err", err)
+		return
+	}
+	slog.Info("overview", "task", t.ID, "repo", p.Name)
+	if s.knowledge == nil {
+		return
+	}
+	if known, err := s.knowledge.load(p.Name); err != nil || known != "" {
+		return
+	}
+	if n, err := s.knowledge.add(p.Name, task.OverviewLearnings(rm.Result)); err != nil {
+		slog.Warn("knowledge", "repo", p.Name, "err", err)
+	} else if n > 0 {
+		slog.Info("knowledge", "task", t.ID, "repo", p.Name, "seeded", n)
+	}
+}
+
+// handleGetRepoOverview returns a repo's overview.
+func (s *Server) handleGetRepoOverview(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		writeError(w, dto.BadRequest("repo is required"))
+		return
+	}
+	if _, ok := s.repoAbsPath(repo); !ok {
+		writeError(w, dto.NotFound("repo"))
+		return
+	}
+	f, err := s.overviews.load(repo)
+	if err != nil {
+		writeError(w, dto.InternalError(err.Error()))
+		return
+	}
+	resp := &v1.RepoOverviewResp{Repo: repo}
+	if f != nil {
+		resp.Content = f.Content
+		resp.TaskID = f.TaskID
+		resp.GeneratedAt = float64(f.GeneratedAt.UnixMilli()) / 1e3
+	}
+	writeJSONResponse(w, resp, nil)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestRepoOverview(t *testing.T) {
+	s := newTestServer(t)
+	s.repos = []repoInfo{{RelPath: "caic", AbsPath: t.TempDir()}}
+	tk := &task.Task{ID: ksid.NewID(), Repos: []task.RepoMount{{Name: "caic"}}, Kind: task.KindExplain}
+	s.setTaskHooks(tk)
+	if tk.OnResult == nil {
+		t.Fatal("OnResult not set for an explain task")
+	}
+
+	// Answers without the expected structure are ignored.
+	tk.OnResult(tk, &agent.ResultMessage{Result: "I looked around."})
+	if f, err := s.overviews.load("caic"); err != nil || f != nil {
+		t.Fatalf("overview = %+v, %v", f, err)
+	}
+
+	const doc = "## Architecture\nA server.\n## Key packages\n- backend/: the server\n## Build and test\n- `make test` runs the tests.\n## Conventions\n- Wrap errors.\n"
+	tk.OnResult(tk, &agent.ResultMessage{Result: doc})
+	w := httptest.NewRecorder()
+	s.handleGetRepoOverview(w, httptest.NewRequest(http.MethodGet, "/api/v1/server/repos/overview?repo=caic", http.NoBody))
+	var resp v1.RepoOverviewResp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Content != doc || resp.TaskID != tk.ID || resp.GeneratedAt == 0 {
+		t.Errorf("resp = %+v", resp)
+	}
+	// The overview seeds the empty knowledge and stands in for the repo map.
+	if got := s.taskKnowledge(tk.Repos); got != "- `make test` runs the tests.\n" {
+		t.Errorf("knowledge = %q", got)
+	}
+	if got := s.taskRepoMap(tk.Repos); got != "- backend/: the server" {
+		t.Errorf("taskRepoMap = %q", got)
+	}
+
+	// Existing knowledge is left alone.
+	if err := s.knowledge.save("caic", "- Use make.\n"); err != nil {
+		t.Fatal(err)
+	}
+	tk.OnResult(tk, &agent.ResultMessage{Result: doc})
+	if got := s.taskKnowledge(tk.Repos); got != "- Use make.\n" {
+		t.Errorf("knowledge = %q", got)
+	}
+
+	// The answer of a coding task isn't an overview.
+	ct := &task.Task{ID: ksid.NewID(), Repos: []task.RepoMount{{Name: "other"}}}
+	s.setTaskHooks(ct)
+	ct.OnResult(ct, &agent.ResultMessage{Result: doc})
+	if f, err := s.overviews.load("other"); err != nil || f != nil {
+		t.Errorf("overview = %+v, %v", f, err)
+	}
+}
@@ -0,0 +1,35 @@
+// Per-repo harness and model policies, enforced when tasks are created.
+package server
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+)
+
+// checkPolicy returns a 403 error when the policy of any of repos forbids
+// running harness with model. Every mounted repository's policy applies,
+// since the harness sees all of their code.
+func (s *Server) checkPolicy(repos []string, harness agent.Harness, model string) error {
+	for _, repo := range repos {
+		p, ok := s.repoPolicies[repo]
+		if !ok {
+			continue
+		}
+		if len(p.Harnesses) > 0 && !slices.Contains(p.Harnesses, string(harness)) {
+			return dto.PolicyViolation(fmt.Sprintf("repo %s may not use harness %s; allowed: %v", repo, harness, p.Harnesses)).
+				WithDetail("repo", repo).
+				WithDetail("harness", string(harness)).
+				WithDetail("allowed", p.Harnesses)
+		}
+		if len(p.Models) > 0 && !slices.Contains(p.Models, model) {
+			return dto.PolicyViolation(fmt.Sprintf("repo %s may not use model %q; allowed: %q", repo, model, p.Models)).
+				WithDetail("repo", repo).
+				WithDetail("model", model).
+				WithDetail("allowed", p.Models)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+)
+
+func TestCheckPolicy(t *testing.T) {
+	s := newTestServer(t)
+	s.repoPolicies = map[string]repoPolicy{
+		"private": {Harnesses: []string{"myagent"}},
+		"pinned":  {Models: []string{"", "sonnet"}},
+	}
+	for _, c := range []struct {
+		repos   []string
+		harness agent.Harness
+		model   string
+		ok      bool
+	}{
+		{nil, agent.Claude, "opus", true},
+		{[]string{"open"}, agent.Claude, "opus", true},
+		{[]string{"private"}, "myagent", "opus", true},
+		{[]string{"private"}, agent.Claude, "", false},
+		{[]string{"open", "private"}, agent.Claude, "", false},
+		{[]string{"pinned"}, agent.Claude, "", true},
+		{[]string{"pinned"}, agent.Claude, "sonnet", true},
+		{[]string{"pinned"}, agent.Claude, "opus", false},
+	} {
+		err := s.checkPolicy(c.repos, c.harness, c.model)
+		if (err == nil) != c.ok {
+			t.Errorf("checkPolicy(%v, %s, %q) = %v", c.repos, c.harness, c.model, err)
+			continue
+		}
+		if err == nil {
+			continue
+		}
+		var apiErr *dto.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusForbidden || apiErr.Code() != dto.CodePolicyViolation {
+			t.Errorf("checkPolicy(%v, %s, %q) = %#v", c.repos, c.harness, c.model, err)
+		}
+	}
+}
+
+func TestRepoPolicies(t *testing.T) {
+	s := &serverSettings{
+		Harnesses: []externalHarness{{Name: "myagent"}},
+		Repos: map[string]repoSettings{
+			"a": {Policy: repoPolicy{Harnesses: []string{"myagent", "codex"}}},
+			"b": {Image: "x"},
+		},
+	}
+	got, err := s.repoPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || len(got["a"].Harnesses) != 2 {
+		t.Errorf("got %+v", got)
+	}
+	s.Repos["c"] = repoSettings{Policy: repoPolicy{Harnesses: []string{"nope"}}}
+	if _, err := s.repoPolicies(); err == nil {
+		t.Error("unknown harness accepted")
+	}
+}
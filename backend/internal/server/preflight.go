@@ -0,0 +1,292 @@
+// Pre-flight checks of task creation, also served as a dry run.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/container"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/caic-xyz/md"
+)
+
+// taskPlan is what the pre-flight checks of a task creation resolve.
+type taskPlan struct {
+	primary *task.Runner
+	extras  []*task.Runner
+	harness agent.Harness
+	backend agent.Backend
+	kind    task.Kind
+	prompt  agent.Prompt
+	image   string
+}
+
+// preflightReport records the outcome of each pre-flight check.
+type preflightReport struct {
+	checks []v1.PreflightCheck
+	err    error // First failure.
+}
+
+func (p *preflightReport) add(name string, err error) {
+	c := v1.PreflightCheck{Name: name, Status: v1.PreflightOK}
+	if err != nil {
+		c.Status = v1.PreflightFailed
+		c.Message = err.Error()
+		var apiErr *dto.APIError
+		if errors.As(err, &apiErr) {
+			c.Code = string(apiErr.Code())
+		}
+		if p.err == nil {
+			p.err = err
+		}
+	}
+	p.checks = append(p.checks, c)
+}
+
+func (p *preflightReport) warn(name, msg string) {
+	p.checks = append(p.checks, v1.PreflightCheck{Name: name, Status: v1.PreflightWarning, Message: msg})
+}
+
+func (p *preflightReport) skip(name, why string) {
+	p.checks = append(p.checks, v1.PreflightCheck{Name: name, Status: v1.PreflightSkipped, Message: why})
+}
+
+// preflight runs the checks of a task creation in order. Task creation stops
+// at the first failure. A dry run records every check, skipping those that
+// depend on a failed one, and also fetches the base branches and looks for
+// the harness configuration, which task creation leaves to provisioning.
+func (s *Server) preflight(ctx context.Context, req *v1.CreateTaskReq, dryRun bool) (*taskPlan, *preflightReport) {
+	rep := &preflightReport{}
+	plan := &taskPlan{}
+	stop := func() bool { return !dryRun && rep.err != nil }
+
+	rep.add(v1.PreflightRepos, s.resolveRunners(req, plan))
+	if stop() {
+		return plan, rep
+	}
+	if dryRun {
+		s.checkBaseBranches(ctx, req, plan, rep)
+	}
+
+	plan.harness = toAgentHarness(req.Harness)
+	if plan.primary != nil {
+		rep.add(v1.PreflightHarness, s.checkHarness(req, plan))
+	} else {
+		rep.skip(v1.PreflightHarness, "no runner for the repos")
+	}
+	if stop() {
+		return plan, rep
+	}
+	if dryRun {
+		checkCredentials(plan.harness, rep)
+	}
+
+	repoNames := make([]string, len(req.Repos))
+	for i, rs := range req.Repos {
+		repoNames[i] = rs.Name
+	}
+	rep.add(v1.PreflightPolicy, s.checkPolicy(repoNames, plan.harness, req.Model))
+	if stop() {
+		return plan, rep
+	}
+
+	if req.GPU {
+		var err error
+		if s.gpus == 0 {
+			err = dto.BadRequest("no GPU available on this server")
+		}
+		rep.add(v1.PreflightGPU, err)
+		if stop() {
+			return plan, rep
+		}
+	} else if dryRun {
+		rep.skip(v1.PreflightGPU, "no GPU requested")
+	}
+
+	var err error
+	if e := s.runtimeCaps.Check(container.FeatureBuild); e != nil {
+		err = dto.BadRequest(e.Error())
+	}
+	rep.add(v1.PreflightRuntime, err)
+	if stop() {
+		return plan, rep
+	}
+
+	plan.image = req.Image
+	if plan.image == "" && len(req.Repos) > 0 {
+		plan.image = s.repoImages[req.Repos[0].Name]
+	}
+	if plan.image != "" {
+		rep.add(v1.PreflightImage, s.checkTaskImage(ctx, plan.image))
+		if stop() {
+			return plan, rep
+		}
+	} else if dryRun {
+		rep.skip(v1.PreflightImage, "default image")
+	}
+
+	rep.add(v1.PreflightSpending, s.checkSpending(plan.harness, toTaskPriority(req.Priority)))
+	if stop() {
+		return plan, rep
+	}
+	rep.add(v1.PreflightRepoLimits, s.checkRepoLimits(ctx, repoNames, toTaskPriority(req.Priority)))
+	return plan, rep
+}
+
+// resolveRunners sets the runners of the primary and extra repos of req.
+func (s *Server) resolveRunners(req *v1.CreateTaskReq, plan *taskPlan) error {
+	name := ""
+	if len(req.Repos) > 0 {
+		name = req.Repos[0].Name
+	}
+	r, ok := s.runners[name]
+	if !ok {
+		if name == "" {
+			return dto.InternalError("no-repo runner not available")
+		}
+		return dto.BadRequest("unknown repo: " + name)
+	}
+	plan.primary = r
+	for _, rs := range req.Repos[min(1, len(req.Repos)):] {
+		er, ok := s.runners[rs.Name]
+		if !ok {
+			return dto.BadRequest("unknown extra repo: " + rs.Name)
+		}
+		plan.extras = append(plan.extras, er)
+	}
+	return nil
+}
+
+// checkHarness verifies that the harness of req supports its model, images
+// and kind, and sets the backend and initial prompt of plan.
+func (s *Server) checkHarness(req *v1.CreateTaskReq, plan *taskPlan) error {
+	backend, ok := plan.primary.Backends[plan.harness]
+	if !ok {
+		return dto.BadRequest("unknown harness: " + string(req.Harness))
+	}
+	plan.backend = backend
+	if req.Model != "" && !slices.Contains(backend.Models(), req.Model) {
+		return dto.BadRequest("unsupported model for " + string(req.Harness) + ": " + req.Model)
+	}
+	if len(req.InitialPrompt.Images) > 0 && !backend.SupportsImages() {
+		return dto.BadRequest(string(req.Harness) + " does not support images")
+	}
+	plan.kind = toTaskKind(req.Kind)
+	plan.prompt = v1PromptToAgent(req.InitialPrompt)
+	if plan.kind == task.KindExplain {
+		if !backend.SupportsReadOnly() {
+			return dto.BadRequest(string(req.Harness) + " does not support read-only tasks")
+		}
+		if plan.prompt.Text == "" {
+			plan.prompt.Text = task.ExplainPrompt
+		}
+	}
+	return nil
+}
+
+// checkTaskImage verifies that the container image of a task can be used.
+func (s *Server) checkTaskImage(ctx context.Context, image string) error {
+	if err := s.checkImage(ctx, image); err != nil {
+		slog.WarnContext(ctx, "image check failed", "image", image, "err", err)
+		return dto.BadRequest("image not available").WithDetail("image", image)
+	}
+	return nil
+}
+
+// checkBaseBranches fetches origin in each repo of req and resolves its base
+// branch.
+func (s *Server) checkBaseBranches(ctx context.Context, req *v1.CreateTaskReq, plan *taskPlan, rep *preflightReport) {
+	if len(req.Repos) == 0 {
+		rep.skip(v1.PreflightBaseBranch, "no repository")
+		return
+	}
+	if plan.primary == nil || len(plan.extras) != len(req.Repos)-1 {
+		rep.skip(v1.PreflightBaseBranch, "no runner for the repos")
+		return
+	}
+	runners := append([]*task.Runner{plan.primary}, plan.extras...)
+	var errs []string
+	for i, rs := range req.Repos {
+		if _, err := runners[i].ResolveBase(ctx, rs.BaseBranch); err != nil {
+			errs = append(errs, rs.Name+": "+err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		rep.add(v1.PreflightBaseBranch, dto.BadRequest("base branch unavailable: "+strings.Join(errs, "; ")))
+		return
+	}
+	rep.add(v1.PreflightBaseBranch, nil)
+}
+
+// checkCredentials looks for the configuration directory md mounts into the
+// container for the harness, where it keeps its credentials. Whether they
+// are still valid is only known once the harness runs, so a missing
+// directory is a warning.
+func checkCredentials(h agent.Harness, rep *preflightReport) {
+	found, paths, ok := findHarnessConfig(h)
+	switch {
+	case !ok:
+		rep.skip(v1.PreflightCredentials, "harness configuration unknown")
+	case found == "":
+		rep.warn(v1.PreflightCredentials, missingConfigMessage(h, paths))
+	default:
+		rep.add(v1.PreflightCredentials, nil)
+	}
+}
+
+// findHarnessConfig returns the first host configuration path of h that
+// exists, if any, and the paths looked in. ok is false when md doesn't know
+// the harness.
+func findHarnessConfig(h agent.Harness) (found string, paths []string, ok bool) {
+	mh, ok := mdHarnesses[h]
+	if !ok {
+		return "", nil, false
+	}
+	paths = harnessConfigPaths(md.HarnessMounts[mh])
+	for _, p := range paths {
+		if _, err := os.Stat(p); err == nil {
+			return p, paths, true
+		}
+	}
+	return "", paths, true
+}
+
+func missingConfigMessage(h agent.Harness, paths []string) string {
+	return "no " + string(h) + " configuration found in " + strings.Join(paths, " or ") + "; log in to " + string(h) + " on the host"
+}
+
+// harnessConfigPaths returns the host paths of the configuration of a
+// harness.
+func harnessConfigPaths(p md.AgentPaths) []string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" {
+		config = filepath.Join(home, ".config")
+	}
+	var out []string
+	for _, h := range p.HomePaths {
+		out = append(out, filepath.Join(home, h))
+	}
+	for _, c := range p.XDGConfigPaths {
+		out = append(out, filepath.Join(config, c))
+	}
+	return out
+}
+
+// validateTask runs the pre-flight checks of a task creation without
+// creating anything.
+func (s *Server) validateTask(ctx context.Context, req *v1.CreateTaskReq) (*v1.ValidateTaskResp, error) {
+	_, rep := s.preflight(ctx, req, true)
+	return &v1.ValidateTaskResp{Ready: rep.err == nil, Checks: rep.checks}, nil
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestValidateTask(t *testing.T) {
+	newServer := func(t *testing.T) *Server {
+		return &Server{
+			ctx: t.Context(),
+			runners: map[string]*task.Runner{
+				"": {
+					Dir:      t.TempDir(),
+					Backends: map[agent.Harness]agent.Backend{"stub": stubBackend{}},
+				},
+			},
+			tasks:   make(map[string]*taskEntry),
+			changed: make(chan struct{}),
+			prefs:   newTestPrefs(t),
+		}
+	}
+	statuses := func(resp *v1.ValidateTaskResp) map[string]v1.PreflightStatus {
+		m := make(map[string]v1.PreflightStatus, len(resp.Checks))
+		for _, c := range resp.Checks {
+			m[c.Name] = c.Status
+		}
+		return m
+	}
+
+	t.Run("Ready", func(t *testing.T) {
+		s := newServer(t)
+		resp, err := s.validateTask(t.Context(), &v1.CreateTaskReq{InitialPrompt: v1.Prompt{Text: "test"}, Harness: "stub"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !resp.Ready {
+			t.Fatalf("Ready = false: %+v", resp.Checks)
+		}
+		got := statuses(resp)
+		want := map[string]v1.PreflightStatus{
+			v1.PreflightRepos:       v1.PreflightOK,
+			v1.PreflightBaseBranch:  v1.PreflightSkipped,
+			v1.PreflightHarness:     v1.PreflightOK,
+			v1.PreflightCredentials: v1.PreflightSkipped,
+			v1.PreflightPolicy:      v1.PreflightOK,
+			v1.PreflightGPU:         v1.PreflightSkipped,
+			v1.PreflightRuntime:     v1.PreflightOK,
+			v1.PreflightImage:       v1.PreflightSkipped,
+			v1.PreflightSpending:    v1.PreflightOK,
+			v1.PreflightRepoLimits:  v1.PreflightOK,
+		}
+		for name, st := range want {
+			if got[name] != st {
+				t.Errorf("%s = %q, want %q", name, got[name], st)
+			}
+		}
+		if len(s.tasks) != 0 {
+			t.Errorf("validation created %d tasks", len(s.tasks))
+		}
+	})
+
+	t.Run("Failures", func(t *testing.T) {
+		s := newServer(t)
+		resp, err := s.validateTask(t.Context(), &v1.CreateTaskReq{
+			InitialPrompt: v1.Prompt{Text: "test"},
+			Repos:         []v1.RepoSpec{{Name: "nope"}},
+			Harness:       "stub",
+			GPU:           true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Ready {
+			t.Fatal("Ready = true")
+		}
+		got := statuses(resp)
+		for name, st := range map[string]v1.PreflightStatus{
+			v1.PreflightRepos:      v1.PreflightFailed,
+			v1.PreflightBaseBranch: v1.PreflightSkipped,
+			v1.PreflightHarness:    v1.PreflightSkipped,
+			v1.PreflightGPU:        v1.PreflightFailed,
+			v1.PreflightSpending:   v1.PreflightOK,
+		} {
+			if got[name] != st {
+				t.Errorf("%s = %q, want %q", name, got[name], st)
+			}
+		}
+		if c := resp.Checks[0]; c.Code != string(dto.CodeBadRequest) || c.Message != "unknown repo: nope" {
+			t.Errorf("repos check = %+v", c)
+		}
+	})
+}
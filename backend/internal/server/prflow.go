@@ -33,6 +33,14 @@ func (s *Server) startPRFlow(ctx context.Context, entry *taskEntry, f forge.Forg
 	if entry.result != nil {
 		body = entry.result.AgentResult
 	}
+	if files, err := s.structuralDiff(ctx, t, ""); err != nil {
+		slog.Warn("PR description: structural diff", "task", t.ID, "err", err)
+	} else if sum := prSummary(files); sum != "" {
+		if body != "" {
+			body += "\n\n"
+		}
+		body += sum
+	}
 	pr, err := f.CreatePR(ctx, info.ForgeOwner, info.ForgeRepo, branch, baseBranch, title, body)
 	if err != nil {
 		return 0, err
@@ -215,9 +223,17 @@ func (s *Server) CreateTask(ctx context.Context, req bot.TaskRequest) (string, e
 	if !ok {
 		return "", fmt.Errorf("runner not found for repo %s", req.Repo)
 	}
-	// Pick harness: prefer agent.Claude if available, otherwise take the first one.
+	// Pick harness: the first one the repo policy allows, else prefer
+	// agent.Claude if available, otherwise take the first one.
 	var harness agent.Harness
-	if _, ok := runner.Backends[agent.Claude]; ok {
+	if allowed := s.repoPolicies[req.Repo].Harnesses; len(allowed) > 0 {
+		for _, h := range allowed {
+			if _, ok := runner.Backends[agent.Harness(h)]; ok {
+				harness = agent.Harness(h)
+				break
+			}
+		}
+	} else if _, ok := runner.Backends[agent.Claude]; ok {
 		harness = agent.Claude
 	} else {
 		for h := range runner.Backends {
@@ -228,16 +244,21 @@ func (s *Server) CreateTask(ctx context.Context, req bot.TaskRequest) (string, e
 	if harness == "" {
 		return "", fmt.Errorf("no backend available for repo %s", req.Repo)
 	}
+	if err := s.checkPolicy([]string{req.Repo}, harness, ""); err != nil {
+		return "", err
+	}
 	t := &task.Task{
 		ID:            ksid.NewID(),
 		InitialPrompt: agent.Prompt{Text: req.Prompt},
 		Repos:         []task.RepoMount{{Name: req.Repo, GitRoot: runner.Dir}},
 		Harness:       harness,
 		StartedAt:     time.Now().UTC(),
-		Provider:      s.provider,
+		Titles:        s.titles,
 		OwnerID:       req.OwnerID,
 		ForgeIssue:    req.IssueNumber,
+		Scrub:         s.scrubber,
 	}
+	t.InitialPrompt = t.ScrubPrompt(t.InitialPrompt)
 	if req.IssueNumber > 0 {
 		// Set forge owner/repo so ListPendingBotTasks can resolve the commenter.
 		for _, ri := range s.repos {
@@ -247,8 +268,8 @@ func (s *Server) CreateTask(ctx context.Context, req bot.TaskRequest) (string, e
 			}
 		}
 	}
-	t.SetTitle(req.Prompt)
-	go t.GenerateTitle(s.ctx) //nolint:contextcheck // fire-and-forget; must outlive request
+	t.SetTitle(task.LocalTitle(t.InitialPrompt.Text))
+	s.titles.Enqueue(t)
 	entry := &taskEntry{task: t, done: make(chan struct{})}
 	s.mu.Lock()
 	s.tasks[t.ID.String()] = entry
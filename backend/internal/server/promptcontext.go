@@ -0,0 +1,107 @@
+// Assembly of the context attached to the initial prompt of a task.
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+const (
+	// promptContextTimeout bounds gathering the context of a new task.
+	promptContextTimeout = time.Minute
+	// contextBudgetShare is the inverse of the share of the model's context
+	// window the injected material may take, leaving room for the work.
+	contextBudgetShare = 4
+	// defaultContextBudget is the budget in tokens when the model's context
+	// window is unknown.
+	defaultContextBudget = 32_000
+)
+
+// contextBudget returns the token budget of the material injected in the
+// initial prompt for a model with a context window of window tokens.
+func contextBudget(window int) int {
+	if window <= 0 {
+		return defaultContextBudget
+	}
+	return window / contextBudgetShare
+}
+
+// promptContext gathers the material referenced by c for the initial prompt.
+// Files are read from repo. A reference that can't be
+// resolved fails the task creation, so that a task never silently starts
+// without context the user asked for.
+func (s *Server) promptContext(ctx context.Context, c *v1.PromptContext, repo *v1.RepoSpec) ([]task.ContextSection, error) {
+	if c == nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, promptContextTimeout)
+	defer cancel()
+	var sections []task.ContextSection
+	for _, id := range c.Tasks {
+		sec, err := s.priorTaskContext(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, sec)
+	}
+	for _, u := range c.URLs {
+		title, content, err := fetchPage(ctx, u)
+		if err != nil {
+			return nil, dto.BadRequest("failed to fetch context url: "+err.Error()).WithDetail("url", u)
+		}
+		sections = append(sections, task.ContextSection{Kind: "url", Source: u, Title: title, Content: content})
+	}
+	for _, p := range c.Files {
+		data, err := s.runners[repo.Name].ShowBaseFile(ctx, repo.BaseBranch, p)
+		if err != nil {
+			return nil, dto.BadRequest("context file not found").WithDetail("path", p)
+		}
+		if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+			return nil, dto.BadRequest("context file is not text").WithDetail("path", p)
+		}
+		sections = append(sections, task.ContextSection{Kind: "file", Source: p, Content: string(data)})
+	}
+	return sections, nil
+}
+
+// priorTaskContext returns the stored summary of the task id, or the gist of
+// its conversation when it was never summarized. No summary is generated so
+// that creating a task stays quick. Tasks of other users are reported as not
+// found.
+func (s *Server) priorTaskContext(ctx context.Context, id ksid.ID) (task.ContextSection, error) {
+	entry, err := s.lookupTask(ctx, id.String())
+	if err != nil {
+		return task.ContextSection{}, dto.BadRequest("context task not found").WithDetail("id", id.String())
+	}
+	sec := task.ContextSection{Kind: "task", Source: id.String(), Title: entry.task.Title()}
+	if s.summaries != nil {
+		sm, err := s.summaries.load(id.String())
+		if err != nil {
+			slog.Warn("summary", "task", id, "err", err)
+		} else if sm != nil {
+			sec.Content = sm.Text
+			return sec, nil
+		}
+	}
+	var b strings.Builder
+	b.WriteString("Request:\n" + entry.task.InitialPrompt.Text + "\n")
+	msgs := entry.task.Messages()
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if r, ok := msgs[i].(*agent.ResultMessage); ok && r.Result != "" {
+			b.WriteString("\nFinal answer:\n" + r.Result + "\n")
+			break
+		}
+	}
+	sec.Content = b.String()
+	return sec, nil
+}
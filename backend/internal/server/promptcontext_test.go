@@ -0,0 +1,75 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestPromptContext(t *testing.T) {
+	s := newTestServer(t)
+	s.summaries = &summaryStore{dir: t.TempDir()}
+	prior := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "add a flag"}}
+	prior.RestoreMessages([]agent.Message{&agent.TextMessage{Text: "ok"}, &agent.ResultMessage{Result: "Added --verbose."}})
+	s.tasks[prior.ID.String()] = &taskEntry{task: prior, done: make(chan struct{})}
+
+	got, err := s.promptContext(t.Context(), &v1.PromptContext{Tasks: []ksid.ID{prior.ID}}, nil)
+	if err != nil || len(got) != 1 {
+		t.Fatal(got, err)
+	}
+	if c := got[0].Content; !strings.Contains(c, "Request:\nadd a flag\n") || !strings.Contains(c, "Final answer:\nAdded --verbose.") {
+		t.Errorf("promptContext() =\n%s", c)
+	}
+
+	// A stored summary is preferred.
+	if err := s.summaries.save(prior.ID.String(), &task.Summary{Text: "It added a verbose flag.", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = s.promptContext(t.Context(), &v1.PromptContext{Tasks: []ksid.ID{prior.ID}}, nil); err != nil || got[0].Content != "It added a verbose flag." {
+		t.Errorf("promptContext() = %q, %v", got, err)
+	}
+
+	if _, err := s.promptContext(t.Context(), &v1.PromptContext{Tasks: []ksid.ID{ksid.NewID()}}, nil); err == nil {
+		t.Error("expected error for an unknown task")
+	}
+}
+
+func TestPromptContextOwner(t *testing.T) {
+	s := newTestServer(t)
+	store, err := auth.Open(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.authStore = store
+	prior := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "rotate the secret"}, OwnerID: "a"}
+	s.tasks[prior.ID.String()] = &taskEntry{task: prior, done: make(chan struct{})}
+	c := &v1.PromptContext{Tasks: []ksid.ID{prior.ID}}
+	if _, err := s.promptContext(auth.NewContext(t.Context(), &auth.User{ID: "a"}), c, nil); err != nil {
+		t.Errorf("owner: %v", err)
+	}
+	// Another user's task is reported like an unknown one.
+	_, err = s.promptContext(auth.NewContext(t.Context(), &auth.User{ID: "b"}), c, nil)
+	var apiErr *dto.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusBadRequest || !strings.Contains(err.Error(), "context task not found") {
+		t.Errorf("other user: %v", err)
+	}
+}
+
+func TestContextBudget(t *testing.T) {
+	if got := contextBudget(200_000); got != 50_000 {
+		t.Errorf("contextBudget(200000) = %d", got)
+	}
+	if got := contextBudget(0); got != defaultContextBudget {
+		t.Errorf("contextBudget(0) = %d", got)
+	}
+}
@@ -0,0 +1,229 @@
+// Reverse-proxy support: serving under a URL prefix and forwarded headers.
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// basePathRe matches a URL prefix: slash-separated segments of unreserved
+// characters, without a trailing slash.
+var basePathRe = regexp.MustCompile(`^(/[A-Za-z0-9._~-]+)+$`)
+
+// cleanBasePath returns p without its trailing slash, "" for the root.
+func cleanBasePath(p string) (string, error) {
+	p = strings.TrimSuffix(p, "/")
+	if p == "" {
+		return "", nil
+	}
+	if !basePathRe.MatchString(p) {
+		return "", fmt.Errorf("invalid base path %q", p)
+	}
+	for seg := range strings.SplitSeq(p[1:], "/") {
+		if seg == "." || seg == ".." {
+			return "", fmt.Errorf("invalid base path %q", p)
+		}
+	}
+	return p, nil
+}
+
+// basePathMiddleware serves next under the base URL prefix, which is
+// stripped before routing. The prefix itself redirects to its trailing slash
+// form so that relative URLs in the frontend resolve; anything else is not
+// found.
+func basePathMiddleware(base string, next http.Handler) http.Handler {
+	strip := http.StripPrefix(base, next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch p := r.URL.Path; {
+		case strings.HasPrefix(p, base+"/"):
+			strip.ServeHTTP(w, r)
+		case p == base:
+			target := base + "/"
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// rebaseIndex returns dist with index.html rewritten to declare base as its
+// <base href>. The frontend uses relative URLs for its assets, API calls and
+// SSE streams, so they all resolve under the prefix.
+func rebaseIndex(dist fs.FS, base string) (fs.FS, error) {
+	f, err := dist.Open("index.html.br")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	raw, err := io.ReadAll(brotli.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("index.html: %w", err)
+	}
+	// The tag is left open so that both <base href="/"> and the self-closing
+	// form written in frontend/index.html match.
+	root := []byte(`<base href="/"`)
+	tag := []byte(`<base href="` + html.EscapeString(base) + `/"`)
+	if bytes.Contains(raw, root) {
+		raw = bytes.Replace(raw, root, tag, 1)
+	} else if i := bytes.Index(raw, []byte("<head>")); i >= 0 {
+		raw = bytes.Join([][]byte{raw[:i+len("<head>")], tag, []byte(">"), raw[i+len("<head>"):]}, nil)
+	} else {
+		return nil, errors.New("index.html: no <head>")
+	}
+	var buf bytes.Buffer
+	w := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	if _, err := w.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &overlayFS{FS: dist, files: map[string][]byte{"index.html.br": buf.Bytes()}}, nil
+}
+
+// overlayFS serves the in-memory files before falling back to the embedded
+// FS. A precompressed sibling of an overridden file would be stale, so it is
+// hidden.
+type overlayFS struct {
+	fs.FS
+	files map[string][]byte
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if data, ok := o.files[name]; ok {
+		return &memFile{Reader: bytes.NewReader(data), name: name, size: int64(len(data))}, nil
+	}
+	if base, ok := strings.CutSuffix(name, ".gz"); ok {
+		if _, ok := o.files[base+".br"]; ok {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+		}
+	}
+	return o.FS.Open(name)
+}
+
+// memFile is an in-memory fs.File that can be seeked for http.ServeContent.
+type memFile struct {
+	*bytes.Reader
+	name string
+	size int64
+}
+
+func (m *memFile) Stat() (fs.FileInfo, error) { return m, nil }
+func (m *memFile) Close() error               { return nil }
+func (m *memFile) Name() string               { return m.name }
+func (m *memFile) Size() int64                { return m.size }
+func (m *memFile) Mode() fs.FileMode          { return 0o444 }
+func (m *memFile) ModTime() time.Time         { return time.Time{} }
+func (m *memFile) IsDir() bool                { return false }
+func (m *memFile) Sys() any                   { return nil }
+
+// parseTrustedProxies parses a comma-separated list of IP addresses and
+// CIDR prefixes.
+func parseTrustedProxies(s string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for v := range strings.SplitSeq(s, ",") {
+		if v = strings.TrimSpace(v); v == "" {
+			continue
+		}
+		if strings.Contains(v, "/") {
+			p, err := netip.ParsePrefix(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+	}
+	return out, nil
+}
+
+// isTrustedProxy reports whether a is in one of the prefixes.
+func isTrustedProxy(trusted []netip.Prefix, a netip.Addr) bool {
+	a = a.Unmap()
+	for _, p := range trusted {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedProtoKey is the context key of the scheme a trusted proxy
+// received the request with.
+type forwardedProtoKey struct{}
+
+// forwardedMiddleware applies the X-Forwarded-For, X-Real-IP,
+// X-Forwarded-Proto and X-Forwarded-Host headers set by a trusted proxy: the
+// request's RemoteAddr becomes the client address and its Host the host the
+// client asked for. The headers are removed afterwards, and from requests
+// not coming from a trusted proxy, so that nothing downstream can be fooled
+// by a spoofed header.
+func forwardedMiddleware(trusted []netip.Prefix, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := r.Header
+		peer, err := netip.ParseAddrPort(r.RemoteAddr)
+		if err == nil && isTrustedProxy(trusted, peer.Addr()) {
+			client := peer.Addr()
+			hops := strings.Split(h.Get("X-Forwarded-For"), ",")
+			if len(hops) == 1 && strings.TrimSpace(hops[0]) == "" {
+				hops = []string{h.Get("X-Real-IP")}
+			}
+			// Walk from the closest hop, skipping the trusted proxies.
+			for i := len(hops) - 1; i >= 0; i-- {
+				a, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+				if err != nil {
+					break
+				}
+				client = a.Unmap()
+				if !isTrustedProxy(trusted, client) {
+					break
+				}
+			}
+			ctx := r.Context()
+			proto, _, _ := strings.Cut(h.Get("X-Forwarded-Proto"), ",")
+			if proto = strings.ToLower(strings.TrimSpace(proto)); proto == "http" || proto == "https" {
+				ctx = context.WithValue(ctx, forwardedProtoKey{}, proto)
+			}
+			r = r.WithContext(ctx)
+			r.RemoteAddr = net.JoinHostPort(client.String(), "0")
+			if host, _, _ := strings.Cut(h.Get("X-Forwarded-Host"), ","); strings.TrimSpace(host) != "" {
+				r.Host = strings.TrimSpace(host)
+			}
+		}
+		for _, k := range []string{"X-Forwarded-For", "X-Real-IP", "X-Forwarded-Proto", "X-Forwarded-Host"} {
+			h.Del(k)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestIsHTTPS reports whether the client connected over https, either
+// directly or through a trusted proxy.
+func requestIsHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	proto, _ := r.Context().Value(forwardedProtoKey{}).(string)
+	return proto == "https"
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestCleanBasePath(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"", ""},
+		{"/", ""},
+		{"/caic", "/caic"},
+		{"/caic/", "/caic"},
+		{"/tools/caic", "/tools/caic"},
+	} {
+		got, err := cleanBasePath(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("cleanBasePath(%q) = %q, %v; want %q", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"caic", "/a//b", "/../x", "/a b", `/"x`} {
+		if _, err := cleanBasePath(in); err == nil {
+			t.Errorf("cleanBasePath(%q) expected error", in)
+		}
+	}
+}
+
+func TestBasePathMiddleware(t *testing.T) {
+	h := basePathMiddleware("/caic", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	for _, tc := range []struct {
+		path     string
+		code     int
+		body     string
+		location string
+	}{
+		{"/caic/api/v1/tasks", http.StatusOK, "/api/v1/tasks", ""},
+		{"/caic/", http.StatusOK, "/", ""},
+		{"/caic?x=1", http.StatusMovedPermanently, "", "/caic/?x=1"},
+		{"/api/v1/tasks", http.StatusNotFound, "", ""},
+		{"/caicx/", http.StatusNotFound, "", ""},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, http.NoBody))
+		if w.Code != tc.code {
+			t.Errorf("%s: status = %d, want %d", tc.path, w.Code, tc.code)
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Errorf("%s: routed to %q, want %q", tc.path, w.Body.String(), tc.body)
+		}
+		if got := w.Header().Get("Location"); got != tc.location {
+			t.Errorf("%s: Location = %q, want %q", tc.path, got, tc.location)
+		}
+	}
+}
+
+func TestRebaseIndex(t *testing.T) {
+	for _, tc := range []struct{ name, in, want string }{
+		{"BaseTag", `<html><head><base href="/" /></head></html>`, `<html><head><base href="/caic/" /></head></html>`},
+		{"NoBaseTag", `<html><head><title>x</title></head></html>`, `<html><head><base href="/caic/"><title>x</title></head></html>`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dist := testFS(t)
+			dist["index.html.br"].Data = brCompress(t, []byte(tc.in))
+			dist["index.html.gz"] = dist["favicon.svg.br"]
+			out, err := rebaseIndex(dist, "/caic")
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw, err := fs.ReadFile(out, "index.html.br")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(brotli.NewReader(bytes.NewReader(raw)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("index.html = %q, want %q", got, tc.want)
+			}
+			// The stale gzip variant is hidden, other files pass through.
+			if _, err := fs.Stat(out, "index.html.gz"); err == nil {
+				t.Error("index.html.gz should be hidden")
+			}
+			if _, err := fs.Stat(out, "assets/app.js.br"); err != nil {
+				t.Error(err)
+			}
+
+			// The static handler serves and transcodes the rewritten file.
+			req := httptest.NewRequest(http.MethodGet, "/task/@x", http.NoBody)
+			w := httptest.NewRecorder()
+			newStaticHandler(out)(w, req)
+			if w.Body.String() != tc.want {
+				t.Errorf("served %q, want %q", w.Body.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	got, err := parseTrustedProxies(" 10.0.0.1, 192.168.0.0/16 ,::1,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.1/32"),
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("::1/128"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if _, err := parseTrustedProxies("nope"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestForwardedMiddleware(t *testing.T) {
+	trusted, err := parseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type seen struct {
+		remote, host string
+		https        bool
+		xff          string
+	}
+	var got seen
+	h := forwardedMiddleware(trusted, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = seen{remote: r.RemoteAddr, host: r.Host, https: requestIsHTTPS(r), xff: r.Header.Get("X-Forwarded-For")}
+	}))
+	for _, tc := range []struct {
+		name    string
+		remote  string
+		headers map[string]string
+		want    seen
+	}{
+		{
+			"Trusted",
+			"10.0.0.2:1234",
+			map[string]string{"X-Forwarded-For": "203.0.113.7", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "caic.example.com"},
+			seen{remote: "203.0.113.7:0", host: "caic.example.com", https: true},
+		},
+		{
+			"ChainSkipsTrustedHops",
+			"10.0.0.2:1234",
+			map[string]string{"X-Forwarded-For": "198.51.100.1, 203.0.113.7, 10.0.0.3"},
+			seen{remote: "203.0.113.7:0", host: "example.com"},
+		},
+		{
+			"RealIP",
+			"10.0.0.2:1234",
+			map[string]string{"X-Real-IP": "2001:db8::1"},
+			seen{remote: "[2001:db8::1]:0", host: "example.com"},
+		},
+		{
+			"Untrusted",
+			"203.0.113.9:1234",
+			map[string]string{"X-Forwarded-For": "10.0.0.5", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example.com"},
+			seen{remote: "203.0.113.9:1234", host: "example.com"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.RemoteAddr = tc.remote
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Fixture bundle recording of finished tasks, for replay and regression tests.
+package server
+
+import (
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/fixture"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// recordTask writes the fixture bundle of t into s.recordDir/<task ID>.
+func (s *Server) recordTask(t *task.Task) {
+	matches, err := filepath.Glob(filepath.Join(s.logDir, t.ID.String()+"-*.jsonl"))
+	if err != nil || len(matches) == 0 {
+		slog.Warn("record fixture: no log", "task", t.ID, "err", err)
+		return
+	}
+	b, err := fixture.FromLog(matches[0])
+	if err != nil {
+		slog.Warn("record fixture", "task", t.ID, "err", err)
+		return
+	}
+	b.Events = fixtureEvents(b)
+	dir := filepath.Join(s.recordDir, t.ID.String())
+	if err := b.Write(dir); err != nil {
+		slog.Warn("record fixture", "task", t.ID, "err", err)
+		return
+	}
+	slog.Info("recorded fixture", "task", t.ID, "dir", dir, "lines", len(b.Wire), "events", len(b.Events))
+}
+
+// fixtureEvents converts the wire lines of b to the events streamed for them.
+// Timestamps and tool durations are zeroed so that the result only depends
+// on the lines, the parser and the converter.
+func fixtureEvents(b *fixture.Bundle) [][]byte {
+	parse := task.ParseFunc(b.Meta.Harness)
+	tt := newToolTimingTracker(b.Meta.Harness)
+	out := [][]byte{}
+	for _, line := range b.Wire {
+		msgs, err := parse(line)
+		if err != nil {
+			// Skipped, as when loading the log.
+			continue
+		}
+		for _, msg := range msgs {
+			events := tt.convertMessage(msg, time.Time{})
+			for i := range events {
+				events[i].Ts = 0
+				data, err := marshalEvent(&events[i])
+				if err != nil {
+					slog.Warn("marshal fixture event", "err", err)
+					continue
+				}
+				out = append(out, data)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/fixture"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+var updateFixtures = flag.Bool("update-fixtures", false, "rewrite the events of the bundled fixtures from the current parsers")
+
+func TestRecord(t *testing.T) {
+	t.Run("Bundled", func(t *testing.T) {
+		// A diff here means a parser or the converter changed what clients
+		// see for a recorded session. Review it, then rerun with
+		// -update-fixtures.
+		bundles, err := fixture.Bundled()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(bundles) == 0 {
+			t.Fatal("no bundles")
+		}
+		for _, b := range bundles {
+			t.Run(b.Name, func(t *testing.T) {
+				got := fixtureEvents(b)
+				if *updateFixtures {
+					b.Events = got
+					if err := b.Write(filepath.Join("..", "fixture", "bundles", b.Name)); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				for i := range max(len(got), len(b.Events)) {
+					var g, w []byte
+					if i < len(got) {
+						g = got[i]
+					}
+					if i < len(b.Events) {
+						w = b.Events[i]
+					}
+					if !bytes.Equal(g, w) {
+						t.Fatalf("event %d of %d:\ngot  %s\nwant %s", i, len(b.Events), g, w)
+					}
+				}
+			})
+		}
+	})
+	t.Run("Task", func(t *testing.T) {
+		s := newTestServer(t)
+		s.logDir = t.TempDir()
+		s.recordDir = t.TempDir()
+		tk := &task.Task{ID: ksid.NewID(), Harness: agent.Claude}
+		log := `{"type":"caic_meta","version":1,"prompt":"hi","repos":[],"harness":"claude","started_at":"2026-01-01T00:00:00Z"}
+{"type":"user","message":{"role":"user","content":"hi"}}
+{"type":"assistant","message":{"role":"assistant","content":[{"type":"text","text":"hello"}]}}
+{"type":"result","subtype":"success","result":"hello","num_turns":1}
+{"type":"caic_result","state":"purged"}
+`
+		if err := os.WriteFile(filepath.Join(s.logDir, tk.ID.String()+"-r-b.jsonl"), []byte(log), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		s.recordTask(tk)
+		b, err := fixture.Load(os.DirFS(s.recordDir), tk.ID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Meta.Prompt != "hi" || len(b.Wire) != 3 {
+			t.Errorf("meta %+v, %d wire lines; want the trailer dropped", b.Meta, len(b.Wire))
+		}
+		if len(b.Events) != 3 {
+			t.Errorf("events = %q", b.Events)
+		}
+	})
+}
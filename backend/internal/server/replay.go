@@ -0,0 +1,39 @@
+// Replay of a historical task from the same commit for side-by-side comparison.
+package server
+
+import (
+	"context"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/maruel/ksid"
+)
+
+// replaySource pins the commit a new task starts from and ties it to the
+// task it replays, if any.
+type replaySource struct {
+	id      ksid.ID // Zero for evaluation tasks.
+	baseSHA string  // Commit the primary repo branch is created from.
+}
+
+// replayTask creates a new task with the prompt, harness, model and
+// container options of the task, starting from the commit the original task
+// started from. Extra repos start from their base branch as usual.
+func (s *Server) replayTask(ctx context.Context, entry *taskEntry, req *v1.ReplayTaskReq) (*v1.CreateTaskResp, error) {
+	t := entry.task
+	src := &replaySource{id: t.ID}
+	if p := t.Primary(); p != nil {
+		if p.BaseSHA == "" {
+			return nil, dto.Conflict("task has no recorded base commit")
+		}
+		src.baseSHA = p.BaseSHA
+	}
+	cr := taskCreateReq(t)
+	if req.Model != "" {
+		cr.Model = req.Model
+	}
+	if err := cr.Validate(); err != nil {
+		return nil, err
+	}
+	return s.newTask(ctx, cr, src)
+}
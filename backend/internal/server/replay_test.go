@@ -0,0 +1,74 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestReplayTask(t *testing.T) {
+	newServer := func(t *testing.T, baseSHA string) (*Server, *taskEntry) {
+		s := newTestServer(t)
+		s.runners["r"] = &task.Runner{
+			BaseBranch: "main",
+			Dir:        t.TempDir(),
+			Backends:   map[agent.Harness]agent.Backend{agent.Claude: stubBackend{}},
+		}
+		tk := &task.Task{
+			ID:            ksid.NewID(),
+			InitialPrompt: agent.Prompt{Text: "fix it"},
+			Harness:       agent.Claude,
+			Model:         "m1",
+			Repos:         []task.RepoMount{{Name: "r", BaseBranch: "main", Branch: "caic-0", BaseSHA: baseSHA}},
+		}
+		setState(t, tk, task.StateWaiting)
+		e := &taskEntry{task: tk, done: make(chan struct{})}
+		s.tasks[tk.ID.String()] = e
+		return s, e
+	}
+	t.Run("Linked", func(t *testing.T) {
+		s, orig := newServer(t, "0123456789abcdef0123456789abcdef01234567")
+		resp, err := s.replayTask(t.Context(), orig, &v1.ReplayTaskReq{Model: "m2"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.mu.Lock()
+		e := s.tasks[resp.ID.String()]
+		s.mu.Unlock()
+		if e == nil {
+			t.Fatal("replay task not created")
+		}
+		rt := e.task
+		if rt.ReplayOf != orig.task.ID {
+			t.Errorf("ReplayOf = %v, want %v", rt.ReplayOf, orig.task.ID)
+		}
+		if rt.InitialPrompt.Text != "fix it" || rt.Model != "m2" || rt.Harness != agent.Claude {
+			t.Errorf("replay task = %+v", rt)
+		}
+		if rt.Repos[0].BaseSHA != orig.task.Repos[0].BaseSHA || rt.Repos[0].BaseBranch != "main" {
+			t.Errorf("replay repo = %+v", rt.Repos[0])
+		}
+		if j := s.toJSON(e); j.ReplayOf != orig.task.ID {
+			t.Errorf("v1 ReplayOf = %v", j.ReplayOf)
+		}
+	})
+	t.Run("NoBaseSHA", func(t *testing.T) {
+		s, orig := newServer(t, "")
+		_, err := s.replayTask(t.Context(), orig, &v1.ReplayTaskReq{})
+		var apiErr *dto.APIError
+		if !errors.As(err, &apiErr) || apiErr.Code() != dto.CodeConflict {
+			t.Fatalf("err = %v, want conflict", err)
+		}
+	})
+	t.Run("UnsupportedModel", func(t *testing.T) {
+		s, orig := newServer(t, "0123456789abcdef0123456789abcdef01234567")
+		if _, err := s.replayTask(t.Context(), orig, &v1.ReplayTaskReq{Model: "nope"}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
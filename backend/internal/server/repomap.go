@@ -0,0 +1,255 @@
+// Per-repo maps of packages and symbols, refreshed when the base branch moves
+// and injected into new tasks.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+const (
+	// repoMapInterval is how often the base branches of the repos with a
+	// map are fetched to detect changes.
+	repoMapInterval = 15 * time.Minute
+	// repoMapTimeout bounds one refresh, fetch included.
+	repoMapTimeout = 5 * time.Minute
+	// repoMapMaxBytes caps a map; the model's token budget may cut it
+	// further.
+	repoMapMaxBytes = 32 << 10
+)
+
+// repoMapFile is a map as stored on disk.
+type repoMapFile struct {
+	SHA         string    `json:"sha"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Files       int       `json:"files"`
+	Symbols     int       `json:"symbols"`
+	Content     string    `json:"content"`
+}
+
+// repoMapStore holds one JSON file per repo under dir, for the repos that
+// enabled it.
+type repoMapStore struct {
+	dir     string
+	enabled map[string]bool // Keyed by repo RelPath.
+
+	mu       sync.Mutex
+	indexing map[string]bool
+	errs     map[string]string // Error of the last refresh, per repo.
+}
+
+func (m *repoMapStore) path(repo string) string {
+	return filepath.Join(m.dir, url.PathEscape(repo)+".json")
+}
+
+// load returns the map of repo, or nil if there is none.
+func (m *repoMapStore) load(repo string) (*repoMapFile, error) {
+	data, err := readSealed(m.path(repo))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f := &repoMapFile{}
+	return f, json.Unmarshal(data, f)
+}
+
+func (m *repoMapStore) save(repo string, f *repoMapFile) error {
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	return writeSealedAtomic(m.path(repo), data)
+}
+
+// begin marks repo as being indexed. It returns false if it already is.
+func (m *repoMapStore) begin(repo string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.indexing[repo] {
+		return false
+	}
+	if m.indexing == nil {
+		m.indexing = map[string]bool{}
+	}
+	m.indexing[repo] = true
+	return true
+}
+
+// end records the outcome of the refresh started with begin.
+func (m *repoMapStore) end(repo string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.indexing, repo)
+	if m.errs == nil {
+		m.errs = map[string]string{}
+	}
+	if err != nil {
+		m.errs[repo] = err.Error()
+	} else {
+		delete(m.errs, repo)
+	}
+}
+
+// status returns whether repo is being indexed and the error of its last
+// refresh.
+func (m *repoMapStore) status(repo string) (bool, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.indexing[repo], m.errs[repo]
+}
+
+// indexRepoMap rebuilds the map of repo when its base branch moved since the
+// stored map, or unconditionally with force.
+func (s *Server) indexRepoMap(ctx context.Context, repo string, force bool) error {
+	r := s.runners[repo]
+	if r == nil {
+		return errors.New("unknown repo")
+	}
+	ctx, cancel := context.WithTimeout(ctx, repoMapTimeout)
+	defer cancel()
+	sha, err := r.ResolveBase(ctx, "")
+	if err != nil {
+		return err
+	}
+	if !force {
+		if old, err := s.repoMaps.load(repo); err == nil && old != nil && old.SHA == sha {
+			return nil
+		}
+	}
+	rm, err := task.BuildRepoMap(ctx, r.Dir, sha, repoMapMaxBytes)
+	if err != nil {
+		return err
+	}
+	slog.Info("repo map", "repo", repo, "sha", sha, "files", rm.Files, "symbols", rm.Symbols)
+	return s.repoMaps.save(repo, &repoMapFile{SHA: sha, GeneratedAt: time.Now().UTC(), Files: rm.Files, Symbols: rm.Symbols, Content: rm.Content})
+}
+
+// refreshRepoMap indexes repo after a successful begin, logging failures.
+func (s *Server) refreshRepoMap(ctx context.Context, repo string, force bool) {
+	err := s.indexRepoMap(ctx, repo, force)
+	if err != nil {
+		slog.Warn("repo map", "repo", repo, "err", err)
+	}
+	s.repoMaps.end(repo, err)
+}
+
+// monitorRepoMaps keeps the maps of the repos that enabled them in sync with
+// their base branch until the server context is cancelled.
+func (s *Server) monitorRepoMaps() {
+	if s.repoMaps == nil || len(s.repoMaps.enabled) == 0 {
+		return
+	}
+	ticker := time.NewTicker(repoMapInterval)
+	defer ticker.Stop()
+	for {
+		for repo := range s.repoMaps.enabled {
+			if s.repoMaps.begin(repo) {
+				s.refreshRepoMap(s.ctx, repo, false)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// taskRepoMap returns the map of the task's primary repo, or the key
+// packages of its overview when it has none, logging failures.
+func (s *Server) taskRepoMap(repos []task.RepoMount) string {
+	if len(repos) == 0 {
+		return ""
+	}
+	repo := repos[0].Name
+	if s.repoMaps != nil && s.repoMaps.enabled[repo] {
+		f, err := s.repoMaps.load(repo)
+		if err != nil {
+			slog.Warn("repo map", "repo", repo, "err", err)
+		}
+		if f != nil && f.Content != "" {
+			return f.Content
+		}
+	}
+	if s.overviews == nil {
+		return ""
+	}
+	f, err := s.overviews.load(repo)
+	if err != nil {
+		slog.Warn("overview", "repo", repo, "err", err)
+	}
+	if f == nil {
+		return ""
+	}
+	return task.OverviewSection(f.Content, task.OverviewPackages)
+}
+
+// repoMapResp returns the status and content of the map of repo.
+func (s *Server) repoMapResp(repo string) (*v1.RepoMapResp, error) {
+	resp := &v1.RepoMapResp{Repo: repo, Enabled: s.repoMaps.enabled[repo]}
+	resp.Indexing, resp.Error = s.repoMaps.status(repo)
+	f, err := s.repoMaps.load(repo)
+	if err != nil {
+		return nil, err
+	}
+	if f != nil {
+		resp.SHA = f.SHA
+		resp.GeneratedAt = float64(f.GeneratedAt.UnixMilli()) / 1e3
+		resp.Files = f.Files
+		resp.Symbols = f.Symbols
+		resp.Content = f.Content
+	}
+	return resp, nil
+}
+
+// handleGetRepoMap returns a repo's map and whether it is being refreshed.
+func (s *Server) handleGetRepoMap(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		writeError(w, dto.BadRequest("repo is required"))
+		return
+	}
+	if _, ok := s.repoAbsPath(repo); !ok {
+		writeError(w, dto.NotFound("repo"))
+		return
+	}
+	resp, err := s.repoMapResp(repo)
+	if err != nil {
+		writeError(w, dto.InternalError(err.Error()))
+		return
+	}
+	writeJSONResponse(w, resp, nil)
+}
+
+// refreshRepoMapNow starts rebuilding a repo's map in the background, even if
+// its base branch didn't move.
+func (s *Server) refreshRepoMapNow(_ context.Context, req *v1.RefreshRepoMapReq) (*v1.RepoMapResp, error) {
+	if _, ok := s.repoAbsPath(req.Repo); !ok {
+		return nil, dto.NotFound("repo")
+	}
+	if !s.repoMaps.enabled[req.Repo] {
+		return nil, dto.BadRequest("repo map is not enabled for this repo; set repoMap in its settings")
+	}
+	if s.repoMaps.begin(req.Repo) {
+		go s.refreshRepoMap(s.ctx, req.Repo, true)
+	}
+	resp, err := s.repoMapResp(req.Repo)
+	if err != nil {
+		return nil, dto.InternalError(err.Error())
+	}
+	return resp, nil
+}
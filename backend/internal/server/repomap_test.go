@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestRepoMapAPI(t *testing.T) {
+	s := newTestServer(t)
+	s.repos = []repoInfo{{RelPath: "caic", AbsPath: t.TempDir()}, {RelPath: "other", AbsPath: t.TempDir()}}
+	s.repoMaps.enabled = map[string]bool{"caic": true}
+	if err := s.repoMaps.save("caic", &repoMapFile{SHA: "abc", Files: 1, Symbols: 2, Content: "./\n  main.go: Run, Stop\n"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.taskRepoMap([]task.RepoMount{{Name: "caic"}}); got != "./\n  main.go: Run, Stop\n" {
+		t.Errorf("taskRepoMap = %q", got)
+	}
+	if got := s.taskRepoMap([]task.RepoMount{{Name: "other"}}); got != "" {
+		t.Errorf("taskRepoMap(other) = %q", got)
+	}
+
+	s.repoMaps.end("caic", errors.New("fetch failed"))
+	w := httptest.NewRecorder()
+	s.handleGetRepoMap(w, httptest.NewRequest(http.MethodGet, "/api/v1/server/repos/map?repo=caic", http.NoBody))
+	var resp v1.RepoMapResp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Enabled || resp.Indexing || resp.SHA != "abc" || resp.Symbols != 2 || resp.Error != "fetch failed" {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	// A refresh in progress isn't started twice.
+	if !s.repoMaps.begin("caic") || s.repoMaps.begin("caic") {
+		t.Error("begin")
+	}
+	w = httptest.NewRecorder()
+	handle(s.refreshRepoMapNow)(w, httptest.NewRequest(http.MethodPost, "/api/v1/server/repos/map/refresh", strings.NewReader(`{"repo":"caic"}`)))
+	resp = v1.RepoMapResp{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || !resp.Indexing {
+		t.Errorf("refresh = %+v, %v", resp, err)
+	}
+	w = httptest.NewRecorder()
+	handle(s.refreshRepoMapNow)(w, httptest.NewRequest(http.MethodPost, "/api/v1/server/repos/map/refresh", strings.NewReader(`{"repo":"other"}`)))
+	if e := decodeError(t, w); e.Code != dto.CodeBadRequest {
+		t.Errorf("code = %q, want %q", e.Code, dto.CodeBadRequest)
+	}
+}
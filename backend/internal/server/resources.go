@@ -0,0 +1,89 @@
+// Container CPU and memory telemetry.
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+const (
+	defaultResourceProbeInterval = time.Minute
+	// resourceProbeTimeout bounds a single probe.
+	resourceProbeTimeout = 30 * time.Second
+)
+
+// monitorResources periodically samples CPU and memory usage in every live
+// container until the server context is cancelled.
+func (s *Server) monitorResources() {
+	interval := s.resourceInterval
+	if interval <= 0 {
+		interval = defaultResourceProbeInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+		s.mu.Lock()
+		entries := make([]*taskEntry, 0, len(s.tasks))
+		for _, e := range s.tasks {
+			if e.task.Container != "" && diskProbeable(e.task.GetState()) {
+				entries = append(entries, e)
+			}
+		}
+		s.mu.Unlock()
+		for _, e := range entries {
+			s.probeTaskResources(s.ctx, e.task)
+		}
+	}
+}
+
+// probeTaskResources samples t's container and appends to its series.
+func (s *Server) probeTaskResources(ctx context.Context, t *task.Task) {
+	ctx, cancel := context.WithTimeout(ctx, resourceProbeTimeout)
+	defer cancel()
+	rs, err := task.ProbeResources(ctx, t.Container)
+	if err != nil {
+		slog.Warn("resource probe failed", "task", t.ID, "ctr", t.Container, "err", err)
+		return
+	}
+	t.AddResourceSample(rs)
+}
+
+// handleGetTaskResources returns the task's CPU and memory series.
+func (s *Server) handleGetTaskResources(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	samples := entry.task.ResourceSamples()
+	resp := v1.TaskResourcesResp{Samples: make([]v1.ResourceSample, len(samples))}
+	for i := range samples {
+		resp.Samples[i] = toV1ResourceSample(&samples[i])
+	}
+	writeJSONResponse(w, &resp, nil)
+}
+
+func toV1ResourceSample(rs *task.ResourceSample) v1.ResourceSample {
+	out := v1.ResourceSample{
+		At:         float64(rs.At.UnixMilli()) / 1e3,
+		CPUPercent: rs.CPUPercent,
+		MemBytes:   rs.MemBytes,
+	}
+	if len(rs.Procs) > 0 {
+		out.Procs = make([]v1.ProcUsage, len(rs.Procs))
+		for i, p := range rs.Procs {
+			out.Procs[i] = v1.ProcUsage{PID: p.PID, Command: p.Command, CPUPercent: p.CPUPercent, RSSBytes: p.RSSBytes}
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestHandleGetTaskResources(t *testing.T) {
+	s := newTestServer(t)
+	tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}}
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tk.AddResourceSample(task.ResourceSample{At: at, MemBytes: 4096, Procs: []task.ProcUsage{{PID: 7, Command: "node", CPUPercent: 12.5, RSSBytes: 2048}}})
+	s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t1/resources", http.NoBody)
+	req.SetPathValue("id", "t1")
+	w := httptest.NewRecorder()
+	s.handleGetTaskResources(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var resp v1.TaskResourcesResp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Samples) != 1 {
+		t.Fatalf("samples = %+v", resp.Samples)
+	}
+	got := resp.Samples[0]
+	if got.At != float64(at.Unix()) || got.MemBytes != 4096 || len(got.Procs) != 1 || got.Procs[0].Command != "node" {
+		t.Errorf("sample = %+v", got)
+	}
+}
@@ -0,0 +1,28 @@
+// Container runtime version detection at startup.
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/caic-xyz/caic/backend/internal/container"
+)
+
+// detectRuntime returns the capabilities of the container runtime rt, logging
+// the features it is too old for. It returns nil when the version can't be
+// read, leaving the runtime to report its own errors.
+func detectRuntime(ctx context.Context, rt string) *container.Capabilities {
+	if rt == "" {
+		return nil
+	}
+	caps, err := container.DetectCapabilities(ctx, rt)
+	if err != nil {
+		slog.Warn("runtime", "err", err)
+		return nil
+	}
+	slog.Info("runtime", "name", caps.Runtime, "version", caps.Version, "md", container.MDVersion())
+	for _, f := range caps.Missing() {
+		slog.Warn("runtime", "err", caps.Check(f))
+	}
+	return caps
+}
@@ -0,0 +1,267 @@
+// Structural JSON validation of request bodies, derived from the Routes tables.
+package server
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	v2 "github.com/caic-xyz/caic/backend/internal/server/dto/v2"
+)
+
+// maxSchemaErrors bounds the field errors reported for one request body.
+const maxSchemaErrors = 20
+
+// schemaKind is the JSON type a value must have.
+type schemaKind int
+
+const (
+	schemaAny schemaKind = iota // custom unmarshaler or interface; not checked
+	schemaString
+	schemaInteger
+	schemaNumber
+	schemaBoolean
+	schemaArray
+	schemaObject // struct: only the declared fields are allowed
+	schemaMap    // map: any key, values of elem
+)
+
+var schemaKindNames = [...]string{"any", "string", "integer", "number", "boolean", "array", "object", "object"}
+
+// schema describes the JSON shape of a Go type, as encoding/json decodes it.
+type schema struct {
+	kind   schemaKind
+	fields map[string]*schema // schemaObject, keyed by JSON name
+	elem   *schema            // schemaArray and schemaMap
+}
+
+// reqSchemas maps each request type in the Routes tables to its schema.
+var reqSchemas = routeSchemas(v1.Routes, v2.Routes)
+
+// routeSchemas compiles the request type of every route.
+func routeSchemas(tables ...[]v1.Route) map[reflect.Type]*schema {
+	out := map[reflect.Type]*schema{}
+	seen := map[reflect.Type]*schema{}
+	for _, routes := range tables {
+		for i := range routes {
+			if t := routes[i].Req; t != nil {
+				out[t] = compileSchema(t, seen)
+			}
+		}
+	}
+	return out
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// compileSchema returns the schema of t. seen holds the schemas already
+// compiled so that recursive types terminate.
+func compileSchema(t reflect.Type, seen map[reflect.Type]*schema) *schema {
+	if s, ok := seen[t]; ok {
+		return s
+	}
+	if t.Kind() == reflect.Pointer && !t.Implements(jsonUnmarshalerType) && !t.Implements(textUnmarshalerType) {
+		s := compileSchema(t.Elem(), seen)
+		seen[t] = s
+		return s
+	}
+	s := &schema{}
+	seen[t] = s
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return s
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		s.kind = schemaString
+		return s
+	}
+	//exhaustive:ignore
+	switch t.Kind() {
+	case reflect.String:
+		s.kind = schemaString
+	case reflect.Bool:
+		s.kind = schemaBoolean
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s.kind = schemaInteger
+	case reflect.Float32, reflect.Float64:
+		s.kind = schemaNumber
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// []byte is base64.
+			s.kind = schemaString
+			break
+		}
+		s.kind = schemaArray
+		s.elem = compileSchema(t.Elem(), seen)
+	case reflect.Map:
+		s.kind = schemaMap
+		s.elem = compileSchema(t.Elem(), seen)
+	case reflect.Struct:
+		s.kind = schemaObject
+		s.fields = map[string]*schema{}
+		addStructFields(s, t, seen)
+	}
+	return s
+}
+
+// addStructFields adds the JSON fields of struct t to s, including those
+// promoted from embedded structs. Like encoding/json, a shallower field
+// shadows a deeper one with the same name.
+func addStructFields(s *schema, t reflect.Type, seen map[reflect.Type]*schema) {
+	var embedded []reflect.Type
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		s.fields[name] = compileSchema(f.Type, seen)
+	}
+	for _, et := range embedded {
+		inner := &schema{fields: map[string]*schema{}}
+		addStructFields(inner, et, seen)
+		for name, fs := range inner.fields {
+			if _, ok := s.fields[name]; !ok {
+				s.fields[name] = fs
+			}
+		}
+	}
+}
+
+// checkSchema validates the JSON document body against s and returns the
+// field errors, sorted by field.
+func checkSchema(s *schema, body []byte) []dto.FieldError {
+	d := json.NewDecoder(bytes.NewReader(body))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return []dto.FieldError{{Field: "", Message: "malformed JSON: " + err.Error()}}
+	}
+	var errs []dto.FieldError
+	s.check("", v, &errs)
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+	if len(errs) > maxSchemaErrors {
+		errs = errs[:maxSchemaErrors]
+	}
+	return errs
+}
+
+// check appends to errs the errors of value v at path.
+func (s *schema) check(path string, v any, errs *[]dto.FieldError) {
+	if v == nil || s.kind == schemaAny || len(*errs) > maxSchemaErrors {
+		// encoding/json leaves the zero value for null.
+		return
+	}
+	fail := func() {
+		*errs = append(*errs, dto.FieldError{Field: path, Message: "expected " + schemaKindNames[s.kind] + ", got " + jsonKind(v)})
+	}
+	switch s.kind {
+	case schemaAny:
+	case schemaString:
+		if _, ok := v.(string); !ok {
+			fail()
+		}
+	case schemaBoolean:
+		if _, ok := v.(bool); !ok {
+			fail()
+		}
+	case schemaNumber:
+		if _, ok := v.(json.Number); !ok {
+			fail()
+		}
+	case schemaInteger:
+		n, ok := v.(json.Number)
+		if !ok {
+			fail()
+		} else if _, err := strconv.ParseInt(n.String(), 10, 64); err != nil {
+			*errs = append(*errs, dto.FieldError{Field: path, Message: "expected integer, got " + n.String()})
+		}
+	case schemaArray:
+		a, ok := v.([]any)
+		if !ok {
+			fail()
+			return
+		}
+		for i, e := range a {
+			s.elem.check(fmt.Sprintf("%s[%d]", path, i), e, errs)
+		}
+	case schemaObject, schemaMap:
+		m, ok := v.(map[string]any)
+		if !ok {
+			fail()
+			return
+		}
+		for k, e := range m {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			if s.kind == schemaMap {
+				s.elem.check(p, e, errs)
+			} else if fs := s.field(k); fs != nil {
+				fs.check(p, e, errs)
+			} else {
+				*errs = append(*errs, dto.FieldError{Field: p, Message: "unknown field"})
+			}
+		}
+	}
+}
+
+// field returns the schema of the object field name, matched like
+// encoding/json does: exactly, else case-insensitively.
+func (s *schema) field(name string) *schema {
+	if fs, ok := s.fields[name]; ok {
+		return fs
+	}
+	for k, fs := range s.fields {
+		if strings.EqualFold(k, name) {
+			return fs
+		}
+	}
+	return nil
+}
+
+// jsonKind names the JSON type of a value decoded with UseNumber.
+func jsonKind(v any) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case json.Number:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return "null"
+	}
+}
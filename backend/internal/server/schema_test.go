@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	v2 "github.com/caic-xyz/caic/backend/internal/server/dto/v2"
+)
+
+func TestSchema(t *testing.T) {
+	t.Run("AllRoutes", func(t *testing.T) {
+		// Every request and response type compiles, including recursive ones.
+		seen := map[reflect.Type]*schema{}
+		for _, routes := range [][]v1.Route{v1.Routes, v2.Routes} {
+			for i := range routes {
+				if r := &routes[i]; r.Req != nil {
+					if s := compileSchema(r.Req, seen); s.kind != schemaObject {
+						t.Errorf("%s: request kind = %d, want object", r.Name, s.kind)
+					}
+				}
+				compileSchema(routes[i].Resp, seen)
+			}
+		}
+		if reqSchemas[reflect.TypeFor[v1.CreateTaskReq]()] == nil {
+			t.Error("CreateTaskReq has no schema")
+		}
+	})
+	t.Run("Check", func(t *testing.T) {
+		s := reqSchemas[reflect.TypeFor[v1.CreateTaskReq]()]
+		for _, tc := range []struct {
+			name string
+			body string
+			want []dto.FieldError
+		}{
+			{"Valid", `{"initialPrompt":{"text":"hi","images":[{"mediaType":"image/png","data":"aGk="}]},"harness":"claude","repos":[{"name":"a"}],"gpu":true}`, nil},
+			{"Null", `{"initialPrompt":null,"repos":null}`, nil},
+			{"CaseInsensitive", `{"Harness":"claude"}`, nil},
+			{"WrongTypes", `{"initialPrompt":{"text":1},"harness":true,"repos":[{"name":"a"},{"name":[]}]}`, []dto.FieldError{
+				{Field: "harness", Message: "expected string, got boolean"},
+				{Field: "initialPrompt.text", Message: "expected string, got number"},
+				{Field: "repos[1].name", Message: "expected string, got array"},
+			}},
+			{"UnknownField", `{"harness":"claude","initialPrompt":{"txt":"hi"}}`, []dto.FieldError{
+				{Field: "initialPrompt.txt", Message: "unknown field"},
+			}},
+			{"NotAnObject", `[]`, []dto.FieldError{{Field: "", Message: "expected object, got array"}}},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				got := checkSchema(s, []byte(tc.body))
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("got %+v\nwant %+v", got, tc.want)
+				}
+			})
+		}
+		got := checkSchema(reqSchemas[reflect.TypeFor[v1.SpendingOverrideReq]()], []byte(`{"duration":"1h"`))
+		if len(got) != 1 || !strings.HasPrefix(got[0].Message, "malformed JSON") {
+			t.Errorf("malformed: %+v", got)
+		}
+	})
+	t.Run("Handler", func(t *testing.T) {
+		called := false
+		h := handle(func(context.Context, *v1.SpendingOverrideReq) (*v1.SpendingResp, error) {
+			called = true
+			return &v1.SpendingResp{}, nil
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/server/spending/override", strings.NewReader(`{"duration":3600}`))
+		w := httptest.NewRecorder()
+		h(w, req)
+		if w.Code != http.StatusBadRequest || called {
+			t.Fatalf("status = %d, called = %v", w.Code, called)
+		}
+		var resp struct {
+			Details struct {
+				Fields []dto.FieldError `json:"fields"`
+			} `json:"details"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		want := []dto.FieldError{{Field: "duration", Message: "expected string, got number"}}
+		if !reflect.DeepEqual(resp.Details.Fields, want) {
+			t.Errorf("fields = %+v", resp.Details.Fields)
+		}
+	})
+}
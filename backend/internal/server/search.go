@@ -0,0 +1,138 @@
+// Conversation search across all stored task logs.
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/search"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 500
+)
+
+// indexLogs indexes the logs scanned at startup. Tasks already indexed from
+// memory are skipped since their index is fresher than their log. Afterward,
+// indexTask keeps the index current as tasks progress, so the log directory
+// is never rescanned.
+func (s *Server) indexLogs(logs []*task.LoadedTask) {
+	n := 0
+	for _, lt := range logs {
+		if s.ctx.Err() != nil {
+			return
+		}
+		if lt.TaskID == "" || !s.search.Version(lt.TaskID).IsZero() {
+			continue
+		}
+		if err := lt.LoadMessages(); err != nil {
+			slog.Warn("search index", "task", lt.TaskID, "err", err)
+			continue
+		}
+		meta := search.Meta{Title: lt.Title, StartedAt: lt.StartedAt, Owner: lt.OwnerID}
+		if meta.Title == "" {
+			meta.Title = task.LocalTitle(lt.Prompt)
+		}
+		if p := lt.Primary(); p != nil {
+			meta.Repo = p.Name
+		}
+		s.search.Set(lt.TaskID, meta, lt.LastStateUpdateAt, searchDocs(lt.Msgs))
+		lt.Msgs = nil
+		n++
+	}
+	slog.Info("search index", "indexed", n, "tasks", s.search.Len())
+}
+
+// indexTask reindexes the conversation of t from memory. It is called when a
+// turn ends and when the task finishes.
+func (s *Server) indexTask(t *task.Task) {
+	if s.search == nil {
+		return
+	}
+	meta := search.Meta{Title: t.Title(), StartedAt: t.StartedAt, Owner: t.OwnerID}
+	if p := t.Primary(); p != nil {
+		meta.Repo = p.Name
+	}
+	s.search.Set(t.ID.String(), meta, time.Now(), searchDocs(t.Messages()))
+}
+
+// searchDocs extracts the searchable documents of msgs. Snippets are cut
+// from the indexed text, so secrets are masked before they can be split.
+func searchDocs(msgs []agent.Message) []search.Doc {
+	docs := search.Extract(msgs)
+	for i := range docs {
+		docs[i].Text, _ = task.MaskSecrets(docs[i].Text)
+	}
+	return docs
+}
+
+// handleSearch full-text searches prompts, assistant text, tool commands and
+// file paths across all stored sessions.
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeError(w, dto.BadRequest("q is required"))
+		return
+	}
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			writeError(w, dto.BadRequest("invalid limit").WithDetail("limit", v))
+			return
+		}
+		limit = n
+	}
+	var keep func(string, *search.Meta) bool
+	if s.authEnabled() {
+		ownerID := ""
+		if u, ok := auth.UserFromContext(r.Context()); ok {
+			ownerID = u.ID
+		}
+		// Snapshot the owners of the loaded tasks; the index calls keep
+		// with its lock held.
+		s.mu.Lock()
+		owners := make(map[string]string, len(s.tasks))
+		for id, e := range s.tasks {
+			owners[id] = e.task.OwnerID
+		}
+		s.mu.Unlock()
+		keep = func(id string, m *search.Meta) bool {
+			if o, ok := owners[id]; ok {
+				return o == "" || o == ownerID
+			}
+			// The owner of tasks only known from their logs must match.
+			return ownerID != "" && m.Owner == ownerID
+		}
+	}
+	hits := s.search.Search(q, limit, keep)
+	resp := v1.SearchResp{Hits: make([]v1.SearchHit, 0, len(hits))}
+	s.mu.Lock()
+	for _, h := range hits {
+		e := s.tasks[h.TaskID]
+		hit := v1.SearchHit{
+			TaskID:       h.TaskID,
+			Title:        h.Meta.Title,
+			Repo:         h.Meta.Repo,
+			StartedAt:    float64(h.Meta.StartedAt.UnixMilli()) / 1e3,
+			MessageIndex: h.Msg,
+			Kind:         string(h.Kind),
+			Snippet:      h.Snippet,
+			Loaded:       e != nil,
+		}
+		if e != nil {
+			hit.Title = e.task.Title()
+		}
+		resp.Hits = append(resp.Hits, hit)
+	}
+	s.mu.Unlock()
+	writeJSONResponse(w, &resp, nil)
+}
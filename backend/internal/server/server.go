@@ -13,6 +13,7 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"os/exec"
@@ -29,15 +30,21 @@ import (
 	"github.com/caic-xyz/caic/backend/internal/agent"
 	"github.com/caic-xyz/caic/backend/internal/auth"
 	"github.com/caic-xyz/caic/backend/internal/bot"
+	"github.com/caic-xyz/caic/backend/internal/chaos"
 	"github.com/caic-xyz/caic/backend/internal/container"
 	"github.com/caic-xyz/caic/backend/internal/forge"
 	"github.com/caic-xyz/caic/backend/internal/forge/forgecache"
 	"github.com/caic-xyz/caic/backend/internal/forge/github"
+	"github.com/caic-xyz/caic/backend/internal/logcrypt"
 	"github.com/caic-xyz/caic/backend/internal/preferences"
+	"github.com/caic-xyz/caic/backend/internal/scrub"
+	"github.com/caic-xyz/caic/backend/internal/search"
 	"github.com/caic-xyz/caic/backend/internal/server/dto"
 	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
 	"github.com/caic-xyz/caic/backend/internal/server/ipgeo"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
 	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/caic-xyz/caic/backend/internal/usagehistory"
 	"github.com/caic-xyz/md"
 	"github.com/caic-xyz/md/gitutil"
 	"github.com/maruel/genai"
@@ -75,6 +82,10 @@ type Config struct {
 	GeminiAPIKey    string // required for Gemini Live audio
 	TailscaleAPIKey string // required for Tailscale networking inside containers
 
+	// AnthropicAdminKey is an Anthropic Admin API key (sk-ant-admin...).
+	// When set, organization-wide spend is included in GET /api/v1/usage.
+	AnthropicAdminKey string
+
 	// LLM features (title generation, commit descriptions).
 	LLMProvider string
 	LLMModel    string
@@ -101,6 +112,46 @@ type Config struct {
 	// Required for OAuth login and webhook delivery.
 	ExternalURL string
 
+	// HostTailscaleServe is the tailnet HTTPS port (e.g. "443") to expose the
+	// server on through the host's "tailscale serve". Empty disables it.
+	HostTailscaleServe string
+
+	// MDNS advertises the server as _caic._tcp on the LAN so clients can
+	// discover it. Only effective when listening on a non-loopback address.
+	MDNS bool
+
+	// SSHMultiplex shares one SSH connection per container between the
+	// ssh processes run in it (OpenSSH ControlMaster) instead of connecting
+	// for every diff or fetch. It applies to containers whose SSH config is
+	// written afterwards, i.e. on start.
+	SSHMultiplex bool
+
+	// ExecSSH runs the agents and probes through the ssh binary, honoring
+	// the user's ssh_config, instead of over the native connection kept
+	// per container.
+	ExecSSH bool
+
+	// Reverse proxy (optional).
+	// BasePath is the URL prefix caic is mounted under (e.g. "/caic") when a
+	// reverse proxy shares the host with other services.
+	BasePath string
+	// TrustedProxies is a comma-separated list of IP addresses and CIDR
+	// prefixes whose X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host
+	// headers are honored. When set, those headers are stripped from other
+	// peers; when empty, X-Forwarded-For is only used for logging and the IP
+	// allowlist.
+	TrustedProxies string
+
+	// TLS termination (optional). Set either TLSCertFile and TLSKeyFile, or
+	// ACMEHosts to obtain certificates from Let's Encrypt.
+	TLSCertFile string // PEM certificate chain
+	TLSKeyFile  string // PEM private key
+	ACMEHosts   string // comma-separated hostnames; mutually exclusive with TLSCertFile
+	ACMEEmail   string // optional contact for expiry notices
+	// HTTPRedirectAddr is a plain HTTP listen address (e.g. ":80") redirecting
+	// to https and answering ACME HTTP-01 challenges. Requires TLS.
+	HTTPRedirectAddr string
+
 	// IP geolocation (optional).
 	// IPGeoDB is the path to a MaxMind MMDB file (e.g. GeoLite2-Country.mmdb).
 	// When set, country codes are resolved and logged for every request.
@@ -110,6 +161,32 @@ type Config struct {
 	// do not resolve to an allowed value are rejected with 403. Requires
 	// IPGeoDB when any token is not "local" or "tailscale".
 	IPGeoAllowlist string
+
+	// LogRing receives the server's own log records; it backs
+	// GET /api/v1/server/logs/events. Nil disables the endpoint.
+	LogRing *LogRing
+
+	// RecordDir receives a fixture bundle (see package fixture) for every
+	// task when it is cleaned up. Empty disables recording. Bundles are
+	// plaintext, so it can't be combined with LogKeyFile.
+	RecordDir string
+
+	// StrictParse writes the wire lines the harness parsers fail to decode
+	// or don't know to a dead-letter file per task, served by
+	// GET /api/v1/tasks/{id}/dead-letters.
+	StrictParse bool
+
+	// LogKeyFile is the path of a file holding a 32 bytes key, base64 or hex
+	// encoded. When set, task logs, dead letters and the stores derived from
+	// the conversations, e.g. summaries and drafts, are encrypted at rest
+	// with it (see package logcrypt). Files written with it can't be read
+	// without it.
+	LogKeyFile string
+
+	// Chaos is a fault injection spec, e.g. "fail=0.1,delay=2s", applied to
+	// the container and agent backends of the runners (see package chaos).
+	// For tests and staging servers only. Empty disables it.
+	Chaos string
 }
 
 // Validate returns an error if the configuration is invalid.
@@ -129,8 +206,8 @@ func (c *Config) Validate() error {
 		if err != nil || u.Host == "" {
 			return fmt.Errorf("CAIC_EXTERNAL_URL is not a valid URL: %q", c.ExternalURL)
 		}
-		if u.Path != "" && u.Path != "/" {
-			return fmt.Errorf("CAIC_EXTERNAL_URL must not contain a path: %q", c.ExternalURL)
+		if p := strings.TrimSuffix(u.Path, "/"); p != "" && p != strings.TrimSuffix(c.BasePath, "/") {
+			return fmt.Errorf("CAIC_EXTERNAL_URL must not contain a path other than CAIC_BASE_PATH: %q", c.ExternalURL)
 		}
 		if oauthConfigured && u.Scheme != "https" {
 			return errors.New("CAIC_EXTERNAL_URL must use https:// when OAuth login is configured")
@@ -159,6 +236,24 @@ func (c *Config) Validate() error {
 	if c.GitLabOAuthClientID != "" && c.GitLabOAuthAllowedUsers == "" {
 		return errors.New("GITLAB_OAUTH_ALLOWED_USERS is required when GitLab OAuth login is configured")
 	}
+	if c.HostTailscaleServe != "" && !validPort(c.HostTailscaleServe) {
+		return fmt.Errorf("CAIC_HOST_TAILSCALE_SERVE must be a port number: %q", c.HostTailscaleServe)
+	}
+	if _, err := cleanBasePath(c.BasePath); err != nil {
+		return fmt.Errorf("CAIC_BASE_PATH: %w", err)
+	}
+	if _, err := parseTrustedProxies(c.TrustedProxies); err != nil {
+		return fmt.Errorf("CAIC_TRUSTED_PROXIES: %w", err)
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("CAIC_TLS_CERT and CAIC_TLS_KEY must both be set or both be unset")
+	}
+	if c.TLSCertFile != "" && c.ACMEHosts != "" {
+		return errors.New("CAIC_TLS_CERT and CAIC_ACME_HOSTS are mutually exclusive")
+	}
+	if c.HTTPRedirectAddr != "" && c.TLSCertFile == "" && c.ACMEHosts == "" {
+		return errors.New("CAIC_HTTP_REDIRECT requires CAIC_TLS_CERT or CAIC_ACME_HOSTS")
+	}
 	if ipgeo.ParseAllowlist(c.IPGeoAllowlist).NeedsDB() && c.IPGeoDB == "" {
 		return errors.New("CAIC_IPGEO_DB is required when CAIC_IPGEO_ALLOWLIST contains country codes")
 	}
@@ -179,7 +274,8 @@ type Server struct {
 	logDir   string
 	ciCache  *forgecache.Cache
 	provider genai.Provider // nil if LLM not configured
-	bot      *bot.Bot       // handles forge event-driven task automation
+	titles   *task.TitleQueue
+	bot      *bot.Bot // handles forge event-driven task automation
 
 	// Agent backends.
 	geminiAPIKey string
@@ -210,8 +306,31 @@ type Server struct {
 	// Auth / session.
 	authStore     *auth.Store // nil when auth disabled
 	sessionSecret []byte      // nil when auth disabled
+	shareSecret   []byte      // signs share links; nil disables sharing
 	allowedHost   string      // hostname from ExternalURL; empty disables host checking
 	usage         *usageFetcher
+	orgUsage      *orgUsageFetcher // nil when no Anthropic admin key
+	usageHistory  *usagehistory.Store
+	search        *search.Index
+	summaries     *summaryStore
+	knowledge     *knowledgeStore
+	overviews     *overviewStore
+	repoMaps      *repoMapStore
+	drafts        *draftStore
+	evals         *evalStore
+	labels        *labelStore
+	annotations   annotationStore
+
+	tls                tlsSettings     // zero when serving plain HTTP
+	hostTailscaleServe string          // tailnet HTTPS port; empty when not exposed on the tailnet
+	mdns               bool            // advertise the listener on the LAN
+	recordDir          string          // fixture bundle per cleaned up task; empty disables
+	deadLetterDir      string          // dropped wire lines per task; empty unless strict parsing
+	externalBackends   []agent.Backend // harnesses registered in settings.json, added to every runner
+	scrubber           *scrub.Scrubber // redacts prompts and logs of new tasks; nil disables
+	chaos              *chaos.Injector // injects faults into the runners' backends; nil disables
+	basePath           string          // URL prefix without trailing slash; empty when mounted at the root
+	trustedProxies     []netip.Prefix  // peers whose forwarded headers are honored
 
 	// IP geolocation.
 	ipgeoChecker   *ipgeo.Checker   // nil when CAIC_IPGEO_DB not set
@@ -220,12 +339,55 @@ type Server struct {
 	// User preferences — all users in a single file.
 	prefs *preferences.Store
 
+	// imageAvailable checks that a task's container image exists; nil skips
+	// the check.
+	imageAvailable func(ctx context.Context, ref string) error
+	// localImages lists the local image store; nil reports no images.
+	localImages func(ctx context.Context) ([]container.LocalImage, error)
+	// runtimeCaps is the container runtime version; nil when unknown, which
+	// gates nothing.
+	runtimeCaps *container.Capabilities
+	// caicContainers lists the containers labeled by caic; nil reports none.
+	caicContainers func(ctx context.Context) ([]container.Info, error)
+	pulls          imagePulls // prefetch results, keyed by image ref
+	// latestAgentVersion looks up the latest release of an npm package; nil
+	// when update checks are disabled in settings.json.
+	latestAgentVersion func(ctx context.Context, pkg string) (string, error)
+	agentLatest        agentLatestCache
+	// agentVersions holds the pinned harness CLI versions from
+	// settings.json, keyed by RelPath.
+	agentVersions map[string]map[agent.Harness]string
+
+	logRing          *LogRing                   // nil when server log streaming is disabled
+	repoGit          map[string]task.GitOptions // per-repo git tuning from settings.json, keyed by RelPath
+	repoImages       map[string]string          // per-repo default container image from settings.json, keyed by RelPath
+	repoPolicies     map[string]repoPolicy      // per-repo harness and model restrictions from settings.json, keyed by RelPath
+	repoLimits       map[string]repoLimits      // per-repo task and branch limits from settings.json, keyed by RelPath
+	pruneMu          sync.Mutex                 // serializes task branch pruning
+	repoWorkHours    map[string]*workHours      // per-repo working hours from settings.json, keyed by RelPath
+	prefetch         prefetchConfig             // background image pulls from settings.json
+	disk             diskConfig                 // container disk monitoring from settings.json
+	resourceInterval time.Duration              // container CPU/memory sampling period from settings.json
+	stuck            stuckConfig                // stuck turn detection from settings.json
+	signing          *task.Signing              // signs the commits created during sync; nil disables
+	userSigning      map[string]*task.Signing   // per-user signing keys, keyed by username
+	gpus             int                        // GPUs available to tasks; 0 disables GPU tasks
+	preempt          bool                       // stop low priority tasks to make room for higher priority ones
+	spending         spendingConfig             // spending limits from settings.json
+	settingsPath     string                     // settings.json, rewritten when feature flags change
+	admins           []string                   // usernames allowed to change settings through the API
+
 	// Guarded by mu.
 	mu                  sync.Mutex
 	tasks               map[string]*taskEntry
 	repoCIStatus        map[string]repoCIState // keyed by repoInfo.RelPath
 	changed             chan struct{}          // closed on task mutation; replaced under mu
+	configChanged       chan struct{}          // closed on repo registration or feature change; replaced under mu
 	githubInstallations map[string]int64       // owner (lowercase) → installation ID
+	spendOverrideUntil  time.Time              // spending limits are not enforced before then
+	features            v1.FeatureFlags        // experimental subsystems enabled
+	spendWarned         map[string]bool        // limits already warned about, keyed by "harness/window"
+	history             map[string]pastTask    // finished tasks, from the logs and this run; keyed by task ID
 }
 
 // mdBackend adapts *md.Client to task.ContainerBackend.
@@ -237,14 +399,16 @@ type mdBackend struct {
 	pendingContainers map[string]*md.Container // keyed by container name
 }
 
+// mdHarnesses maps the built-in harnesses to their md counterpart.
+var mdHarnesses = map[agent.Harness]md.Harness{
+	agent.Claude: md.HarnessClaude,
+	agent.Codex:  md.HarnessCodex,
+	agent.Gemini: md.HarnessGemini,
+	agent.Kilo:   md.HarnessKilo,
+}
+
 func (b *mdBackend) mdStartOpts(labels []string, opts *task.StartOptions) (client *md.Client, mdOpts *md.StartOpts) {
-	harnessMap := map[agent.Harness]md.Harness{
-		agent.Claude: md.HarnessClaude,
-		agent.Codex:  md.HarnessCodex,
-		agent.Gemini: md.HarnessGemini,
-		agent.Kilo:   md.HarnessKilo,
-	}
-	mdHarness := harnessMap[opts.Harness]
+	mdHarness := mdHarnesses[opts.Harness]
 	harnessPaths := md.HarnessMounts[mdHarness]
 	image := opts.DockerImage
 	if image == "" {
@@ -263,18 +427,22 @@ func (b *mdBackend) mdStartOpts(labels []string, opts *task.StartOptions) (clien
 	return client, mdOpts
 }
 
+// gpuPassthrough reports whether containers can be given the host GPUs. It is
+// false until md.StartOpts can request "docker run --gpus".
+func (b *mdBackend) gpuPassthrough() bool {
+	return false
+}
+
 func (b *mdBackend) Launch(ctx context.Context, repos []md.Repo, labels []string, opts *task.StartOptions) error {
+	if opts.GPU && !b.gpuPassthrough() {
+		return errors.New("GPU passthrough is not supported by the md container backend")
+	}
 	if len(repos) > 0 {
 		slog.Info("md", "phase", "launch", "dir", repos[0].GitRoot, "br", repos[0].Branch, "hns", opts.Harness)
 	} else {
 		slog.Info("md", "phase", "launch", "hns", opts.Harness)
 	}
-	if _, ok := map[agent.Harness]md.Harness{
-		agent.Claude: md.HarnessClaude,
-		agent.Codex:  md.HarnessCodex,
-		agent.Gemini: md.HarnessGemini,
-		agent.Kilo:   md.HarnessKilo,
-	}[opts.Harness]; !ok {
+	if _, ok := mdHarnesses[opts.Harness]; !ok {
 		return fmt.Errorf("unknown harness %q", opts.Harness)
 	}
 	client, mdOpts := b.mdStartOpts(labels, opts)
@@ -307,6 +475,9 @@ func (b *mdBackend) Connect(ctx context.Context, repos []md.Repo, opts *task.Sta
 		delete(b.pendingContainers, c.Name)
 	}
 	b.mu.Unlock()
+	if len(opts.Clones) != 0 {
+		b.seedClones(ctx, c.Name, opts.Clones)
+	}
 	_, mdOpts := b.mdStartOpts(nil, opts)
 	sr, err := c.Connect(ctx, mdOpts)
 	if err != nil {
@@ -315,6 +486,35 @@ func (b *mdBackend) Connect(ctx context.Context, repos []md.Repo, opts *task.Sta
 	return c.Name, sr.TailscaleFQDN, nil
 }
 
+// seedClones runs the container clone commands once the container accepts
+// SSH connections. Failures are only logged: md then pushes the repositories
+// in full.
+func (b *mdBackend) seedClones(ctx context.Context, name string, cmds []string) {
+	deadline := time.Now().Add(30 * time.Second)
+	for _, command := range cmds {
+		for {
+			args := b.client.SSHCommand(name, command)
+			out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput() //nolint:gosec // command is built from quoted values.
+			if err == nil {
+				slog.Info("md clone", "ctr", name)
+				break
+			}
+			// ssh exits with 255 until sshd accepts connections.
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == 255 && time.Now().Before(deadline) {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(500 * time.Millisecond):
+				}
+				continue
+			}
+			slog.Warn("md clone", "ctr", name, "err", err, "out", string(bytes.TrimSpace(out)))
+			break
+		}
+	}
+}
+
 func (b *mdBackend) Diff(ctx context.Context, repo md.Repo, args ...string) (string, error) {
 	slog.Info("md diff", "dir", repo.GitRoot, "br", repo.Branch, "args", args)
 	var stdout bytes.Buffer
@@ -357,6 +557,26 @@ func (b *mdBackend) Purge(ctx context.Context, name string, repos []md.Repo) err
 	return ct.Purge(ctx)
 }
 
+func (b *mdBackend) SparseCheckout(ctx context.Context, name string, repo md.Repo, paths []string) error {
+	slog.Info("md sparse-checkout", "dir", repo.GitRoot, "ctr", name, "paths", paths)
+	args := b.client.SSHCommand(name, task.SparseCheckoutCommand(repo.GitRoot, paths))
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // paths are validated and quoted.
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+func (b *mdBackend) Run(ctx context.Context, name, command string) error {
+	slog.Info("md run", "ctr", name)
+	args := b.client.SSHCommand(name, command)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...) //nolint:gosec // command is built from quoted values.
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
 func (b *mdBackend) Revive(ctx context.Context, name string, repos []md.Repo) error {
 	if len(repos) > 0 {
 		slog.Info("md revive", "dir", repos[0].GitRoot, "br", repos[0].Branch, "ctr", name)
@@ -377,7 +597,14 @@ type taskEntry struct {
 	cleanupOnce sync.Once // ensures exactly one cleanup runs per task
 	// CI monitoring: set when a PR is created; used by webhook handlers to
 	// find the task waiting for CI results.
-	monitorBranch string // branch being monitored (e.g. "caic-123"); empty when no CI monitoring active
+	monitorBranch string    // branch being monitored (e.g. "caic-123"); empty when no CI monitoring active
+	gpuHeld       bool      // task holds a GPU slot while its container is live; protected by Server.mu
+	gpuQueuedAt   time.Time // non-zero while the task waits for a GPU slot; protected by Server.mu
+	preempted     bool      // paused by preemptTask; holds no GPU nor repo task slot until resumed; protected by Server.mu
+	askHandled    time.Time // start of the question the ask policy last answered or paused; protected by Server.mu
+	askNotified   time.Time // start of the question the ask policy last notified about; protected by Server.mu
+	stuckSince    time.Time // last activity of the stall last reported; protected by Server.mu
+	stuckRetries  int       // consecutive restarts of stuck turns; protected by Server.mu
 }
 
 // New creates a new Server. It discovers repos under rootDir, creates a Runner
@@ -401,13 +628,29 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 	if err != nil {
 		return nil, err
 	}
+	chaosCfg, err := chaos.Parse(cfg.Chaos)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set before any log is read or written.
+	if cfg.LogKeyFile != "" {
+		if cfg.RecordDir != "" {
+			return nil, errors.New("fixture recording writes the logs in plaintext; it can't be enabled with a log key")
+		}
+		key, err := logcrypt.ReadKeyFile(cfg.LogKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("log key: %w", err)
+		}
+		logcrypt.SetKey(key)
+	}
 
 	// container.New is instant; run it serially to simplify.
 	mdClient, err := container.New(cfg.TailscaleAPIKey)
 	if err != nil {
 		return nil, fmt.Errorf("init container library: %w", err)
 	}
-	mdClient.DigestCacheTTL = warmupInterval
+	caps := detectRuntime(ctx, mdClient.Runtime)
 
 	// Phase 1: Parallel I/O — repos discovery, logs loading, and container listing.
 	type reposResult struct {
@@ -453,10 +696,96 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 	}
 
 	// Load persistent settings (generates sessionSecret on first run).
-	settings, err := loadSettings(filepath.Join(cfg.ConfigDir, "settings.json"))
+	settingsPath := filepath.Join(cfg.ConfigDir, "settings.json")
+	settings, err := loadSettings(settingsPath)
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	repoGit, err := settings.gitOptions()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	disk, err := settings.diskConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	resourceInterval, err := settings.resourceInterval()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	stuck, err := settings.stuckConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	signing, userSigning, err := settings.signing()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	repoImages, err := settings.repoImages()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	agentVersions, err := settings.agentVersions()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	repoPolicies, err := settings.repoPolicies()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	repoLimits, err := settings.repoLimits()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	repoWorkHours, err := settings.repoWorkHours()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	prefetch, err := settings.prefetchConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	// Container starts between prefetch cycles reuse the cached digest
+	// instead of hitting the registry.
+	mdClient.DigestCacheTTL = prefetch.interval
+	mdClient.ControlMaster = cfg.SSHMultiplex
+	if !cfg.ExecSSH {
+		sshconn.SetDefault(sshconn.New(filepath.Join(mdClient.Home, ".ssh", "config.d")))
+	}
+	spending, err := settings.spendingConfig()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	externalBackends, err := settings.externalBackends()
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	scrubber, err := scrub.New(&settings.Scrub)
+	if err != nil {
+		return nil, fmt.Errorf("load settings: %w", err)
+	}
+	knowledge, err := settings.knowledgeStore(filepath.Join(cfg.ConfigDir, "knowledge"))
 	if err != nil {
 		return nil, fmt.Errorf("load settings: %w", err)
 	}
+	gpus := settings.GPUs
+	if gpus == 0 {
+		gpus = detectGPUs(ctx)
+	} else if gpus < 0 {
+		gpus = 0
+	}
+	// Token prices for harnesses that don't report a cost. A bad file is not
+	// fatal: the built-in table stays in effect.
+	if err := agent.DefaultPricing.Load(filepath.Join(cfg.ConfigDir, "pricing.json")); err != nil {
+		slog.Warn("pricing", "err", err)
+	}
+
+	// Share links are signed with the session secret even when auth is
+	// disabled, so links minted before enabling auth stay valid.
+	shareSecret, err := hexDecode(settings.SessionSecret)
+	if err != nil {
+		return nil, fmt.Errorf("decode session secret: %w", err)
+	}
 
 	// Initialize auth store and OAuth providers when auth is configured.
 	var authStore *auth.Store
@@ -464,12 +793,18 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 	var githubOAuth *auth.ProviderConfig
 	var gitlabOAuth *auth.ProviderConfig
 	var allowedHost string
+	externalURL := strings.TrimSuffix(cfg.ExternalURL, "/")
 	if cfg.ExternalURL != "" {
 		u, err := url.Parse(cfg.ExternalURL)
 		if err != nil {
 			return nil, fmt.Errorf("parse ExternalURL: %w", err)
 		}
 		allowedHost = u.Hostname()
+		if p := strings.TrimSuffix(u.Path, "/"); p == "" {
+			// OAuth callbacks are routed under the base path.
+			base, _ := cleanBasePath(cfg.BasePath)
+			externalURL = strings.TrimSuffix(cfg.ExternalURL, "/") + base
+		}
 		secret, err := hexDecode(settings.SessionSecret)
 		if err != nil {
 			return nil, fmt.Errorf("decode session secret: %w", err)
@@ -481,11 +816,11 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 		}
 		authStore = store
 		if cfg.GitHubOAuthClientID != "" && cfg.GitHubOAuthClientSecret != "" {
-			c := auth.GitHubConfig(cfg.GitHubOAuthClientID, cfg.GitHubOAuthClientSecret, cfg.ExternalURL)
+			c := auth.GitHubConfig(cfg.GitHubOAuthClientID, cfg.GitHubOAuthClientSecret, externalURL)
 			githubOAuth = &c
 		}
 		if cfg.GitLabOAuthClientID != "" && cfg.GitLabOAuthClientSecret != "" {
-			c := auth.GitLabConfig(cfg.GitLabOAuthClientID, cfg.GitLabOAuthClientSecret, cfg.GitLabURL, cfg.ExternalURL)
+			c := auth.GitLabConfig(cfg.GitLabOAuthClientID, cfg.GitLabOAuthClientSecret, cfg.GitLabURL, externalURL)
 			gitlabOAuth = &c
 		}
 	}
@@ -499,7 +834,26 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 		return nil, fmt.Errorf("open preferences: %w", err)
 	}
 
+	drafts, err := openDraftStore(filepath.Join(cfg.ConfigDir, "drafts.json"))
+	if err != nil {
+		return nil, fmt.Errorf("open drafts: %w", err)
+	}
+	evals, err := openEvalStore(filepath.Join(cfg.ConfigDir, "evals.json"))
+	if err != nil {
+		return nil, fmt.Errorf("open evals: %w", err)
+	}
+	labels, err := openLabelStore(filepath.Join(cfg.ConfigDir, "labels.json"))
+	if err != nil {
+		return nil, fmt.Errorf("open labels: %w", err)
+	}
+
 	backend := &mdBackend{client: mdClient}
+	if gpus > 0 && !backend.gpuPassthrough() {
+		// Advertising the GPUs would queue GPU tasks only for them to fail
+		// at launch; preflight refuses them instead.
+		slog.Warn("gpu", "msg", "GPU passthrough is not supported by the md container backend; GPU tasks are disabled", "count", gpus)
+		gpus = 0
+	}
 
 	cachePath := filepath.Join(cfg.CacheDir, "ci_results.json")
 	cache, err := forgecache.Open(cachePath)
@@ -508,6 +862,13 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 		cache, _ = forgecache.Open("")
 	}
 
+	historyPath := filepath.Join(cfg.CacheDir, "usage_history.jsonl")
+	history, err := usagehistory.Open(historyPath)
+	if err != nil {
+		slog.Warn("cannot open usage history; falling back to in-memory", "path", historyPath, "err", err)
+		history, _ = usagehistory.Open("")
+	}
+
 	s := &Server{
 		ctx:                  ctx,
 		absRoot:              absRoot,
@@ -517,12 +878,17 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 		prefs:                prefsStore,
 		authStore:            authStore,
 		sessionSecret:        sessionSecret,
+		shareSecret:          shareSecret,
 		githubOAuth:          githubOAuth,
 		gitlabOAuth:          gitlabOAuth,
 		githubAllowedUsers:   githubAllowedUsers,
 		gitlabAllowedUsers:   gitlabAllowedUsers,
 		allowedHost:          allowedHost,
 		usage:                newUsageFetcher(ctx),
+		orgUsage:             newOrgUsageFetcher(cfg.AnthropicAdminKey),
+		usageHistory:         history,
+		search:               search.New(),
+		summaries:            &summaryStore{dir: filepath.Join(cfg.CacheDir, "summaries")},
 		geminiAPIKey:         cfg.GeminiAPIKey,
 		githubToken:          cfg.GitHubToken,
 		gitlabToken:          cfg.GitLabToken,
@@ -536,7 +902,49 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 		tasks:                make(map[string]*taskEntry),
 		repoCIStatus:         make(map[string]repoCIState),
 		changed:              make(chan struct{}),
+		configChanged:        make(chan struct{}),
 		githubInstallations:  make(map[string]int64),
+		logRing:              cfg.LogRing,
+		repoGit:              repoGit,
+		repoImages:           repoImages,
+		repoPolicies:         repoPolicies,
+		repoLimits:           repoLimits,
+		repoWorkHours:        repoWorkHours,
+		imageAvailable:       container.ImageAvailable,
+		runtimeCaps:          caps,
+		localImages:          container.LocalImages,
+		caicContainers:       container.List,
+		prefetch:             prefetch,
+		agentVersions:        agentVersions,
+		disk:                 disk,
+		resourceInterval:     resourceInterval,
+		stuck:                stuck,
+		signing:              signing,
+		userSigning:          userSigning,
+		gpus:                 gpus,
+		preempt:              settings.Preempt,
+		spending:             spending,
+		externalBackends:     externalBackends,
+		scrubber:             scrubber,
+		settingsPath:         settingsPath,
+		admins:               settings.Admins,
+		features:             settings.Features,
+		knowledge:            knowledge,
+		overviews:            &overviewStore{dir: filepath.Join(cfg.ConfigDir, "overview")},
+		repoMaps:             settings.repoMapStore(filepath.Join(cfg.ConfigDir, "repomap")),
+		drafts:               drafts,
+		evals:                evals,
+		labels:               labels,
+		annotations:          annotationStore{dir: logDir},
+	}
+	if cfg.StrictParse {
+		s.deadLetterDir = filepath.Join(logDir, "deadletter")
+	}
+	if chaosCfg != nil {
+		s.chaos = chaos.New(chaosCfg)
+	}
+	if settings.CheckAgentUpdates {
+		s.latestAgentVersion = npmLatestVersion
 	}
 	s.githubWebhookSecret = cfg.GitHubWebhookSecret
 	s.gitlabWebhookSecret = cfg.GitLabWebhookSecret
@@ -570,6 +978,11 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 			}
 		}
 	}
+	// Titles are pushed to clients through the global task event stream.
+	// Without an LLM, titles are derived from the prompt.
+	s.titles = task.NewTitleQueue(s.provider, func(*task.Task) { s.notifyTaskChange() })
+	s.titles.Existing = s.taskTitles
+	go s.titles.Run(ctx)
 
 	// Phase 2: Runner init (parallel per-repo).
 	type repoResult struct {
@@ -591,10 +1004,14 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 			}
 			remote := gitutil.RemoteOriginURL(ctx, abs)
 			runner := &task.Runner{
-				BaseBranch: branch,
-				Dir:        abs,
-				LogDir:     logDir,
-				Container:  backend,
+				BaseBranch:    branch,
+				Dir:           abs,
+				Git:           repoGit[rel],
+				LogDir:        logDir,
+				DeadLetterDir: s.deadLetterDir,
+				Backends:      s.newBackends(),
+				Container:     s.runnerContainer(),
+				AgentVersions: agentVersions[rel],
 			}
 			if err := runner.Init(ctx); err != nil {
 				slog.Warn("runner init failed", "path", abs, "err", err)
@@ -633,14 +1050,17 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 
 	// Always register a no-repo runner (keyed by "") for tasks that don't
 	// need a git repository.
-	noRepoRunner := &task.Runner{LogDir: logDir, Container: backend}
+	noRepoRunner := &task.Runner{LogDir: logDir, DeadLetterDir: s.deadLetterDir, Backends: s.newBackends(), Container: s.runnerContainer()}
 	_ = noRepoRunner.Init(ctx) // populates Backends; no-op for no-repo (no branches to scan)
 	s.runners[""] = noRepoRunner
+	s.logDoctor(ctx)
 
 	// Phase 3: Load purged tasks from pre-loaded logs.
 	if logRes.err != nil {
 		slog.Warn("load logs failed", "err", logRes.err)
 	} else {
+		// Before loadPurgedTasksFrom, which synthesizes missing results.
+		s.history = loadHistory(logRes.logs, time.Now())
 		if err := s.loadPurgedTasksFrom(logRes.logs); err != nil {
 			return nil, fmt.Errorf("load purged tasks: %w", err)
 		}
@@ -658,6 +1078,17 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 	// Resume bot comment watchers for adopted tasks with pending forge issues.
 	s.bot.ResumePendingComments()
 
+	s.tls = newTLSSettings(cfg)
+	s.hostTailscaleServe = cfg.HostTailscaleServe
+	s.mdns = cfg.MDNS
+	s.recordDir = cfg.RecordDir
+	if s.basePath, err = cleanBasePath(cfg.BasePath); err != nil {
+		return nil, err
+	}
+	if s.trustedProxies, err = parseTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+
 	if cfg.IPGeoDB != "" {
 		checker, err := ipgeo.Open(cfg.IPGeoDB)
 		if err != nil {
@@ -670,6 +1101,16 @@ func New(ctx context.Context, rootDir string, cfg *Config) (*Server, error) {
 
 	s.watchContainerEvents(ctx)
 	go s.warmupImages()
+	go s.monitorDisk()
+	go s.monitorResources()
+	go s.refreshPricing()
+	go s.monitorSpending()
+	go s.monitorAsks()
+	go s.monitorStuck()
+	go s.monitorBranches()
+	go s.monitorRepoMaps()
+	go s.recordUsage()
+	go s.indexLogs(logRes.logs)
 	return s, nil
 }
 
@@ -691,62 +1132,138 @@ func (s *Server) buildHandler() (http.Handler, error) {
 	apiMux := http.NewServeMux()
 	apiMux.HandleFunc("GET /api/v1/server/preferences", handle(s.getPreferences))
 	apiMux.HandleFunc("POST /api/v1/server/preferences", handle(s.updatePreferences))
+	apiMux.HandleFunc("GET /api/v1/server/spending", handle(s.getSpending))
+	apiMux.HandleFunc("POST /api/v1/server/spending/override", handle(s.overrideSpending))
+	apiMux.HandleFunc("POST /api/v1/server/features", handle(s.updateFeatures))
 	apiMux.HandleFunc("GET /api/v1/server/harnesses", handle(s.listHarnesses))
+	apiMux.HandleFunc("GET /api/v1/server/harnesses/versions", handle(s.listAgentVersions))
+	apiMux.HandleFunc("GET /api/v1/server/status", handle(s.getServerStatus))
+	apiMux.HandleFunc("GET /api/v1/server/doctor", handle(s.getDoctor))
 	apiMux.HandleFunc("GET /api/v1/server/caches", handle(s.listCaches))
+	apiMux.HandleFunc("GET /api/v1/server/images", handle(s.listImages))
+	apiMux.HandleFunc("GET /api/v1/server/containers", handle(s.listContainers))
 	apiMux.HandleFunc("GET /api/v1/server/repos", handle(s.listRepos))
 	apiMux.HandleFunc("POST /api/v1/server/repos", handle(s.cloneRepo))
 	apiMux.HandleFunc("GET /api/v1/server/repos/branches", s.handleListRepoBranches)
+	apiMux.HandleFunc("POST /api/v1/server/repos/branches/prune", handle(s.pruneRepoBranches))
+	apiMux.HandleFunc("GET /api/v1/server/costs", handle(s.getCostReport))
+	apiMux.HandleFunc("GET /api/v1/server/repos/heatmap", s.handleGetRepoHeatmap)
+	apiMux.HandleFunc("GET /api/v1/server/repos/tools", s.handleGetRepoTools)
+	apiMux.HandleFunc("GET /api/v1/server/repos/knowledge", s.handleGetRepoKnowledge)
+	apiMux.HandleFunc("POST /api/v1/server/repos/knowledge", handle(s.updateRepoKnowledge))
+	apiMux.HandleFunc("GET /api/v1/server/repos/map", s.handleGetRepoMap)
+	apiMux.HandleFunc("GET /api/v1/server/repos/overview", s.handleGetRepoOverview)
+	apiMux.HandleFunc("POST /api/v1/server/repos/map/refresh", handle(s.refreshRepoMapNow))
 	apiMux.HandleFunc("POST /api/v1/bot/fix-ci", handle(s.botFixCI))
 	apiMux.HandleFunc("POST /api/v1/bot/fix-pr", handle(s.botFixPR))
+	apiMux.HandleFunc("GET /api/v1/drafts", handle(s.listDrafts))
+	apiMux.HandleFunc("POST /api/v1/drafts", handle(s.createDraft))
+	apiMux.HandleFunc("POST /api/v1/drafts/start", handle(s.startDrafts))
+	apiMux.HandleFunc("POST /api/v1/drafts/{id}", handleWithDraft(s, s.updateDraft))
+	apiMux.HandleFunc("POST /api/v1/drafts/{id}/delete", handleWithDraft(s, s.deleteDraft))
+	apiMux.HandleFunc("POST /api/v1/drafts/{id}/start", handleWithDraft(s, s.startDraft))
+	apiMux.HandleFunc("GET /api/v1/events/schema", handle(s.getEventSchema))
+	apiMux.HandleFunc("GET /api/v1/evals", handle(s.listEvals))
+	apiMux.HandleFunc("POST /api/v1/evals", handle(s.createEval))
+	apiMux.HandleFunc("GET /api/v1/evals/{id}", s.getEval)
 	apiMux.HandleFunc("GET /api/v1/tasks", handle(s.listTasks))
 	apiMux.HandleFunc("POST /api/v1/tasks", handle(s.createTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/validate", handle(s.validateTask))
+	apiMux.HandleFunc("GET /api/v2/tasks", handle(s.listTasksV2))
+	apiMux.HandleFunc("GET /api/v1/tasks/labeled", s.handleListLabeledTasks)
+	apiMux.HandleFunc("POST /api/v1/tasks/bulk", handle(s.bulkTasks))
 	apiMux.HandleFunc("GET /api/v1/tasks/{id}/raw_events", s.handleTaskRawEvents)
 	apiMux.HandleFunc("GET /api/v1/tasks/{id}/events", s.handleTaskEvents)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/events/range", s.handleTaskEventRange)
 	apiMux.HandleFunc("POST /api/v1/tasks/{id}/input", handleWithTask(s, s.sendInput))
 	apiMux.HandleFunc("POST /api/v1/tasks/{id}/restart", handleWithTask(s, s.restartTask))
 	apiMux.HandleFunc("POST /api/v1/tasks/{id}/stop", handleWithTask(s, s.stopTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/clean", handleWithTask(s, s.cleanTask))
 	apiMux.HandleFunc("POST /api/v1/tasks/{id}/purge", handleWithTask(s, s.purgeTask))
 	apiMux.HandleFunc("POST /api/v1/tasks/{id}/revive", handleWithTask(s, s.reviveTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/pause", handleWithTask(s, s.pauseTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/resume", handleWithTask(s, s.resumeTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/handoff", handleWithTask(s, s.handoffTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/handoff/import", handleWithTask(s, s.importHandoff))
 	apiMux.HandleFunc("GET /api/v1/tasks/{id}/ci-log", s.handleGetCILog)
 	apiMux.HandleFunc("POST /api/v1/tasks/{id}/sync", handleWithTask(s, s.syncTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/apply", handleWithTask(s, s.applyTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/replay", handleWithTask(s, s.replayTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/label", handleWithTask(s, s.labelTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/share", handleWithTask(s, s.shareTask))
 	apiMux.HandleFunc("GET /api/v1/tasks/{id}/diff", s.handleGetDiff)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/export.html", s.handleExportTask)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/commits", s.handleGetTaskCommits)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/snapshot.tar.gz", s.handleGetTaskSnapshot)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/usage", s.handleGetTaskUsage)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/tools", s.handleGetTaskTools)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/resources", s.handleGetTaskResources)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/env", s.handleGetTaskEnv)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/annotations", s.handleListAnnotations)
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/annotations", handleWithTask(s, s.annotateTask))
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/annotations/{annotationID}/delete", s.handleDeleteAnnotation)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/commands", s.handleGetTaskCommands)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/todos", s.handleGetTaskTodos)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/dead-letters", s.handleGetTaskDeadLetters)
+	apiMux.HandleFunc("GET /api/v1/tasks/{id}/summary", s.handleGetTaskSummary)
+	apiMux.HandleFunc("POST /api/v1/tasks/{id}/summary", handleWithTask(s, s.summarizeTask))
 	apiMux.HandleFunc("GET /api/v1/tasks/{id}/tool/{toolUseID}", s.handleTaskToolInput)
 	apiMux.HandleFunc("GET /api/v1/usage", s.handleGetUsage)
+	apiMux.HandleFunc("GET /api/v1/usage/history", s.handleGetUsageHistory)
+	apiMux.HandleFunc("GET /api/v1/search", s.handleSearch)
 	apiMux.HandleFunc("GET /api/v1/voice/token", handle(s.getVoiceToken))
 	apiMux.HandleFunc("POST /api/v1/web/fetch", handle(s.webFetch))
 	apiMux.HandleFunc("GET /api/v1/server/tasks/events", s.handleTaskListEvents)
+	apiMux.HandleFunc("GET /api/v1/server/config/events", s.handleConfigEvents)
 	apiMux.HandleFunc("GET /api/v1/server/usage/events", s.handleUsageEvents)
+	apiMux.HandleFunc("GET /api/v1/server/logs/events", s.handleServerLogEvents)
 
 	// Combine: auth routes first, then protected API routes (gated by RequireUser when auth enabled).
-	var protectedAPI http.Handler = apiMux
+	protectedAPI := routeSpan(apiMux)
 	if s.authEnabled() {
-		protectedAPI = auth.RequireUser(apiMux)
+		protectedAPI = auth.RequireUser(protectedAPI)
 	}
 
 	mux := http.NewServeMux()
-	mux.Handle("/api/v1/auth/", authMux)
+	mux.Handle("/api/v1/auth/", routeSpan(authMux))
 	mux.HandleFunc("GET /api/v1/server/config", handle(s.getConfig))
 	mux.HandleFunc("POST /webhooks/github", s.handleGitHubWebhook)
 	mux.HandleFunc("POST /webhooks/gitlab", s.handleGitLabWebhook)
+	// Read-only task links, authorized by the share token instead of a session.
+	mux.HandleFunc("GET /api/v1/shared/{token}", s.shared(s.handleGetSharedTask))
+	mux.HandleFunc("GET /api/v1/shared/{token}/events", s.shared(s.handleTaskEvents))
+	mux.HandleFunc("GET /api/v1/shared/{token}/events/range", s.shared(s.handleTaskEventRange))
+	mux.HandleFunc("GET /api/v1/shared/{token}/diff", s.shared(s.handleGetDiff))
+	mux.HandleFunc("GET /api/v1/shared/{token}/export.html", s.shared(s.handleExportTask))
 	mux.Handle("/api/v1/", protectedAPI)
+	mux.Handle("/api/v2/", protectedAPI)
 
 	// Serve embedded frontend with SPA fallback and precompressed variants.
 	dist, err := fs.Sub(frontend.Files, "dist")
 	if err != nil {
 		return nil, err
 	}
+	if s.basePath != "" {
+		if dist, err = rebaseIndex(dist, s.basePath); err != nil {
+			return nil, err
+		}
+	}
 	mux.HandleFunc("/", newStaticHandler(dist))
 
-	// Middleware chain: logging → host check → auth → decompress → compress → mux.
-	var inner http.Handler = mux
+	// Middleware chain: forwarded headers → logging → base path → host check →
+	// auth → decompress → compress → API version → mux.
+	inner := apiVersionMiddleware(apiMux, routeSpan(mux))
 	inner = compressMiddleware(inner)
 	inner = decompressMiddleware(inner)
 	inner = auth.Middleware(s.authStore, s.sessionSecret)(inner)
 	if s.allowedHost != "" {
 		inner = hostCheckMiddleware(s.allowedHost, inner)
 	}
+	if s.basePath != "" {
+		inner = basePathMiddleware(s.basePath, inner)
+	}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	var outer http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		clientIP := ipgeo.GetClientIP(r)
 		cc := s.ipgeoChecker.CountryCode(clientIP)
 		if !s.ipgeoAllowlist.Allowed(cc) {
@@ -755,8 +1272,10 @@ func (s *Server) buildHandler() (http.Handler, error) {
 			return
 		}
 		start := time.Now()
-		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		r, span := startHTTPSpan(r)
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK, span: span}
 		inner.ServeHTTP(rw, r)
+		rw.endSpan()
 		logFn := slog.InfoContext
 		if rw.status < 300 {
 			logFn = slog.DebugContext
@@ -770,10 +1289,15 @@ func (s *Server) buildHandler() (http.Handler, error) {
 			"ip", clientIP,
 			"cc", cc,
 		)
-	}), nil
+	})
+	if len(s.trustedProxies) != 0 {
+		outer = forwardedMiddleware(s.trustedProxies, outer)
+	}
+	return outer, nil
 }
 
 // ListenAndServe starts the HTTP server on addr and blocks until ctx is cancelled.
+// addr is a TCP address or a Unix domain socket path prefixed with "unix:".
 func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
 	handler, err := s.buildHandler()
 	if err != nil {
@@ -788,17 +1312,63 @@ func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
 			return ctx
 		},
 	}
+	var redirect *http.Server
+	if s.tls.enabled() {
+		cfg, redirectHandler, err := s.tls.config()
+		if err != nil {
+			return fmt.Errorf("tls: %w", err)
+		}
+		srv.TLSConfig = cfg
+		if s.tls.redirectAddr != "" {
+			redirect = &http.Server{
+				Addr:              s.tls.redirectAddr,
+				Handler:           redirectHandler,
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+		}
+	}
+	ln, err := listen(ctx, addr)
+	if err != nil {
+		return err
+	}
+	unserve := func() {}
+	if s.hostTailscaleServe != "" {
+		if unserve, err = hostTailscaleServe(ctx, ln, s.hostTailscaleServe, srv.TLSConfig != nil); err != nil {
+			_ = ln.Close()
+			return err
+		}
+	}
+	if s.mdns {
+		s.advertise(ctx, ln)
+	}
 	shutdownDone := make(chan struct{})
 	go func() { //nolint:gosec // G118: goroutine intentionally uses Background; parent ctx is already cancelled at shutdown
 		defer close(shutdownDone)
 		<-ctx.Done()
+		unserve()
 		// Use Background because the parent ctx is already cancelled.
 		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if redirect != nil {
+			_ = redirect.Shutdown(shutdownCtx) //nolint:contextcheck // parent ctx is already cancelled at shutdown time
+		}
 		_ = srv.Shutdown(shutdownCtx) //nolint:contextcheck // parent ctx is already cancelled at shutdown time
 		shutdownCancel()
 	}()
-	slog.Info("listening", "addr", addr)
-	err = srv.ListenAndServe()
+	if redirect != nil {
+		go func() {
+			slog.Info("listening", "addr", redirect.Addr, "redirect", "https")
+			if err := redirect.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				slog.Error("redirect listener", "err", err)
+			}
+		}()
+	}
+	slog.Info("listening", "addr", ln.Addr().String(), "tls", srv.TLSConfig != nil)
+	if srv.TLSConfig != nil {
+		// Certificates come from TLSConfig; HTTP/2 is negotiated over ALPN.
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		err = srv.Serve(ln)
+	}
 	if errors.Is(err, http.ErrServerClosed) {
 		<-shutdownDone
 		return nil
@@ -811,14 +1381,23 @@ func (s *Server) getConfig(_ context.Context, _ *dto.EmptyReq) (*v1.Config, erro
 		TailscaleAvailable: s.mdClient.TailscaleAPIKey != "",
 		USBAvailable:       runtime.GOOS == "linux",
 		DisplayAvailable:   true,
+		GPUCount:           s.gpus,
 		GitHubAppEnabled:   s.githubApp != nil,
 	}
 	if s.authEnabled() {
 		cfg.AuthProviders = s.authProviders()
 	}
+	s.mu.Lock()
+	cfg.Features = s.features
+	s.mu.Unlock()
 	return cfg, nil
 }
 
+// getEventSchema returns the event kinds the task event streams may emit.
+func (s *Server) getEventSchema(_ context.Context, _ *dto.EmptyReq) (*v1.EventSchemaResp, error) {
+	return v1.EventSchema(), nil
+}
+
 func (s *Server) getPreferences(ctx context.Context, _ *dto.EmptyReq) (*v1.PreferencesResp, error) {
 	prefs := s.prefs.Get(userIDFromCtx(ctx))
 	recent := prefs.RecentRepos(time.Now())
@@ -877,6 +1456,27 @@ func (s *Server) updatePreferences(ctx context.Context, req *v1.UpdatePreference
 	return s.getPreferences(ctx, nil)
 }
 
+// newBackends returns the backends of a new runner: the built-in ones plus
+// the external harnesses registered in settings.json.
+func (s *Server) newBackends() map[agent.Harness]agent.Backend {
+	m := task.DefaultBackends()
+	for _, b := range s.externalBackends {
+		m[b.Harness()] = b
+	}
+	if s.chaos != nil {
+		return s.chaos.Backends(m)
+	}
+	return m
+}
+
+// runnerContainer returns the container backend of a new runner.
+func (s *Server) runnerContainer() task.ContainerBackend {
+	if s.chaos != nil {
+		return s.chaos.Container(s.backend)
+	}
+	return s.backend
+}
+
 func (s *Server) listHarnesses(_ context.Context, _ *dto.EmptyReq) (*[]v1.HarnessInfo, error) {
 	// Collect unique harness backends from all runners.
 	seen := make(map[agent.Harness]agent.Backend)
@@ -1008,7 +1608,11 @@ func (s *Server) cloneRepo(ctx context.Context, req *v1.CloneRepoReq) (*v1.Repo,
 	// Run git clone with timeout.
 	cloneCtx, cancel := context.WithTimeout(ctx, 10*time.Minute)
 	defer cancel()
-	args := []string{"clone", "--depth", strconv.Itoa(depth), "--recurse-submodules", "--shallow-submodules", req.URL, absTarget}
+	args := []string{"clone", "--depth", strconv.Itoa(depth), "--recurse-submodules", "--shallow-submodules"}
+	if req.Filter != "" {
+		args = append(args, "--filter="+req.Filter)
+	}
+	args = append(args, req.URL, absTarget)
 	cmd := exec.CommandContext(cloneCtx, "git", args...) //nolint:gosec // args are validated: depth is an int, URL is user-provided input, absTarget is validated above
 	if out, err := cmd.CombinedOutput(); err != nil {
 		// Clean up partial clone.
@@ -1026,11 +1630,19 @@ func (s *Server) cloneRepo(ctx context.Context, req *v1.CloneRepoReq) (*v1.Repo,
 	remote := gitutil.RemoteOriginURL(ctx, absTarget)
 
 	// Create and init runner.
+	gitOpts := s.repoGit[targetPath]
+	if gitOpts.FetchFilter == "" {
+		gitOpts.FetchFilter = req.Filter
+	}
 	runner := &task.Runner{
-		BaseBranch: branch,
-		Dir:        absTarget,
-		LogDir:     s.logDir,
-		Container:  s.backend,
+		BaseBranch:    branch,
+		Dir:           absTarget,
+		Git:           gitOpts,
+		LogDir:        s.logDir,
+		DeadLetterDir: s.deadLetterDir,
+		Backends:      s.newBackends(),
+		Container:     s.runnerContainer(),
+		AgentVersions: s.agentVersions[targetPath],
 	}
 	if err := runner.Init(ctx); err != nil {
 		_ = os.RemoveAll(absTarget)
@@ -1043,8 +1655,11 @@ func (s *Server) cloneRepo(ctx context.Context, req *v1.CloneRepoReq) (*v1.Repo,
 		cloneForgeKind, cloneForgeOwner, cloneForgeRepo, _ = forge.ParseRemoteURL(rawURL)
 	}
 	info := repoInfo{RelPath: targetPath, AbsPath: absTarget, BaseBranch: branch, Remote: remote, ForgeKind: cloneForgeKind, ForgeOwner: cloneForgeOwner, ForgeRepo: cloneForgeRepo}
+	s.mu.Lock()
 	s.repos = append(s.repos, info)
 	s.runners[targetPath] = runner
+	s.configChangedLocked()
+	s.mu.Unlock()
 	slog.Info("cloned repo", "url", req.URL, "path", targetPath)
 
 	return &v1.Repo{Path: targetPath, BaseBranch: branch, RemoteURL: gitutil.RemoteToHTTPS(remote), Forge: v1.Forge(cloneForgeKind)}, nil
@@ -1071,44 +1686,24 @@ func (s *Server) listTasks(ctx context.Context, _ *dto.EmptyReq) (*[]v1.Task, er
 }
 
 func (s *Server) createTask(ctx context.Context, req *v1.CreateTaskReq) (*v1.CreateTaskResp, error) {
-	// Resolve primary runner (first repo, or no-repo).
-	var primaryRunner *task.Runner
-	if len(req.Repos) > 0 {
-		r, ok := s.runners[req.Repos[0].Name]
-		if !ok {
-			return nil, dto.BadRequest("unknown repo: " + req.Repos[0].Name)
-		}
-		primaryRunner = r
-	} else {
-		r, ok := s.runners[""]
-		if !ok {
-			return nil, dto.InternalError("no-repo runner not available")
-		}
-		primaryRunner = r
-	}
-
-	// Validate and resolve extra repo runners.
-	extraRunners := make([]*task.Runner, 0, max(0, len(req.Repos)-1))
-	for _, rs := range req.Repos[min(1, len(req.Repos)):] {
-		er, ok := s.runners[rs.Name]
-		if !ok {
-			return nil, dto.BadRequest("unknown extra repo: " + rs.Name)
-		}
-		extraRunners = append(extraRunners, er)
-	}
+	return s.newTask(ctx, req, nil)
+}
 
-	harness := toAgentHarness(req.Harness)
-	backend, ok := primaryRunner.Backends[harness]
-	if !ok {
-		return nil, dto.BadRequest("unknown harness: " + string(req.Harness))
+// newTask creates and starts a task. src is non-nil when the task must start
+// from a given commit, to replay a previous task or as part of an evaluation.
+func (s *Server) newTask(ctx context.Context, req *v1.CreateTaskReq, src *replaySource) (*v1.CreateTaskResp, error) {
+	plan, rep := s.preflight(ctx, req, false)
+	if rep.err != nil {
+		return nil, rep.err
 	}
 
-	if req.Model != "" && !slices.Contains(backend.Models(), req.Model) {
-		return nil, dto.BadRequest("unsupported model for " + string(req.Harness) + ": " + req.Model)
+	var primaryRepo *v1.RepoSpec
+	if len(req.Repos) > 0 {
+		primaryRepo = &req.Repos[0]
 	}
-
-	if len(req.InitialPrompt.Images) > 0 && !backend.SupportsImages() {
-		return nil, dto.BadRequest(string(req.Harness) + " does not support images")
+	sections, err := s.promptContext(ctx, req.Context, primaryRepo)
+	if err != nil {
+		return nil, err
 	}
 
 	var ownerID string
@@ -1120,36 +1715,76 @@ func (s *Server) createTask(ctx context.Context, req *v1.CreateTaskReq) (*v1.Cre
 	mounts := make([]task.RepoMount, len(req.Repos))
 	for i, rs := range req.Repos {
 		r := s.runners[rs.Name]
-		mounts[i] = task.RepoMount{Name: rs.Name, BaseBranch: rs.BaseBranch, GitRoot: r.Dir}
+		mounts[i] = task.RepoMount{Name: rs.Name, BaseBranch: rs.BaseBranch, GitRoot: r.Dir, SparsePaths: task.SparsePaths(rs.Paths, r.Git.SparseShared), Submodules: r.Git.Submodules, LFS: r.Git.LFS, Filter: r.Git.FetchFilter}
+	}
+	knowledge, repoMap, promptContext, budget := task.AssembleContext(s.taskKnowledge(mounts), s.taskRepoMap(mounts), sections, contextBudget(plan.backend.ContextWindowLimit(req.Model)), task.TokenizerFor(plan.harness, req.Model))
+	if len(budget.Cuts) != 0 {
+		slog.InfoContext(ctx, "prompt context over budget", "budget", budget.Budget, "used", budget.Used, "cuts", budget.Cuts)
+	}
+	var replayOf ksid.ID
+	if src != nil {
+		replayOf = src.id
+		if len(mounts) > 0 {
+			mounts[0].BaseSHA = src.baseSHA
+		}
 	}
 
 	t := &task.Task{
 		ID:            ksid.NewID(),
-		InitialPrompt: v1PromptToAgent(req.InitialPrompt),
+		InitialPrompt: plan.prompt,
 		Repos:         mounts,
-		Harness:       harness,
+		Harness:       plan.harness,
 		Model:         req.Model,
-		DockerImage:   req.Image,
+		DockerImage:   plan.image,
 		Tailscale:     req.Tailscale,
 		USB:           req.USB,
 		Display:       req.Display,
+		GPU:           req.GPU,
+		AutoResume:    req.AutoResume,
+		AskPolicy:     toTaskAskPolicy(req.AskPolicy),
+		Automation:    task.Automation(req.Automation),
+		Priority:      toTaskPriority(req.Priority),
 		StartedAt:     time.Now().UTC(),
 		OwnerID:       ownerID,
-		Provider:      s.provider,
-	}
-	t.SetTitle(req.InitialPrompt.Text)
-	go t.GenerateTitle(s.ctx) //nolint:contextcheck // fire-and-forget; must outlive request
+		Titles:        s.titles,
+		Knowledge:     knowledge,
+		RepoMap:       repoMap,
+		ReplayOf:      replayOf,
+		Kind:          plan.kind,
+		Scrub:         s.scrubber,
+	}
+	s.setTaskHooks(t)
+	// Scrub before anything, like the title, sees the prompt.
+	t.InitialPrompt = t.ScrubPrompt(t.InitialPrompt)
+	t.Context = t.ScrubPrompt(agent.Prompt{Text: promptContext}).Text
+	t.SetTitle(task.LocalTitle(t.InitialPrompt.Text))
 	entry := &taskEntry{task: t, done: make(chan struct{})}
+	repoNames := make([]string, len(mounts))
+	for i, m := range mounts {
+		repoNames[i] = m.Name
+	}
 
-	s.mu.Lock()
-	s.tasks[t.ID.String()] = entry
-	s.taskChanged()
-	s.mu.Unlock()
+	if err := s.insertTask(ctx, entry, repoNames); err != nil {
+		return nil, err
+	}
+	s.titles.Enqueue(t)
+	s.makeBranchRoom(repoNames)
 
 	// Run in background using the server context, not the request context.
 	go func() {
+		if t.GPU {
+			if err := s.acquireGPU(s.ctx, entry); err != nil {
+				result := task.Result{State: task.StateFailed, Err: err}
+				s.mu.Lock()
+				entry.result = &result
+				s.taskChanged()
+				s.mu.Unlock()
+				close(entry.done)
+				return
+			}
+		}
 		// Allocate branches for extra repos before starting the container.
-		for i, er := range extraRunners {
+		for i, er := range plan.extras {
 			branch, err := er.AllocateBranch(s.ctx)
 			if err != nil {
 				result := task.Result{State: task.StateFailed, Err: fmt.Errorf("allocate branch for extra repo: %w", err)}
@@ -1163,7 +1798,7 @@ func (s *Server) createTask(ctx context.Context, req *v1.CreateTaskReq) (*v1.Cre
 			t.Repos[i+1].Branch = branch
 		}
 
-		h, err := primaryRunner.Start(s.ctx, t)
+		h, err := plan.primary.Start(s.ctx, t)
 		if err != nil {
 			result := task.Result{State: task.StateFailed, Err: err}
 			s.mu.Lock()
@@ -1173,7 +1808,8 @@ func (s *Server) createTask(ctx context.Context, req *v1.CreateTaskReq) (*v1.Cre
 			close(entry.done)
 			return
 		}
-		s.watchSession(entry, primaryRunner, h)
+		go s.captureEnv(s.ctx, t)
+		s.watchSession(entry, plan.primary, h)
 	}()
 
 	go s.maybeFakeCI(t)
@@ -1191,7 +1827,11 @@ func (s *Server) createTask(ctx context.Context, req *v1.CreateTaskReq) (*v1.Cre
 		}
 	}
 
-	return &v1.CreateTaskResp{Status: "accepted", ID: t.ID}, nil
+	resp := &v1.CreateTaskResp{Status: "accepted", ID: t.ID}
+	for _, c := range budget.Cuts {
+		resp.ContextCuts = append(resp.ContextCuts, v1.ContextCut{Name: c.Name, Tokens: c.Tokens, Kept: c.Kept})
+	}
+	return resp, nil
 }
 
 // handleTaskRawEvents delegates to handleTaskEvents — both endpoints now
@@ -1224,6 +1864,7 @@ func (s *Server) handleTaskEvents(w http.ResponseWriter, r *http.Request) {
 	defer unsub()
 
 	tracker := newToolTimingTracker(entry.task.Harness)
+	var status statusTracker
 	idx := 0
 
 	writeEvents := func(events []v1.EventMessage) {
@@ -1241,6 +1882,7 @@ func (s *Server) handleTaskEvents(w http.ResponseWriter, r *http.Request) {
 	now := time.Now()
 	for _, msg := range filterHistoryForReplay(history) {
 		writeEvents(tracker.convertMessage(msg, now))
+		status.update(msg)
 	}
 	_, _ = fmt.Fprint(w, "event: ready\ndata: {}\n\n")
 	flusher.Flush()
@@ -1249,9 +1891,19 @@ func (s *Server) handleTaskEvents(w http.ResponseWriter, r *http.Request) {
 	if state == task.StatePurged || state == task.StateFailed {
 		return
 	}
+	// Only the latest status of the history is relevant.
+	if status.status.Status != "" {
+		writeEvents([]v1.EventMessage{status.event(now.UnixMilli())})
+		flusher.Flush()
+	}
 
 	for msg := range live {
-		writeEvents(tracker.convertMessage(msg, time.Now()))
+		now := time.Now()
+		events := tracker.convertMessage(msg, now)
+		if status.update(msg) {
+			events = append(events, status.event(now.UnixMilli()))
+		}
+		writeEvents(events)
 		flusher.Flush()
 	}
 }
@@ -1281,6 +1933,73 @@ func (s *Server) handleTaskToolInput(w http.ResponseWriter, r *http.Request) {
 	writeError(w, dto.NotFound("tool use"))
 }
 
+// handleGetTaskCommands returns the shell commands run by the agent.
+func (s *Server) handleGetTaskCommands(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	cmds := task.ExtractCommands(entry.task.Messages())
+	resp := v1.TaskCommandsResp{Commands: make([]v1.CommandExecution, len(cmds))}
+	for i := range cmds {
+		c := &cmds[i]
+		resp.Commands[i] = v1.CommandExecution{
+			MessageIndex: c.MessageIndex,
+			ToolUseID:    c.ToolUseID,
+			Command:      c.Command,
+			Cwd:          c.Cwd,
+			Done:         c.Done,
+			ExitCode:     c.ExitCode,
+			Duration:     c.Duration.Seconds(),
+			Output:       c.Output,
+			Error:        c.Error,
+		}
+	}
+	writeJSONResponse(w, &resp, nil)
+}
+
+// handleGetTaskTodos returns the latest todo list of the agent.
+func (s *Server) handleGetTaskTodos(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	todos := toV1TodoItems(entry.task.Todos())
+	if todos == nil {
+		todos = []v1.TodoItem{}
+	}
+	writeJSONResponse(w, &v1.TaskTodosResp{Todos: todos}, nil)
+}
+
+// handleGetTaskDeadLetters returns the wire lines of a task that the harness
+// parser dropped, as captured in strict parsing mode.
+func (s *Server) handleGetTaskDeadLetters(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := v1.TaskDeadLettersResp{Enabled: s.deadLetterDir != "", DeadLetters: []v1.DeadLetter{}}
+	if resp.Enabled {
+		dls, err := task.ReadDeadLetters(s.deadLetterDir, entry.task.ID)
+		if err != nil {
+			writeError(w, dto.InternalError("read dead letters: "+err.Error()))
+			return
+		}
+		for _, d := range dls {
+			resp.DeadLetters = append(resp.DeadLetters, v1.DeadLetter{
+				Ts:   float64(d.Ts.UnixMilli()) / 1e3,
+				Type: d.Type,
+				Err:  d.Err,
+				Line: d.Line,
+			})
+		}
+	}
+	writeJSONResponse(w, &resp, nil)
+}
+
 // handleTaskListEvents streams patch events for the task list as SSE. On first
 // iteration it sends a full snapshot; thereafter it sends only upsert/delete
 // events for changed or removed tasks. It pushes immediately when a
@@ -1437,15 +2156,7 @@ func (s *Server) handleUsageEvents(w http.ResponseWriter, r *http.Request) {
 		ch := s.changed
 		s.mu.Unlock()
 
-		if s.usage != nil {
-			if oauth := s.usage.get(); oauth != nil {
-				resp.FiveHour.Utilization = oauth.FiveHour.Utilization
-				resp.FiveHour.ResetsAt = oauth.FiveHour.ResetsAt
-				resp.SevenDay.Utilization = oauth.SevenDay.Utilization
-				resp.SevenDay.ResetsAt = oauth.SevenDay.ResetsAt
-				resp.ExtraUsage = oauth.ExtraUsage
-			}
-		}
+		s.mergeRemoteUsage(&resp)
 
 		data, err := json.Marshal(resp)
 		if err == nil && !bytes.Equal(data, prev) {
@@ -1463,6 +2174,115 @@ func (s *Server) handleUsageEvents(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleConfigEvents streams a ConfigEvent snapshot as SSE: once on connect,
+// then whenever it changes, so that clients do not have to poll getConfig,
+// listRepos and listHarnesses. Repo registration and feature flag changes
+// push immediately; the
+// ticker catches changes that are not signaled, like a repo's CI status.
+func (s *Server) handleConfigEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, dto.InternalError("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	var prev []byte
+
+	for {
+		ev, ch := s.configSnapshot(r.Context())
+		data, err := json.Marshal(ev)
+		if err == nil && !bytes.Equal(data, prev) {
+			_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			flusher.Flush()
+			prev = data
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+		case <-ticker.C:
+		}
+	}
+}
+
+// configSnapshot returns the current ConfigEvent and the channel closed on
+// the next config change.
+func (s *Server) configSnapshot(ctx context.Context) (*v1.ConfigEvent, <-chan struct{}) {
+	cfg, _ := s.getConfig(ctx, nil)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	harnesses, _ := s.listHarnesses(ctx, nil)
+	return &v1.ConfigEvent{Config: *cfg, Repos: *s.reposLocked(), Harnesses: *harnesses}, s.configChanged
+}
+
+// handleServerLogEvents streams the server's own log records as SSE. Buffered
+// records are replayed first, then new ones are sent as they are logged. The
+// optional "level" query parameter (debug, info, warn, error) sets the
+// minimum level. With auth enabled, only admins may read them.
+func (s *Server) handleServerLogEvents(w http.ResponseWriter, r *http.Request) {
+	if s.authStore != nil {
+		u, ok := auth.UserFromContext(r.Context())
+		if !ok || !slices.Contains(s.admins, u.Username) {
+			writeError(w, dto.Forbidden("server logs"))
+			return
+		}
+	}
+	if s.logRing == nil {
+		writeError(w, dto.NotFound("server logs"))
+		return
+	}
+	minLevel, ok := parseLogLevel(r.URL.Query().Get("level"))
+	if !ok {
+		writeError(w, dto.BadRequest("invalid level").WithDetail("level", r.URL.Query().Get("level")))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, dto.InternalError("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher.Flush()
+
+	var seen uint64
+	for {
+		entries, total, ch := s.logRing.since(seen)
+		seen = total
+		sent := false
+		for i := range entries {
+			if entryLevel(&entries[i]) < minLevel {
+				continue
+			}
+			data, err := json.Marshal(&entries[i])
+			if err != nil {
+				continue
+			}
+			_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+			sent = true
+		}
+		if sent {
+			flusher.Flush()
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ch:
+		}
+	}
+}
+
 // sendInput forwards user input to the agent session. On failure, it probes
 // the relay daemon's liveness over SSH and returns diagnostic details in the
 // 409 response so the frontend can show the user what went wrong.
@@ -1550,10 +2370,12 @@ func (s *Server) restartTask(_ context.Context, entry *taskEntry, req *v1.Restar
 
 func (s *Server) stopTask(_ context.Context, entry *taskEntry, _ *dto.EmptyReq) (*v1.StatusResp, error) {
 	state := entry.task.GetState()
-	if state != task.StateWaiting && state != task.StateAsking && state != task.StateHasPlan && state != task.StateRunning {
+	if state != task.StateWaiting && state != task.StateAsking && state != task.StateHasPlan && state != task.StateRunning && state != task.StatePaused {
 		return nil, dto.Conflict("task is not running or waiting")
 	}
-	entry.task.SetState(task.StateStopping)
+	if err := entry.task.Transition(task.StateStopping); err != nil {
+		return nil, dto.Conflict(err.Error())
+	}
 	s.mu.Lock()
 	s.taskChanged()
 	s.mu.Unlock()
@@ -1573,10 +2395,12 @@ func (s *Server) stopTask(_ context.Context, entry *taskEntry, _ *dto.EmptyReq)
 
 func (s *Server) purgeTask(_ context.Context, entry *taskEntry, _ *dto.EmptyReq) (*v1.StatusResp, error) {
 	state := entry.task.GetState()
-	if state != task.StateWaiting && state != task.StateAsking && state != task.StateHasPlan && state != task.StateRunning && state != task.StateStopping && state != task.StateStopped {
+	if state != task.StateWaiting && state != task.StateAsking && state != task.StateHasPlan && state != task.StateRunning && state != task.StatePaused && state != task.StateStopping && state != task.StateStopped {
 		return nil, dto.Conflict("task is not running or waiting")
 	}
-	entry.task.SetState(task.StatePurging)
+	if err := entry.task.Transition(task.StatePurging); err != nil {
+		return nil, dto.Conflict(err.Error())
+	}
 	s.mu.Lock()
 	s.taskChanged()
 	s.mu.Unlock()
@@ -1599,11 +2423,17 @@ func (s *Server) reviveTask(_ context.Context, entry *taskEntry, _ *dto.EmptyReq
 		revivePrimaryName = p.Name
 	}
 	runner := s.runners[revivePrimaryName]
-	entry.task.SetState(task.StateProvisioning)
+	if entry.task.GPU && !s.tryAcquireGPU(entry) {
+		return nil, dto.Conflict("all GPUs are in use")
+	}
+	if err := entry.task.Transition(task.StateProvisioning); err != nil {
+		return nil, dto.Conflict(err.Error())
+	}
 	s.mu.Lock()
 	// Reset done channel so watchSession works on the revived task.
 	entry.done = make(chan struct{})
 	entry.result = nil
+	entry.preempted = false
 	entry.cleanupOnce = sync.Once{}
 	s.taskChanged()
 	s.mu.Unlock()
@@ -1618,6 +2448,91 @@ func (s *Server) reviveTask(_ context.Context, entry *taskEntry, _ *dto.EmptyReq
 	return &v1.StatusResp{Status: "provisioning"}, nil
 }
 
+// pauseTask closes the agent session but keeps the container running, so
+// that resumeTask is quicker than reviving a stopped task.
+func (s *Server) pauseTask(_ context.Context, entry *taskEntry, _ *dto.EmptyReq) (*v1.StatusResp, error) {
+	state := entry.task.GetState()
+	if state != task.StateWaiting && state != task.StateAsking && state != task.StateHasPlan && state != task.StateRunning {
+		return nil, dto.Conflict("task is not running or waiting")
+	}
+	pausePrimaryName := ""
+	if p := entry.task.Primary(); p != nil {
+		pausePrimaryName = p.Name
+	}
+	if err := s.runners[pausePrimaryName].PauseSession(entry.task); err != nil {
+		return nil, dto.Conflict(err.Error())
+	}
+	s.mu.Lock()
+	s.taskChanged()
+	s.mu.Unlock()
+	return &v1.StatusResp{Status: "paused"}, nil
+}
+
+func (s *Server) resumeTask(_ context.Context, entry *taskEntry, _ *dto.EmptyReq) (*v1.StatusResp, error) {
+	if entry.task.GetState() != task.StatePaused {
+		return nil, dto.Conflict("task is not paused")
+	}
+	if err := s.reclaimSlots(entry); err != nil {
+		return nil, err
+	}
+	resumePrimaryName := ""
+	if p := entry.task.Primary(); p != nil {
+		resumePrimaryName = p.Name
+	}
+	runner := s.runners[resumePrimaryName]
+	// Use the server-lifetime context, not the HTTP request context.
+	// The resumed agent session must outlive this request.
+	h, err := runner.ResumeSession(s.ctx, entry.task) //nolint:contextcheck // intentionally using server context
+	if err != nil {
+		return nil, dto.InternalError(err.Error())
+	}
+	s.watchSession(entry, runner, h)
+	s.mu.Lock()
+	s.taskChanged()
+	s.mu.Unlock()
+	return &v1.StatusResp{Status: "resumed"}, nil
+}
+
+// handoffTask pauses the task and returns the commands to continue its
+// conversation interactively in the container.
+func (s *Server) handoffTask(ctx context.Context, entry *taskEntry, _ *dto.EmptyReq) (*v1.HandoffResp, error) {
+	state := entry.task.GetState()
+	if state != task.StateWaiting && state != task.StateAsking && state != task.StateHasPlan && state != task.StateRunning && state != task.StatePaused {
+		return nil, dto.Conflict("task is not running, waiting or paused")
+	}
+	handoffPrimaryName := ""
+	if p := entry.task.Primary(); p != nil {
+		handoffPrimaryName = p.Name
+	}
+	h, err := s.runners[handoffPrimaryName].Handoff(ctx, entry.task)
+	if err != nil {
+		return nil, dto.Conflict(err.Error())
+	}
+	s.mu.Lock()
+	s.taskChanged()
+	s.mu.Unlock()
+	return &v1.HandoffResp{Container: h.Container, Dir: h.Dir, SessionID: h.SessionID, Command: h.Command, SSH: h.SSHCommand(), Script: h.Script()}, nil
+}
+
+// importHandoff merges what was done in the terminal since handoffTask into
+// the task's history. The task stays handed off.
+func (s *Server) importHandoff(ctx context.Context, entry *taskEntry, _ *dto.EmptyReq) (*v1.ImportHandoffResp, error) {
+	importPrimaryName := ""
+	if p := entry.task.Primary(); p != nil {
+		importPrimaryName = p.Name
+	}
+	n, err := s.runners[importPrimaryName].ImportHandoff(ctx, entry.task)
+	if err != nil {
+		return nil, dto.Conflict(err.Error())
+	}
+	if n > 0 {
+		s.mu.Lock()
+		s.taskChanged()
+		s.mu.Unlock()
+	}
+	return &v1.ImportHandoffResp{Messages: n}, nil
+}
+
 func (s *Server) syncTask(ctx context.Context, entry *taskEntry, req *v1.SyncReq) (*v1.SyncResp, error) {
 	t := entry.task
 	switch t.GetState() {
@@ -1625,7 +2540,7 @@ func (s *Server) syncTask(ctx context.Context, entry *taskEntry, req *v1.SyncReq
 		return nil, dto.Conflict("task has no container yet")
 	case task.StateStopping, task.StateStopped, task.StatePurging, task.StateFailed, task.StatePurged:
 		return nil, dto.Conflict("task is in a terminal state")
-	case task.StateBranching, task.StateProvisioning, task.StateStarting, task.StateRunning, task.StateWaiting, task.StateAsking, task.StateHasPlan, task.StatePulling, task.StatePushing:
+	case task.StateBranching, task.StateProvisioning, task.StateStarting, task.StateRunning, task.StateWaiting, task.StateAsking, task.StateHasPlan, task.StatePaused, task.StatePulling, task.StatePushing:
 	}
 	syncPrimaryName := ""
 	syncPrimaryBranch := ""
@@ -1646,7 +2561,7 @@ func (s *Server) syncTask(ctx context.Context, entry *taskEntry, req *v1.SyncReq
 		if message == "" {
 			message = t.InitialPrompt.Text
 		}
-		ds, issues, err := runner.SyncToDefault(ctx, syncPrimaryBranch, t.Container, message, t.ExtraMDRepos())
+		ds, issues, err := runner.SyncToDefault(ctx, syncPrimaryBranch, t.Container, message, t.ExtraMDRepos(), s.syncOptions(runner, t))
 		if err != nil {
 			return nil, dto.InternalError(err.Error())
 		}
@@ -1660,7 +2575,7 @@ func (s *Server) syncTask(ctx context.Context, entry *taskEntry, req *v1.SyncReq
 	}
 
 	// Default: push to the task's own branch.
-	ds, issues, err := runner.SyncToOrigin(ctx, syncPrimaryBranch, t.Container, req.Force, t.ExtraMDRepos())
+	ds, issues, err := runner.SyncToOrigin(ctx, syncPrimaryBranch, t.Container, req.Force, t.ExtraMDRepos(), s.syncOptions(runner, t))
 	if err != nil {
 		return nil, dto.InternalError(err.Error())
 	}
@@ -1690,6 +2605,195 @@ func (s *Server) syncTask(ctx context.Context, entry *taskEntry, req *v1.SyncReq
 	return resp, nil
 }
 
+// branchNotes returns the notes committed on the branch of t, or "" when its
+// repository doesn't enable them.
+func branchNotes(r *task.Runner, t *task.Task) string {
+	if !r.Git.TaskNotes {
+		return ""
+	}
+	return t.Notes()
+}
+
+// syncOptions returns what is added to the commits of t synced to origin:
+// the notes and trailers its repository enables and the signature, with the
+// key of its owner when configured. The owner is credited as co-author with
+// the no-reply address of their forge account.
+func (s *Server) syncOptions(r *task.Runner, t *task.Task) *task.SyncOptions {
+	opts := &task.SyncOptions{Notes: branchNotes(r, t), Sign: s.signing}
+	var owner *auth.User
+	if s.authStore != nil && t.OwnerID != "" {
+		if u, ok := s.authStore.FindByID(t.OwnerID); ok {
+			owner = &u
+			if sign, ok := s.userSigning[u.Username]; ok {
+				opts.Sign = sign
+			}
+		}
+	}
+	if !r.Git.NoTrailers {
+		coAuthor := ""
+		if owner != nil {
+			coAuthor = noReplyAuthor(owner)
+		}
+		opts.Trailers = t.Trailers(coAuthor)
+	}
+	return opts
+}
+
+// noReplyAuthor returns the name and no-reply email address of the forge
+// account of u, e.g. "jane <42+jane@users.noreply.github.com>".
+func noReplyAuthor(u *auth.User) string {
+	if u.Username == "" || u.ProviderID == "" {
+		return ""
+	}
+	switch u.Provider {
+	case forge.KindGitHub:
+		return u.Username + " <" + u.ProviderID + "+" + u.Username + "@users.noreply.github.com>"
+	case forge.KindGitLab:
+		return u.Username + " <" + u.ProviderID + "-" + u.Username + "@users.noreply.gitlab.com>"
+	default:
+		return ""
+	}
+}
+
+// applyTask applies the task's changes to a clean worktree of its repository
+// on the server, leaving branches alone.
+func (s *Server) applyTask(ctx context.Context, entry *taskEntry, req *v1.ApplyTaskReq) (*v1.ApplyTaskResp, error) {
+	t := entry.task
+	p := t.Primary()
+	if p == nil {
+		return nil, dto.BadRequest("task has no repository")
+	}
+	if t.Container == "" {
+		return nil, dto.Conflict("task has no container")
+	}
+	runner, ok := s.runners[p.Name]
+	if !ok {
+		return nil, dto.InternalError("unknown repo")
+	}
+	ds, err := runner.ApplyToWorktree(ctx, p.Branch, req.Path)
+	switch {
+	case errors.Is(err, task.ErrNotWorktree):
+		return nil, dto.BadRequest(err.Error())
+	case errors.Is(err, task.ErrDirtyWorktree), errors.Is(err, task.ErrPatchFailed):
+		return nil, dto.Conflict(err.Error())
+	case err != nil:
+		return nil, dto.InternalError(err.Error())
+	}
+	status := "applied"
+	if len(ds) == 0 {
+		status = "empty"
+	}
+	return &v1.ApplyTaskResp{Status: status, Path: req.Path, DiffStat: toV1DiffStat(ds)}, nil
+}
+
+// handleGetTaskCommits lists the commits the agent made on the task branch,
+// each correlated with the turn that produced it.
+func (s *Server) handleGetTaskCommits(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t := entry.task
+	p := t.Primary()
+	if p == nil {
+		writeError(w, dto.BadRequest("task has no repository"))
+		return
+	}
+	if t.Container == "" {
+		writeError(w, dto.Conflict("task has no container"))
+		return
+	}
+	commits, err := task.ListCommits(r.Context(), t.Container, p.GitRoot)
+	if err != nil {
+		writeError(w, dto.InternalError(err.Error()))
+		return
+	}
+	turns := t.TurnUsages()
+	resp := v1.TaskCommitsResp{Commits: make([]v1.TaskCommit, len(commits))}
+	for i := range commits {
+		c := &commits[i]
+		tc := v1.TaskCommit{
+			SHA:         c.SHA,
+			Author:      c.Author,
+			CommittedAt: float64(c.CommittedAt.UnixMilli()) / 1e3,
+			Message:     c.Message,
+			Files:       toV1DiffStat(c.Files),
+			Turn:        task.CommitTurn(turns, c.CommittedAt),
+		}
+		for _, f := range c.Files {
+			tc.Added += f.Added
+			tc.Deleted += f.Deleted
+		}
+		resp.Commits[i] = tc
+	}
+	writeJSONResponse(w, &resp, nil)
+}
+
+// handleGetTaskSnapshot streams a tarball of the files the task changed so
+// they can be inspected without syncing the branch. Their secrets are masked
+// unless ?unmask=true is allowed.
+func (s *Server) handleGetTaskSnapshot(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	t := entry.task
+	p := t.Primary()
+	if p == nil {
+		writeError(w, dto.BadRequest("task has no repository"))
+		return
+	}
+	if t.Container == "" {
+		writeError(w, dto.Conflict("task has no container"))
+		return
+	}
+	unmask, err := s.unmask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	aw := &archiveWriter{w: w, name: strings.ReplaceAll(p.Branch, "/", "-") + ".tar.gz"}
+	if unmask {
+		err = task.WriteArchive(r.Context(), t.Container, p.GitRoot, aw)
+	} else {
+		pr, pw := io.Pipe()
+		go func() {
+			_ = pw.CloseWithError(task.WriteArchive(r.Context(), t.Container, p.GitRoot, pw))
+		}()
+		_, err = task.MaskArchive(aw, pr)
+		// Unblocks the archive if masking stopped early.
+		_ = pr.CloseWithError(err)
+	}
+	if err != nil {
+		if !aw.started {
+			writeError(w, dto.InternalError(err.Error()))
+			return
+		}
+		slog.WarnContext(r.Context(), "snapshot interrupted", "task", t.ID, "err", err)
+	}
+}
+
+// archiveWriter sets the download headers on the first write so an error
+// before any output can still be reported as JSON.
+type archiveWriter struct {
+	w       http.ResponseWriter
+	name    string
+	started bool
+}
+
+func (a *archiveWriter) Write(p []byte) (int, error) {
+	if !a.started {
+		a.started = true
+		a.w.Header().Set("Content-Type", "application/gzip")
+		a.w.Header().Set("Content-Disposition", `attachment; filename="`+a.name+`"`)
+	}
+	return a.w.Write(p)
+}
+
+// handleGetDiff returns the task's diff against its base branch, or against
+// the ?base= ref, e.g. the commit of the last review.
 func (s *Server) handleGetDiff(w http.ResponseWriter, r *http.Request) {
 	entry, err := s.getTask(r)
 	if err != nil {
@@ -1712,14 +2816,44 @@ func (s *Server) handleGetDiff(w http.ResponseWriter, r *http.Request) {
 		writeError(w, dto.InternalError("unknown repo"))
 		return
 	}
+	unmask, err := s.unmask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 	path := r.URL.Query().Get("path")
-	diff, err := runner.DiffContent(r.Context(), diffPrimaryBranch, path)
+	var diff string
+	if base := r.URL.Query().Get("base"); base != "" {
+		if !task.ValidDiffBase(base) {
+			writeError(w, dto.BadRequest("invalid base").WithDetail("base", base))
+			return
+		}
+		diff, err = runner.DiffContentFrom(r.Context(), diffPrimaryBranch, t.Container, base, path, t.ExtraMDRepos())
+	} else {
+		diff, err = runner.DiffContent(r.Context(), diffPrimaryBranch, path)
+	}
+	if errors.Is(err, task.ErrUnknownRef) {
+		writeError(w, dto.BadRequest(err.Error()))
+		return
+	}
 	if err != nil {
 		writeError(w, dto.InternalError(err.Error()))
 		return
 	}
+	resp := v1.DiffResp{Diff: diff}
+	if !unmask {
+		resp.Diff, resp.Masked = task.MaskSecrets(diff)
+	}
+	if r.URL.Query().Get("summary") == "true" {
+		files, err := s.structuralDiff(r.Context(), t, r.URL.Query().Get("base"))
+		if err != nil {
+			writeError(w, dto.InternalError(err.Error()))
+			return
+		}
+		resp.Summary = toV1FileSummaries(files)
+	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(v1.DiffResp{Diff: diff})
+	_ = json.NewEncoder(w).Encode(resp)
 }
 
 func (s *Server) handleGetUsage(w http.ResponseWriter, _ *http.Request) {
@@ -1727,15 +2861,7 @@ func (s *Server) handleGetUsage(w http.ResponseWriter, _ *http.Request) {
 	resp := computeUsage(s.tasks, time.Now())
 	s.mu.Unlock()
 
-	if s.usage != nil {
-		if oauth := s.usage.get(); oauth != nil {
-			resp.FiveHour.Utilization = oauth.FiveHour.Utilization
-			resp.FiveHour.ResetsAt = oauth.FiveHour.ResetsAt
-			resp.SevenDay.Utilization = oauth.SevenDay.Utilization
-			resp.SevenDay.ResetsAt = oauth.SevenDay.ResetsAt
-			resp.ExtraUsage = oauth.ExtraUsage
-		}
-	}
+	s.mergeRemoteUsage(&resp)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
@@ -1830,8 +2956,17 @@ func (s *Server) getVoiceTokenEphemeral(ctx context.Context, _ *dto.EmptyReq) (*
 	}, nil
 }
 
-// SetRunnerOps overrides container and agent backends on all runners.
+// SetRunnerOps overrides container and agent backends on all runners. Faults
+// are injected into them when Config.Chaos is set.
 func (s *Server) SetRunnerOps(c task.ContainerBackend, backends map[agent.Harness]agent.Backend) {
+	if s.chaos != nil {
+		if c != nil {
+			c = s.chaos.Container(c)
+		}
+		if backends != nil {
+			backends = s.chaos.Backends(backends)
+		}
+	}
 	for _, r := range s.runners {
 		if c != nil {
 			r.Container = c
@@ -1892,18 +3027,34 @@ func (s *Server) loadPurgedTasksFrom(all []*task.LoadedTask) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	for _, lt := range purged {
+		// Keep the ID from the log file name so outcome labels and replay
+		// links survive restarts.
+		id, err := ksid.Parse(lt.TaskID)
+		if _, dup := s.tasks[id.String()]; err != nil || dup {
+			id = ksid.NewID()
+		}
 		t := &task.Task{
-			ID:            ksid.NewID(),
+			ID:            id,
 			InitialPrompt: agent.Prompt{Text: lt.Prompt},
 			Repos:         lt.Repos, // GitRoot is empty for purged tasks
 			Harness:       lt.Harness,
+			Model:         lt.Model,
 			StartedAt:     lt.StartedAt,
+			ReplayOf:      lt.ReplayOf,
+			Kind:          lt.Kind,
+			AutoResume:    lt.AutoResume,
+			AskPolicy:     lt.AskPolicy,
+			Automation:    lt.Automation,
+			OwnerID:       lt.OwnerID,
+		}
+		if err := t.Restore(lt.State); err != nil {
+			slog.Warn("restore task failed", "task", t.ID, "err", err)
+			continue
 		}
-		t.SetState(lt.State)
 		if lt.Title != "" {
 			t.SetTitle(lt.Title)
 		} else {
-			t.SetTitle(lt.Prompt)
+			t.SetTitle(task.LocalTitle(lt.Prompt))
 		}
 		// TODO: Figure out when it was purged.
 		if err := lt.LoadMessages(); err != nil {
@@ -2022,17 +3173,18 @@ func (s *Server) adoptContainers(ctx context.Context, containers []*md.Container
 func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner, c *md.Container, branch string, branchID map[string]string, allLogs []*task.LoadedTask) error { //nolint:gocritic // repoInfo size increase from GitHub fields; refactor not worth it
 	// Only adopt containers that caic started. The caic label is set at
 	// container creation and is the authoritative proof of ownership.
-	labelVal, err := container.LabelValue(ctx, c.Name, "caic")
+	labels, err := container.Labels(ctx, c.Name)
 	if err != nil {
 		return fmt.Errorf("label check for %s: %w", c.Name, err)
 	}
-	if labelVal == "" {
+	meta := container.ParseMeta(labels)
+	if meta.TaskID == "" {
 		slog.Info("container", "msg", "skipping non-caic", "repo", ri.RelPath, "ctr", c.Name, "br", branch)
 		return nil
 	}
-	taskID, err := ksid.Parse(labelVal)
+	taskID, err := ksid.Parse(meta.TaskID)
 	if err != nil {
-		return fmt.Errorf("parse caic label %q on %s: %w", labelVal, c.Name, err)
+		return fmt.Errorf("parse caic label %q on %s: %w", meta.TaskID, c.Name, err)
 	}
 
 	// Exited containers are adopted as stopped tasks. The user can
@@ -2071,8 +3223,7 @@ func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner,
 
 	// Read the harness from the container label (authoritative), falling
 	// back to the log file, then to Claude as the default.
-	harnessLabel, _ := container.LabelValue(ctx, c.Name, "harness")
-	harnessName := agent.Harness(harnessLabel)
+	harnessName := agent.Harness(meta.Harness)
 	if harnessName == "" && lt != nil {
 		harnessName = lt.Harness
 	}
@@ -2107,6 +3258,9 @@ func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner,
 		startedAt = lt.StartedAt
 		stateUpdatedAt = lt.LastStateUpdateAt
 	}
+	if startedAt.IsZero() {
+		startedAt = meta.CreatedAt
+	}
 
 	if stateUpdatedAt.IsZero() {
 		stateUpdatedAt = time.Now().UTC()
@@ -2116,40 +3270,73 @@ func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner,
 		// Primary mount from repoInfo; extra mounts from log.
 		adoptRepos = []task.RepoMount{{Name: ri.RelPath, GitRoot: ri.AbsPath, Branch: branch}}
 		if lt != nil {
+			adoptRepos[0].SparsePaths = lt.Repos[0].SparsePaths
+			adoptRepos[0].BaseSHA = lt.Repos[0].BaseSHA
 			for _, lm := range lt.Repos[1:] {
 				gitRoot := ""
 				if er, ok := s.runners[lm.Name]; ok {
 					gitRoot = er.Dir
 				}
-				adoptRepos = append(adoptRepos, task.RepoMount{Name: lm.Name, BaseBranch: lm.BaseBranch, Branch: lm.Branch, GitRoot: gitRoot})
+				adoptRepos = append(adoptRepos, task.RepoMount{Name: lm.Name, BaseBranch: lm.BaseBranch, Branch: lm.Branch, BaseSHA: lm.BaseSHA, GitRoot: gitRoot, SparsePaths: lm.SparsePaths})
 			}
 		}
 	}
 	var forgeIssue int
+	var model string
+	var replayOf ksid.ID
+	var kind task.Kind
+	var autoResume bool
+	var askPolicy *task.AskPolicy
+	var automation task.Automation
+	owner := meta.Owner
 	if lt != nil {
 		forgeIssue = lt.ForgeIssue
-	}
+		model = lt.Model
+		replayOf = lt.ReplayOf
+		kind = lt.Kind
+		autoResume = lt.AutoResume
+		askPolicy = lt.AskPolicy
+		automation = lt.Automation
+		if owner == "" {
+			owner = lt.OwnerID
+		}
+	}
+	// A missing or unknown label means normal priority.
+	priority, _ := task.ParsePriority(meta.Priority)
 	t := &task.Task{
 		ID:            taskID,
 		InitialPrompt: agent.Prompt{Text: prompt},
 		Repos:         adoptRepos,
 		Harness:       harnessName,
+		Model:         model,
 		Container:     c.Name,
 		StartedAt:     startedAt,
+		OwnerID:       owner,
 		Tailscale:     c.Tailscale,
 		TailscaleFQDN: c.TailscaleFQDN(ctx),
 		USB:           c.USB,
 		Display:       c.Display,
-		Provider:      s.provider,
+		GPU:           meta.GPU,
+		Priority:      priority,
+		Titles:        s.titles,
 		ForgeIssue:    forgeIssue,
+		ReplayOf:      replayOf,
+		Kind:          kind,
+		AutoResume:    autoResume,
+		AskPolicy:     askPolicy,
+		Automation:    automation,
+		Scrub:         s.scrubber,
+	}
+	s.setTaskHooks(t)
+	if err := t.Adopt(task.StateRunning, stateUpdatedAt); err != nil {
+		return err
 	}
-	t.SetStateAt(task.StateRunning, stateUpdatedAt)
-	// Set an immediate fallback title; GenerateTitle is fired async below
+	// Set an immediate fallback title; a generated title is queued below
 	// after messages are restored so the LLM sees the full conversation.
 	if lt != nil && lt.Title != "" {
 		t.SetTitle(lt.Title)
 	} else {
-		t.SetTitle(prompt)
+		t.SetTitle(task.LocalTitle(prompt))
 	}
 	switch {
 	case lt != nil && lt.ForgePR > 0:
@@ -2215,7 +3402,9 @@ func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner,
 
 	// Exited containers are always stopped — user must revive explicitly.
 	if isExited {
-		t.SetState(task.StateStopped)
+		if err := t.Transition(task.StateStopped); err != nil {
+			return err
+		}
 	} else if !relayAlive {
 		// Relay is dead but container is running. Read relay log for
 		// diagnostics, then mark waiting so the user can restart or
@@ -2224,8 +3413,7 @@ func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner,
 		if relayLog != "" {
 			slog.Warn("relay", "msg", "log from dead relay", "ctr", c.Name, "br", branch, "diag", relayDiag, "log", relayLog)
 		}
-		if t.GetState() == task.StateRunning {
-			t.SetState(task.StateWaiting)
+		if t.SetStateIf(task.StateRunning, task.StateWaiting) {
 			slog.Warn("relay", "msg", "dead, marking waiting",
 				"repo", ri.RelPath, "br", branch, "ctr", c.Name,
 				"sess", t.GetSessionID(), "msgs", len(t.Messages()))
@@ -2234,7 +3422,7 @@ func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner,
 
 	// Track whether we've already registered the task entry (happens for external PRs).
 	entryRegistered := false
-	entry := &taskEntry{task: t, done: make(chan struct{})}
+	entry := &taskEntry{task: t, done: make(chan struct{}), gpuHeld: t.GPU}
 
 	// Register entry and start CI monitoring if a PR was found (either from logs or external).
 	if t.GetPR() > 0 && ri.ForgeOwner != "" && ri.ForgeKind != "" {
@@ -2295,7 +3483,7 @@ func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner,
 	// Count results in the restored messages; if the relay has more than the
 	// log, a turn happened while the server was down and the title is stale.
 	if needsTitleRegen(t, lt) {
-		go t.GenerateTitle(s.ctx) //nolint:contextcheck // fire-and-forget; must outlive adoption
+		s.titles.Enqueue(t)
 	}
 
 	// Auto-reconnect in background: relay alive → attach; relay dead
@@ -2319,8 +3507,7 @@ func (s *Server) adoptOne(ctx context.Context, ri repoInfo, runner *task.Runner,
 			// start a fresh idle relay so the task can accept prompts.
 			h, err = runner.EnsureSession(ctx, t, h, tlog)
 			if err != nil {
-				tlog.Warn("ensure session failed", "err", err)
-				t.SetState(task.StateWaiting)
+				tlog.Warn("ensure session failed", "err", errors.Join(err, t.Transition(task.StateWaiting)))
 				s.notifyTaskChange()
 				return
 			}
@@ -2354,9 +3541,17 @@ func (s *Server) cleanupTask(entry *taskEntry, runner *task.Runner, reason task.
 		result := runner.Cleanup(s.ctx, entry.task, reason)
 		s.mu.Lock()
 		entry.result = &result
+		s.recordHistoryLocked(entry.task, &result)
 		s.taskChanged()
 		s.mu.Unlock()
 		close(entry.done)
+		s.indexTask(entry.task)
+		if result.Err == nil {
+			s.learnFromTask(entry.task)
+		}
+		if s.recordDir != "" {
+			s.recordTask(entry.task)
+		}
 	})
 }
 
@@ -2417,7 +3612,7 @@ func (s *Server) watchSession(entry *taskEntry, runner *task.Runner, h *task.Ses
 func (s *Server) watchContainerEvents(ctx context.Context) {
 	go func() {
 		for {
-			ch, err := container.WatchEvents(ctx, "caic")
+			ch, err := container.WatchEvents(ctx, container.LabelTask)
 			if err != nil {
 				if ctx.Err() != nil {
 					return
@@ -2447,41 +3642,26 @@ func (s *Server) watchContainerEvents(ctx context.Context) {
 	}()
 }
 
-// warmupInterval controls how often warmupImages re-checks for new base image
-// versions. It also sets DigestCacheTTL so that container starts between
-// warmup cycles reuse the cached digest instead of hitting the registry.
-const warmupInterval = 6 * time.Hour
+// pricingRefreshInterval controls how often refreshPricing checks
+// pricing.json for edits.
+const pricingRefreshInterval = time.Minute
 
-// warmupImages periodically calls md.Client.Warmup for the default base image
-// and any custom images configured in user preferences. This ensures the image
-// is pulled and the md-user layer is built before a task needs it.
-func (s *Server) warmupImages() {
-	// Run immediately on startup, then every warmupInterval.
-	ticker := time.NewTicker(warmupInterval)
+// refreshPricing reloads the token pricing override file when it changes so
+// price updates apply without a restart.
+func (s *Server) refreshPricing() {
+	ticker := time.NewTicker(pricingRefreshInterval)
 	defer ticker.Stop()
 	for {
-		images := []string{md.DefaultBaseImage + ":latest"}
-		for _, img := range s.prefs.BaseImages() {
-			if !slices.Contains(images, img) {
-				images = append(images, img)
-			}
-		}
-		for _, img := range images {
-			built, err := s.mdClient.Warmup(s.ctx, &md.WarmupOpts{
-				BaseImage: img,
-				Quiet:     true,
-			})
-			if err != nil {
-				slog.Warn("warmup", "image", img, "err", err)
-			} else if built {
-				slog.Info("warmup", "image", img, "built", true)
-			}
-		}
 		select {
 		case <-ticker.C:
 		case <-s.ctx.Done():
 			return
 		}
+		if changed, err := agent.DefaultPricing.Refresh(); err != nil {
+			slog.Warn("pricing", "err", err)
+		} else if changed {
+			slog.Info("pricing", "msg", "reloaded")
+		}
 	}
 }
 
@@ -2516,7 +3696,9 @@ func (s *Server) handleContainerDeath(containerName string) {
 	slog.Info("container", "msg", "died, archiving as stopped", "ctr", containerName, "task", t.ID, "br", deathBranch, "prev_state", state)
 	// Detach any active session (SSH is dead).
 	t.DetachSession()
-	t.SetState(task.StateStopped)
+	if err := t.Transition(task.StateStopped); err != nil {
+		slog.Warn("container", "msg", "archiving failed", "ctr", containerName, "task", t.ID, "err", err)
+	}
 	s.notifyTaskChange()
 }
 
@@ -2524,6 +3706,12 @@ func (s *Server) handleContainerDeath(containerName string) {
 // When auth is enabled, returns 403 if the task belongs to a different user.
 func (s *Server) getTask(r *http.Request) (*taskEntry, error) {
 	id := r.PathValue("id")
+	tagSpanTask(r, id)
+	return s.lookupTask(r.Context(), id)
+}
+
+// lookupTask returns the task id if the user in ctx may access it.
+func (s *Server) lookupTask(ctx context.Context, id string) (*taskEntry, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	entry, ok := s.tasks[id]
@@ -2531,7 +3719,7 @@ func (s *Server) getTask(r *http.Request) (*taskEntry, error) {
 		return nil, dto.NotFound("task")
 	}
 	if s.authEnabled() {
-		if u, ok := auth.UserFromContext(r.Context()); ok {
+		if u, ok := auth.UserFromContext(ctx); ok {
 			if entry.task.OwnerID != "" && entry.task.OwnerID != u.ID {
 				return nil, dto.Forbidden("task")
 			}
@@ -2547,6 +3735,13 @@ func (s *Server) taskChanged() {
 	s.changed = make(chan struct{})
 }
 
+// configChangedLocked wakes up config event streams. Must be called with
+// s.mu held.
+func (s *Server) configChangedLocked() {
+	close(s.configChanged)
+	s.configChanged = make(chan struct{})
+}
+
 // notifyTaskChange signals that task data may have changed.
 func (s *Server) notifyTaskChange() {
 	s.mu.Lock()
@@ -2554,6 +3749,19 @@ func (s *Server) notifyTaskChange() {
 	s.mu.Unlock()
 }
 
+// taskTitles returns the titles of all tasks but except.
+func (s *Server) taskTitles(except *task.Task) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]string, 0, len(s.tasks))
+	for _, e := range s.tasks {
+		if e.task != except {
+			out = append(out, e.task.Title())
+		}
+	}
+	return out
+}
+
 func (s *Server) toJSON(e *taskEntry) v1.Task {
 	// Read all volatile fields in a single locked snapshot to avoid
 	// data races with addMessage/RestoreMessages.
@@ -2562,7 +3770,7 @@ func (s *Server) toJSON(e *taskEntry) v1.Task {
 	// Build Repos slice for API response.
 	taskRepos := make([]v1.TaskRepo, len(e.task.Repos))
 	for i, r := range e.task.Repos {
-		taskRepos[i] = v1.TaskRepo{Name: r.Name, BaseBranch: r.BaseBranch, Branch: r.Branch, RemoteURL: s.repoURL(r.Name), Forge: s.repoForge(r.Name)}
+		taskRepos[i] = v1.TaskRepo{Name: r.Name, BaseBranch: r.BaseBranch, Branch: r.Branch, BaseSHA: r.BaseSHA, RemoteURL: s.repoURL(r.Name), Forge: s.repoForge(r.Name), SparsePaths: r.SparsePaths}
 	}
 	if len(taskRepos) == 0 {
 		taskRepos = nil
@@ -2588,12 +3796,24 @@ func (s *Server) toJSON(e *taskEntry) v1.Task {
 		SessionID:      snap.SessionID,
 		InPlanMode:     snap.InPlanMode,
 		PlanContent:    snap.PlanContent,
+		TodosOpen:      snap.TodosOpen,
+		TodosCompleted: snap.TodosCompleted,
 		Tailscale:      tailscaleURL(e.task),
 		USB:            e.task.USB,
 		Display:        e.task.Display,
+		GPU:            e.task.GPU,
+		AutoResume:     e.task.AutoResume,
+		AskPolicy:      toV1AskPolicy(e.task.AskPolicy),
+		Automation:     v1.Automation(e.task.Automation),
+		Priority:       toV1Priority(e.task.Priority),
+		Image:          e.task.DockerImage,
+		ImageID:        snap.ImageID,
 		CostUSD:        snap.CostUSD,
 		NumTurns:       snap.NumTurns,
 		Duration:       snap.Duration.Seconds(),
+		ReplayOf:       e.task.ReplayOf,
+		Kind:           toV1TaskKind(e.task.Kind),
+		Label:          s.taskLabel(e.task.ID),
 	}
 	if !e.task.StartedAt.IsZero() {
 		j.StartedAt = float64(e.task.StartedAt.UnixMilli()) / 1e3
@@ -2601,6 +3821,17 @@ func (s *Server) toJSON(e *taskEntry) v1.Task {
 	if !snap.TurnStartedAt.IsZero() {
 		j.TurnStartedAt = float64(snap.TurnStartedAt.UnixMilli()) / 1e3
 	}
+	if !snap.HandedOffAt.IsZero() {
+		j.HandedOffAt = float64(snap.HandedOffAt.UnixMilli()) / 1e3
+	}
+	if !snap.LastActivityAt.IsZero() {
+		j.LastActivityAt = float64(snap.LastActivityAt.UnixMilli()) / 1e3
+	}
+	j.Stuck = s.stuck.stuck(&snap, time.Now())
+	j.Warnings = toV1Warnings(e.task.Warnings())
+	if a := e.task.AffectedTargets(); a != nil {
+		j.AffectedTargets = &v1.AffectedTargets{Tool: v1.AffectedTool(a.Tool), Targets: a.Targets, Truncated: a.Truncated, ComputedAt: float64(a.At.UnixMilli()) / 1e3}
+	}
 	j.CumulativeInputTokens = snap.Usage.InputTokens
 	j.CumulativeOutputTokens = snap.Usage.OutputTokens
 	j.CumulativeCacheCreationInputTokens = snap.Usage.CacheCreationInputTokens
@@ -2637,6 +3868,8 @@ func (s *Server) toJSON(e *taskEntry) v1.Task {
 			j.CIChecks[i] = checkToDTO(&snap.CIChecks[i])
 		}
 	}
+	j.DiskUsage = toV1DiskUsage(&snap.DiskUsage)
+	j.Scrubbed = snap.Scrubbed
 	if s.authStore != nil && e.task.OwnerID != "" {
 		if u, ok := s.authStore.FindByID(e.task.OwnerID); ok {
 			j.Owner = u.Username
@@ -11,6 +11,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"testing"
@@ -18,11 +19,15 @@ import (
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
 	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/container"
 	"github.com/caic-xyz/caic/backend/internal/forge"
 	"github.com/caic-xyz/caic/backend/internal/preferences"
+	"github.com/caic-xyz/caic/backend/internal/search"
 	"github.com/caic-xyz/caic/backend/internal/server/dto"
 	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
 	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/caic-xyz/md"
+	"github.com/maruel/ksid"
 )
 
 // stubBackend implements agent.Backend for test map-membership checks.
@@ -50,6 +55,10 @@ func (stubBackend) Models() []string { return []string{"m1", "m2"} }
 
 func (stubBackend) SupportsImages() bool { return false }
 
+func (stubBackend) SupportsReadOnly() bool { return false }
+
+func (stubBackend) IsMutatingTool(string) bool { return false }
+
 func (stubBackend) ContextWindowLimit(string) int { return 180_000 }
 
 func decodeError(t *testing.T, w *httptest.ResponseRecorder) dto.ErrorDetails {
@@ -71,14 +80,89 @@ func newTestPrefs(t *testing.T) *preferences.Store {
 	return store
 }
 
+// setState moves tk to s. A new task is first adopted as running, like a
+// task found in a container on startup, unless it may move to s directly.
+func setState(t testing.TB, tk *task.Task, s task.State) {
+	t.Helper()
+	if st := tk.GetState(); st == task.StatePending && !task.CanTransition(st, s) {
+		if err := tk.Adopt(task.StateRunning, time.Now().UTC()); err != nil {
+			t.Fatal(err)
+		}
+		tk.SetTurnStartedAt(time.Now().UTC())
+	}
+	if err := tk.Transition(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func newTestServer(t *testing.T) *Server {
 	t.Helper()
 	return &Server{
-		ctx:     t.Context(),
-		runners: map[string]*task.Runner{},
-		tasks:   make(map[string]*taskEntry),
-		changed: make(chan struct{}),
-		prefs:   newTestPrefs(t),
+		ctx:           t.Context(),
+		runners:       map[string]*task.Runner{},
+		tasks:         make(map[string]*taskEntry),
+		changed:       make(chan struct{}),
+		configChanged: make(chan struct{}),
+		prefs:         newTestPrefs(t),
+		knowledge:     &knowledgeStore{dir: t.TempDir(), maxBytes: defaultKnowledgeMaxBytes},
+		repoMaps:      &repoMapStore{dir: t.TempDir()},
+		overviews:     &overviewStore{dir: t.TempDir()},
+		drafts:        &draftStore{drafts: map[ksid.ID]*draft{}},
+		evals:         &evalStore{runs: map[ksid.ID]*evalRun{}},
+		labels:        &labelStore{labels: map[ksid.ID]outcomeLabel{}},
+	}
+}
+
+func TestHandleConfigEvents(t *testing.T) {
+	s := newTestServer(t)
+	s.runners["a"] = &task.Runner{Backends: map[agent.Harness]agent.Backend{"stub": stubBackend{}}}
+	s.repos = []repoInfo{{RelPath: "a", BaseBranch: "main"}}
+	s.mdClient = &md.Client{}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/server/config/events", http.NoBody).WithContext(ctx)
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		s.handleConfigEvents(w, req)
+		close(done)
+	}()
+	// Register a repo once the first snapshot is out, then a no-op change.
+	time.Sleep(50 * time.Millisecond)
+	for _, rel := range []string{"b", ""} {
+		s.mu.Lock()
+		if rel != "" {
+			s.repos = append(s.repos, repoInfo{RelPath: rel, BaseBranch: "main"})
+		}
+		s.configChangedLocked()
+		s.mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	var got [][]string
+	for line := range strings.SplitSeq(w.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var ev v1.ConfigEvent
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			t.Fatal(err)
+		}
+		if len(ev.Harnesses) != 1 || ev.Harnesses[0].Name != "stub" {
+			t.Errorf("harnesses = %+v", ev.Harnesses)
+		}
+		var paths []string
+		for _, r := range ev.Repos {
+			paths = append(paths, r.Path)
+		}
+		got = append(got, paths)
+	}
+	want := [][]string{{"a"}, {"a", "b"}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("repos per event = %v, want %v", got, want)
 	}
 }
 
@@ -162,7 +246,7 @@ func TestHandleRestart(t *testing.T) {
 	t.Run("NotWaiting", func(t *testing.T) {
 		s := newTestServer(t)
 		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(task.StateRunning)
+		setState(t, tk, task.StateRunning)
 		s.tasks["t1"] = &taskEntry{
 			task: tk,
 			done: make(chan struct{}),
@@ -185,7 +269,7 @@ func TestHandleRestart(t *testing.T) {
 	t.Run("EmptyPrompt", func(t *testing.T) {
 		s := newTestServer(t)
 		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(task.StateWaiting)
+		setState(t, tk, task.StateWaiting)
 		s.tasks["t1"] = &taskEntry{
 			task: tk,
 			done: make(chan struct{}),
@@ -231,7 +315,7 @@ func TestHandlePurge(t *testing.T) {
 
 	t.Run("Waiting", func(t *testing.T) {
 		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Repos: []task.RepoMount{{Name: "r"}}}
-		tk.SetState(task.StateWaiting)
+		setState(t, tk, task.StateWaiting)
 		s := newTestServer(t)
 		s.runners["r"] = &task.Runner{BaseBranch: "main", Dir: t.TempDir()}
 		s.tasks["t1"] = &taskEntry{
@@ -261,7 +345,7 @@ func TestHandlePurge(t *testing.T) {
 
 	t.Run("CancelledContext", func(t *testing.T) {
 		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Repos: []task.RepoMount{{Name: "r"}}}
-		tk.SetState(task.StateRunning)
+		setState(t, tk, task.StateRunning)
 		s := newTestServer(t)
 		s.runners["r"] = &task.Runner{BaseBranch: "main", Dir: t.TempDir()}
 		s.tasks["t1"] = &taskEntry{
@@ -284,6 +368,33 @@ func TestHandlePurge(t *testing.T) {
 	})
 }
 
+func TestHandlePauseResume(t *testing.T) {
+	s := newTestServer(t)
+	s.runners["r"] = &task.Runner{BaseBranch: "main", Dir: t.TempDir()}
+	tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Repos: []task.RepoMount{{Name: "r"}}}
+	setState(t, tk, task.StateWaiting)
+	s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+	do := func(action string, h http.HandlerFunc) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/t1/"+action, http.NoBody)
+		req.SetPathValue("id", "t1")
+		w := httptest.NewRecorder()
+		h(w, req)
+		return w.Code
+	}
+	if code := do("resume", handleWithTask(s, s.resumeTask)); code != http.StatusConflict {
+		t.Errorf("resume while waiting: status = %d, want %d", code, http.StatusConflict)
+	}
+	if code := do("pause", handleWithTask(s, s.pauseTask)); code != http.StatusOK {
+		t.Errorf("pause: status = %d, want %d", code, http.StatusOK)
+	}
+	if tk.GetState() != task.StatePaused {
+		t.Errorf("state = %v, want %v", tk.GetState(), task.StatePaused)
+	}
+	if code := do("pause", handleWithTask(s, s.pauseTask)); code != http.StatusConflict {
+		t.Errorf("pause while paused: status = %d, want %d", code, http.StatusConflict)
+	}
+}
+
 func TestHandleContainerDeath(t *testing.T) {
 	t.Run("ArchivesAsStopped", func(t *testing.T) {
 		s := newTestServer(t)
@@ -292,7 +403,7 @@ func TestHandleContainerDeath(t *testing.T) {
 			Repos:         []task.RepoMount{{Name: "r"}},
 			Container:     "md-repo-caic-0",
 		}
-		tk.SetState(task.StateRunning)
+		setState(t, tk, task.StateRunning)
 		s.runners["r"] = &task.Runner{BaseBranch: "main", Dir: t.TempDir()}
 		entry := &taskEntry{task: tk, done: make(chan struct{})}
 		s.tasks["t1"] = entry
@@ -441,6 +552,35 @@ func TestHandleCreateTask(t *testing.T) {
 		}
 	})
 
+	t.Run("OldRuntime", func(t *testing.T) {
+		s := &Server{
+			ctx: t.Context(),
+			runners: map[string]*task.Runner{
+				"myrepo": {
+					BaseBranch: "main",
+					Dir:        t.TempDir(),
+					Backends:   map[agent.Harness]agent.Backend{"stub": stubBackend{}},
+				},
+			},
+			tasks:       make(map[string]*taskEntry),
+			changed:     make(chan struct{}),
+			runtimeCaps: &container.Capabilities{Runtime: "docker", Version: "20.10.24"},
+		}
+		handler := handle(s.createTask)
+
+		body := strings.NewReader(`{"initialPrompt":{"text":"test"},"repos":[{"name":"myrepo"}],"harness":"stub"}`)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", body)
+		w := httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+		if e := decodeError(t, w); e.Message != "docker >= 23.0 required for image builds, found 20.10.24; upgrade docker" {
+			t.Errorf("message = %q", e.Message)
+		}
+	})
+
 	t.Run("ValidModel", func(t *testing.T) {
 		s := &Server{
 			ctx: t.Context(),
@@ -518,6 +658,57 @@ func TestHandleCreateTask(t *testing.T) {
 		}
 	})
 
+	t.Run("RepoImage", func(t *testing.T) {
+		var checked []string
+		s := &Server{
+			ctx: t.Context(),
+			runners: map[string]*task.Runner{
+				"myrepo": {
+					BaseBranch: "main",
+					Dir:        t.TempDir(),
+					Backends:   map[agent.Harness]agent.Backend{agent.Claude: stubBackend{}},
+				},
+			},
+			tasks:      make(map[string]*taskEntry),
+			changed:    make(chan struct{}),
+			prefs:      newTestPrefs(t),
+			repoImages: map[string]string{"myrepo": "ghcr.io/my/toolchain:v2"},
+			imageAvailable: func(_ context.Context, ref string) error {
+				checked = append(checked, ref)
+				if ref == "missing:v1" {
+					return errors.New("not found")
+				}
+				return nil
+			},
+		}
+		handler := handle(s.createTask)
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/api/v1/tasks", strings.NewReader(`{"initialPrompt":{"text":"test"},"repos":[{"name":"myrepo"}],"harness":"claude"}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+		}
+		var resp v1.CreateTaskResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		s.mu.Lock()
+		entry := s.tasks[resp.ID.String()]
+		s.mu.Unlock()
+		if entry == nil || entry.task.DockerImage != "ghcr.io/my/toolchain:v2" {
+			t.Fatalf("task image not defaulted from repo settings: %+v", entry)
+		}
+
+		w = httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodPost, "/api/v1/tasks", strings.NewReader(`{"initialPrompt":{"text":"test"},"repos":[{"name":"myrepo"}],"harness":"claude","image":"missing:v1"}`)))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("unavailable image: status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+		if want := []string{"ghcr.io/my/toolchain:v2", "missing:v1"}; !slices.Equal(checked, want) {
+			t.Errorf("checked = %v, want %v", checked, want)
+		}
+	})
+
 	t.Run("NoRepoTask", func(t *testing.T) {
 		// Regression: creating a task with no repos panicked with
 		// "makeslice: cap out of range" because len(req.Repos)-1 == -1.
@@ -1436,6 +1627,54 @@ func TestConfigValidate(t *testing.T) {
 			t.Fatal("Validate() expected error, got nil")
 		}
 	})
+	t.Run("TLS cert without key is invalid", func(t *testing.T) {
+		c := &Config{TLSCertFile: "cert.pem"}
+		if err := c.Validate(); err == nil {
+			t.Fatal("Validate() expected error, got nil")
+		}
+	})
+	t.Run("TLS cert and ACME together is invalid", func(t *testing.T) {
+		c := &Config{TLSCertFile: "cert.pem", TLSKeyFile: "key.pem", ACMEHosts: "caic.example.com"}
+		if err := c.Validate(); err == nil {
+			t.Fatal("Validate() expected error, got nil")
+		}
+	})
+	t.Run("HTTP redirect without TLS is invalid", func(t *testing.T) {
+		c := &Config{HTTPRedirectAddr: ":80"}
+		if err := c.Validate(); err == nil {
+			t.Fatal("Validate() expected error, got nil")
+		}
+	})
+	t.Run("invalid base path", func(t *testing.T) {
+		c := &Config{BasePath: "/a/../b"}
+		if err := c.Validate(); err == nil {
+			t.Fatal("Validate() expected error, got nil")
+		}
+	})
+	t.Run("ExternalURL path matching base path is valid", func(t *testing.T) {
+		c := &Config{BasePath: "/caic/", ExternalURL: "https://example.com/caic"}
+		if err := c.Validate(); err != nil {
+			t.Fatalf("Validate() unexpected error: %v", err)
+		}
+	})
+	t.Run("ExternalURL path differing from base path is invalid", func(t *testing.T) {
+		c := &Config{BasePath: "/caic", ExternalURL: "https://example.com/other"}
+		if err := c.Validate(); err == nil {
+			t.Fatal("Validate() expected error, got nil")
+		}
+	})
+	t.Run("invalid trusted proxy", func(t *testing.T) {
+		c := &Config{TrustedProxies: "10.0.0.0/33"}
+		if err := c.Validate(); err == nil {
+			t.Fatal("Validate() expected error, got nil")
+		}
+	})
+	t.Run("ACME with redirect is valid", func(t *testing.T) {
+		c := &Config{ACMEHosts: "caic.example.com", HTTPRedirectAddr: ":80"}
+		if err := c.Validate(); err != nil {
+			t.Fatalf("Validate() unexpected error: %v", err)
+		}
+	})
 }
 
 func TestBuildHandler(t *testing.T) {
@@ -1738,3 +1977,149 @@ func TestPrefsPerUser(t *testing.T) {
 		}
 	})
 }
+
+func TestHandleGetTaskUsage(t *testing.T) {
+	s := newTestServer(t)
+	tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Model: "claude-opus-4-6"}
+	tk.RestoreMessages([]agent.Message{
+		&agent.ResultMessage{MessageType: "result", TotalCostUSD: 0.5, DurationMs: 1000, Usage: agent.Usage{InputTokens: 100, OutputTokens: 20}},
+		&agent.ResultMessage{MessageType: "result", TotalCostUSD: 2, DurationMs: 1000, Usage: agent.Usage{InputTokens: 200, OutputTokens: 40}},
+	})
+	s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t1/usage", http.NoBody)
+	req.SetPathValue("id", "t1")
+	w := httptest.NewRecorder()
+	s.handleGetTaskUsage(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+	var resp v1.TaskUsageResp
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Turns) != 2 || resp.CostUSD != 2 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	if tu := resp.Turns[1]; tu.CostUSD != 1.5 || tu.InputTokens != 200 || tu.Model != "claude-opus-4-6" || tu.Ts != 0 {
+		t.Errorf("turn 1 = %+v", tu)
+	}
+}
+
+func TestHandleGetTaskTodos(t *testing.T) {
+	s := newTestServer(t)
+	tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}}
+	tk.RestoreMessages([]agent.Message{
+		&agent.TodoMessage{ToolUseID: "a", Todos: []agent.TodoItem{{Content: "parse", Status: "completed"}, {Content: "test", Status: "in_progress", ActiveForm: "Testing"}}},
+	})
+	s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+	s.tasks["t2"] = &taskEntry{task: &task.Task{InitialPrompt: agent.Prompt{Text: "test"}}, done: make(chan struct{})}
+	get := func(id string) v1.TaskTodosResp {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/"+id+"/todos", http.NoBody)
+		req.SetPathValue("id", id)
+		w := httptest.NewRecorder()
+		s.handleGetTaskTodos(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+		var resp v1.TaskTodosResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if resp := get("t1"); len(resp.Todos) != 2 || resp.Todos[1] != (v1.TodoItem{Content: "test", Status: "in_progress", ActiveForm: "Testing"}) {
+		t.Errorf("t1 = %+v", resp)
+	}
+	if resp := get("t2"); resp.Todos == nil || len(resp.Todos) != 0 {
+		t.Errorf("t2 = %+v", resp)
+	}
+	if j := s.toJSON(s.tasks["t1"]); j.TodosOpen != 1 || j.TodosCompleted != 1 {
+		t.Errorf("task todos open %d completed %d", j.TodosOpen, j.TodosCompleted)
+	}
+}
+
+func TestHandleSearch(t *testing.T) {
+	s := newTestServer(t)
+	s.search = search.New()
+	s.search.Set("t1", search.Meta{Title: "old title", Repo: "caic"}, time.Unix(1, 0), []search.Doc{
+		{Msg: 5, Kind: search.KindPath, Text: "backend/internal/auth/middleware.go"},
+	})
+	tk := &task.Task{InitialPrompt: agent.Prompt{Text: "x"}}
+	tk.SetTitle("Harden auth")
+	s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+	t.Run("MissingQuery", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleSearch(w, httptest.NewRequest(http.MethodGet, "/api/v1/search", http.NoBody))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+	t.Run("Hit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		s.handleSearch(w, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=auth+middleware", http.NoBody))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp v1.SearchResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Hits) != 1 {
+			t.Fatalf("hits = %+v", resp.Hits)
+		}
+		h := resp.Hits[0]
+		if h.TaskID != "t1" || h.Title != "Harden auth" || !h.Loaded || h.MessageIndex != 5 || h.Kind != "path" {
+			t.Errorf("hit = %+v", h)
+		}
+	})
+	t.Run("Owner", func(t *testing.T) {
+		store, err := auth.Open(filepath.Join(t.TempDir(), "users.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.authStore = store
+		defer func() { s.authStore = nil }()
+		// Unloaded tasks that rank above t1: one owned by someone else and
+		// one without a recorded owner. Neither may use up the limit.
+		for _, id := range []string{"t2", "t3"} {
+			owner := ""
+			if id == "t2" {
+				owner = "usr_b"
+			}
+			s.search.Set(id, search.Meta{Title: "auth middleware", Owner: owner}, time.Unix(2, 0), []search.Doc{
+				{Kind: search.KindPath, Text: "auth/middleware.go auth middleware"},
+			})
+		}
+		tk.OwnerID = "usr_a"
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/search?q=auth+middleware&limit=1", http.NoBody)
+		r = r.WithContext(auth.NewContext(r.Context(), &auth.User{ID: "usr_a", Username: "alice"}))
+		w := httptest.NewRecorder()
+		s.handleSearch(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp v1.SearchResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Hits) != 1 || resp.Hits[0].TaskID != "t1" {
+			t.Errorf("hits = %+v", resp.Hits)
+		}
+	})
+	t.Run("TurnEnd", func(t *testing.T) {
+		// Live tasks are indexed from memory as their turns end.
+		lt := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "x"}}
+		s.setTaskHooks(lt)
+		lt.RestoreMessages([]agent.Message{&agent.TextMessage{Text: "the zanzibar cache is stale"}})
+		lt.OnResult(lt, &agent.ResultMessage{MessageType: "result"})
+		w := httptest.NewRecorder()
+		s.handleSearch(w, httptest.NewRequest(http.MethodGet, "/api/v1/search?q=zanzibar", http.NoBody))
+		var resp v1.SearchResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Hits) != 1 || resp.Hits[0].TaskID != lt.ID.String() {
+			t.Errorf("hits = %+v", resp.Hits)
+		}
+	})
+}
@@ -5,14 +5,556 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/agent/external"
+	"github.com/caic-xyz/caic/backend/internal/logcrypt"
+	"github.com/caic-xyz/caic/backend/internal/scrub"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
 )
 
 // serverSettings holds persistent server configuration stored in settings.json.
 type serverSettings struct {
 	SessionSecret string `json:"sessionSecret,omitempty"`
+	// Repos holds per-repository overrides, keyed by repo path relative to
+	// the root directory (e.g. "github/caic"). Edited by hand.
+	Repos map[string]repoSettings `json:"repos,omitempty"`
+	// Disk configures container disk usage monitoring. Edited by hand.
+	Disk diskSettings `json:"disk,omitzero"`
+	// Resources configures container CPU/memory sampling. Edited by hand.
+	Resources resourceSettings `json:"resources,omitzero"`
+	// Stuck configures the detection of turns making no progress. Edited by
+	// hand.
+	Stuck stuckSettings `json:"stuck,omitzero"`
+	// GPUs caps the number of concurrent GPU tasks. 0 autodetects with
+	// nvidia-smi; a negative value disables GPU tasks. GPU tasks stay
+	// disabled while the md container backend lacks GPU passthrough.
+	GPUs int `json:"gpus,omitempty"`
+	// Preempt pauses lower priority tasks holding a GPU or a repo task slot
+	// a higher priority task needs, and low priority tasks a spending limit
+	// applies to when it is exceeded. Preempted tasks can be resumed.
+	Preempt bool `json:"preempt,omitempty"`
+	// Spending caps USD spend across all tasks. Edited by hand.
+	Spending spendingSettings `json:"spending,omitzero"`
+	// Knowledge configures the per-repo knowledge base. Edited by hand.
+	Knowledge knowledgeSettings `json:"knowledge,omitzero"`
+	// Images configures background container image prefetching. Edited by
+	// hand.
+	Images imageSettings `json:"images,omitzero"`
+	// CheckAgentUpdates enables looking up the latest harness CLI releases
+	// on the npm registry for GET /api/v1/server/harnesses/versions.
+	CheckAgentUpdates bool `json:"checkAgentUpdates,omitempty"`
+	// Features holds the experimental feature flags. Written by
+	// POST /api/v1/server/features.
+	Features v1.FeatureFlags `json:"features,omitzero"`
+	// Admins lists usernames allowed, when auth is enabled, to change server
+	// settings through the API, like feature flags, to override the spending
+	// limits, to prune branches and to unmask secrets. Without auth, anyone
+	// can.
+	Admins []string `json:"admins,omitempty"`
+	// Harnesses registers external harnesses: executables speaking the
+	// protocol of package external. Edited by hand.
+	Harnesses []externalHarness `json:"harnesses,omitempty"`
+	// Scrub redacts personal data from the prompts sent to the harnesses and
+	// from the task logs. Edited by hand.
+	Scrub scrub.Config `json:"scrub,omitzero"`
+	// Signing signs the commits caic creates during sync. Edited by hand.
+	Signing signingSettings `json:"signing,omitzero"`
+}
+
+// signingSettings configures task.Signing. An empty Key disables signing,
+// except for the users listed in Users.
+type signingSettings struct {
+	Format string `json:"format,omitempty"` // "openpgp" (default), "ssh" or "x509".
+	Key    string `json:"key,omitempty"`    // Key ID, or for "ssh" the path of the key.
+	// Users overrides Key per username, so that the commits of the tasks of
+	// a user are signed with their own key. Users share Format.
+	Users map[string]string `json:"users,omitempty"`
+}
+
+// signing converts the signing settings to the server default, nil when
+// disabled, and the per-user overrides keyed by username.
+func (s *serverSettings) signing() (*task.Signing, map[string]*task.Signing, error) {
+	var def *task.Signing
+	if s.Signing.Key != "" {
+		def = &task.Signing{Format: s.Signing.Format, Key: s.Signing.Key}
+		if err := def.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("signing: %w", err)
+		}
+	}
+	users := make(map[string]*task.Signing, len(s.Signing.Users))
+	for name, key := range s.Signing.Users {
+		u := &task.Signing{Format: s.Signing.Format, Key: key}
+		if err := u.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("signing.users[%q]: %w", name, err)
+		}
+		users[name] = u
+	}
+	return def, users, nil
+}
+
+// externalHarness registers an external harness.
+type externalHarness struct {
+	Name          string   `json:"name"`
+	Command       []string `json:"command"` // Run inside the container, e.g. ["python3", "/opt/myagent/caic.py"].
+	Models        []string `json:"models,omitempty"`
+	Images        bool     `json:"images,omitempty"`
+	ContextWindow int      `json:"contextWindow,omitempty"`
+	MutatingTools []string `json:"mutatingTools,omitempty"` // Patterns like "Edit" or "fs_write_*".
+}
+
+// externalBackends registers the external harnesses.
+func (s *serverSettings) externalBackends() ([]agent.Backend, error) {
+	out := make([]agent.Backend, 0, len(s.Harnesses))
+	for _, h := range s.Harnesses {
+		b, err := external.Register(&external.Config{
+			Name:          agent.Harness(h.Name),
+			Command:       h.Command,
+			Models:        h.Models,
+			Images:        h.Images,
+			ContextWindow: h.ContextWindow,
+			MutatingTools: h.MutatingTools,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+// imageSettings configures background container image prefetching. The
+// default base image, the base images from preferences and the per-repo
+// images are always prefetched.
+type imageSettings struct {
+	Prefetch         []string `json:"prefetch,omitempty"`         // Extra images to keep pulled.
+	PrefetchInterval string   `json:"prefetchInterval,omitempty"` // Go duration; default 6h.
+}
+
+// knowledgeSettings configures the per-repo knowledge base injected into new
+// tasks.
+type knowledgeSettings struct {
+	// Extract enables LLM extraction of learnings from purged tasks.
+	Extract bool `json:"extract,omitempty"`
+	// MaxBytes caps each repo's knowledge file; 0 uses the default.
+	MaxBytes int `json:"maxBytes,omitempty"`
+}
+
+// spendingSettings configures server-wide spending limits over rolling
+// windows. A zero limit is not enforced.
+type spendingSettings struct {
+	DailyUSD  float64 `json:"dailyUSD,omitempty"`
+	WeeklyUSD float64 `json:"weeklyUSD,omitempty"`
+	// Harness holds per-harness limits, keyed by harness name (e.g. "codex").
+	Harness map[string]spendingLimit `json:"harness,omitempty"`
+	// Admins is the former list of usernames allowed to override the limits.
+	//
+	// Deprecated: merged into serverSettings.Admins on load.
+	Admins []string `json:"admins,omitempty"`
+}
+
+// spendingLimit caps spend for one scope.
+type spendingLimit struct {
+	DailyUSD  float64 `json:"dailyUSD,omitempty"`
+	WeeklyUSD float64 `json:"weeklyUSD,omitempty"`
+}
+
+// knowledgeStore returns the knowledge store configured by the settings,
+// rooted at dir.
+func (s *serverSettings) knowledgeStore(dir string) (*knowledgeStore, error) {
+	k := &knowledgeStore{dir: dir, maxBytes: s.Knowledge.MaxBytes, extract: s.Knowledge.Extract}
+	if k.maxBytes < 0 {
+		return nil, errors.New("knowledge.maxBytes must not be negative")
+	}
+	if k.maxBytes == 0 {
+		k.maxBytes = defaultKnowledgeMaxBytes
+	}
+	return k, nil
+}
+
+// repoMapStore returns the repo map store rooted at dir, for the repos that
+// enabled it.
+func (s *serverSettings) repoMapStore(dir string) *repoMapStore {
+	m := &repoMapStore{dir: dir, enabled: map[string]bool{}}
+	for rel, rs := range s.Repos {
+		if rs.RepoMap {
+			m.enabled[rel] = true
+		}
+	}
+	return m
+}
+
+// spendingConfig converts the spending settings.
+func (s *serverSettings) spendingConfig() (spendingConfig, error) {
+	sp := &s.Spending
+	c := spendingConfig{overall: spendingLimit{DailyUSD: sp.DailyUSD, WeeklyUSD: sp.WeeklyUSD}}
+	if sp.DailyUSD < 0 || sp.WeeklyUSD < 0 {
+		return c, errors.New("spending limits must not be negative")
+	}
+	if len(sp.Harness) > 0 {
+		c.harness = make(map[agent.Harness]spendingLimit, len(sp.Harness))
+		for name, l := range sp.Harness {
+			if l.DailyUSD < 0 || l.WeeklyUSD < 0 {
+				return c, fmt.Errorf("spending.harness[%q]: limits must not be negative", name)
+			}
+			c.harness[agent.Harness(name)] = l
+		}
+	}
+	return c, nil
+}
+
+// diskSettings configures container disk usage monitoring.
+type diskSettings struct {
+	ProbeInterval string `json:"probeInterval,omitempty"` // Go duration; default 5m.
+	WarnPercent   int    `json:"warnPercent,omitempty"`   // Default 90.
+	// CleanCommand is run by POST /api/v1/tasks/{id}/clean from the primary
+	// repo checkout, e.g. "git clean -fdX && rm -rf ~/.cache/*".
+	CleanCommand string `json:"cleanCommand,omitempty"`
+}
+
+// resourceSettings configures container CPU/memory sampling.
+type resourceSettings struct {
+	ProbeInterval string `json:"probeInterval,omitempty"` // Go duration; default 1m.
+}
+
+// stuckSettings configures the detection of turns without any message from
+// the agent, e.g. because the model or the network hangs.
+type stuckSettings struct {
+	Timeout     string `json:"timeout,omitempty"`     // Go duration; default 10m, "0" disables.
+	ToolTimeout string `json:"toolTimeout,omitempty"` // Timeout while a tool runs; default 1h.
+	// Retry restarts the agent of a stuck turn and asks it to continue.
+	Retry bool `json:"retry,omitempty"`
+}
+
+// stuckConfig converts the stuck turn settings, applying defaults.
+func (s *serverSettings) stuckConfig() (stuckConfig, error) {
+	c := stuckConfig{timeout: defaultStuckTimeout, toolTimeout: defaultStuckToolTimeout, retry: s.Stuck.Retry}
+	for _, d := range []struct {
+		name string
+		in   string
+		out  *time.Duration
+	}{
+		{"stuck.timeout", s.Stuck.Timeout, &c.timeout},
+		{"stuck.toolTimeout", s.Stuck.ToolTimeout, &c.toolTimeout},
+	} {
+		if d.in == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.in)
+		if err != nil {
+			return c, fmt.Errorf("%s: %w", d.name, err)
+		}
+		if v < 0 {
+			return c, fmt.Errorf("%s must not be negative", d.name)
+		}
+		*d.out = v
+	}
+	return c, nil
+}
+
+// resourceInterval returns the resource sampling period, applying the
+// default.
+func (s *serverSettings) resourceInterval() (time.Duration, error) {
+	if s.Resources.ProbeInterval == "" {
+		return defaultResourceProbeInterval, nil
+	}
+	d, err := time.ParseDuration(s.Resources.ProbeInterval)
+	if err != nil {
+		return 0, fmt.Errorf("resources.probeInterval: %w", err)
+	}
+	if d <= 0 {
+		return 0, errors.New("resources.probeInterval must be positive")
+	}
+	return d, nil
+}
+
+// diskConfig converts the disk settings, applying defaults.
+func (s *serverSettings) diskConfig() (diskConfig, error) {
+	c := diskConfig{interval: defaultDiskProbeInterval, warnPercent: defaultDiskWarnPercent, cleanCommand: s.Disk.CleanCommand}
+	if s.Disk.ProbeInterval != "" {
+		d, err := time.ParseDuration(s.Disk.ProbeInterval)
+		if err != nil {
+			return c, fmt.Errorf("disk.probeInterval: %w", err)
+		}
+		if d <= 0 {
+			return c, errors.New("disk.probeInterval must be positive")
+		}
+		c.interval = d
+	}
+	if s.Disk.WarnPercent != 0 {
+		if s.Disk.WarnPercent < 0 || s.Disk.WarnPercent > 100 {
+			return c, errors.New("disk.warnPercent must be between 1 and 100")
+		}
+		c.warnPercent = s.Disk.WarnPercent
+	}
+	return c, nil
+}
+
+// repoSettings holds per-repository git tuning. Durations use Go syntax
+// (e.g. "5m").
+type repoSettings struct {
+	FetchTimeout  string `json:"fetchTimeout,omitempty"`
+	BranchTimeout string `json:"branchTimeout,omitempty"`
+	DiffTimeout   string `json:"diffTimeout,omitempty"`
+	FetchDepth    int    `json:"fetchDepth,omitempty"`
+	FetchFilter   string `json:"fetchFilter,omitempty"` // e.g. "blob:none"
+	// MaxFetches caps the concurrent fetches into the repository, from origin
+	// or from task containers; 0 is unlimited.
+	MaxFetches int `json:"maxFetches,omitempty"`
+	// SharedPaths are directories always checked out when a task is scoped to
+	// a subset of the repository, e.g. build tooling used by every project.
+	SharedPaths []string `json:"sharedPaths,omitempty"`
+	// ReservedBranchPrefixes are branch name prefixes used by humans that
+	// task branches must never match.
+	ReservedBranchPrefixes []string `json:"reservedBranchPrefixes,omitempty"`
+	// Image is the container image used by tasks of this repository that do
+	// not request one, e.g. a toolchain image for the project.
+	Image string `json:"image,omitempty"`
+	// AgentVersions pins harness CLI versions, keyed by harness (e.g.
+	// "claude": "1.0.98"). The version is installed in each new container.
+	AgentVersions map[string]string `json:"agentVersions,omitempty"`
+	// Policy restricts the harnesses and models tasks mounting this
+	// repository may use, e.g. to keep its code on a self-hosted harness.
+	Policy repoPolicy `json:"policy,omitzero"`
+	// MaxTasks caps the tasks of this repository running at once; 0 is
+	// unlimited.
+	MaxTasks int `json:"maxTasks,omitempty"`
+	// MaxBranches caps the task branches on origin. When reached, merged
+	// branches and, with BranchRetention, stale ones are deleted to make
+	// room for a new task; 0 is unlimited.
+	MaxBranches int `json:"maxBranches,omitempty"`
+	// BranchRetention is how long, as a Go duration, an unmerged task
+	// branch that no task uses is kept before it can be pruned. Empty keeps
+	// them. When set, the repository's branches are also pruned daily.
+	BranchRetention string `json:"branchRetention,omitempty"`
+	// RepoMap maintains a map of the packages and symbols of the base
+	// branch, injected into new tasks so agents explore less.
+	RepoMap bool `json:"repoMap,omitempty"`
+	// TaskNotes commits a TASK.md with the prompt, plan, decisions and test
+	// commands of the task on its branch when pushed, so reviewers of the
+	// branch get context without access to caic.
+	TaskNotes bool `json:"taskNotes,omitempty"`
+	// WorkHours lets tasks proceed without a human outside of working
+	// hours. Tasks can override it with their automation mode.
+	WorkHours *workHoursSettings `json:"workHours,omitempty"`
+	// TestGuard tunes the safety check blocking syncs of diffs that delete
+	// test files or remove more test lines than code lines, until forced.
+	TestGuard testGuardSettings `json:"testGuard,omitzero"`
+	// NoTrailers opts out of the trailers recording the agent, the task and
+	// its owner on the commits caic syncs, e.g. "Task: <id>".
+	NoTrailers bool `json:"noTrailers,omitempty"`
+	// Submodules inits the submodules in each new container; they are
+	// cloned from their upstream, relative URLs resolved against origin.
+	Submodules bool `json:"submodules,omitempty"`
+	// LFS pulls the Git LFS objects of the checkout in each new container
+	// from origin. The container image must provide git-lfs.
+	LFS bool `json:"lfs,omitempty"`
+	// AffectedTargets computes the build targets affected by a task's
+	// branch after each diff stat update: "go" for the Go packages depending
+	// on the changed ones, "bazel" for the reverse dependencies of the
+	// changed files.
+	AffectedTargets string `json:"affectedTargets,omitempty"`
+}
+
+// testGuardSettings tunes task.TestGuard.
+type testGuardSettings struct {
+	Disabled bool    `json:"disabled,omitempty"`
+	MinLines int     `json:"minLines,omitempty"` // Test lines removed net from which a reduction is flagged; default 50.
+	Ratio    float64 `json:"ratio,omitempty"`    // Flagged above this ratio of the code lines deleted; default 1.
+}
+
+// workHoursSettings defines the working hours of a repository. During them,
+// questions and plans wait for a human. Outside of them, so that overnight
+// batches finish, questions are answered with a default and plans are
+// approved after a grace delay.
+type workHoursSettings struct {
+	Days     []string `json:"days,omitempty"`     // e.g. ["sat"]; default "mon" to "fri".
+	Start    string   `json:"start"`              // e.g. "09:00".
+	End      string   `json:"end"`                // e.g. "18:00"; before Start for hours spanning midnight.
+	TimeZone string   `json:"timeZone,omitempty"` // IANA name; default the server's.
+	// Grace is how long, as a Go duration, a question or plan waits for a
+	// human outside of working hours; default 5m.
+	Grace string `json:"grace,omitempty"`
+	// Answer replies to questions outside of working hours; default "Use
+	// your best judgment.".
+	Answer string `json:"answer,omitempty"`
+}
+
+// repoPolicy restricts the harnesses and models usable on a repository. An
+// empty list allows any.
+type repoPolicy struct {
+	Harnesses []string `json:"harnesses,omitempty"` // e.g. ["myagent"].
+	Models    []string `json:"models,omitempty"`    // Model names; "" is the harness default.
+}
+
+// gitOptions converts the per-repo settings to task.GitOptions, keyed by
+// repo path.
+func (s *serverSettings) gitOptions() (map[string]task.GitOptions, error) {
+	out := make(map[string]task.GitOptions, len(s.Repos))
+	for rel, rs := range s.Repos {
+		var o task.GitOptions
+		for _, d := range []struct {
+			name string
+			in   string
+			out  *time.Duration
+		}{
+			{"fetchTimeout", rs.FetchTimeout, &o.FetchTimeout},
+			{"branchTimeout", rs.BranchTimeout, &o.BranchTimeout},
+			{"diffTimeout", rs.DiffTimeout, &o.DiffTimeout},
+		} {
+			if d.in == "" {
+				continue
+			}
+			v, err := time.ParseDuration(d.in)
+			if err != nil {
+				return nil, fmt.Errorf("repos[%q].%s: %w", rel, d.name, err)
+			}
+			*d.out = v
+		}
+		o.FetchDepth = rs.FetchDepth
+		o.FetchFilter = rs.FetchFilter
+		o.MaxFetches = rs.MaxFetches
+		o.SparseShared = rs.SharedPaths
+		o.ReservedPrefixes = rs.ReservedBranchPrefixes
+		o.TaskNotes = rs.TaskNotes
+		o.NoTrailers = rs.NoTrailers
+		o.Submodules = rs.Submodules
+		o.LFS = rs.LFS
+		o.AffectedTargets = task.AffectedTool(rs.AffectedTargets)
+		o.TestGuard = task.TestGuard{Disabled: rs.TestGuard.Disabled, MinLines: rs.TestGuard.MinLines, Ratio: rs.TestGuard.Ratio}
+		if err := o.Validate(); err != nil {
+			return nil, fmt.Errorf("repos[%q]: %w", rel, err)
+		}
+		out[rel] = o
+	}
+	return out, nil
+}
+
+// repoImages returns the default container image per repo path.
+func (s *serverSettings) repoImages() (map[string]string, error) {
+	out := map[string]string{}
+	for rel, rs := range s.Repos {
+		if rs.Image == "" {
+			continue
+		}
+		if !v1.ValidImageRef(rs.Image) {
+			return nil, fmt.Errorf("repos[%q].image: invalid image reference %q", rel, rs.Image)
+		}
+		out[rel] = rs.Image
+	}
+	return out, nil
+}
+
+// agentVersions returns the pinned harness CLI versions per repo path.
+func (s *serverSettings) agentVersions() (map[string]map[agent.Harness]string, error) {
+	out := map[string]map[agent.Harness]string{}
+	for rel, rs := range s.Repos {
+		for h, v := range rs.AgentVersions {
+			if _, ok := agent.Packages[agent.Harness(h)]; !ok {
+				return nil, fmt.Errorf("repos[%q].agentVersions: unknown harness %q", rel, h)
+			}
+			if !agent.ValidVersion(v) {
+				return nil, fmt.Errorf("repos[%q].agentVersions[%q]: invalid version %q", rel, h, v)
+			}
+			if out[rel] == nil {
+				out[rel] = map[agent.Harness]string{}
+			}
+			out[rel][agent.Harness(h)] = v
+		}
+	}
+	return out, nil
+}
+
+// repoPolicies returns the non-empty harness and model policies per repo
+// path.
+func (s *serverSettings) repoPolicies() (map[string]repoPolicy, error) {
+	out := map[string]repoPolicy{}
+	for rel, rs := range s.Repos {
+		p := rs.Policy
+		if len(p.Harnesses) == 0 && len(p.Models) == 0 {
+			continue
+		}
+		for _, h := range p.Harnesses {
+			_, builtin := agent.Packages[agent.Harness(h)]
+			external := slices.ContainsFunc(s.Harnesses, func(e externalHarness) bool { return e.Name == h })
+			if !builtin && !external {
+				return nil, fmt.Errorf("repos[%q].policy.harnesses: unknown harness %q", rel, h)
+			}
+		}
+		out[rel] = p
+	}
+	return out, nil
+}
+
+// repoLimits returns the task and branch limits per repo path.
+func (s *serverSettings) repoLimits() (map[string]repoLimits, error) {
+	out := map[string]repoLimits{}
+	for rel, rs := range s.Repos {
+		if rs.MaxTasks < 0 || rs.MaxBranches < 0 {
+			return nil, fmt.Errorf("repos[%q]: maxTasks and maxBranches must not be negative", rel)
+		}
+		l := repoLimits{maxTasks: rs.MaxTasks, maxBranches: rs.MaxBranches}
+		if rs.BranchRetention != "" {
+			d, err := time.ParseDuration(rs.BranchRetention)
+			if err != nil {
+				return nil, fmt.Errorf("repos[%q].branchRetention: %w", rel, err)
+			}
+			if d <= 0 {
+				return nil, fmt.Errorf("repos[%q].branchRetention must be positive", rel)
+			}
+			l.retention = d
+		}
+		if l != (repoLimits{}) {
+			out[rel] = l
+		}
+	}
+	return out, nil
+}
+
+// repoWorkHours returns the working hours per repo path.
+func (s *serverSettings) repoWorkHours() (map[string]*workHours, error) {
+	out := map[string]*workHours{}
+	for rel, rs := range s.Repos {
+		if rs.WorkHours == nil {
+			continue
+		}
+		w, err := parseWorkHours(rs.WorkHours)
+		if err != nil {
+			return nil, fmt.Errorf("repos[%q].workHours: %w", rel, err)
+		}
+		out[rel] = w
+	}
+	return out, nil
+}
+
+// prefetchConfig converts the image settings, applying defaults.
+func (s *serverSettings) prefetchConfig() (prefetchConfig, error) {
+	c := prefetchConfig{interval: warmupInterval}
+	for _, img := range s.Images.Prefetch {
+		if !v1.ValidImageRef(img) {
+			return c, fmt.Errorf("images.prefetch: invalid image reference %q", img)
+		}
+		c.images = append(c.images, img)
+	}
+	if s.Images.PrefetchInterval != "" {
+		d, err := time.ParseDuration(s.Images.PrefetchInterval)
+		if err != nil {
+			return c, fmt.Errorf("images.prefetchInterval: %w", err)
+		}
+		if d <= 0 {
+			return c, errors.New("images.prefetchInterval must be positive")
+		}
+		c.interval = d
+	}
+	return c, nil
 }
 
 // loadSettings reads settings from path, generating any missing values and
@@ -35,6 +577,15 @@ func loadSettings(path string) (*serverSettings, error) {
 		s.SessionSecret = hex.EncodeToString(raw[:])
 		dirty = true
 	}
+	if len(s.Spending.Admins) != 0 {
+		for _, a := range s.Spending.Admins {
+			if !slices.Contains(s.Admins, a) {
+				s.Admins = append(s.Admins, a)
+			}
+		}
+		s.Spending.Admins = nil
+		dirty = true
+	}
 
 	if dirty {
 		if err := writeSettingsAtomic(path, &s); err != nil {
@@ -46,14 +597,35 @@ func loadSettings(path string) (*serverSettings, error) {
 
 // writeSettingsAtomic writes settings to path via a temp file + rename.
 func writeSettingsAtomic(path string, s *serverSettings) error {
-	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+	data, err := json.MarshalIndent(s, "", "  ") //nolint:gosec // G117: sessionSecret is intentionally written to config file owned by the user
+	if err != nil {
 		return err
 	}
-	data, err := json.MarshalIndent(s, "", "  ") //nolint:gosec // G117: sessionSecret is intentionally written to config file owned by the user
+	return writeFileAtomic(path, append(data, '\n'))
+}
+
+// writeSealedAtomic is writeFileAtomic for content derived from the task
+// conversations, encrypted like the logs when a log key is set.
+func writeSealedAtomic(path string, data []byte) error {
+	return writeFileAtomic(path, logcrypt.SealFile(data))
+}
+
+// readSealed returns the plaintext content of a file written with
+// writeSealedAtomic, or written in plaintext before encryption was enabled.
+func readSealed(path string) ([]byte, error) {
+	raw, err := os.ReadFile(filepath.Clean(path))
 	if err != nil {
+		return nil, err
+	}
+	return logcrypt.OpenFile(raw)
+}
+
+// writeFileAtomic writes data to path through a temporary file renamed over
+// it, creating the parent directory, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
 		return err
 	}
-	data = append(data, '\n')
 	tmp := path + ".tmp"
 	if err := os.WriteFile(tmp, data, 0o600); err != nil {
 		return err
@@ -0,0 +1,126 @@
+// Shareable read-only task links authorized by signed, expiring tokens.
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/maruel/ksid"
+)
+
+const (
+	// defaultShareTTL is the lifetime of a share link when none is requested.
+	defaultShareTTL = 24 * time.Hour
+	// maxShareTTL bounds the lifetime of a share link.
+	maxShareTTL = 30 * 24 * time.Hour
+)
+
+// sharePayload is the signed content of a share token.
+type sharePayload struct {
+	TaskID ksid.ID `json:"tid"`
+	Expiry int64   `json:"exp"` // Unix seconds.
+}
+
+// signShare returns the signature of an encoded payload. The "share." prefix
+// keeps share tokens from being valid session tokens and vice versa.
+func signShare(secret []byte, encoded string) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte("share." + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// issueShareToken returns a token granting read-only access to task id until
+// exp.
+func issueShareToken(secret []byte, id ksid.ID, exp time.Time) (string, error) {
+	data, err := json.Marshal(sharePayload{TaskID: id, Expiry: exp.Unix()})
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + signShare(secret, encoded), nil
+}
+
+// validateShareToken returns the task a share token grants access to.
+func validateShareToken(secret []byte, token string, now time.Time) (ksid.ID, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || len(secret) == 0 {
+		return 0, errors.New("invalid share token")
+	}
+	if !hmac.Equal([]byte(sig), []byte(signShare(secret, encoded))) {
+		return 0, errors.New("invalid share token signature")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return 0, errors.New("invalid share token")
+	}
+	var p sharePayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return 0, errors.New("invalid share token")
+	}
+	if now.Unix() > p.Expiry {
+		return 0, errors.New("share token expired")
+	}
+	return p.TaskID, nil
+}
+
+// shareTask mints a read-only link to the task.
+func (s *Server) shareTask(_ context.Context, entry *taskEntry, req *v1.ShareTaskReq) (*v1.ShareTaskResp, error) {
+	if len(s.shareSecret) == 0 {
+		return nil, dto.Conflict("sharing is not configured")
+	}
+	ttl := defaultShareTTL
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 || d > maxShareTTL {
+			return nil, dto.BadRequest("invalid ttl").WithDetail("max", maxShareTTL.String())
+		}
+		ttl = d
+	}
+	exp := time.Now().Add(ttl)
+	token, err := issueShareToken(s.shareSecret, entry.task.ID, exp)
+	if err != nil {
+		return nil, dto.InternalError("issue share token").Wrap(err)
+	}
+	return &v1.ShareTaskResp{
+		Token:     token,
+		Path:      s.basePath + "/api/v1/shared/" + token,
+		ExpiresAt: float64(exp.UnixMilli()) / 1e3,
+	}, nil
+}
+
+// shared wraps a task handler so it is reachable with a share token instead
+// of a session. The request is stripped of any user so the task's ownership
+// check does not apply: the token is the authorization.
+func (s *Server) shared(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := validateShareToken(s.shareSecret, r.PathValue("token"), time.Now())
+		if err != nil {
+			writeError(w, dto.NotFound("shared task"))
+			return
+		}
+		r = r.WithContext(auth.NewContext(r.Context(), nil))
+		r.SetPathValue("id", id.String())
+		h(w, r)
+	}
+}
+
+// handleGetSharedTask returns the task a share token grants access to.
+func (s *Server) handleGetSharedTask(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	j := s.toJSON(entry)
+	writeJSONResponse(w, &j, nil)
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+func TestShareToken(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	id := ksid.NewID()
+	now := time.Now()
+	token, err := issueShareToken(secret, id, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := validateShareToken(secret, token, now); err != nil || got != id {
+		t.Fatalf("validate = %v, %v", got, err)
+	}
+	if _, err := validateShareToken(secret, token, now.Add(2*time.Hour)); err == nil {
+		t.Error("expired token accepted")
+	}
+	if _, err := validateShareToken([]byte("other"), token, now); err == nil {
+		t.Error("token accepted with another secret")
+	}
+	payload, _, _ := strings.Cut(token, ".")
+	forged, err := issueShareToken([]byte("other"), ksid.NewID(), now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, forgedSig, _ := strings.Cut(forged, ".")
+	if _, err := validateShareToken(secret, payload+"."+forgedSig, now); err == nil {
+		t.Error("tampered token accepted")
+	}
+	// A session token signed with the same secret is not a share token.
+	jwt, err := auth.IssueToken(&auth.User{ID: "u"}, secret, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := validateShareToken(secret, jwt, now); err == nil {
+		t.Error("session token accepted as share token")
+	}
+}
+
+func TestSharedTask(t *testing.T) {
+	s := newTestServer(t)
+	s.shareSecret = []byte("0123456789abcdef0123456789abcdef")
+	s.sessionSecret = []byte("fedcba9876543210fedcba9876543210")
+	store, err := auth.Open(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.authStore = store
+	tk := &task.Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "secret sauce"}, OwnerID: "alice"}
+	setState(t, tk, task.StateWaiting)
+	e := &taskEntry{task: tk, done: make(chan struct{})}
+	s.tasks[tk.ID.String()] = e
+
+	if _, err := s.shareTask(t.Context(), e, &v1.ShareTaskReq{TTL: "800h"}); err == nil {
+		t.Error("expected error for a TTL above the maximum")
+	}
+	resp, err := s.shareTask(t.Context(), e, &v1.ShareTaskReq{TTL: "1h"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Until(time.UnixMilli(int64(resp.ExpiresAt * 1e3))); d <= 0 || d > time.Hour {
+		t.Errorf("expires in %s", d)
+	}
+
+	h, err := s.buildHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+	// The regular API requires a session.
+	if w := get("/api/v1/tasks"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated /api/v1/tasks: status = %d", w.Code)
+	}
+	w := get(resp.Path)
+	if w.Code != http.StatusOK {
+		t.Fatalf("shared task: status = %d: %s", w.Code, w.Body)
+	}
+	var j v1.Task
+	if err := json.NewDecoder(w.Body).Decode(&j); err != nil {
+		t.Fatal(err)
+	}
+	if j.ID != tk.ID || j.InitialPrompt != "secret sauce" {
+		t.Errorf("shared task = %+v", j)
+	}
+	if w := get(resp.Path + "x"); w.Code != http.StatusNotFound {
+		t.Errorf("bad token: status = %d", w.Code)
+	}
+	// Writes are not reachable with a share token.
+	req := httptest.NewRequest(http.MethodPost, resp.Path+"/events", http.NoBody)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code == http.StatusOK {
+		t.Errorf("POST on shared path: status = %d", w.Code)
+	}
+}
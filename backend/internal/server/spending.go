@@ -0,0 +1,264 @@
+// Server-wide spending limits: enforcement at task creation, warnings to
+// running tasks and a temporary admin override.
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// spendingCheckInterval controls how often monitorSpending looks for newly
+// exceeded limits.
+const spendingCheckInterval = time.Minute
+
+// spendWindow is the longest window spending limits apply to.
+const spendWindow = 7 * 24 * time.Hour
+
+// lowPrioritySpendShare is the share of a spending limit past which low
+// priority tasks are refused, keeping the rest for the other tasks.
+const lowPrioritySpendShare = 0.8
+
+// spendingConfig is the parsed form of spendingSettings.
+type spendingConfig struct {
+	overall spendingLimit
+	harness map[agent.Harness]spendingLimit
+}
+
+// enabled reports whether any limit is configured.
+func (c *spendingConfig) enabled() bool {
+	if c.overall != (spendingLimit{}) {
+		return true
+	}
+	for _, l := range c.harness {
+		if l != (spendingLimit{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// spendTotals is the USD spend over the rolling windows, overall and per
+// harness.
+type spendTotals struct {
+	day, week               float64
+	harnessDay, harnessWeek map[agent.Harness]float64
+}
+
+// computeSpend sums the cost of turns that ended within the last 24 hours
+// and 7 days. It relies on the per-turn series so spend is attributed to when
+// it happened, not to when the task started. The turns of past tasks count
+// unless the task is in tasks, since the UI list only keeps a few tasks per
+// repo across restarts.
+func computeSpend(tasks map[string]*taskEntry, past map[string]pastTask, now time.Time) spendTotals {
+	dayCutoff := now.Add(-24 * time.Hour)
+	weekCutoff := now.Add(-spendWindow)
+	out := spendTotals{harnessDay: map[agent.Harness]float64{}, harnessWeek: map[agent.Harness]float64{}}
+	add := func(h agent.Harness, turns []task.TurnUsage) {
+		for _, tu := range turns {
+			if tu.EndedAt.IsZero() || !tu.EndedAt.After(weekCutoff) {
+				continue
+			}
+			out.week += tu.CostUSD
+			out.harnessWeek[h] += tu.CostUSD
+			if tu.EndedAt.After(dayCutoff) {
+				out.day += tu.CostUSD
+				out.harnessDay[h] += tu.CostUSD
+			}
+		}
+	}
+	for _, e := range tasks {
+		add(e.task.Harness, e.task.TurnUsages())
+	}
+	for id, p := range past {
+		if _, ok := tasks[id]; !ok {
+			add(p.harness, p.turns)
+		}
+	}
+	return out
+}
+
+// limits returns the state of every configured limit: overall first, then
+// per harness sorted by name.
+func (c *spendingConfig) limits(st *spendTotals) []v1.SpendingLimit {
+	out := []v1.SpendingLimit{}
+	add := func(h agent.Harness, l spendingLimit, day, week float64) {
+		if l.DailyUSD > 0 {
+			out = append(out, v1.SpendingLimit{Harness: toV1Harness(h), Window: "daily", LimitUSD: l.DailyUSD, SpentUSD: day, Exceeded: day >= l.DailyUSD})
+		}
+		if l.WeeklyUSD > 0 {
+			out = append(out, v1.SpendingLimit{Harness: toV1Harness(h), Window: "weekly", LimitUSD: l.WeeklyUSD, SpentUSD: week, Exceeded: week >= l.WeeklyUSD})
+		}
+	}
+	add("", c.overall, st.day, st.week)
+	for _, h := range slices.Sorted(maps.Keys(c.harness)) {
+		add(h, c.harness[h], st.harnessDay[h], st.harnessWeek[h])
+	}
+	return out
+}
+
+// spendingStatusLocked returns the current state of the limits. s.mu must be
+// held.
+func (s *Server) spendingStatusLocked(now time.Time) *v1.SpendingResp {
+	st := computeSpend(s.tasks, s.history, now)
+	resp := &v1.SpendingResp{Limits: s.spending.limits(&st)}
+	if now.Before(s.spendOverrideUntil) {
+		resp.OverrideUntil = float64(s.spendOverrideUntil.UnixMilli()) / 1e3
+	}
+	return resp
+}
+
+// checkSpending returns a 429 error when a limit applying to harness is
+// exceeded, or for a low priority task nearly so, and no override is active.
+func (s *Server) checkSpending(harness agent.Harness, prio task.Priority) error {
+	if !s.spending.enabled() {
+		return nil
+	}
+	s.mu.Lock()
+	st := s.spendingStatusLocked(time.Now())
+	s.mu.Unlock()
+	if st.OverrideUntil != 0 {
+		return nil
+	}
+	for _, l := range st.Limits {
+		if l.Harness != "" && l.Harness != toV1Harness(harness) {
+			continue
+		}
+		msg := ""
+		switch {
+		case l.Exceeded:
+			msg = spendingDetail(&l)
+		case prio == task.PriorityLow && l.SpentUSD >= lowPrioritySpendShare*l.LimitUSD:
+			msg = fmt.Sprintf("%s %s spending is past the %.0f%% low priority tasks may use: $%.2f of $%.2f", spendingScope(&l), l.Window, 100*lowPrioritySpendShare, l.SpentUSD, l.LimitUSD)
+		default:
+			continue
+		}
+		return dto.TooManyRequests(msg).
+			WithDetail("harness", l.Harness).
+			WithDetail("window", l.Window).
+			WithDetail("limitUSD", l.LimitUSD).
+			WithDetail("spentUSD", l.SpentUSD)
+	}
+	return nil
+}
+
+// spendingScope names what a limit applies to.
+func spendingScope(l *v1.SpendingLimit) string {
+	if l.Harness != "" {
+		return string(l.Harness)
+	}
+	return "overall"
+}
+
+// spendingDetail describes an exceeded limit.
+func spendingDetail(l *v1.SpendingLimit) string {
+	return fmt.Sprintf("%s %s spending limit exceeded: $%.2f of $%.2f", spendingScope(l), l.Window, l.SpentUSD, l.LimitUSD)
+}
+
+// monitorSpending periodically warns running tasks about newly exceeded
+// limits until the server context is cancelled.
+func (s *Server) monitorSpending() {
+	if !s.spending.enabled() {
+		return
+	}
+	ticker := time.NewTicker(spendingCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+		s.warnSpending(s.ctx)
+	}
+}
+
+// warnSpending emits a spending_limit_exceeded event to the running tasks a
+// limit applies to, once per excursion above the limit. When preemption is
+// enabled, low priority tasks are also paused.
+func (s *Server) warnSpending(ctx context.Context) {
+	type warning struct {
+		e      *taskEntry
+		detail string
+	}
+	var warnings []warning
+	s.mu.Lock()
+	st := s.spendingStatusLocked(time.Now())
+	exceeded := make(map[string]bool)
+	for i := range st.Limits {
+		l := &st.Limits[i]
+		if !l.Exceeded {
+			continue
+		}
+		key := string(l.Harness) + "/" + l.Window
+		exceeded[key] = true
+		if s.spendWarned[key] {
+			continue
+		}
+		detail := spendingDetail(l)
+		slog.Warn("spending", "msg", detail)
+		for _, e := range s.tasks {
+			if e.result != nil || (l.Harness != "" && toV1Harness(e.task.Harness) != l.Harness) {
+				continue
+			}
+			switch e.task.GetState() {
+			case task.StateRunning, task.StateWaiting, task.StateAsking, task.StateHasPlan:
+				warnings = append(warnings, warning{e, detail})
+			default:
+			}
+		}
+	}
+	s.spendWarned = exceeded
+	s.mu.Unlock()
+	for _, w := range warnings {
+		w.e.task.Notify(ctx, "spending_limit_exceeded", w.detail)
+		if s.preempt && w.e.task.Priority == task.PriorityLow {
+			s.preemptTask(ctx, w.e, nil, "save spending quota")
+		}
+	}
+	if len(warnings) > 0 {
+		s.notifyTaskChange()
+	}
+}
+
+// getSpending returns the state of the configured spending limits.
+func (s *Server) getSpending(_ context.Context, _ *dto.EmptyReq) (*v1.SpendingResp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.spendingStatusLocked(time.Now()), nil
+}
+
+// overrideSpending suspends limit enforcement for the requested duration.
+func (s *Server) overrideSpending(ctx context.Context, req *v1.SpendingOverrideReq) (*v1.SpendingResp, error) {
+	user := ""
+	if s.authStore != nil {
+		u, ok := auth.UserFromContext(ctx)
+		if !ok || !slices.Contains(s.admins, u.Username) {
+			return nil, dto.Forbidden("spending override")
+		}
+		user = u.Username
+	}
+	d, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return nil, dto.BadRequest("invalid duration: " + req.Duration)
+	}
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if d == 0 {
+		s.spendOverrideUntil = time.Time{}
+	} else {
+		s.spendOverrideUntil = now.Add(d)
+	}
+	slog.Info("spending", "msg", "override", "until", s.spendOverrideUntil, "user", user)
+	return s.spendingStatusLocked(now), nil
+}
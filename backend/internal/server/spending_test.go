@@ -0,0 +1,171 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/ksid"
+)
+
+// spendTask returns a running task that spent costUSD in one turn ending
+// about age ago.
+func spendTask(t *testing.T, h agent.Harness, costUSD float64, age time.Duration) *taskEntry {
+	tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Harness: h, StartedAt: time.Now().Add(-age)}
+	setState(t, tk, task.StateRunning)
+	tk.RestoreMessages([]agent.Message{&agent.ResultMessage{MessageType: "result", TotalCostUSD: costUSD, DurationMs: 1000}})
+	return &taskEntry{task: tk, done: make(chan struct{})}
+}
+
+func TestSpending(t *testing.T) {
+	t.Run("ComputeSpend", func(t *testing.T) {
+		tasks := map[string]*taskEntry{
+			"a": spendTask(t, agent.Claude, 5, time.Hour),
+			"b": spendTask(t, agent.Codex, 3, 2*24*time.Hour),
+			"c": spendTask(t, agent.Codex, 100, 8*24*time.Hour),
+		}
+		st := computeSpend(tasks, nil, time.Now())
+		if st.day != 5 || st.week != 8 {
+			t.Errorf("day = %v, week = %v", st.day, st.week)
+		}
+		if st.harnessDay[agent.Codex] != 0 || st.harnessWeek[agent.Codex] != 3 {
+			t.Errorf("codex day = %v, week = %v", st.harnessDay[agent.Codex], st.harnessWeek[agent.Codex])
+		}
+	})
+	t.Run("Restart", func(t *testing.T) {
+		// More tasks in one repo than loadPurgedTasksFrom lists.
+		logDir := t.TempDir()
+		for i := range 7 {
+			meta := mustJSON(t, agent.MetaMessage{
+				MessageType: "caic_meta", Version: 1, Prompt: fmt.Sprintf("a-%d", i),
+				Repos: []agent.MetaRepo{{Name: "a", Branch: fmt.Sprintf("caic-%d", i)}}, Harness: agent.Claude, StartedAt: time.Now().Add(-time.Hour),
+			})
+			result := mustJSON(t, agent.ResultMessage{MessageType: "result", Subtype: "success", TotalCostUSD: 2, DurationMs: 1000})
+			trailer := mustJSON(t, agent.MetaResultMessage{MessageType: "caic_result", State: "purged", CostUSD: 2})
+			writeLogFile(t, logDir, fmt.Sprintf("%s-a-caic-%d.jsonl", ksid.NewID(), i), meta, result, trailer)
+		}
+		logs, err := task.LoadLogs(logDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := newTestServer(t)
+		s.history = loadHistory(logs, time.Now())
+		if err := s.loadPurgedTasksFrom(logs); err != nil {
+			t.Fatal(err)
+		}
+		s.spending = spendingConfig{overall: spendingLimit{DailyUSD: 100}}
+		s.mu.Lock()
+		n := len(s.tasks)
+		st := s.spendingStatusLocked(time.Now())
+		s.mu.Unlock()
+		if n != 5 {
+			t.Errorf("len(tasks) = %d, want 5", n)
+		}
+		if len(st.Limits) != 1 || st.Limits[0].SpentUSD != 14 {
+			t.Errorf("limits = %+v, want $14 spent", st.Limits)
+		}
+	})
+	t.Run("CheckSpending", func(t *testing.T) {
+		s := newTestServer(t)
+		s.tasks["a"] = spendTask(t, agent.Codex, 12, time.Hour)
+		s.spending = spendingConfig{
+			overall: spendingLimit{WeeklyUSD: 100},
+			harness: map[agent.Harness]spendingLimit{agent.Codex: {DailyUSD: 10}},
+		}
+		if err := s.checkSpending(agent.Claude, task.PriorityNormal); err != nil {
+			t.Errorf("claude: %v", err)
+		}
+		err := s.checkSpending(agent.Codex, task.PriorityNormal)
+		apiErr, ok := err.(*dto.APIError)
+		if !ok || apiErr.StatusCode() != http.StatusTooManyRequests || apiErr.Details()["window"] != "daily" {
+			t.Fatalf("codex: %v", err)
+		}
+		if _, err := s.overrideSpending(t.Context(), &v1.SpendingOverrideReq{Duration: "1h"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.checkSpending(agent.Codex, task.PriorityNormal); err != nil {
+			t.Errorf("overridden: %v", err)
+		}
+		resp, err := s.overrideSpending(t.Context(), &v1.SpendingOverrideReq{Duration: "0s"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.OverrideUntil != 0 || len(resp.Limits) != 2 || !resp.Limits[1].Exceeded || resp.Limits[0].Exceeded {
+			t.Errorf("resp = %+v", resp)
+		}
+		if s.checkSpending(agent.Codex, task.PriorityNormal) == nil {
+			t.Error("override not cancelled")
+		}
+	})
+	t.Run("LowPriority", func(t *testing.T) {
+		s := newTestServer(t)
+		s.tasks["a"] = spendTask(t, agent.Claude, 9, time.Hour)
+		s.spending = spendingConfig{overall: spendingLimit{DailyUSD: 10}}
+		if err := s.checkSpending(agent.Claude, task.PriorityNormal); err != nil {
+			t.Errorf("normal: %v", err)
+		}
+		if s.checkSpending(agent.Claude, task.PriorityLow) == nil {
+			t.Error("low priority task allowed past its share")
+		}
+	})
+	t.Run("WarnOnce", func(t *testing.T) {
+		s := newTestServer(t)
+		e := spendTask(t, agent.Claude, 20, time.Hour)
+		s.tasks["a"] = e
+		s.spending = spendingConfig{overall: spendingLimit{DailyUSD: 10}}
+		count := func() int {
+			n := 0
+			for _, m := range e.task.Messages() {
+				if sm, ok := m.(*agent.SystemMessage); ok && sm.Subtype == "spending_limit_exceeded" {
+					n++
+				}
+			}
+			return n
+		}
+		s.warnSpending(t.Context())
+		s.warnSpending(t.Context())
+		if n := count(); n != 1 {
+			t.Errorf("warnings = %d, want 1", n)
+		}
+	})
+}
+
+func TestSpendingConfig(t *testing.T) {
+	c, err := (&serverSettings{Spending: spendingSettings{Harness: map[string]spendingLimit{"codex": {WeeklyUSD: 50}}}}).spendingConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.enabled() || c.harness[agent.Codex].WeeklyUSD != 50 {
+		t.Errorf("got %+v", c)
+	}
+	if c, _ := (&serverSettings{}).spendingConfig(); c.enabled() {
+		t.Error("empty settings enabled limits")
+	}
+	if _, err := (&serverSettings{Spending: spendingSettings{DailyUSD: -1}}).spendingConfig(); err == nil {
+		t.Error("negative limit accepted")
+	}
+}
+
+func TestSpendingAdminsMigrated(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "settings.json")
+	if err := writeSettingsAtomic(p, &serverSettings{SessionSecret: "abcd", Admins: []string{"root"}, Spending: spendingSettings{Admins: []string{"alice", "root"}}}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadSettings(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(s.Admins, []string{"root", "alice"}) || s.Spending.Admins != nil {
+		t.Errorf("admins = %v, spending admins = %v", s.Admins, s.Spending.Admins)
+	}
+	if s, err = loadSettings(p); err != nil || len(s.Spending.Admins) != 0 {
+		t.Errorf("migration not persisted: %+v, %v", s, err)
+	}
+}
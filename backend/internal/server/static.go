@@ -2,12 +2,17 @@
 //
 // At build time, each file in dist/ is brotli-compressed at maximum quality
 // and the original is deleted, so only .br files are embedded. This handler
-// serves .br directly when the client accepts it, and lazily transcodes to
-// gzip, zstd, or uncompressed for other clients, caching the result.
+// serves .br directly when the client accepts it, serves an optional .gz
+// sibling to gzip-only clients, and lazily transcodes to gzip, zstd, or
+// uncompressed for other clients, caching the result. Every response carries
+// a strong ETag derived from the embedded content so that revalidation of
+// index.html costs a 304.
 package server
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"io/fs"
 	"mime"
@@ -33,11 +38,14 @@ type transcodeEntry struct {
 // newStaticHandler returns an http.HandlerFunc that serves precompressed
 // static files from dist with SPA fallback to index.html.
 //
-// Only .br files exist on disk. The handler serves brotli directly when
-// accepted, and lazily transcodes to zstd/gzip/identity otherwise.
+// Every file has a .br variant; a .gz variant is optional. The handler serves
+// a precompressed variant directly when accepted, and lazily transcodes to
+// zstd/gzip/identity otherwise.
 func newStaticHandler(dist fs.FS) http.HandlerFunc {
 	// cache maps "path\x00encoding" → *transcodeEntry.
 	var cache sync.Map
+	// etags maps path → *transcodeEntry holding the content hash.
+	var etags sync.Map
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet && r.Method != http.MethodHead {
@@ -60,13 +68,25 @@ func newStaticHandler(dist fs.FS) http.HandlerFunc {
 			ct = "application/octet-stream"
 		}
 
+		tag, err := contentTag(&etags, dist, clean)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
 		accepted := parseAcceptEncoding(r.Header.Get("Accept-Encoding"))
 
-		// Fast path: serve .br directly.
+		// Fast path: serve a precompressed variant directly.
 		if accepted["br"] {
-			serveBrotli(w, r, dist, clean, ct)
+			servePrecompressed(w, r, dist, clean, ct, "br", tag)
 			return
 		}
+		if accepted["gzip"] {
+			if _, err := fs.Stat(dist, clean+".gz"); err == nil {
+				servePrecompressed(w, r, dist, clean, ct, "gzip", tag)
+				return
+			}
+		}
 
 		// Pick best accepted encoding, falling back to identity.
 		enc := "identity"
@@ -77,6 +97,15 @@ func newStaticHandler(dist fs.FS) http.HandlerFunc {
 			}
 		}
 
+		etag := variantETag(tag, enc)
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Vary", "Accept-Encoding")
+		setStaticCacheControl(w, clean)
+		if etagMatch(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
 		data, err := transcode(&cache, dist, clean, enc)
 		if err != nil {
 			http.NotFound(w, r)
@@ -88,16 +117,19 @@ func newStaticHandler(dist fs.FS) http.HandlerFunc {
 			w.Header().Set("Content-Encoding", enc)
 		}
 		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
-		w.Header().Set("Vary", "Accept-Encoding")
-		setStaticCacheControl(w, clean)
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write(data) //nolint:gosec // data from embedded FS, not user input
 	}
 }
 
-// serveBrotli serves a .br file directly from the embedded FS.
-func serveBrotli(w http.ResponseWriter, r *http.Request, dist fs.FS, clean, ct string) {
-	f, err := dist.Open(clean + ".br")
+// precompressedExt maps a Content-Encoding to the extension of its
+// precompressed variant in dist.
+var precompressedExt = map[string]string{"br": ".br", "gzip": ".gz"}
+
+// servePrecompressed serves a precompressed variant directly from the
+// embedded FS. http.ServeContent answers If-None-Match with a 304.
+func servePrecompressed(w http.ResponseWriter, r *http.Request, dist fs.FS, clean, ct, enc, tag string) {
+	f, err := dist.Open(clean + precompressedExt[enc])
 	if err != nil {
 		http.NotFound(w, r)
 		return
@@ -111,8 +143,9 @@ func serveBrotli(w http.ResponseWriter, r *http.Request, dist fs.FS, clean, ct s
 	}
 
 	w.Header().Set("Content-Type", ct)
-	w.Header().Set("Content-Encoding", "br")
+	w.Header().Set("Content-Encoding", enc)
 	w.Header().Set("Content-Length", strconv.FormatInt(stat.Size(), 10))
+	w.Header().Set("ETag", variantETag(tag, enc))
 	w.Header().Set("Vary", "Accept-Encoding")
 	setStaticCacheControl(w, clean)
 	http.ServeContent(w, r, clean, stat.ModTime(), f.(io.ReadSeeker))
@@ -130,6 +163,41 @@ func transcode(cache *sync.Map, dist fs.FS, clean, enc string) ([]byte, error) {
 	return entry.data, entry.err
 }
 
+// contentTag returns a hash of the .br file, computed once per path. The .br
+// file is the build's source of truth so the hash changes with every content
+// change, independent of the embedded FS's zero modification times.
+func contentTag(cache *sync.Map, dist fs.FS, clean string) (string, error) {
+	val, _ := cache.LoadOrStore(clean, &transcodeEntry{})
+	entry := val.(*transcodeEntry)
+	entry.once.Do(func() {
+		var raw []byte
+		if raw, entry.err = fs.ReadFile(dist, clean+".br"); entry.err == nil {
+			sum := sha256.Sum256(raw)
+			entry.data = []byte(hex.EncodeToString(sum[:12]))
+		}
+	})
+	return string(entry.data), entry.err
+}
+
+// variantETag returns the strong ETag of the enc representation of a file
+// whose content hash is tag. Each encoding is a distinct representation so
+// it gets a distinct ETag.
+func variantETag(tag, enc string) string {
+	return `"` + tag + "-" + enc + `"`
+}
+
+// etagMatch reports whether the If-None-Match header value matches etag,
+// using the weak comparison RFC 9110 mandates for If-None-Match.
+func etagMatch(header, etag string) bool {
+	for part := range strings.SplitSeq(header, ",") {
+		part = strings.TrimPrefix(strings.TrimSpace(part), "W/")
+		if part == "*" || part == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // doTranscode performs the actual decompress-then-recompress.
 func doTranscode(dist fs.FS, clean, enc string) ([]byte, error) {
 	f, err := dist.Open(clean + ".br")
@@ -194,6 +194,77 @@ func TestStaticHandler(t *testing.T) {
 			t.Errorf("Content-Encoding = %q, want %q", got, "br")
 		}
 	})
+	t.Run("ETagRevalidation", func(t *testing.T) {
+		for _, ae := range []string{"br", "zstd", ""} {
+			t.Run(ae, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+				req.Header.Set("Accept-Encoding", ae)
+				w := httptest.NewRecorder()
+				h(w, req)
+				etag := w.Header().Get("ETag")
+				if w.Code != http.StatusOK || etag == "" {
+					t.Fatalf("status = %d, ETag = %q", w.Code, etag)
+				}
+
+				req = httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+				req.Header.Set("Accept-Encoding", ae)
+				req.Header.Set("If-None-Match", `"stale", `+etag)
+				w = httptest.NewRecorder()
+				compressMiddleware(h).ServeHTTP(w, req)
+				if w.Code != http.StatusNotModified {
+					t.Fatalf("status = %d, want 304", w.Code)
+				}
+				if w.Body.Len() != 0 {
+					t.Errorf("body = %q, want empty", w.Body.Bytes())
+				}
+				if got := w.Header().Get("Content-Encoding"); got != "" {
+					t.Errorf("Content-Encoding = %q, want empty", got)
+				}
+			})
+		}
+	})
+
+	t.Run("ETagPerEncoding", func(t *testing.T) {
+		etag := func(ae string) string {
+			req := httptest.NewRequest(http.MethodGet, "/assets/app.js", http.NoBody)
+			req.Header.Set("Accept-Encoding", ae)
+			w := httptest.NewRecorder()
+			h(w, req)
+			return w.Header().Get("ETag")
+		}
+		if br, gz := etag("br"), etag("gzip"); br == gz {
+			t.Errorf("br and gzip share ETag %q", br)
+		}
+		if a, b := etag("br"), etag("br"); a != b {
+			t.Errorf("ETag not stable: %q != %q", a, b)
+		}
+	})
+}
+
+func TestStaticHandlerPrecompressedGzip(t *testing.T) {
+	// A .gz sibling is served as-is instead of being transcoded.
+	gz := []byte("not really gzip")
+	dist := testFS(t)
+	dist["assets/app.js.gz"] = &fstest.MapFile{Data: gz}
+	h := newStaticHandler(dist)
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/app.js", http.NoBody)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	compressMiddleware(h).ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("Content-Encoding = %q, want %q", got, "gzip")
+	}
+	if !bytes.Equal(w.Body.Bytes(), gz) {
+		t.Errorf("body = %q, want the .gz file verbatim", w.Body.Bytes())
+	}
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Errorf("Cache-Control = %q, want immutable", got)
+	}
 }
 
 func TestParseAcceptEncoding(t *testing.T) {
@@ -0,0 +1,43 @@
+// Server status endpoint reporting harness schema drift, the container
+// runtime and the resource usage of the process.
+package server
+
+import (
+	"context"
+	"runtime"
+
+	"github.com/caic-xyz/caic/backend/internal/container"
+	"github.com/caic-xyz/caic/backend/internal/jsonutil"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// getServerStatus reports the unknown fields and record types the parsers
+// met, so operators notice a harness format change before it breaks parsing,
+// the container runtime version with the features it is too old for, and the
+// memory and goroutines in use, which soak tests watch for leaks.
+func (s *Server) getServerStatus(_ context.Context, _ *dto.EmptyReq) (*v1.ServerStatusResp, error) {
+	drifts := jsonutil.Drifts()
+	resp := &v1.ServerStatusResp{Drift: make([]v1.SchemaDrift, 0, len(drifts))}
+	for _, d := range drifts {
+		resp.Drift = append(resp.Drift, v1.SchemaDrift{
+			Harness: d.Source,
+			Type:    d.Type,
+			Field:   d.Field,
+			Count:   d.Count,
+			LastAt:  float64(d.Last.UnixMilli()) / 1e3,
+		})
+	}
+	resp.Runtime.MD = container.MDVersion()
+	if c := s.runtimeCaps; c != nil {
+		resp.Runtime.Name = c.Runtime
+		resp.Runtime.Version = c.Version
+		for _, f := range c.Missing() {
+			resp.Runtime.Unsupported = append(resp.Runtime.Unsupported, c.Check(f).Error())
+		}
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	resp.Process = v1.ProcessStatus{HeapBytes: ms.HeapAlloc, SysBytes: ms.Sys, Goroutines: runtime.NumGoroutine()}
+	return resp, nil
+}
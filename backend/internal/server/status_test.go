@@ -0,0 +1,57 @@
+package server
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent/claude"
+	"github.com/caic-xyz/caic/backend/internal/container"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+func TestGetServerStatus(t *testing.T) {
+	s := newTestServer(t)
+	lines := []string{
+		`{"type":"system","subtype":"init","session_id":"s","drift_test_field":1}`,
+		`{"type":"drift_test_type"}`,
+		`{"type":"drift_test_type"}`,
+	}
+	for _, l := range lines {
+		if _, err := claude.ParseMessage([]byte(l)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := s.getServerStatus(t.Context(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	find := func(typ, field string) *v1.SchemaDrift {
+		for i := range resp.Drift {
+			if d := &resp.Drift[i]; d.Harness == "claude" && d.Type == typ && d.Field == field {
+				return d
+			}
+		}
+		return nil
+	}
+	if d := find("drift_test_type", ""); d == nil || d.Count != 2 || d.LastAt == 0 {
+		t.Errorf("unknown type: %+v", d)
+	}
+	if d := find("initWire", "drift_test_field"); d == nil || d.Count != 1 {
+		t.Errorf("unknown field: %+v in %+v", d, resp.Drift)
+	}
+	if resp.Runtime.Name != "" || resp.Runtime.MD == "" {
+		t.Errorf("undetected runtime: %+v", resp.Runtime)
+	}
+	if p := resp.Process; p.HeapBytes == 0 || p.SysBytes < p.HeapBytes || p.Goroutines == 0 {
+		t.Errorf("process: %+v", p)
+	}
+
+	s.runtimeCaps = &container.Capabilities{Runtime: "docker", Version: "20.10.24"}
+	if resp, err = s.getServerStatus(t.Context(), nil); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"docker >= 23.0 required for image builds, found 20.10.24; upgrade docker"}
+	if resp.Runtime.Name != "docker" || resp.Runtime.Version != "20.10.24" || !slices.Equal(resp.Runtime.Unsupported, want) {
+		t.Errorf("runtime: %+v", resp.Runtime)
+	}
+}
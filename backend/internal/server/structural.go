@@ -0,0 +1,71 @@
+// Structural summaries of task diffs for the diff endpoint and PR
+// descriptions.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// maxPRSummaryChanges bounds the changes listed per file in a PR
+// description.
+const maxPRSummaryChanges = 10
+
+// structuralDiff summarizes the changes of t's primary repo since base, or
+// since the commit the branch was created from when empty.
+func (s *Server) structuralDiff(ctx context.Context, t *task.Task, base string) ([]task.FileSummary, error) {
+	p := t.Primary()
+	if p == nil {
+		return nil, errors.New("task has no repository")
+	}
+	runner, ok := s.runners[p.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown repo %q", p.Name)
+	}
+	if base == "" {
+		base = p.BaseSHA
+	}
+	if base == "" {
+		base = "origin/" + s.effectiveBaseBranch(t)
+	}
+	return runner.StructuralDiff(ctx, p.Branch, t.Container, base, t.ExtraMDRepos())
+}
+
+func toV1FileSummaries(files []task.FileSummary) []v1.DiffFileSummary {
+	if len(files) == 0 {
+		return nil
+	}
+	out := make([]v1.DiffFileSummary, len(files))
+	for i, f := range files {
+		out[i] = v1.DiffFileSummary{Path: f.Path, Changes: make([]v1.DeclChange, len(f.Changes))}
+		for j, c := range f.Changes {
+			out[i].Changes[j] = v1.DeclChange{Op: v1.DeclOp(c.Op), Kind: string(c.Kind), Name: c.Name}
+		}
+	}
+	return out
+}
+
+// prSummary renders files as a markdown section of a PR description.
+func prSummary(files []task.FileSummary) string {
+	if len(files) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## Changes by declaration\n\n")
+	for _, f := range files {
+		parts := make([]string, 0, min(len(f.Changes), maxPRSummaryChanges)+1)
+		for _, c := range f.Changes[:min(len(f.Changes), maxPRSummaryChanges)] {
+			parts = append(parts, c.String())
+		}
+		if n := len(f.Changes) - maxPRSummaryChanges; n > 0 {
+			parts = append(parts, fmt.Sprintf("and %d more", n))
+		}
+		fmt.Fprintf(&b, "- `%s`: %s\n", f.Path, strings.Join(parts, ", "))
+	}
+	return b.String()
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/structdiff"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestPRSummary(t *testing.T) {
+	if got := prSummary(nil); got != "" {
+		t.Errorf("empty: got %q", got)
+	}
+	many := make([]structdiff.Change, maxPRSummaryChanges+2)
+	for i := range many {
+		many[i] = structdiff.Change{Op: structdiff.Added, Kind: structdiff.Function, Name: "F"}
+	}
+	got := prSummary([]task.FileSummary{
+		{Path: "a.go", Changes: []structdiff.Change{{Op: structdiff.Signature, Kind: structdiff.Method, Name: "T.Run"}, {Op: structdiff.Added, Kind: structdiff.Type, Name: "Z"}}},
+		{Path: "gen.go", Changes: many},
+	})
+	for _, want := range []string{
+		"## Changes by declaration\n\n",
+		"- `a.go`: modified signature of method T.Run, added type Z\n",
+		"added function F, and 2 more\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Detection of turns making no progress: the agent emitted no message for
+// too long, e.g. because the model or the network hangs.
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// Stuck turn detection defaults.
+const (
+	defaultStuckTimeout     = 10 * time.Minute
+	defaultStuckToolTimeout = time.Hour
+	// stuckCheckInterval controls how often monitorStuck looks for stuck
+	// turns.
+	stuckCheckInterval = 30 * time.Second
+	// maxStuckRetries bounds the consecutive restarts of a task's stuck
+	// turns; further stalls are only reported.
+	maxStuckRetries = 2
+)
+
+// stuckConfig is the parsed form of stuckSettings.
+type stuckConfig struct {
+	timeout     time.Duration // 0 disables detection.
+	toolTimeout time.Duration
+	retry       bool
+}
+
+// stalledSince returns since when the running turn of snap made no progress
+// and the timeout applying to it. The timeout is 0 when the task isn't
+// running.
+func (c *stuckConfig) stalledSince(snap *task.Snapshot) (since time.Time, timeout time.Duration) {
+	if snap.State != task.StateRunning || snap.TurnStartedAt.IsZero() {
+		return time.Time{}, 0
+	}
+	since = snap.TurnStartedAt
+	if snap.LastActivityAt.After(since) {
+		since = snap.LastActivityAt
+	}
+	timeout = c.timeout
+	if snap.ToolsRunning > 0 {
+		timeout = max(timeout, c.toolTimeout)
+	}
+	return since, timeout
+}
+
+// stuck reports whether the running turn of snap is stuck at now.
+func (c *stuckConfig) stuck(snap *task.Snapshot, now time.Time) bool {
+	if c.timeout <= 0 {
+		return false
+	}
+	since, timeout := c.stalledSince(snap)
+	return timeout > 0 && now.Sub(since) >= timeout
+}
+
+// monitorStuck reports stuck turns and, when configured, restarts them.
+func (s *Server) monitorStuck() {
+	if s.stuck.timeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(stuckCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+		s.checkStuck(s.ctx, time.Now())
+	}
+}
+
+// checkStuck emits a turn_stuck event once per stall of a running turn and
+// restarts the turn when retries are enabled.
+func (s *Server) checkStuck(ctx context.Context, now time.Time) {
+	type stall struct {
+		e      *taskEntry
+		detail string
+		retry  bool
+	}
+	var stalls []stall
+	s.mu.Lock()
+	for _, e := range s.tasks {
+		if e.result != nil {
+			continue
+		}
+		snap := e.task.Snapshot()
+		switch snap.State {
+		case task.StateRunning:
+		case task.StateWaiting, task.StateAsking, task.StateHasPlan:
+			// The turn completed.
+			e.stuckRetries = 0
+			continue
+		default:
+			continue
+		}
+		if !s.stuck.stuck(&snap, now) {
+			continue
+		}
+		since, timeout := s.stuck.stalledSince(&snap)
+		if since.Equal(e.stuckSince) {
+			continue
+		}
+		e.stuckSince = since
+		st := stall{e: e, detail: "no activity from the agent for " + timeout.String()}
+		if snap.ToolsRunning > 0 {
+			st.detail += " while a tool runs"
+		}
+		if s.stuck.retry && e.stuckRetries < maxStuckRetries {
+			e.stuckRetries++
+			st.retry = true
+			st.detail += "; restarting the agent"
+		}
+		stalls = append(stalls, st)
+	}
+	s.mu.Unlock()
+	for _, st := range stalls {
+		t := st.e.task
+		slog.Warn("turn stuck", "task", t.ID, "msg", st.detail)
+		t.Notify(ctx, "turn_stuck", st.detail)
+		if st.retry {
+			s.retryTurn(ctx, st.e)
+		}
+	}
+	if len(stalls) > 0 {
+		s.notifyTaskChange()
+	}
+}
+
+// retryTurn restarts the agent of entry and watches the new session.
+func (s *Server) retryTurn(ctx context.Context, entry *taskEntry) {
+	name := ""
+	if p := entry.task.Primary(); p != nil {
+		name = p.Name
+	}
+	runner := s.runners[name]
+	h, err := runner.RetryTurn(ctx, entry.task)
+	if h != nil {
+		s.watchSession(entry, runner, h)
+	}
+	if err != nil {
+		slog.Warn("retry stuck turn failed", "task", entry.task.ID, "err", err)
+		entry.task.Notify(ctx, "turn_stuck", "restarting the agent failed: "+err.Error())
+	}
+}
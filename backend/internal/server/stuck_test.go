@@ -0,0 +1,87 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestStuck(t *testing.T) {
+	t.Run("Config", func(t *testing.T) {
+		c, err := (&serverSettings{}).stuckConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != (stuckConfig{timeout: defaultStuckTimeout, toolTimeout: defaultStuckToolTimeout}) {
+			t.Errorf("defaults = %+v", c)
+		}
+		c, err = (&serverSettings{Stuck: stuckSettings{Timeout: "0", Retry: true}}).stuckConfig()
+		if err != nil || c.timeout != 0 || !c.retry {
+			t.Errorf("disabled = %+v, %v", c, err)
+		}
+		for _, st := range []stuckSettings{{Timeout: "soon"}, {ToolTimeout: "-1m"}} {
+			if _, err := (&serverSettings{Stuck: st}).stuckConfig(); err == nil {
+				t.Errorf("%+v: expected error", st)
+			}
+		}
+	})
+	t.Run("Detect", func(t *testing.T) {
+		c := stuckConfig{timeout: 10 * time.Minute, toolTimeout: time.Hour}
+		start := time.Now()
+		snap := task.Snapshot{State: task.StateRunning, TurnStartedAt: start, LastActivityAt: start.Add(5 * time.Minute)}
+		for _, tc := range []struct {
+			at    time.Duration
+			tools int
+			want  bool
+		}{
+			{14 * time.Minute, 0, false},
+			{15 * time.Minute, 0, true},
+			{15 * time.Minute, 1, false},
+			{65 * time.Minute, 1, true},
+		} {
+			snap.ToolsRunning = tc.tools
+			if got := c.stuck(&snap, start.Add(tc.at)); got != tc.want {
+				t.Errorf("%v with %d tools: %v, want %v", tc.at, tc.tools, got, tc.want)
+			}
+		}
+		snap.State = task.StateWaiting
+		if c.stuck(&snap, start.Add(time.Hour)) {
+			t.Error("waiting task is stuck")
+		}
+		snap.State = task.StateRunning
+		if (&stuckConfig{}).stuck(&snap, start.Add(24*time.Hour)) {
+			t.Error("disabled detection")
+		}
+	})
+	t.Run("ReportOnce", func(t *testing.T) {
+		s := newTestServer(t)
+		s.stuck = stuckConfig{timeout: 10 * time.Minute, toolTimeout: time.Hour}
+		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Harness: agent.Claude}
+		setState(t, tk, task.StateRunning)
+		s.tasks["a"] = &taskEntry{task: tk, done: make(chan struct{})}
+		count := func() int {
+			n := 0
+			for _, m := range tk.Messages() {
+				if sm, ok := m.(*agent.SystemMessage); ok && sm.Subtype == "turn_stuck" {
+					n++
+				}
+			}
+			return n
+		}
+		now := time.Now()
+		s.checkStuck(t.Context(), now.Add(5*time.Minute))
+		if n := count(); n != 0 {
+			t.Fatalf("%d events before the timeout", n)
+		}
+		s.checkStuck(t.Context(), now.Add(11*time.Minute))
+		s.checkStuck(t.Context(), now.Add(12*time.Minute))
+		if n := count(); n != 1 {
+			t.Errorf("%d events, want 1", n)
+		}
+		if j := s.toJSON(s.tasks["a"]); j.Stuck {
+			t.Error("stuck right after the turn started")
+		}
+	})
+}
@@ -0,0 +1,128 @@
+// Task transcript summaries: generated on demand and cached on disk.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"golang.org/x/sync/singleflight"
+)
+
+// summaryTimeout bounds generating one summary, map and reduce calls
+// included.
+const summaryTimeout = 10 * time.Minute
+
+// summaryStore persists one summary per task as <dir>/<task id>.json.
+type summaryStore struct {
+	dir   string
+	group singleflight.Group // Coalesces concurrent generations per task.
+}
+
+func (st *summaryStore) path(id string) string {
+	return filepath.Join(st.dir, id+".json")
+}
+
+// load returns the stored summary for id, or nil if there is none.
+func (st *summaryStore) load(id string) (*task.Summary, error) {
+	data, err := readSealed(st.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var sm task.Summary
+	if err := json.Unmarshal(data, &sm); err != nil {
+		return nil, err
+	}
+	return &sm, nil
+}
+
+// save atomically writes sm for id.
+func (st *summaryStore) save(id string, sm *task.Summary) error {
+	data, err := json.Marshal(sm)
+	if err != nil {
+		return err
+	}
+	return writeSealedAtomic(st.path(id), data)
+}
+
+// handleGetTaskSummary returns the stored summary of a task without
+// generating one.
+func (s *Server) handleGetTaskSummary(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	sm, err := s.summaries.load(entry.task.ID.String())
+	if err != nil {
+		writeError(w, dto.InternalError(err.Error()))
+		return
+	}
+	if sm == nil {
+		writeError(w, dto.NotFound("summary"))
+		return
+	}
+	resp := toV1TaskSummary(sm, len(entry.task.Messages()))
+	writeJSONResponse(w, resp, nil)
+}
+
+// summarizeTask generates a summary of the task's conversation with the LLM
+// provider, unless the stored one already covers every message.
+func (s *Server) summarizeTask(_ context.Context, entry *taskEntry, req *v1.TaskSummaryReq) (*v1.TaskSummaryResp, error) {
+	if s.provider == nil {
+		return nil, dto.BadRequest("no LLM provider configured").WithDetail("setting", "CAIC_LLM_PROVIDER")
+	}
+	id := entry.task.ID.String()
+	msgs := entry.task.Messages()
+	if !req.Force {
+		sm, err := s.summaries.load(id)
+		if err != nil {
+			slog.Warn("summary", "task", id, "err", err)
+		} else if sm != nil && sm.Messages >= len(msgs) {
+			return toV1TaskSummary(sm, len(msgs)), nil
+		}
+	}
+	v, err, _ := s.summaries.group.Do(id, func() (any, error) {
+		// Use the server-lifetime context so a client disconnect does not
+		// waste the calls already made.
+		ctx, cancel := context.WithTimeout(s.ctx, summaryTimeout)
+		defer cancel()
+		start := time.Now()
+		sm, err := task.Summarize(ctx, s.provider, msgs) //nolint:contextcheck // intentionally using server context
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("summary", "task", id, "msgs", sm.Messages, "chunks", sm.Chunks, "d", time.Since(start).Round(time.Millisecond))
+		if err := s.summaries.save(id, sm); err != nil {
+			slog.Warn("summary", "task", id, "err", err)
+		}
+		return sm, nil
+	})
+	if err != nil {
+		return nil, dto.InternalError("summarization failed: " + err.Error())
+	}
+	return toV1TaskSummary(v.(*task.Summary), len(entry.task.Messages())), nil
+}
+
+// toV1TaskSummary converts sm; numMsgs is the task's current message count,
+// used to flag summaries that predate newer messages.
+func toV1TaskSummary(sm *task.Summary, numMsgs int) *v1.TaskSummaryResp {
+	return &v1.TaskSummaryResp{
+		Text:      sm.Text,
+		Messages:  sm.Messages,
+		Chunks:    sm.Chunks,
+		CreatedAt: float64(sm.CreatedAt.UnixMilli()) / 1e3,
+		Stale:     sm.Messages < numMsgs,
+	}
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/logcrypt"
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"github.com/maruel/genai"
+)
+
+// countingProvider answers every GenSync with a fixed text.
+type countingProvider struct {
+	genai.Provider
+	calls int
+}
+
+func (p *countingProvider) GenSync(context.Context, genai.Messages, ...genai.GenOption) (genai.Result, error) {
+	p.calls++
+	return genai.Result{Message: genai.Message{Replies: []genai.Reply{{Text: "It fixed the bug."}}}}, nil
+}
+
+func TestTaskSummary(t *testing.T) {
+	s := newTestServer(t)
+	s.summaries = &summaryStore{dir: t.TempDir()}
+	tk := &task.Task{InitialPrompt: agent.Prompt{Text: "fix"}}
+	tk.RestoreMessages([]agent.Message{&agent.UserInputMessage{Text: "fix"}, &agent.TextMessage{Text: "done"}})
+	s.tasks["t1"] = &taskEntry{task: tk, done: make(chan struct{})}
+	get := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t1/summary", http.NoBody)
+		req.SetPathValue("id", "t1")
+		w := httptest.NewRecorder()
+		s.handleGetTaskSummary(w, req)
+		return w
+	}
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks/t1/summary", strings.NewReader(body))
+		req.SetPathValue("id", "t1")
+		w := httptest.NewRecorder()
+		handleWithTask(s, s.summarizeTask)(w, req)
+		return w
+	}
+	decode := func(w *httptest.ResponseRecorder) v1.TaskSummaryResp {
+		t.Helper()
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp v1.TaskSummaryResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if w := get(); w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if w := post("{}"); w.Code != http.StatusBadRequest {
+		t.Fatalf("no provider: status = %d, want %d", w.Code, http.StatusBadRequest)
+	} else if e := decodeError(t, w); e.Code != dto.CodeBadRequest {
+		t.Errorf("code = %q", e.Code)
+	}
+	p := &countingProvider{}
+	s.provider = p
+	if resp := decode(post("{}")); resp.Text != "It fixed the bug." || resp.Messages != 2 || resp.Stale {
+		t.Errorf("resp = %+v", resp)
+	}
+	// Re-served from storage without recomputation.
+	decode(post("{}"))
+	if resp := decode(get()); resp.Text != "It fixed the bug." || p.calls != 1 {
+		t.Errorf("resp = %+v, calls = %d", resp, p.calls)
+	}
+	// New messages make the stored summary stale.
+	tk.RestoreMessages([]agent.Message{&agent.UserInputMessage{Text: "fix"}, &agent.TextMessage{Text: "done"}, &agent.UserInputMessage{Text: "more"}})
+	if resp := decode(get()); !resp.Stale {
+		t.Errorf("resp = %+v, want stale", resp)
+	}
+	decode(post(`{"force":true}`))
+	if p.calls != 2 {
+		t.Errorf("calls = %d, want 2", p.calls)
+	}
+}
+
+func TestSummaryStoreEncrypted(t *testing.T) {
+	logcrypt.SetKey(&logcrypt.Key{1})
+	t.Cleanup(func() { logcrypt.SetKey(nil) })
+	st := &summaryStore{dir: t.TempDir()}
+	if err := st.save("t1", &task.Summary{Text: "It fixed the bug."}); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(st.path("t1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "fixed") {
+		t.Errorf("summary stored in plaintext: %s", raw)
+	}
+	sm, err := st.load("t1")
+	if err != nil || sm.Text != "It fixed the bug." {
+		t.Errorf("load = %+v, %v", sm, err)
+	}
+}
@@ -0,0 +1,93 @@
+// Built-in TLS termination with a static certificate or automatic ACME.
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// tlsSettings configures TLS termination. The zero value serves plain HTTP.
+type tlsSettings struct {
+	certFile string // PEM certificate chain; used with keyFile
+	keyFile  string
+	// acmeHosts are the hostnames certificates are requested for from Let's
+	// Encrypt. Mutually exclusive with certFile.
+	acmeHosts []string
+	acmeEmail string
+	acmeCache string // directory holding issued certificates and the account key
+	// redirectAddr is the plain HTTP listen address redirecting to https. It
+	// also answers ACME HTTP-01 challenges. Empty disables it.
+	redirectAddr string
+}
+
+// newTLSSettings returns the TLS settings of cfg.
+func newTLSSettings(cfg *Config) tlsSettings {
+	t := tlsSettings{
+		certFile:     cfg.TLSCertFile,
+		keyFile:      cfg.TLSKeyFile,
+		acmeEmail:    cfg.ACMEEmail,
+		redirectAddr: cfg.HTTPRedirectAddr,
+	}
+	for h := range strings.SplitSeq(cfg.ACMEHosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			t.acmeHosts = append(t.acmeHosts, h)
+		}
+	}
+	if len(t.acmeHosts) != 0 && cfg.ConfigDir != "" {
+		t.acmeCache = filepath.Join(cfg.ConfigDir, "acme")
+	}
+	return t
+}
+
+func (t *tlsSettings) enabled() bool {
+	return t.certFile != "" || len(t.acmeHosts) != 0
+}
+
+// config returns the tls.Config to serve with and the handler of the
+// redirect listener. The tls.Config leaves NextProtos to net/http so HTTP/2
+// is negotiated, except for ACME where autocert adds its own protocol.
+func (t *tlsSettings) config() (*tls.Config, http.Handler, error) {
+	if t.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.certFile, t.keyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg := &tls.Config{MinVersion: tls.VersionTLS12, Certificates: []tls.Certificate{cert}}
+		return cfg, http.HandlerFunc(redirectHTTPS), nil
+	}
+	if t.acmeCache == "" {
+		return nil, nil, errors.New("ACME requires a config directory to store certificates")
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(t.acmeHosts...),
+		Cache:      autocert.DirCache(t.acmeCache),
+		Email:      t.acmeEmail,
+	}
+	cfg := m.TLSConfig()
+	cfg.MinVersion = tls.VersionTLS12
+	return cfg, m.HTTPHandler(nil), nil
+}
+
+// redirectHTTPS redirects a plain HTTP request to its https equivalent on the
+// default port, like autocert's handler does.
+func redirectHTTPS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "use https", http.StatusBadRequest)
+		return
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+		if strings.Contains(h, ":") {
+			host = "[" + h + "]"
+		}
+	}
+	http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusFound)
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeSelfSigned writes a self-signed certificate for localhost and its key
+// to dir and returns their paths.
+func writeSelfSigned(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestTLSSettings(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		ts := newTLSSettings(&Config{})
+		if ts.enabled() {
+			t.Error("enabled() = true, want false")
+		}
+	})
+	t.Run("ACMEHosts", func(t *testing.T) {
+		ts := newTLSSettings(&Config{ConfigDir: "/cfg", ACMEHosts: " a.example.com, ,b.example.com"})
+		if !ts.enabled() {
+			t.Fatal("enabled() = false, want true")
+		}
+		if want := []string{"a.example.com", "b.example.com"}; !slices.Equal(ts.acmeHosts, want) {
+			t.Errorf("acmeHosts = %q, want %q", ts.acmeHosts, want)
+		}
+		if ts.acmeCache != filepath.Join("/cfg", "acme") {
+			t.Errorf("acmeCache = %q", ts.acmeCache)
+		}
+		cfg, h, err := ts.config()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.GetCertificate == nil || h == nil {
+			t.Error("want a certificate getter and a challenge handler")
+		}
+	})
+	t.Run("StaticCertHTTP2", func(t *testing.T) {
+		certFile, keyFile := writeSelfSigned(t, t.TempDir())
+		ts := newTLSSettings(&Config{TLSCertFile: certFile, TLSKeyFile: keyFile})
+		cfg, _, err := ts.config()
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(r.Proto))
+		}))
+		srv.TLS = cfg
+		srv.EnableHTTP2 = true
+		srv.StartTLS()
+		defer srv.Close()
+		client := srv.Client()
+		client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true //nolint:gosec // self-signed test certificate
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.ProtoMajor != 2 {
+			t.Errorf("proto = %s, want HTTP/2", resp.Proto)
+		}
+		if resp.TLS == nil || resp.TLS.Version < tls.VersionTLS12 {
+			t.Errorf("TLS = %+v", resp.TLS)
+		}
+	})
+	t.Run("MissingCert", func(t *testing.T) {
+		dir := t.TempDir()
+		ts := newTLSSettings(&Config{TLSCertFile: filepath.Join(dir, "no.pem"), TLSKeyFile: filepath.Join(dir, "no.key")})
+		if _, _, err := ts.config(); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	for _, tc := range []struct{ host, target, want string }{
+		{"caic.example.com", "/tasks?x=1", "https://caic.example.com/tasks?x=1"},
+		{"caic.example.com:80", "/", "https://caic.example.com/"},
+		{"[::1]:80", "/a", "https://[::1]/a"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.target, http.NoBody)
+		req.Host = tc.host
+		w := httptest.NewRecorder()
+		redirectHTTPS(w, req)
+		if w.Code != http.StatusFound {
+			t.Errorf("%s: status = %d, want 302", tc.host, w.Code)
+		}
+		if got := w.Header().Get("Location"); got != tc.want {
+			t.Errorf("%s: Location = %q, want %q", tc.host, got, tc.want)
+		}
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/tasks", http.NoBody)
+	w := httptest.NewRecorder()
+	redirectHTTPS(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("POST: status = %d, want 400", w.Code)
+	}
+}
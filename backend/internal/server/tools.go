@@ -0,0 +1,78 @@
+// Per-tool call statistics of a task and of the tasks of a repository.
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// handleGetTaskTools returns the call counts, durations and failure rates of
+// the tools a task used.
+func (s *Server) handleGetTaskTools(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	_, _, d, _, _ := entry.task.LiveStats()
+	resp := v1.TaskToolsResp{Duration: d.Seconds(), Tools: toV1ToolStats(entry.task.ToolStats(), d)}
+	writeJSONResponse(w, &resp, nil)
+}
+
+// handleGetRepoTools rolls up the tool statistics of the tasks of a
+// repository known to the server, limited to the user's own when auth is on.
+func (s *Server) handleGetRepoTools(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		writeError(w, dto.BadRequest("repo is required"))
+		return
+	}
+	if _, ok := s.repoAbsPath(repo); !ok {
+		writeError(w, dto.NotFound("repo"))
+		return
+	}
+	ownerID := s.draftOwner(r.Context())
+	var tasks []*task.Task
+	s.mu.Lock()
+	for _, e := range s.tasks {
+		if ownerID != "" && e.task.OwnerID != "" && e.task.OwnerID != ownerID {
+			continue
+		}
+		if p := e.task.Primary(); p != nil && p.Name == repo {
+			tasks = append(tasks, e.task)
+		}
+	}
+	s.mu.Unlock()
+	stats := make([][]task.ToolStat, len(tasks))
+	var d time.Duration
+	for i, t := range tasks {
+		stats[i] = t.ToolStats()
+		_, _, td, _, _ := t.LiveStats()
+		d += td
+	}
+	resp := v1.RepoToolsResp{Repo: repo, Tasks: len(tasks), Duration: d.Seconds(), Tools: toV1ToolStats(task.MergeToolStats(stats...), d)}
+	writeJSONResponse(w, &resp, nil)
+}
+
+// toV1ToolStats converts stats; session is the agent's session time the
+// shares are relative to.
+func toV1ToolStats(stats []task.ToolStat, session time.Duration) []v1.ToolStats {
+	out := make([]v1.ToolStats, len(stats))
+	for i, st := range stats {
+		out[i] = v1.ToolStats{
+			Name:        st.Name,
+			Calls:       st.Calls,
+			Failures:    st.Failures,
+			FailureRate: float64(st.Failures) / float64(st.Calls),
+			Duration:    st.Duration.Seconds(),
+		}
+		if session > 0 {
+			out[i].Share = float64(st.Duration) / float64(session)
+		}
+	}
+	return out
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/auth"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestHandleGetToolStats(t *testing.T) {
+	s := newTestServer(t)
+	s.repos = []repoInfo{{RelPath: "caic"}}
+	for id, ms := range map[string]int64{"t1": 3000, "t2": 1000} {
+		tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Repos: []task.RepoMount{{Name: "caic"}}}
+		tk.RestoreMessages([]agent.Message{
+			&agent.ToolUseMessage{ToolUseID: "b", Name: "Bash"},
+			&agent.ToolResultMessage{ToolUseID: "b", DurationMs: ms, Error: "Exit code 1"},
+			&agent.ToolUseMessage{ToolUseID: "r", Name: "Read"},
+			&agent.ToolResultMessage{ToolUseID: "r", DurationMs: 1000},
+			&agent.ResultMessage{MessageType: "result", DurationMs: 10000},
+		})
+		s.tasks[id] = &taskEntry{task: tk, done: make(chan struct{})}
+	}
+
+	t.Run("Task", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/tasks/t1/tools", http.NoBody)
+		req.SetPathValue("id", "t1")
+		w := httptest.NewRecorder()
+		s.handleGetTaskTools(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp v1.TaskToolsResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		want := v1.ToolStats{Name: "Bash", Calls: 1, Failures: 1, FailureRate: 1, Duration: 3, Share: 0.3}
+		if resp.Duration != 10 || len(resp.Tools) != 2 || resp.Tools[0] != want {
+			t.Errorf("resp = %+v", resp)
+		}
+	})
+	t.Run("Repo", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/server/repos/tools?repo=caic", http.NoBody)
+		w := httptest.NewRecorder()
+		s.handleGetRepoTools(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp v1.RepoToolsResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Tasks != 2 || resp.Duration != 20 || len(resp.Tools) != 2 || resp.Tools[0].Calls != 2 || resp.Tools[0].Duration != 4 || resp.Tools[1].Share != 0.1 {
+			t.Errorf("resp = %+v", resp)
+		}
+	})
+	t.Run("UnknownRepo", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/server/repos/tools?repo=other", http.NoBody)
+		w := httptest.NewRecorder()
+		s.handleGetRepoTools(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("status = %d", w.Code)
+		}
+	})
+	t.Run("Owner", func(t *testing.T) {
+		store, err := auth.Open(filepath.Join(t.TempDir(), "users.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.authStore = store
+		s.tasks["t1"].task.OwnerID = "a"
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/server/repos/tools?repo=caic", http.NoBody)
+		req = req.WithContext(auth.NewContext(req.Context(), &auth.User{ID: "b"}))
+		w := httptest.NewRecorder()
+		s.handleGetRepoTools(w, req)
+		var resp v1.RepoToolsResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Tasks != 1 || resp.Duration != 10 {
+			t.Errorf("resp = %+v", resp)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+// OpenTelemetry spans for HTTP requests.
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/task"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer is a no-op until the process installs a global TracerProvider.
+var tracer = otel.Tracer("github.com/caic-xyz/caic/backend/internal/server")
+
+// startHTTPSpan starts a server span for r, continuing any trace propagated
+// by the client, and returns r with the span in its context. The span is
+// named after the method only until routeSpan knows the route: paths hold
+// task IDs and share tokens.
+func startHTTPSpan(r *http.Request) (*http.Request, trace.Span) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, r.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("http.request.method", r.Method),
+			attribute.String("url.path", r.URL.Path),
+		))
+	return r.WithContext(ctx), span
+}
+
+// endHTTPSpan records the response status on span and ends it.
+func endHTTPSpan(span trace.Span, status int) {
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if status >= 500 {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+	span.End()
+}
+
+// routeSpan names the request span after the route pattern matched by mux,
+// e.g. "GET /api/v1/tasks/{id}". Nested muxes each rename it; the innermost
+// match wins.
+func routeSpan(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			route := pattern
+			if _, p, ok := strings.Cut(pattern, " "); ok {
+				route = p
+			}
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// tagSpanTask attaches the task ID to the request span, if any.
+func tagSpanTask(r *http.Request, id string) {
+	trace.SpanFromContext(r.Context()).SetAttributes(task.TaskIDKey.String(id))
+}
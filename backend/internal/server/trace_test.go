@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHTTPSpan(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	old := tracer
+	tracer = tp.Tracer("test")
+	t.Cleanup(func() { tracer = old })
+
+	ended := -1
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/shared/{token}", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /api/v1/shared/{token}/events", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		ended = len(rec.Ended())
+	})
+	h := routeSpan(mux)
+	serve := func(path string) {
+		r, span := startHTTPSpan(httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		rw := &responseWriter{ResponseWriter: httptest.NewRecorder(), status: http.StatusOK, span: span}
+		h.ServeHTTP(rw, r)
+		rw.endSpan()
+	}
+
+	serve("/api/v1/shared/secret")
+	serve("/api/v1/shared/secret/events")
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2", len(spans))
+	}
+	for i, want := range []string{"GET /api/v1/shared/{token}", "GET /api/v1/shared/{token}/events"} {
+		if got := spans[i].Name(); got != want {
+			t.Errorf("span %d name = %q, want %q", i, got, want)
+		}
+	}
+	if ended != 2 {
+		t.Errorf("SSE span still open once the stream started")
+	}
+}
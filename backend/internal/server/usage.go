@@ -16,6 +16,7 @@ import (
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
 	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/task"
 	"github.com/fsnotify/fsnotify"
 )
 
@@ -249,6 +250,63 @@ type claudeCreds struct {
 	} `json:"claudeAiOauth"`
 }
 
+// handleGetTaskUsage returns the per-turn token usage and cost series of a
+// task.
+func (s *Server) handleGetTaskUsage(w http.ResponseWriter, r *http.Request) {
+	entry, err := s.getTask(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(toV1TaskUsage(entry.task.TurnUsages()))
+}
+
+// toV1TaskUsage converts the per-turn series.
+func toV1TaskUsage(turns []task.TurnUsage) *v1.TaskUsageResp {
+	out := &v1.TaskUsageResp{Turns: make([]v1.TurnUsage, len(turns))}
+	for i, tu := range turns {
+		var ts float64
+		if !tu.EndedAt.IsZero() {
+			ts = float64(tu.EndedAt.UnixMilli()) / 1e3
+		}
+		out.Turns[i] = v1.TurnUsage{
+			Ts:                       ts,
+			Estimated:                tu.Estimated,
+			Model:                    tu.Model,
+			InputTokens:              tu.Usage.InputTokens,
+			OutputTokens:             tu.Usage.OutputTokens,
+			CacheCreationInputTokens: tu.Usage.CacheCreationInputTokens,
+			CacheReadInputTokens:     tu.Usage.CacheReadInputTokens,
+			CostUSD:                  tu.CostUSD,
+			Duration:                 tu.Duration.Seconds(),
+			FirstToken:               tu.Latency.FirstToken.Seconds(),
+			Completion:               tu.Latency.Completion.Seconds(),
+			ToolTime:                 tu.Latency.Tools.Seconds(),
+			ModelTime:                tu.Latency.Model.Seconds(),
+		}
+		out.CostUSD += tu.CostUSD
+	}
+	return out
+}
+
+// mergeRemoteUsage fills resp with the OAuth quota utilization and the
+// organization cost, when available.
+func (s *Server) mergeRemoteUsage(resp *v1.UsageResp) {
+	if s.usage != nil {
+		if oauth := s.usage.get(); oauth != nil {
+			resp.FiveHour.Utilization = oauth.FiveHour.Utilization
+			resp.FiveHour.ResetsAt = oauth.FiveHour.ResetsAt
+			resp.SevenDay.Utilization = oauth.SevenDay.Utilization
+			resp.SevenDay.ResetsAt = oauth.SevenDay.ResetsAt
+			resp.ExtraUsage = oauth.ExtraUsage
+		}
+	}
+	if s.orgUsage != nil {
+		resp.Org = s.orgUsage.get()
+	}
+}
+
 // computeUsage aggregates task cost and token usage within rolling 5-hour and
 // 7-day windows. Tasks are attributed to the window that contains their
 // StartedAt time. For running tasks without a final result, the current live
@@ -0,0 +1,142 @@
+// Periodic usage sampling and the usage history API.
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/server/dto"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/usagehistory"
+)
+
+// usageSampleInterval controls how often recordUsage takes a sample. It
+// matches the OAuth cache TTL so each sample sees fresh utilization.
+const usageSampleInterval = usageCacheTTL
+
+// recordUsage periodically appends a usage sample to s.usageHistory until the
+// server context is cancelled.
+func (s *Server) recordUsage() {
+	ticker := time.NewTicker(usageSampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.ctx.Done():
+			return
+		}
+		sm := s.sampleUsage(time.Now())
+		if err := s.usageHistory.Add(sm); err != nil {
+			slog.Warn("usage history", "err", err)
+		}
+	}
+}
+
+// sampleUsage builds a sample at now. Task spend is attributed from the
+// per-turn series for turns that ended since the previous sample.
+func (s *Server) sampleUsage(now time.Time) *usagehistory.Sample {
+	prev := now.Add(-usageSampleInterval)
+	if last, ok := s.usageHistory.Last(); ok && last.Time.After(prev) {
+		prev = last.Time
+	}
+	s.mu.Lock()
+	resp := computeUsage(s.tasks, now)
+	var tasks []usagehistory.TaskSpend
+	for id, e := range s.tasks {
+		cost := 0.
+		for _, tu := range e.task.TurnUsages() {
+			// Estimated turns were loaded from logs after a restart; they
+			// were sampled live before it.
+			if !tu.Estimated && tu.EndedAt.After(prev) && !tu.EndedAt.After(now) {
+				cost += tu.CostUSD
+			}
+		}
+		if cost > 0 {
+			tasks = append(tasks, usagehistory.TaskSpend{ID: id, CostUSD: cost})
+		}
+	}
+	s.mu.Unlock()
+	s.mergeRemoteUsage(&resp)
+	slices.SortFunc(tasks, func(a, b usagehistory.TaskSpend) int { return strings.Compare(a.ID, b.ID) })
+	sm := &usagehistory.Sample{
+		Time:                now.UTC(),
+		FiveHourUtilization: resp.FiveHour.Utilization,
+		SevenDayUtilization: resp.SevenDay.Utilization,
+		FiveHourCostUSD:     resp.FiveHour.CostUSD,
+		SevenDayCostUSD:     resp.SevenDay.CostUSD,
+		Tasks:               tasks,
+	}
+	if resp.Org != nil {
+		sm.OrgSevenDayUSD = resp.Org.SevenDayUSD
+	}
+	return sm
+}
+
+// parseHistoryWindow parses a window such as "7d", "12h" or "90m". Empty
+// defaults to 7 days.
+func parseHistoryWindow(v string) (time.Duration, error) {
+	if v == "" {
+		return 7 * 24 * time.Hour, nil
+	}
+	var d time.Duration
+	if n, ok := strings.CutSuffix(v, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, err
+		}
+		d = time.Duration(days) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(v); err != nil {
+			return 0, err
+		}
+	}
+	if d <= 0 || d > usagehistory.MaxAge {
+		return 0, strconv.ErrRange
+	}
+	return d, nil
+}
+
+// handleGetUsageHistory returns the usage samples within the requested
+// window.
+func (s *Server) handleGetUsageHistory(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	d, err := parseHistoryWindow(window)
+	if err != nil {
+		writeError(w, dto.BadRequest("invalid window; use e.g. 24h, 7d or 30d").WithDetail("window", window))
+		return
+	}
+	samples := s.usageHistory.Since(time.Now().Add(-d))
+	titles := make(map[string]string)
+	s.mu.Lock()
+	for i := range samples {
+		for _, ts := range samples[i].Tasks {
+			if e, ok := s.tasks[ts.ID]; ok {
+				titles[ts.ID] = e.task.Title()
+			}
+		}
+	}
+	s.mu.Unlock()
+	resp := v1.UsageHistoryResp{Samples: make([]v1.UsageSample, len(samples))}
+	for i, sm := range samples {
+		out := v1.UsageSample{
+			Ts:                  float64(sm.Time.UnixMilli()) / 1e3,
+			FiveHourUtilization: sm.FiveHourUtilization,
+			SevenDayUtilization: sm.SevenDayUtilization,
+			FiveHourCostUSD:     sm.FiveHourCostUSD,
+			SevenDayCostUSD:     sm.SevenDayCostUSD,
+			OrgSevenDayUSD:      sm.OrgSevenDayUSD,
+		}
+		for _, ts := range sm.Tasks {
+			out.Tasks = append(out.Tasks, v1.UsageSampleTask{ID: ts.ID, Title: titles[ts.ID], CostUSD: ts.CostUSD})
+		}
+		resp.Samples[i] = out
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(&resp)
+}
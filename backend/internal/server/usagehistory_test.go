@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/caic-xyz/caic/backend/internal/usagehistory"
+)
+
+func TestUsageHistory(t *testing.T) {
+	t.Run("ParseWindow", func(t *testing.T) {
+		for in, want := range map[string]time.Duration{"": 7 * 24 * time.Hour, "1d": 24 * time.Hour, "30d": 30 * 24 * time.Hour, "90m": 90 * time.Minute} {
+			if got, err := parseHistoryWindow(in); err != nil || got != want {
+				t.Errorf("parseHistoryWindow(%q) = %v, %v; want %v", in, got, err, want)
+			}
+		}
+		for _, in := range []string{"xd", "31d", "-1h", "0s", "soon"} {
+			if _, err := parseHistoryWindow(in); err == nil {
+				t.Errorf("parseHistoryWindow(%q) succeeded", in)
+			}
+		}
+	})
+	t.Run("SampleAndServe", func(t *testing.T) {
+		s := newTestServer(t)
+		s.usageHistory, _ = usagehistory.Open("")
+		e := spendTask(t, agent.Claude, 2, time.Minute)
+		e.task.SetTitle("fix the bug")
+		s.tasks["t1"] = e
+		sm := s.sampleUsage(time.Now())
+		if len(sm.Tasks) != 0 {
+			t.Errorf("turns restored from logs must not be attributed: %+v", sm.Tasks)
+		}
+		if sm.SevenDayCostUSD != 2 {
+			t.Errorf("SevenDayCostUSD = %v, want 2", sm.SevenDayCostUSD)
+		}
+		sm.Tasks = []usagehistory.TaskSpend{{ID: "t1", CostUSD: 2}}
+		if err := s.usageHistory.Add(sm); err != nil {
+			t.Fatal(err)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/usage/history?window=1d", http.NoBody)
+		w := httptest.NewRecorder()
+		s.handleGetUsageHistory(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+		var resp v1.UsageHistoryResp
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Samples) != 1 || len(resp.Samples[0].Tasks) != 1 || resp.Samples[0].Tasks[0].Title != "fix the bug" {
+			t.Fatalf("samples = %+v", resp.Samples)
+		}
+
+		req = httptest.NewRequest(http.MethodGet, "/api/v1/usage/history?window=1y", http.NoBody)
+		w = httptest.NewRecorder()
+		s.handleGetUsageHistory(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", w.Code)
+		}
+	})
+}
@@ -22,30 +22,39 @@ const (
 )
 
 func (s *Server) webFetch(ctx context.Context, req *v1.WebFetchReq) (*v1.WebFetchResp, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, http.NoBody)
+	title, content, err := fetchPage(ctx, req.URL)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, err
+	}
+	return &v1.WebFetchResp{Title: title, Content: content}, nil
+}
+
+// fetchPage fetches the page at rawURL and returns its title and text.
+func fetchPage(ctx context.Context, rawURL string) (title, content string, err error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, http.NoBody)
+	if err != nil {
+		return "", "", fmt.Errorf("creating request: %w", err)
 	}
 	httpReq.Header.Set("User-Agent", "Mozilla/5.0 (compatible; caic/1.0)")
 
 	client := &http.Client{Timeout: webFetchTimeout}
 	resp, err := client.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("fetching URL: %w", err)
+		return "", "", fmt.Errorf("fetching URL: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, req.URL)
+		return "", "", fmt.Errorf("HTTP %d from %s", resp.StatusCode, rawURL)
 	}
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, webFetchMaxBody))
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
+		return "", "", fmt.Errorf("reading response body: %w", err)
 	}
 
-	title, content := extractHTML(body)
-	return &v1.WebFetchResp{Title: title, Content: content}, nil
+	title, content = extractHTML(body)
+	return title, content, nil
 }
 
 // skipTags are elements whose text content should be discarded.
@@ -0,0 +1,117 @@
+// Working hours of the repositories: outside of them, tasks proceed without
+// a human.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+// defaultWorkHoursGrace is how long a question or plan waits for a human
+// outside of working hours when not configured.
+const defaultWorkHoursGrace = 5 * time.Minute
+
+// unattendedHours is used by tasks in AutomationUnattended mode in a
+// repository without working hours.
+var unattendedHours = &workHours{grace: defaultWorkHoursGrace}
+
+// workHours is the parsed form of workHoursSettings.
+type workHours struct {
+	days       [7]bool       // Indexed by time.Weekday.
+	start, end time.Duration // Since midnight.
+	loc        *time.Location
+	grace      time.Duration
+	answer     string
+}
+
+// weekdays maps the day names of workHoursSettings.Days.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseWorkHours(in *workHoursSettings) (*workHours, error) {
+	w := &workHours{loc: time.Local, grace: defaultWorkHoursGrace, answer: in.Answer}
+	days := in.Days
+	if len(days) == 0 {
+		days = []string{"mon", "tue", "wed", "thu", "fri"}
+	}
+	for _, d := range days {
+		wd, ok := weekdays[d]
+		if !ok {
+			return nil, fmt.Errorf("unknown day %q", d)
+		}
+		w.days[wd] = true
+	}
+	var err error
+	if w.start, err = parseClock(in.Start); err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	if w.end, err = parseClock(in.End); err != nil {
+		return nil, fmt.Errorf("end: %w", err)
+	}
+	if w.start == w.end {
+		return nil, errors.New("start and end must differ")
+	}
+	if in.TimeZone != "" {
+		if w.loc, err = time.LoadLocation(in.TimeZone); err != nil {
+			return nil, fmt.Errorf("timeZone: %w", err)
+		}
+	}
+	if in.Grace != "" {
+		if w.grace, err = time.ParseDuration(in.Grace); err != nil {
+			return nil, fmt.Errorf("grace: %w", err)
+		}
+		if w.grace < 0 {
+			return nil, errors.New("grace must not be negative")
+		}
+	}
+	return w, nil
+}
+
+// parseClock parses a "15:04" time of day.
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// contains reports whether now is within the working hours. Hours spanning
+// midnight belong to the day they start.
+func (w *workHours) contains(now time.Time) bool {
+	now = now.In(w.loc)
+	day := now.Weekday()
+	d := time.Duration(now.Hour())*time.Hour + time.Duration(now.Minute())*time.Minute + time.Duration(now.Second())*time.Second
+	if w.start < w.end {
+		return w.days[day] && d >= w.start && d < w.end
+	}
+	if d >= w.start {
+		return w.days[day]
+	}
+	return d < w.end && w.days[(day+6)%7]
+}
+
+// offHours returns the working hours e is outside of at now, nil when a
+// human is expected to answer.
+func (s *Server) offHours(e *taskEntry, now time.Time) *workHours {
+	switch e.task.Automation {
+	case task.AutomationInteractive:
+		return nil
+	case task.AutomationUnattended:
+		return unattendedHours
+	}
+	p := e.task.Primary()
+	if p == nil {
+		return nil
+	}
+	w := s.repoWorkHours[p.Name]
+	if w == nil || w.contains(now) {
+		return nil
+	}
+	return w
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/task"
+)
+
+func TestWorkHours(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		w, err := parseWorkHours(&workHoursSettings{Start: "09:00", End: "17:30", TimeZone: "UTC", Grace: "10m"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.start != 9*time.Hour || w.end != 17*time.Hour+30*time.Minute || w.grace != 10*time.Minute || w.loc != time.UTC {
+			t.Errorf("got %+v", w)
+		}
+		if w.days[time.Saturday] || !w.days[time.Monday] || !w.days[time.Friday] {
+			t.Errorf("days = %v", w.days)
+		}
+		for _, in := range []workHoursSettings{
+			{Start: "9am", End: "17:00"},
+			{Start: "09:00", End: "09:00"},
+			{Start: "09:00", End: "17:00", Days: []string{"monday"}},
+			{Start: "09:00", End: "17:00", TimeZone: "Nowhere/Land"},
+			{Start: "09:00", End: "17:00", Grace: "-1m"},
+		} {
+			if _, err := parseWorkHours(&in); err == nil {
+				t.Errorf("%+v: expected error", in)
+			}
+		}
+	})
+	t.Run("Contains", func(t *testing.T) {
+		// 2026-10-12 is a Monday.
+		at := func(day, hour, minute int) time.Time { return time.Date(2026, 10, day, hour, minute, 0, 0, time.UTC) }
+		day := &workHours{days: [7]bool{time.Monday: true}, start: 9 * time.Hour, end: 17 * time.Hour, loc: time.UTC}
+		night := &workHours{days: [7]bool{time.Monday: true}, start: 22 * time.Hour, end: 6 * time.Hour, loc: time.UTC}
+		for _, tc := range []struct {
+			w    *workHours
+			now  time.Time
+			want bool
+		}{
+			{day, at(12, 9, 0), true},
+			{day, at(12, 16, 59), true},
+			{day, at(12, 17, 0), false},
+			{day, at(12, 8, 59), false},
+			{day, at(13, 12, 0), false},
+			{night, at(12, 23, 0), true},
+			{night, at(13, 5, 0), true},
+			{night, at(13, 6, 0), false},
+			{night, at(12, 5, 0), false},
+			{night, at(13, 23, 0), false},
+		} {
+			if got := tc.w.contains(tc.now); got != tc.want {
+				t.Errorf("%v in %v-%v: %v, want %v", tc.now, tc.w.start, tc.w.end, got, tc.want)
+			}
+		}
+		// The hours are in their own time zone.
+		tz := &workHours{days: day.days, start: day.start, end: day.end, loc: time.FixedZone("UTC-5", -5*3600)}
+		if !tz.contains(at(12, 20, 0)) || tz.contains(at(12, 10, 0)) {
+			t.Error("time zone ignored")
+		}
+	})
+	t.Run("OffHours", func(t *testing.T) {
+		s := newTestServer(t)
+		s.repoWorkHours = map[string]*workHours{"r": {days: [7]bool{true, true, true, true, true, true, true}, start: 9 * time.Hour, end: 17 * time.Hour, loc: time.UTC, grace: time.Minute}}
+		mk := func(a task.Automation) *taskEntry {
+			tk := &task.Task{InitialPrompt: agent.Prompt{Text: "test"}, Repos: []task.RepoMount{{Name: "r"}}, Automation: a}
+			return &taskEntry{task: tk, done: make(chan struct{})}
+		}
+		work := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+		night := time.Date(2026, 10, 12, 23, 0, 0, 0, time.UTC)
+		if s.offHours(mk(task.AutomationSchedule), work) != nil {
+			t.Error("working hours")
+		}
+		if w := s.offHours(mk(task.AutomationSchedule), night); w == nil || w.grace != time.Minute {
+			t.Errorf("off hours: %+v", w)
+		}
+		if s.offHours(mk(task.AutomationInteractive), night) != nil {
+			t.Error("interactive")
+		}
+		if s.offHours(mk(task.AutomationUnattended), work) != unattendedHours {
+			t.Error("unattended")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+//go:build soak
+
+package soak
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestSoak runs against a local server started with
+// "go run -tags e2e ./backend/cmd/caic", configured with the CAIC_SOAK_*
+// environment variables set by "make soak".
+func TestSoak(t *testing.T) {
+	cfg := &Config{
+		URL:           envString("CAIC_SOAK_URL", "http://localhost:8090"),
+		Messages:      envInt(t, "CAIC_SOAK_MESSAGES", 1_000_000),
+		Rate:          envInt(t, "CAIC_SOAK_RATE", 200),
+		Subscribers:   envInt(t, "CAIC_SOAK_SUBSCRIBERS", 4),
+		Reconnect:     time.Duration(envInt(t, "CAIC_SOAK_RECONNECT_SECONDS", 600)) * time.Second,
+		Sample:        30 * time.Second,
+		MaxHeap:       uint64(envInt(t, "CAIC_SOAK_MAX_HEAP_MB", 2048)) << 20,
+		MaxGoroutines: 20,
+		LogDir:        envString("CAIC_SOAK_LOG_DIR", filepath.Join(os.TempDir(), "caic-e2e-logs")),
+		MaxLog:        int64(envInt(t, "CAIC_SOAK_MAX_LOG_MB", 0)) << 20,
+		Progress:      t.Logf,
+	}
+	rep, err := Run(t.Context(), cfg)
+	if rep != nil {
+		t.Logf("task %s ran %s: peak heap %d MiB, goroutines %d -> %d, logs %d MiB", rep.TaskID, rep.Duration.Round(time.Second), rep.PeakHeap>>20, rep.Goroutines[0], rep.Goroutines[1], rep.LogBytes>>20)
+		for i, s := range rep.Subscribers {
+			t.Logf("subscriber %d: %+v", i, s)
+		}
+		for _, v := range rep.Violations {
+			t.Error(v)
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func envString(name, def string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(t *testing.T, name string, def int) int {
+	v := os.Getenv(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return n
+}
@@ -0,0 +1,362 @@
+// Package soak drives a long agent session through a caic server running the
+// fake agent (go run -tags e2e ./backend/cmd/caic) and checks that the server
+// stays healthy: memory stays bounded, event stream subscribers receive every
+// message in order across reconnections, goroutines don't leak and the
+// session logs stay bounded.
+//
+// The fake agent emits "soak <i>" text messages when prompted with
+// "FAKE_SOAK <count> <rate>". Run it with "make soak".
+package soak
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+)
+
+// Config describes a soak run.
+type Config struct {
+	URL         string        // Base URL of the server, e.g. "http://localhost:8090".
+	Messages    int           // Messages the fake agent emits.
+	Rate        int           // Messages per second; 0 is as fast as possible.
+	Subscribers int           // Concurrent event stream subscribers.
+	Reconnect   time.Duration // Interval at which subscribers reconnect; 0 never.
+	Sample      time.Duration // Interval between memory samples; defaults to 5s.
+	MaxHeap     uint64        // Heap limit in bytes; 0 disables the check.
+	// MaxGoroutines is how many goroutines the server may have left over once
+	// the subscribers are gone, compared to before the run.
+	MaxGoroutines int
+	// Settle is how long the server gets to close the streams before its
+	// goroutines are counted; defaults to 2s.
+	Settle   time.Duration
+	LogDir   string // Server's log directory; empty skips the log check.
+	MaxLog   int64  // Session log size limit in bytes; 0 disables the check.
+	Progress func(format string, args ...any)
+}
+
+// Report is the outcome of a soak run.
+type Report struct {
+	TaskID         string
+	Duration       time.Duration
+	Subscribers    []SubscriberStats
+	PeakHeap       uint64
+	Goroutines     [2]int // Before and after the run.
+	LogBytes       int64
+	Violations     []string
+	violationsLock sync.Mutex
+	received       atomic.Int64 // Highest message number received by a subscriber.
+}
+
+// SubscriberStats is what one event stream subscriber saw.
+type SubscriberStats struct {
+	Connections int // 1 plus the reconnections, forced or after being dropped.
+	Events      int // Total events received over all connections.
+	Last        int // Highest message number received.
+	Done        bool
+}
+
+func (r *Report) violate(format string, args ...any) {
+	r.violationsLock.Lock()
+	defer r.violationsLock.Unlock()
+	r.Violations = append(r.Violations, fmt.Sprintf(format, args...))
+}
+
+// Run creates a soak task on the server and follows it until the fake agent
+// emitted every message. It returns an error when the run couldn't complete;
+// checks that failed are listed in Report.Violations.
+func Run(ctx context.Context, cfg *Config) (*Report, error) {
+	if cfg.Messages <= 0 || cfg.Subscribers <= 0 {
+		return nil, errors.New("soak: Messages and Subscribers must be positive")
+	}
+	c := &client{base: strings.TrimSuffix(cfg.URL, "/"), http: &http.Client{}}
+	progress := cfg.Progress
+	if progress == nil {
+		progress = func(string, ...any) {}
+	}
+	sample := cfg.Sample
+	if sample <= 0 {
+		sample = 5 * time.Second
+	}
+	rep := &Report{}
+	var st v1.ServerStatusResp
+	if err := c.get(ctx, "/api/v1/server/status", &st); err != nil {
+		return nil, err
+	}
+	rep.Goroutines[0] = st.Process.Goroutines
+	var repos []v1.Repo
+	if err := c.get(ctx, "/api/v1/server/repos", &repos); err != nil {
+		return nil, err
+	}
+	if len(repos) == 0 {
+		return nil, errors.New("soak: the server has no repository")
+	}
+	req := v1.CreateTaskReq{
+		InitialPrompt: v1.Prompt{Text: fmt.Sprintf("FAKE_SOAK %d %d", cfg.Messages, cfg.Rate)},
+		Repos:         []v1.RepoSpec{{Name: repos[0].Path}},
+		Harness:       "fake",
+	}
+	var created v1.CreateTaskResp
+	if err := c.post(ctx, "/api/v1/tasks", &req, &created); err != nil {
+		return nil, err
+	}
+	rep.TaskID = created.ID.String()
+	progress("task %s: %d messages", rep.TaskID, cfg.Messages)
+	start := time.Now()
+
+	rep.Subscribers = make([]SubscriberStats, cfg.Subscribers)
+	var wg sync.WaitGroup
+	for i := range rep.Subscribers {
+		wg.Go(func() { c.subscribe(ctx, cfg, rep, i) })
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	t := time.NewTicker(sample)
+	defer t.Stop()
+loop:
+	for {
+		select {
+		case <-done:
+			break loop
+		case <-t.C:
+			if err := c.get(ctx, "/api/v1/server/status", &st); err != nil {
+				progress("status: %v", err)
+				continue
+			}
+			rep.PeakHeap = max(rep.PeakHeap, st.Process.HeapBytes)
+			if cfg.MaxHeap != 0 && st.Process.HeapBytes > cfg.MaxHeap {
+				rep.violate("heap %d bytes exceeds %d", st.Process.HeapBytes, cfg.MaxHeap)
+			}
+			progress("heap %d MiB, goroutines %d, received %d/%d", st.Process.HeapBytes>>20, st.Process.Goroutines, rep.received.Load(), cfg.Messages)
+		}
+	}
+	rep.Duration = time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return rep, err
+	}
+
+	settle := cfg.Settle
+	if settle <= 0 {
+		settle = 2 * time.Second
+	}
+	time.Sleep(settle)
+	if err := c.get(ctx, "/api/v1/server/status", &st); err != nil {
+		return rep, err
+	}
+	rep.Goroutines[1] = st.Process.Goroutines
+	rep.PeakHeap = max(rep.PeakHeap, st.Process.HeapBytes)
+	if d := rep.Goroutines[1] - rep.Goroutines[0]; d > cfg.MaxGoroutines {
+		rep.violate("%d goroutines left over, from %d to %d", d, rep.Goroutines[0], rep.Goroutines[1])
+	}
+	if cfg.LogDir != "" {
+		n, err := logSize(cfg.LogDir, rep.TaskID)
+		if err != nil {
+			return rep, err
+		}
+		rep.LogBytes = n
+		if cfg.MaxLog != 0 && n > cfg.MaxLog {
+			rep.violate("session logs total %d bytes, over %d", n, cfg.MaxLog)
+		}
+	}
+	if err := c.post(ctx, "/api/v1/tasks/"+rep.TaskID+"/purge", nil, nil); err != nil {
+		progress("purge: %v", err)
+	}
+	return rep, nil
+}
+
+// subscribe follows the event stream of the task until the result arrives,
+// reconnecting when dropped or every cfg.Reconnect.
+func (c *client) subscribe(ctx context.Context, cfg *Config, rep *Report, i int) {
+	s := &rep.Subscribers[i]
+	for ctx.Err() == nil && !s.Done {
+		cctx, cancel := ctx, context.CancelFunc(func() {})
+		if cfg.Reconnect > 0 {
+			cctx, cancel = context.WithTimeout(ctx, cfg.Reconnect)
+		}
+		s.Connections++
+		err := c.follow(cctx, rep, s)
+		cancel()
+		if err != nil && ctx.Err() == nil && !errors.Is(err, context.DeadlineExceeded) {
+			rep.violate("subscriber %d: %v", i, err)
+			return
+		}
+	}
+	if s.Done && s.Last != cfg.Messages {
+		rep.violate("subscriber %d: result after message %d of %d", i, s.Last, cfg.Messages)
+	}
+}
+
+// follow reads one connection of the event stream. The history is replayed
+// first, so the messages must be numbered from 1 without gap on every
+// connection.
+func (c *client) follow(ctx context.Context, rep *Report, s *SubscriberStats) error {
+	r, err := http.NewRequestWithContext(ctx, http.MethodGet, c.base+"/api/v1/tasks/"+rep.TaskID+"/events", http.NoBody)
+	if err != nil {
+		return err
+	}
+	resp, err := c.http.Do(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("events: %s", resp.Status)
+	}
+	next := 1
+	err = readEvents(resp.Body, func(ev *v1.EventMessage) error {
+		s.Events++
+		switch ev.Kind {
+		case v1.EventKindText:
+			n, ok := soakNumber(ev.Text.Text)
+			if !ok {
+				return nil
+			}
+			if n != next {
+				return fmt.Errorf("connection %d: got message %d, want %d", s.Connections, n, next)
+			}
+			next++
+			if n > s.Last {
+				s.Last = n
+				storeMax(&rep.received, int64(n))
+			}
+		case v1.EventKindResult:
+			if next > 1 {
+				s.Done = true
+				return io.EOF
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// storeMax sets a to v unless it is already larger.
+func storeMax(a *atomic.Int64, v int64) {
+	for {
+		old := a.Load()
+		if old >= v || a.CompareAndSwap(old, v) {
+			return
+		}
+	}
+}
+
+// soakNumber returns i of a "soak <i>" message.
+func soakNumber(text string) (int, bool) {
+	s, ok := strings.CutPrefix(text, "soak ")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// readEvents calls fn with each message event of an SSE stream until the
+// stream ends or fn returns an error.
+func readEvents(r io.Reader, fn func(*v1.EventMessage) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), 32<<20)
+	event := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if v, ok := strings.CutPrefix(line, "event: "); ok {
+			event = v
+			continue
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok || event != "message" {
+			continue
+		}
+		var ev v1.EventMessage
+		if err := json.Unmarshal([]byte(data), &ev); err != nil {
+			return fmt.Errorf("event %q: %w", data, err)
+		}
+		if err := fn(&ev); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// logSize returns the total size of the session logs of task id in dir.
+func logSize(dir, id string) (int64, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, id+"-*"))
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for _, m := range matches {
+		fi, err := os.Stat(m)
+		if err != nil {
+			return 0, err
+		}
+		n += fi.Size()
+	}
+	return n, nil
+}
+
+type client struct {
+	base string
+	http *http.Client
+}
+
+func (c *client) get(ctx context.Context, path string, out any) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+func (c *client) post(ctx context.Context, path string, in, out any) error {
+	return c.do(ctx, http.MethodPost, path, in, out)
+}
+
+func (c *client) do(ctx context.Context, method, path string, in, out any) error {
+	body := []byte("{}")
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	var rd io.Reader = http.NoBody
+	if method != http.MethodGet {
+		rd = bytes.NewReader(body)
+	}
+	r, err := http.NewRequestWithContext(ctx, method, c.base+path, rd)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(b))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
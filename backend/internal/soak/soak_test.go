@@ -0,0 +1,121 @@
+package soak
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/caic-xyz/caic/backend/internal/server/dto/v1"
+	"github.com/maruel/ksid"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("Healthy", func(t *testing.T) {
+		srv := newFakeServer(t, 50, 0)
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, srv.id.String()+"-repo-branch.jsonl"), make([]byte, 100), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		rep, err := Run(t.Context(), &Config{URL: srv.URL, Messages: 50, Subscribers: 3, MaxGoroutines: 5, Settle: time.Millisecond, LogDir: dir, MaxLog: 1000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rep.Violations) != 0 {
+			t.Errorf("violations: %q", rep.Violations)
+		}
+		for i, s := range rep.Subscribers {
+			if !s.Done || s.Last != 50 || s.Connections != 1 {
+				t.Errorf("subscriber %d: %+v", i, s)
+			}
+		}
+		if rep.TaskID != srv.id.String() || rep.LogBytes != 100 || !srv.purged {
+			t.Errorf("report: %+v, purged %t", rep, srv.purged)
+		}
+	})
+	t.Run("Gap", func(t *testing.T) {
+		srv := newFakeServer(t, 10, 4)
+		rep, err := Run(t.Context(), &Config{URL: srv.URL, Messages: 10, Subscribers: 1, Settle: time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rep.Violations) != 1 || !strings.Contains(rep.Violations[0], "got message 5, want 4") {
+			t.Errorf("violations: %q", rep.Violations)
+		}
+	})
+	t.Run("LogTooLarge", func(t *testing.T) {
+		srv := newFakeServer(t, 1, 0)
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, srv.id.String()+"-repo-branch.jsonl"), make([]byte, 100), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		rep, err := Run(t.Context(), &Config{URL: srv.URL, Messages: 1, Subscribers: 1, Settle: time.Millisecond, LogDir: dir, MaxLog: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rep.Violations) != 1 || !strings.Contains(rep.Violations[0], "session logs") {
+			t.Errorf("violations: %q", rep.Violations)
+		}
+	})
+}
+
+type fakeServer struct {
+	*httptest.Server
+	id     ksid.ID
+	purged bool
+}
+
+// newFakeServer serves a task whose event stream holds messages numbered 1
+// to n, skipping skip when not 0.
+func newFakeServer(t *testing.T, n, skip int) *fakeServer {
+	f := &fakeServer{id: ksid.NewID()}
+	mux := http.NewServeMux()
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(v)
+	}
+	mux.HandleFunc("GET /api/v1/server/status", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, &v1.ServerStatusResp{Process: v1.ProcessStatus{HeapBytes: 1 << 20, Goroutines: 10}})
+	})
+	mux.HandleFunc("GET /api/v1/server/repos", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, []v1.Repo{{Path: "repo"}})
+	})
+	mux.HandleFunc("POST /api/v1/tasks", func(w http.ResponseWriter, r *http.Request) {
+		var req v1.CreateTaskReq
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Harness != "fake" || req.Repos[0].Name != "repo" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if want := fmt.Sprintf("FAKE_SOAK %d 0", n); req.InitialPrompt.Text != want {
+			t.Errorf("prompt = %q, want %q", req.InitialPrompt.Text, want)
+		}
+		writeJSON(w, &v1.CreateTaskResp{Status: "accepted", ID: f.id})
+	})
+	mux.HandleFunc("GET /api/v1/tasks/{id}/events", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		write := func(ev *v1.EventMessage) {
+			b, _ := json.Marshal(ev)
+			_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", b)
+		}
+		write(&v1.EventMessage{Kind: v1.EventKindText, Text: &v1.EventText{Text: "hello"}})
+		for i := 1; i <= n; i++ {
+			if i != skip {
+				write(&v1.EventMessage{Kind: v1.EventKindText, Text: &v1.EventText{Text: fmt.Sprintf("soak %d", i)}})
+			}
+		}
+		write(&v1.EventMessage{Kind: v1.EventKindResult, Result: &v1.EventResult{}})
+		_, _ = fmt.Fprint(w, "event: ready\ndata: {}\n\n")
+	})
+	mux.HandleFunc("POST /api/v1/tasks/{id}/purge", func(w http.ResponseWriter, _ *http.Request) {
+		f.purged = true
+		writeJSON(w, &v1.StatusResp{Status: "ok"})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
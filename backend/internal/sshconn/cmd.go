@@ -0,0 +1,254 @@
+// Remote commands mirroring the subset of exec.Cmd caic uses.
+package sshconn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// ExitError is a remote command that ran but exited unsuccessfully.
+type ExitError struct {
+	Code   int    // Exit status; -1 when killed by a signal.
+	Signal string // Signal that killed the command, if any.
+}
+
+func (e *ExitError) Error() string {
+	if e.Signal != "" {
+		return "signal: " + e.Signal
+	}
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ConnError is a failure to reach the container or a connection lost before
+// the remote command reported its exit status. It is usually transient.
+type ConnError struct {
+	Container string
+	Err       error
+}
+
+func (e *ConnError) Error() string {
+	return "ssh " + e.Container + ": " + e.Err.Error()
+}
+
+func (e *ConnError) Unwrap() error {
+	return e.Err
+}
+
+// Cmd is a command to run in a container. Create it with Command. Like
+// exec.Cmd, it runs once.
+type Cmd struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	ctx       context.Context
+	container string
+	args      []string
+	ex        *exec.Cmd    // When spawning the ssh binary.
+	sess      *ssh.Session // When using a Manager connection.
+	done      chan struct{}
+}
+
+// Command returns the command running args in container. As with ssh, args
+// are joined with spaces and run by the remote login shell. Killing ctx kills
+// the command.
+//
+// It uses the Manager set with SetDefault when the container has an md SSH
+// config, otherwise the ssh binary.
+func Command(ctx context.Context, container string, args ...string) *Cmd {
+	return &Cmd{ctx: ctx, container: container, args: args}
+}
+
+// prepare selects the transport. It is called once, before the command
+// starts.
+func (c *Cmd) prepare() error {
+	if c.ex != nil || c.sess != nil {
+		return nil
+	}
+	if m := defaultManager.Load(); m != nil {
+		sess, err := m.session(c.ctx, c.container)
+		if err == nil {
+			c.sess = sess
+			return nil
+		}
+		if !errors.Is(err, errNoConfig) {
+			return err
+		}
+	}
+	c.ex = exec.CommandContext(c.ctx, "ssh", append([]string{c.container}, c.args...)...) //nolint:gosec // callers don't pass user-controlled containers.
+	return nil
+}
+
+// StdinPipe returns a pipe connected to the command's standard input.
+func (c *Cmd) StdinPipe() (io.WriteCloser, error) {
+	if err := c.prepare(); err != nil {
+		return nil, err
+	}
+	if c.ex != nil {
+		return c.ex.StdinPipe()
+	}
+	return c.sess.StdinPipe()
+}
+
+// StdoutPipe returns a pipe connected to the command's standard output.
+func (c *Cmd) StdoutPipe() (io.ReadCloser, error) {
+	if err := c.prepare(); err != nil {
+		return nil, err
+	}
+	if c.ex != nil {
+		return c.ex.StdoutPipe()
+	}
+	r, err := c.sess.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(r), nil
+}
+
+// Start starts the command without waiting for it to complete.
+func (c *Cmd) Start() error {
+	if err := c.prepare(); err != nil {
+		return err
+	}
+	if c.ex != nil {
+		if c.Stdin != nil {
+			c.ex.Stdin = c.Stdin
+		}
+		if c.Stdout != nil {
+			c.ex.Stdout = c.Stdout
+		}
+		c.ex.Stderr = c.Stderr
+		return c.ex.Start()
+	}
+	if c.Stdin != nil {
+		c.sess.Stdin = c.Stdin
+	}
+	if c.Stdout != nil {
+		c.sess.Stdout = c.Stdout
+	}
+	c.sess.Stderr = c.Stderr
+	if err := c.sess.Start(strings.Join(c.args, " ")); err != nil {
+		_ = c.sess.Close()
+		return &ConnError{Container: c.container, Err: err}
+	}
+	c.done = make(chan struct{})
+	go func() {
+		select {
+		case <-c.ctx.Done():
+			_ = c.Kill()
+		case <-c.done:
+		}
+	}()
+	return nil
+}
+
+// Wait waits for the command to exit. A non-zero exit status is returned as
+// an *ExitError, a connection failure as a *ConnError.
+func (c *Cmd) Wait() error {
+	if c.ex != nil {
+		err := c.ex.Wait()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+		// ssh exits with 255 when it fails on its own.
+		if exitErr.ExitCode() == 255 {
+			return &ConnError{Container: c.container, Err: err}
+		}
+		e := &ExitError{Code: exitErr.ExitCode()}
+		if ws, ok := exitErr.Sys().(interface{ Signal() syscall.Signal }); ok && e.Code == -1 {
+			e.Signal = ws.Signal().String()
+		}
+		return e
+	}
+	if c.sess == nil {
+		return errors.New("sshconn: not started")
+	}
+	err := c.sess.Wait()
+	close(c.done)
+	_ = c.sess.Close()
+	if c.ctx.Err() != nil && err != nil {
+		return &ExitError{Code: -1, Signal: "killed"}
+	}
+	var exitErr *ssh.ExitError
+	var missing *ssh.ExitMissingError
+	switch {
+	case errors.As(err, &exitErr):
+		if exitErr.Signal() != "" {
+			return &ExitError{Code: -1, Signal: exitErr.Signal()}
+		}
+		return &ExitError{Code: exitErr.ExitStatus()}
+	case errors.As(err, &missing):
+		return &ConnError{Container: c.container, Err: err}
+	}
+	return err
+}
+
+// Run starts the command and waits for it to complete.
+func (c *Cmd) Run() error {
+	if err := c.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Output runs the command and returns its standard output.
+func (c *Cmd) Output() ([]byte, error) {
+	if c.Stdout != nil {
+		return nil, errors.New("sshconn: Stdout already set")
+	}
+	var b bytes.Buffer
+	c.Stdout = &b
+	err := c.Run()
+	return b.Bytes(), err
+}
+
+// CombinedOutput runs the command and returns its combined standard output
+// and standard error.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	if c.Stdout != nil || c.Stderr != nil {
+		return nil, errors.New("sshconn: Stdout or Stderr already set")
+	}
+	// The SSH session copies both streams concurrently.
+	var b lockedBuffer
+	c.Stdout = &b
+	c.Stderr = &b
+	err := c.Run()
+	return b.b.Bytes(), err
+}
+
+// Kill kills the started command. Over a Manager connection the signal is
+// best effort and the session is closed either way.
+func (c *Cmd) Kill() error {
+	if c.ex != nil {
+		if c.ex.Process == nil {
+			return errors.New("sshconn: not started")
+		}
+		return c.ex.Process.Kill()
+	}
+	if c.sess == nil {
+		return errors.New("sshconn: not started")
+	}
+	_ = c.sess.Signal(ssh.SIGKILL)
+	return c.sess.Close()
+}
+
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (l *lockedBuffer) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
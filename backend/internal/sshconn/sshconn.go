@@ -0,0 +1,267 @@
+// Package sshconn runs commands in md containers over native SSH connections
+// kept open per container, instead of spawning the ssh binary for each one.
+package sshconn
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Manager keeps one SSH connection per container, dialed on first use from
+// the config md writes for the container. Connections are dropped when a
+// keepalive fails or the container's config changes, e.g. on restart with a
+// new port, and redialed on next use.
+type Manager struct {
+	// Dir holds the <container>.conf and <container>.known_hosts files md
+	// writes, usually ~/.ssh/config.d.
+	Dir string
+	// DialTimeout bounds connecting and the SSH handshake.
+	DialTimeout time.Duration
+	// KeepAlive is the interval between keepalive requests.
+	KeepAlive time.Duration
+
+	mu    sync.Mutex
+	conns map[string]*conn
+}
+
+// New returns a Manager reading the container configs in dir.
+func New(dir string) *Manager {
+	return &Manager{Dir: dir, DialTimeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+}
+
+// Close closes all the connections.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	conns := m.conns
+	m.conns = nil
+	m.mu.Unlock()
+	for _, c := range conns {
+		<-c.ready
+		if c.client != nil {
+			_ = c.client.Close()
+		}
+	}
+}
+
+var defaultManager atomic.Pointer[Manager]
+
+// SetDefault makes Command use m. nil, the default, makes it spawn the ssh
+// binary.
+func SetDefault(m *Manager) {
+	defaultManager.Store(m)
+}
+
+// conn is a connection to one container. ready is closed once the dial
+// completed, after which client or err is set.
+type conn struct {
+	cfg    hostConfig
+	ready  chan struct{}
+	client *ssh.Client
+	err    error
+}
+
+// hostConfig is the subset of an md container SSH config needed to dial it.
+type hostConfig struct {
+	addr       string // host:port
+	user       string
+	identity   string
+	knownHosts string
+}
+
+// errNoConfig means the container has no md SSH config; the caller falls
+// back to the ssh binary so user managed hosts keep working.
+var errNoConfig = errors.New("no md ssh config")
+
+// readConfig parses the <container>.conf file md writes. It only understands
+// the flat "Key Value" lines md emits, not the ssh_config language.
+func (m *Manager) readConfig(container string) (hostConfig, error) {
+	f, err := os.Open(filepath.Join(m.Dir, container+".conf")) //nolint:gosec // container is not user-controlled
+	if errors.Is(err, os.ErrNotExist) {
+		return hostConfig{}, errNoConfig
+	}
+	if err != nil {
+		return hostConfig{}, err
+	}
+	defer func() { _ = f.Close() }()
+	host, port := "", "22"
+	var cfg hostConfig
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		k, v, ok := strings.Cut(strings.TrimSpace(s.Text()), " ")
+		if !ok {
+			continue
+		}
+		v = strings.TrimSpace(v)
+		switch strings.ToLower(k) {
+		case "hostname":
+			host = v
+		case "port":
+			port = v
+		case "user":
+			cfg.user = v
+		case "identityfile":
+			cfg.identity = v
+		case "userknownhostsfile":
+			cfg.knownHosts = v
+		}
+	}
+	if err := s.Err(); err != nil {
+		return hostConfig{}, err
+	}
+	if host == "" || cfg.user == "" || cfg.identity == "" || cfg.knownHosts == "" {
+		return hostConfig{}, fmt.Errorf("incomplete ssh config for %s", container)
+	}
+	cfg.addr = net.JoinHostPort(host, port)
+	return cfg, nil
+}
+
+// session opens a session on the container's connection, dialing it if
+// needed. A stale connection is redialed once.
+func (m *Manager) session(ctx context.Context, container string) (*ssh.Session, error) {
+	cfg, err := m.readConfig(container)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; ; i++ {
+		c, err := m.get(ctx, container, cfg)
+		if err != nil {
+			return nil, &ConnError{Container: container, Err: err}
+		}
+		sess, err := c.client.NewSession()
+		if err == nil {
+			return sess, nil
+		}
+		m.drop(container, c)
+		if i == 1 {
+			return nil, &ConnError{Container: container, Err: err}
+		}
+	}
+}
+
+// get returns the connection to container, dialing it if there is none or
+// its config changed.
+func (m *Manager) get(ctx context.Context, container string, cfg hostConfig) (*conn, error) {
+	m.mu.Lock()
+	c := m.conns[container]
+	if c != nil && c.cfg != cfg {
+		delete(m.conns, container)
+		go func(old *conn) {
+			<-old.ready
+			if old.client != nil {
+				_ = old.client.Close()
+			}
+		}(c)
+		c = nil
+	}
+	if c == nil {
+		c = &conn{cfg: cfg, ready: make(chan struct{})}
+		if m.conns == nil {
+			m.conns = map[string]*conn{}
+		}
+		m.conns[container] = c
+		m.mu.Unlock()
+		c.client, c.err = m.dial(ctx, cfg)
+		close(c.ready)
+		if c.err != nil {
+			m.drop(container, c)
+			return nil, c.err
+		}
+		go m.keepAlive(container, c)
+		return c, nil
+	}
+	m.mu.Unlock()
+	select {
+	case <-c.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c, nil
+}
+
+// drop forgets c and closes its client, unless it was already replaced.
+func (m *Manager) drop(container string, c *conn) {
+	m.mu.Lock()
+	if m.conns[container] == c {
+		delete(m.conns, container)
+	}
+	m.mu.Unlock()
+	if c.client != nil {
+		_ = c.client.Close()
+	}
+}
+
+func (m *Manager) dial(ctx context.Context, cfg hostConfig) (*ssh.Client, error) {
+	key, err := os.ReadFile(cfg.identity)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", cfg.identity, err)
+	}
+	hostKey, err := knownhosts.New(cfg.knownHosts)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.DialTimeout)
+	defer cancel()
+	nc, err := (&net.Dialer{}).DialContext(ctx, "tcp", cfg.addr)
+	if err != nil {
+		return nil, err
+	}
+	// The handshake doesn't take a context; bound it with a deadline.
+	if d, ok := ctx.Deadline(); ok {
+		_ = nc.SetDeadline(d)
+	}
+	cc, chans, reqs, err := ssh.NewClientConn(nc, cfg.addr, &ssh.ClientConfig{
+		User:            cfg.user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKey,
+	})
+	if err != nil {
+		_ = nc.Close()
+		return nil, err
+	}
+	_ = nc.SetDeadline(time.Time{})
+	return ssh.NewClient(cc, chans, reqs), nil
+}
+
+// keepAlive probes c until it fails, then drops it.
+func (m *Manager) keepAlive(container string, c *conn) {
+	t := time.NewTicker(m.KeepAlive)
+	defer t.Stop()
+	closed := make(chan struct{})
+	go func() {
+		_ = c.client.Wait()
+		close(closed)
+	}()
+	for {
+		select {
+		case <-closed:
+			m.drop(container, c)
+			return
+		case <-t.C:
+			if _, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+				slog.Debug("ssh", "msg", "keepalive failed", "ctr", container, "err", err)
+				m.drop(container, c)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package sshconn
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestCommand(t *testing.T) {
+	m, dials := newTestServer(t)
+	SetDefault(m)
+	t.Cleanup(func() { SetDefault(nil) })
+	t.Run("Output", func(t *testing.T) {
+		out, err := Command(t.Context(), "ctr", "echo", "hi").Output()
+		if err != nil || string(out) != "hi\n" {
+			t.Errorf("got %q, %v", out, err)
+		}
+	})
+	t.Run("Stdin", func(t *testing.T) {
+		cmd := Command(t.Context(), "ctr", "cat")
+		cmd.Stdin = strings.NewReader("data")
+		out, err := cmd.Output()
+		if err != nil || string(out) != "data" {
+			t.Errorf("got %q, %v", out, err)
+		}
+	})
+	t.Run("Pipes", func(t *testing.T) {
+		cmd := Command(t.Context(), "ctr", "cat")
+		stdin, err := cmd.StdinPipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		_, _ = io.WriteString(stdin, "ping")
+		_ = stdin.Close()
+		out, _ := io.ReadAll(stdout)
+		if err := cmd.Wait(); err != nil || string(out) != "ping" {
+			t.Errorf("got %q, %v", out, err)
+		}
+	})
+	t.Run("ExitError", func(t *testing.T) {
+		out, err := Command(t.Context(), "ctr", "fail").CombinedOutput()
+		var exitErr *ExitError
+		if !errors.As(err, &exitErr) || exitErr.Code != 3 || string(out) != "oops\n" {
+			t.Errorf("got %q, %v", out, err)
+		}
+	})
+	t.Run("Reuse", func(t *testing.T) {
+		if n := dials.Load(); n != 1 {
+			t.Errorf("%d connections, want 1", n)
+		}
+		m.Close()
+		if err := Command(t.Context(), "ctr", "echo").Run(); err != nil {
+			t.Fatal(err)
+		}
+		if n := dials.Load(); n != 2 {
+			t.Errorf("%d connections after Close, want 2", n)
+		}
+	})
+	t.Run("NoConfig", func(t *testing.T) {
+		if _, err := m.readConfig("unknown"); !errors.Is(err, errNoConfig) {
+			t.Errorf("got %v", err)
+		}
+	})
+}
+
+// newTestServer starts an SSH server answering a few canned commands and
+// returns a Manager configured for it as container "ctr", like md would.
+func newTestServer(t *testing.T) (*Manager, *atomic.Int32) {
+	dir := t.TempDir()
+	_, hostPriv, _ := ed25519.GenerateKey(rand.Reader)
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userPub, userPriv, _ := ed25519.GenerateKey(rand.Reader)
+	block, err := ssh.MarshalPrivateKey(userPriv, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := filepath.Join(dir, "id")
+	if err := os.WriteFile(identity, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sshUserPub, _ := ssh.NewPublicKey(userPub)
+
+	cfg := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(sshUserPub.Marshal()) {
+				return nil, errors.New("unknown key")
+			}
+			return nil, nil
+		},
+	}
+	cfg.AddHostKey(hostSigner)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	dials := &atomic.Int32{}
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(nc, cfg)
+				if err != nil {
+					return
+				}
+				dials.Add(1)
+				go ssh.DiscardRequests(reqs)
+				for nch := range chans {
+					ch, creqs, err := nch.Accept()
+					if err != nil {
+						continue
+					}
+					go serveSession(ch, creqs)
+				}
+			}()
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	known := filepath.Join(dir, "ctr.known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr.String())}, hostSigner.PublicKey())
+	if err := os.WriteFile(known, []byte(line+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	conf := fmt.Sprintf("Host ctr\n  HostName 127.0.0.1\n  Port %d\n  User user\n  IdentityFile %s\n  UserKnownHostsFile %s\n", addr.Port, identity, known)
+	if err := os.WriteFile(filepath.Join(dir, "ctr.conf"), []byte(conf), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	m := New(dir)
+	t.Cleanup(m.Close)
+	return m, dials
+}
+
+func serveSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer func() { _ = ch.Close() }()
+	for req := range reqs {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		var p struct{ Command string }
+		_ = ssh.Unmarshal(req.Payload, &p)
+		_ = req.Reply(true, nil)
+		status := 0
+		switch cmd, arg, _ := strings.Cut(p.Command, " "); cmd {
+		case "echo":
+			_, _ = io.WriteString(ch, arg+"\n")
+		case "cat":
+			_, _ = io.Copy(ch, ch)
+		case "fail":
+			_, _ = io.WriteString(ch.Stderr(), "oops\n")
+			status = 3
+		default:
+			status, _ = strconv.Atoi(arg)
+		}
+		_, _ = ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{uint32(status)})) //nolint:gosec // test statuses are small.
+		return
+	}
+}
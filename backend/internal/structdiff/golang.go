@@ -0,0 +1,107 @@
+// Go declarations, from go/parser.
+package structdiff
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+)
+
+// goDeclarations returns the top level declarations of the Go source src,
+// or false when it doesn't parse.
+func goDeclarations(src []byte) ([]decl, bool) {
+	if len(src) == 0 {
+		return nil, true
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	text := func(n ast.Node) string {
+		var b bytes.Buffer
+		if printer.Fprint(&b, fset, n) != nil {
+			return ""
+		}
+		return b.String()
+	}
+	var out []decl
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			fd := decl{kind: Function, name: d.Name.Name}
+			// Parameter names don't change the signature.
+			sig := &ast.FuncType{TypeParams: d.Type.TypeParams, Params: anonymous(d.Type.Params), Results: anonymous(d.Type.Results)}
+			fd.sig = text(sig)
+			if d.Recv != nil && len(d.Recv.List) == 1 {
+				fd.kind, fd.name = Method, receiverType(d.Recv.List[0].Type)+"."+d.Name.Name
+			}
+			fd.body = text(d.Type)
+			if d.Body != nil {
+				fd.body += text(d.Body)
+			}
+			out = append(out, fd)
+		case *ast.GenDecl:
+			for _, s := range d.Specs {
+				switch s := s.(type) {
+				case *ast.TypeSpec:
+					k := Type
+					if _, ok := s.Type.(*ast.InterfaceType); ok {
+						k = Interface
+					}
+					out = append(out, decl{kind: k, name: s.Name.Name, body: text(s)})
+				case *ast.ValueSpec:
+					k := Var
+					if d.Tok == token.CONST {
+						k = Const
+					}
+					body := text(s)
+					for _, n := range s.Names {
+						if n.Name != "_" {
+							out = append(out, decl{kind: k, name: n.Name, body: body})
+						}
+					}
+				}
+			}
+		}
+	}
+	return out, true
+}
+
+// anonymous returns fl without the names of its fields.
+func anonymous(fl *ast.FieldList) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+	out := &ast.FieldList{}
+	for _, f := range fl.List {
+		n := max(len(f.Names), 1)
+		for range n {
+			out.List = append(out.List, &ast.Field{Type: f.Type})
+		}
+	}
+	return out
+}
+
+// receiverType returns the name of the type of a method receiver, without
+// pointer or type parameters.
+func receiverType(e ast.Expr) string {
+	for {
+		switch t := e.(type) {
+		case *ast.StarExpr:
+			e = t.X
+		case *ast.IndexExpr:
+			e = t.X
+		case *ast.IndexListExpr:
+			e = t.X
+		case *ast.ParenExpr:
+			e = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return "?"
+		}
+	}
+}
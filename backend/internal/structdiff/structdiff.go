@@ -0,0 +1,257 @@
+// Package structdiff summarizes the changes to a source file by declaration,
+// e.g. "added function X, modified signature of Y, added type Z", which says
+// more than raw hunks about large mechanical changes.
+//
+// Go is parsed with go/parser. Python, JavaScript, TypeScript and Rust
+// declarations are recognized line by line, which is enough for the common
+// layouts of these languages.
+package structdiff
+
+import (
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Op is what happened to a declaration.
+type Op string
+
+// Op values.
+const (
+	Added     Op = "added"
+	Removed   Op = "removed"
+	Signature Op = "signature" // The signature changed, and maybe the body.
+	Modified  Op = "modified"  // Only the body changed.
+)
+
+// Kind is the kind of a declaration.
+type Kind string
+
+// Kind values.
+const (
+	Function  Kind = "function"
+	Method    Kind = "method"
+	Type      Kind = "type"
+	Class     Kind = "class"
+	Interface Kind = "interface"
+	Trait     Kind = "trait"
+	Const     Kind = "const"
+	Var       Kind = "var"
+)
+
+// Change is a change to one declaration.
+type Change struct {
+	Op   Op
+	Kind Kind
+	Name string // Methods are qualified by their type, e.g. "Task.Run".
+}
+
+// String returns the change as a phrase, e.g. "added function X" or
+// "modified signature of method T.M".
+func (c Change) String() string {
+	switch c.Op {
+	case Signature:
+		return "modified signature of " + string(c.Kind) + " " + c.Name
+	case Modified:
+		return "modified " + string(c.Kind) + " " + c.Name
+	default:
+		return string(c.Op) + " " + string(c.Kind) + " " + c.Name
+	}
+}
+
+// decl is a declaration of a source file.
+type decl struct {
+	kind Kind
+	name string
+	sig  string // Normalized signature; empty for declarations without one.
+	body string // Normalized text of the declaration.
+}
+
+func (d *decl) key() string {
+	return string(d.kind) + " " + d.name
+}
+
+// Language returns the language of the file at p from its extension, or ""
+// when not supported.
+func Language(p string) string {
+	switch path.Ext(p) {
+	case ".go":
+		return "go"
+	case ".py":
+		return "python"
+	case ".js", ".jsx", ".mjs", ".cjs":
+		return "javascript"
+	case ".ts", ".tsx", ".mts", ".cts":
+		return "typescript"
+	case ".rs":
+		return "rust"
+	}
+	return ""
+}
+
+// Summarize returns the changes between the old and new content of the file
+// at p, in the order of the new file followed by the removals. A missing side
+// is nil. It returns nil for unsupported languages.
+func Summarize(p string, old, new []byte) []Change {
+	lang := Language(p)
+	if lang == "" {
+		return nil
+	}
+	before, after := declarations(lang, old, new)
+	prev := make(map[string]*decl, len(before))
+	for i := range before {
+		prev[before[i].key()] = &before[i]
+	}
+	seen := make(map[string]bool, len(after))
+	var out []Change
+	for _, d := range after {
+		k := d.key()
+		if seen[k] {
+			// Redeclared, e.g. overloads or init functions.
+			continue
+		}
+		seen[k] = true
+		c := Change{Kind: d.kind, Name: d.name}
+		switch o, ok := prev[k]; {
+		case !ok:
+			c.Op = Added
+		case o.sig != d.sig:
+			c.Op = Signature
+		case o.body != d.body:
+			c.Op = Modified
+		default:
+			continue
+		}
+		out = append(out, c)
+	}
+	for _, d := range before {
+		if k := d.key(); !seen[k] {
+			seen[k] = true
+			out = append(out, Change{Op: Removed, Kind: d.kind, Name: d.name})
+		}
+	}
+	return out
+}
+
+// declarations returns the declarations of old and new in lang. Go sources
+// both parsing are compared by their syntax tree, otherwise line by line.
+func declarations(lang string, old, new []byte) (before, after []decl) {
+	if lang == "go" {
+		var ok1, ok2 bool
+		if before, ok1 = goDeclarations(old); ok1 {
+			if after, ok2 = goDeclarations(new); ok2 {
+				return before, after
+			}
+		}
+	}
+	return lineDeclarations(lineRules[lang], string(old)), lineDeclarations(lineRules[lang], string(new))
+}
+
+// lineRule recognizes the first line of a declaration. The name is the
+// non-empty submatches joined by dots; a function with a qualified name, e.g.
+// "Type.Method", is a method.
+type lineRule struct {
+	re   *regexp.Regexp
+	kind Kind
+	// container declarations qualify the functions declared in them, which
+	// are then methods.
+	container bool
+}
+
+var lineRules = func() map[string][]lineRule {
+	js := []lineRule{
+		{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`), kind: Function},
+		{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`), kind: Class, container: true},
+		{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*(?::[^=]+)?=>`), kind: Function},
+	}
+	ts := append(slices.Clone(js),
+		lineRule{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?interface\s+([A-Za-z_$][\w$]*)`), kind: Interface},
+		lineRule{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?type\s+([A-Za-z_$][\w$]*)\s*(?:<[^=]*>)?\s*=`), kind: Type},
+		lineRule{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:const\s+)?enum\s+([A-Za-z_$][\w$]*)`), kind: Type},
+	)
+	return map[string][]lineRule{
+		"go": {
+			{re: regexp.MustCompile(`^func\s+\(\s*(?:\w+\s+)?\*?\s*(\w+)(?:\[[^\]]*\])?\s*\)\s*(\w+)`), kind: Function},
+			{re: regexp.MustCompile(`^func\s+(\w+)`), kind: Function},
+			{re: regexp.MustCompile(`^type\s+(\w+)`), kind: Type},
+		},
+		"python": {
+			{re: regexp.MustCompile(`^\s*(?:async\s+)?def\s+(\w+)\s*\(`), kind: Function},
+			{re: regexp.MustCompile(`^\s*class\s+(\w+)`), kind: Class, container: true},
+		},
+		"javascript": js,
+		"typescript": ts,
+		"rust": {
+			{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?(?:extern\s+"[^"]*"\s+)?fn\s+(\w+)`), kind: Function},
+			{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:struct|enum|union|type)\s+(\w+)`), kind: Type},
+			{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?trait\s+(\w+)`), kind: Trait, container: true},
+			{re: regexp.MustCompile(`^\s*(?:unsafe\s+)?impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?(\w+)`), container: true},
+		},
+	}
+}()
+
+// lineDeclarations recognizes the declarations of src with rules, one per
+// line matching one of them. A declaration's text runs to the next one;
+// functions indented in a container are its methods.
+func lineDeclarations(rules []lineRule, src string) []decl {
+	type scope struct {
+		indent int
+		name   string
+	}
+	var out []decl
+	var scopes []scope
+	cur := -1
+	var body strings.Builder
+	flush := func() {
+		if cur >= 0 {
+			out[cur].body = body.String()
+		}
+		body.Reset()
+	}
+	for line := range strings.SplitSeq(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		var rule *lineRule
+		var name string
+		for i := range rules {
+			if m := rules[i].re.FindStringSubmatch(line); m != nil {
+				rule, name = &rules[i], strings.Join(slices.DeleteFunc(m[1:], func(s string) bool { return s == "" }), ".")
+				break
+			}
+		}
+		if rule == nil {
+			if trimmed != "" {
+				body.WriteString(trimmed)
+				body.WriteByte('\n')
+			}
+			continue
+		}
+		flush()
+		for len(scopes) != 0 && scopes[len(scopes)-1].indent >= indent {
+			scopes = scopes[:len(scopes)-1]
+		}
+		if rule.container {
+			scopes = append(scopes, scope{indent: indent, name: name})
+			if rule.kind == "" {
+				// impl blocks only qualify their functions.
+				cur = -1
+				continue
+			}
+		}
+		d := decl{kind: rule.kind, name: name, sig: strings.Join(strings.Fields(trimmed), " ")}
+		switch {
+		case rule.kind != Function:
+		case strings.Contains(name, "."):
+			d.kind = Method
+		case len(scopes) != 0:
+			d.kind, d.name = Method, scopes[len(scopes)-1].name+"."+name
+		}
+		out = append(out, d)
+		cur = len(out) - 1
+		body.WriteString(d.sig)
+		body.WriteByte('\n')
+	}
+	flush()
+	return out
+}
@@ -0,0 +1,109 @@
+package structdiff
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	strs := func(cs []Change) []string {
+		var out []string
+		for _, c := range cs {
+			out = append(out, c.String())
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		name     string
+		path     string
+		old, new string
+		want     []string
+	}{
+		{
+			name: "Go",
+			path: "a.go",
+			old: `package a
+
+type T struct{ n int }
+
+func (t *T) Get() int { return t.n }
+
+func Parse(s string) (int, error) { return 0, nil }
+
+func old() {}
+
+const limit = 3
+`,
+			new: `package a
+
+// T is documented now.
+type T struct{ n int }
+
+func (t *T) Get() int { return t.n + 1 }
+
+func Parse(in string, base int) (int, error) { return 0, nil }
+
+func (x T) Set[V any](v int) {}
+
+type Reader interface{ Read() }
+
+const limit = 3
+`,
+			want: []string{"modified method T.Get", "modified signature of function Parse", "added method T.Set", "added interface Reader", "removed function old"},
+		},
+		{
+			name: "GoRenamedParam",
+			path: "a.go",
+			old:  "package a\n\nfunc F(a int) {}\n",
+			new:  "package a\n\nfunc F(b int) {}\n",
+			want: []string{"modified function F"},
+		},
+		{
+			name: "GoUnparsable",
+			path: "a.go",
+			old:  "package a\n\nfunc F() {\n}\n\nfunc (t *T) M() {}\n",
+			new:  "package a\n\nfunc F() {\n\tif {\n}\n\nfunc (t *T) M(x int) {}\n",
+			want: []string{"modified function F", "modified signature of method T.M"},
+		},
+		{
+			name: "NewFile",
+			path: "x.py",
+			new:  "class Parser:\n    def parse(self):\n        pass\n\ndef main():\n    pass\n",
+			want: []string{"added class Parser", "added method Parser.parse", "added function main"},
+		},
+		{
+			name: "Python",
+			path: "x.py",
+			old:  "class Parser:\n    def parse(self):\n        return 1\n\n    def reset(self):\n        pass\n",
+			new:  "class Parser:\n    def parse(self, strict):\n        return 1\n\n    def reset(self):\n        self.x = 0\n",
+			want: []string{"modified signature of method Parser.parse", "modified method Parser.reset"},
+		},
+		{
+			name: "TypeScript",
+			path: "src/api.ts",
+			old:  "export interface Task {\n  id: string;\n}\n\nexport function load(id: string) {\n  return fetch(id);\n}\n",
+			new:  "export interface Task {\n  id: string;\n  title: string;\n}\n\nexport type ID = string;\n\nexport const save = async (t: Task) => {\n  return t;\n};\n",
+			want: []string{"modified interface Task", "added type ID", "added function save", "removed function load"},
+		},
+		{
+			name: "Rust",
+			path: "src/lib.rs",
+			old:  "pub struct Config {}\n\nimpl Config {\n    pub fn new() -> Self { Config {} }\n}\n",
+			new:  "pub struct Config {}\n\nimpl Config {\n    pub fn new(path: &str) -> Self { Config {} }\n}\n\nfn helper() {}\n",
+			want: []string{"modified signature of method Config.new", "added function helper"},
+		},
+		{
+			name: "Unsupported",
+			path: "README.md",
+			old:  "a",
+			new:  "b",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := strs(Summarize(tc.path, []byte(tc.old), []byte(tc.new)))
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got  %q\nwant %q", got, tc.want)
+			}
+		})
+	}
+}
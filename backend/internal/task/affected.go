@@ -0,0 +1,229 @@
+// Build targets affected by a task's diff, from the Go package graph or a
+// Bazel query run inside the container.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+// AffectedTool selects how the affected build targets are computed.
+type AffectedTool string
+
+// Affected target tools.
+const (
+	AffectedGo    AffectedTool = "go"    // Go packages depending on the changed ones, per go list.
+	AffectedBazel AffectedTool = "bazel" // Reverse dependencies of the changed files, per bazel query.
+)
+
+// AffectedTargets are the build targets a task's diff may break: the ones
+// whose tests are worth running and the blast radius of the change.
+type AffectedTargets struct {
+	Tool      AffectedTool
+	Targets   []string // Go import paths or Bazel labels, sorted.
+	Truncated bool     // More than maxAffectedTargets were affected.
+	At        time.Time
+}
+
+// Export returns a shell statement exporting the targets as
+// CAIC_AFFECTED_TARGETS, space separated, to prefix a verification command.
+// It returns "" when truncated, so the command falls back to checking
+// everything.
+func (a *AffectedTargets) Export() string {
+	if a.Truncated {
+		return ""
+	}
+	return "export CAIC_AFFECTED_TARGETS=" + shellQuote(strings.Join(a.Targets, " ")) + "; "
+}
+
+const (
+	// maxAffectedTargets bounds the targets recorded.
+	maxAffectedTargets = 500
+	// affectedTimeout bounds a single computation; cold bazel servers are
+	// slow to start.
+	affectedTimeout = 5 * time.Minute
+	// bazelPartialExit is the exit status of a bazel query with
+	// --keep_going that skipped some targets, e.g. deleted files.
+	bazelPartialExit = 3
+)
+
+// goListFormat prints one line per package: import path, directory, then
+// the dependencies of the package and of its tests.
+const goListFormat = `{{.ImportPath}}{{"\t"}}{{.Dir}}{{"\t"}}{{join .Deps " "}} {{join .TestImports " "}} {{join .XTestImports " "}}`
+
+// ComputeAffected returns the targets affected by the files of ds in the
+// checkout of gitRoot inside container, according to tool.
+func ComputeAffected(ctx context.Context, container, gitRoot string, tool AffectedTool, ds agent.DiffStat) (*AffectedTargets, error) {
+	paths := make([]string, 0, len(ds))
+	for _, f := range ds {
+		paths = append(paths, f.Path)
+	}
+	var targets []string
+	if len(paths) != 0 {
+		var err error
+		switch tool {
+		case AffectedGo:
+			var out string
+			// The login profile is sourced so go is in the PATH.
+			if out, err = RunInCheckout(ctx, container, gitRoot, ". ~/.profile >/dev/null 2>&1; pwd && go list -e -f '"+goListFormat+"' ./... 2>/dev/null"); err == nil {
+				targets = affectedGoPackages(out, paths)
+			}
+		case AffectedBazel:
+			quoted := make([]string, len(paths))
+			for i, p := range paths {
+				quoted[i] = `"` + p + `"`
+			}
+			q := "rdeps(//..., set(" + strings.Join(quoted, " ") + "))"
+			var out string
+			out, err = RunInCheckout(ctx, container, gitRoot, ". ~/.profile >/dev/null 2>&1; bazel query --keep_going --noshow_progress --output=label "+shellQuote(q)+" 2>/dev/null")
+			if ee := (*sshconn.ExitError)(nil); errors.As(err, &ee) && ee.Code == bazelPartialExit {
+				err = nil
+			}
+			if err == nil {
+				targets = bazelLabels(out)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported affected targets tool %q", tool)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tool, err)
+		}
+	}
+	a := &AffectedTargets{Tool: tool, Targets: targets, At: time.Now().UTC()}
+	if len(a.Targets) > maxAffectedTargets {
+		a.Targets, a.Truncated = a.Targets[:maxAffectedTargets], true
+	}
+	return a, nil
+}
+
+// affectedGoPackages parses the output of "pwd && go list -f goListFormat"
+// and returns the packages containing one of paths, relative to the working
+// directory, or depending on one, themselves or through their tests. A
+// changed go.mod or go.sum affects every package.
+func affectedGoPackages(out string, paths []string) []string {
+	root, out, _ := strings.Cut(out, "\n")
+	root = strings.TrimSpace(root)
+	type pkg struct {
+		importPath string
+		deps       []string
+	}
+	byDir := map[string]pkg{}
+	for line := range strings.SplitSeq(out, "\n") {
+		f := strings.SplitN(line, "\t", 3)
+		if len(f) != 3 {
+			continue
+		}
+		dir := "."
+		if f[1] != root {
+			rel, ok := strings.CutPrefix(f[1], root+"/")
+			if !ok {
+				// Outside of the checkout, e.g. the standard library.
+				continue
+			}
+			dir = rel
+		}
+		byDir[dir] = pkg{importPath: f[0], deps: strings.Fields(f[2])}
+	}
+	changed := map[string]bool{}
+	for _, p := range paths {
+		if p == "go.mod" || p == "go.sum" {
+			for _, pk := range byDir {
+				changed[pk.importPath] = true
+			}
+			break
+		}
+		// Files of a package's subdirectories, e.g. testdata, belong to it.
+		for d := path.Dir(p); ; d = path.Dir(d) {
+			if pk, ok := byDir[d]; ok {
+				changed[pk.importPath] = true
+				break
+			}
+			if d == "." || d == "/" {
+				break
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	var targets []string
+	for _, pk := range byDir {
+		if changed[pk.importPath] || slices.ContainsFunc(pk.deps, func(d string) bool { return changed[d] }) {
+			targets = append(targets, pk.importPath)
+		}
+	}
+	slices.Sort(targets)
+	return slices.Compact(targets)
+}
+
+// bazelLabels returns the sorted labels output by bazel query
+// --output=label, ignoring any other line.
+func bazelLabels(out string) []string {
+	var labels []string
+	for line := range strings.SplitSeq(out, "\n") {
+		if line = strings.TrimSpace(line); strings.HasPrefix(line, "//") || strings.HasPrefix(line, "@") {
+			labels = append(labels, line)
+		}
+	}
+	slices.Sort(labels)
+	return slices.Compact(labels)
+}
+
+// AffectedTargets returns the targets affected by the last diff stat, or nil
+// before they are first computed.
+func (t *Task) AffectedTargets() *AffectedTargets {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.affected
+}
+
+// updateAffected recomputes in the background the targets affected by ds,
+// the diff stat of the task's branch, with the tool of the repo. One
+// computation runs at a time per task; the updates arriving meanwhile are
+// coalesced into the next one.
+func (r *Runner) updateAffected(ctx context.Context, t *Task, container string, ds agent.DiffStat) {
+	tool := r.Git.AffectedTargets
+	if tool == "" || container == "" {
+		return
+	}
+	t.mu.Lock()
+	t.affectedNext, t.affectedQueued = ds, true
+	if t.affectedRunning {
+		t.mu.Unlock()
+		return
+	}
+	t.affectedRunning = true
+	t.mu.Unlock()
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		for {
+			t.mu.Lock()
+			if !t.affectedQueued {
+				t.affectedRunning = false
+				t.mu.Unlock()
+				return
+			}
+			next := t.affectedNext
+			t.affectedQueued = false
+			t.mu.Unlock()
+			actx, cancel := context.WithTimeout(ctx, affectedTimeout)
+			a, err := ComputeAffected(actx, container, r.Dir, tool, next)
+			cancel()
+			if err != nil {
+				r.log.Warn("affected targets", "ctr", container, "err", err)
+				continue
+			}
+			t.mu.Lock()
+			t.affected = a
+			t.mu.Unlock()
+		}
+	}()
+}
@@ -0,0 +1,51 @@
+package task
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAffectedGoPackages(t *testing.T) {
+	out := "/home/user/src/repo\n" +
+		"example.com/m\t/home/user/src/repo\tfmt example.com/m/util \n" +
+		"example.com/m/util\t/home/user/src/repo/util\tstrings \n" +
+		"example.com/m/api\t/home/user/src/repo/api\tnet/http  example.com/m/util\n" +
+		"example.com/m/cmd/tool\t/home/user/src/repo/cmd/tool\tos example.com/m/api \n" +
+		"example.com/m/other\t/home/user/src/repo/other\tos \n" +
+		"go: warning: ignoring symlink\n"
+	for _, tc := range []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{"Dependents", []string{"util/strings.go"}, []string{"example.com/m", "example.com/m/api", "example.com/m/util"}},
+		{"TestImport", []string{"api/testdata/golden.json"}, []string{"example.com/m/api", "example.com/m/cmd/tool"}},
+		{"Module", []string{"README.md", "go.sum"}, []string{"example.com/m", "example.com/m/api", "example.com/m/cmd/tool", "example.com/m/other", "example.com/m/util"}},
+		{"RootFile", []string{"README.md"}, []string{"example.com/m"}},
+		{"NoPackage", []string{"/abs/x.go"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := affectedGoPackages(out, tc.paths); !slices.Equal(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBazelLabels(t *testing.T) {
+	out := "Loading: 0 packages loaded\n//pkg:lib\n@rules_go//go:stdlib\n//app:app_test\n//pkg:lib\n"
+	if got, want := bazelLabels(out), []string{"//app:app_test", "//pkg:lib", "@rules_go//go:stdlib"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAffectedTargetsExport(t *testing.T) {
+	a := &AffectedTargets{Tool: AffectedBazel, Targets: []string{"//a:b", "//c:d"}}
+	if got, want := a.Export(), "export CAIC_AFFECTED_TARGETS='//a:b //c:d'; "; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	a.Truncated = true
+	if got := a.Export(); got != "" {
+		t.Errorf("truncated: got %q", got)
+	}
+}
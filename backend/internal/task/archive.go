@@ -0,0 +1,34 @@
+// Tarball of the files a task changed, streamed from its container.
+package task
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+// archiveScript lists the files modified or added since the base branch,
+// committed or not, and untracked files not ignored, then archives their
+// current content. Deleted files are omitted. It does not touch the index.
+const archiveScript = `{ git diff -z --name-only --no-renames --diff-filter=d base -- . && ` +
+	`git ls-files -z --others --exclude-standard; } | tar -czf - --null --no-recursion -T -`
+
+// WriteArchive streams a gzipped tarball of the files changed by the task in
+// the clone of gitRoot inside container to w. Paths are relative to the
+// repository root.
+func WriteArchive(ctx context.Context, container, gitRoot string, w io.Writer) error {
+	script := "cd ~/src/" + shellQuote(filepath.Base(gitRoot)) + " && " + archiveScript
+	cmd := sshconn.Command(ctx, container, script)
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("archive: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
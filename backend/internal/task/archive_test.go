@@ -0,0 +1,73 @@
+package task
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestArchiveScript(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	runGit(t, clone, "branch", "base")
+	write := func(name, content string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(clone, name)), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(clone, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("committed.txt", "c\n")
+	write("gone.txt", "g\n")
+	runGit(t, clone, "add", ".")
+	runGit(t, clone, "commit", "-q", "-m", "work")
+	runGit(t, clone, "rm", "-q", "gone.txt")
+	write("README.md", "modified\n")
+	write("dir/new.txt", "n\n")
+	write(".gitignore", "*.log\n")
+	write("ignored.log", "x\n")
+
+	cmd := exec.Command("sh", "-c", archiveScript)
+	cmd.Dir = clone
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := gzip.NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	got := map[string]string{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(tr)
+		got[h.Name] = string(b)
+	}
+	var names []string
+	for n := range got {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	want := []string{".gitignore", "README.md", "committed.txt", "dir/new.txt"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("files = %v, want %v", names, want)
+	}
+	if got["README.md"] != "modified\n" {
+		t.Errorf("README.md = %q", got["README.md"])
+	}
+}
@@ -0,0 +1,72 @@
+// Policy applied to questions left unanswered.
+package task
+
+import (
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+// AskAction is what happens to a question left unanswered for
+// AskPolicy.Timeout.
+type AskAction string
+
+// Ask actions.
+const (
+	AskAnswer AskAction = "answer" // Reply with AskPolicy.Answer.
+	AskNotify AskAction = "notify" // Emit an ask_timeout event.
+	AskPause  AskAction = "pause"  // Pause the session, stopping the agent.
+)
+
+// DefaultAskAnswer is the reply of AskAnswer when AskPolicy.Answer is empty.
+const DefaultAskAnswer = "Use your best judgment."
+
+// PlanApproval is the reply approving a plan when nobody is around to
+// review it.
+const PlanApproval = "The plan is approved. Go ahead."
+
+// Automation selects whether the task may proceed without a human when it
+// asks a question or proposes a plan.
+type Automation string
+
+// Automation modes.
+const (
+	AutomationSchedule    Automation = ""            // Follows the working hours of the repository.
+	AutomationInteractive Automation = "interactive" // Always waits for a human.
+	AutomationUnattended  Automation = "unattended"  // Always proceeds on its own.
+)
+
+// AskPolicy decides what happens when the task sits in StateAsking with no
+// response, so that a forgotten question doesn't keep the container busy.
+type AskPolicy struct {
+	Timeout time.Duration
+	Action  AskAction
+	Answer  string // Only used by AskAnswer; defaults to DefaultAskAnswer.
+}
+
+// Reply returns the answer sent by AskAnswer.
+func (p *AskPolicy) Reply() string {
+	if p.Answer == "" {
+		return DefaultAskAnswer
+	}
+	return p.Answer
+}
+
+// Due reports whether a question asked at since is past the timeout at now.
+func (p *AskPolicy) Due(since, now time.Time) bool {
+	return !since.IsZero() && now.Sub(since) >= p.Timeout
+}
+
+func (p *AskPolicy) toMeta() *agent.MetaAskPolicy {
+	if p == nil {
+		return nil
+	}
+	return &agent.MetaAskPolicy{Timeout: p.Timeout.Seconds(), Action: string(p.Action), Answer: p.Answer}
+}
+
+func askPolicyFromMeta(m *agent.MetaAskPolicy) *AskPolicy {
+	if m == nil {
+		return nil
+	}
+	return &AskPolicy{Timeout: time.Duration(m.Timeout * float64(time.Second)), Action: AskAction(m.Action), Answer: m.Answer}
+}
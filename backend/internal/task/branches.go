@@ -0,0 +1,109 @@
+// Listing and pruning of the task branches pushed to origin.
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+// RemoteBranch is a task branch on origin.
+type RemoteBranch struct {
+	Name       string    // e.g. "caic-3".
+	CommitTime time.Time // Committer date of its head.
+	Merged     bool      // Its head is reachable from origin's default branch.
+}
+
+// IsTaskBranch reports whether name has the form of the branches allocated
+// for tasks, "caic-N".
+func IsTaskBranch(name string) bool {
+	n, ok := strings.CutPrefix(name, "caic-")
+	if !ok || n == "" || strings.TrimLeft(n, "0123456789") != "" {
+		return false
+	}
+	_, err := strconv.Atoi(n)
+	return err == nil
+}
+
+// RemoteBranches lists the task branches on origin as of the last fetch,
+// oldest first.
+func (r *Runner) RemoteBranches(ctx context.Context) ([]RemoteBranch, error) {
+	r.initDefaults()
+	if r.Dir == "" {
+		return nil, errors.New("no repository")
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.Git.BranchTimeout)
+	defer cancel()
+	const pattern = "refs/remotes/origin/caic-*"
+	out, err := gitutil.RunGit(ctx, r.Dir, "for-each-ref", "--format=%(refname:lstrip=3) %(committerdate:unix)", pattern)
+	if err != nil {
+		return nil, fmt.Errorf("list branches: %w", err)
+	}
+	var bs []RemoteBranch
+	for line := range strings.SplitSeq(strings.TrimSpace(out), "\n") {
+		name, ts, _ := strings.Cut(line, " ")
+		if !IsTaskBranch(name) {
+			continue
+		}
+		sec, _ := strconv.ParseInt(ts, 10, 64)
+		bs = append(bs, RemoteBranch{Name: name, CommitTime: time.Unix(sec, 0).UTC()})
+	}
+	if len(bs) == 0 {
+		return nil, nil
+	}
+	out, err = gitutil.RunGit(ctx, r.Dir, "for-each-ref", "--format=%(refname:lstrip=3)", "--merged="+r.startPoint(ctx, r.BaseBranch), pattern)
+	if err != nil {
+		return nil, fmt.Errorf("list merged branches: %w", err)
+	}
+	merged := strings.Fields(out)
+	for i := range bs {
+		bs[i].Merged = slices.Contains(merged, bs[i].Name)
+	}
+	slices.SortStableFunc(bs, func(a, b RemoteBranch) int { return a.CommitTime.Compare(b.CommitTime) })
+	return bs, nil
+}
+
+// DeleteRemoteBranch deletes the task branch name from origin.
+func (r *Runner) DeleteRemoteBranch(ctx context.Context, name string) error {
+	r.initDefaults()
+	if !IsTaskBranch(name) {
+		return fmt.Errorf("not a task branch: %q", name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.GitTimeout)
+	defer cancel()
+	if _, err := gitutil.RunGit(ctx, r.Dir, "push", "origin", "--delete", name); err != nil {
+		return fmt.Errorf("delete %s: %w", name, err)
+	}
+	return nil
+}
+
+// PruneCandidates returns the branches of bs that can be deleted, merged ones
+// first, then oldest first: those inUse rejects that are merged or whose head
+// is older than retention. A zero retention only selects merged branches.
+func PruneCandidates(bs []RemoteBranch, inUse func(name string) bool, retention time.Duration, now time.Time) []RemoteBranch {
+	var out []RemoteBranch
+	for _, b := range bs {
+		if inUse(b.Name) {
+			continue
+		}
+		if b.Merged || (retention > 0 && now.Sub(b.CommitTime) > retention) {
+			out = append(out, b)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b RemoteBranch) int {
+		if a.Merged != b.Merged {
+			if a.Merged {
+				return -1
+			}
+			return 1
+		}
+		return a.CommitTime.Compare(b.CommitTime)
+	})
+	return out
+}
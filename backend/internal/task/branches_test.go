@@ -0,0 +1,72 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsTaskBranch(t *testing.T) {
+	for name, want := range map[string]bool{
+		"caic-0": true, "caic-42": true,
+		"caic-": false, "caic-x": false, "caic-+1": false, "caic-1/wip": false, "main": false,
+	} {
+		if got := IsTaskBranch(name); got != want {
+			t.Errorf("IsTaskBranch(%q) = %v", name, got)
+		}
+	}
+}
+
+func TestRemoteBranches(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	runGit(t, clone, "push", "origin", "main:caic-1")
+	runGit(t, clone, "commit", "--allow-empty", "-m", "wip")
+	runGit(t, clone, "push", "origin", "HEAD:caic-2", "HEAD:feature")
+	runGit(t, clone, "fetch", "origin")
+	r := &Runner{BaseBranch: "main", Dir: clone}
+	bs, err := r.RemoteBranches(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bs) != 2 {
+		t.Fatalf("got %+v", bs)
+	}
+	for _, b := range bs {
+		if b.Merged != (b.Name == "caic-1") {
+			t.Errorf("%s: merged = %v", b.Name, b.Merged)
+		}
+	}
+	if err := r.DeleteRemoteBranch(t.Context(), "caic-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteRemoteBranch(t.Context(), "feature"); err == nil {
+		t.Error("deleted a non-task branch")
+	}
+	if bs, err = r.RemoteBranches(t.Context()); err != nil || len(bs) != 1 || bs[0].Name != "caic-2" {
+		t.Errorf("got %+v, %v", bs, err)
+	}
+}
+
+func TestPruneCandidates(t *testing.T) {
+	now := time.Now()
+	bs := []RemoteBranch{
+		{Name: "caic-1", CommitTime: now.Add(-90 * 24 * time.Hour)},
+		{Name: "caic-2", CommitTime: now.Add(-60 * 24 * time.Hour)},
+		{Name: "caic-3", CommitTime: now.Add(-50 * 24 * time.Hour), Merged: true},
+		{Name: "caic-4", CommitTime: now.Add(-40 * 24 * time.Hour), Merged: true},
+		{Name: "caic-5", CommitTime: now.Add(-time.Hour)},
+	}
+	inUse := func(name string) bool { return name == "caic-2" || name == "caic-4" }
+	names := func(bs []RemoteBranch) []string {
+		var out []string
+		for _, b := range bs {
+			out = append(out, b.Name)
+		}
+		return out
+	}
+	if got := names(PruneCandidates(bs, inUse, 0, now)); len(got) != 1 || got[0] != "caic-3" {
+		t.Errorf("no retention: %v", got)
+	}
+	if got := names(PruneCandidates(bs, inUse, 30*24*time.Hour, now)); len(got) != 2 || got[0] != "caic-3" || got[1] != "caic-1" {
+		t.Errorf("30 days: %v", got)
+	}
+}
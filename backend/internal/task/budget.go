@@ -0,0 +1,154 @@
+// Token budget of the material injected in prompts.
+package task
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+// Tokenizer counts the tokens of text as a model would.
+type Tokenizer interface {
+	Count(s string) int
+}
+
+// estimator approximates a model's tokenizer: ASCII text averages a number of
+// bytes per token that depends on the vocabulary, while other scripts take
+// about a token per character. The estimate errs on the high side so that
+// assembled prompts fit.
+type estimator struct {
+	bytesPerToken float64
+}
+
+func (e estimator) Count(s string) int {
+	ascii, other := 0, 0
+	for i := 0; i < len(s); {
+		if s[i] < utf8.RuneSelf {
+			ascii++
+			i++
+			continue
+		}
+		_, n := utf8.DecodeRuneInString(s[i:])
+		other++
+		i += n
+	}
+	return int(math.Ceil(float64(ascii)/e.bytesPerToken)) + other
+}
+
+// TokenizerFor returns the tokenizer of model, falling back to the harness'
+// usual models when the model is unknown or empty.
+func TokenizerFor(h agent.Harness, model string) Tokenizer {
+	m := strings.ToLower(model)
+	switch {
+	case strings.Contains(m, "claude"), strings.Contains(m, "opus"), strings.Contains(m, "sonnet"), strings.Contains(m, "haiku"):
+		return estimator{bytesPerToken: 3.5}
+	case strings.HasPrefix(m, "gpt"), strings.HasPrefix(m, "o1"), strings.HasPrefix(m, "o3"), strings.HasPrefix(m, "o4"), strings.Contains(m, "codex"):
+		return estimator{bytesPerToken: 4}
+	case strings.Contains(m, "gemini"):
+		return estimator{bytesPerToken: 4}
+	}
+	switch h {
+	case agent.Codex, agent.Gemini:
+		return estimator{bytesPerToken: 4}
+	case agent.Claude, agent.Kilo:
+		return estimator{bytesPerToken: 3.5}
+	default:
+		return estimator{bytesPerToken: 3}
+	}
+}
+
+// BudgetPart is a piece of material competing for the token budget.
+type BudgetPart struct {
+	Name     string // Reported when cut, e.g. "file:docs/design.md".
+	Priority int    // Higher is kept first; ties keep the earlier part.
+	Text     string
+}
+
+// BudgetCut reports a part that didn't fit entirely.
+type BudgetCut struct {
+	Name   string
+	Tokens int // Tokens of the whole part.
+	Kept   int // Tokens kept when truncated; 0 when dropped.
+}
+
+// BudgetReport is the outcome of FitBudget.
+type BudgetReport struct {
+	Budget int
+	Used   int
+	Cuts   []BudgetCut // In the order of the parts.
+}
+
+// minTruncatedTokens is the smallest useful remainder of a truncated part; a
+// part that would keep fewer tokens is dropped instead.
+const minTruncatedTokens = 200
+
+const truncatedMarker = "\n[truncated]"
+
+// FitBudget selects the parts to keep within budget tokens. Parts are kept
+// whole by decreasing priority as long as they fit; then the rest of the
+// budget goes, in the same order, to truncating the parts that didn't fit.
+// A part is dropped when too little budget is left. It returns the text kept
+// of each part, empty when dropped. The outcome only depends on its inputs.
+func FitBudget(parts []BudgetPart, budget int, tok Tokenizer) ([]string, BudgetReport) {
+	order := make([]int, len(parts))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return parts[b].Priority - parts[a].Priority })
+	out := make([]string, len(parts))
+	cuts := make([]*BudgetCut, len(parts))
+	rep := BudgetReport{Budget: budget}
+	for _, i := range order {
+		if n := tok.Count(parts[i].Text); n <= budget-rep.Used {
+			out[i] = parts[i].Text
+			rep.Used += n
+		} else {
+			cuts[i] = &BudgetCut{Name: parts[i].Name, Tokens: n}
+		}
+	}
+	for _, i := range order {
+		if cuts[i] == nil {
+			continue
+		}
+		p := parts[i]
+		if keep := budget - rep.Used - tok.Count(truncatedMarker); keep >= minTruncatedTokens {
+			out[i] = truncateTokens(p.Text, keep, tok) + truncatedMarker
+			cuts[i].Kept = tok.Count(out[i])
+			rep.Used += cuts[i].Kept
+		}
+	}
+	for _, c := range cuts {
+		if c != nil {
+			rep.Cuts = append(rep.Cuts, *c)
+		}
+	}
+	return out, rep
+}
+
+// truncateTokens returns the longest prefix of s of at most n tokens, cut at
+// a line boundary when one is close enough.
+func truncateTokens(s string, n int, tok Tokenizer) string {
+	// Binary search the number of runes to keep.
+	offs := make([]int, 0, len(s)+1)
+	for i := range s {
+		offs = append(offs, i)
+	}
+	offs = append(offs, len(s))
+	lo, hi := 0, len(offs)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if tok.Count(s[:offs[mid]]) <= n {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	s = s[:offs[lo]]
+	if i := strings.LastIndexByte(s, '\n'); i > len(s)/2 {
+		s = s[:i]
+	}
+	return s
+}
@@ -0,0 +1,63 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestTokenizer(t *testing.T) {
+	claude := TokenizerFor(agent.Codex, "claude-sonnet-4")
+	codex := TokenizerFor(agent.Codex, "")
+	s := strings.Repeat("a", 700)
+	if got := claude.Count(s); got != 200 {
+		t.Errorf("claude Count() = %d", got)
+	}
+	if got := codex.Count(s); got != 175 {
+		t.Errorf("codex Count() = %d", got)
+	}
+	if got := codex.Count("日本語"); got != 3 {
+		t.Errorf("Count(日本語) = %d", got)
+	}
+	if got := codex.Count(""); got != 0 {
+		t.Errorf("Count(\"\") = %d", got)
+	}
+}
+
+func TestFitBudget(t *testing.T) {
+	tok := estimator{bytesPerToken: 1}
+	parts := []BudgetPart{
+		{Name: "low", Priority: 0, Text: strings.Repeat("l", 500)},
+		{Name: "high", Priority: 2, Text: strings.Repeat("h", 300)},
+		{Name: "mid", Priority: 1, Text: strings.Repeat("m", 500)},
+		{Name: "tiny", Priority: 0, Text: "t"},
+	}
+	got, rep := FitBudget(parts, 600, tok)
+	if got[1] != parts[1].Text || got[3] != "t" {
+		t.Errorf("whole parts not kept: %q, %q", got[1], got[3])
+	}
+	if !strings.HasSuffix(got[2], truncatedMarker) || len(got[2]) > 299 {
+		t.Errorf("mid = %d bytes", len(got[2]))
+	}
+	if got[0] != "" {
+		t.Errorf("low kept %d bytes", len(got[0]))
+	}
+	want := []BudgetCut{{Name: "low", Tokens: 500}, {Name: "mid", Tokens: 500, Kept: len(got[2])}}
+	if len(rep.Cuts) != 2 || rep.Cuts[0] != want[0] || rep.Cuts[1] != want[1] {
+		t.Errorf("Cuts = %+v", rep.Cuts)
+	}
+	if rep.Budget != 600 || rep.Used > 600 {
+		t.Errorf("report = %+v", rep)
+	}
+}
+
+func TestTruncateTokens(t *testing.T) {
+	tok := estimator{bytesPerToken: 1}
+	if got := truncateTokens("héllo wörld", 5, tok); got != "héllo" {
+		t.Errorf("truncateTokens() = %q", got)
+	}
+	if got := truncateTokens("aaaa\nbbbb\ncc", 11, tok); got != "aaaa\nbbbb" {
+		t.Errorf("truncateTokens() = %q", got)
+	}
+}
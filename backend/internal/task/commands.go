@@ -0,0 +1,100 @@
+// Shell command history extracted from a task's conversation.
+package task
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+// Command is a shell command run by the agent.
+type Command struct {
+	MessageIndex int    // Index of the tool use in the task's messages.
+	ToolUseID    string // Identifies the tool call across events.
+	Command      string
+	Cwd          string        // Empty when not reported.
+	Done         bool          // The tool result was received.
+	ExitCode     *int          // Nil when unknown or not done.
+	Duration     time.Duration // Zero when not reported by the harness.
+	Output       string        // Tail of the output; see agent.TailOutput.
+	Error        string        // Set when the tool reported an error.
+}
+
+// shellTools are the tool names harnesses use for shell commands.
+var shellTools = map[string]bool{
+	"Bash":              true, // Claude; Codex commandExecution is mapped to it.
+	"bash":              true, // Kilo.
+	"run_shell_command": true, // Gemini.
+	"shell":             true,
+}
+
+// exitCodeRe matches the exit code Claude Code reports in failed Bash
+// results.
+var exitCodeRe = regexp.MustCompile(`^Exit code (\d+)`)
+
+// ExtractCommands returns the shell commands found in msgs, in order.
+func ExtractCommands(msgs []agent.Message) []Command {
+	var out []Command
+	byID := map[string]int{}
+	for i, m := range msgs {
+		switch v := m.(type) {
+		case *agent.ToolUseMessage:
+			if !shellTools[v.Name] {
+				continue
+			}
+			var in struct {
+				Command json.RawMessage `json:"command"`
+				Cwd     string          `json:"cwd"`
+			}
+			if json.Unmarshal(v.Input, &in) != nil {
+				continue
+			}
+			cmd := CommandString(in.Command)
+			if cmd == "" {
+				continue
+			}
+			byID[v.ToolUseID] = len(out)
+			out = append(out, Command{MessageIndex: i, ToolUseID: v.ToolUseID, Command: cmd, Cwd: in.Cwd})
+		case *agent.ToolResultMessage:
+			j, ok := byID[v.ToolUseID]
+			if !ok {
+				continue
+			}
+			c := &out[j]
+			c.Done = true
+			c.Error = v.Error
+			c.Output = v.Output
+			c.Duration = time.Duration(v.DurationMs) * time.Millisecond
+			c.ExitCode = v.ExitCode
+			if c.ExitCode == nil && v.Error != "" {
+				if m := exitCodeRe.FindStringSubmatch(v.Error); m != nil {
+					if n, err := strconv.Atoi(m[1]); err == nil {
+						c.ExitCode = &n
+					}
+				}
+			}
+		}
+	}
+	return out
+}
+
+// CommandString decodes a tool command, which harnesses send either as a
+// string or as an argv array.
+func CommandString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var argv []string
+	if json.Unmarshal(raw, &argv) == nil {
+		return strings.Join(argv, " ")
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package task
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestExtractCommands(t *testing.T) {
+	zero := 0
+	msgs := []agent.Message{
+		&agent.UserInputMessage{Text: "run the tests"},
+		&agent.ToolUseMessage{ToolUseID: "t1", Name: "Bash", Input: json.RawMessage(`{"command":"go test ./...","cwd":"/src"}`)},
+		&agent.ToolUseMessage{ToolUseID: "t2", Name: "Read", Input: json.RawMessage(`{"file_path":"a.go"}`)},
+		&agent.ToolResultMessage{ToolUseID: "t2", Output: "package a"},
+		&agent.ToolResultMessage{ToolUseID: "t1", Error: "Exit code 1\nFAIL", Output: "Exit code 1\nFAIL"},
+		&agent.ToolUseMessage{ToolUseID: "t3", Name: "shell", Input: json.RawMessage(`{"command":["ls","-l"]}`)},
+		&agent.ToolResultMessage{ToolUseID: "t3", ExitCode: &zero, DurationMs: 1500, Output: "total 0"},
+		&agent.ToolUseMessage{ToolUseID: "t4", Name: "Bash", Input: json.RawMessage(`{"command":"sleep 100"}`)},
+	}
+	got := ExtractCommands(msgs)
+	if len(got) != 3 {
+		t.Fatalf("got %+v", got)
+	}
+	c := got[0]
+	if c.MessageIndex != 1 || c.Command != "go test ./..." || c.Cwd != "/src" || !c.Done || c.ExitCode == nil || *c.ExitCode != 1 || c.Error == "" {
+		t.Errorf("got[0] = %+v", c)
+	}
+	c = got[1]
+	if c.Command != "ls -l" || c.ExitCode == nil || *c.ExitCode != 0 || c.Duration != 1500*time.Millisecond || c.Output != "total 0" {
+		t.Errorf("got[1] = %+v", c)
+	}
+	c = got[2]
+	if c.Done || c.ExitCode != nil {
+		t.Errorf("got[2] = %+v", c)
+	}
+}
@@ -0,0 +1,81 @@
+// Commits made on the task branch inside the container.
+package task
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+// Commit is a commit on the task branch that is not on the base branch.
+type Commit struct {
+	SHA         string
+	Author      string
+	CommittedAt time.Time
+	Message     string
+	Files       agent.DiffStat
+}
+
+// commitLogFormat separates commits with RS and fields with US so messages
+// can contain anything but those. The numstat follows the last US.
+const commitLogFormat = "%x1e%H%x1f%ct%x1f%an%x1f%B%x1f"
+
+// ListCommits returns the commits on the branch checked out in the clone of
+// gitRoot inside container since it forked from the base branch, oldest
+// first. Uncommitted changes are not included.
+func ListCommits(ctx context.Context, container, gitRoot string) ([]Commit, error) {
+	script := "cd ~/src/" + shellQuote(filepath.Base(gitRoot)) +
+		" && git log --reverse --no-color --numstat --format=" + commitLogFormat + " base..HEAD"
+	var out []byte
+	err := agent.RetrySSH(ctx, func() error {
+		var err error
+		out, err = sshconn.Command(ctx, container, script).Output()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("git log: %w", err)
+	}
+	return parseCommitLog(string(out)), nil
+}
+
+// parseCommitLog parses the output of git log with commitLogFormat and
+// --numstat.
+func parseCommitLog(out string) []Commit {
+	var commits []Commit
+	for rec := range strings.SplitSeq(out, "\x1e") {
+		f := strings.SplitN(rec, "\x1f", 5)
+		if len(f) != 5 {
+			continue
+		}
+		c := Commit{SHA: f[0], Author: f[2], Message: strings.TrimSpace(f[3]), Files: ParseDiffNumstat(f[4])}
+		if ts, err := strconv.ParseInt(f[1], 10, 64); err == nil {
+			c.CommittedAt = time.Unix(ts, 0).UTC()
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// CommitTurn returns the 1-based turn that produced a commit made at, given
+// the task's completed turns: the first turn that ended at or after it, or
+// the turn following the last completed one. It returns 0 when at is
+// unknown. Turns loaded from logs have estimated end times so the result is
+// best-effort for them.
+func CommitTurn(turns []TurnUsage, at time.Time) int {
+	if at.IsZero() {
+		return 0
+	}
+	for i, tu := range turns {
+		// Commit timestamps have a one second resolution.
+		if !tu.EndedAt.IsZero() && !tu.EndedAt.Add(time.Second).Before(at) {
+			return i + 1
+		}
+	}
+	return len(turns) + 1
+}
@@ -0,0 +1,47 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCommitLog(t *testing.T) {
+	out := "\x1eabc123\x1f1700000000\x1fAgent\x1fAdd foo\n\nDetails.\n\x1f\n\n3\t1\tfoo.go\n-\t-\timg.png\n" +
+		"\x1edef456\x1f1700000100\x1fAgent\x1fFix\x1f\n\n1\t0\tbar.go\n"
+	got := parseCommitLog(out)
+	if len(got) != 2 {
+		t.Fatalf("got %d commits: %+v", len(got), got)
+	}
+	c := got[0]
+	if c.SHA != "abc123" || c.Author != "Agent" || c.Message != "Add foo\n\nDetails." || !c.CommittedAt.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("commit[0] = %+v", c)
+	}
+	if len(c.Files) != 2 || c.Files[0].Path != "foo.go" || c.Files[0].Added != 3 || !c.Files[1].Binary {
+		t.Errorf("commit[0].Files = %+v", c.Files)
+	}
+	if got[1].SHA != "def456" || len(got[1].Files) != 1 {
+		t.Errorf("commit[1] = %+v", got[1])
+	}
+	if got := parseCommitLog(""); got != nil {
+		t.Errorf("empty log = %+v", got)
+	}
+}
+
+func TestCommitTurn(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	turns := []TurnUsage{{EndedAt: t0.Add(time.Minute)}, {EndedAt: t0.Add(3 * time.Minute)}}
+	for _, tc := range []struct {
+		at   time.Time
+		want int
+	}{
+		{t0.Add(30 * time.Second), 1},
+		{t0.Add(time.Minute), 1},
+		{t0.Add(2 * time.Minute), 2},
+		{t0.Add(5 * time.Minute), 3},
+		{time.Time{}, 0},
+	} {
+		if got := CommitTurn(turns, tc.at); got != tc.want {
+			t.Errorf("CommitTurn(%v) = %d, want %d", tc.at, got, tc.want)
+		}
+	}
+}
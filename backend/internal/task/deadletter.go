@@ -0,0 +1,106 @@
+// Dead-letter files of the wire lines the harness parsers dropped.
+package task
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/logcrypt"
+	"github.com/maruel/ksid"
+)
+
+// DeadLetter is a wire line the harness parser could not turn into an event:
+// either it failed to decode or its record type is unknown. Such lines only
+// reach the UI as raw events, if at all.
+type DeadLetter struct {
+	Ts   time.Time `json:"ts"`
+	Type string    `json:"type,omitempty"` // Record type or method; empty when the line didn't decode.
+	Err  string    `json:"err,omitempty"`  // Decoding error; empty for an unknown record type.
+	Line string    `json:"line"`
+}
+
+// DeadLetterPath returns the dead-letter file of task id in dir.
+func DeadLetterPath(dir string, id ksid.ID) string {
+	return filepath.Join(dir, id.String()+".jsonl")
+}
+
+// ReadDeadLetters returns the dead letters of task id in dir, oldest first. A
+// missing file means none.
+func ReadDeadLetters(dir string, id ksid.ID) ([]DeadLetter, error) {
+	f, err := os.Open(DeadLetterPath(dir, id)) //nolint:gosec // name is derived from ksid, not arbitrary user input.
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	// Like the task logs the lines come from.
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLogLine)
+	var out []DeadLetter
+	for scanner.Scan() {
+		line, err := logcrypt.Open(scanner.Bytes())
+		if err != nil {
+			return out, err
+		}
+		var d DeadLetter
+		if err := json.Unmarshal(line, &d); err != nil {
+			return out, err
+		}
+		out = append(out, d)
+	}
+	return out, scanner.Err()
+}
+
+// newDeadLetter returns the dead letter for m, or nil if m is not a dropped
+// line.
+func newDeadLetter(m agent.Message, now time.Time) *DeadLetter {
+	switch m := m.(type) {
+	case *agent.ParseErrorMessage:
+		return &DeadLetter{Ts: now, Err: m.Err, Line: m.Line}
+	case *agent.RawMessage:
+		if m.Unknown {
+			return &DeadLetter{Ts: now, Type: m.MessageType, Line: string(m.Raw)}
+		}
+	}
+	return nil
+}
+
+// deadLetterWriter appends dead letters to a file, created on first use so
+// that well behaved sessions leave no file behind.
+type deadLetterWriter struct {
+	path string
+	f    *os.File
+}
+
+func (w *deadLetterWriter) write(d *DeadLetter) error {
+	if w.f == nil {
+		if err := os.MkdirAll(filepath.Dir(w.path), 0o750); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return err
+		}
+		w.f = f
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	_, err = w.f.Write(append(logcrypt.Seal(data), '\n'))
+	return err
+}
+
+func (w *deadLetterWriter) close() {
+	if w.f != nil {
+		_ = w.f.Close()
+	}
+}
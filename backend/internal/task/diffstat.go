@@ -1,6 +1,12 @@
 package task
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
 	"strconv"
 	"strings"
 
@@ -12,16 +18,52 @@ import (
 // Binary files use "-\t-\t<path>".
 // Returns nil if there are no changed files.
 func ParseDiffNumstat(numstat string) agent.DiffStat {
-	numstat = strings.TrimSpace(numstat)
-	if numstat == "" {
-		return nil
+	ds, _ := parseDiff(numstat)
+	return ds
+}
+
+// parseDiff parses git diff --raw --no-abbrev --numstat output into a
+// DiffStat, with the submodule bumps from the raw lines. It also returns the
+// blob of each regular file, the new one or the old one when deleted, keyed
+// by path. Renames and copies are left out.
+func parseDiff(out string) (agent.DiffStat, map[string]string) {
+	out = strings.TrimSpace(out)
+	if out == "" {
+		return nil, nil
 	}
 	var files agent.DiffStat
-	for line := range strings.SplitSeq(numstat, "\n") {
+	blobs := map[string]string{}
+	submodules := map[string]agent.SubmoduleBump{}
+	for line := range strings.SplitSeq(out, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
+		if raw, ok := strings.CutPrefix(line, ":"); ok {
+			// :<old mode> <new mode> <old blob> <new blob> <status>\t<path>
+			meta, p, _ := strings.Cut(raw, "\t")
+			f := strings.Fields(meta)
+			if len(f) != 5 || strings.ContainsAny(f[4], "RC") {
+				continue
+			}
+			// An all zero blob is a missing side.
+			oldBlob, newBlob := f[2], f[3]
+			if strings.Trim(oldBlob, "0") == "" {
+				oldBlob = ""
+			}
+			if strings.Trim(newBlob, "0") == "" {
+				newBlob = ""
+			}
+			switch {
+			case f[0] == gitlinkMode || f[1] == gitlinkMode:
+				submodules[p] = agent.SubmoduleBump{From: oldBlob, To: newBlob}
+			case newBlob != "":
+				blobs[p] = newBlob
+			case oldBlob != "":
+				blobs[p] = oldBlob
+			}
+			continue
+		}
 		parts := strings.SplitN(line, "\t", 3)
 		if len(parts) != 3 {
 			continue
@@ -33,7 +75,89 @@ func ParseDiffNumstat(numstat string) agent.DiffStat {
 			fs.Added, _ = strconv.Atoi(parts[0])
 			fs.Deleted, _ = strconv.Atoi(parts[1])
 		}
+		if b, ok := submodules[fs.Path]; ok {
+			// The counts are of the "Subproject commit" lines.
+			fs.Added, fs.Deleted, fs.Submodule = 0, 0, b
+		}
 		files = append(files, fs)
 	}
-	return files
+	return files, blobs
+}
+
+// gitlinkMode is the mode of submodule entries in git trees.
+const gitlinkMode = "160000"
+
+// lfsPointerPrefix starts the content of Git LFS pointer files.
+const lfsPointerPrefix = "version https://git-lfs.github.com/spec/"
+
+// lfsPointerMaxLines is the number of lines of a Git LFS pointer file.
+const lfsPointerMaxLines = 3
+
+// markLFS sets LFS on the files of ds whose blob, looked up in the repository
+// at dir, is a Git LFS pointer. Blobs missing from dir are skipped.
+func markLFS(ctx context.Context, dir string, ds agent.DiffStat, blobs map[string]string) error {
+	var paths, objs []string
+	for _, f := range ds {
+		if b := blobs[f.Path]; b != "" && !f.Binary && f.Added <= lfsPointerMaxLines && f.Deleted <= lfsPointerMaxLines {
+			paths = append(paths, f.Path)
+			objs = append(objs, b)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	contents, err := catFiles(ctx, dir, objs)
+	if err != nil {
+		return err
+	}
+	lfs := map[string]bool{}
+	for i, p := range paths {
+		lfs[p] = bytes.HasPrefix(contents[i], []byte(lfsPointerPrefix))
+	}
+	for i := range ds {
+		ds[i].LFS = lfs[ds[i].Path]
+	}
+	return nil
+}
+
+// catFiles returns the content of objs, object names like a blob SHA or
+// "rev:path", in the repository at dir. Missing objects are nil.
+func catFiles(ctx context.Context, dir string, objs []string) ([][]byte, error) {
+	var in strings.Builder
+	for _, o := range objs {
+		if strings.ContainsAny(o, "\n") {
+			return nil, fmt.Errorf("invalid object name %q", o)
+		}
+		in.WriteString(o + "\n")
+	}
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(in.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git cat-file: %w", err)
+	}
+	// Each object is "<sha> <type> <size>\n<content>\n", or "<name> missing\n".
+	r := bufio.NewReader(bytes.NewReader(out))
+	contents := make([][]byte, len(objs))
+	for i := range objs {
+		header, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		f := strings.Fields(header)
+		if len(f) != 3 || strings.HasSuffix(header, " missing\n") {
+			continue
+		}
+		size, err := strconv.Atoi(f[2])
+		if err != nil {
+			return nil, fmt.Errorf("git cat-file: %q", header)
+		}
+		content := make([]byte, size+1)
+		if _, err := io.ReadFull(r, content); err != nil {
+			return nil, fmt.Errorf("git cat-file: %w", err)
+		}
+		contents[i] = content[:size]
+	}
+	return contents, nil
 }
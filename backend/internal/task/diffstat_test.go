@@ -1,9 +1,13 @@
 package task
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/md/gitutil"
 )
 
 func TestParseDiffNumstat(t *testing.T) {
@@ -48,6 +52,30 @@ func TestParseDiffNumstat(t *testing.T) {
 		}
 	})
 
+	t.Run("Raw", func(t *testing.T) {
+		const (
+			a = "1111111111111111111111111111111111111111"
+			b = "2222222222222222222222222222222222222222"
+			z = "0000000000000000000000000000000000000000"
+		)
+		input := ":100644 100644 " + a + " " + b + " M\tmain.go\n" +
+			":160000 160000 " + a + " " + b + " M\tvendor/lib\n" +
+			":100644 000000 " + a + " " + z + " D\told.go\n" +
+			"3\t1\tmain.go\n1\t1\tvendor/lib\n0\t9\told.go\n"
+		ds, blobs := parseDiff(input)
+		want := agent.DiffStat{
+			{Path: "main.go", Added: 3, Deleted: 1},
+			{Path: "vendor/lib", Submodule: agent.SubmoduleBump{From: a, To: b}},
+			{Path: "old.go", Deleted: 9},
+		}
+		if !slices.Equal(ds, want) {
+			t.Errorf("got %+v, want %+v", ds, want)
+		}
+		if len(blobs) != 2 || blobs["main.go"] != b || blobs["old.go"] != a {
+			t.Errorf("blobs = %v", blobs)
+		}
+	})
+
 	t.Run("Mixed", func(t *testing.T) {
 		input := "10\t3\tsrc/main.go\n-\t-\tdata.bin\n2\t1\tREADME.md\n"
 		ds := ParseDiffNumstat(input)
@@ -62,3 +90,28 @@ func TestParseDiffNumstat(t *testing.T) {
 		}
 	})
 }
+
+func TestMarkLFS(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+	for name, content := range map[string]string{"model.bin": pointer, "notes.txt": "a\nb\n"} {
+		if err := os.WriteFile(filepath.Join(clone, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, clone, "add", ".")
+	runGit(t, clone, "commit", "-m", "add files")
+	out, err := gitutil.RunGit(t.Context(), clone, "diff", "--raw", "--no-abbrev", "--numstat", "HEAD~1", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, blobs := parseDiff(out)
+	if err := markLFS(t.Context(), clone, ds, blobs); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range ds {
+		if f.LFS != (f.Path == "model.bin") {
+			t.Errorf("%s: LFS = %t", f.Path, f.LFS)
+		}
+	}
+}
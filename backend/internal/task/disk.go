@@ -0,0 +1,172 @@
+// Container disk usage probes and cleanup.
+package task
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+// DiskUsage is the result of a disk probe inside a task's container.
+type DiskUsage struct {
+	UsedBytes  int64     // Bytes used on the filesystem holding the home directory.
+	TotalBytes int64     // Size of that filesystem.
+	Dirs       []DirSize // Largest known space consumers, biggest first.
+	CheckedAt  time.Time
+}
+
+// Percent returns the used fraction of the filesystem as a percentage.
+func (d *DiskUsage) Percent() int {
+	if d.TotalBytes <= 0 {
+		return 0
+	}
+	return int(d.UsedBytes * 100 / d.TotalBytes)
+}
+
+// DirSize is the size of a directory inside the container.
+type DirSize struct {
+	Path  string
+	Bytes int64
+}
+
+// diskProbeScript prints the df line for the home filesystem followed by du
+// totals for the usual space hogs: the repos themselves, their node_modules
+// and the per-user caches (go, npm, pip, model weights).
+const diskProbeScript = `df -Pk ~ | tail -n 1; du -skx ~/src/* ~/src/*/node_modules ~/.cache ~/go ~/.npm 2>/dev/null; true`
+
+// ProbeDisk measures disk usage inside container over SSH.
+func ProbeDisk(ctx context.Context, container string) (DiskUsage, error) {
+	var out []byte
+	err := agent.RetrySSH(ctx, func() error {
+		var err error
+		out, err = sshconn.Command(ctx, container, diskProbeScript).Output()
+		return err
+	})
+	if err != nil {
+		return DiskUsage{}, fmt.Errorf("disk probe: %w", err)
+	}
+	du, err := parseDiskProbe(string(out))
+	if err != nil {
+		return DiskUsage{}, err
+	}
+	du.CheckedAt = time.Now().UTC()
+	return du, nil
+}
+
+// maxDiskDirs bounds the number of directories reported in DiskUsage.Dirs.
+const maxDiskDirs = 8
+
+// parseDiskProbe parses the output of diskProbeScript.
+func parseDiskProbe(out string) (DiskUsage, error) {
+	var du DiskUsage
+	s := bufio.NewScanner(strings.NewReader(out))
+	if !s.Scan() {
+		return du, errors.New("disk probe: empty output")
+	}
+	// Filesystem 1024-blocks Used Available Capacity Mounted-on
+	f := strings.Fields(s.Text())
+	if len(f) < 4 {
+		return du, fmt.Errorf("disk probe: unexpected df output %q", s.Text())
+	}
+	total, err1 := strconv.ParseInt(f[1], 10, 64)
+	used, err2 := strconv.ParseInt(f[2], 10, 64)
+	if err := errors.Join(err1, err2); err != nil {
+		return du, fmt.Errorf("disk probe: %w", err)
+	}
+	du.TotalBytes = total * 1024
+	du.UsedBytes = used * 1024
+	for s.Scan() {
+		kb, path, ok := strings.Cut(s.Text(), "\t")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(kb, 10, 64)
+		if err != nil {
+			continue
+		}
+		du.Dirs = append(du.Dirs, DirSize{Path: path, Bytes: n * 1024})
+	}
+	slices.SortStableFunc(du.Dirs, func(a, b DirSize) int { return cmp.Compare(b.Bytes, a.Bytes) })
+	if len(du.Dirs) > maxDiskDirs {
+		du.Dirs = du.Dirs[:maxDiskDirs]
+	}
+	return du, nil
+}
+
+// DiskUsage returns the latest disk probe result; CheckedAt is zero if the
+// container was never probed.
+func (t *Task) DiskUsage() DiskUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.diskUsage
+}
+
+// SetDiskUsage stores a disk probe result. When usage crosses warnPercent, a
+// "disk_usage_warning" system message is emitted once; it is re-armed when
+// usage drops back below the threshold.
+func (t *Task) SetDiskUsage(ctx context.Context, du DiskUsage, warnPercent int) {
+	t.mu.Lock()
+	t.diskUsage = du
+	warn := false
+	if pct := du.Percent(); warnPercent > 0 && pct >= warnPercent {
+		warn = !t.diskWarned
+		t.diskWarned = true
+	} else {
+		t.diskWarned = false
+	}
+	t.mu.Unlock()
+	if !warn {
+		return
+	}
+	detail := fmt.Sprintf("container disk %d%% full (%s of %s)", du.Percent(), formatBytes(du.UsedBytes), formatBytes(du.TotalBytes))
+	if len(du.Dirs) > 0 {
+		detail += fmt.Sprintf("; largest: %s %s", du.Dirs[0].Path, formatBytes(du.Dirs[0].Bytes))
+	}
+	t.Notify(ctx, "disk_usage_warning", detail)
+}
+
+// CleanDisk runs command through the shell inside container, from the
+// checkout of gitRoot when set, and returns its combined output.
+func CleanDisk(ctx context.Context, container, gitRoot, command string) (string, error) {
+	out, err := RunInCheckout(ctx, container, gitRoot, command)
+	if err != nil {
+		return out, fmt.Errorf("clean: %w", err)
+	}
+	return out, nil
+}
+
+// RunInCheckout runs command through the shell inside container, from the
+// checkout of gitRoot when set, and returns its combined output. A non-zero
+// exit status is returned as an *sshconn.ExitError.
+func RunInCheckout(ctx context.Context, container, gitRoot, command string) (string, error) {
+	script := command
+	if gitRoot != "" {
+		script = "cd ~/src/" + shellQuote(filepath.Base(gitRoot)) + " && " + command
+	}
+	cmd := sshconn.Command(ctx, container, script)
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return strconv.FormatInt(n, 10) + " B"
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
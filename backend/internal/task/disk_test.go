@@ -0,0 +1,70 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestParseDiskProbe(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		out := "overlay 1000 900 100 90% /\n" +
+			"10\t/home/user/src/caic\n" +
+			"600\t/home/user/src/caic/node_modules\n" +
+			"200\t/home/user/.cache\n"
+		du, err := parseDiskProbe(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if du.TotalBytes != 1000*1024 || du.UsedBytes != 900*1024 {
+			t.Errorf("total=%d used=%d", du.TotalBytes, du.UsedBytes)
+		}
+		if du.Percent() != 90 {
+			t.Errorf("Percent() = %d, want 90", du.Percent())
+		}
+		if len(du.Dirs) != 3 || du.Dirs[0].Path != "/home/user/src/caic/node_modules" || du.Dirs[2].Bytes != 10*1024 {
+			t.Errorf("Dirs = %+v", du.Dirs)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		if _, err := parseDiskProbe(""); err == nil {
+			t.Error("expected error")
+		}
+	})
+	t.Run("BadDF", func(t *testing.T) {
+		if _, err := parseDiskProbe("overlay x y z 1% /\n"); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
+
+func TestSetDiskUsage(t *testing.T) {
+	tk := &Task{}
+	countWarnings := func() int {
+		n := 0
+		for _, m := range tk.Messages() {
+			if sm, ok := m.(*agent.SystemMessage); ok && sm.Subtype == "disk_usage_warning" {
+				n++
+			}
+		}
+		return n
+	}
+	tk.SetDiskUsage(t.Context(), DiskUsage{UsedBytes: 50, TotalBytes: 100}, 90)
+	if n := countWarnings(); n != 0 {
+		t.Fatalf("warnings = %d, want 0", n)
+	}
+	tk.SetDiskUsage(t.Context(), DiskUsage{UsedBytes: 95, TotalBytes: 100}, 90)
+	tk.SetDiskUsage(t.Context(), DiskUsage{UsedBytes: 96, TotalBytes: 100}, 90)
+	if n := countWarnings(); n != 1 {
+		t.Fatalf("warnings = %d, want 1", n)
+	}
+	// Dropping below the threshold re-arms the warning.
+	tk.SetDiskUsage(t.Context(), DiskUsage{UsedBytes: 10, TotalBytes: 100}, 90)
+	tk.SetDiskUsage(t.Context(), DiskUsage{UsedBytes: 91, TotalBytes: 100}, 90)
+	if n := countWarnings(); n != 2 {
+		t.Fatalf("warnings = %d, want 2", n)
+	}
+	if got := tk.DiskUsage(); got.UsedBytes != 91 {
+		t.Errorf("DiskUsage().UsedBytes = %d, want 91", got.UsedBytes)
+	}
+}
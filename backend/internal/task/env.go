@@ -0,0 +1,130 @@
+// Toolchain and environment report of a task's container.
+package task
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+// EnvReport describes the environment of a task's container right after it
+// was provisioned, to diagnose differences with the user's machine.
+type EnvReport struct {
+	At       time.Time
+	Image    string            // Image reference the container was created from.
+	ImageID  string            // Content digest of the image, "sha256:...".
+	Tools    map[string]string // Tool name to version output; missing tools are absent.
+	Env      map[string]string // Selected environment variables; see envVars.
+	Platform string            // uname -srm
+}
+
+// envTools are the tools whose version is reported, with the command
+// printing it.
+var envTools = []struct{ name, cmd string }{
+	{"go", "go version"},
+	{"node", "node --version"},
+	{"python", "python3 --version"},
+	{"git", "git --version"},
+}
+
+// envVars are the environment variables reported. Others may hold secrets.
+var envVars = map[string]bool{
+	"GOFLAGS":     true,
+	"GOPATH":      true,
+	"GOROOT":      true,
+	"GOTOOLCHAIN": true,
+	"LANG":        true,
+	"LC_ALL":      true,
+	"NODE_ENV":    true,
+	"PATH":        true,
+	"SHELL":       true,
+	"TZ":          true,
+	"VIRTUAL_ENV": true,
+}
+
+// envProbeScript prints "tool<TAB>version" for each of envTools, the
+// platform, then the environment after a marker line. The login profile is
+// sourced so the PATH matches an interactive shell's.
+var envProbeScript = func() string {
+	var b strings.Builder
+	b.WriteString(". ~/.profile >/dev/null 2>&1; ")
+	for _, t := range envTools {
+		fmt.Fprintf(&b, "printf '%s\\t%%s\\n' \"$(%s 2>/dev/null | head -n 1)\"; ", t.name, t.cmd)
+	}
+	b.WriteString("printf 'uname\\t%s\\n' \"$(uname -srm)\"; echo --env--; env")
+	return b.String()
+}()
+
+// ProbeEnv collects the tool versions and environment of container over SSH.
+// Image and ImageID are left for the caller to fill.
+func ProbeEnv(ctx context.Context, container string) (*EnvReport, error) {
+	var out []byte
+	err := agent.RetrySSH(ctx, func() error {
+		var err error
+		out, err = sshconn.Command(ctx, container, envProbeScript).Output()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("env probe: %w", err)
+	}
+	r := parseEnvProbe(string(out))
+	r.At = time.Now().UTC()
+	return r, nil
+}
+
+// parseEnvProbe parses the output of envProbeScript.
+func parseEnvProbe(out string) *EnvReport {
+	r := &EnvReport{Tools: map[string]string{}, Env: map[string]string{}}
+	inEnv := false
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := s.Text()
+		if line == "--env--" {
+			inEnv = true
+			continue
+		}
+		if inEnv {
+			if k, v, ok := strings.Cut(line, "="); ok && envVars[k] {
+				r.Env[k] = v
+			}
+			continue
+		}
+		k, v, ok := strings.Cut(line, "\t")
+		if !ok || v == "" {
+			continue
+		}
+		if k == "uname" {
+			r.Platform = v
+		} else {
+			r.Tools[k] = v
+		}
+	}
+	return r
+}
+
+// SetEnvReport records the container environment report.
+func (t *Task) SetEnvReport(r *EnvReport) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.envReport = r
+}
+
+// EnvReport returns a copy of the container environment report, or nil if
+// it was not captured.
+func (t *Task) EnvReport() *EnvReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.envReport == nil {
+		return nil
+	}
+	r := *t.envReport
+	r.Tools = maps.Clone(r.Tools)
+	r.Env = maps.Clone(r.Env)
+	return &r
+}
@@ -0,0 +1,34 @@
+package task
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEnvProbe(t *testing.T) {
+	out := "go\tgo version go1.26.0 linux/amd64\n" +
+		"node\tv22.1.0\n" +
+		"python\t\n" +
+		"git\tgit version 2.47.0\n" +
+		"uname\tLinux 6.8.0 x86_64\n" +
+		"--env--\n" +
+		"PATH=/usr/local/go/bin:/usr/bin\n" +
+		"ANTHROPIC_API_KEY=secret\n" +
+		"GOFLAGS=-mod=mod\n"
+	r := parseEnvProbe(out)
+	if r.Tools["go"] != "go version go1.26.0 linux/amd64" || r.Tools["node"] != "v22.1.0" || r.Tools["git"] != "git version 2.47.0" {
+		t.Errorf("tools = %v", r.Tools)
+	}
+	if _, ok := r.Tools["python"]; ok {
+		t.Error("missing python reported")
+	}
+	if r.Platform != "Linux 6.8.0 x86_64" {
+		t.Errorf("platform = %q", r.Platform)
+	}
+	if len(r.Env) != 2 || r.Env["PATH"] != "/usr/local/go/bin:/usr/bin" || r.Env["GOFLAGS"] != "-mod=mod" {
+		t.Errorf("env = %v", r.Env)
+	}
+	if !strings.Contains(envProbeScript, "printf 'go\\t%s\\n' \"$(go version 2>/dev/null | head -n 1)\"") {
+		t.Errorf("script = %s", envProbeScript)
+	}
+}
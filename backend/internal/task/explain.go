@@ -0,0 +1,76 @@
+// Explain tasks: read-only exploration writing a structured overview of a
+// repository.
+package task
+
+import (
+	"strings"
+)
+
+// Kind is the type of work a task does.
+type Kind string
+
+// Task kinds.
+const (
+	KindCode    Kind = ""        // Edits the repository.
+	KindExplain Kind = "explain" // Explores read-only and answers with a repository overview.
+)
+
+// ParseKind parses a kind name; "code" and "" are KindCode.
+func ParseKind(s string) (Kind, bool) {
+	switch s {
+	case "", "code":
+		return KindCode, true
+	case string(KindExplain):
+		return KindExplain, true
+	}
+	return KindCode, false
+}
+
+// ReadOnly reports whether the agent of t must not edit the repository.
+func (t *Task) ReadOnly() bool {
+	return t.Kind == KindExplain
+}
+
+// Sections of a repository overview, in order.
+const (
+	OverviewArchitecture = "Architecture"
+	OverviewPackages     = "Key packages"
+	OverviewBuild        = "Build and test"
+	OverviewConventions  = "Conventions"
+)
+
+// ExplainPrompt is the default prompt of explain tasks.
+const ExplainPrompt = "Explore this repository without modifying anything and write an overview for a developer, " +
+	"or another coding agent, about to work on it. Read the documentation, the build files and the main entry points; " +
+	"run read-only commands only.\n\n" +
+	"Reply with only the overview, in Markdown, with exactly these level 2 headings in this order:\n" +
+	"## " + OverviewArchitecture + "\nWhat the project does and how its parts fit together, in a few paragraphs.\n" +
+	"## " + OverviewPackages + "\nOne \"- path: purpose\" line per important directory or package.\n" +
+	"## " + OverviewBuild + "\nOne \"- \" line per command to build, test, lint or run the project, with when to use it.\n" +
+	"## " + OverviewConventions + "\nOne \"- \" line per code style, layout or workflow convention worth following."
+
+// OverviewSection returns the body of the "## heading" section of the
+// overview doc, trimmed, or "" if there is none.
+func OverviewSection(doc, heading string) string {
+	var b strings.Builder
+	in := false
+	for line := range strings.Lines(doc) {
+		if h, ok := strings.CutPrefix(line, "## "); ok {
+			if in {
+				break
+			}
+			in = strings.EqualFold(strings.TrimSpace(h), heading)
+			continue
+		}
+		if in {
+			b.WriteString(line)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// OverviewLearnings returns the commands listed in the build section of the
+// overview doc as knowledge learnings.
+func OverviewLearnings(doc string) []string {
+	return parseLearnings(OverviewSection(doc, OverviewBuild))
+}
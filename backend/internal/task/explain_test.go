@@ -0,0 +1,40 @@
+package task
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestOverview(t *testing.T) {
+	const doc = "Intro.\n\n## Architecture\nA server and a frontend.\n\n" +
+		"## Key packages\n- backend/: the server\n\n" +
+		"## Build and test\n- `make test` runs the tests.\n* `make lint` before sending.\nNot a command.\n\n" +
+		"## Conventions\n- Wrap errors.\n"
+	if got := OverviewSection(doc, OverviewArchitecture); got != "A server and a frontend." {
+		t.Errorf("OverviewSection(Architecture) = %q", got)
+	}
+	if got := OverviewSection(doc, "key packages"); got != "- backend/: the server" {
+		t.Errorf("OverviewSection(Key packages) = %q", got)
+	}
+	if got := OverviewSection(doc, "Missing"); got != "" {
+		t.Errorf("OverviewSection(Missing) = %q", got)
+	}
+	want := []string{"`make test` runs the tests.", "`make lint` before sending."}
+	if got := OverviewLearnings(doc); !slices.Equal(got, want) {
+		t.Errorf("OverviewLearnings() = %q", got)
+	}
+}
+
+func TestKind(t *testing.T) {
+	for s, want := range map[string]Kind{"": KindCode, "code": KindCode, "explain": KindExplain} {
+		if got, ok := ParseKind(s); !ok || got != want {
+			t.Errorf("ParseKind(%q) = %q, %v", s, got, ok)
+		}
+	}
+	if _, ok := ParseKind("review"); ok {
+		t.Error("ParseKind(review) succeeded")
+	}
+	if (&Task{}).ReadOnly() || !(&Task{Kind: KindExplain}).ReadOnly() {
+		t.Error("ReadOnly mismatch")
+	}
+}
@@ -0,0 +1,443 @@
+// Per-repository git tuning: fetch depth, partial clone filter and timeouts,
+// plus lazy recovery from objects missing in shallow or partial clones,
+// partial and sparse container clones.
+package task
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+// GitOptions tunes git operations for a single repository. Zero values fall
+// back to Runner.GitTimeout and a plain "git fetch origin".
+type GitOptions struct {
+	FetchTimeout  time.Duration // git fetch (host and container); defaults to Runner.GitTimeout.
+	BranchTimeout time.Duration // branch resolution and creation; defaults to Runner.GitTimeout.
+	DiffTimeout   time.Duration // diff and diff stat; defaults to Runner.GitTimeout.
+	FetchDepth    int           // >0 limits host fetches of shallow clones to this many commits (--depth); a full clone is never made shallow.
+	FetchFilter   string        // Partial clone filter for host fetches and container clones, e.g. "blob:none".
+	SparseShared  []string      // Directories always checked out when a task narrows the checkout to some paths.
+	// MaxFetches caps the fetches into the host clone running at once, from
+	// origin or from task containers; 0 is unlimited.
+	MaxFetches int
+	// ReservedPrefixes are branch name prefixes reserved for humans; caic
+	// never creates a task branch matching one.
+	ReservedPrefixes []string
+	// TaskNotes commits the task notes as NotesFile on the task branch when
+	// it is pushed, see Task.Notes.
+	TaskNotes bool
+	// TestGuard tunes the safety check flagging diffs that remove tests.
+	TestGuard TestGuard
+	// NoTrailers leaves the synced commits without provenance trailers, see
+	// Task.Trailers.
+	NoTrailers bool
+	// Submodules and LFS init the submodules and pull the Git LFS objects
+	// of the container checkout, see checkoutDepsCommand.
+	Submodules bool
+	LFS        bool
+	// AffectedTargets computes the build targets affected by the branch
+	// after each diff stat update when set, see Task.AffectedTargets.
+	AffectedTargets AffectedTool
+}
+
+// Validate returns an error if the options are invalid.
+func (o *GitOptions) Validate() error {
+	if o.FetchTimeout < 0 || o.BranchTimeout < 0 || o.DiffTimeout < 0 {
+		return errors.New("git timeouts must not be negative")
+	}
+	if o.FetchDepth < 0 {
+		return errors.New("fetch depth must not be negative")
+	}
+	if o.MaxFetches < 0 {
+		return errors.New("max fetches must not be negative")
+	}
+	if o.FetchFilter != "" && !validFetchFilter(o.FetchFilter) {
+		return fmt.Errorf("unsupported fetch filter %q", o.FetchFilter)
+	}
+	for _, p := range o.SparseShared {
+		if !ValidRepoPath(p) {
+			return fmt.Errorf("invalid shared sparse path %q", p)
+		}
+	}
+	for _, p := range o.ReservedPrefixes {
+		if p == "" {
+			return errors.New("reserved branch prefix must not be empty")
+		}
+	}
+	if o.TestGuard.MinLines < 0 || o.TestGuard.Ratio < 0 {
+		return errors.New("test guard thresholds must not be negative")
+	}
+	switch o.AffectedTargets {
+	case "", AffectedGo, AffectedBazel:
+	default:
+		return fmt.Errorf("unsupported affected targets tool %q", o.AffectedTargets)
+	}
+	return nil
+}
+
+// validFetchFilter reports whether f is a filter spec accepted by
+// git fetch --filter that caic knows how to handle.
+func validFetchFilter(f string) bool {
+	switch {
+	case f == "blob:none", f == "tree:0":
+		return true
+	case strings.HasPrefix(f, "blob:limit="):
+		return len(f) > len("blob:limit=")
+	default:
+		return false
+	}
+}
+
+// withDefaults returns o with zero timeouts replaced by def.
+func (o GitOptions) withDefaults(def time.Duration) GitOptions {
+	if o.FetchTimeout == 0 {
+		o.FetchTimeout = def
+	}
+	if o.BranchTimeout == 0 {
+		o.BranchTimeout = def
+	}
+	if o.DiffTimeout == 0 {
+		o.DiffTimeout = def
+	}
+	return o
+}
+
+// BranchConflictError reports that a task branch name collides with an
+// existing ref or a reserved prefix.
+type BranchConflictError struct {
+	Branch   string
+	Ref      string // Conflicting ref, e.g. "refs/remotes/origin/caic-3"; empty for a reserved prefix.
+	Reserved string // Matching reserved prefix; empty for a ref conflict.
+}
+
+func (e *BranchConflictError) Error() string {
+	if e.Reserved != "" {
+		return fmt.Sprintf("branch %q matches reserved prefix %q", e.Branch, e.Reserved)
+	}
+	return fmt.Sprintf("branch %q conflicts with existing ref %s", e.Branch, e.Ref)
+}
+
+// checkBranchFree verifies that branch can be created in dir without
+// clobbering someone else's work: it must not match a reserved prefix nor
+// exist, locally or on any remote, as a ref or a ref directory.
+func checkBranchFree(ctx context.Context, dir, branch string, reserved []string) error {
+	for _, p := range reserved {
+		if strings.HasPrefix(branch, p) {
+			return &BranchConflictError{Branch: branch, Reserved: p}
+		}
+	}
+	out, err := gitutil.RunGit(ctx, dir, "for-each-ref", "--format=%(refname)",
+		"refs/heads/"+branch, "refs/remotes/*/"+branch, "refs/remotes/*/"+branch+"/*")
+	if err != nil {
+		return fmt.Errorf("list refs: %w", err)
+	}
+	if ref, _, _ := strings.Cut(strings.TrimSpace(out), "\n"); ref != "" {
+		return &BranchConflictError{Branch: branch, Ref: ref}
+	}
+	return nil
+}
+
+// ValidDiffBase reports whether ref is acceptable as the base of a diff: a
+// non-empty revision that cannot be mistaken for a git option.
+func ValidDiffBase(ref string) bool {
+	return ref != "" && len(ref) <= 256 && !strings.HasPrefix(ref, "-") &&
+		!strings.ContainsAny(ref, " \t\n\x00")
+}
+
+// ErrUnknownRef is returned when a diff base does not resolve to a commit.
+var ErrUnknownRef = errors.New("unknown ref")
+
+// resolveCommit resolves ref to a commit SHA in dir.
+func resolveCommit(ctx context.Context, dir, ref string) (string, error) {
+	if !ValidDiffBase(ref) {
+		return "", fmt.Errorf("invalid ref %q", ref)
+	}
+	out, err := gitutil.RunGit(ctx, dir, "rev-parse", "--verify", "--quiet", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("%w %q", ErrUnknownRef, ref)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// diffRefs returns the unified diff from base to head in dir, optionally
+// restricted to path. Only read-only plumbing is used; the working tree is
+// left untouched.
+func diffRefs(ctx context.Context, dir, base, head, path string) (string, error) {
+	args := []string{"diff", "--no-ext-diff", "--no-textconv", base, head, "--"}
+	if path != "" {
+		args = append(args, path)
+	}
+	out, err := gitutil.RunGit(ctx, dir, args...)
+	if out != "" {
+		out += "\n" // RunGit trims the final newline git apply expects.
+	}
+	return out, err
+}
+
+// ErrNotWorktree is returned when a directory is not a worktree of the
+// expected repository.
+var ErrNotWorktree = errors.New("not a worktree of the task's repository")
+
+// ErrDirtyWorktree is returned when a worktree has uncommitted changes.
+var ErrDirtyWorktree = errors.New("worktree has uncommitted changes")
+
+// ErrPatchFailed is returned when a patch does not apply cleanly.
+var ErrPatchFailed = errors.New("patch does not apply")
+
+// checkWorktree verifies that dir is a clean worktree sharing repo's object
+// store and returns its top level directory.
+func checkWorktree(ctx context.Context, repo, dir string) (string, error) {
+	want, err := gitutil.RunGit(ctx, repo, "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	out, err := gitutil.RunGit(ctx, dir, "rev-parse", "--path-format=absolute", "--git-common-dir", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNotWorktree, dir)
+	}
+	got, top, _ := strings.Cut(out, "\n")
+	if !sameDir(got, want) || top == "" {
+		return "", fmt.Errorf("%w: %s", ErrNotWorktree, dir)
+	}
+	status, err := gitutil.RunGit(ctx, top, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return "", err
+	}
+	if status != "" {
+		return "", fmt.Errorf("%w: %s", ErrDirtyWorktree, top)
+	}
+	return top, nil
+}
+
+// sameDir reports whether a and b are the same directory once symlinks are
+// resolved.
+func sameDir(a, b string) bool {
+	ra, err1 := filepath.EvalSymlinks(a)
+	rb, err2 := filepath.EvalSymlinks(b)
+	return err1 == nil && err2 == nil && ra == rb
+}
+
+// applyPatch applies patch to the working tree of dir without touching the
+// index, HEAD or any branch and returns its numstat. Nothing is applied when
+// a hunk fails.
+func applyPatch(ctx context.Context, dir, patch string) (string, error) {
+	if _, err := gitApply(ctx, dir, patch); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrPatchFailed, err)
+	}
+	return gitApply(ctx, dir, patch, "--numstat")
+}
+
+// gitApply runs git apply in dir with patch on stdin.
+func gitApply(ctx context.Context, dir, patch string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", append(append([]string{"apply", "--whitespace=nowarn"}, args...), "-")...) //nolint:gosec // args are constants
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(patch)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git apply: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// fetchOrigin runs git fetch origin in dir, honoring the configured depth
+// and partial clone filter. The depth only applies to clones that are already
+// shallow: --depth would otherwise truncate the history of a full clone and
+// break merge bases.
+func fetchOrigin(ctx context.Context, dir string, o *GitOptions) error {
+	depth := 0
+	if o.FetchDepth > 0 && isShallow(ctx, dir) {
+		depth = o.FetchDepth
+	}
+	if depth == 0 && o.FetchFilter == "" {
+		return gitutil.Fetch(ctx, dir)
+	}
+	args := []string{"fetch"}
+	if depth > 0 {
+		args = append(args, "--depth="+strconv.Itoa(depth))
+	}
+	if o.FetchFilter != "" {
+		args = append(args, "--filter="+o.FetchFilter)
+	}
+	args = append(args, "origin")
+	slog.Info("git fetch", "dir", dir, "depth", depth, "filter", o.FetchFilter)
+	_, err := gitutil.RunGit(ctx, dir, args...)
+	return err
+}
+
+// deepenStep is the number of commits fetched per attempt when a shallow
+// clone lacks the history needed to find a merge base.
+const deepenStep = 200
+
+// maxMissingRetries bounds how many times an operation is retried after
+// fetching missing objects or deepening history.
+const maxMissingRetries = 3
+
+// missingObjectRe matches git errors naming an object absent from a partial
+// or shallow clone.
+var missingObjectRe = regexp.MustCompile(`(?:missing (?:blob|tree|commit) object|unable to read|bad object|could not read|did not receive expected object) '?([0-9a-f]{40,64})'?`)
+
+// truncatedHistoryRe matches git errors caused by history cut off at the
+// boundary of a shallow clone.
+var truncatedHistoryRe = regexp.MustCompile(`no merge base|refusing to merge unrelated histories`)
+
+// recoverMissing inspects a failed git operation's error and tries to fetch
+// what is missing from origin: specific objects for partial clones, more
+// history for shallow clones. Returns true if something was fetched and the
+// operation is worth retrying.
+func recoverMissing(ctx context.Context, dir string, err error) bool {
+	msg := err.Error()
+	if m := missingObjectRe.FindAllStringSubmatch(msg, -1); len(m) > 0 {
+		args := []string{"fetch", "--no-tags", "--no-write-fetch-head", "origin"}
+		seen := map[string]bool{}
+		for _, g := range m {
+			if !seen[g[1]] {
+				seen[g[1]] = true
+				args = append(args, g[1])
+			}
+		}
+		slog.Info("git fetch missing objects", "dir", dir, "n", len(seen))
+		if _, ferr := gitutil.RunGit(ctx, dir, args...); ferr != nil {
+			slog.Warn("git fetch missing objects failed", "dir", dir, "err", ferr)
+			return false
+		}
+		return true
+	}
+	if truncatedHistoryRe.MatchString(msg) && isShallow(ctx, dir) {
+		slog.Info("git deepen", "dir", dir, "n", deepenStep)
+		if _, ferr := gitutil.RunGit(ctx, dir, "fetch", "--deepen="+strconv.Itoa(deepenStep), "origin"); ferr != nil {
+			slog.Warn("git deepen failed", "dir", dir, "err", ferr)
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// isShallow reports whether the clone in dir is shallow.
+func isShallow(ctx context.Context, dir string) bool {
+	out, err := gitutil.RunGit(ctx, dir, "rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// retryMissing runs fn and, when it fails because the local clone lacks
+// objects or history, fetches them lazily and retries.
+func retryMissing[T any](ctx context.Context, dir string, fn func() (T, error)) (T, error) {
+	v, err := fn()
+	for i := 0; err != nil && i < maxMissingRetries && ctx.Err() == nil; i++ {
+		if !recoverMissing(ctx, dir, err) {
+			break
+		}
+		v, err = fn()
+	}
+	return v, err
+}
+
+// containerCloneCommand returns the shell command run inside the container to
+// clone the repository at gitRoot from its upstream with the partial clone
+// filter, at branch when set, before md pushes the task branch on top of it.
+// md then only transfers the objects the upstream doesn't have instead of the
+// whole history. With paths, the clone is a sparse checkout of these
+// directories and filter defaults to "blob:none", so only their blobs are
+// ever downloaded. The directory is removed when the clone fails so md falls
+// back to a full push.
+func containerCloneCommand(ctx context.Context, gitRoot, branch, filter string, paths []string) (string, error) {
+	origin, err := gitutil.RunGit(ctx, gitRoot, "remote", "get-url", "origin")
+	if err != nil {
+		return "", err
+	}
+	url, ok := httpsURL(origin)
+	if !ok {
+		return "", fmt.Errorf("origin %q is not reachable from the container", origin)
+	}
+	if filter == "" {
+		filter = "blob:none"
+	}
+	dir := "~/src/" + shellQuote(filepath.Base(gitRoot))
+	var b strings.Builder
+	b.WriteString("{ git clone -q --filter=")
+	b.WriteString(shellQuote(filter))
+	if len(paths) != 0 {
+		b.WriteString(" --sparse")
+	}
+	if branch != "" {
+		b.WriteString(" --single-branch --branch ")
+		b.WriteString(shellQuote(branch))
+	}
+	b.WriteString(" -- ")
+	b.WriteString(shellQuote(url))
+	b.WriteString(" " + dir)
+	if len(paths) != 0 {
+		b.WriteString(" && git -C " + dir + " sparse-checkout set --cone --")
+		for _, p := range paths {
+			b.WriteByte(' ')
+			b.WriteString(shellQuote(p))
+		}
+	}
+	b.WriteString("; } || { rm -rf " + dir + "; exit 1; }")
+	return b.String(), nil
+}
+
+// repoPathSegmentRe matches a path segment inside a repository, including
+// dot-directories such as ".github".
+var repoPathSegmentRe = regexp.MustCompile(`^[a-zA-Z0-9._@+-]+$`)
+
+// ValidRepoPath reports whether p is a clean, slash separated path relative to
+// the repository root that does not escape it. It is used for the sparse
+// checkout directories and for files read from the repository.
+func ValidRepoPath(p string) bool {
+	if p == "" || len(p) > 255 {
+		return false
+	}
+	for seg := range strings.SplitSeq(p, "/") {
+		if seg == "." || seg == ".." || !repoPathSegmentRe.MatchString(seg) {
+			return false
+		}
+	}
+	return true
+}
+
+// SparsePaths returns the directories to keep in a sparse checkout: paths plus
+// shared, sorted and deduplicated. It returns nil when paths is empty, meaning
+// the full tree is checked out.
+func SparsePaths(paths, shared []string) []string {
+	if len(paths) == 0 {
+		return nil
+	}
+	out := slices.Concat(paths, shared)
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// SparseCheckoutCommand returns the shell command run inside the container to
+// restrict the working tree of the repository at gitRoot to paths. md pushes
+// each repository to ~/src/<basename>.
+func SparseCheckoutCommand(gitRoot string, paths []string) string {
+	var b strings.Builder
+	b.WriteString("cd ~/src/")
+	b.WriteString(shellQuote(filepath.Base(gitRoot)))
+	b.WriteString(" && git sparse-checkout set --cone --")
+	for _, p := range paths {
+		b.WriteByte(' ')
+		b.WriteString(shellQuote(p))
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
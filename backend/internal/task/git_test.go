@@ -0,0 +1,338 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+func TestGitOptions(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		for _, tc := range []struct {
+			name string
+			o    GitOptions
+			ok   bool
+		}{
+			{"zero", GitOptions{}, true},
+			{"blobNone", GitOptions{FetchDepth: 50, FetchFilter: "blob:none"}, true},
+			{"blobLimit", GitOptions{FetchFilter: "blob:limit=1m"}, true},
+			{"treeZero", GitOptions{FetchFilter: "tree:0"}, true},
+			{"badFilter", GitOptions{FetchFilter: "sparse:oid=abc"}, false},
+			{"emptyLimit", GitOptions{FetchFilter: "blob:limit="}, false},
+			{"negDepth", GitOptions{FetchDepth: -1}, false},
+			{"maxFetches", GitOptions{MaxFetches: 2}, true},
+			{"negMaxFetches", GitOptions{MaxFetches: -1}, false},
+			{"negTimeout", GitOptions{DiffTimeout: -time.Second}, false},
+			{"shared", GitOptions{SparseShared: []string{"tools", "third_party/go"}}, true},
+			{"sharedEscape", GitOptions{SparseShared: []string{"../x"}}, false},
+			{"testGuard", GitOptions{TestGuard: TestGuard{MinLines: 10, Ratio: 0.5}}, true},
+			{"negTestGuard", GitOptions{TestGuard: TestGuard{Ratio: -1}}, false},
+			{"affectedBazel", GitOptions{AffectedTargets: AffectedBazel}, true},
+			{"badAffected", GitOptions{AffectedTargets: "make"}, false},
+			{"reserved", GitOptions{ReservedPrefixes: []string{"caic-9"}}, true},
+			{"reservedEmpty", GitOptions{ReservedPrefixes: []string{""}}, false},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				if err := tc.o.Validate(); (err == nil) != tc.ok {
+					t.Errorf("Validate() = %v, want ok=%v", err, tc.ok)
+				}
+			})
+		}
+	})
+	t.Run("Defaults", func(t *testing.T) {
+		r := &Runner{GitTimeout: 2 * time.Minute, Git: GitOptions{FetchTimeout: 10 * time.Minute}}
+		r.initDefaults()
+		if r.Git.FetchTimeout != 10*time.Minute {
+			t.Errorf("FetchTimeout = %v, want 10m", r.Git.FetchTimeout)
+		}
+		if r.Git.BranchTimeout != 2*time.Minute || r.Git.DiffTimeout != 2*time.Minute {
+			t.Errorf("BranchTimeout = %v, DiffTimeout = %v, want 2m", r.Git.BranchTimeout, r.Git.DiffTimeout)
+		}
+	})
+	t.Run("DepthKeepsFullClone", func(t *testing.T) {
+		clone := initTestRepo(t, "main")
+		for _, m := range []string{"c1", "c2"} {
+			runGit(t, clone, "commit", "--allow-empty", "-m", m)
+		}
+		runGit(t, clone, "push", "origin", "main")
+		if err := fetchOrigin(t.Context(), clone, &GitOptions{FetchDepth: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if isShallow(t.Context(), clone) {
+			t.Error("fetch with a depth made a full clone shallow")
+		}
+	})
+	t.Run("MaxFetches", func(t *testing.T) {
+		r := &Runner{Git: GitOptions{MaxFetches: 1}}
+		r.initDefaults()
+		release, err := r.acquireFetch(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := r.acquireFetch(ctx); err == nil {
+			t.Error("second fetch acquired a slot beyond MaxFetches")
+		}
+		release()
+		release, err = r.acquireFetch(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	})
+}
+
+func TestSparse(t *testing.T) {
+	t.Run("ValidRepoPath", func(t *testing.T) {
+		for _, p := range []string{"a", "proj/a", "a.b/c-d", ".github/workflows"} {
+			if !ValidRepoPath(p) {
+				t.Errorf("ValidRepoPath(%q) = false", p)
+			}
+		}
+		for _, p := range []string{"", ".", "..", "../a", "/a", "a/", "a//b", "a/../b", "./a", `a\b`, "it's", "a b"} {
+			if ValidRepoPath(p) {
+				t.Errorf("ValidRepoPath(%q) = true", p)
+			}
+		}
+	})
+	t.Run("SparsePaths", func(t *testing.T) {
+		if got := SparsePaths(nil, []string{"tools"}); got != nil {
+			t.Errorf("SparsePaths(nil) = %v, want nil", got)
+		}
+		got := SparsePaths([]string{"proj/b", "tools"}, []string{"tools", "proj/a"})
+		if strings.Join(got, ",") != "proj/a,proj/b,tools" {
+			t.Errorf("SparsePaths = %v", got)
+		}
+	})
+	t.Run("Command", func(t *testing.T) {
+		got := SparseCheckoutCommand("/home/u/src/mono", []string{"proj/a", "it's"})
+		want := `cd ~/src/'mono' && git sparse-checkout set --cone -- 'proj/a' 'it'\''s'`
+		if got != want {
+			t.Errorf("SparseCheckoutCommand =\n  %s\nwant\n  %s", got, want)
+		}
+	})
+}
+
+func TestRetryMissing(t *testing.T) {
+	t.Run("Deepen", func(t *testing.T) {
+		clone := initTestRepo(t, "main")
+		for i := range 3 {
+			if err := os.WriteFile(filepath.Join(clone, "f.txt"), []byte(strconv.Itoa(i)), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			runGit(t, clone, "add", ".")
+			runGit(t, clone, "commit", "-m", "c"+strconv.Itoa(i))
+		}
+		runGit(t, clone, "push", "origin", "main")
+		shallow := filepath.Join(t.TempDir(), "shallow")
+		runGit(t, "", "clone", "--depth", "1", "--branch", "main", "file://"+filepath.Join(filepath.Dir(clone), "remote.git"), shallow)
+
+		calls := 0
+		got, err := retryMissing(t.Context(), shallow, func() (string, error) {
+			calls++
+			out, err := gitutil.RunGit(t.Context(), shallow, "rev-list", "--count", "HEAD")
+			if err == nil && out == "1" {
+				return "", errors.New("fatal: origin/main...HEAD: no merge base")
+			}
+			return out, err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if calls != 2 || got != "4" {
+			t.Errorf("calls = %d, count = %q; want 2, \"4\"", calls, got)
+		}
+	})
+	t.Run("Unrelated", func(t *testing.T) {
+		calls := 0
+		_, err := retryMissing(t.Context(), t.TempDir(), func() (int, error) {
+			calls++
+			return 0, errors.New("permission denied")
+		})
+		if err == nil || calls != 1 {
+			t.Errorf("err = %v, calls = %d; want error after 1 call", err, calls)
+		}
+	})
+	t.Run("NotShallow", func(t *testing.T) {
+		clone := initTestRepo(t, "main")
+		for _, msg := range []string{"fatal: shallow file has changed since we read it", "fatal: origin/main...HEAD: no merge base"} {
+			calls := 0
+			_, err := retryMissing(t.Context(), clone, func() (int, error) {
+				calls++
+				return 0, errors.New(msg)
+			})
+			if err == nil || calls != 1 {
+				t.Errorf("%q: err = %v, calls = %d; want error after 1 call", msg, err, calls)
+			}
+		}
+	})
+}
+
+func TestContainerCloneCommand(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "repo")
+	runGit(t, "", "init", "-q", dir)
+	runGit(t, dir, "remote", "add", "origin", "git@github.com:org/repo.git")
+	got, err := containerCloneCommand(t.Context(), dir, "main", "tree:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{ git clone -q --filter='tree:0' --single-branch --branch 'main' -- 'https://github.com/org/repo.git' ~/src/'repo'; } || { rm -rf ~/src/'repo'; exit 1; }"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	got, err = containerCloneCommand(t.Context(), dir, "", "", []string{"proj/a", "tools"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "{ git clone -q --filter='blob:none' --sparse -- 'https://github.com/org/repo.git' ~/src/'repo' && git -C ~/src/'repo' sparse-checkout set --cone -- 'proj/a' 'tools'; } || { rm -rf ~/src/'repo'; exit 1; }"
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	runGit(t, dir, "remote", "set-url", "origin", "/srv/git/repo.git")
+	if _, err := containerCloneCommand(t.Context(), dir, "", "blob:none", nil); err == nil {
+		t.Error("expected an error for a local origin")
+	}
+}
+
+func TestCheckBranchFree(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	runGit(t, clone, "branch", "caic-1")
+	// Remote-only branch, e.g. pushed by a human from another machine.
+	runGit(t, clone, "push", "origin", "main:caic-2")
+	runGit(t, clone, "fetch", "origin")
+	// A ref below the name makes it unusable as a branch.
+	runGit(t, clone, "push", "origin", "main:refs/heads/caic-3/wip")
+	runGit(t, clone, "fetch", "origin")
+	for _, tc := range []struct {
+		branch, ref, reserved string
+	}{
+		{"caic-1", "refs/heads/caic-1", ""},
+		{"caic-2", "refs/remotes/origin/caic-2", ""},
+		{"caic-3", "refs/remotes/origin/caic-3/wip", ""},
+		{"caic-42", "", "caic-4"},
+		{"caic-5", "", ""},
+	} {
+		t.Run(tc.branch, func(t *testing.T) {
+			err := checkBranchFree(t.Context(), clone, tc.branch, []string{"caic-4"})
+			if tc.ref == "" && tc.reserved == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var bce *BranchConflictError
+			if !errors.As(err, &bce) {
+				t.Fatalf("err = %v, want BranchConflictError", err)
+			}
+			if bce.Branch != tc.branch || bce.Ref != tc.ref || bce.Reserved != tc.reserved {
+				t.Errorf("err = %+v", bce)
+			}
+		})
+	}
+	t.Run("AllocateSkips", func(t *testing.T) {
+		r := &Runner{BaseBranch: "main", Dir: clone, LogDir: t.TempDir(), Git: GitOptions{ReservedPrefixes: []string{"caic-4"}}}
+		r.initDefaults()
+		r.nextID = 1
+		branch, err := r.AllocateBranch(t.Context())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if branch != "caic-5" {
+			t.Errorf("branch = %q, want caic-5", branch)
+		}
+	})
+}
+
+func TestDiffRefs(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	runGit(t, clone, "tag", "v1")
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(clone, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, clone, "add", ".")
+		runGit(t, clone, "commit", "-q", "-m", name)
+	}
+	write("a.txt", "a\n")
+	reviewed, err := resolveCommit(t.Context(), clone, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("b.txt", "b\n")
+
+	diff, err := diffRefs(t.Context(), clone, reviewed, "HEAD", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "b/b.txt") || strings.Contains(diff, "a.txt") {
+		t.Errorf("diff since review:\n%s", diff)
+	}
+	v1, err := resolveCommit(t.Context(), clone, "v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diff, err = diffRefs(t.Context(), clone, v1, "HEAD", "a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "b/a.txt") || strings.Contains(diff, "b.txt") || !strings.HasSuffix(diff, "\n") {
+		t.Errorf("diff since tag restricted to a.txt:\n%s", diff)
+	}
+	for _, ref := range []string{"nope", "--output=/tmp/x", "", "a b"} {
+		if _, err := resolveCommit(t.Context(), clone, ref); err == nil {
+			t.Errorf("resolveCommit(%q) succeeded", ref)
+		}
+	}
+	if _, err := resolveCommit(t.Context(), clone, "nope"); !errors.Is(err, ErrUnknownRef) {
+		t.Errorf("err = %v, want ErrUnknownRef", err)
+	}
+}
+
+func TestApplyToWorktree(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	wt := filepath.Join(t.TempDir(), "wt")
+	runGit(t, clone, "worktree", "add", "-q", "-b", "feature", wt)
+	other := initTestRepo(t, "main")
+
+	if _, err := checkWorktree(t.Context(), clone, other); !errors.Is(err, ErrNotWorktree) {
+		t.Errorf("other repo: err = %v, want ErrNotWorktree", err)
+	}
+	if _, err := checkWorktree(t.Context(), clone, t.TempDir()); !errors.Is(err, ErrNotWorktree) {
+		t.Errorf("plain dir: err = %v, want ErrNotWorktree", err)
+	}
+	top, err := checkWorktree(t.Context(), clone, filepath.Join(wt, "."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := "diff --git a/README.md b/README.md\n--- a/README.md\n+++ b/README.md\n@@ -1 +1 @@\n-hello\n+bonjour\n" +
+		"diff --git a/new.txt b/new.txt\nnew file mode 100644\n--- /dev/null\n+++ b/new.txt\n@@ -0,0 +1 @@\n+new\n"
+	numstat, err := applyPatch(t.Context(), top, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds := ParseDiffNumstat(numstat); len(ds) != 2 || ds[0].Path != "README.md" || ds[1].Path != "new.txt" {
+		t.Errorf("diff stat = %+v", ds)
+	}
+	if b, _ := os.ReadFile(filepath.Join(wt, "README.md")); string(b) != "bonjour\n" {
+		t.Errorf("README.md = %q", b)
+	}
+	out, err := gitutil.RunGit(t.Context(), wt, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil || out != "feature" {
+		t.Errorf("HEAD = %q, %v", out, err)
+	}
+	if _, err := checkWorktree(t.Context(), clone, wt); !errors.Is(err, ErrDirtyWorktree) {
+		t.Errorf("dirty: err = %v, want ErrDirtyWorktree", err)
+	}
+	if _, err := applyPatch(t.Context(), top, patch); !errors.Is(err, ErrPatchFailed) {
+		t.Errorf("reapply: err = %v, want ErrPatchFailed", err)
+	}
+}
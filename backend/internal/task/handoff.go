@@ -0,0 +1,141 @@
+// Handoff of a task's conversation to an interactive harness CLI.
+package task
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+// Handoff describes how to continue a task's conversation interactively in
+// its container.
+type Handoff struct {
+	Container string
+	Dir       string // Working directory in the container.
+	SessionID string
+	Command   []string // Harness CLI command line resuming the session.
+}
+
+// SSHCommand returns the shell command opening the harness CLI in the
+// container from the host running caic.
+func (h *Handoff) SSHCommand() string {
+	args := make([]string, len(h.Command))
+	for i, a := range h.Command {
+		args[i] = shellQuote(a)
+	}
+	// Load the profile like installAgent does, so that the pinned CLI is used.
+	remote := "cd " + shellQuote(h.Dir) + " && . ~/.profile >/dev/null 2>&1; exec " + strings.Join(args, " ")
+	return "ssh -t " + shellQuote(h.Container) + " " + shellQuote(remote)
+}
+
+// Script returns a shell script running SSHCommand.
+func (h *Handoff) Script() string {
+	return "#!/bin/sh\n# Continue caic session " + h.SessionID + " in " + h.Container + ".\nexec " + h.SSHCommand() + "\n"
+}
+
+// Handoff pauses t, unless it already is, and marks it handed off so the user
+// can continue the conversation in a terminal. ResumeSession takes it back,
+// including what was done interactively since the harness resumes the same
+// session.
+func (r *Runner) Handoff(ctx context.Context, t *Task) (*Handoff, error) {
+	r.initDefaults()
+	cmd, err := agent.ResumeCommand(t.Harness, t.GetSessionID())
+	if err != nil {
+		return nil, err
+	}
+	if t.GetState() != StatePaused {
+		if err := r.PauseSession(t); err != nil {
+			return nil, err
+		}
+	}
+	// Remember where the interactive session starts in the transcript for
+	// ImportHandoff. Harnesses whose transcript can't be imported are still
+	// handed off.
+	var offset int64
+	if path, err := agent.SessionTranscript(t.Harness, r.containerDir(), t.GetSessionID()); err == nil && r.Container != nil {
+		if offset, err = agent.TranscriptSize(ctx, t.Container, path); err != nil {
+			return nil, err
+		}
+	}
+	t.mu.Lock()
+	if t.handedOffAt.IsZero() {
+		t.handoffOffset = offset
+	}
+	t.handedOffAt = time.Now().UTC()
+	t.mu.Unlock()
+	return &Handoff{Container: t.Container, Dir: r.containerDir(), SessionID: t.GetSessionID(), Command: cmd}, nil
+}
+
+// ImportHandoff merges the messages added to the session in a terminal since
+// Handoff into t's history and log, so that the task stays the record of the
+// whole conversation. It can be called repeatedly while the task is handed
+// off and returns the number of messages imported.
+func (r *Runner) ImportHandoff(ctx context.Context, t *Task) (int, error) {
+	r.initDefaults()
+	t.mu.Lock()
+	handedOff, offset := !t.handedOffAt.IsZero(), t.handoffOffset
+	t.mu.Unlock()
+	if !handedOff {
+		return 0, errors.New("task is not handed off")
+	}
+	path, err := agent.SessionTranscript(t.Harness, r.containerDir(), t.GetSessionID())
+	if err != nil {
+		return 0, err
+	}
+	data, err := agent.ReadTranscript(ctx, t.Container, path, offset)
+	if err != nil {
+		return 0, err
+	}
+	logW, err := r.openLog(t)
+	if err != nil {
+		return 0, err
+	}
+	n, consumed := r.importTranscript(ctx, t, data, logW)
+	t.mu.Lock()
+	t.handoffOffset = offset + consumed
+	t.mu.Unlock()
+	return n, logW.Close()
+}
+
+// importTranscript adds the messages of the complete lines of the session
+// transcript data to t and writes their lines to logW, where RestoreMessages
+// parses them like the harness output. It returns the number of messages
+// added and of bytes consumed.
+func (r *Runner) importTranscript(ctx context.Context, t *Task, data []byte, logW io.Writer) (int, int64) {
+	// The harness may be writing the last line.
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+	b := r.backend(t.Harness)
+	n := 0
+	for line := range bytes.Lines(data) {
+		line = bytes.TrimSuffix(line, []byte("\n"))
+		if len(line) == 0 {
+			continue
+		}
+		msgs, err := b.ParseMessage(line)
+		if err != nil {
+			r.log.Warn("skipping unparseable transcript line", "task", t.ID, "err", err)
+			continue
+		}
+		// Drop the transcript's bookkeeping records, e.g. summaries and file
+		// history snapshots.
+		msgs = slices.DeleteFunc(msgs, func(m agent.Message) bool {
+			raw, ok := m.(*agent.RawMessage)
+			return ok && raw.Unknown
+		})
+		if len(msgs) == 0 {
+			continue
+		}
+		_, _ = logW.Write(append(line, '\n'))
+		for _, m := range msgs {
+			t.addMessage(ctx, m, true)
+		}
+		n += len(msgs)
+	}
+	return n, int64(len(data))
+}
@@ -0,0 +1,74 @@
+package task
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestHandoff(t *testing.T) {
+	r := &Runner{BaseBranch: "main", Dir: "/src/caic"}
+	tk := &Task{Harness: agent.Claude, Container: "md-caic-0"}
+	setState(t, tk, StateWaiting)
+	if _, err := r.Handoff(t.Context(), tk); err == nil {
+		t.Fatal("expected error without a session")
+	}
+	tk.sessionID = "abc"
+	h, err := r.Handoff(t.Context(), tk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = `ssh -t 'md-caic-0' 'cd '\''/home/user/src/caic'\'' && . ~/.profile >/dev/null 2>&1; exec '\''claude'\'' '\''--resume'\'' '\''abc'\'''`
+	if got := h.SSHCommand(); got != want {
+		t.Errorf("SSHCommand() =\n%s\nwant\n%s", got, want)
+	}
+	if snap := tk.Snapshot(); snap.State != StatePaused || snap.HandedOffAt.IsZero() {
+		t.Errorf("state = %s, handed off at %v", snap.State, snap.HandedOffAt)
+	}
+	// Handing off again is fine.
+	if _, err := r.Handoff(t.Context(), tk); err != nil {
+		t.Fatal(err)
+	}
+
+	tk = &Task{Harness: agent.Kilo, Container: "md-caic-1", sessionID: "abc"}
+	setState(t, tk, StateWaiting)
+	if _, err := r.Handoff(t.Context(), tk); err == nil || tk.GetState() != StateWaiting {
+		t.Errorf("Handoff(kilo) = %v, state = %s", err, tk.GetState())
+	}
+}
+
+func TestImportTranscript(t *testing.T) {
+	r := &Runner{}
+	r.initDefaults()
+	tk := &Task{Harness: agent.Claude, Container: "md-caic-0"}
+	setState(t, tk, StatePaused)
+	lines := []string{
+		`{"type":"user","message":{"role":"user","content":"add a test"},"uuid":"u1","sessionId":"abc"}`,
+		`{"type":"file-history-snapshot","messageId":"u1"}`,
+		`{"type":"assistant","message":{"id":"m1","type":"message","role":"assistant","content":[{"type":"text","text":"Done."}]},"uuid":"a1","sessionId":"abc"}`,
+	}
+	data := strings.Join(lines, "\n") + "\n" + `{"type":"assistant","mess`
+	var log bytes.Buffer
+	n, consumed := r.importTranscript(t.Context(), tk, []byte(data), &log)
+	if n != 2 || consumed != int64(len(data)-len(`{"type":"assistant","mess`)) {
+		t.Errorf("importTranscript = %d, %d", n, consumed)
+	}
+	if want := lines[0] + "\n" + lines[2] + "\n"; log.String() != want {
+		t.Errorf("log =\n%s\nwant\n%s", log.String(), want)
+	}
+	msgs := tk.Messages()
+	if len(msgs) != 2 {
+		t.Fatalf("msgs = %#v", msgs)
+	}
+	if u, ok := msgs[0].(*agent.UserInputMessage); !ok || u.Text != "add a test" {
+		t.Errorf("msgs[0] = %#v", msgs[0])
+	}
+	if m, ok := msgs[1].(*agent.TextMessage); !ok || m.Text != "Done." {
+		t.Errorf("msgs[1] = %#v", msgs[1])
+	}
+	if tk.GetState() != StatePaused {
+		t.Errorf("state = %s", tk.GetState())
+	}
+}
@@ -0,0 +1,83 @@
+// Per-repo knowledge: LLM extraction of learnings and injection into prompts.
+package task
+
+import (
+	"context"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/maruel/genai"
+)
+
+const (
+	learningsSystemPrompt = "You are given the transcript of a finished coding agent session on a repository, " +
+		"followed by the notes already known about that repository. Extract durable learnings useful to a future agent " +
+		"working on the same repository: build, test and lint commands that work, gotchas, conventions and style notes. " +
+		"Skip anything specific to this task, already in the notes, or uncertain. " +
+		"Reply with one learning per line, each starting with \"- \", at most 5 lines. Reply NONE if there is nothing new."
+
+	// learningsMaxChars bounds the transcript sent for extraction; the end
+	// of the session is kept since it reflects what finally worked.
+	learningsMaxChars = 40000
+	// learningMaxChars bounds a single learning.
+	learningMaxChars = 300
+)
+
+// ExtractLearnings asks p for repository learnings from t's conversation
+// that are not already in known. It returns nil when the session had no
+// completed turn or nothing new was learned.
+func ExtractLearnings(ctx context.Context, p genai.Provider, t *Task, known string) ([]string, error) {
+	msgs := t.Messages()
+	if lastResult(msgs) == nil {
+		return nil, nil
+	}
+	chunks := transcriptChunks(msgs, learningsMaxChars)
+	input := "# Transcript\n" + chunks[len(chunks)-1] + "\n# Known notes\n" + known
+	text, err := genText(ctx, p, learningsSystemPrompt, input)
+	if err != nil {
+		return nil, err
+	}
+	return parseLearnings(text), nil
+}
+
+// lastResult returns the last ResultMessage in msgs, if any.
+func lastResult(msgs []agent.Message) *agent.ResultMessage {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if rm, ok := msgs[i].(*agent.ResultMessage); ok {
+			return rm
+		}
+	}
+	return nil
+}
+
+// parseLearnings extracts the "- " bullet lines of an LLM reply.
+func parseLearnings(text string) []string {
+	var out []string
+	for line := range strings.Lines(text) {
+		line = strings.TrimSpace(line)
+		l, ok := strings.CutPrefix(line, "- ")
+		if !ok {
+			l, ok = strings.CutPrefix(line, "* ")
+		}
+		if l = strings.TrimSpace(l); !ok || l == "" {
+			continue
+		}
+		if len(l) > learningMaxChars {
+			l = strings.ToValidUTF8(l[:learningMaxChars], "")
+		}
+		out = append(out, l)
+	}
+	return out
+}
+
+// withKnowledge prepends the repository notes to the first prompt of a fresh
+// session. The task's InitialPrompt, shown to users, is left untouched.
+func withKnowledge(p agent.Prompt, knowledge string) agent.Prompt {
+	knowledge = strings.TrimSpace(knowledge)
+	if knowledge == "" {
+		return p
+	}
+	p.Text = "<repository-notes>\nNotes learned by previous agents working on this repository; they may be outdated.\n" +
+		knowledge + "\n</repository-notes>\n\n" + p.Text
+	return p
+}
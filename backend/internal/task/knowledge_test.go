@@ -0,0 +1,55 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestParseLearnings(t *testing.T) {
+	got := parseLearnings("Here you go:\n- Run `make test`.\n* Avoid cgo\n-\nNONE\n- " + strings.Repeat("x", 400))
+	if len(got) != 3 || got[0] != "Run `make test`." || got[1] != "Avoid cgo" || len(got[2]) != learningMaxChars {
+		t.Errorf("got %q", got)
+	}
+	if got := parseLearnings("NONE"); got != nil {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestWithKnowledge(t *testing.T) {
+	p := agent.Prompt{Text: "fix it"}
+	if got := withKnowledge(p, " \n"); got.Text != "fix it" {
+		t.Errorf("empty knowledge changed prompt: %q", got.Text)
+	}
+	got := withKnowledge(p, "- Use make\n")
+	if !strings.HasPrefix(got.Text, "<repository-notes>\n") || !strings.Contains(got.Text, "- Use make\n</repository-notes>") || !strings.HasSuffix(got.Text, "\n\nfix it") {
+		t.Errorf("got %q", got.Text)
+	}
+	if p.Text != "fix it" {
+		t.Error("input mutated")
+	}
+}
+
+func TestExtractLearnings(t *testing.T) {
+	p := &fakeProvider{reply: func(input string, _ int) (string, error) {
+		if !strings.Contains(input, "# Known notes\n- old") {
+			t.Errorf("input = %q", input)
+		}
+		return "- Run go generate first", nil
+	}}
+	tk := &Task{}
+	// No completed turn: nothing to learn and no LLM call.
+	tk.RestoreMessages([]agent.Message{&agent.UserInputMessage{Text: "go"}})
+	if got, err := ExtractLearnings(t.Context(), p, tk, "- old"); err != nil || got != nil || len(p.calls) != 0 {
+		t.Fatalf("got %q, %v, calls = %d", got, err, len(p.calls))
+	}
+	tk.RestoreMessages([]agent.Message{&agent.UserInputMessage{Text: "go"}, &agent.ResultMessage{Result: "done"}})
+	got, err := ExtractLearnings(t.Context(), p, tk, "- old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "Run go generate first" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -17,11 +17,18 @@ import (
 	"github.com/caic-xyz/caic/backend/internal/agent"
 	agentclaude "github.com/caic-xyz/caic/backend/internal/agent/claude"
 	agentcodex "github.com/caic-xyz/caic/backend/internal/agent/codex"
+	agentexternal "github.com/caic-xyz/caic/backend/internal/agent/external"
 	agentgemini "github.com/caic-xyz/caic/backend/internal/agent/gemini"
 	agentkilo "github.com/caic-xyz/caic/backend/internal/agent/kilo"
 	"github.com/caic-xyz/caic/backend/internal/jsonutil"
+	"github.com/caic-xyz/caic/backend/internal/logcrypt"
+	"github.com/maruel/ksid"
 )
 
+// maxLogLine bounds a log line: user input with base64 images can produce
+// very long NDJSON lines, a third longer once encrypted.
+const maxLogLine = 48 << 20
+
 // errNotLogFile is returned when a file doesn't contain a valid caic_meta header.
 var errNotLogFile = errors.New("not a caic log file")
 
@@ -38,6 +45,7 @@ type LoadedTask struct {
 	Title             string
 	Repos             []RepoMount // GitRoot will be empty for purged tasks loaded from logs.
 	Harness           agent.Harness
+	Model             string
 	StartedAt         time.Time
 	LastStateUpdateAt time.Time // Derived from log file mtime; best-effort for adopt.
 	State             State
@@ -47,6 +55,12 @@ type LoadedTask struct {
 	ForgePR           int // PR number created during the task; 0 if none.
 	Msgs              []agent.Message
 	Result            *Result
+	ReplayOf          ksid.ID // Task this one replays; zero otherwise.
+	Kind              Kind
+	AutoResume        bool
+	AskPolicy         *AskPolicy
+	Automation        Automation
+	OwnerID           string
 
 	path string // Absolute path for lazy message loading via LoadMessages.
 }
@@ -142,7 +156,7 @@ func unmarshalMeta(data []byte, m *agent.MetaMessage) error {
 	}
 	var raw map[string]json.RawMessage
 	if err := json.Unmarshal(data, &raw); err == nil {
-		jsonutil.WarnUnknown("caic_meta", jsonutil.CollectUnknown(raw, metaKnown))
+		jsonutil.WarnUnknown("caic", "caic_meta", jsonutil.CollectUnknown(raw, metaKnown))
 	}
 	return nil
 }
@@ -163,12 +177,16 @@ func loadLogHeader(path string) (_ *LoadedTask, retErr error) {
 
 	// Read first line: metadata header.
 	scanner := bufio.NewScanner(f)
-	scanner.Buffer(make([]byte, 0, 4096), 32<<20)
+	scanner.Buffer(make([]byte, 0, 4096), maxLogLine)
 	if !scanner.Scan() {
 		return nil, errNotLogFile
 	}
+	header, err := logcrypt.Open(scanner.Bytes())
+	if err != nil {
+		return nil, err
+	}
 	var meta agent.MetaMessage
-	if err := unmarshalMeta(scanner.Bytes(), &meta); err != nil {
+	if err := unmarshalMeta(header, &meta); err != nil {
 		return nil, errNotLogFile
 	}
 	if err := meta.Validate(); err != nil {
@@ -189,7 +207,7 @@ func loadLogHeader(path string) (_ *LoadedTask, retErr error) {
 
 	repos := make([]RepoMount, len(meta.Repos))
 	for i, mr := range meta.Repos {
-		repos[i] = RepoMount{Name: mr.Name, BaseBranch: mr.BaseBranch, Branch: mr.Branch}
+		repos[i] = RepoMount{Name: mr.Name, BaseBranch: mr.BaseBranch, Branch: mr.Branch, BaseSHA: mr.BaseSHA, SparsePaths: mr.SparsePaths}
 	}
 	lt := &LoadedTask{
 		path:              path,
@@ -198,10 +216,19 @@ func loadLogHeader(path string) (_ *LoadedTask, retErr error) {
 		Title:             meta.Title,
 		Repos:             repos,
 		Harness:           meta.Harness,
+		Model:             meta.Model,
 		StartedAt:         meta.StartedAt,
 		LastStateUpdateAt: info.ModTime().UTC(),
 		State:             StateFailed, // default if no trailer
 		ForgeIssue:        meta.ForgeIssue,
+		Kind:              Kind(meta.Kind),
+		AutoResume:        meta.AutoResume,
+		AskPolicy:         askPolicyFromMeta(meta.AskPolicy),
+		Automation:        Automation(meta.Automation),
+		OwnerID:           meta.Owner,
+	}
+	if meta.ReplayOf != "" {
+		_ = lt.ReplayOf.UnmarshalText([]byte(meta.ReplayOf))
 	}
 
 	// Read the tail of the file to find caic_pr and caic_result records.
@@ -216,6 +243,10 @@ func loadLogHeader(path string) (_ *LoadedTask, retErr error) {
 			if len(line) == 0 {
 				continue
 			}
+			// The first line may be cut; it then fails to decrypt.
+			if line, err = logcrypt.Open(line); err != nil {
+				continue
+			}
 			if bytes.Contains(line, []byte(`"caic_pr"`)) {
 				var mp agent.MetaPRMessage
 				if json.Unmarshal(line, &mp) == nil && mp.ForgePR > 0 {
@@ -229,7 +260,7 @@ func loadLogHeader(path string) (_ *LoadedTask, retErr error) {
 				if err := json.Unmarshal(line, &mr); err == nil {
 					var raw map[string]json.RawMessage
 					if json.Unmarshal(line, &raw) == nil {
-						jsonutil.WarnUnknown("caic_result", jsonutil.CollectUnknown(raw, resultKnown))
+						jsonutil.WarnUnknown("caic", "caic_result", jsonutil.CollectUnknown(raw, resultKnown))
 					}
 					lt.State = parseState(mr.State)
 					if mr.Title != "" {
@@ -274,15 +305,18 @@ func loadLogFile(path string) (_ *LoadedTask, retErr error) {
 	}()
 
 	scanner := bufio.NewScanner(f)
-	// 32 MiB max line: user input with base64 images can produce very long NDJSON lines.
-	scanner.Buffer(make([]byte, 0, 1<<20), 32<<20)
+	scanner.Buffer(make([]byte, 0, 1<<20), maxLogLine)
 
 	// First line must be the metadata header.
 	if !scanner.Scan() {
 		return nil, errNotLogFile
 	}
+	header, err := logcrypt.Open(scanner.Bytes())
+	if err != nil {
+		return nil, err
+	}
 	var meta agent.MetaMessage
-	if err := unmarshalMeta(scanner.Bytes(), &meta); err != nil {
+	if err := unmarshalMeta(header, &meta); err != nil {
 		return nil, errNotLogFile
 	}
 	if err := meta.Validate(); err != nil {
@@ -298,17 +332,26 @@ func loadLogFile(path string) (_ *LoadedTask, retErr error) {
 
 	repos := make([]RepoMount, len(meta.Repos))
 	for i, mr := range meta.Repos {
-		repos[i] = RepoMount{Name: mr.Name, BaseBranch: mr.BaseBranch, Branch: mr.Branch}
+		repos[i] = RepoMount{Name: mr.Name, BaseBranch: mr.BaseBranch, Branch: mr.Branch, BaseSHA: mr.BaseSHA, SparsePaths: mr.SparsePaths}
 	}
 	lt := &LoadedTask{
 		Prompt:            meta.Prompt,
 		Title:             meta.Title,
 		Repos:             repos,
 		Harness:           meta.Harness,
+		Model:             meta.Model,
 		StartedAt:         meta.StartedAt,
 		LastStateUpdateAt: mtime,
 		State:             StateFailed, // default if no trailer
 		ForgeIssue:        meta.ForgeIssue,
+		Kind:              Kind(meta.Kind),
+		AutoResume:        meta.AutoResume,
+		AskPolicy:         askPolicyFromMeta(meta.AskPolicy),
+		Automation:        Automation(meta.Automation),
+		OwnerID:           meta.Owner,
+	}
+	if meta.ReplayOf != "" {
+		_ = lt.ReplayOf.UnmarshalText([]byte(meta.ReplayOf))
 	}
 
 	parseFn := parseFnForHarness(meta.Harness)
@@ -322,6 +365,9 @@ func loadLogFile(path string) (_ *LoadedTask, retErr error) {
 		if len(line) == 0 {
 			continue
 		}
+		if line, err = logcrypt.Open(line); err != nil {
+			continue
+		}
 
 		if err := json.Unmarshal(line, &envelope); err != nil {
 			continue
@@ -344,7 +390,7 @@ func loadLogFile(path string) (_ *LoadedTask, retErr error) {
 			}
 			var raw map[string]json.RawMessage
 			if json.Unmarshal(line, &raw) == nil {
-				jsonutil.WarnUnknown("caic_result", jsonutil.CollectUnknown(raw, resultKnown))
+				jsonutil.WarnUnknown("caic", "caic_result", jsonutil.CollectUnknown(raw, resultKnown))
 			}
 			lt.State = parseState(mr.State)
 			if mr.Title != "" {
@@ -381,6 +427,11 @@ func loadLogFile(path string) (_ *LoadedTask, retErr error) {
 	return lt, scanner.Err()
 }
 
+// ParseFunc returns the wire parser logs of harness h are loaded with.
+func ParseFunc(h agent.Harness) func([]byte) ([]agent.Message, error) {
+	return parseFnForHarness(h)
+}
+
 // parseFnForHarness returns the message parser for the given harness.
 //
 // TODO: This is a layering violation, let's fix this eventually.
@@ -392,9 +443,11 @@ func parseFnForHarness(h agent.Harness) func([]byte) ([]agent.Message, error) {
 		return agentgemini.ParseMessage
 	case agent.Kilo:
 		return agentkilo.ParseMessage
-	default:
-		return agentclaude.ParseMessage
 	}
+	if b := agentexternal.Lookup(h); b != nil {
+		return b.ParseMessage
+	}
+	return agentclaude.ParseMessage
 }
 
 // parseState converts a state string back to a State value.
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/logcrypt"
 )
 
 func writeLogFile(t *testing.T, dir, name string, lines ...string) {
@@ -82,6 +83,34 @@ func TestLoadLogs(t *testing.T) {
 			t.Errorf("State = %v, want %v", tasks[0].State, StatePurged)
 		}
 	})
+	t.Run("Encrypted", func(t *testing.T) {
+		logcrypt.SetKey(&logcrypt.Key{1})
+		t.Cleanup(func() { logcrypt.SetKey(nil) })
+		dir := t.TempDir()
+		meta := mustJSON(t, agent.MetaMessage{MessageType: "caic_meta", Version: 1, Prompt: "secret", Repos: []agent.MetaRepo{{Name: "r", Branch: "caic-0"}}, Harness: "claude"})
+		asst := claudeAssistant(t, map[string]any{"type": "text", "text": "hello"})
+		trailer := mustJSON(t, agent.MetaResultMessage{MessageType: "caic_result", State: "purged"})
+		writeLogFile(t, dir, "a.jsonl", string(logcrypt.Seal([]byte(meta))), string(logcrypt.Seal([]byte(asst))), string(logcrypt.Seal([]byte(trailer))))
+
+		tasks, err := LoadLogs(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 1 || tasks[0].Prompt != "secret" || tasks[0].State != StatePurged {
+			t.Fatalf("got %+v", tasks)
+		}
+		if err := tasks[0].LoadMessages(); err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks[0].Msgs) == 0 {
+			t.Error("no messages")
+		}
+
+		logcrypt.SetKey(nil)
+		if tasks, err := LoadLogs(dir); err != nil || len(tasks) != 0 {
+			t.Errorf("without key: got %d tasks, %v", len(tasks), err)
+		}
+	})
 	t.Run("NotExist", func(t *testing.T) {
 		tasks, err := LoadLogs(filepath.Join(t.TempDir(), "nope"))
 		if err != nil {
@@ -179,7 +208,7 @@ func TestLoadLogs(t *testing.T) {
 		}
 		// After restore, plan state must be empty because context_cleared resets it.
 		tk := &Task{InitialPrompt: agent.Prompt{Text: lt.Prompt}}
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		tk.RestoreMessages(lt.Msgs)
 		snap := tk.Snapshot()
 		if snap.InPlanMode {
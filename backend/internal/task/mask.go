@@ -0,0 +1,136 @@
+// Masking of the secrets the safety scanner looks for, so serving a task's
+// content doesn't expose a credential the agent committed.
+package task
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// maskKeep is the number of leading bytes of a masked token left visible,
+// e.g. "AKIA" or "ghp_", enough to tell what kind of secret it was.
+const maskKeep = 4
+
+var (
+	// armorEnd ends the body of a private key.
+	armorEnd = regexp.MustCompile(`-{5}END\s+[A-Z ]*KEY-{5}`)
+	// armorBody is the base64 of a private key line. It can't start with
+	// "+" so the marker of a diff line is kept.
+	armorBody = regexp.MustCompile(`[A-Za-z0-9/=][A-Za-z0-9+/=]{15,}\s*$`)
+)
+
+// MaskSecrets returns s with the secrets matched by the safety scanner
+// replaced by "*", byte for byte so offsets and line lengths are unchanged,
+// and the number of secrets masked. The first bytes of tokens with a well
+// known prefix are kept; private keys keep their BEGIN and END lines.
+func MaskSecrets(s string) (string, int) {
+	b := []byte(s)
+	n := maskSecrets(b)
+	if n == 0 {
+		return s, 0
+	}
+	return string(b), n
+}
+
+// maskSecrets masks the secrets of b in place and returns how many.
+func maskSecrets(b []byte) int {
+	n := 0
+	armor := false
+	for len(b) != 0 {
+		line := b
+		if i := bytes.IndexByte(b, '\n'); i != -1 {
+			line, b = b[:i], b[i+1:]
+		} else {
+			b = nil
+		}
+		if armor {
+			if armorEnd.Match(line) {
+				armor = false
+			} else if loc := armorBody.FindIndex(line); loc != nil {
+				mask(line[loc[0]:loc[1]])
+			}
+			continue
+		}
+		for _, sp := range secretPatterns {
+			for _, m := range sp.re.FindAllSubmatchIndex(line, -1) {
+				if sp.armor {
+					armor = true
+					n++
+					continue
+				}
+				lo, hi := m[2*sp.group], m[2*sp.group+1]
+				if sp.group == 0 {
+					lo += min(maskKeep, hi-lo)
+				}
+				// A span already masked by a previous pattern, e.g. a token
+				// assigned to a password variable, is only counted once.
+				if bytes.IndexByte(line[lo:hi], '*') == -1 {
+					n++
+				}
+				mask(line[lo:hi])
+			}
+		}
+	}
+	return n
+}
+
+func mask(b []byte) {
+	for i := range b {
+		b[i] = '*'
+	}
+}
+
+// MaskArchive copies the gzipped tarball read from src to dst with the
+// secrets of its files masked, and returns the number of secrets masked.
+// Nothing is written to dst before the first entry is read, so an error
+// reading src early can still be reported instead.
+func MaskArchive(dst io.Writer, src io.Reader) (int, error) {
+	gr, err := gzip.NewReader(src)
+	if err != nil {
+		return 0, fmt.Errorf("mask archive: %w", err)
+	}
+	tr := tar.NewReader(gr)
+	var gw *gzip.Writer
+	var tw *tar.Writer
+	n := 0
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return n, fmt.Errorf("mask archive: %w", err)
+		}
+		// Masking keeps the size, so the header is copied as is.
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			return n, fmt.Errorf("mask archive: %w", err)
+		}
+		if h.Typeflag == tar.TypeReg {
+			n += maskSecrets(b)
+		}
+		if tw == nil {
+			gw = gzip.NewWriter(dst)
+			tw = tar.NewWriter(gw)
+		}
+		if err := tw.WriteHeader(h); err != nil {
+			return n, err
+		}
+		if _, err := tw.Write(b); err != nil {
+			return n, err
+		}
+	}
+	if tw == nil {
+		gw = gzip.NewWriter(dst)
+		tw = tar.NewWriter(gw)
+	}
+	if err := tw.Close(); err != nil {
+		return n, err
+	}
+	return n, gw.Close()
+}
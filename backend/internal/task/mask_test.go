@@ -0,0 +1,112 @@
+package task
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMaskSecrets(t *testing.T) {
+	aws := "AK" + "IAIOSFODNN7EXAMPLE"
+	ghp := "gh" + "p_" + strings.Repeat("a", 36)
+	key := "-----BEGIN RSA PRIV" + "ATE KEY-----"
+	tests := []struct {
+		name string
+		in   string
+		want string
+		n    int
+	}{
+		{"None", "nothing to see\n", "nothing to see\n", 0},
+		{"AWS", "+key = \"" + aws + "\"\n", "+key = \"AKIA" + strings.Repeat("*", 16) + "\"\n", 1},
+		{"Two", aws + " " + aws, "AKIA" + strings.Repeat("*", 16) + " AKIA" + strings.Repeat("*", 16), 2},
+		{"Credential", `pass` + `word = "hunter2hunter2"`, `pass` + `word = "**************"`, 1},
+		{"CredentialToken", `to` + `ken: "` + ghp + `"`, `to` + `ken: "` + strings.Repeat("*", 40) + `"`, 1},
+		{
+			"PrivateKey",
+			" ctx\n+" + key + "\n+MIIEowIBAAKCAQEAvR5n+8a\n+abc/def+ghi=jklmnop\n+-----END RSA PRIVATE KEY-----\n+after = 1\n",
+			" ctx\n+" + key + "\n+" + strings.Repeat("*", 23) + "\n+" + strings.Repeat("*", 19) + "\n+-----END RSA PRIVATE KEY-----\n+after = 1\n",
+			1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n := MaskSecrets(tt.in)
+			if got != tt.want || n != tt.n {
+				t.Errorf("MaskSecrets() = %q, %d\nwant %q, %d", got, n, tt.want, tt.n)
+			}
+			if len(got) != len(tt.in) {
+				t.Errorf("length changed: %d != %d", len(got), len(tt.in))
+			}
+		})
+	}
+}
+
+func TestMaskArchive(t *testing.T) {
+	files := map[string]string{
+		"a.txt":    "id = AK" + "IAIOSFODNN7EXAMPLE\n",
+		"dir/b.go": "package b\n",
+	}
+	var src bytes.Buffer
+	gw := gzip.NewWriter(&src)
+	tw := tar.NewWriter(gw)
+	for _, name := range []string{"a.txt", "dir/b.go"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(files[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var dst bytes.Buffer
+	n, err := MaskArchive(&dst, &src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("masked %d, want 1", n)
+	}
+	gr, err := gzip.NewReader(&dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gr)
+	got := map[string]string{}
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(tr)
+		got[h.Name] = string(b)
+	}
+	want := map[string]string{
+		"a.txt":    "id = AKIA" + strings.Repeat("*", 16) + "\n",
+		"dir/b.go": "package b\n",
+	}
+	if len(got) != len(want) || got["a.txt"] != want["a.txt"] || got["dir/b.go"] != want["dir/b.go"] {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	t.Run("Invalid", func(t *testing.T) {
+		var dst bytes.Buffer
+		if _, err := MaskArchive(&dst, strings.NewReader("not gzip")); err == nil {
+			t.Fatal("expected error")
+		}
+		if dst.Len() != 0 {
+			t.Error("wrote output on error")
+		}
+	})
+}
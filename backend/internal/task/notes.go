@@ -0,0 +1,187 @@
+// Task notes committed as TASK.md on the task branch for reviewers.
+package task
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/md/gitutil"
+)
+
+// NotesFile is the file the task notes are committed as at the root of the
+// task branch.
+const NotesFile = "TASK.md"
+
+// maxTestCommands bounds the commands listed under "How to test".
+const maxTestCommands = 10
+
+// testCommandRe matches shell commands that run a test suite.
+var testCommandRe = regexp.MustCompile(`(^|[\s;&|(])(go test|pytest|python3? -m (pytest|unittest)|(npm|pnpm|yarn|bun)( run)? test|npx (vitest|jest)|cargo (test|nextest)|make (test|check)|ctest|mvn (test|verify)|\./gradlew test|gradle test|bundle exec rspec|tox|deno test|swift test|dotnet test)\b`)
+
+// Notes renders what a reviewer of the task branch needs without access to
+// caic: the prompt, the latest plan, the questions the agent asked with their
+// answers, the test commands that passed and the outcome of the last turn.
+// The result is scrubbed like the log.
+func (t *Task) Notes() string {
+	msgs := t.Messages()
+	snap := t.Snapshot()
+	var b strings.Builder
+	title := snap.Title
+	if title == "" {
+		title = firstLine(t.InitialPrompt.Text)
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	b.WriteString("Task " + t.ID.String() + " run with " + string(t.Harness))
+	if snap.Model != "" {
+		b.WriteString(" (" + snap.Model + ")")
+	}
+	if p := t.Primary(); p != nil && p.Branch != "" {
+		b.WriteString(" on branch " + p.Branch)
+	}
+	b.WriteString(". Maintained by caic from the session and rewritten at each push.\n")
+
+	section(&b, "Prompt", t.InitialPrompt.Text)
+	plan := snap.PlanContent
+	if plan == "" {
+		plan = lastPlan(msgs)
+	}
+	section(&b, "Plan", plan)
+	section(&b, "Decisions", decisions(msgs))
+	if cmds := testCommands(msgs); len(cmds) != 0 {
+		section(&b, "How to test", "```sh\n"+strings.Join(cmds, "\n")+"\n```")
+	}
+	if rm := lastResult(msgs); rm != nil && !rm.IsError {
+		section(&b, "Outcome", rm.Result)
+	}
+	notes := b.String()
+	if t.Scrub != nil {
+		notes, _ = t.Scrub.String(notes)
+	}
+	return notes
+}
+
+// section writes a second level heading followed by body, unless body is
+// empty.
+func section(b *strings.Builder, heading, body string) {
+	if body = strings.TrimSpace(body); body != "" {
+		b.WriteString("\n## " + heading + "\n\n" + body + "\n")
+	}
+}
+
+func firstLine(s string) string {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "\n")
+	return s
+}
+
+// lastPlan returns the plan of the last ExitPlanMode call, if any.
+func lastPlan(msgs []agent.Message) string {
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if tu, ok := msgs[i].(*agent.ToolUseMessage); ok && tu.PlanContent != "" {
+			return tu.PlanContent
+		}
+	}
+	return ""
+}
+
+// decisions lists the questions the agent asked, each followed by the next
+// user input as its answer.
+func decisions(msgs []agent.Message) string {
+	var b strings.Builder
+	var pending []string
+	flush := func(answer string) {
+		for _, q := range pending {
+			b.WriteString("- " + q + "\n")
+		}
+		if len(pending) != 0 {
+			b.WriteString("  - Answer: " + answer + "\n")
+		}
+		pending = nil
+	}
+	for _, m := range msgs {
+		switch v := m.(type) {
+		case *agent.AskMessage:
+			for _, q := range v.Questions {
+				pending = append(pending, firstLine(q.Question))
+			}
+		case *agent.UserInputMessage:
+			if len(pending) != 0 {
+				flush(firstLine(v.Text))
+			}
+		}
+	}
+	flush("unanswered")
+	return b.String()
+}
+
+// testCommands returns the distinct test commands that succeeded, most
+// recent last.
+func testCommands(msgs []agent.Message) []string {
+	var out []string
+	for _, c := range ExtractCommands(msgs) {
+		if !c.Done || c.Error != "" || (c.ExitCode != nil && *c.ExitCode != 0) || !testCommandRe.MatchString(c.Command) {
+			continue
+		}
+		cmd := strings.TrimSpace(c.Command)
+		if c.Cwd != "" {
+			cmd = "(cd " + shellQuote(c.Cwd) + " && " + cmd + ")"
+		}
+		if i := slices.Index(out, cmd); i != -1 {
+			out = append(out[:i], out[i+1:]...)
+		}
+		out = append(out, cmd)
+	}
+	return out[max(0, len(out)-maxTestCommands):]
+}
+
+// commitNotes returns a commit on top of parent in dir setting NotesFile to
+// notes, signed with sign unless nil, without touching the working tree or
+// any ref. It returns parent when the file already has this content.
+func commitNotes(ctx context.Context, dir, parent, notes string, sign *Signing) (string, error) {
+	blob, err := gitStdin(ctx, dir, notes, "hash-object", "-w", "--stdin")
+	if err != nil {
+		return "", err
+	}
+	tree, err := gitutil.RunGit(ctx, dir, "rev-parse", parent+"^{tree}")
+	if err != nil {
+		return "", err
+	}
+	entries, err := gitutil.RunGit(ctx, dir, "ls-tree", tree)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for e := range strings.SplitSeq(entries, "\n") {
+		if _, name, _ := strings.Cut(e, "\t"); e != "" && name != NotesFile {
+			b.WriteString(e + "\n")
+		}
+	}
+	b.WriteString("100644 blob " + blob + "\t" + NotesFile + "\n")
+	newTree, err := gitStdin(ctx, dir, b.String(), "mktree")
+	if err != nil {
+		return "", err
+	}
+	if newTree == tree {
+		return parent, nil
+	}
+	return commitTree(ctx, dir, sign, nil, "Update "+NotesFile, "-p", parent, newTree)
+}
+
+// gitStdin runs git in dir with stdin and returns its trimmed output.
+func gitStdin(ctx context.Context, dir, stdin string, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...) //nolint:gosec // args are constants
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
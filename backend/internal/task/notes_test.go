@@ -0,0 +1,89 @@
+package task
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/md/gitutil"
+	"github.com/maruel/ksid"
+)
+
+func TestNotes(t *testing.T) {
+	bash := func(id, cmd string) *agent.ToolUseMessage {
+		in, _ := json.Marshal(map[string]string{"command": cmd})
+		return &agent.ToolUseMessage{ToolUseID: id, Name: "Bash", Input: in}
+	}
+	failed := 1
+	tk := &Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "Fix the parser\nIt crashes on empty input."}, Harness: "claude", Repos: []RepoMount{{Name: "r", Branch: "caic-1"}}}
+	tk.RestoreMessages([]agent.Message{
+		&agent.AskMessage{ToolUseID: "a", Questions: []agent.AskQuestion{{Question: "Keep the old API?"}}},
+		&agent.UserInputMessage{Text: "No, break it."},
+		bash("1", "go test ./parser"),
+		&agent.ToolResultMessage{ToolUseID: "1", Error: "Exit code 1"},
+		bash("2", "go test ./parser"),
+		&agent.ToolResultMessage{ToolUseID: "2"},
+		bash("3", "ls"),
+		&agent.ToolResultMessage{ToolUseID: "3"},
+		bash("4", "npm test"),
+		&agent.ToolResultMessage{ToolUseID: "4", ExitCode: &failed},
+		&agent.ResultMessage{Result: "Empty input now returns an error."},
+	})
+	got := tk.Notes()
+	for _, want := range []string{
+		"# Fix the parser\n",
+		"on branch caic-1.",
+		"## Prompt\n\nFix the parser\nIt crashes on empty input.\n",
+		"## Decisions\n\n- Keep the old API?\n  - Answer: No, break it.\n",
+		"## How to test\n\n```sh\ngo test ./parser\n```\n",
+		"## Outcome\n\nEmpty input now returns an error.\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "## Plan") || strings.Contains(got, "npm test") {
+		t.Errorf("unexpected section or command in:\n%s", got)
+	}
+}
+
+func TestCommitNotes(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	head, err := gitutil.RevParse(t.Context(), clone, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1, err := commitNotes(t.Context(), clone, "HEAD", "v1\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1 == head {
+		t.Fatal("no commit")
+	}
+	if got, err := gitutil.RunGit(t.Context(), clone, "show", c1+":"+NotesFile); err != nil || got != "v1" {
+		t.Errorf("%s = %q, %v", NotesFile, got, err)
+	}
+	if got, err := gitutil.RunGit(t.Context(), clone, "show", c1+":README.md"); err != nil || got != "hello" {
+		t.Errorf("README.md = %q, %v", got, err)
+	}
+	if c, err := commitNotes(t.Context(), clone, c1, "v1\n", nil); err != nil || c != c1 {
+		t.Errorf("unchanged notes = %q, %v; want %q", c, err, c1)
+	}
+	c2, err := commitNotes(t.Context(), clone, c1, "v2\n", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := gitutil.RunGit(t.Context(), clone, "show", c2+":"+NotesFile); err != nil || got != "v2" {
+		t.Errorf("%s = %q, %v", NotesFile, got, err)
+	}
+	// The working tree and the branch are left alone.
+	if _, err := os.Stat(filepath.Join(clone, NotesFile)); !os.IsNotExist(err) {
+		t.Errorf("%s in the working tree: %v", NotesFile, err)
+	}
+	if got, _ := gitutil.RevParse(t.Context(), clone, "HEAD"); got != head {
+		t.Errorf("HEAD moved to %s", got)
+	}
+}
@@ -0,0 +1,82 @@
+// Context attached by the user to the initial prompt of a task.
+package task
+
+import (
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+// ContextSection is one piece of context: a prior task, a web page or a
+// repository file.
+type ContextSection struct {
+	Kind    string // "task", "url" or "file".
+	Source  string // Task ID, URL or file path.
+	Title   string
+	Content string
+}
+
+// contextPriority orders the material kept first when the budget is tight:
+// files the user picked, then prior tasks, then web pages. The repository
+// knowledge and then the repository map come last.
+var contextPriority = map[string]int{"file": 3, "task": 2, "url": 1}
+
+// AssembleContext fits the repository knowledge and map and the attached
+// sections in budget tokens as counted by tok. It returns the knowledge, the
+// map and the rendered sections to inject, and what had to be truncated or
+// dropped.
+func AssembleContext(knowledge, repoMap string, sections []ContextSection, budget int, tok Tokenizer) (string, string, string, BudgetReport) {
+	parts := make([]BudgetPart, 0, len(sections)+1)
+	overhead := 0
+	for _, s := range sections {
+		open, closing := sectionTags(&s)
+		overhead += tok.Count(open + closing)
+		parts = append(parts, BudgetPart{Name: s.Kind + ":" + s.Source, Priority: contextPriority[s.Kind], Text: strings.TrimSpace(s.Content)})
+	}
+	parts = append(parts,
+		BudgetPart{Name: "knowledge", Text: strings.TrimSpace(knowledge)},
+		BudgetPart{Name: "repomap", Priority: -1, Text: strings.TrimSpace(repoMap)})
+	kept, rep := FitBudget(parts, max(0, budget-overhead), tok)
+	rep.Budget = budget
+	var b strings.Builder
+	var dropped []string
+	for i, s := range sections {
+		if kept[i] == "" && parts[i].Text != "" {
+			dropped = append(dropped, parts[i].Name)
+			continue
+		}
+		open, closing := sectionTags(&s)
+		b.WriteString(open + kept[i] + closing)
+		rep.Used += tok.Count(open + closing)
+	}
+	if len(dropped) != 0 {
+		b.WriteString("[Left out for lack of room: " + strings.Join(dropped, ", ") + "]\n")
+	}
+	return kept[len(sections)], kept[len(sections)+1], b.String(), rep
+}
+
+func sectionTags(s *ContextSection) (string, string) {
+	open := "<" + s.Kind + " source=\"" + escapeAttr(s.Source) + "\""
+	if s.Title != "" {
+		open += " title=\"" + escapeAttr(s.Title) + "\""
+	}
+	return open + ">\n", "\n</" + s.Kind + ">\n"
+}
+
+// withContext prepends the context attached to the task to the first prompt
+// of its first session. The task's InitialPrompt, shown to users, is left
+// untouched.
+func withContext(p agent.Prompt, context string) agent.Prompt {
+	context = strings.TrimSpace(context)
+	if context == "" {
+		return p
+	}
+	p.Text = "<context>\nMaterial attached by the user to this task.\n" + context + "\n</context>\n\n" + p.Text
+	return p
+}
+
+var attrEscaper = strings.NewReplacer(`"`, "&quot;", "\n", " ")
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
@@ -0,0 +1,64 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestAssembleContext(t *testing.T) {
+	tok := TokenizerFor(agent.Claude, "")
+	t.Run("Fits", func(t *testing.T) {
+		knowledge, repoMap, got, rep := AssembleContext("Run make.", "./\n  main.go: Run\n", []ContextSection{
+			{Kind: "task", Source: "abc", Title: `say "hi"`, Content: "Added a flag.\n"},
+			{Kind: "url", Source: "https://x", Content: "doc"},
+		}, 1000, tok)
+		const want = "<task source=\"abc\" title=\"say &quot;hi&quot;\">\nAdded a flag.\n</task>\n<url source=\"https://x\">\ndoc\n</url>\n"
+		if got != want || knowledge != "Run make." || repoMap != "./\n  main.go: Run" || len(rep.Cuts) != 0 {
+			t.Errorf("AssembleContext() = %q, %q, %q, %+v", knowledge, repoMap, got, rep)
+		}
+		if rep.Used == 0 || rep.Used > rep.Budget {
+			t.Errorf("Used = %d", rep.Used)
+		}
+	})
+	t.Run("OverBudget", func(t *testing.T) {
+		big := strings.Repeat("line of text\n", 1000)
+		knowledge, repoMap, got, rep := AssembleContext("Run make.", big, []ContextSection{
+			{Kind: "url", Source: "https://x", Content: big},
+			{Kind: "file", Source: "a.md", Content: big},
+			{Kind: "task", Source: "abc", Content: "short"},
+		}, 3000, tok)
+		// The task and the knowledge fit; the file is truncated before the
+		// page and the map, which are left out.
+		if knowledge != "Run make." || repoMap != "" || strings.Contains(got, "<url") || !strings.Contains(got, "short") || !strings.Contains(got, "text\n[truncated]\n</file>") {
+			t.Errorf("AssembleContext() = %q,\n%s", knowledge, got)
+		}
+		if !strings.HasSuffix(got, "[Left out for lack of room: url:https://x]\n") {
+			t.Errorf("AssembleContext() ends with %q", got[len(got)-60:])
+		}
+		if len(rep.Cuts) != 3 || rep.Cuts[0].Name != "url:https://x" || rep.Cuts[0].Kept != 0 || rep.Cuts[1].Name != "file:a.md" || rep.Cuts[1].Kept == 0 || rep.Cuts[2].Name != "repomap" {
+			t.Errorf("Cuts = %+v", rep.Cuts)
+		}
+		if rep.Used > rep.Budget {
+			t.Errorf("Used %d > Budget %d", rep.Used, rep.Budget)
+		}
+		// Deterministic.
+		_, _, again, _ := AssembleContext("Run make.", big, []ContextSection{
+			{Kind: "url", Source: "https://x", Content: big},
+			{Kind: "file", Source: "a.md", Content: big},
+			{Kind: "task", Source: "abc", Content: "short"},
+		}, 3000, tok)
+		if again != got {
+			t.Error("AssembleContext() isn't deterministic")
+		}
+	})
+
+	p := withContext(agent.Prompt{Text: "fix it"}, "<url source=\"https://x\">\ndoc\n</url>\n")
+	if !strings.HasPrefix(p.Text, "<context>\n") || !strings.HasSuffix(p.Text, "</context>\n\nfix it") {
+		t.Errorf("withContext() = %q", p.Text)
+	}
+	if p := withContext(agent.Prompt{Text: "fix it"}, ""); p.Text != "fix it" {
+		t.Errorf("withContext() = %q", p.Text)
+	}
+}
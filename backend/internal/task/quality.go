@@ -0,0 +1,219 @@
+// Post-turn heuristics flagging suspicious outcomes before the user syncs.
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+// WarningKind identifies the heuristic that raised a Warning.
+type WarningKind string
+
+// Warning kinds.
+const (
+	WarningNoChanges     WarningKind = "noChanges"     // The prompt asked for a fix but the turn changed no file.
+	WarningTestsDeleted  WarningKind = "testsDeleted"  // Test files lost lines without gaining any.
+	WarningLargeDeletion WarningKind = "largeDeletion" // Large deletions in files no prompt mentions.
+	WarningStubsAdded    WarningKind = "stubsAdded"    // TODO/FIXME markers or stubs were added.
+)
+
+// Warning is a suspicious outcome of the last turn that deserves a closer look
+// before syncing.
+type Warning struct {
+	Kind   WarningKind
+	Detail string
+}
+
+const (
+	// largeDeletionLines is the number of deleted lines in one file from which
+	// a deletion is large, when it is also more than largeDeletionRatio times
+	// the lines added.
+	largeDeletionLines = 200
+	largeDeletionRatio = 4
+	// maxWarningPaths bounds the paths listed in a warning.
+	maxWarningPaths = 5
+)
+
+var (
+	// fixPromptRe matches prompts asking for a fix.
+	fixPromptRe = regexp.MustCompile(`(?i)\b(fix(es|ed|ing)?|bugs?|broken|crash(es|ing)?|repair|failing|regression)\b`)
+	// stubRe matches markers of unfinished code.
+	stubRe = regexp.MustCompile(`\b(TODO|FIXME)\b|NotImplementedError|[Nn]ot (yet )?implemented|\bunimplemented!|\btodo!\(`)
+	// testDirs are directory names holding tests.
+	testDirs = []string{"test", "tests", "__tests__", "spec", "testdata"}
+)
+
+// isTestFile reports whether p, a slash separated path, is a test file by
+// the naming conventions of the common languages.
+func isTestFile(p string) bool {
+	dir, name := path.Split(p)
+	for d := range strings.SplitSeq(strings.Trim(dir, "/"), "/") {
+		if slices.Contains(testDirs, d) {
+			return true
+		}
+	}
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	switch {
+	case strings.HasSuffix(stem, "_test"), strings.HasPrefix(stem, "test_"), strings.HasSuffix(stem, "_spec"):
+		return true
+	case strings.HasSuffix(stem, ".test"), strings.HasSuffix(stem, ".spec"):
+		return true
+	case ext == ".java" || ext == ".kt" || ext == ".swift" || ext == ".cs":
+		return strings.HasSuffix(stem, "Test") || strings.HasSuffix(stem, "Tests")
+	}
+	return false
+}
+
+// checkQuality returns the warnings raised by the last turn of msgs, which
+// ends with a ResultMessage. The caller must hold t.mu.
+func (t *Task) checkQuality(msgs []agent.Message) []Warning {
+	if t.ReadOnly() || t.Primary() == nil {
+		return nil
+	}
+	end := -1
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if _, ok := msgs[i].(*agent.ResultMessage); ok {
+			end = i
+			break
+		}
+	}
+	if end == -1 {
+		return nil
+	}
+	rm := msgs[end].(*agent.ResultMessage)
+	start := 0
+	for i := end - 1; i >= 0; i-- {
+		if _, ok := msgs[i].(*agent.ResultMessage); ok {
+			start = i + 1
+			break
+		}
+	}
+	var prompts, turnPrompt []string
+	for i, m := range msgs[:end] {
+		if u, ok := m.(*agent.UserInputMessage); ok {
+			prompts = append(prompts, strings.ToLower(u.Text))
+			if i >= start {
+				turnPrompt = append(turnPrompt, u.Text)
+			}
+		}
+	}
+	var out []Warning
+	if !rm.IsError && fixPromptRe.MatchString(strings.Join(turnPrompt, "\n")) && slices.Equal(rm.DiffStat, diffStatBefore(msgs, start)) {
+		out = append(out, Warning{Kind: WarningNoChanges, Detail: "the prompt asks for a fix but the turn changed no file"})
+	}
+	var tests, large []string
+	for _, f := range rm.DiffStat {
+		switch {
+		case f.Binary || f.Deleted == 0:
+		case isTestFile(f.Path):
+			if f.Added == 0 {
+				tests = append(tests, fmt.Sprintf("%s (-%d)", f.Path, f.Deleted))
+			}
+		case f.Deleted >= largeDeletionLines && f.Deleted > largeDeletionRatio*f.Added && !mentioned(prompts, f.Path):
+			large = append(large, fmt.Sprintf("%s (-%d)", f.Path, f.Deleted))
+		}
+	}
+	if len(tests) != 0 {
+		out = append(out, Warning{Kind: WarningTestsDeleted, Detail: "test files lost lines without gaining any: " + listPaths(tests)})
+	}
+	if len(large) != 0 {
+		out = append(out, Warning{Kind: WarningLargeDeletion, Detail: "large deletions in files no prompt mentions: " + listPaths(large)})
+	}
+	if stubs := stubsAdded(msgs[start:end]); len(stubs) != 0 {
+		out = append(out, Warning{Kind: WarningStubsAdded, Detail: "TODO/FIXME markers or stubs added in " + listPaths(stubs)})
+	}
+	return out
+}
+
+// diffStatBefore returns the diff stat of the branch before msgs[i], from the
+// last DiffStatMessage or ResultMessage preceding it.
+func diffStatBefore(msgs []agent.Message, i int) agent.DiffStat {
+	for i--; i >= 0; i-- {
+		switch m := msgs[i].(type) {
+		case *agent.DiffStatMessage:
+			return m.DiffStat
+		case *agent.ResultMessage:
+			return m.DiffStat
+		}
+	}
+	return nil
+}
+
+// mentioned reports whether a lowercase prompt mentions the file p by name
+// or its directory.
+func mentioned(prompts []string, p string) bool {
+	name := strings.ToLower(path.Base(p))
+	stem := strings.TrimSuffix(name, path.Ext(name))
+	dir := strings.ToLower(path.Base(path.Dir(p)))
+	for _, s := range prompts {
+		if strings.Contains(s, stem) || (dir != "." && strings.Contains(s, dir)) {
+			return true
+		}
+	}
+	return false
+}
+
+// multiEditToolInput is the JSON input schema for the MultiEdit tool_use
+// block.
+type multiEditToolInput struct {
+	FilePath string          `json:"file_path"`
+	Edits    []editToolInput `json:"edits"`
+}
+
+// stubsAdded returns the files whose Write, Edit or MultiEdit calls in msgs
+// added more stub markers than they removed.
+func stubsAdded(msgs []agent.Message) []string {
+	var out []string
+	add := func(p string, n int) {
+		if n > 0 && !slices.Contains(out, p) {
+			out = append(out, p)
+		}
+	}
+	for _, m := range msgs {
+		tu, ok := m.(*agent.ToolUseMessage)
+		if !ok {
+			continue
+		}
+		switch tu.Name {
+		case "Write":
+			var in writeToolInput
+			if json.Unmarshal(tu.Input, &in) == nil {
+				add(in.FilePath, countStubs(in.Content))
+			}
+		case "Edit":
+			var in editToolInput
+			if json.Unmarshal(tu.Input, &in) == nil {
+				add(in.FilePath, countStubs(in.NewString)-countStubs(in.OldString))
+			}
+		case "MultiEdit":
+			var in multiEditToolInput
+			if json.Unmarshal(tu.Input, &in) == nil {
+				n := 0
+				for _, e := range in.Edits {
+					n += countStubs(e.NewString) - countStubs(e.OldString)
+				}
+				add(in.FilePath, n)
+			}
+		}
+	}
+	return out
+}
+
+func countStubs(s string) int {
+	return len(stubRe.FindAllStringIndex(s, -1))
+}
+
+// listPaths joins paths, eliding those past maxWarningPaths.
+func listPaths(paths []string) string {
+	if len(paths) <= maxWarningPaths {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(paths[:maxWarningPaths], ", "), len(paths)-maxWarningPaths)
+}
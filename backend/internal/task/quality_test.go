@@ -0,0 +1,101 @@
+package task
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestIsTestFile(t *testing.T) {
+	for _, p := range []string{"a/b_test.go", "tests/helpers.py", "test_parse.py", "src/x.test.ts", "app/Foo.spec.tsx", "lib/x_spec.rb", "src/FooTest.java", "Tests/BarTests.swift", "pkg/testdata/in.txt"} {
+		if !isTestFile(p) {
+			t.Errorf("isTestFile(%q) = false", p)
+		}
+	}
+	for _, p := range []string{"main.go", "latest.go", "contest/x.py", "src/Testing.java", "attest.ts"} {
+		if isTestFile(p) {
+			t.Errorf("isTestFile(%q) = true", p)
+		}
+	}
+}
+
+func TestCheckQuality(t *testing.T) {
+	edit := func(file, old, new string) *agent.ToolUseMessage {
+		in, _ := json.Marshal(editToolInput{FilePath: file, OldString: old, NewString: new})
+		return &agent.ToolUseMessage{ToolUseID: file, Name: "Edit", Input: in}
+	}
+	kinds := func(ws []Warning) []WarningKind {
+		var out []WarningKind
+		for _, w := range ws {
+			out = append(out, w.Kind)
+		}
+		return out
+	}
+	t.Run("Suspicious", func(t *testing.T) {
+		tk := &Task{InitialPrompt: agent.Prompt{Text: "Fix the parser"}, Repos: []RepoMount{{Name: "r"}}}
+		tk.RestoreMessages([]agent.Message{
+			&agent.UserInputMessage{Text: "Fix the parser"},
+			edit("/src/parser.go", "return nil", "// TODO: handle empty input\nreturn nil"),
+			edit("/src/lexer.go", "// TODO: x", "x()"),
+			&agent.ResultMessage{DiffStat: agent.DiffStat{
+				{Path: "parser.go", Added: 1},
+				{Path: "parser_test.go", Deleted: 40},
+				{Path: "legacy/codec.go", Added: 3, Deleted: 250},
+				{Path: "lexer.go", Added: 1, Deleted: 1},
+			}},
+		})
+		ws := tk.Warnings()
+		if want := []WarningKind{WarningTestsDeleted, WarningLargeDeletion, WarningStubsAdded}; !slices.Equal(kinds(ws), want) {
+			t.Fatalf("got %+v, want %v", ws, want)
+		}
+		for i, want := range []string{"parser_test.go (-40)", "legacy/codec.go (-250)", "/src/parser.go"} {
+			if !strings.HasSuffix(ws[i].Detail, want) {
+				t.Errorf("%s: %q", ws[i].Kind, ws[i].Detail)
+			}
+		}
+	})
+	t.Run("NoChanges", func(t *testing.T) {
+		ds := agent.DiffStat{{Path: "parser.go", Added: 3}}
+		tk := &Task{Repos: []RepoMount{{Name: "r"}}}
+		tk.RestoreMessages([]agent.Message{
+			&agent.UserInputMessage{Text: "Add a parser"},
+			&agent.ResultMessage{DiffStat: ds},
+			&agent.UserInputMessage{Text: "The tests are still failing."},
+			&agent.TextMessage{Text: "They pass for me."},
+			&agent.ResultMessage{DiffStat: ds},
+		})
+		if got := kinds(tk.Warnings()); !slices.Equal(got, []WarningKind{WarningNoChanges}) {
+			t.Errorf("got %v", got)
+		}
+	})
+	t.Run("Clean", func(t *testing.T) {
+		tk := &Task{Repos: []RepoMount{{Name: "r"}}}
+		tk.RestoreMessages([]agent.Message{
+			&agent.UserInputMessage{Text: "Fix the bug and drop the legacy codec"},
+			&agent.ResultMessage{DiffStat: agent.DiffStat{{Path: "legacy/codec.go", Deleted: 250}, {Path: "parser_test.go", Added: 2, Deleted: 1}}},
+		})
+		if ws := tk.Warnings(); len(ws) != 0 {
+			t.Errorf("got %+v", ws)
+		}
+	})
+	t.Run("Live", func(t *testing.T) {
+		tk := &Task{Repos: []RepoMount{{Name: "r"}}}
+		ds := agent.DiffStat{{Path: "a_test.go", Deleted: 5}}
+		for range 2 {
+			tk.addMessage(t.Context(), &agent.UserInputMessage{Text: "go"}, true)
+			tk.addMessage(t.Context(), &agent.ResultMessage{DiffStat: ds}, true)
+		}
+		var got []*agent.WarningMessage
+		for _, m := range tk.Messages() {
+			if wm, ok := m.(*agent.WarningMessage); ok {
+				got = append(got, wm)
+			}
+		}
+		if len(got) != 1 || got[0].Kind != string(WarningTestsDeleted) {
+			t.Errorf("got %+v, want one warning raised once", got)
+		}
+	})
+}
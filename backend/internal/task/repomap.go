@@ -0,0 +1,309 @@
+// Repository map: a compact index of the packages and top-level symbols of a
+// repository, injected into sessions so agents explore less.
+package task
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"maps"
+	"os/exec"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/md/gitutil"
+)
+
+// repoMapMaxFileBytes skips files too large to be hand written, like
+// generated code and vendored bundles.
+const repoMapMaxFileBytes = 256 << 10
+
+// RepoMap is the index of a repository at a commit.
+type RepoMap struct {
+	SHA     string
+	Files   int // Files with at least one symbol.
+	Symbols int
+	Content string
+}
+
+// symbolPatterns extract the top-level declarations of the languages without
+// a dedicated parser, keyed by file extension, in the spirit of ctags.
+var symbolPatterns = map[string]*regexp.Regexp{}
+
+func init() {
+	py := regexp.MustCompile(`(?m)^(?:async\s+def|def|class)\s+([A-Za-z]\w*)`)
+	js := regexp.MustCompile(`(?m)^export\s+(?:default\s+)?(?:declare\s+)?(?:abstract\s+)?(?:async\s+)?(?:function\*?|class|const|let|interface|type|enum)\s+([A-Za-z_$][\w$]*)`)
+	rs := regexp.MustCompile(`(?m)^pub\s+(?:async\s+)?(?:fn|struct|enum|trait|type|mod|const|static)\s+([A-Za-z_]\w*)`)
+	jvm := regexp.MustCompile(`(?m)^(?:public\s+|open\s+)?(?:final\s+|abstract\s+|data\s+|sealed\s+|enum\s+)*(?:class|interface|object|struct|protocol)\s+([A-Za-z_]\w*)`)
+	symbolPatterns[".py"] = py
+	for _, ext := range []string{".js", ".jsx", ".mjs", ".ts", ".tsx"} {
+		symbolPatterns[ext] = js
+	}
+	symbolPatterns[".rs"] = rs
+	for _, ext := range []string{".java", ".kt", ".swift"} {
+		symbolPatterns[ext] = jvm
+	}
+}
+
+// BuildRepoMap indexes the repository in dir at commit sha. Go files are
+// parsed; other languages are scanned with symbolPatterns. Each directory is
+// listed with its package summary followed by its files' exported symbols.
+// The content stops at maxBytes, saying how many files were left out.
+func BuildRepoMap(ctx context.Context, dir, sha string, maxBytes int) (*RepoMap, error) {
+	if !ValidDiffBase(sha) {
+		return nil, fmt.Errorf("invalid commit %q", sha)
+	}
+	out, err := gitutil.RunGit(ctx, dir, "ls-tree", "-r", "-z", "-l", "--end-of-options", sha)
+	if err != nil {
+		return nil, fmt.Errorf("ls-tree: %w", err)
+	}
+	// "<mode> blob <oid> <size>\t<path>" entries.
+	var paths, oids []string
+	for entry := range strings.SplitSeq(strings.TrimSuffix(out, "\x00"), "\x00") {
+		meta, p, ok := strings.Cut(entry, "\t")
+		f := strings.Fields(meta)
+		if !ok || len(f) != 4 || f[1] != "blob" || !indexable(p) {
+			continue
+		}
+		if size, err := strconv.Atoi(f[3]); err != nil || size > repoMapMaxFileBytes {
+			continue
+		}
+		paths = append(paths, p)
+		oids = append(oids, f[2])
+	}
+	blobs, err := catBlobs(ctx, dir, oids)
+	if err != nil {
+		return nil, err
+	}
+
+	type file struct {
+		name    string
+		symbols []string
+	}
+	dirs := map[string][]file{}
+	summaries := map[string]string{}
+	m := &RepoMap{SHA: sha}
+	for i, p := range paths {
+		d, name := path.Split(p)
+		if strings.EqualFold(name, "README.md") {
+			if _, ok := summaries[d]; !ok {
+				summaries[d] = readmeSummary(blobs[i])
+			}
+			continue
+		}
+		syms, doc := fileSymbols(name, blobs[i])
+		if doc != "" {
+			summaries[d] = doc
+		}
+		if len(syms) == 0 {
+			continue
+		}
+		dirs[d] = append(dirs[d], file{name, syms})
+		m.Files++
+		m.Symbols += len(syms)
+	}
+
+	var b strings.Builder
+	left := m.Files
+	for _, d := range slices.Sorted(maps.Keys(dirs)) {
+		var sec strings.Builder
+		name := d
+		if name == "" {
+			name = "./"
+		}
+		sec.WriteString(name)
+		if s := summaries[d]; s != "" {
+			sec.WriteString(": " + s)
+		}
+		sec.WriteString("\n")
+		for _, f := range dirs[d] {
+			sec.WriteString("  " + f.name + ": " + strings.Join(f.symbols, ", ") + "\n")
+		}
+		if b.Len()+sec.Len() > maxBytes {
+			b.WriteString("[" + strconv.Itoa(left) + " more files not listed]\n")
+			break
+		}
+		b.WriteString(sec.String())
+		left -= len(dirs[d])
+	}
+	m.Content = b.String()
+	return m, nil
+}
+
+// indexable reports whether the file at p may hold symbols worth listing.
+func indexable(p string) bool {
+	for _, seg := range strings.Split(path.Dir(p), "/") {
+		if seg == "vendor" || seg == "node_modules" || seg == "third_party" || seg == "testdata" || (strings.HasPrefix(seg, ".") && seg != ".") {
+			return false
+		}
+	}
+	name := path.Base(p)
+	if strings.EqualFold(name, "README.md") {
+		return true
+	}
+	if strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, ".pb.go") || strings.Contains(name, ".min.") || strings.Contains(name, ".gen.") || strings.Contains(name, ".test.") {
+		return false
+	}
+	ext := path.Ext(name)
+	return ext == ".go" || symbolPatterns[ext] != nil
+}
+
+// fileSymbols returns the exported top-level symbols of the file name and,
+// for Go files, the first sentence of the package documentation.
+func fileSymbols(name string, src []byte) ([]string, string) {
+	if path.Ext(name) != ".go" {
+		var out []string
+		for _, sm := range symbolPatterns[path.Ext(name)].FindAllSubmatch(src, -1) {
+			if s := string(sm[1]); !strings.HasPrefix(s, "_") && !slices.Contains(out, s) {
+				out = append(out, s)
+			}
+		}
+		return out, ""
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), name, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, ""
+	}
+	var out []string
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv != nil && len(d.Recv.List) == 1 {
+				recv := receiverType(d.Recv.List[0].Type)
+				if !ast.IsExported(recv) {
+					continue
+				}
+				out = append(out, recv+"."+d.Name.Name)
+				continue
+			}
+			out = append(out, d.Name.Name)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, s := range d.Specs {
+				if ts := s.(*ast.TypeSpec); ts.Name.IsExported() {
+					out = append(out, ts.Name.Name)
+				}
+			}
+		}
+	}
+	return out, firstSentence(f.Doc.Text())
+}
+
+// receiverType returns the name of the type of a method receiver.
+func receiverType(e ast.Expr) string {
+	for {
+		switch t := e.(type) {
+		case *ast.StarExpr:
+			e = t.X
+		case *ast.IndexExpr:
+			e = t.X
+		case *ast.IndexListExpr:
+			e = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// readmeSummary returns the first sentence of the first paragraph of a
+// README that isn't a heading, badge or HTML.
+func readmeSummary(src []byte) string {
+	for para := range strings.SplitSeq(string(src), "\n\n") {
+		para = strings.TrimSpace(para)
+		if para == "" || strings.ContainsAny(para[:1], "#[!<`|=-") {
+			continue
+		}
+		return firstSentence(para)
+	}
+	return ""
+}
+
+// firstSentence returns the first sentence of s on a single line, capped in
+// length.
+func firstSentence(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if i := strings.Index(s, ". "); i >= 0 {
+		s = s[:i+1]
+	}
+	const maxLen = 160
+	if len(s) > maxLen {
+		s = strings.ToValidUTF8(s[:maxLen], "") + "…"
+	}
+	return s
+}
+
+// catBlobs returns the content of the blobs oids in dir, in order.
+func catBlobs(ctx context.Context, dir string, oids []string) ([][]byte, error) {
+	if len(oids) == 0 {
+		return nil, nil
+	}
+	cmd := exec.CommandContext(ctx, "git", "cat-file", "--batch")
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(strings.Join(oids, "\n") + "\n")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	out := make([][]byte, 0, len(oids))
+	r := bufio.NewReader(stdout)
+	for range oids {
+		// "<oid> blob <size>\n<content>\n"
+		hdr, err := r.ReadString('\n')
+		if err != nil {
+			break
+		}
+		f := strings.Fields(hdr)
+		size := -1
+		if len(f) == 3 {
+			size, _ = strconv.Atoi(f[2])
+		}
+		if size < 0 {
+			err = fmt.Errorf("cat-file: unexpected %q", strings.TrimSpace(hdr))
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return nil, err
+		}
+		buf := make([]byte, size+1)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			break
+		}
+		out = append(out, buf[:size])
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("cat-file: %w", err)
+	}
+	if len(out) != len(oids) {
+		return nil, fmt.Errorf("cat-file: read %d of %d blobs", len(out), len(oids))
+	}
+	return out, nil
+}
+
+// withRepoMap prepends the repository map to the first prompt of a fresh
+// session.
+func withRepoMap(p agent.Prompt, repoMap string) agent.Prompt {
+	repoMap = strings.TrimSpace(repoMap)
+	if repoMap == "" {
+		return p
+	}
+	p.Text = "<repository-map>\nLayout of the repository as of the base branch; read the files for details.\n" +
+		repoMap + "\n</repository-map>\n\n" + p.Text
+	return p
+}
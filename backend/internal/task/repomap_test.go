@@ -0,0 +1,72 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestBuildRepoMap(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	files := map[string]string{
+		"README.md":            "# Demo\n\nDemo is a tool to show maps. It does more.\n",
+		"main.go":              "package main\n\nfunc main() {}\n\nfunc Run() {}\n",
+		"pkg/store/doc.go":     "// Package store persists things.\npackage store\n",
+		"pkg/store/kv.go":      "package store\n\ntype KV struct{}\n\nfunc (k *KV) Get() {}\nfunc (k *KV) set() {}\nfunc helper() {}\ntype inner[T any] struct{}\nfunc (i inner[T]) Do() {}\n",
+		"pkg/store/kv_test.go": "package store\n\nfunc TestX() {}\n",
+		"web/app.ts":           "export function render() {}\nexport const App = 1;\nfunction local() {}\n",
+		"tools/gen.py":         "def main():\n    pass\n\nclass Builder:\n    def build(self): pass\n\ndef _private(): pass\n",
+		"vendor/x/x.go":        "package x\n\nfunc Vendored() {}\n",
+	}
+	for p, c := range files {
+		p = filepath.Join(dir, p)
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(c), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "-m", "init")
+	sha, err := resolveCommit(t.Context(), dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := BuildRepoMap(t.Context(), dir, sha, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "./: Demo is a tool to show maps.\n" +
+		"  main.go: Run\n" +
+		"pkg/store/: Package store persists things.\n" +
+		"  kv.go: KV, KV.Get\n" +
+		"tools/\n" +
+		"  gen.py: main, Builder\n" +
+		"web/\n" +
+		"  app.ts: render, App\n"
+	if m.Content != want {
+		t.Errorf("Content =\n%s\nwant\n%s", m.Content, want)
+	}
+	if m.SHA != sha || m.Files != 4 || m.Symbols != 7 {
+		t.Errorf("RepoMap = %+v", m)
+	}
+
+	m, err = BuildRepoMap(t.Context(), dir, sha, 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(m.Content, "[3 more files not listed]\n") {
+		t.Errorf("Content =\n%s", m.Content)
+	}
+
+	p := withRepoMap(agent.Prompt{Text: "fix it"}, m.Content)
+	if !strings.HasPrefix(p.Text, "<repository-map>\n") || !strings.HasSuffix(p.Text, "</repository-map>\n\nfix it") {
+		t.Errorf("withRepoMap() = %q", p.Text)
+	}
+}
@@ -0,0 +1,136 @@
+// Container CPU and memory telemetry sampled over SSH.
+package task
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
+)
+
+// ResourceSample is one CPU and memory measurement of a task's container.
+type ResourceSample struct {
+	At time.Time
+	// CPUPercent is the container's CPU usage since the previous sample, in
+	// percent of one core; 0 for the first sample.
+	CPUPercent float64
+	// MemBytes is the container's memory usage per its cgroup.
+	MemBytes int64
+	// Procs are the heaviest processes by CPU and by RSS.
+	Procs []ProcUsage
+
+	cpuUsec int64 // Cumulative cgroup CPU time, to compute the next CPUPercent.
+}
+
+// ProcUsage is a process inside the container.
+type ProcUsage struct {
+	PID     int
+	Command string
+	// CPUPercent is the ps %CPU: CPU time over the process lifetime.
+	CPUPercent float64
+	RSSBytes   int64
+}
+
+// resourceProbeScript prints the cgroup v2 CPU time and memory usage of the
+// container followed by every process.
+const resourceProbeScript = `printf 'cpu %s\n' "$(awk '/^usage_usec/{print $2}' /sys/fs/cgroup/cpu.stat 2>/dev/null)"; ` +
+	`printf 'mem %s\n' "$(cat /sys/fs/cgroup/memory.current 2>/dev/null)"; ` +
+	`ps -eo pid=,pcpu=,rss=,comm= 2>/dev/null; true`
+
+// maxSampleProcs bounds the processes kept per ranking in a sample.
+const maxSampleProcs = 5
+
+// maxResourceSamples bounds the series kept per task; at the default probe
+// interval it covers 6 hours.
+const maxResourceSamples = 360
+
+// ProbeResources samples CPU and memory usage inside container over SSH.
+func ProbeResources(ctx context.Context, container string) (ResourceSample, error) {
+	var out []byte
+	err := agent.RetrySSH(ctx, func() error {
+		var err error
+		out, err = sshconn.Command(ctx, container, resourceProbeScript).Output()
+		return err
+	})
+	if err != nil {
+		return ResourceSample{}, fmt.Errorf("resource probe: %w", err)
+	}
+	rs := parseResourceProbe(string(out))
+	rs.At = time.Now().UTC()
+	return rs, nil
+}
+
+// parseResourceProbe parses the output of resourceProbeScript. Missing
+// cgroup files leave the container totals at zero.
+func parseResourceProbe(out string) ResourceSample {
+	var rs ResourceSample
+	var procs []ProcUsage
+	s := bufio.NewScanner(strings.NewReader(out))
+	for s.Scan() {
+		line := s.Text()
+		if v, ok := strings.CutPrefix(line, "cpu "); ok {
+			rs.cpuUsec, _ = strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			continue
+		}
+		if v, ok := strings.CutPrefix(line, "mem "); ok {
+			rs.MemBytes, _ = strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			continue
+		}
+		// pid %cpu rss(KiB) comm
+		f := strings.Fields(line)
+		if len(f) < 4 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(f[0])
+		pcpu, err2 := strconv.ParseFloat(f[1], 64)
+		rss, err3 := strconv.ParseInt(f[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		procs = append(procs, ProcUsage{PID: pid, Command: strings.Join(f[3:], " "), CPUPercent: pcpu, RSSBytes: rss * 1024})
+	}
+	// Keep the top consumers of each resource; a leaking agent may use
+	// little CPU and a test suite little memory.
+	slices.SortStableFunc(procs, func(a, b ProcUsage) int { return cmp.Compare(b.CPUPercent, a.CPUPercent) })
+	rs.Procs = append(rs.Procs, procs[:min(maxSampleProcs, len(procs))]...)
+	slices.SortStableFunc(procs, func(a, b ProcUsage) int { return cmp.Compare(b.RSSBytes, a.RSSBytes) })
+	for _, p := range procs[:min(maxSampleProcs, len(procs))] {
+		if !slices.ContainsFunc(rs.Procs, func(q ProcUsage) bool { return q.PID == p.PID }) {
+			rs.Procs = append(rs.Procs, p)
+		}
+	}
+	return rs
+}
+
+// AddResourceSample appends rs to the task's telemetry series, deriving its
+// CPU usage from the previous sample. The oldest samples are dropped beyond
+// maxResourceSamples.
+func (t *Task) AddResourceSample(rs ResourceSample) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n := len(t.resources); n > 0 {
+		prev := &t.resources[n-1]
+		if wall := rs.At.Sub(prev.At); wall > 0 && prev.cpuUsec > 0 && rs.cpuUsec >= prev.cpuUsec {
+			rs.CPUPercent = float64(rs.cpuUsec-prev.cpuUsec) / float64(wall.Microseconds()) * 100
+		}
+	}
+	if len(t.resources) >= maxResourceSamples {
+		t.resources = slices.Delete(t.resources, 0, len(t.resources)-maxResourceSamples+1)
+	}
+	t.resources = append(t.resources, rs)
+}
+
+// ResourceSamples returns a copy of the task's telemetry series, oldest
+// first.
+func (t *Task) ResourceSamples() []ResourceSample {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.resources)
+}
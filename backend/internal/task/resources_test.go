@@ -0,0 +1,44 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseResourceProbe(t *testing.T) {
+	out := "cpu 5000000\n" +
+		"mem 1048576\n" +
+		"    1  0.0  1024 tini\n" +
+		"   12 10.5 900000 node\n" +
+		"   40 95.0  2048 go test\n" +
+		"   41  1.0   512 sh\n" +
+		"garbage\n"
+	rs := parseResourceProbe(out)
+	if rs.cpuUsec != 5000000 || rs.MemBytes != 1048576 {
+		t.Errorf("cpu=%d mem=%d", rs.cpuUsec, rs.MemBytes)
+	}
+	if len(rs.Procs) != 4 {
+		t.Fatalf("Procs = %+v", rs.Procs)
+	}
+	if p := rs.Procs[0]; p.PID != 40 || p.Command != "go test" || p.CPUPercent != 95 || p.RSSBytes != 2048*1024 {
+		t.Errorf("Procs[0] = %+v", p)
+	}
+}
+
+func TestAddResourceSample(t *testing.T) {
+	var tk Task
+	start := time.Now()
+	tk.AddResourceSample(ResourceSample{At: start, cpuUsec: 1_000_000})
+	// 1.5 s of CPU over 1 s of wall time.
+	tk.AddResourceSample(ResourceSample{At: start.Add(time.Second), cpuUsec: 2_500_000})
+	got := tk.ResourceSamples()
+	if len(got) != 2 || got[0].CPUPercent != 0 || got[1].CPUPercent != 150 {
+		t.Errorf("samples = %+v", got)
+	}
+	for i := range maxResourceSamples {
+		tk.AddResourceSample(ResourceSample{At: start.Add(time.Duration(i+2) * time.Second)})
+	}
+	if n := len(tk.ResourceSamples()); n != maxResourceSamples {
+		t.Errorf("len = %d, want %d", n, maxResourceSamples)
+	}
+}
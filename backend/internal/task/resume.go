@@ -0,0 +1,63 @@
+// Automatic continuation of a turn interrupted by the loss of the relay.
+package task
+
+import (
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+const (
+	// resumeRecapMessages is how many of the last relevant messages are
+	// recapped in the resume prompt.
+	resumeRecapMessages = 10
+	// resumeMaxMessageChars bounds each recapped message.
+	resumeMaxMessageChars = 500
+)
+
+// ResumePrompt returns the prompt continuing a turn cut short when the relay
+// was lost and the session had to be restarted with --resume: a recap of the
+// last messages of msgs and of the todo items still open, asking the agent
+// to continue where it left off.
+func ResumePrompt(msgs []agent.Message) agent.Prompt {
+	var recap []string
+	var todos []agent.TodoItem
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if todos == nil {
+			if tm, ok := msgs[i].(*agent.TodoMessage); ok {
+				todos = tm.Todos
+			}
+		}
+		if len(recap) == resumeRecapMessages {
+			continue
+		}
+		if s := renderForSummary(msgs[i]); s != "" {
+			if len(s) > resumeMaxMessageChars {
+				s = strings.ToValidUTF8(s[:resumeMaxMessageChars], "") + " […]"
+			}
+			recap = append(recap, s)
+		}
+	}
+	var b strings.Builder
+	b.WriteString("The connection to this session was lost while you were working and it was restarted. Nothing you did was undone.\n")
+	if len(recap) != 0 {
+		b.WriteString("\nThe last messages were:\n")
+		for i := len(recap) - 1; i >= 0; i-- {
+			b.WriteString("- " + strings.ReplaceAll(recap[i], "\n", " ") + "\n")
+		}
+	}
+	var open []string
+	for _, td := range todos {
+		if td.Status != "completed" {
+			open = append(open, td.Content)
+		}
+	}
+	if len(open) != 0 {
+		b.WriteString("\nYour open todo items are:\n")
+		for _, s := range open {
+			b.WriteString("- " + s + "\n")
+		}
+	}
+	b.WriteString("\nCheck the state of the working tree, then continue where you left off.")
+	return agent.Prompt{Text: b.String()}
+}
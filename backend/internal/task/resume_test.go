@@ -0,0 +1,44 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestResumePrompt(t *testing.T) {
+	t.Run("Recap", func(t *testing.T) {
+		msgs := []agent.Message{
+			&agent.TodoMessage{Todos: []agent.TodoItem{{Content: "old", Status: "pending"}}},
+			&agent.TodoMessage{Todos: []agent.TodoItem{{Content: "parse", Status: "completed"}, {Content: "test", Status: "in_progress"}, {Content: "docs", Status: "pending"}}},
+		}
+		for i := range 12 {
+			msgs = append(msgs, &agent.TextMessage{Text: fmt.Sprintf("step %d\ndone", i)})
+		}
+		msgs = append(msgs, &agent.TextDeltaMessage{Text: "ignored"}, &agent.TextMessage{Text: strings.Repeat("x", 600)})
+		got := ResumePrompt(msgs).Text
+		for _, want := range []string{
+			"\n- Assistant: step 3 done\n",
+			"\n- Assistant: step 11 done\n- Assistant: " + strings.Repeat("x", 489) + " […]\n",
+			"Your open todo items are:\n- test\n- docs\n",
+			"continue where you left off.",
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("missing %q in:\n%s", want, got)
+			}
+		}
+		for _, bad := range []string{"step 2 ", "ignored", "- parse", "- old"} {
+			if strings.Contains(got, bad) {
+				t.Errorf("unexpected %q in:\n%s", bad, got)
+			}
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		got := ResumePrompt(nil).Text
+		if strings.Contains(got, "last messages") || strings.Contains(got, "todo") || !strings.Contains(got, "continue where you left off") {
+			t.Errorf("got:\n%s", got)
+		}
+	})
+}
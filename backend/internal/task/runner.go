@@ -19,8 +19,12 @@ import (
 	"github.com/caic-xyz/caic/backend/internal/agent"
 	"github.com/caic-xyz/caic/backend/internal/agent/claude"
 	"github.com/caic-xyz/caic/backend/internal/agent/codex"
+	"github.com/caic-xyz/caic/backend/internal/container"
+	"github.com/caic-xyz/caic/backend/internal/logcrypt"
+	"github.com/caic-xyz/caic/backend/internal/sshconn"
 	"github.com/caic-xyz/md"
 	"github.com/caic-xyz/md/gitutil"
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -31,9 +35,14 @@ type StartOptions struct {
 	Tailscale   bool
 	USB         bool
 	Display     bool
+	GPU         bool
 	// LogWriter receives provisioning log lines. When non-nil, the container
 	// backend should set Quiet=false and write its progress messages here.
 	LogWriter io.Writer
+	// Clones are shell commands seeding the container clones from upstream,
+	// run by Connect once SSH is up and before the repos are pushed. A
+	// failed command is logged and the repo is pushed in full.
+	Clones []string
 }
 
 // ContainerBackend abstracts md container lifecycle operations for testability.
@@ -55,6 +64,11 @@ type ContainerBackend interface {
 	// Revive restarts a stopped (exited) container, re-establishes SSH, and
 	// waits for connectivity. The container's filesystem is preserved.
 	Revive(ctx context.Context, name string, repos []md.Repo) error
+	// SparseCheckout restricts the working tree of repo in container name to
+	// the given directories.
+	SparseCheckout(ctx context.Context, name string, repo md.Repo, paths []string) error
+	// Run runs the shell command in container name.
+	Run(ctx context.Context, name, command string) error
 }
 
 // Result holds the outcome of a completed task.
@@ -74,8 +88,13 @@ type Runner struct {
 	BaseBranch            string
 	Dir                   string        // Absolute path to the git repository.
 	GitTimeout            time.Duration // Timeout for git/container ops; defaults to 1 minute.
+	Git                   GitOptions    // Per-repo git tuning; zero timeouts default to GitTimeout.
 	ContainerStartTimeout time.Duration // Timeout for container start (image pull); defaults to 1 hour.
 	LogDir                string        // Directory for raw JSONL session logs (required).
+	// DeadLetterDir enables strict parsing: the wire lines the harness
+	// parser fails to decode or doesn't know are also appended to a file per
+	// task in this directory. Empty disables.
+	DeadLetterDir string
 
 	// Container provides md container lifecycle operations. Must be set before
 	// calling Start.
@@ -83,11 +102,15 @@ type Runner struct {
 	// Backends maps harness names to their Backend implementations. The runner
 	// selects the backend matching Task.Harness.
 	Backends map[agent.Harness]agent.Backend
+	// AgentVersions pins harness CLI versions; the pinned version is
+	// installed in the container before the agent starts.
+	AgentVersions map[agent.Harness]string
 
 	log      *slog.Logger
 	initOnce sync.Once
-	branchMu sync.Mutex // Serializes branch creation (nextID + git branch) to avoid duplicate names.
-	nextID   int        // Next branch sequence number (protected by branchMu).
+	branchMu sync.Mutex    // Serializes branch creation (nextID + git branch) to avoid duplicate names.
+	nextID   int           // Next branch sequence number (protected by branchMu).
+	fetchSem chan struct{} // Bounds concurrent fetches to Git.MaxFetches; nil is unlimited.
 }
 
 // provisioningWriter is an io.Writer that converts line-by-line output from the
@@ -117,10 +140,7 @@ func (w *provisioningWriter) Write(p []byte) (int, error) {
 func (r *Runner) initDefaults() {
 	r.initOnce.Do(func() {
 		if r.Backends == nil {
-			r.Backends = map[agent.Harness]agent.Backend{
-				agent.Claude: claude.New(),
-				agent.Codex:  codex.New(),
-			}
+			r.Backends = DefaultBackends()
 		}
 		if r.GitTimeout == 0 {
 			r.GitTimeout = time.Minute
@@ -128,6 +148,10 @@ func (r *Runner) initDefaults() {
 		if r.ContainerStartTimeout == 0 {
 			r.ContainerStartTimeout = time.Hour
 		}
+		r.Git = r.Git.withDefaults(r.GitTimeout)
+		if r.Git.MaxFetches > 0 {
+			r.fetchSem = make(chan struct{}, r.Git.MaxFetches)
+		}
 		repoName := filepath.Base(r.Dir)
 		if r.Dir == "" {
 			repoName = "(none)"
@@ -136,6 +160,49 @@ func (r *Runner) initDefaults() {
 	})
 }
 
+// acquireFetch waits for a fetch slot and returns the function releasing it.
+func (r *Runner) acquireFetch(ctx context.Context) (func(), error) {
+	if r.fetchSem == nil {
+		return func() {}, nil
+	}
+	select {
+	case r.fetchSem <- struct{}{}:
+		return func() { <-r.fetchSem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetchOrigin fetches origin into the host clone within Git.MaxFetches.
+func (r *Runner) fetchOrigin(ctx context.Context) error {
+	release, err := r.acquireFetch(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fetchOrigin(ctx, r.Dir, &r.Git)
+}
+
+// fetchContainer fetches the branches of repos from the task container into
+// the host clones within Git.MaxFetches.
+func (r *Runner) fetchContainer(ctx context.Context, repos []md.Repo) error {
+	release, err := r.acquireFetch(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return r.Container.Fetch(ctx, repos)
+}
+
+// DefaultBackends returns new instances of the built-in agent backends a
+// Runner uses when Backends is not set.
+func DefaultBackends() map[agent.Harness]agent.Backend {
+	return map[agent.Harness]agent.Backend{
+		agent.Claude: claude.New(),
+		agent.Codex:  codex.New(),
+	}
+}
+
 // backend returns the Backend for the given agent name.
 func (r *Runner) backend(name agent.Harness) agent.Backend {
 	return r.Backends[name]
@@ -150,6 +217,24 @@ func (r *Runner) containerDir() string {
 	return "/home/user/src/" + filepath.Base(r.Dir)
 }
 
+// installAgent installs the pinned version of the task's harness CLI in its
+// container. The installer output is streamed as log messages.
+func (r *Runner) installAgent(ctx context.Context, t *Task, version string) error {
+	script, err := agent.InstallScript(t.Harness, version)
+	if err != nil {
+		return err
+	}
+	r.log.Info("installing agent", "ctr", t.Container, "hns", t.Harness, "version", version)
+	w := &provisioningWriter{ctx: ctx, t: t}
+	cmd := sshconn.Command(ctx, t.Container, script)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("install %s %s: %w", t.Harness, version, err)
+	}
+	return nil
+}
+
 // Init sets nextID past any existing caic-* branches so that restarts don't
 // waste attempts on branches that already exist. No-op for no-repo runners.
 func (r *Runner) Init(ctx context.Context) error {
@@ -187,10 +272,13 @@ func (r *Runner) Init(ctx context.Context) error {
 //   - Relay attach: keeps StateWaiting/StateAsking if agent already finished its
 //     turn; transitions to StateRunning only if the agent was mid-output.
 //   - --resume fallback: always transitions to StateRunning since a new agent
-//     process is started.
+//     process is started. When the agent was mid-turn and t.AutoResume is
+//     set, ResumePrompt is sent so the agent continues without user input.
 //   - All-fail: reverts to StateWaiting.
-func (r *Runner) Reconnect(ctx context.Context, t *Task, skipSideEffects bool) (*SessionHandle, error) {
+func (r *Runner) Reconnect(ctx context.Context, t *Task, skipSideEffects bool) (_ *SessionHandle, err error) {
 	r.initDefaults()
+	ctx, span := startSpan(ctx, "task.Reconnect", t)
+	defer func() { agent.EndSpan(span, err) }()
 	if t.HasSession() {
 		return nil, errors.New("session already active")
 	}
@@ -227,38 +315,57 @@ func (r *Runner) Reconnect(ctx context.Context, t *Task, skipSideEffects bool) (
 		// If the agent had already completed its turn, keep the inferred
 		// StateWaiting/StateAsking so the UI shows the correct status.
 		if prevState != StateWaiting && prevState != StateAsking {
-			t.SetState(StateRunning)
+			err = t.Transition(StateRunning)
 		}
-		session, err = r.backend(t.Harness).AttachRelay(ctx, &agent.Options{
-			Container:       t.Container,
-			RelayOffset:     t.RelayOffset,
-			ResumeSessionID: t.GetSessionID(),
-		}, msgCh, logW)
-		if err != nil {
+		if err == nil {
+			session, err = r.backend(t.Harness).AttachRelay(ctx, &agent.Options{
+				Container:       t.Container,
+				RelayOffset:     t.RelayOffset,
+				ResumeSessionID: t.GetSessionID(),
+			}, msgCh, logW)
+		}
+		var te *TransitionError
+		if err != nil && !errors.As(err, &te) {
 			// Relay died between the IsRelayRunning check and the attach
-			// attempt. This is a known race; fall back to --resume.
-			r.log.Warn("attach relay failed, using --resume", "br", primaryBranch, "ctr", t.Container, "err", err)
+			// attempt, a known race, or it was started by a caic speaking
+			// another relay protocol. Fall back to --resume.
+			var verErr *agent.RelayVersionError
+			if errors.As(err, &verErr) {
+				r.log.Warn("relay protocol mismatch, using --resume", "br", primaryBranch, "ctr", t.Container, "err", err)
+			} else {
+				r.log.Warn("attach relay failed, using --resume", "br", primaryBranch, "ctr", t.Container, "err", err)
+			}
 			relayAlive = false
 		}
 	}
 	if !relayAlive {
 		// Starting a new session via --resume always re-engages the agent.
-		t.SetState(StateRunning)
-		session, err = r.backend(t.Harness).Start(ctx, &agent.Options{
-			Container:       t.Container,
-			Dir:             r.containerDir(),
-			Model:           t.Model,
-			ResumeSessionID: t.GetSessionID(),
-		}, msgCh, logW)
+		if err = t.Transition(StateRunning); err == nil {
+			session, err = r.backend(t.Harness).Start(ctx, &agent.Options{
+				Container:       t.Container,
+				Dir:             r.containerDir(),
+				Model:           t.Model,
+				ResumeSessionID: t.GetSessionID(),
+				ReadOnly:        t.ReadOnly(),
+			}, msgCh, logW)
+		}
+		if err == nil && t.AutoResume && prevState == StateRunning {
+			p := t.ScrubPrompt(ResumePrompt(t.Messages()))
+			r.log.Info("resuming interrupted turn", "br", primaryBranch, "ctr", t.Container)
+			t.addMessage(ctx, syntheticUserInput(p), true)
+			if serr := session.Send(p); serr != nil {
+				r.log.Warn("send resume prompt failed", "br", primaryBranch, "ctr", t.Container, "err", serr)
+			}
+		}
 	}
 	if err != nil {
 		_ = logW.Close()
 		close(msgCh)
 		<-dispatchDone
 		// Both attach and --resume failed. Revert to StateWaiting so the
-		// user can try again (restart) or purge.
-		t.SetState(StateWaiting)
-		return nil, fmt.Errorf("reconnect: %w", err)
+		// user can try again (restart) or purge. This fails too when the
+		// task moved on meanwhile, e.g. to StatePurging.
+		return nil, fmt.Errorf("reconnect: %w", errors.Join(err, t.Transition(StateWaiting)))
 	}
 
 	h := &SessionHandle{Session: session, MsgCh: msgCh, DispatchDone: dispatchDone, LogW: logW}
@@ -279,22 +386,25 @@ func (r *Runner) Reconnect(ctx context.Context, t *Task, skipSideEffects bool) (
 //  4. Send the initial prompt to the agent.
 //
 // The session is left open for follow-up messages via SendInput.
-func (r *Runner) Start(ctx context.Context, t *Task) (*SessionHandle, error) {
+func (r *Runner) Start(ctx context.Context, t *Task) (_ *SessionHandle, err error) {
 	r.initDefaults()
+	ctx, span := startSpan(ctx, "task.Start", t)
+	defer func() { agent.EndSpan(span, err) }()
 	if r.Container == nil {
 		return nil, errors.New("runner has no container backend configured")
 	}
 	if r.Dir != "" {
-		t.SetState(StateBranching)
+		if err := t.Transition(StateBranching); err != nil {
+			return nil, err
+		}
 	}
 
 	tStart := time.Now()
 	// 1. Create branch (serialized) + start container (concurrent).
 	r.log.Info("setup task")
-	sr, err := r.setup(ctx, t, []string{"caic=" + t.ID.String(), "harness=" + string(t.Harness)})
+	sr, err := r.setup(ctx, t)
 	if err != nil {
-		t.SetState(StateFailed)
-		return nil, err
+		return nil, errors.Join(err, t.Transition(StateFailed))
 	}
 	t.Container = sr.Container
 	t.TailscaleFQDN = sr.TailscaleFQDN
@@ -305,14 +415,20 @@ func (r *Runner) Start(ctx context.Context, t *Task) (*SessionHandle, error) {
 	r.log.Info("container ready", "br", primaryBranch, "ctr", t.Container, "dur", time.Since(tStart))
 
 	// 2. Start the agent session.
-	t.SetState(StateStarting)
+	if err := t.Transition(StateStarting); err != nil {
+		return nil, err
+	}
+	if v := r.AgentVersions[t.Harness]; v != "" {
+		if err := r.installAgent(ctx, t, v); err != nil {
+			return nil, errors.Join(err, t.Transition(StateFailed))
+		}
+	}
 	msgCh, dispatchDone := r.startMessageDispatch(ctx, t, false)
 	logW, err := r.openLog(t)
 	if err != nil {
 		close(msgCh)
 		<-dispatchDone
-		t.SetState(StateFailed)
-		return nil, err
+		return nil, errors.Join(err, t.Transition(StateFailed))
 	}
 
 	tSession := time.Now()
@@ -322,15 +438,15 @@ func (r *Runner) Start(ctx context.Context, t *Task) (*SessionHandle, error) {
 		Container:     t.Container,
 		Dir:           r.containerDir(),
 		Model:         t.Model,
-		InitialPrompt: t.InitialPrompt,
+		InitialPrompt: withKnowledge(withRepoMap(withContext(t.InitialPrompt, t.Context), t.RepoMap), t.Knowledge),
+		ReadOnly:      t.ReadOnly(),
 	}, msgCh, logW)
 	if err != nil {
 		_ = logW.Close()
 		close(msgCh)
 		<-dispatchDone
-		t.SetState(StateFailed)
 		tlog.Error("session start failed", "err", err)
-		return nil, err
+		return nil, errors.Join(err, t.Transition(StateFailed))
 	}
 
 	// Store handle so SendInput can reach it.
@@ -338,7 +454,11 @@ func (r *Runner) Start(ctx context.Context, t *Task) (*SessionHandle, error) {
 	t.AttachSession(h)
 
 	t.addMessage(ctx, syntheticUserInput(t.InitialPrompt), false)
-	t.SetState(StateRunning)
+	if err := t.Transition(StateRunning); err != nil {
+		// The task was stopped or purged meanwhile; return the session
+		// anyway so that the caller's watcher drains it.
+		tlog.Warn("agent started", "err", err)
+	}
 	tlog.Info("agent running", "session_dur", time.Since(tSession), "total_startup_dur", time.Since(tStart))
 	return h, nil
 }
@@ -385,7 +505,9 @@ func (r *Runner) Cleanup(ctx context.Context, t *Task, reason State) Result {
 		}
 	}
 
-	t.SetState(reason)
+	if err := t.Transition(reason); err != nil {
+		tlog.Warn("cleanup", "err", err)
+	}
 
 	tlog.Info("purge container")
 	if name != "" && r.Container != nil {
@@ -466,7 +588,9 @@ func (r *Runner) StopTask(ctx context.Context, t *Task) {
 		}
 	}
 
-	t.SetState(StateStopping)
+	if err := t.Transition(StateStopping); err != nil {
+		tlog.Warn("stop", "err", err)
+	}
 
 	tlog.Info("stop container")
 	if name != "" && r.Container != nil {
@@ -484,7 +608,9 @@ func (r *Runner) StopTask(ctx context.Context, t *Task) {
 		<-h.DispatchDone
 	}
 
-	t.SetState(StateStopped)
+	if err := t.Transition(StateStopped); err != nil {
+		tlog.Warn("stop", "err", err)
+	}
 
 	var logW io.WriteCloser
 	if h != nil {
@@ -514,34 +640,117 @@ func (r *Runner) ReviveTask(ctx context.Context, t *Task) (*SessionHandle, error
 	tlog := r.log.With("br", primaryBranch, "ctr", t.Container)
 
 	// 1. Revive the container (docker start + SSH).
-	t.SetState(StateProvisioning)
+	if err := t.Transition(StateProvisioning); err != nil {
+		return nil, err
+	}
 	repos := t.MDRepos()
 	tlog.Info("reviving container")
 	if err := r.Container.Revive(ctx, t.Container, repos); err != nil {
-		t.SetState(StateFailed)
-		return nil, fmt.Errorf("revive container: %w", err)
+		return nil, fmt.Errorf("revive container: %w", errors.Join(err, t.Transition(StateFailed)))
 	}
 
 	// 2. Reconnect to the agent (attach relay or --resume).
-	t.SetState(StateStarting)
+	if err := t.Transition(StateStarting); err != nil {
+		return nil, err
+	}
 	tlog.Info("reconnecting after revive", "sess", t.GetSessionID())
 	h, err := r.Reconnect(ctx, t, false)
 	if err != nil {
-		t.SetState(StateFailed)
-		return nil, fmt.Errorf("reconnect after revive: %w", err)
+		return nil, fmt.Errorf("reconnect after revive: %w", errors.Join(err, t.Transition(StateFailed)))
 	}
 
 	// 3. If --resume caused the session to exit immediately (e.g. previous
 	// session was already complete), start a fresh idle session.
 	h, err = r.EnsureSession(ctx, t, h, tlog)
 	if err != nil {
-		t.SetState(StateFailed)
-		return nil, err
+		return nil, errors.Join(err, t.Transition(StateFailed))
 	}
 	tlog.Info("agent ready after revive", "state", t.GetState())
 	return h, nil
 }
 
+// PauseSession closes the agent session of a live task and moves it to
+// StatePaused. Unlike StopTask, the container is kept running so that
+// ResumeSession doesn't have to provision it again; the relay output stays
+// in the container and the conversation is continued via --resume.
+func (r *Runner) PauseSession(t *Task) error {
+	r.initDefaults()
+	if state := t.GetState(); !CanTransition(state, StatePaused) {
+		return &TransitionError{From: state, To: StatePaused}
+	}
+	h := t.CloseAndDetachSession()
+	if h != nil {
+		// Drain the final messages of the session first; StatePaused only
+		// moves on through ResumeSession.
+		h.CloseMsgCh()
+		<-h.DispatchDone
+		if h.LogW != nil {
+			_ = h.LogW.Close()
+		}
+	}
+	return t.Transition(StatePaused)
+}
+
+// ResumeSession reconnects to the agent of a task paused by PauseSession or
+// Handoff, importing the messages of a handed off session first. It attaches
+// to the relay if the agent didn't exit yet, or resumes the session via
+// --resume. On failure the task is left paused so the user can retry.
+func (r *Runner) ResumeSession(ctx context.Context, t *Task) (*SessionHandle, error) {
+	r.initDefaults()
+	var primaryBranch string
+	if p := t.Primary(); p != nil {
+		primaryBranch = p.Branch
+	}
+	tlog := r.log.With("br", primaryBranch, "ctr", t.Container)
+	if !t.Snapshot().HandedOffAt.IsZero() {
+		// Catch up with what was done in the terminal before the harness
+		// continues the session.
+		if n, err := r.ImportHandoff(ctx, t); err != nil {
+			tlog.Warn("import handed off session failed", "err", err)
+		} else if n > 0 {
+			tlog.Info("imported handed off session", "msgs", n)
+		}
+	}
+	if err := t.Transition(StateStarting); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.handedOffAt = time.Time{}
+	t.mu.Unlock()
+	tlog.Info("resuming session", "sess", t.GetSessionID())
+	h, err := r.Reconnect(ctx, t, false)
+	if err == nil {
+		h, err = r.EnsureSession(ctx, t, h, tlog)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resume: %w", errors.Join(err, t.Transition(StatePaused)))
+	}
+	tlog.Info("agent ready after resume", "state", t.GetState())
+	return h, nil
+}
+
+// RetryTurn restarts the agent of a task whose turn makes no progress, e.g.
+// because the model or the network hangs: the session is paused, the agent
+// killed, then the session resumed with --resume and the agent asked to
+// continue with ResumePrompt. On failure the task is left paused so the user
+// can retry.
+func (r *Runner) RetryTurn(ctx context.Context, t *Task) (*SessionHandle, error) {
+	if err := r.PauseSession(t); err != nil {
+		return nil, err
+	}
+	if err := agent.KillAgent(ctx, t.Container); err != nil {
+		return nil, err
+	}
+	h, err := r.ResumeSession(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.SendInput(ctx, ResumePrompt(t.Messages())); err != nil {
+		return h, fmt.Errorf("retry: %w", err)
+	}
+	return h, nil
+}
+
 // EnsureSession waits briefly for h to confirm it's alive. If the session
 // exits within 10 seconds (e.g. --resume found a completed session), it
 // starts a fresh idle relay so the task can accept new prompts.
@@ -575,6 +784,7 @@ func (r *Runner) EnsureSession(ctx context.Context, t *Task, h *SessionHandle, t
 // and the task stays in its current state (typically StateWaiting).
 func (r *Runner) StartSession(ctx context.Context, t *Task, prompt agent.Prompt) (*SessionHandle, error) {
 	r.initDefaults()
+	prompt = t.ScrubPrompt(prompt)
 	if t.Container == "" {
 		return nil, errors.New("no container")
 	}
@@ -598,6 +808,7 @@ func (r *Runner) StartSession(ctx context.Context, t *Task, prompt agent.Prompt)
 		Dir:           r.containerDir(),
 		Model:         t.Model,
 		InitialPrompt: prompt,
+		ReadOnly:      t.ReadOnly(),
 	}, msgCh, logW)
 	if err != nil {
 		_ = logW.Close()
@@ -611,7 +822,9 @@ func (r *Runner) StartSession(ctx context.Context, t *Task, prompt agent.Prompt)
 	t.AttachSession(h)
 	if prompt.Text != "" || len(prompt.Images) > 0 {
 		t.addMessage(ctx, syntheticUserInput(prompt), false)
-		t.SetState(StateRunning)
+		if err := t.Transition(StateRunning); err != nil {
+			tlog.Warn("session started", "err", err)
+		}
 	}
 	return h, nil
 }
@@ -626,14 +839,19 @@ type setupResult struct {
 // allocateBranchLocked fetches origin, resolves the start point, and creates
 // the task branch. Must be called under branchMu. Used by AllocateBranch for
 // extra repos; primary repo branch allocation uses reserveBranchID + fetchAndCreateBranch.
-func (r *Runner) allocateBranchLocked(ctx context.Context, t *Task) (string, error) {
+func (r *Runner) allocateBranchLocked(ctx context.Context, t *Task) (_ string, err error) {
+	ctx, span := startSpan(ctx, "git.AllocateBranch", t, attribute.String("caic.repo", r.Dir))
+	defer func() { agent.EndSpan(span, err) }()
 	detached := context.WithoutCancel(ctx)
-	gitCtx, gitCancel := context.WithTimeout(detached, r.GitTimeout)
-	defer gitCancel()
 	// Fetch so that origin/<base> is up to date.
-	if err := gitutil.Fetch(gitCtx, r.Dir); err != nil {
+	fetchCtx, fetchCancel := context.WithTimeout(detached, r.Git.FetchTimeout)
+	err = r.fetchOrigin(fetchCtx)
+	fetchCancel()
+	if err != nil {
 		return "", fmt.Errorf("fetch: %w", err)
 	}
+	gitCtx, gitCancel := context.WithTimeout(detached, r.Git.BranchTimeout)
+	defer gitCancel()
 	// Resolve effective base branch: use task override if provided.
 	effectiveBase := r.BaseBranch
 	if p := t.Primary(); p != nil && p.BaseBranch != "" {
@@ -647,13 +865,16 @@ func (r *Runner) allocateBranchLocked(ctx context.Context, t *Task) (string, err
 	}
 	// Assign a sequential branch name, skipping existing ones.
 	var branch string
-	var err error
 	for range 100 {
 		if gitCtx.Err() != nil {
 			return "", gitCtx.Err()
 		}
 		branch = fmt.Sprintf("caic-%d", r.nextID)
 		r.nextID++
+		if err = checkBranchFree(gitCtx, r.Dir, branch, r.Git.ReservedPrefixes); err != nil {
+			r.log.Warn("skipping branch", "br", branch, "err", err)
+			continue
+		}
 		r.log.Info("creating branch", "br", branch, "base", effectiveBase)
 		err = gitutil.CreateBranch(gitCtx, r.Dir, branch, startPoint)
 		if err == nil {
@@ -676,31 +897,115 @@ func (r *Runner) AllocateBranch(ctx context.Context) (string, error) {
 	return r.allocateBranchLocked(ctx, &Task{})
 }
 
+// startPoint returns origin's copy of base, or the local branch when base
+// only exists locally.
+func (r *Runner) startPoint(ctx context.Context, base string) string {
+	if _, err := gitutil.RevParse(ctx, r.Dir, "origin/"+base); err != nil {
+		return base
+	}
+	return "origin/" + base
+}
+
+// ResolveBase fetches origin and returns the commit that a task branch forked
+// from base would start from. An empty base means the runner's default
+// branch.
+func (r *Runner) ResolveBase(ctx context.Context, base string) (string, error) {
+	r.initDefaults()
+	if r.Dir == "" {
+		return "", errors.New("no repository")
+	}
+	if base == "" {
+		base = r.BaseBranch
+	}
+	r.branchMu.Lock()
+	defer r.branchMu.Unlock()
+	fetchCtx, fetchCancel := context.WithTimeout(ctx, r.Git.FetchTimeout)
+	err := r.fetchOrigin(fetchCtx)
+	fetchCancel()
+	if err != nil {
+		return "", fmt.Errorf("fetch: %w", err)
+	}
+	gitCtx, gitCancel := context.WithTimeout(ctx, r.Git.BranchTimeout)
+	defer gitCancel()
+	return resolveCommit(gitCtx, r.Dir, r.startPoint(gitCtx, base))
+}
+
+// ShowBaseFile returns the content of the file at path on base, as seen by a
+// task branch forked from it before fetching. An empty base means the
+// runner's default branch.
+func (r *Runner) ShowBaseFile(ctx context.Context, base, path string) ([]byte, error) {
+	if r.Dir == "" {
+		return nil, errors.New("no repository")
+	}
+	if base == "" {
+		base = r.BaseBranch
+	}
+	return r.ShowFile(ctx, r.startPoint(ctx, base), path)
+}
+
+// ShowFile returns the content of the file at path in commit rev of the
+// repository.
+func (r *Runner) ShowFile(ctx context.Context, rev, path string) ([]byte, error) {
+	if r.Dir == "" {
+		return nil, errors.New("no repository")
+	}
+	if !ValidRepoPath(path) || !ValidDiffBase(rev) {
+		return nil, fmt.Errorf("invalid file %s:%s", rev, path)
+	}
+	cmd := exec.CommandContext(ctx, "git", "show", "--no-textconv", rev+":"+path) //nolint:gosec // rev and path are validated
+	cmd.Dir = r.Dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s:%s: %w", rev, path, err)
+	}
+	return out, nil
+}
+
 // fetchAndCreateBranch fetches origin and creates the given branch from the
 // resolved base. Acquires branchMu to serialize git operations across concurrent
 // task setups on the same repo (git fetch/branch are not safe to run in parallel
 // on the same working tree). Container.Launch can still run concurrently since it
 // does not touch the repo.
-func (r *Runner) fetchAndCreateBranch(ctx context.Context, t *Task, branch string) error {
+func (r *Runner) fetchAndCreateBranch(ctx context.Context, t *Task, branch string) (err error) {
+	ctx, span := startSpan(ctx, "git.CreateBranch", t, attribute.String("caic.repo", r.Dir), attribute.String("caic.branch", branch))
+	defer func() { agent.EndSpan(span, err) }()
 	r.branchMu.Lock()
 	defer r.branchMu.Unlock()
-	gitCtx, gitCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
-	defer gitCancel()
-	if err := gitutil.Fetch(gitCtx, r.Dir); err != nil {
+	detached := context.WithoutCancel(ctx)
+	fetchCtx, fetchCancel := context.WithTimeout(detached, r.Git.FetchTimeout)
+	err = r.fetchOrigin(fetchCtx)
+	fetchCancel()
+	if err != nil {
 		return fmt.Errorf("fetch: %w", err)
 	}
+	gitCtx, gitCancel := context.WithTimeout(detached, r.Git.BranchTimeout)
+	defer gitCancel()
 	effectiveBase := r.BaseBranch
 	if p := t.Primary(); p != nil && p.BaseBranch != "" {
 		effectiveBase = p.BaseBranch
 	}
-	startPoint := "origin/" + effectiveBase
-	if _, err := gitutil.RevParse(gitCtx, r.Dir, startPoint); err != nil {
-		startPoint = effectiveBase
+	startPoint := r.startPoint(gitCtx, effectiveBase)
+	if p := t.Primary(); p != nil && p.BaseSHA != "" {
+		// Replays start from the exact commit of the original task.
+		effectiveBase = p.BaseSHA
+		startPoint = p.BaseSHA
+	}
+	sha, err := resolveCommit(gitCtx, r.Dir, startPoint)
+	if err != nil {
+		return fmt.Errorf("resolve base %s: %w", effectiveBase, err)
+	}
+	// The name was reserved before the fetch; verify no human branch
+	// appeared under it since.
+	if err := checkBranchFree(gitCtx, r.Dir, branch, r.Git.ReservedPrefixes); err != nil {
+		return err
 	}
 	r.log.Info("creating branch", "br", branch, "base", effectiveBase)
-	if err := gitutil.CreateBranch(gitCtx, r.Dir, branch, startPoint); err != nil {
+	if err := gitutil.CreateBranch(gitCtx, r.Dir, branch, sha); err != nil {
 		return fmt.Errorf("create branch: %w", err)
 	}
+	if p := t.Primary(); p != nil {
+		p.BaseSHA = sha
+	}
 	return nil
 }
 
@@ -708,7 +1013,9 @@ func (r *Runner) fetchAndCreateBranch(ctx context.Context, t *Task, branch strin
 // git branch concurrently, then completes container startup (Phase B).
 // Phase A (docker run) and git fetch+branch-create overlap, cutting the
 // branch-allocation time off the critical path.
-func (r *Runner) setup(ctx context.Context, t *Task, labels []string) (setupResult, error) {
+func (r *Runner) setup(ctx context.Context, t *Task) (_ setupResult, err error) {
+	ctx, span := startSpan(ctx, "task.setup", t)
+	defer func() { agent.EndSpan(span, err) }()
 	// Reserve the branch ID instantly (under lock, ~µs). The branch itself is
 	// created concurrently with docker run in Phase A.
 	if r.Dir != "" {
@@ -718,21 +1025,38 @@ func (r *Runner) setup(ctx context.Context, t *Task, labels []string) (setupResu
 		r.branchMu.Unlock()
 	}
 
-	t.SetState(StateProvisioning)
+	if err := t.Transition(StateProvisioning); err != nil {
+		return setupResult{}, err
+	}
 	detached := context.WithoutCancel(ctx)
 	var primaryBranch string
 	if p := t.Primary(); p != nil {
 		primaryBranch = p.Branch
 	}
-	r.log.Info("starting container", "br", primaryBranch, "img", t.DockerImage, "hns", t.Harness, "ts", t.Tailscale, "usb", t.USB, "dpy", t.Display)
+	r.log.Info("starting container", "br", primaryBranch, "img", t.DockerImage, "hns", t.Harness, "ts", t.Tailscale, "usb", t.USB, "dpy", t.Display, "gpu", t.GPU)
 	tContainer := time.Now()
 	startCtx, startCancel := context.WithTimeout(detached, r.ContainerStartTimeout)
 	defer startCancel()
 
 	opts := &StartOptions{
-		DockerImage: t.DockerImage, Harness: t.Harness, Tailscale: t.Tailscale, USB: t.USB, Display: t.Display,
+		DockerImage: t.DockerImage, Harness: t.Harness, Tailscale: t.Tailscale, USB: t.USB, Display: t.Display, GPU: t.GPU,
 		LogWriter: &provisioningWriter{ctx: ctx, t: t},
 	}
+	for i, m := range t.Repos {
+		if (m.Filter == "" && len(m.SparsePaths) == 0) || m.GitRoot == "" {
+			continue
+		}
+		base := m.BaseBranch
+		if i == 0 && base == "" {
+			base = r.BaseBranch
+		}
+		cmd, err := containerCloneCommand(startCtx, m.GitRoot, base, m.Filter, m.SparsePaths)
+		if err != nil {
+			r.log.Warn("partial container clone", "repo", m.Name, "err", err)
+			continue
+		}
+		opts.Clones = append(opts.Clones, cmd)
+	}
 
 	// Phase A: docker run + SSH config. Branch creation runs concurrently so
 	// git fetch overlaps with the container SSH boot time (~500 ms–3 s).
@@ -740,9 +1064,12 @@ func (r *Runner) setup(ctx context.Context, t *Task, labels []string) (setupResu
 	if r.Dir != "" {
 		repos = t.MDRepos()
 	}
+	labels := containerMeta(t).Labels()
 	eg, egCtx := errgroup.WithContext(startCtx)
-	eg.Go(func() error {
-		return r.Container.Launch(egCtx, repos, labels, opts)
+	eg.Go(func() (err error) {
+		launchCtx, span := startSpan(egCtx, "container.Launch", t)
+		defer func() { agent.EndSpan(span, err) }()
+		return r.Container.Launch(launchCtx, repos, labels, opts)
 	})
 	if r.Dir != "" {
 		eg.Go(func() error {
@@ -754,27 +1081,81 @@ func (r *Runner) setup(ctx context.Context, t *Task, labels []string) (setupResu
 	}
 
 	// Phase B: wait for SSH + push (branch now exists locally).
-	name, tailscaleFQDN, err := r.Container.Connect(startCtx, repos, opts)
+	connectCtx, connectSpan := startSpan(startCtx, "container.Connect", t)
+	name, tailscaleFQDN, err := r.Container.Connect(connectCtx, repos, opts)
+	agent.EndSpan(connectSpan, err)
 	if err != nil {
 		return setupResult{}, fmt.Errorf("start container: %w", err)
 	}
+	// The seeded clone is already sparse; this only matters when seeding
+	// failed and md pushed the repository in full.
+	for _, m := range t.Repos {
+		if len(m.SparsePaths) == 0 || m.GitRoot == "" {
+			continue
+		}
+		r.log.Info("sparse checkout", "repo", m.Name, "paths", m.SparsePaths)
+		if err := r.Container.SparseCheckout(startCtx, name, md.Repo{GitRoot: m.GitRoot, Branch: m.Branch}, m.SparsePaths); err != nil {
+			return setupResult{}, fmt.Errorf("sparse checkout %s: %w", m.Name, err)
+		}
+	}
+	for _, m := range t.Repos {
+		if (!m.Submodules && !m.LFS) || m.GitRoot == "" {
+			continue
+		}
+		cmd, err := checkoutDepsCommand(startCtx, m.GitRoot, m.Branch, m.Submodules, m.LFS)
+		if err != nil {
+			return setupResult{}, fmt.Errorf("submodules and LFS %s: %w", m.Name, err)
+		}
+		if cmd == "" {
+			continue
+		}
+		r.log.Info("submodules and LFS", "repo", m.Name, "submodules", m.Submodules, "lfs", m.LFS)
+		if err := r.Container.Run(startCtx, name, cmd); err != nil {
+			return setupResult{}, fmt.Errorf("submodules and LFS %s: %w", m.Name, err)
+		}
+	}
 	r.log.Info("container started", "br", primaryBranch, "dur", time.Since(tContainer))
 	return setupResult{Container: name, TailscaleFQDN: tailscaleFQDN}, nil
 }
 
+// containerMeta returns the metadata recorded in the labels of the task's
+// container, once its branch is reserved.
+func containerMeta(t *Task) *container.Meta {
+	m := &container.Meta{
+		TaskID:    t.ID.String(),
+		Harness:   string(t.Harness),
+		GPU:       t.GPU,
+		Owner:     t.OwnerID,
+		CreatedAt: t.StartedAt,
+	}
+	if t.Priority != PriorityNormal {
+		m.Priority = t.Priority.String()
+	}
+	if p := t.Primary(); p != nil {
+		m.Repo = p.Name
+		m.Branch = p.Branch
+	}
+	return m
+}
+
 // SyncToOrigin fetches changes from the container, runs safety checks, and
 // pushes the container's remote-tracking ref to origin. If safety issues are
-// found and force is false, it returns the issues without pushing.
-func (r *Runner) SyncToOrigin(ctx context.Context, branch, container string, force bool, extraRepos []md.Repo) (agent.DiffStat, []SafetyIssue, error) {
+// found and force is false, it returns the issues without pushing. The pushed
+// commits carry opts.Trailers and opts.Notes is committed as NotesFile on
+// top; the container's branch is left alone so they are rewritten at each
+// push.
+func (r *Runner) SyncToOrigin(ctx context.Context, branch, container string, force bool, extraRepos []md.Repo, opts *SyncOptions) (_ agent.DiffStat, _ []SafetyIssue, err error) {
 	r.initDefaults()
+	ctx, span := startSpan(ctx, "git.SyncToOrigin", nil, attribute.String("caic.repo", r.Dir), attribute.String("caic.branch", branch))
+	defer func() { agent.EndSpan(span, err) }()
 	if r.Dir == "" {
 		return nil, nil, errors.New("sync is not supported for no-repo tasks")
 	}
-	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
+	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.FetchTimeout)
 	defer fetchCancel()
 	r.branchMu.Lock()
 	r.log.Info("fetch", "br", branch)
-	if err := r.Container.Fetch(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
+	if err := r.fetchContainer(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
 		r.branchMu.Unlock()
 		return nil, nil, err
 	}
@@ -784,7 +1165,9 @@ func (r *Runner) SyncToOrigin(ctx context.Context, branch, container string, for
 	ref := "refs/remotes/" + container + "/" + branch
 	safetyCtx, safetyCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
 	defer safetyCancel()
-	issues, err := CheckSafety(safetyCtx, r.Dir, ref, r.BaseBranch, ds)
+	issues, err := retryMissing(safetyCtx, r.Dir, func() ([]SafetyIssue, error) {
+		return CheckSafety(safetyCtx, r.Dir, ref, r.BaseBranch, ds, r.Git.TestGuard)
+	})
 	if err != nil {
 		return ds, issues, fmt.Errorf("safety check: %w", err)
 	}
@@ -794,6 +1177,16 @@ func (r *Runner) SyncToOrigin(ctx context.Context, branch, container string, for
 
 	pushCtx, pushCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
 	defer pushCancel()
+	if len(opts.Trailers) != 0 {
+		if ref, err = addTrailers(pushCtx, r.Dir, ref, r.BaseBranch, opts.Trailers, opts.Sign); err != nil {
+			return ds, issues, fmt.Errorf("add trailers: %w", err)
+		}
+	}
+	if opts.Notes != "" {
+		if ref, err = commitNotes(pushCtx, r.Dir, ref, opts.Notes, opts.Sign); err != nil {
+			return ds, issues, fmt.Errorf("commit %s: %w", NotesFile, err)
+		}
+	}
 	if err := gitutil.PushRef(pushCtx, r.Dir, ref, branch, true); err != nil {
 		return ds, issues, fmt.Errorf("push to origin: %w", err)
 	}
@@ -802,17 +1195,20 @@ func (r *Runner) SyncToOrigin(ctx context.Context, branch, container string, for
 
 // SyncToDefault fetches changes from the container, runs safety checks, and
 // squash-pushes onto the repo's default branch. Safety issues always block
-// (no force override). The commit message is built from the task title.
-func (r *Runner) SyncToDefault(ctx context.Context, branch, container, message string, extraRepos []md.Repo) (agent.DiffStat, []SafetyIssue, error) {
+// (no force override). The commit message is built from the task title and
+// carries opts.Trailers; opts.Notes is ignored.
+func (r *Runner) SyncToDefault(ctx context.Context, branch, container, message string, extraRepos []md.Repo, opts *SyncOptions) (_ agent.DiffStat, _ []SafetyIssue, err error) {
 	r.initDefaults()
+	ctx, span := startSpan(ctx, "git.SyncToDefault", nil, attribute.String("caic.repo", r.Dir), attribute.String("caic.branch", branch))
+	defer func() { agent.EndSpan(span, err) }()
 	if r.Dir == "" {
 		return nil, nil, errors.New("sync is not supported for no-repo tasks")
 	}
-	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
+	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.FetchTimeout)
 	defer fetchCancel()
 	r.branchMu.Lock()
 	r.log.Info("fetch for default sync", "br", branch)
-	if err := r.Container.Fetch(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
+	if err := r.fetchContainer(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
 		r.branchMu.Unlock()
 		return nil, nil, err
 	}
@@ -822,7 +1218,9 @@ func (r *Runner) SyncToDefault(ctx context.Context, branch, container, message s
 	ref := "refs/remotes/" + container + "/" + branch
 	safetyCtx, safetyCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
 	defer safetyCancel()
-	issues, err := CheckSafety(safetyCtx, r.Dir, ref, r.BaseBranch, ds)
+	issues, err := retryMissing(safetyCtx, r.Dir, func() ([]SafetyIssue, error) {
+		return CheckSafety(safetyCtx, r.Dir, ref, r.BaseBranch, ds, r.Git.TestGuard)
+	})
 	if err != nil {
 		return ds, issues, fmt.Errorf("safety check: %w", err)
 	}
@@ -831,7 +1229,7 @@ func (r *Runner) SyncToDefault(ctx context.Context, branch, container, message s
 	}
 	squashCtx, squashCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
 	defer squashCancel()
-	if err := gitutil.SquashOnto(squashCtx, r.Dir, ref, r.BaseBranch, message); err != nil {
+	if err := squashOnto(squashCtx, r.Dir, ref, r.BaseBranch, AppendTrailers(message, opts.Trailers), opts.Sign); err != nil {
 		return ds, issues, fmt.Errorf("squash onto %s: %w", r.BaseBranch, err)
 	}
 	return ds, issues, nil
@@ -842,6 +1240,7 @@ func (r *Runner) SyncToDefault(ctx context.Context, branch, container, message s
 // caller can start a session watcher.
 func (r *Runner) RestartSession(ctx context.Context, t *Task, prompt agent.Prompt) (*SessionHandle, error) {
 	r.initDefaults()
+	prompt = t.ScrubPrompt(prompt)
 
 	state := t.GetState()
 	if state != StateWaiting && state != StateAsking && state != StateHasPlan {
@@ -867,12 +1266,14 @@ func (r *Runner) RestartSession(ctx context.Context, t *Task, prompt agent.Promp
 	// 3. Open new log segment.
 	logW, err := r.openLog(t)
 	if err != nil {
-		t.SetState(StateFailed)
-		return nil, fmt.Errorf("open log: %w", err)
+		return nil, fmt.Errorf("open log: %w", errors.Join(err, t.Transition(StateFailed)))
 	}
 
 	// 4. Start new session.
-	t.SetState(StateStarting)
+	if err := t.Transition(StateStarting); err != nil {
+		_ = logW.Close()
+		return nil, err
+	}
 
 	msgCh, dispatchDone := r.startMessageDispatch(ctx, t, false)
 
@@ -886,14 +1287,14 @@ func (r *Runner) RestartSession(ctx context.Context, t *Task, prompt agent.Promp
 		Container:     t.Container,
 		Dir:           r.containerDir(),
 		Model:         t.Model,
-		InitialPrompt: prompt,
+		InitialPrompt: withKnowledge(withRepoMap(prompt, t.RepoMap), t.Knowledge),
+		ReadOnly:      t.ReadOnly(),
 	}, msgCh, logW)
 	if err != nil {
 		_ = logW.Close()
 		close(msgCh)
 		<-dispatchDone
-		t.SetState(StateFailed)
-		return nil, fmt.Errorf("start session: %w", err)
+		return nil, fmt.Errorf("start session: %w", errors.Join(err, t.Transition(StateFailed)))
 	}
 
 	// 5. Store new handle.
@@ -902,7 +1303,9 @@ func (r *Runner) RestartSession(ctx context.Context, t *Task, prompt agent.Promp
 
 	t.addMessage(ctx, syntheticUserInput(prompt), false)
 
-	t.SetState(StateRunning)
+	if err := t.Transition(StateRunning); err != nil {
+		tlog.Warn("session restarted", "err", err)
+	}
 	tlog.Info("session restarted")
 	return h, nil
 }
@@ -916,12 +1319,14 @@ func (r *Runner) ReadRelayOutput(ctx context.Context, container string, agentNam
 
 // DiffContent returns the unified diff for the given branch, optionally
 // filtered to a single file path. Holds branchMu during the fetch+diff.
-func (r *Runner) DiffContent(ctx context.Context, branch, path string) (string, error) {
+func (r *Runner) DiffContent(ctx context.Context, branch, path string) (_ string, err error) {
 	r.initDefaults()
 	if r.Dir == "" {
 		return "", errors.New("diff is not supported for no-repo tasks")
 	}
-	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
+	ctx, span := startSpan(ctx, "git.Diff", nil, attribute.String("caic.repo", r.Dir), attribute.String("caic.branch", branch))
+	defer func() { agent.EndSpan(span, err) }()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.DiffTimeout)
 	defer cancel()
 	r.branchMu.Lock()
 	defer r.branchMu.Unlock()
@@ -929,7 +1334,99 @@ func (r *Runner) DiffContent(ctx context.Context, branch, path string) (string,
 	if path != "" {
 		args = append(args, "--", path)
 	}
-	return r.Container.Diff(ctx, md.Repo{GitRoot: r.Dir, Branch: branch}, args...)
+	return retryMissing(ctx, r.Dir, func() (string, error) {
+		return r.Container.Diff(ctx, md.Repo{GitRoot: r.Dir, Branch: branch}, args...)
+	})
+}
+
+// DiffContentFrom returns the unified diff of the task branch against base,
+// any commit-ish of the host repository such as another branch, a tag or a
+// previously reviewed commit. The container's work is fetched into the host
+// repository first; the diff is computed there without touching any working
+// tree.
+func (r *Runner) DiffContentFrom(ctx context.Context, branch, container, base, path string, extraRepos []md.Repo) (_ string, err error) {
+	r.initDefaults()
+	if r.Dir == "" {
+		return "", errors.New("diff is not supported for no-repo tasks")
+	}
+	ctx, span := startSpan(ctx, "git.DiffFrom", nil, attribute.String("caic.repo", r.Dir), attribute.String("caic.branch", branch))
+	defer func() { agent.EndSpan(span, err) }()
+	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.FetchTimeout)
+	defer fetchCancel()
+	r.branchMu.Lock()
+	defer r.branchMu.Unlock()
+	if err := r.fetchContainer(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.DiffTimeout)
+	defer cancel()
+	baseSHA, err := resolveCommit(ctx, r.Dir, base)
+	if err != nil {
+		return "", err
+	}
+	head := "refs/remotes/" + container + "/" + branch
+	return retryMissing(ctx, r.Dir, func() (string, error) {
+		return diffRefs(ctx, r.Dir, baseSHA, head, path)
+	})
+}
+
+// HostDiff returns the unified diff of the task branch against base from the
+// host repository alone, for tasks whose container is gone. It uses the
+// container's work last fetched into the host repository, or the local task
+// branch if it was never fetched.
+func (r *Runner) HostDiff(ctx context.Context, branch, container, base string) (string, error) {
+	r.initDefaults()
+	if r.Dir == "" {
+		return "", errors.New("diff is not supported for no-repo tasks")
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.Git.DiffTimeout)
+	defer cancel()
+	baseSHA, err := resolveCommit(ctx, r.Dir, base)
+	if err != nil {
+		return "", err
+	}
+	head, err := resolveCommit(ctx, r.Dir, "refs/remotes/"+container+"/"+branch)
+	if err != nil {
+		if head, err = resolveCommit(ctx, r.Dir, branch); err != nil {
+			return "", err
+		}
+	}
+	return diffRefs(ctx, r.Dir, baseSHA, head, "")
+}
+
+// ApplyToWorktree applies the task's changes relative to its base branch to
+// the working tree of worktree, which must be a clean worktree of the same
+// repository. Branches, HEAD and the index are left untouched so the changes
+// can be integrated manually. It returns the resulting diff stat.
+func (r *Runner) ApplyToWorktree(ctx context.Context, branch, worktree string) (_ agent.DiffStat, err error) {
+	r.initDefaults()
+	if r.Dir == "" {
+		return nil, errors.New("apply is not supported for no-repo tasks")
+	}
+	ctx, span := startSpan(ctx, "git.Apply", nil, attribute.String("caic.repo", r.Dir), attribute.String("caic.branch", branch))
+	defer func() { agent.EndSpan(span, err) }()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.DiffTimeout)
+	defer cancel()
+	top, err := checkWorktree(ctx, r.Dir, worktree)
+	if err != nil {
+		return nil, err
+	}
+	r.branchMu.Lock()
+	patch, err := retryMissing(ctx, r.Dir, func() (string, error) {
+		return r.Container.Diff(ctx, md.Repo{GitRoot: r.Dir, Branch: branch}, "--binary")
+	})
+	r.branchMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(patch) == "" {
+		return nil, nil
+	}
+	numstat, err := applyPatch(ctx, top, patch)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDiffNumstat(numstat), nil
 }
 
 // PurgeContainer stops and removes the md container identified by containerName,
@@ -945,19 +1442,11 @@ func (r *Runner) PurgeContainer(ctx context.Context, containerName, branch strin
 	return r.Container.Purge(ctx, containerName, repos)
 }
 
-// mutatingTools lists tool names whose execution may change files in the
-// container, warranting a diff stat refresh after their result arrives.
-var mutatingTools = map[string]struct{}{
-	"Bash":         {},
-	"Edit":         {},
-	"Write":        {},
-	"NotebookEdit": {},
-}
-
 // startMessageDispatch starts a goroutine that reads from msgCh and dispatches
 // to t.addMessage. For ResultMessages, it fetches from the container first and
-// attaches the diff stat. For tool results following a mutating tool (Edit,
-// Bash, Write, NotebookEdit), it also fetches and emits a DiffStatMessage.
+// attaches the diff stat. For tool results following a tool the harness
+// declares as mutating (see agent.Backend.IsMutatingTool), it also fetches and
+// emits a DiffStatMessage.
 // When skipSideEffects is true, fetch+diff and title generation are suppressed
 // (used during adoption where these are handled once at the end).
 // Returns the message channel and a done channel that closes when the
@@ -969,39 +1458,58 @@ func (r *Runner) startMessageDispatch(ctx context.Context, t *Task, skipSideEffe
 		primaryBranch = p.Branch
 	}
 	extraRepos := t.ExtraMDRepos()
+	container := t.Container
+	backend := r.backend(t.Harness)
 	msgCh = make(chan agent.Message, 256)
 	done := make(chan struct{})
 	dispatchDone = done
 	go func() {
 		defer close(done)
+		var dl *deadLetterWriter
+		if r.DeadLetterDir != "" {
+			dl = &deadLetterWriter{path: DeadLetterPath(r.DeadLetterDir, t.ID)}
+			defer dl.close()
+		}
 		// Track tool_use IDs from ToolUseMessage that may mutate files.
 		pendingMutating := make(map[string]struct{})
 		for m := range msgCh {
+			if dl != nil {
+				if d := newDeadLetter(m, time.Now()); d != nil {
+					if err := dl.write(d); err != nil {
+						r.log.Warn("dead letter", "br", primaryBranch, "err", err)
+					}
+				}
+			}
 			switch msg := m.(type) {
 			case *agent.ToolUseMessage:
-				if _, ok := mutatingTools[msg.Name]; ok {
+				if backend != nil && backend.IsMutatingTool(msg.Name) {
 					pendingMutating[msg.ToolUseID] = struct{}{}
 				}
 			case *agent.ToolResultMessage:
 				if !skipSideEffects && r.Container != nil && r.Dir != "" {
 					if _, ok := pendingMutating[msg.ToolUseID]; ok {
 						delete(pendingMutating, msg.ToolUseID)
-						r.fetchDiffStatBranch(ctx, t, primaryBranch, extraRepos)
+						r.fetchDiffStatBranch(ctx, t, msg.ToolUseID, container, primaryBranch, extraRepos)
 					}
 				}
 			case *agent.ResultMessage:
 				if !skipSideEffects && r.Container != nil && r.Dir != "" {
-					fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
+					fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.FetchTimeout)
 					r.branchMu.Lock()
-					if err := r.Container.Fetch(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: primaryBranch}}, extraRepos...)); err != nil {
+					if err := r.fetchContainer(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: primaryBranch}}, extraRepos...)); err != nil {
 						r.log.Warn("fetch on result failed", "br", primaryBranch, "err", err)
 					}
 					msg.DiffStat = r.diffStat(fetchCtx, primaryBranch)
 					r.branchMu.Unlock()
 					fetchCancel()
+					r.updateAffected(ctx, t, container, msg.DiffStat)
 				}
 			}
+			t.recordActivity()
 			t.addMessage(ctx, m, skipSideEffects)
+			if rm, ok := m.(*agent.ResultMessage); ok && !skipSideEffects && t.OnResult != nil {
+				t.OnResult(t, rm)
+			}
 		}
 	}()
 	return
@@ -1009,14 +1517,16 @@ func (r *Runner) startMessageDispatch(ctx context.Context, t *Task, skipSideEffe
 
 // fetchDiffStatBranch fetches from the container and emits a DiffStatMessage
 // into the task's message stream. Used after mutating tool results to keep the
-// live diff stat up to date. Branch and extraRepos are passed explicitly so
-// this can be called safely from a goroutine started before branch allocation.
-func (r *Runner) fetchDiffStatBranch(ctx context.Context, t *Task, branch string, extraRepos []md.Repo) {
-	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
+// live diff stat up to date and to link the tool call toolUseID to the commit
+// the fetch recorded its changes in. Branch and extraRepos are passed
+// explicitly so this can be called safely from a goroutine started before
+// branch allocation.
+func (r *Runner) fetchDiffStatBranch(ctx context.Context, t *Task, toolUseID, container, branch string, extraRepos []md.Repo) {
+	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.FetchTimeout)
 	defer fetchCancel()
 	r.branchMu.Lock()
 	defer r.branchMu.Unlock()
-	if err := r.Container.Fetch(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
+	if err := r.fetchContainer(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
 		r.log.Warn("fetch on tool result failed", "br", branch, "err", err)
 		return
 	}
@@ -1027,7 +1537,25 @@ func (r *Runner) fetchDiffStatBranch(ctx context.Context, t *Task, branch string
 	t.addMessage(ctx, &agent.DiffStatMessage{
 		MessageType: "caic_diff_stat",
 		DiffStat:    ds,
+		ToolUseID:   toolUseID,
+		HeadSHA:     r.fetchedHead(fetchCtx, container, branch),
 	}, false)
+	r.updateAffected(ctx, t, container, ds)
+}
+
+// fetchedHead returns the SHA of branch as last fetched from container, or
+// an empty string when unknown. Container.Fetch commits pending changes
+// first, so it is the branch HEAD including them.
+func (r *Runner) fetchedHead(ctx context.Context, container, branch string) string {
+	if container == "" {
+		return ""
+	}
+	sha, err := gitutil.RunGit(ctx, r.Dir, "rev-parse", "--verify", "--quiet", "refs/remotes/"+container+"/"+branch)
+	if err != nil {
+		r.log.Debug("resolve fetched head failed", "br", branch, "err", err)
+		return ""
+	}
+	return sha
 }
 
 // BranchDiffStat fetches from the container and returns the host-side branch
@@ -1039,32 +1567,44 @@ func (r *Runner) BranchDiffStat(ctx context.Context, branch string, extraRepos [
 	if r.Container == nil || r.Dir == "" {
 		return nil
 	}
-	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.GitTimeout)
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.FetchTimeout)
 	defer cancel()
 	r.branchMu.Lock()
 	defer r.branchMu.Unlock()
-	if err := r.Container.Fetch(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
+	if err := r.fetchContainer(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
 		r.log.Warn("fetch for branch diff stat failed", "br", branch, "err", err)
 		return nil
 	}
 	return r.diffStat(fetchCtx, branch)
 }
 
-// diffStat runs Diff("--numstat") and parses the output. Returns nil for no-repo runners.
+// diffStat runs Diff("--raw", "--numstat") and parses the output, marking the
+// Git LFS pointers. Returns nil for no-repo runners.
 func (r *Runner) diffStat(ctx context.Context, branch string) agent.DiffStat {
 	if r.Dir == "" {
 		return nil
 	}
-	numstat, err := r.Container.Diff(ctx, md.Repo{GitRoot: r.Dir, Branch: branch}, "--numstat")
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.DiffTimeout)
+	defer cancel()
+	ctx, span := startSpan(ctx, "git.DiffStat", nil, attribute.String("caic.repo", r.Dir), attribute.String("caic.branch", branch))
+	numstat, err := retryMissing(ctx, r.Dir, func() (string, error) {
+		return r.Container.Diff(ctx, md.Repo{GitRoot: r.Dir, Branch: branch}, "--raw", "--no-abbrev", "--numstat")
+	})
+	agent.EndSpan(span, err)
 	if err != nil {
 		r.log.Warn("diff numstat failed", "br", branch, "err", err)
 		return nil
 	}
-	return ParseDiffNumstat(numstat)
+	ds, blobs := parseDiff(numstat)
+	if err := markLFS(ctx, r.Dir, ds, blobs); err != nil {
+		r.log.Warn("lfs pointers", "br", branch, "err", err)
+	}
+	return ds
 }
 
 // openLog creates a JSONL log file in LogDir and writes a metadata header as
-// the first line.
+// the first line. Lines are redacted by t.Scrub, then encrypted when a
+// logcrypt key is set.
 func (r *Runner) openLog(t *Task) (io.WriteCloser, error) {
 	if err := os.MkdirAll(r.LogDir, 0o750); err != nil {
 		return nil, fmt.Errorf("create log dir: %w", err)
@@ -1080,10 +1620,18 @@ func (r *Runner) openLog(t *Task) (io.WriteCloser, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create log file: %w", err)
 	}
+	w := logcrypt.NewWriter(f)
+	if t.Scrub != nil {
+		w = t.Scrub.NewWriter(w, t.addScrubbed)
+	}
 	// Write metadata header as the first line.
 	metaRepos := make([]agent.MetaRepo, len(t.Repos))
 	for i, r := range t.Repos {
-		metaRepos[i] = agent.MetaRepo{Name: r.Name, BaseBranch: r.BaseBranch, Branch: r.Branch}
+		metaRepos[i] = agent.MetaRepo{Name: r.Name, BaseBranch: r.BaseBranch, Branch: r.Branch, BaseSHA: r.BaseSHA, SparsePaths: r.SparsePaths}
+	}
+	var replayOf string
+	if !t.ReplayOf.IsZero() {
+		replayOf = t.ReplayOf.String()
 	}
 	meta := agent.MetaMessage{
 		MessageType: "caic_meta",
@@ -1095,11 +1643,17 @@ func (r *Runner) openLog(t *Task) (io.WriteCloser, error) {
 		Model:       t.Model,
 		StartedAt:   t.StartedAt,
 		ForgeIssue:  t.ForgeIssue,
+		ReplayOf:    replayOf,
+		Kind:        string(t.Kind),
+		AutoResume:  t.AutoResume,
+		AskPolicy:   t.AskPolicy.toMeta(),
+		Automation:  string(t.Automation),
+		Owner:       t.OwnerID,
 	}
 	if data, err := json.Marshal(meta); err == nil {
-		_, _ = f.Write(append(data, '\n'))
+		_, _ = w.Write(append(data, '\n'))
 	}
-	return f, nil
+	return w, nil
 }
 
 // writeLogTrailer appends a MetaResultMessage to the log file.
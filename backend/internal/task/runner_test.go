@@ -8,13 +8,17 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
 	agentclaude "github.com/caic-xyz/caic/backend/internal/agent/claude"
+	"github.com/caic-xyz/caic/backend/internal/agent/gemini"
+	"github.com/caic-xyz/caic/backend/internal/agent/kilo"
 	"github.com/caic-xyz/md"
+	"github.com/caic-xyz/md/gitutil"
 	"github.com/maruel/ksid"
 )
 
@@ -55,6 +59,12 @@ func (b *testBackend) Models() []string { return []string{"test-model"} }
 // SupportsImages always returns false in the test backend.
 func (b *testBackend) SupportsImages() bool { return false }
 
+func (b *testBackend) SupportsReadOnly() bool { return false }
+
+func (b *testBackend) IsMutatingTool(name string) bool {
+	return agent.MatchTool([]string{"Bash", "Edit", "Write"}, name)
+}
+
 func (b *testBackend) ContextWindowLimit(string) int { return 180_000 }
 
 // testWire implements agent.WireFormat for testing.
@@ -190,11 +200,20 @@ func TestRunner(t *testing.T) {
 				InitialPrompt: agent.Prompt{Text: "test"},
 				Repos:         []RepoMount{{Name: "org/repo", BaseBranch: "feature"}},
 				Harness:       agent.Claude,
+				OwnerID:       "u1",
+				StartedAt:     time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
 			}
 
-			if _, err := r.setup(t.Context(), tk, nil); err != nil {
+			if _, err := r.setup(t.Context(), tk); err != nil {
 				t.Fatal(err)
 			}
+			want := []string{
+				"caic=" + tk.ID.String(), "harness=claude", "caic.repo=org/repo", "caic.branch=" + tk.Repos[0].Branch,
+				"caic.owner=u1", "caic.createdAt=2026-03-01T12:00:00Z",
+			}
+			if !slices.Equal(stub.labels, want) {
+				t.Errorf("labels = %q, want %q", stub.labels, want)
+			}
 
 			// The task branch must contain the feature commit (feature.txt).
 			out, execErr := exec.Command("git", "-C", clone, "show", tk.Repos[0].Branch+":feature.txt").Output() //nolint:gosec // controlled test args
@@ -235,7 +254,7 @@ func TestRunner(t *testing.T) {
 				Harness:       agent.Claude,
 			}
 
-			if _, err := r.setup(t.Context(), tk, nil); err != nil {
+			if _, err := r.setup(t.Context(), tk); err != nil {
 				t.Fatal(err)
 			}
 
@@ -248,6 +267,108 @@ func TestRunner(t *testing.T) {
 				t.Errorf("local.txt content = %q, want %q", string(out), "local\n")
 			}
 		})
+		t.Run("BaseSHA", func(t *testing.T) {
+			// The base commit is recorded, and a recorded base commit is
+			// honored even after the base branch moved.
+			clone := initTestRepo(t, "main")
+			r := &Runner{
+				BaseBranch: "main",
+				Dir:        clone,
+				LogDir:     t.TempDir(),
+				Container:  &stubContainer{},
+			}
+			r.initDefaults()
+			orig := &Task{
+				ID:            ksid.NewID(),
+				InitialPrompt: agent.Prompt{Text: "test"},
+				Repos:         []RepoMount{{Name: "org/repo"}},
+				Harness:       agent.Claude,
+			}
+			if _, err := r.setup(t.Context(), orig); err != nil {
+				t.Fatal(err)
+			}
+			want, err := gitutil.RunGit(t.Context(), clone, "rev-parse", "origin/main")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if orig.Repos[0].BaseSHA != want {
+				t.Fatalf("BaseSHA = %q, want %q", orig.Repos[0].BaseSHA, want)
+			}
+
+			if err := os.WriteFile(filepath.Join(clone, "later.txt"), []byte("later\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			runGit(t, clone, "add", ".")
+			runGit(t, clone, "commit", "-m", "later commit")
+			runGit(t, clone, "push", "origin", "main")
+
+			replay := &Task{
+				ID:            ksid.NewID(),
+				InitialPrompt: agent.Prompt{Text: "test"},
+				Repos:         []RepoMount{{Name: "org/repo", BaseSHA: want}},
+				Harness:       agent.Claude,
+				ReplayOf:      orig.ID,
+			}
+			if _, err := r.setup(t.Context(), replay); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := gitutil.RunGit(t.Context(), clone, "rev-parse", replay.Repos[0].Branch); got != want {
+				t.Errorf("replay branch at %s, want %s", got, want)
+			}
+			if replay.Repos[0].BaseSHA != want {
+				t.Errorf("replay BaseSHA = %q, want %q", replay.Repos[0].BaseSHA, want)
+			}
+		})
+		t.Run("SparsePaths", func(t *testing.T) {
+			clone := initTestRepo(t, "main")
+			stub := &stubContainer{}
+			r := &Runner{
+				BaseBranch: "main",
+				Dir:        clone,
+				LogDir:     t.TempDir(),
+				Container:  stub,
+			}
+			r.initDefaults()
+
+			tk := &Task{
+				ID:            ksid.NewID(),
+				InitialPrompt: agent.Prompt{Text: "test"},
+				Repos: []RepoMount{
+					{Name: "org/repo", GitRoot: clone, SparsePaths: []string{"proj/a", "shared"}},
+				},
+				Harness: agent.Claude,
+			}
+			if _, err := r.setup(t.Context(), tk); err != nil {
+				t.Fatal(err)
+			}
+			if len(stub.sparse) != 1 || strings.Join(stub.sparse[0], ",") != "proj/a,shared" {
+				t.Errorf("SparseCheckout calls = %v, want [[proj/a shared]]", stub.sparse)
+			}
+		})
+		t.Run("LFS", func(t *testing.T) {
+			clone := initTestRepo(t, "main")
+			stub := &stubContainer{}
+			r := &Runner{
+				BaseBranch: "main",
+				Dir:        clone,
+				LogDir:     t.TempDir(),
+				Container:  stub,
+			}
+			r.initDefaults()
+
+			tk := &Task{
+				ID:            ksid.NewID(),
+				InitialPrompt: agent.Prompt{Text: "test"},
+				Repos:         []RepoMount{{Name: "org/repo", GitRoot: clone, Submodules: true, LFS: true}},
+				Harness:       agent.Claude,
+			}
+			if _, err := r.setup(t.Context(), tk); err != nil {
+				t.Fatal(err)
+			}
+			if len(stub.runs) != 1 || !strings.HasSuffix(stub.runs[0], "git lfs pull") || strings.Contains(stub.runs[0], "submodule") {
+				t.Errorf("Run calls = %q, want LFS pull only", stub.runs)
+			}
+		})
 	})
 
 	t.Run("Cleanup", func(t *testing.T) {
@@ -266,7 +387,7 @@ func TestRunner(t *testing.T) {
 				InitialPrompt: agent.Prompt{Text: "test"},
 				Repos:         []RepoMount{{Name: "org/repo", Branch: "main"}},
 			}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 
 			// Restore messages with cost info (simulates RestoreMessages from logs).
 			tk.RestoreMessages([]agent.Message{
@@ -305,7 +426,7 @@ func TestRunner(t *testing.T) {
 				InitialPrompt: agent.Prompt{Text: "test"},
 				Repos:         []RepoMount{{Name: "org/repo", Branch: "main"}},
 			}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 
 			// Restore messages including a DiffStatMessage (simulates relay output).
 			tk.RestoreMessages([]agent.Message{
@@ -383,7 +504,7 @@ func TestRunner(t *testing.T) {
 			r.initDefaults()
 
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, Repos: []RepoMount{{Branch: "caic-0"}}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			_, ch, unsub := tk.Subscribe(t.Context())
 			defer unsub()
 
@@ -413,14 +534,29 @@ func TestRunner(t *testing.T) {
 		})
 
 		t.Run("MutatingToolEmitsDiffStat", func(t *testing.T) {
-			for _, tool := range []string{"Edit", "Bash", "Write", "NotebookEdit"} {
-				t.Run(tool, func(t *testing.T) {
+			for _, tc := range []struct {
+				harness agent.Harness
+				tool    string
+			}{
+				{agent.Claude, "Edit"},
+				{agent.Claude, "Bash"},
+				{agent.Claude, "Write"},
+				{agent.Claude, "NotebookEdit"},
+				{agent.Codex, "write_file"},
+				{agent.Gemini, "smart_edit"},
+				{agent.Kilo, "multiedit"},
+			} {
+				tool := tc.tool
+				t.Run(string(tc.harness)+"/"+tool, func(t *testing.T) {
 					stub := &stubContainer{}
-					r := &Runner{Container: stub, Dir: "/repo"}
+					backends := DefaultBackends()
+					backends[agent.Gemini] = gemini.New()
+					backends[agent.Kilo] = kilo.New()
+					r := &Runner{Container: stub, Dir: "/repo", Backends: backends}
 					r.initDefaults()
 
-					tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, Repos: []RepoMount{{Branch: "caic-0"}}}
-					tk.SetState(StateRunning)
+					tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, Harness: tc.harness, Repos: []RepoMount{{Branch: "caic-0"}}}
+					setState(t, tk, StateRunning)
 					_, ch, unsub := tk.Subscribe(t.Context())
 					defer unsub()
 
@@ -463,13 +599,46 @@ func TestRunner(t *testing.T) {
 			}
 		})
 
+		t.Run("DiffStatRecordsHead", func(t *testing.T) {
+			clone := initTestRepo(t, "main")
+			// What Container.Fetch leaves behind in the host repository.
+			runGit(t, clone, "update-ref", "refs/remotes/ctr/caic-0", "HEAD")
+			want, err := gitutil.RunGit(t.Context(), clone, "rev-parse", "HEAD")
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &Runner{Container: &stubContainer{}, Dir: clone}
+			r.initDefaults()
+			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, Harness: agent.Claude, Repos: []RepoMount{{Branch: "caic-0"}}, Container: "ctr"}
+			setState(t, tk, StateRunning)
+			_, ch, unsub := tk.Subscribe(t.Context())
+			defer unsub()
+			msgCh, _ := r.startMessageDispatch(t.Context(), tk, false)
+			msgCh <- &agent.ToolUseMessage{ToolUseID: "t1", Name: "Edit", Input: json.RawMessage(`{}`)}
+			recvMsg(t, ch)
+			msgCh <- &agent.ToolResultMessage{ToolUseID: "t1"}
+			var ds *agent.DiffStatMessage
+			for range 2 {
+				if m, ok := recvMsg(t, ch).(*agent.DiffStatMessage); ok {
+					ds = m
+				}
+			}
+			if ds == nil {
+				t.Fatal("no DiffStatMessage")
+			}
+			if ds.ToolUseID != "t1" || ds.HeadSHA != want {
+				t.Errorf("DiffStatMessage = %+v, want tool t1 at %s", ds, want)
+			}
+			close(msgCh)
+		})
+
 		t.Run("NonMutatingToolNoDiffStat", func(t *testing.T) {
 			stub := &stubContainer{}
 			r := &Runner{Container: stub}
 			r.initDefaults()
 
-			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, Repos: []RepoMount{{Branch: "caic-0"}}}
-			tk.SetState(StateRunning)
+			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, Harness: agent.Claude, Repos: []RepoMount{{Branch: "caic-0"}}}
+			setState(t, tk, StateRunning)
 			_, ch, unsub := tk.Subscribe(t.Context())
 			defer unsub()
 
@@ -502,7 +671,7 @@ func TestRunner(t *testing.T) {
 			r.initDefaults()
 
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 
 			msgCh, done := r.startMessageDispatch(t.Context(), tk, false)
 
@@ -535,6 +704,38 @@ func TestRunner(t *testing.T) {
 		})
 	})
 
+	t.Run("DeadLetters", func(t *testing.T) {
+		r := &Runner{DeadLetterDir: t.TempDir()}
+		r.initDefaults()
+		tk := &Task{ID: ksid.NewID(), InitialPrompt: agent.Prompt{Text: "test"}}
+		msgCh, done := r.startMessageDispatch(t.Context(), tk, false)
+		msgCh <- &agent.TextMessage{Text: "kept"}
+		msgCh <- &agent.RawMessage{MessageType: "jsonrpc_response", Raw: []byte(`{"id":1}`)}
+		msgCh <- &agent.RawMessage{MessageType: "new_thing", Raw: []byte(`{"type":"new_thing"}`), Unknown: true}
+		msgCh <- &agent.ParseErrorMessage{Err: "unexpected end of JSON input", Line: `{"type":`}
+		close(msgCh)
+		<-done
+		got, err := ReadDeadLetters(r.DeadLetterDir, tk.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Fatalf("got %d dead letters, want 2: %+v", len(got), got)
+		}
+		if got[0].Type != "new_thing" || got[0].Err != "" || got[0].Line != `{"type":"new_thing"}` {
+			t.Errorf("unknown type: %+v", got[0])
+		}
+		if got[1].Type != "" || got[1].Err == "" || got[1].Line != `{"type":` {
+			t.Errorf("parse error: %+v", got[1])
+		}
+		if n := len(tk.Messages()); n != 4 {
+			t.Errorf("task has %d messages, want all 4", n)
+		}
+		if got, err := ReadDeadLetters(r.DeadLetterDir, ksid.NewID()); err != nil || got != nil {
+			t.Errorf("missing file: %v, %v", got, err)
+		}
+	})
+
 	t.Run("RestartSession", func(t *testing.T) {
 		for _, startState := range []State{StateWaiting, StateAsking, StateHasPlan} {
 			t.Run(startState.String(), func(t *testing.T) {
@@ -553,7 +754,7 @@ func TestRunner(t *testing.T) {
 					Harness:       "test",
 					Container:     "fake-container",
 				}
-				tk.SetState(startState)
+				setState(t, tk, startState)
 
 				h, err := r.RestartSession(t.Context(), tk, agent.Prompt{Text: "new plan"})
 				if err != nil {
@@ -592,6 +793,35 @@ func TestRunner(t *testing.T) {
 		}
 	})
 
+	t.Run("PauseSession", func(t *testing.T) {
+		r := &Runner{
+			LogDir:   t.TempDir(),
+			Backends: map[agent.Harness]agent.Backend{"test": &testBackend{}},
+		}
+		tk := &Task{
+			ID:        ksid.NewID(),
+			Repos:     []RepoMount{{Name: "org/repo", Branch: "caic-0"}},
+			Harness:   "test",
+			Container: "fake-container",
+		}
+		setState(t, tk, StateWaiting)
+		if _, err := r.StartSession(t.Context(), tk, agent.Prompt{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.PauseSession(tk); err != nil {
+			t.Fatal(err)
+		}
+		if tk.GetState() != StatePaused || tk.HasSession() {
+			t.Errorf("state = %v, session = %v", tk.GetState(), tk.HasSession())
+		}
+		setState(t, tk, StateStarting)
+		setState(t, tk, StatePulling)
+		var te *TransitionError
+		if err := r.PauseSession(tk); !errors.As(err, &te) || tk.GetState() != StatePulling {
+			t.Errorf("PauseSession while pulling: %v, state = %v", err, tk.GetState())
+		}
+	})
+
 	t.Run("RestartSession/LogContainsContextCleared", func(t *testing.T) {
 		logDir := t.TempDir()
 		backend := &testBackend{}
@@ -624,7 +854,7 @@ func TestRunner(t *testing.T) {
 		close(alreadyDone)
 		h1 := &SessionHandle{Session: session, MsgCh: msgCh, DispatchDone: alreadyDone, LogW: logW}
 		tk.AttachSession(h1)
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 
 		// Simulate the agent writing a plan file.
 		tk.addMessage(t.Context(), &agent.ToolUseMessage{
@@ -638,7 +868,7 @@ func TestRunner(t *testing.T) {
 		// Gracefully end the first session so we can restart.
 		h1.Session.Close()
 		<-h1.Session.Done()
-		tk.SetState(StateWaiting)
+		setState(t, tk, StateWaiting)
 
 		// Restart: should write context_cleared to the log before closing it.
 		h2, err := r.RestartSession(t.Context(), tk, agent.Prompt{Text: "execute plan"})
@@ -681,11 +911,15 @@ func TestRunner(t *testing.T) {
 // stubContainer implements ContainerBackend for testing. Diff returns a fixed
 // numstat line; Fetch records that it was called.
 type stubContainer struct {
+	labels   []string // Labels passed to Launch.
 	fetched  bool
-	fetchErr error // If set, Fetch returns this error.
+	fetchErr error      // If set, Fetch returns this error.
+	sparse   [][]string // Paths passed to each SparseCheckout call.
+	runs     []string   // Commands passed to Run.
 }
 
-func (s *stubContainer) Launch(_ context.Context, _ []md.Repo, _ []string, _ *StartOptions) error {
+func (s *stubContainer) Launch(_ context.Context, _ []md.Repo, labels []string, _ *StartOptions) error {
+	s.labels = labels
 	return nil
 }
 
@@ -709,6 +943,16 @@ func (s *stubContainer) Stop(_ context.Context, _ string) error                {
 func (s *stubContainer) Purge(_ context.Context, _ string, _ []md.Repo) error  { return nil }
 func (s *stubContainer) Revive(_ context.Context, _ string, _ []md.Repo) error { return nil }
 
+func (s *stubContainer) SparseCheckout(_ context.Context, _ string, _ md.Repo, paths []string) error {
+	s.sparse = append(s.sparse, paths)
+	return nil
+}
+
+func (s *stubContainer) Run(_ context.Context, _, command string) error {
+	s.runs = append(s.runs, command)
+	return nil
+}
+
 // recvMsg reads a single message from ch, respecting the test context and a
 // 1-second safety timeout.
 func recvMsg(t *testing.T, ch <-chan agent.Message) agent.Message {
@@ -760,3 +1004,55 @@ func runGit(t *testing.T, dir string, args ...string) {
 		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
 	}
 }
+
+func TestRunnerResolveBase(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	r := &Runner{BaseBranch: "main", Dir: clone}
+	want, err := gitutil.RunGit(t.Context(), clone, "rev-parse", "origin/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := r.ResolveBase(t.Context(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("ResolveBase = %q, want %q", got, want)
+	}
+	// A branch only known locally is used as-is.
+	runGit(t, clone, "branch", "local", "main")
+	if got, err = r.ResolveBase(t.Context(), "local"); err != nil || got != want {
+		t.Errorf("ResolveBase(local) = %q, %v", got, err)
+	}
+	if _, err := r.ResolveBase(t.Context(), "missing"); err == nil {
+		t.Error("expected error for missing branch")
+	}
+	data, err := r.ShowFile(t.Context(), got, "README.md")
+	if err != nil || string(data) != "hello\n" {
+		t.Errorf("ShowFile = %q, %v", data, err)
+	}
+	if _, err := r.ShowFile(t.Context(), got, "../etc/passwd"); err == nil {
+		t.Error("expected error for invalid path")
+	}
+}
+
+func TestRunnerHostDiff(t *testing.T) {
+	clone := initTestRepo(t, "main")
+	runGit(t, clone, "checkout", "-q", "-b", "caic-0")
+	if err := os.WriteFile(filepath.Join(clone, "new.txt"), []byte("new\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, clone, "add", ".")
+	runGit(t, clone, "commit", "-q", "-m", "work")
+	r := &Runner{BaseBranch: "main", Dir: clone}
+	diff, err := r.HostDiff(t.Context(), "caic-0", "md-repo-caic-0", "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+++ b/new.txt") || !strings.HasSuffix(diff, "+new\n") {
+		t.Errorf("diff = %q", diff)
+	}
+	if _, err := r.HostDiff(t.Context(), "missing", "", "main"); !errors.Is(err, ErrUnknownRef) {
+		t.Errorf("err = %v, want ErrUnknownRef", err)
+	}
+}
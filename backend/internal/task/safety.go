@@ -17,39 +17,65 @@ import (
 // SafetyIssue describes a potential problem detected before pushing to origin.
 type SafetyIssue struct {
 	File   string
-	Kind   string // "large_binary" or "secret"
+	Kind   string // "large_binary", "secret" or "test_deletion"
 	Detail string // Human-readable description.
 }
 
 // maxBinarySize is the threshold above which a binary file triggers a warning.
 const maxBinarySize = 500 * 1024 // 500 KB
 
+// TestGuard flags diffs deleting test files or removing significantly more
+// test lines than code lines, since an agent may delete the tests it fails
+// to fix. Zero thresholds use the defaults.
+type TestGuard struct {
+	Disabled bool
+	// MinLines is the number of test lines removed net from which the
+	// reduction is flagged; defaults to 50.
+	MinLines int
+	// Ratio flags the reduction when the test lines removed net exceed Ratio
+	// times the code lines deleted; defaults to 1.
+	Ratio float64
+}
+
+// Test guard defaults.
+const (
+	defaultTestGuardMinLines = 50
+	defaultTestGuardRatio    = 1.0
+)
+
 // secretPatterns are compiled regexps that match common secret material in diff
 // added lines. Pattern strings are split so they don't match themselves.
 var secretPatterns = []*secretPattern{
-	{regexp.MustCompile(`AK` + `IA[0-9A-Z]{16}`), "AWS access key"},
-	{regexp.MustCompile(`-{5}` + `BEGIN\s+(RSA|DSA|EC|OPENSSH|PGP)\s+PRIV` + `ATE\s+KEY-{5}`), "private key"},
-	{regexp.MustCompile(`gh` + `p_[A-Za-z0-9_]{36}`), "GitHub personal access token"},
-	{regexp.MustCompile(`gh` + `o_[A-Za-z0-9_]{36}`), "GitHub OAuth token"},
-	{regexp.MustCompile(`github` + `_pat_[A-Za-z0-9_]{22,}`), "GitHub fine-grained PAT"},
-	{regexp.MustCompile(`sk` + `-[A-Za-z0-9]{20,}`), "API secret key"},
-	{regexp.MustCompile(`(?i)(pass` + `word|sec` + `ret|to` + `ken|api[_-]?key)\s*[:=]\s*['"][^'"]{8,}`), "hardcoded credential"},
+	{re: regexp.MustCompile(`AK` + `IA[0-9A-Z]{16}`), desc: "AWS access key"},
+	{re: regexp.MustCompile(`-{5}` + `BEGIN\s+(RSA|DSA|EC|OPENSSH|PGP)\s+PRIV` + `ATE\s+KEY-{5}`), desc: "private key", armor: true},
+	{re: regexp.MustCompile(`gh` + `p_[A-Za-z0-9_]{36}`), desc: "GitHub personal access token"},
+	{re: regexp.MustCompile(`gh` + `o_[A-Za-z0-9_]{36}`), desc: "GitHub OAuth token"},
+	{re: regexp.MustCompile(`github` + `_pat_[A-Za-z0-9_]{22,}`), desc: "GitHub fine-grained PAT"},
+	{re: regexp.MustCompile(`sk` + `-[A-Za-z0-9]{20,}`), desc: "API secret key"},
+	{re: regexp.MustCompile(`(?i)(?:pass` + `word|sec` + `ret|to` + `ken|api[_-]?key)\s*[:=]\s*['"]([^'"]{8,})`), desc: "hardcoded credential", group: 1},
 }
 
 type secretPattern struct {
 	re   *regexp.Regexp
 	desc string
+	// group is the submatch holding the secret itself; 0 is the whole match.
+	group int
+	// armor is set when the match is a header and the secret is in the lines
+	// following it, up to the END line.
+	armor bool
 }
 
-// CheckSafety scans the diff for large binary files and potential secrets.
-// It returns any issues found. A non-nil error indicates a git command failure,
-// not a safety problem.
-func CheckSafety(ctx context.Context, dir, branch, baseBranch string, ds agent.DiffStat) ([]SafetyIssue, error) {
+// CheckSafety scans the diff for large binary files, potential secrets and,
+// unless tg is disabled, removed tests. Git LFS pointers of ds are skipped:
+// they stand for binaries kept out of the repository. It returns any issues
+// found. A non-nil error indicates a git command failure, not a safety
+// problem.
+func CheckSafety(ctx context.Context, dir, branch, baseBranch string, ds agent.DiffStat, tg TestGuard) ([]SafetyIssue, error) {
 	var issues []SafetyIssue
 
 	// Check binary file sizes.
 	for _, f := range ds {
-		if !f.Binary {
+		if !f.Binary || f.LFS {
 			continue
 		}
 		size, err := gitCatFileSize(ctx, dir, branch, f.Path)
@@ -66,8 +92,21 @@ func CheckSafety(ctx context.Context, dir, branch, baseBranch string, ds agent.D
 		}
 	}
 
+	if !tg.Disabled {
+		issues = append(issues, testDeletionIssues(ds, tg, func(path string) bool {
+			_, err := gitCatFileSize(ctx, dir, branch, path)
+			return err != nil
+		})...)
+	}
+
 	// Scan added lines for secrets.
-	secretIssues, err := scanDiffForSecrets(ctx, dir, branch, baseBranch)
+	lfs := map[string]bool{}
+	for _, f := range ds {
+		if f.LFS {
+			lfs[f.Path] = true
+		}
+	}
+	secretIssues, err := scanDiffForSecrets(ctx, dir, branch, baseBranch, lfs)
 	if err != nil {
 		return issues, err
 	}
@@ -75,6 +114,52 @@ func CheckSafety(ctx context.Context, dir, branch, baseBranch string, ds agent.D
 	return issues, nil
 }
 
+// testDeletionIssues returns an issue per test file of ds that was deleted
+// and, when the test lines removed net cross the thresholds of tg, per test
+// file that lost lines. deleted reports whether a file is gone on the branch.
+func testDeletionIssues(ds agent.DiffStat, tg TestGuard, deleted func(string) bool) []SafetyIssue {
+	if tg.MinLines == 0 {
+		tg.MinLines = defaultTestGuardMinLines
+	}
+	if tg.Ratio == 0 {
+		tg.Ratio = defaultTestGuardRatio
+	}
+	var issues []SafetyIssue
+	var shrunk []agent.DiffFileStat
+	removed, codeDeleted := 0, 0
+	for _, f := range ds {
+		if f.Binary || f.LFS || f.Submodule != (agent.SubmoduleBump{}) {
+			continue
+		}
+		if !isTestFile(f.Path) {
+			codeDeleted += f.Deleted
+			continue
+		}
+		removed += f.Deleted - f.Added
+		switch {
+		case f.Added == 0 && f.Deleted > 0 && deleted(f.Path):
+			issues = append(issues, SafetyIssue{
+				File:   f.Path,
+				Kind:   "test_deletion",
+				Detail: fmt.Sprintf("test file deleted (%d lines)", f.Deleted),
+			})
+		case f.Deleted > f.Added:
+			shrunk = append(shrunk, f)
+		}
+	}
+	if removed < tg.MinLines || float64(removed) <= tg.Ratio*float64(codeDeleted) {
+		return issues
+	}
+	for _, f := range shrunk {
+		issues = append(issues, SafetyIssue{
+			File:   f.Path,
+			Kind:   "test_deletion",
+			Detail: fmt.Sprintf("test file loses %d lines; the diff removes %d test lines net while deleting %d lines of code", f.Deleted-f.Added, removed, codeDeleted),
+		})
+	}
+	return issues
+}
+
 // gitCatFileSize returns the size of a blob in the given branch.
 func gitCatFileSize(ctx context.Context, dir, branch, path string) (int64, error) {
 	slog.Debug("git cat-file size", "branch", branch, "path", path)
@@ -87,8 +172,9 @@ func gitCatFileSize(ctx context.Context, dir, branch, path string) (int64, error
 	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
 }
 
-// scanDiffForSecrets runs git diff and scans added lines for secret patterns.
-func scanDiffForSecrets(ctx context.Context, dir, branch, baseBranch string) ([]SafetyIssue, error) {
+// scanDiffForSecrets runs git diff and scans added lines for secret patterns,
+// except in the files of skip.
+func scanDiffForSecrets(ctx context.Context, dir, branch, baseBranch string, skip map[string]bool) ([]SafetyIssue, error) {
 	slog.Info("git diff for secrets", "branch", branch, "baseBranch", baseBranch)
 	cmd := exec.CommandContext(ctx, "git", "diff", "origin/"+baseBranch+"..."+branch) //nolint:gosec // branch names are from internal git state.
 	cmd.Dir = dir
@@ -112,7 +198,7 @@ func scanDiffForSecrets(ctx context.Context, dir, branch, baseBranch string) ([]
 			continue
 		}
 		// Only scan added lines.
-		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") {
+		if !strings.HasPrefix(line, "+") || strings.HasPrefix(line, "+++") || skip[currentFile] {
 			continue
 		}
 		added := line[1:]
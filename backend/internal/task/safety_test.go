@@ -27,7 +27,7 @@ func TestCheckSafety(t *testing.T) {
 		runGit(t, clone, "commit", "-m", "add binary")
 
 		ds := agent.DiffStat{{Path: "big.bin", Binary: true}}
-		issues, err := CheckSafety(ctx, clone, "caic-0", "main", ds)
+		issues, err := CheckSafety(ctx, clone, "caic-0", "main", ds, TestGuard{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -55,7 +55,7 @@ func TestCheckSafety(t *testing.T) {
 		runGit(t, clone, "commit", "-m", "add small binary")
 
 		ds := agent.DiffStat{{Path: "small.bin", Binary: true}}
-		issues, err := CheckSafety(ctx, clone, "caic-0", "main", ds)
+		issues, err := CheckSafety(ctx, clone, "caic-0", "main", ds, TestGuard{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -64,6 +64,29 @@ func TestCheckSafety(t *testing.T) {
 		}
 	})
 
+	t.Run("LFSPointerSkipped", func(t *testing.T) {
+		ctx := t.Context()
+		clone := initTestRepo(t, "main")
+
+		runGit(t, clone, "checkout", "-b", "caic-0")
+		data := make([]byte, 600*1024)
+		copy(data, "sk"+"-abcdefghijklmnopqrstuvwxyz")
+		if err := os.WriteFile(filepath.Join(clone, "model.bin"), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, clone, "add", "model.bin")
+		runGit(t, clone, "commit", "-m", "add model")
+
+		ds := agent.DiffStat{{Path: "model.bin", Binary: true, LFS: true}}
+		issues, err := CheckSafety(ctx, clone, "caic-0", "main", ds, TestGuard{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 0 {
+			t.Errorf("got %+v, want none", issues)
+		}
+	})
+
 	t.Run("SecretDetection", func(t *testing.T) {
 		ctx := t.Context()
 		clone := initTestRepo(t, "main")
@@ -76,7 +99,7 @@ func TestCheckSafety(t *testing.T) {
 		runGit(t, clone, "add", "config.go")
 		runGit(t, clone, "commit", "-m", "add config")
 
-		issues, err := CheckSafety(ctx, clone, "caic-0", "main", nil)
+		issues, err := CheckSafety(ctx, clone, "caic-0", "main", nil, TestGuard{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -103,7 +126,7 @@ func TestCheckSafety(t *testing.T) {
 		runGit(t, clone, "add", "key.pem")
 		runGit(t, clone, "commit", "-m", "add key")
 
-		issues, err := CheckSafety(ctx, clone, "caic-0", "main", nil)
+		issues, err := CheckSafety(ctx, clone, "caic-0", "main", nil, TestGuard{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -127,7 +150,7 @@ func TestCheckSafety(t *testing.T) {
 		runGit(t, clone, "add", "app.conf")
 		runGit(t, clone, "commit", "-m", "add config")
 
-		issues, err := CheckSafety(ctx, clone, "caic-0", "main", nil)
+		issues, err := CheckSafety(ctx, clone, "caic-0", "main", nil, TestGuard{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -160,7 +183,7 @@ func TestCheckSafety(t *testing.T) {
 
 		// Using the bare branch name would fail (the old bug).
 		ref := "refs/remotes/md-caic-0/caic-0"
-		issues, err := CheckSafety(ctx, clone, ref, "main", nil)
+		issues, err := CheckSafety(ctx, clone, ref, "main", nil, TestGuard{})
 		if err != nil {
 			t.Fatalf("CheckSafety with remote ref failed: %v", err)
 		}
@@ -169,6 +192,33 @@ func TestCheckSafety(t *testing.T) {
 		}
 	})
 
+	t.Run("TestDeletion", func(t *testing.T) {
+		ctx := t.Context()
+		clone := initTestRepo(t, "main")
+		if err := os.WriteFile(filepath.Join(clone, "a_test.go"), []byte("package a\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, clone, "add", "a_test.go")
+		runGit(t, clone, "commit", "-m", "add test")
+		runGit(t, clone, "push", "origin", "main")
+
+		runGit(t, clone, "checkout", "-b", "caic-0")
+		runGit(t, clone, "rm", "a_test.go")
+		runGit(t, clone, "commit", "-m", "drop test")
+
+		ds := agent.DiffStat{{Path: "a_test.go", Deleted: 1}}
+		issues, err := CheckSafety(ctx, clone, "caic-0", "main", ds, TestGuard{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(issues) != 1 || issues[0].Kind != "test_deletion" || issues[0].File != "a_test.go" {
+			t.Errorf("got %+v", issues)
+		}
+		if issues, err = CheckSafety(ctx, clone, "caic-0", "main", ds, TestGuard{Disabled: true}); err != nil || len(issues) != 0 {
+			t.Errorf("disabled: got %+v, %v", issues, err)
+		}
+	})
+
 	t.Run("NoIssues", func(t *testing.T) {
 		ctx := t.Context()
 		clone := initTestRepo(t, "main")
@@ -181,7 +231,7 @@ func TestCheckSafety(t *testing.T) {
 		runGit(t, clone, "commit", "-m", "add clean")
 
 		ds := agent.DiffStat{{Path: "clean.go", Added: 1}}
-		issues, err := CheckSafety(ctx, clone, "caic-0", "main", ds)
+		issues, err := CheckSafety(ctx, clone, "caic-0", "main", ds, TestGuard{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -191,6 +241,39 @@ func TestCheckSafety(t *testing.T) {
 	})
 }
 
+func TestTestDeletionIssues(t *testing.T) {
+	gone := func(p string) bool { return p == "old_test.go" }
+	ds := agent.DiffStat{
+		{Path: "old_test.go", Deleted: 30},
+		{Path: "parser_test.go", Added: 5, Deleted: 45},
+		{Path: "lexer_test.go", Added: 10, Deleted: 2},
+		{Path: "parser.go", Added: 20, Deleted: 10},
+	}
+	t.Run("Default", func(t *testing.T) {
+		// 62 test lines removed net for 10 lines of code deleted.
+		issues := testDeletionIssues(ds, TestGuard{}, gone)
+		if len(issues) != 2 || issues[0].File != "old_test.go" || issues[1].File != "parser_test.go" {
+			t.Fatalf("got %+v", issues)
+		}
+		if want := "test file loses 40 lines; the diff removes 62 test lines net while deleting 10 lines of code"; issues[1].Detail != want {
+			t.Errorf("detail = %q, want %q", issues[1].Detail, want)
+		}
+	})
+	t.Run("Thresholds", func(t *testing.T) {
+		for _, tg := range []TestGuard{{MinLines: 100}, {Ratio: 10}} {
+			if issues := testDeletionIssues(ds, tg, gone); len(issues) != 1 || issues[0].File != "old_test.go" {
+				t.Errorf("%+v: got %+v", tg, issues)
+			}
+		}
+	})
+	t.Run("CodeRemoved", func(t *testing.T) {
+		ds := agent.DiffStat{{Path: "feature_test.go", Deleted: 80}, {Path: "feature.go", Deleted: 200}}
+		if issues := testDeletionIssues(ds, TestGuard{}, func(string) bool { return false }); len(issues) != 0 {
+			t.Errorf("got %+v", issues)
+		}
+	})
+}
+
 func TestHumanSize(t *testing.T) {
 	tests := []struct {
 		in   int64
@@ -224,7 +307,7 @@ func TestScanDiffForSecrets_Deduplication(t *testing.T) {
 	runGit(t, clone, "add", "keys.go")
 	runGit(t, clone, "commit", "-m", "add keys")
 
-	issues, err := scanDiffForSecrets(ctx, clone, "caic-0", "main")
+	issues, err := scanDiffForSecrets(ctx, clone, "caic-0", "main", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,88 @@
+// Signing of the commits caic creates during sync.
+package task
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+// Signing signs the commits caic creates during sync: squash commits, the
+// task notes and the commits rewritten to carry trailers. Protected branches
+// often require signed commits.
+type Signing struct {
+	Format string // git's gpg.format: "openpgp" (default), "ssh" or "x509".
+	Key    string // Key ID, or for "ssh" the path of the key.
+}
+
+// Validate returns an error if the signing configuration is invalid.
+func (s *Signing) Validate() error {
+	switch s.Format {
+	case "", "openpgp", "ssh", "x509":
+	default:
+		return fmt.Errorf("unsupported signing format %q", s.Format)
+	}
+	if s.Key == "" {
+		return errors.New("signing key must not be empty")
+	}
+	if s.Format == "ssh" {
+		if _, err := os.Stat(s.Key); err != nil {
+			return fmt.Errorf("signing key: %w", err)
+		}
+	}
+	return nil
+}
+
+// SyncOptions are what caic adds to the commits it syncs to origin.
+type SyncOptions struct {
+	Notes    string   // Committed as NotesFile on top of a pushed branch when not empty.
+	Trailers []string // Added to the synced commits, see Task.Trailers.
+	Sign     *Signing // Signs the commits caic creates; nil leaves them unsigned.
+}
+
+// commitTree runs git commit-tree in dir with message and args, e.g. the
+// tree and "-p" parents, and returns the new commit. It is signed with sign
+// unless nil. env adds variables to git's environment.
+func commitTree(ctx context.Context, dir string, sign *Signing, env []string, message string, args ...string) (string, error) {
+	var pre []string
+	if sign != nil {
+		format := sign.Format
+		if format == "" {
+			format = "openpgp"
+		}
+		pre = []string{"-c", "gpg.format=" + format, "commit-tree", "-S" + sign.Key}
+	} else {
+		pre = []string{"commit-tree"}
+	}
+	cmd := exec.CommandContext(ctx, "git", append(pre, args...)...) //nolint:gosec // args are from internal git state
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = strings.NewReader(message)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git commit-tree: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// squashOnto creates a commit with the tree of ref on top of
+// origin/baseBranch and pushes it to baseBranch, without force. It is
+// gitutil.SquashOnto with signing.
+func squashOnto(ctx context.Context, dir, ref, baseBranch, message string, sign *Signing) error {
+	if err := gitutil.Fetch(ctx, dir); err != nil {
+		return err
+	}
+	c, err := commitTree(ctx, dir, sign, nil, message, ref+"^{tree}", "-p", "origin/"+baseBranch)
+	if err != nil {
+		return err
+	}
+	return gitutil.PushRef(ctx, dir, c, baseBranch, false)
+}
@@ -0,0 +1,57 @@
+package task
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+func TestSigningValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		s    Signing
+		ok   bool
+	}{
+		{"openpgp", Signing{Key: "ABCD1234"}, true},
+		{"x509", Signing{Format: "x509", Key: "ABCD1234"}, true},
+		{"noKey", Signing{Format: "openpgp"}, false},
+		{"badFormat", Signing{Format: "pgp", Key: "ABCD1234"}, false},
+		{"sshMissing", Signing{Format: "ssh", Key: filepath.Join(t.TempDir(), "id")}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.s.Validate(); (err == nil) != tc.ok {
+				t.Errorf("Validate() = %v", err)
+			}
+		})
+	}
+}
+
+func TestCommitTreeSigned(t *testing.T) {
+	if _, err := exec.LookPath("ssh-keygen"); err != nil {
+		t.Skip("ssh-keygen not found")
+	}
+	key := filepath.Join(t.TempDir(), "id")
+	if out, err := exec.Command("ssh-keygen", "-q", "-t", "ed25519", "-N", "", "-f", key).CombinedOutput(); err != nil { //nolint:gosec // test key path
+		t.Fatalf("ssh-keygen: %v\n%s", err, out)
+	}
+	sign := &Signing{Format: "ssh", Key: key}
+	if err := sign.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	ctx := t.Context()
+	clone := initTestRepo(t, "main")
+	c, err := commitNotes(ctx, clone, "HEAD", "notes\n", sign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := gitutil.RunGit(ctx, clone, "cat-file", "commit", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(raw, "gpgsig -----BEGIN SSH SIGNATURE-----") {
+		t.Errorf("unsigned commit:\n%s", raw)
+	}
+}
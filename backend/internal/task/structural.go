@@ -0,0 +1,105 @@
+// Structural summaries of the changes of a task branch, by declaration.
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/caic-xyz/caic/backend/internal/structdiff"
+	"github.com/caic-xyz/md"
+	"github.com/caic-xyz/md/gitutil"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// FileSummary is the structural summary of the changes to a source file.
+type FileSummary struct {
+	Path    string
+	Changes []structdiff.Change
+}
+
+const (
+	// maxSummaryFiles bounds the files summarized.
+	maxSummaryFiles = 200
+	// maxSummaryFileSize is the size from which a file isn't parsed.
+	maxSummaryFileSize = 1 << 20
+)
+
+// StructuralDiff summarizes by declaration the changes of the task branch
+// since its merge base with base, any commit-ish of the host repository. The
+// container's work is fetched first, like DiffContentFrom. Files in
+// unsupported languages or without declaration changes are left out.
+func (r *Runner) StructuralDiff(ctx context.Context, branch, container, base string, extraRepos []md.Repo) (_ []FileSummary, err error) {
+	r.initDefaults()
+	if r.Dir == "" {
+		return nil, errors.New("diff is not supported for no-repo tasks")
+	}
+	if r.Container == nil {
+		return nil, errors.New("no container backend")
+	}
+	ctx, span := startSpan(ctx, "git.StructuralDiff", nil, attribute.String("caic.repo", r.Dir), attribute.String("caic.branch", branch))
+	defer func() { agent.EndSpan(span, err) }()
+	fetchCtx, fetchCancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.FetchTimeout)
+	defer fetchCancel()
+	r.branchMu.Lock()
+	defer r.branchMu.Unlock()
+	if err := r.fetchContainer(fetchCtx, append([]md.Repo{{GitRoot: r.Dir, Branch: branch}}, extraRepos...)); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), r.Git.DiffTimeout)
+	defer cancel()
+	baseSHA, err := resolveCommit(ctx, r.Dir, base)
+	if err != nil {
+		return nil, err
+	}
+	head, err := resolveCommit(ctx, r.Dir, "refs/remotes/"+container+"/"+branch)
+	if err != nil {
+		return nil, err
+	}
+	from, err := gitutil.RunGit(ctx, r.Dir, "merge-base", baseSHA, head)
+	if err != nil {
+		return nil, err
+	}
+	return retryMissing(ctx, r.Dir, func() ([]FileSummary, error) {
+		return summarizeRefs(ctx, r.Dir, from, head)
+	})
+}
+
+// summarizeRefs summarizes by declaration the changes from commit from to
+// commit to in dir.
+func summarizeRefs(ctx context.Context, dir, from, to string) ([]FileSummary, error) {
+	out, err := gitutil.RunGit(ctx, dir, "diff", "--no-renames", "--name-only", "-z", from, to, "--")
+	if err != nil {
+		return nil, err
+	}
+	var paths, objs []string
+	for p := range strings.SplitSeq(out, "\x00") {
+		if p == "" || structdiff.Language(p) == "" || strings.Contains(p, "\n") {
+			continue
+		}
+		if len(paths) == maxSummaryFiles {
+			break
+		}
+		paths = append(paths, p)
+		objs = append(objs, from+":"+p, to+":"+p)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	contents, err := catFiles(ctx, dir, objs)
+	if err != nil {
+		return nil, err
+	}
+	var files []FileSummary
+	for i, p := range paths {
+		old, cur := contents[2*i], contents[2*i+1]
+		if len(old) > maxSummaryFileSize || len(cur) > maxSummaryFileSize {
+			continue
+		}
+		if c := structdiff.Summarize(p, old, cur); len(c) != 0 {
+			files = append(files, FileSummary{Path: p, Changes: c})
+		}
+	}
+	return files, nil
+}
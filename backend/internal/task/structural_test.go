@@ -0,0 +1,50 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+func TestSummarizeRefs(t *testing.T) {
+	ctx := t.Context()
+	clone := initTestRepo(t, "main")
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(clone, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package a\n\nfunc A() {}\n\nfunc B() int { return 1 }\n")
+	runGit(t, clone, "add", ".")
+	runGit(t, clone, "commit", "-m", "a")
+	from, err := gitutil.RevParse(ctx, clone, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("a.go", "package a\n\nfunc A(x int) {}\n\nfunc B() int { return 2 }\n")
+	write("b.py", "def main():\n    pass\n")
+	write("notes.txt", "def nope():\n")
+	runGit(t, clone, "add", ".")
+	runGit(t, clone, "commit", "-m", "b")
+	to, err := gitutil.RevParse(ctx, clone, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := summarizeRefs(ctx, clone, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range files {
+		for _, c := range f.Changes {
+			got = append(got, f.Path+": "+c.String())
+		}
+	}
+	want := []string{"a.go: modified signature of function A", "a.go: modified function B", "b.py: added function main"}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+// Submodules and Git LFS objects of the container checkout.
+package task
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+// checkoutDepsCommand returns the shell command run inside the container to
+// init the submodules and, with lfs, pull the Git LFS objects of the
+// repository at gitRoot, pushed by md to ~/src/<basename> at branch. It
+// returns "" when there is nothing to do.
+//
+// The container clone's origin is the host, which has neither the submodules
+// nor the LFS objects, so both are fetched from the upstream of the host's
+// origin.
+func checkoutDepsCommand(ctx context.Context, gitRoot, branch string, submodules, lfs bool) (string, error) {
+	origin, err := gitutil.RunGit(ctx, gitRoot, "remote", "get-url", "origin")
+	if err != nil {
+		return "", err
+	}
+	var cmds []string
+	if submodules {
+		urls, err := submoduleURLs(ctx, gitRoot, branch)
+		if err != nil {
+			return "", err
+		}
+		names := make([]string, 0, len(urls))
+		for name := range urls {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			cmds = append(cmds, "git config "+shellQuote("submodule."+name+".url")+" "+shellQuote(resolveRemoteURL(origin, urls[name])))
+		}
+		if len(names) != 0 {
+			cmds = append(cmds, "git submodule update --init --recursive")
+		}
+	}
+	if lfs {
+		cmds = append(cmds, "git config lfs.url "+shellQuote(lfsURL(origin)), "git lfs install --local", "git lfs pull")
+	}
+	if len(cmds) == 0 {
+		return "", nil
+	}
+	return "cd ~/src/" + shellQuote(filepath.Base(gitRoot)) + " && " + strings.Join(cmds, " && "), nil
+}
+
+// submoduleURLs returns the URLs of the submodules declared in the
+// .gitmodules of ref, keyed by submodule name.
+func submoduleURLs(ctx context.Context, dir, ref string) (map[string]string, error) {
+	if _, err := gitutil.RunGit(ctx, dir, "cat-file", "-e", ref+":.gitmodules"); err != nil {
+		// No .gitmodules, no submodules.
+		return nil, nil
+	}
+	out, err := gitutil.RunGit(ctx, dir, "config", "--blob", ref+":.gitmodules", "--get-regexp", `^submodule\..*\.url$`)
+	if err != nil {
+		return nil, fmt.Errorf("read .gitmodules: %w", err)
+	}
+	urls := map[string]string{}
+	for line := range strings.SplitSeq(out, "\n") {
+		key, url, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		urls[strings.TrimSuffix(strings.TrimPrefix(key, "submodule."), ".url")] = url
+	}
+	return urls, nil
+}
+
+// resolveRemoteURL resolves url, when relative ("./" or "../"), against the
+// remote URL base the way git resolves submodule URLs. base may be an URL or
+// scp-like, e.g. "git@github.com:org/repo.git".
+func resolveRemoteURL(base, url string) string {
+	if !strings.HasPrefix(url, "./") && !strings.HasPrefix(url, "../") {
+		return url
+	}
+	base = strings.TrimSuffix(base, "/")
+	// Never strip the scheme.
+	root := 0
+	if i := strings.Index(base, "://"); i != -1 {
+		root = i + 3
+	}
+	sep := "/"
+	for {
+		switch {
+		case strings.HasPrefix(url, "./"):
+			url = url[2:]
+		case strings.HasPrefix(url, "../"):
+			url = url[3:]
+			if i := strings.LastIndexAny(base[root:], "/:"); i != -1 {
+				sep = base[root+i : root+i+1]
+				base = base[:root+i]
+			}
+		default:
+			return base + sep + url
+		}
+	}
+}
+
+// lfsURL returns the Git LFS endpoint of the remote URL origin, following
+// git-lfs' defaults: ssh remotes map to https on the same host.
+func lfsURL(origin string) string {
+	if u, ok := httpsURL(origin); ok {
+		return u + "/info/lfs"
+	}
+	return strings.TrimSuffix(origin, "/") + "/info/lfs"
+}
+
+// httpsURL returns the https URL, ending in .git, of the remote URL origin;
+// ssh remotes map to the same host and path. It reports false for local
+// paths and unknown schemes.
+func httpsURL(origin string) (string, bool) {
+	u := strings.TrimSuffix(origin, "/")
+	switch {
+	case strings.HasPrefix(u, "https://"), strings.HasPrefix(u, "http://"):
+	case strings.HasPrefix(u, "ssh://"):
+		host, p, _ := strings.Cut(strings.TrimPrefix(u, "ssh://"), "/")
+		if _, h, ok := strings.Cut(host, "@"); ok {
+			host = h
+		}
+		if h, _, ok := strings.Cut(host, ":"); ok {
+			host = h
+		}
+		u = "https://" + host + "/" + p
+	case strings.Contains(u, ":") && !strings.Contains(u, "://"):
+		host, p, _ := strings.Cut(u, ":")
+		if _, h, ok := strings.Cut(host, "@"); ok {
+			host = h
+		}
+		u = "https://" + host + "/" + p
+	default:
+		return "", false
+	}
+	if !strings.HasSuffix(u, ".git") {
+		u += ".git"
+	}
+	return u, true
+}
@@ -0,0 +1,78 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveRemoteURL(t *testing.T) {
+	for _, c := range []struct{ base, url, want string }{
+		{"https://github.com/org/repo.git", "../lib.git", "https://github.com/org/lib.git"},
+		{"https://github.com/org/repo/", "./sub", "https://github.com/org/repo/sub"},
+		{"https://github.com/org/repo.git", "../../other/lib.git", "https://github.com/other/lib.git"},
+		{"git@github.com:org/repo.git", "../lib.git", "git@github.com:org/lib.git"},
+		{"git@host:repo.git", "../lib.git", "git@host:lib.git"},
+		{"/srv/git/repo.git", "../lib.git", "/srv/git/lib.git"},
+		{"https://github.com/org/repo.git", "https://example.com/lib.git", "https://example.com/lib.git"},
+	} {
+		if got := resolveRemoteURL(c.base, c.url); got != c.want {
+			t.Errorf("resolveRemoteURL(%q, %q) = %q, want %q", c.base, c.url, got, c.want)
+		}
+	}
+}
+
+func TestLFSURL(t *testing.T) {
+	for _, c := range []struct{ origin, want string }{
+		{"https://github.com/org/repo.git", "https://github.com/org/repo.git/info/lfs"},
+		{"https://github.com/org/repo", "https://github.com/org/repo.git/info/lfs"},
+		{"git@github.com:org/repo.git", "https://github.com/org/repo.git/info/lfs"},
+		{"ssh://git@gitlab.com:2222/org/repo.git", "https://gitlab.com/org/repo.git/info/lfs"},
+	} {
+		if got := lfsURL(c.origin); got != c.want {
+			t.Errorf("lfsURL(%q) = %q, want %q", c.origin, got, c.want)
+		}
+	}
+}
+
+func TestCheckoutDepsCommand(t *testing.T) {
+	ctx := t.Context()
+	clone := initTestRepo(t, "main")
+	origin := filepath.Join(filepath.Dir(clone), "remote.git")
+	t.Run("NoSubmodules", func(t *testing.T) {
+		cmd, err := checkoutDepsCommand(ctx, clone, "main", true, false)
+		if err != nil || cmd != "" {
+			t.Errorf("got %q, %v, want nothing to do", cmd, err)
+		}
+	})
+	gitmodules := "[submodule \"lib\"]\n\tpath = lib\n\turl = ../lib.git\n[submodule \"x.y\"]\n\tpath = third_party/x\n\turl = https://example.com/x.git\n"
+	if err := os.WriteFile(filepath.Join(clone, ".gitmodules"), []byte(gitmodules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, clone, "add", ".gitmodules")
+	runGit(t, clone, "commit", "-m", "submodules")
+	t.Run("Both", func(t *testing.T) {
+		cmd, err := checkoutDepsCommand(ctx, clone, "main", true, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "cd ~/src/'clone'" +
+			" && git config 'submodule.lib.url' '" + filepath.Join(filepath.Dir(origin), "lib.git") + "'" +
+			" && git config 'submodule.x.y.url' 'https://example.com/x.git'" +
+			" && git submodule update --init --recursive" +
+			" && git config lfs.url '" + origin + "/info/lfs' && git lfs install --local && git lfs pull"
+		if cmd != want {
+			t.Errorf("got:\n%s\nwant:\n%s", cmd, want)
+		}
+	})
+	t.Run("LFSOnly", func(t *testing.T) {
+		cmd, err := checkoutDepsCommand(ctx, clone, "main", false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(cmd, "submodule") || !strings.HasSuffix(cmd, "git lfs pull") {
+			t.Errorf("got %q", cmd)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+// Transcript summarization of long sessions via LLM map-reduce over chunks.
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/maruel/genai"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	summaryMapPrompt = "You are reading part %d of %d of a transcript of a coding agent session. " +
+		"Write concise notes covering: what was attempted, key decisions and their rationale, files changed, " +
+		"commands that mattered, and dead ends or reverted approaches. Use short bullet points. Do not invent facts."
+	summaryReducePrompt = "You are given a transcript of a coding agent session, or notes on its consecutive parts. " +
+		"Write a condensed narrative of the whole session in Markdown with the sections " +
+		"\"Summary\", \"Key decisions\", \"Files changed\" and \"Dead ends\". Be concise; omit empty sections. Do not invent facts."
+
+	// summaryChunkChars bounds the transcript sent in one map call.
+	summaryChunkChars = 40000
+	// summaryMaxMessageChars bounds a single rendered message so one huge
+	// tool call does not fill a chunk.
+	summaryMaxMessageChars = 4000
+	// summaryParallelism bounds concurrent map calls.
+	summaryParallelism = 3
+)
+
+// Summary is a condensed narrative of a task's conversation.
+type Summary struct {
+	Text      string    `json:"text"`
+	Messages  int       `json:"messages"` // Number of messages summarized.
+	Chunks    int       `json:"chunks"`   // Number of map calls.
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Summarize condenses msgs with p. Each chunk of the transcript is
+// summarized independently, then the partial notes are merged in a final
+// call. Short sessions are summarized in a single call.
+func Summarize(ctx context.Context, p genai.Provider, msgs []agent.Message) (*Summary, error) {
+	chunks := transcriptChunks(msgs, summaryChunkChars)
+	if len(chunks) == 0 {
+		return nil, errors.New("nothing to summarize")
+	}
+	sm := &Summary{Messages: len(msgs), Chunks: len(chunks)}
+	if len(chunks) == 1 {
+		text, err := genText(ctx, p, summaryReducePrompt, chunks[0])
+		if err != nil {
+			return nil, err
+		}
+		sm.Text = text
+		sm.CreatedAt = time.Now().UTC()
+		return sm, nil
+	}
+	notes := make([]string, len(chunks))
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(summaryParallelism)
+	for i, c := range chunks {
+		eg.Go(func() error {
+			text, err := genText(egCtx, p, fmt.Sprintf(summaryMapPrompt, i+1, len(chunks)), c)
+			if err != nil {
+				return fmt.Errorf("part %d: %w", i+1, err)
+			}
+			notes[i] = text
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	for i, n := range notes {
+		fmt.Fprintf(&b, "## Part %d\n%s\n\n", i+1, n)
+	}
+	text, err := genText(ctx, p, summaryReducePrompt, b.String())
+	if err != nil {
+		return nil, err
+	}
+	sm.Text = text
+	sm.CreatedAt = time.Now().UTC()
+	return sm, nil
+}
+
+func genText(ctx context.Context, p genai.Provider, system, input string) (string, error) {
+	res, err := p.GenSync(ctx,
+		genai.Messages{genai.NewTextMessage(input)},
+		&genai.GenOptionText{SystemPrompt: system},
+	)
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(res.String())
+	if text == "" {
+		return "", errors.New("empty reply")
+	}
+	return text, nil
+}
+
+// transcriptChunks renders msgs as plain text split into chunks of at most
+// maxChars, breaking between messages.
+func transcriptChunks(msgs []agent.Message, maxChars int) []string {
+	var chunks []string
+	var b strings.Builder
+	for _, m := range msgs {
+		line := renderForSummary(m)
+		if line == "" {
+			continue
+		}
+		if len(line) > summaryMaxMessageChars {
+			line = strings.ToValidUTF8(line[:summaryMaxMessageChars], "") + " […]"
+		}
+		if b.Len() > 0 && b.Len()+len(line)+1 > maxChars {
+			chunks = append(chunks, b.String())
+			b.Reset()
+		}
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	if b.Len() > 0 {
+		chunks = append(chunks, b.String())
+	}
+	return chunks
+}
+
+// renderForSummary returns a one-paragraph rendering of m, or "" for
+// messages irrelevant to a narrative (deltas, usage, raw events).
+func renderForSummary(m agent.Message) string {
+	switch v := m.(type) {
+	case *agent.UserInputMessage:
+		return "User: " + v.Text
+	case *agent.TextMessage:
+		return "Assistant: " + v.Text
+	case *agent.ToolUseMessage:
+		var in bytes.Buffer
+		if json.Compact(&in, v.Input) != nil {
+			return "Tool " + v.Name
+		}
+		return "Tool " + v.Name + ": " + in.String()
+	case *agent.ToolResultMessage:
+		if v.Error != "" {
+			return "Tool error: " + v.Error
+		}
+	case *agent.AskMessage:
+		qs := make([]string, len(v.Questions))
+		for i, q := range v.Questions {
+			qs[i] = q.Question
+		}
+		return "Agent asked: " + strings.Join(qs, " / ")
+	case *agent.ResultMessage:
+		if v.IsError {
+			return "Turn failed: " + v.Result
+		}
+		return "Turn result: " + v.Result
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestTranscriptChunks(t *testing.T) {
+	msgs := []agent.Message{
+		&agent.UserInputMessage{Text: "fix it"},
+		&agent.TextDeltaMessage{Text: "ignored"},
+		&agent.TextMessage{Text: strings.Repeat("a", 30)},
+		&agent.ToolResultMessage{Error: "boom"},
+		&agent.ResultMessage{Result: "done"},
+	}
+	chunks := transcriptChunks(msgs, 50)
+	if len(chunks) != 3 {
+		t.Fatalf("chunks = %q", chunks)
+	}
+	if chunks[0] != "User: fix it\n" || !strings.HasPrefix(chunks[1], "Assistant: a") || chunks[2] != "Tool error: boom\nTurn result: done\n" {
+		t.Errorf("chunks = %q", chunks)
+	}
+	if got := transcriptChunks([]agent.Message{&agent.UsageMessage{}}, 50); got != nil {
+		t.Errorf("chunks = %q", got)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	t.Run("Single", func(t *testing.T) {
+		p := &fakeProvider{reply: func(string, int) (string, error) { return " narrative ", nil }}
+		sm, err := Summarize(t.Context(), p, []agent.Message{&agent.UserInputMessage{Text: "hi"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sm.Text != "narrative" || sm.Chunks != 1 || sm.Messages != 1 || len(p.calls) != 1 {
+			t.Errorf("summary = %+v, calls = %d", sm, len(p.calls))
+		}
+	})
+	t.Run("MapReduce", func(t *testing.T) {
+		p := &fakeProvider{reply: func(input string, _ int) (string, error) {
+			if strings.HasPrefix(input, "## Part 1") {
+				return "final", nil
+			}
+			return "notes", nil
+		}}
+		var msgs []agent.Message
+		for range 25 {
+			msgs = append(msgs, &agent.TextMessage{Text: strings.Repeat("x", summaryMaxMessageChars)})
+		}
+		sm, err := Summarize(t.Context(), p, msgs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sm.Text != "final" || sm.Chunks < 2 || len(p.calls) != sm.Chunks+1 {
+			t.Errorf("summary = %+v, calls = %d", sm, len(p.calls))
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		if _, err := Summarize(t.Context(), &fakeProvider{}, nil); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
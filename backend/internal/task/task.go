@@ -8,14 +8,16 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/caic-xyz/caic/backend/internal/agent"
 	"github.com/caic-xyz/caic/backend/internal/forge"
+	"github.com/caic-xyz/caic/backend/internal/scrub"
 	"github.com/caic-xyz/md"
-	"github.com/maruel/genai"
 	"github.com/maruel/ksid"
 )
 
@@ -32,6 +34,7 @@ const (
 	StateWaiting            // Agent completed a turn, awaiting user input or purge.
 	StateAsking             // Agent asked a question (AskUserQuestion), needs answer.
 	StateHasPlan            // Agent finished planning (ExitPlanMode with plan content), awaiting approval.
+	StatePaused             // Agent session closed by the user; container kept running for a resume.
 	StatePulling            // Pulling changes from container.
 	StatePushing            // Pushing to origin.
 	StateStopping           // Graceful stop in progress (container being stopped, preserved for revival).
@@ -59,6 +62,8 @@ func (s State) String() string {
 		return "asking"
 	case StateHasPlan:
 		return "has_plan"
+	case StatePaused:
+		return "paused"
 	case StatePulling:
 		return "pulling"
 	case StatePushing:
@@ -78,6 +83,44 @@ func (s State) String() string {
 	}
 }
 
+// Priority orders tasks competing for scarce capacity such as GPUs.
+type Priority int
+
+// Task priorities.
+const (
+	PriorityLow    Priority = -1
+	PriorityNormal Priority = 0
+	PriorityHigh   Priority = 1
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityNormal:
+		return "normal"
+	case PriorityHigh:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePriority returns the Priority named s. An empty string is
+// PriorityNormal.
+func ParsePriority(s string) (Priority, error) {
+	switch s {
+	case "low":
+		return PriorityLow, nil
+	case "", "normal":
+		return PriorityNormal, nil
+	case "high":
+		return PriorityHigh, nil
+	default:
+		return PriorityNormal, fmt.Errorf("unknown priority %q", s)
+	}
+}
+
 // SessionHandle bundles the resources associated with an active agent session:
 // the SSH session, the message dispatch channel, and the log writer.
 // DispatchDone is closed when the dispatch goroutine exits after MsgCh is closed.
@@ -103,6 +146,20 @@ type RepoMount struct {
 	BaseBranch string // branch to fork from; empty = runner default
 	Branch     string // allocated branch, e.g. "caic-0"
 	GitRoot    string // absolute host path; empty in purged-task entries
+	// BaseSHA is the commit Branch was created from, recorded during setup.
+	// When set before setup, the branch is created from it instead of
+	// BaseBranch.
+	BaseSHA string
+	// SparsePaths restricts the container checkout to these directories when
+	// non-empty. See SparsePaths.
+	SparsePaths []string
+	// Submodules and LFS are GitOptions.Submodules and GitOptions.LFS of the
+	// repository, applied when the container is set up.
+	Submodules bool
+	LFS        bool
+	// Filter is GitOptions.FetchFilter of the repository; the container
+	// clone is a partial clone with it when set.
+	Filter string
 }
 
 // Task represents a single unit of work.
@@ -117,10 +174,31 @@ type Task struct {
 	Tailscale     bool          // Enable Tailscale networking in the container.
 	USB           bool          // Enable USB passthrough in the container.
 	Display       bool          // Enable Xvfb display in the container.
+	GPU           bool          // Enable GPU passthrough in the container.
+	Priority      Priority      // Scheduling priority when capacity is scarce.
 	StartedAt     time.Time     // When the task was created.
 	OwnerID       string        // Internal user ID of the creator; empty in no-auth mode.
 	ForgeIssue    int           // Originating issue number for bot comment callbacks; 0 = none.
-	Provider      genai.Provider
+	Titles        *TitleQueue   // Title generation; nil disables it.
+	Knowledge     string        // Repository notes prepended to the first prompt of fresh sessions.
+	RepoMap       string        // Repository map prepended to the first prompt of fresh sessions.
+	Context       string        // Rendered by AssembleContext; prepended to the first prompt of the first session.
+	ReplayOf      ksid.ID       // Task this one replays; zero otherwise.
+	Kind          Kind
+	// AutoResume continues a turn interrupted by the loss of the relay with
+	// ResumePrompt instead of waiting for user input.
+	AutoResume bool
+	// AskPolicy handles questions left unanswered; nil waits for the user.
+	AskPolicy *AskPolicy
+	// Automation overrides the working hours of the repository deciding
+	// whether questions and plans wait for a human.
+	Automation Automation
+	// OnResult is called after each result of the live session; nil
+	// disables.
+	OnResult func(*Task, *agent.ResultMessage)
+	// Scrub redacts the prompts sent to the harness, see ScrubPrompt, and
+	// the log. Nil disables.
+	Scrub *scrub.Scrubber
 
 	// Write-once fields — set during setup/adoption, never modified after.
 	Container     string
@@ -130,35 +208,56 @@ type Task struct {
 	// mu protects all fields below.
 	mu                    sync.Mutex
 	state                 State
-	stateUpdatedAt        time.Time // UTC timestamp of the last state transition.
-	sessionID             string    // Agent session ID, captured from SystemInitMessage.
-	reportedModel         string    // Model reported by SystemInitMessage (may differ from Model).
-	agentVersion          string    // Agent version, captured from SystemInitMessage.
-	reportedContextWindow int       // Context window size reported by the agent (0 = unknown).
-	planFile              string    // Path to plan file inside container, captured from Write tool_use.
-	planContent           string    // Content of the plan file, captured from Write tool_use input.
-	planDismissed         bool      // True after ClearMessages; suppresses plan tracking until the next ResultMessage.
-	inPlanMode            bool      // True while the agent is in plan mode (between EnterPlanMode and ExitPlanMode).
-	title                 string    // LLM-generated short title; set via SetTitle.
+	stateUpdatedAt        time.Time       // UTC timestamp of the last state transition.
+	stateOut              []agent.Message // StateMessages not yet sent to subs; see publishLocked.
+	sessionID             string          // Agent session ID, captured from SystemInitMessage.
+	handedOffAt           time.Time       // Set by Runner.Handoff, cleared by ResumeSession.
+	handoffOffset         int64           // Bytes of the session transcript already in msgs; see Runner.ImportHandoff.
+	reportedModel         string          // Model reported by SystemInitMessage (may differ from Model).
+	agentVersion          string          // Agent version, captured from SystemInitMessage.
+	reportedContextWindow int             // Context window size reported by the agent (0 = unknown).
+	planFile              string          // Path to plan file inside container, captured from Write tool_use.
+	planContent           string          // Content of the plan file, captured from Write tool_use input.
+	planDismissed         bool            // True after ClearMessages; suppresses plan tracking until the next ResultMessage.
+	inPlanMode            bool            // True while the agent is in plan mode (between EnterPlanMode and ExitPlanMode).
+	title                 string          // LLM-generated short title; set via SetTitle.
 	msgs                  []agent.Message
 	subs                  []*sub         // active SSE subscribers
 	handle                *SessionHandle // current active session; nil when no session is attached
 	priorCostUSD          float64        // accumulated cost from all cleared sessions
 	priorNumTurns         int            // accumulated turns from all cleared sessions
 	priorDuration         time.Duration  // accumulated duration from all cleared sessions
+	pricedCostUSD         float64        // current session cost computed from the pricing table; see priceResult
 	turnStartedAt         time.Time      // when the current running turn started; zero when not running
 	liveCostUSD           float64
 	liveNumTurns          int
 	liveDuration          time.Duration
 	liveUsage             agent.Usage
-	lastUsage             agent.Usage    // Most recent ResultMessage usage (active context).
-	lastAPIUsage          agent.Usage    // Most recent per-API-call usage from AssistantMessage (context window fill).
-	liveDiffStat          agent.DiffStat // Updated by DiffStatMessage from relay.
+	lastUsage             agent.Usage              // Most recent ResultMessage usage (active context).
+	lastAPIUsage          agent.Usage              // Most recent per-API-call usage from AssistantMessage (context window fill).
+	liveDiffStat          agent.DiffStat           // Updated by DiffStatMessage from relay.
+	turns                 []TurnUsage              // Per-turn usage series; see TurnUsages.
+	turnTiming            turnTiming               // Latency of the running turn.
+	toolStarts            map[string]time.Time     // Pending tool calls by tool use ID; see ToolStats.
+	toolDurations         map[string]time.Duration // Measured tool call durations by tool use ID.
+	turnModel             string                   // Model from the current turn's UsageMessages.
 	forgeOwner            string
 	forgeRepo             string
 	forgePR               int
 	ciStatus              forge.CIStatus
 	ciChecks              []forge.Check
+	diskUsage             DiskUsage        // Latest container disk probe; see SetDiskUsage.
+	scrubbed              scrub.Report     // What Scrub redacted so far; nil when nothing.
+	resources             []ResourceSample // Container CPU/memory series; see AddResourceSample.
+	todos                 []agent.TodoItem // Latest todo list, from the last TodoMessage.
+	warnings              []Warning        // Raised by the last turn; see Warnings.
+	affected              *AffectedTargets // See AffectedTargets.
+	affectedNext          agent.DiffStat   // Diff stat of the next computation of affected.
+	affectedQueued        bool             // affectedNext awaits computation.
+	affectedRunning       bool             // A computation of affected is running.
+	lastActivity          time.Time        // Last message of the live session.
+	envReport             *EnvReport       // Container toolchain captured after provisioning.
+	diskWarned            bool             // True once disk_usage_warning was emitted for the current excursion.
 }
 
 // Primary returns a pointer to the primary RepoMount (Repos[0]), or nil for no-repo tasks.
@@ -186,33 +285,65 @@ func (t *Task) ExtraMDRepos() []md.Repo {
 	return t.MDRepos()[1:]
 }
 
-// setState updates the state and records the transition time. The caller must
-// hold t.mu when called from a locked context, or ensure exclusive access.
-func (t *Task) setState(s State) {
+// setState moves to state s at time at and queues a StateMessage for the
+// subscribers, see publishLocked. It reports false, leaving the state
+// unchanged, when the transition table doesn't allow it. The caller must hold
+// t.mu when called from a locked context, or ensure exclusive access.
+func (t *Task) setState(s State, at time.Time) bool {
+	if !CanTransition(t.state, s) {
+		return false
+	}
 	if s == StateRunning && t.state != StateRunning {
-		t.turnStartedAt = time.Now().UTC()
+		t.turnStartedAt = at
 	} else if s != StateRunning {
 		t.turnStartedAt = time.Time{}
 	}
+	if s != t.state {
+		t.stateOut = append(t.stateOut, &agent.StateMessage{From: t.state.String(), To: s.String(), At: at})
+	}
+	slog.Debug("container", "state", s, "from", t.state, "task", t.ID, "ctr", t.Container)
 	t.state = s
-	t.stateUpdatedAt = time.Now().UTC()
-	slog.Debug("container", "state", s, "task", t.ID, "ctr", t.Container)
+	t.stateUpdatedAt = at
+	return true
 }
 
-// SetState updates the state under the mutex and records the transition time.
-func (t *Task) SetState(s State) {
+// Transition moves to state s under the mutex. It returns a
+// *TransitionError, leaving the state unchanged, when the transition table
+// doesn't allow moving from the current state to s.
+func (t *Task) Transition(s State) error {
 	t.mu.Lock()
-	t.setState(s)
-	t.mu.Unlock()
+	defer t.mu.Unlock()
+	defer t.publishLocked()
+	if from := t.state; !t.setState(s, time.Now().UTC()) {
+		return &TransitionError{From: from, To: s}
+	}
+	return nil
+}
+
+// Restore moves a pending task loaded from its log to the state recorded in
+// the log trailer, see restoredStates.
+func (t *Task) Restore(s State) error {
+	return t.enter(s, time.Now().UTC(), restoredStates)
 }
 
-// SetStateAt updates the state under the mutex with an explicit timestamp.
-// Used during adoption to preserve the original transition time.
-func (t *Task) SetStateAt(s State, at time.Time) {
+// Adopt moves a pending task adopted from a container to state s, with the
+// time of the transition recovered from the container, see adoptedStates.
+func (t *Task) Adopt(s State, at time.Time) error {
+	return t.enter(s, at, adoptedStates)
+}
+
+// enter moves a pending task to one of the entry states allowed.
+func (t *Task) enter(s State, at time.Time, allowed []State) error {
 	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state != StatePending || !slices.Contains(allowed, s) {
+		return &TransitionError{From: t.state, To: s}
+	}
+	// Bypass the transition table: the task was created in state s before
+	// this process started.
 	t.state = s
 	t.stateUpdatedAt = at
-	t.mu.Unlock()
+	return nil
 }
 
 // SetTurnStartedAt sets the turn start time if the task is currently running.
@@ -233,8 +364,8 @@ func (t *Task) SetStateIf(expected, next State) bool {
 	if t.state != expected {
 		return false
 	}
-	t.setState(next)
-	return true
+	defer t.publishLocked()
+	return t.setState(next, time.Now().UTC())
 }
 
 // GetState returns the current state under the mutex.
@@ -364,6 +495,8 @@ type Snapshot struct {
 	InPlanMode         bool
 	PlanFile           string
 	PlanContent        string
+	TodosOpen          int // Todo items not completed yet.
+	TodosCompleted     int
 	CostUSD            float64
 	NumTurns           int
 	Duration           time.Duration
@@ -377,6 +510,12 @@ type Snapshot struct {
 	ForgeIssue         int
 	CIStatus           forge.CIStatus
 	CIChecks           []forge.Check
+	DiskUsage          DiskUsage
+	ImageID            string       // Content digest of the container image; empty until probed.
+	Scrubbed           scrub.Report // Matches redacted per scrubbing rule; nil when none.
+	HandedOffAt        time.Time    // Non-zero while the conversation is handed off to a terminal.
+	LastActivityAt     time.Time    // Last message of the live session; zero until one arrives.
+	ToolsRunning       int          // Tool calls of the running turn without a result yet.
 }
 
 // Snapshot returns a consistent read of all volatile fields under the mutex.
@@ -387,18 +526,39 @@ func (t *Task) Snapshot() Snapshot {
 	if model == "" {
 		model = t.Model
 	}
+	imageID := ""
+	if t.envReport != nil {
+		imageID = t.envReport.ImageID
+	}
+	running := 0
+	if !t.turnStartedAt.IsZero() {
+		for _, started := range t.toolStarts {
+			if !started.Before(t.turnStartedAt) {
+				running++
+			}
+		}
+	}
+	completed := 0
+	for _, td := range t.todos {
+		if td.Status == "completed" {
+			completed++
+		}
+	}
 	return Snapshot{
 		State:              t.state,
 		StateUpdatedAt:     t.stateUpdatedAt,
 		TurnStartedAt:      t.turnStartedAt,
 		Title:              t.title,
 		SessionID:          t.sessionID,
+		HandedOffAt:        t.handedOffAt,
 		Model:              model,
 		AgentVersion:       t.agentVersion,
 		ContextWindowLimit: t.reportedContextWindow,
 		InPlanMode:         t.inPlanMode,
 		PlanFile:           t.planFile,
 		PlanContent:        t.planContent,
+		TodosOpen:          len(t.todos) - completed,
+		TodosCompleted:     completed,
 		CostUSD:            t.liveCostUSD,
 		NumTurns:           t.liveNumTurns,
 		Duration:           t.liveDuration,
@@ -412,9 +572,54 @@ func (t *Task) Snapshot() Snapshot {
 		ForgeIssue:         t.ForgeIssue,
 		CIStatus:           t.ciStatus,
 		CIChecks:           append([]forge.Check(nil), t.ciChecks...),
+		DiskUsage:          t.diskUsage,
+		ImageID:            imageID,
+		Scrubbed:           maps.Clone(t.scrubbed),
+		LastActivityAt:     t.lastActivity,
+		ToolsRunning:       running,
 	}
 }
 
+// ScrubPrompt returns p with its text redacted by Scrub, and records what was
+// redacted. It returns p as is when Scrub is nil.
+func (t *Task) ScrubPrompt(p agent.Prompt) agent.Prompt {
+	if t.Scrub == nil {
+		return p
+	}
+	var rep scrub.Report
+	p.Text, rep = t.Scrub.String(p.Text)
+	t.addScrubbed(rep)
+	return p
+}
+
+// addScrubbed records the matches redacted by Scrub.
+func (t *Task) addScrubbed(rep scrub.Report) {
+	if rep == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.scrubbed == nil {
+		t.scrubbed = scrub.Report{}
+	}
+	t.scrubbed.Add(rep)
+}
+
+// Todos returns the latest todo list of the agent; it is reset when the
+// context is cleared.
+func (t *Task) Todos() []agent.TodoItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.todos)
+}
+
+// Warnings returns the suspicious outcomes of the last turn, see Warning.
+func (t *Task) Warnings() []Warning {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return slices.Clone(t.warnings)
+}
+
 // Messages returns a copy of all received agent messages.
 func (t *Task) Messages() []agent.Message {
 	t.mu.Lock()
@@ -440,6 +645,7 @@ func (t *Task) Messages() []agent.Message {
 func (t *Task) RestoreMessages(msgs []agent.Message) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	defer t.publishLocked()
 	t.msgs = msgs
 	// Scan forward so later entries (model_rerouted) override earlier ones.
 	for _, m := range msgs {
@@ -467,6 +673,7 @@ func (t *Task) RestoreMessages(msgs []agent.Message) {
 			t.planFile = ""
 			t.planContent = ""
 			t.planDismissed = true
+			t.todos = nil
 			if lastExitPlan != nil {
 				lastExitPlan.PlanContent = ""
 				lastExitPlan = nil
@@ -481,6 +688,9 @@ func (t *Task) RestoreMessages(msgs []agent.Message) {
 				lastExitPlan = tu
 			}
 		}
+		if tm, ok := m.(*agent.TodoMessage); ok {
+			t.todos = tm.Todos
+		}
 		if u, ok := m.(*agent.UsageMessage); ok {
 			t.lastAPIUsage = u.Usage
 			if u.ContextWindow > 0 {
@@ -491,6 +701,7 @@ func (t *Task) RestoreMessages(msgs []agent.Message) {
 			t.planDismissed = false
 		}
 	}
+	t.warnings = t.checkQuality(msgs)
 	// Restore live diff stat from the last DiffStatMessage or ResultMessage,
 	// whichever appears later. ResultMessage carries the authoritative
 	// host-side diff stat but a DiffStatMessage from the relay may follow it.
@@ -508,28 +719,46 @@ func (t *Task) RestoreMessages(msgs []agent.Message) {
 	// compact_boundary), so cost uses priorCostUSD + currentSessionTotal.
 	// DurationMs and NumTurns are per-invocation, so they always accumulate (+=).
 	// Token usage is always summed.
+	t.turns = nil
+	t.turnModel = ""
 	for _, m := range msgs {
+		if u, ok := m.(*agent.UsageMessage); ok && u.Model != "" {
+			t.turnModel = u.Model
+			continue
+		}
 		if sm, ok := m.(*agent.SystemMessage); ok &&
 			(sm.Subtype == "context_cleared" || sm.Subtype == "compact_boundary") {
 			t.priorCostUSD = t.liveCostUSD
 			t.priorNumTurns = t.liveNumTurns
 			t.priorDuration = t.liveDuration
+			t.pricedCostUSD = 0
 			continue
 		}
 		rm, ok := m.(*agent.ResultMessage)
 		if !ok {
 			continue
 		}
+		priced := t.priceResult(rm)
+		prevCost := t.liveCostUSD
 		t.liveUsage.InputTokens += rm.Usage.InputTokens
 		t.liveUsage.OutputTokens += rm.Usage.OutputTokens
 		t.liveUsage.CacheCreationInputTokens += rm.Usage.CacheCreationInputTokens
 		t.liveUsage.CacheReadInputTokens += rm.Usage.CacheReadInputTokens
 		t.lastUsage = rm.Usage
-		// Compute cost from token counts: TotalCostUSD from Claude Code excludes
-		// cache_read_input_tokens, which are charged but omitted from its total.
-		t.liveCostUSD = t.priorCostUSD + computeCost(rm.TotalCostUSD, rm.Usage)
+		if priced {
+			t.liveCostUSD = t.priorCostUSD + rm.TotalCostUSD
+		} else {
+			// Compute cost from token counts: TotalCostUSD from Claude Code excludes
+			// cache_read_input_tokens, which are charged but omitted from its total.
+			t.liveCostUSD = t.priorCostUSD + computeCost(rm.TotalCostUSD, rm.Usage)
+		}
 		t.liveNumTurns += rm.NumTurns
 		t.liveDuration += time.Duration(rm.DurationMs) * time.Millisecond
+		var at time.Time
+		if !t.StartedAt.IsZero() {
+			at = t.StartedAt.Add(t.liveDuration)
+		}
+		t.recordTurn(rm, t.liveCostUSD-prevCost, at, true)
 	}
 	// Infer state: if the last agent-emitted message is a ResultMessage, the
 	// agent finished its turn and is waiting for user input (or asking a
@@ -541,11 +770,11 @@ func (t *Task) RestoreMessages(msgs []agent.Message) {
 		if lastAgentMessage(msgs) != nil {
 			switch {
 			case lastTurnHasAsk(msgs):
-				t.setState(StateAsking)
+				t.setState(StateAsking, time.Now().UTC())
 			case lastTurnHasExitPlan(msgs) && t.planContent != "":
-				t.setState(StateHasPlan)
+				t.setState(StateHasPlan, time.Now().UTC())
 			default:
-				t.setState(StateWaiting)
+				t.setState(StateWaiting, time.Now().UTC())
 			}
 		}
 	}
@@ -554,7 +783,14 @@ func (t *Task) RestoreMessages(msgs []agent.Message) {
 func (t *Task) addMessage(ctx context.Context, m agent.Message, skipTitleGen bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	priced := false
+	if rm, ok := m.(*agent.ResultMessage); ok {
+		priced = t.priceResult(rm)
+	}
 	t.msgs = append(t.msgs, m)
+	now := time.Now()
+	t.recordToolTiming(m, now)
+	t.recordTurnTiming(m, now)
 	// Capture metadata from the init message.
 	if init, ok := m.(*agent.InitMessage); ok && init.SessionID != "" {
 		t.sessionID = init.SessionID
@@ -578,11 +814,17 @@ func (t *Task) addMessage(ctx context.Context, m agent.Message, skipTitleGen boo
 			}
 		}
 	}
+	if tm, ok := m.(*agent.TodoMessage); ok {
+		t.todos = tm.Todos
+	}
 	if u, ok := m.(*agent.UsageMessage); ok {
 		t.lastAPIUsage = u.Usage
 		if u.ContextWindow > 0 {
 			t.reportedContextWindow = u.ContextWindow
 		}
+		if u.Model != "" {
+			t.turnModel = u.Model
+		}
 	}
 	// Transition to running when the agent starts producing output
 	// while the task is in a waiting state. This covers the case where
@@ -592,7 +834,7 @@ func (t *Task) addMessage(ctx context.Context, m agent.Message, skipTitleGen boo
 	switch m.(type) {
 	case *agent.TextMessage, *agent.ToolUseMessage, *agent.AskMessage, *agent.TodoMessage:
 		if t.state == StateWaiting || t.state == StateAsking || t.state == StateHasPlan {
-			t.setState(StateRunning)
+			t.setState(StateRunning, time.Now().UTC())
 		}
 	}
 	// Update live diff stat from relay polling.
@@ -609,6 +851,9 @@ func (t *Task) addMessage(ctx context.Context, m agent.Message, skipTitleGen boo
 		t.priorNumTurns = t.liveNumTurns
 		t.priorDuration = t.liveDuration
 	}
+	if sm, ok := m.(*agent.SystemMessage); ok && (sm.Subtype == "compact_boundary" || sm.Subtype == "context_cleared") {
+		t.pricedCostUSD = 0
+	}
 	// Transition to waiting/asking when a result arrives.
 	if rm, ok := m.(*agent.ResultMessage); ok {
 		if len(rm.DiffStat) > 0 {
@@ -619,11 +864,17 @@ func (t *Task) addMessage(ctx context.Context, m agent.Message, skipTitleGen boo
 		t.liveUsage.CacheCreationInputTokens += rm.Usage.CacheCreationInputTokens
 		t.liveUsage.CacheReadInputTokens += rm.Usage.CacheReadInputTokens
 		t.lastUsage = rm.Usage
-		// Compute cost from token counts: TotalCostUSD from Claude Code excludes
-		// cache_read_input_tokens, which are charged but omitted from its total.
-		t.liveCostUSD = t.priorCostUSD + computeCost(rm.TotalCostUSD, rm.Usage)
+		prevCost := t.liveCostUSD
+		if priced {
+			t.liveCostUSD = t.priorCostUSD + rm.TotalCostUSD
+		} else {
+			// Compute cost from token counts: TotalCostUSD from Claude Code excludes
+			// cache_read_input_tokens, which are charged but omitted from its total.
+			t.liveCostUSD = t.priorCostUSD + computeCost(rm.TotalCostUSD, rm.Usage)
+		}
 		t.liveNumTurns += rm.NumTurns
 		t.liveDuration += time.Duration(rm.DurationMs) * time.Millisecond
+		t.recordTurn(rm, t.liveCostUSD-prevCost, time.Now().UTC(), false)
 		t.planDismissed = false
 		// Transition Running→Waiting/Asking/HasPlan. Also handle
 		// Running/Waiting because watchSession may have already set
@@ -633,28 +884,59 @@ func (t *Task) addMessage(ctx context.Context, m agent.Message, skipTitleGen boo
 		if t.state == StateRunning || t.state == StateWaiting {
 			switch {
 			case lastTurnHasAsk(t.msgs):
-				t.setState(StateAsking)
+				t.setState(StateAsking, time.Now().UTC())
 			case lastTurnHasExitPlan(t.msgs) && t.planContent != "":
-				t.setState(StateHasPlan)
+				t.setState(StateHasPlan, time.Now().UTC())
 			default:
-				t.setState(StateWaiting)
+				t.setState(StateWaiting, time.Now().UTC())
 			}
 		}
 		if !skipTitleGen {
-			go t.GenerateTitle(ctx)
+			t.Titles.Enqueue(t)
 		}
 	}
-	// Fan out to subscribers (non-blocking).
-	for i := 0; i < len(t.subs); i++ {
-		select {
-		case t.subs[i].ch <- m:
-		default:
-			// Slow subscriber — drop and remove.
-			t.subs[i].close()
-			t.subs = append(t.subs[:i], t.subs[i+1:]...)
-			i--
+	out := []agent.Message{m}
+	if _, ok := m.(*agent.ResultMessage); ok {
+		out = append(out, t.updateWarnings()...)
+	}
+	t.publishLocked(out...)
+}
+
+// publishLocked fans msgs out to the subscribers, followed by the state
+// changes queued by setState since the last call. The caller must hold t.mu.
+func (t *Task) publishLocked(msgs ...agent.Message) {
+	msgs = append(msgs, t.stateOut...)
+	t.stateOut = nil
+	// Non-blocking.
+	for _, m := range msgs {
+		for i := 0; i < len(t.subs); i++ {
+			select {
+			case t.subs[i].ch <- m:
+			default:
+				// Slow subscriber — drop and remove.
+				t.subs[i].close()
+				t.subs = append(t.subs[:i], t.subs[i+1:]...)
+				i--
+			}
+		}
+	}
+}
+
+// updateWarnings recomputes the warnings after a result and returns a
+// WarningMessage, appended to msgs, for each one not already raised by the
+// previous turn. The caller must hold t.mu.
+func (t *Task) updateWarnings() []agent.Message {
+	prev := t.warnings
+	t.warnings = t.checkQuality(t.msgs)
+	var out []agent.Message
+	for _, w := range t.warnings {
+		if !slices.Contains(prev, w) {
+			wm := &agent.WarningMessage{Kind: string(w.Kind), Detail: w.Detail}
+			t.msgs = append(t.msgs, wm)
+			out = append(out, wm)
 		}
 	}
+	return out
 }
 
 // writeToolInput is the JSON input schema for the Write tool_use block.
@@ -781,6 +1063,7 @@ func (t *Task) ClearMessages(ctx context.Context) {
 	t.planFile = ""
 	t.planContent = ""
 	t.planDismissed = true
+	t.todos = nil
 	// Clear PlanContent on all ExitPlanMode messages so new subscribers
 	// do not see stale plan content after context is cleared.
 	for _, m := range t.msgs {
@@ -821,6 +1104,8 @@ func lastAgentMessage(msgs []agent.Message) *agent.ResultMessage {
 			continue // tool_progress, etc.; skip.
 		case *agent.UsageMessage:
 			continue // Token usage metadata; skip.
+		case *agent.WarningMessage:
+			continue // Emitted by caic after the result; skip.
 		case *agent.ResultMessage:
 			return m
 		default:
@@ -949,6 +1234,7 @@ const (
 // dead-session detection proactively, so SendInput no longer does lazy
 // cleanup.
 func (t *Task) SendInput(ctx context.Context, p agent.Prompt) error {
+	p = t.ScrubPrompt(p)
 	t.mu.Lock()
 	h := t.handle
 	sessionStatus := SessionNone
@@ -962,7 +1248,7 @@ func (t *Task) SendInput(ctx context.Context, p agent.Prompt) error {
 	}
 	state := t.state
 	if h != nil && (state == StateWaiting || state == StateAsking || state == StateHasPlan) {
-		t.setState(StateRunning)
+		t.setState(StateRunning, time.Now().UTC())
 		// Plan content is preserved — the UI hides naturally while the
 		// task is Running (isWaiting is false). When the agent finishes,
 		// the plan reappears (original or updated via Write/Edit).
@@ -977,6 +1263,27 @@ func (t *Task) SendInput(ctx context.Context, p agent.Prompt) error {
 	return h.Session.Send(p)
 }
 
+// priceResult fills rm.TotalCostUSD from agent.DefaultPricing when the
+// harness did not report a cost (Codex, Gemini). Like Claude Code's value, the
+// result is cumulative for the current session. Returns true if the cost was
+// computed here. t.mu must be held.
+func (t *Task) priceResult(rm *agent.ResultMessage) bool {
+	if rm.TotalCostUSD != 0 {
+		return false
+	}
+	model := t.reportedModel
+	if model == "" {
+		model = t.Model
+	}
+	c, ok := agent.DefaultPricing.Cost(model, rm.Usage)
+	if !ok || c == 0 {
+		return false
+	}
+	t.pricedCostUSD += c
+	rm.TotalCostUSD = t.pricedCostUSD
+	return true
+}
+
 // computeCost returns the true USD cost for a Claude API result by adding the
 // cache-read surcharge that TotalCostUSD omits.
 //
@@ -998,7 +1305,18 @@ func computeCost(totalCostUSD float64, u agent.Usage) float64 {
 	return totalCostUSD + float64(u.CacheReadInputTokens)*0.10*inputPricePerTok
 }
 
-const titleSystemPrompt = "Summarize this coding task conversation in 3-8 words as a short title. Reply with ONLY the title, no quotes."
+// recordActivity records that the live session emitted a message, see
+// Snapshot.LastActivityAt.
+func (t *Task) recordActivity() {
+	t.mu.Lock()
+	t.lastActivity = time.Now()
+	t.mu.Unlock()
+}
+
+// Notify appends a system event for clients. It is not sent to the agent.
+func (t *Task) Notify(ctx context.Context, subtype, detail string) {
+	t.addMessage(ctx, &agent.SystemMessage{MessageType: "system", Subtype: subtype, Detail: detail}, true)
+}
 
 // SetTitle sets the title under the mutex. Empty strings are ignored to
 // preserve the prompt-fallback invariant.
@@ -1010,52 +1328,3 @@ func (t *Task) SetTitle(title string) {
 	t.title = title
 	t.mu.Unlock()
 }
-
-// GenerateTitle asks the LLM for a short title from the prompt and any result
-// messages. No-op when the provider is unconfigured.
-func (t *Task) GenerateTitle(ctx context.Context) {
-	if t.Provider == nil {
-		return
-	}
-	msgs := t.Messages()
-	var b strings.Builder
-	for _, m := range msgs {
-		if v, ok := m.(*agent.ResultMessage); ok && v.Result != "" {
-			if b.Len() > 0 {
-				b.WriteByte('\n')
-			}
-			b.WriteString("Result: ")
-			b.WriteString(v.Result)
-		}
-	}
-	// Prepend the original prompt.
-	// TODO: Use the images too.
-	input := "Prompt: " + t.InitialPrompt.Text
-	if b.Len() > 0 {
-		input += "\n" + b.String()
-	}
-	// Truncate to keep it working on most providers.
-	const maxChars = 50000
-	if len(input) > maxChars {
-		input = input[:maxChars]
-	}
-
-	start := time.Now()
-	res, err := t.Provider.GenSync(ctx,
-		genai.Messages{genai.NewTextMessage(input)},
-		&genai.GenOptionText{SystemPrompt: titleSystemPrompt},
-	)
-	d := time.Since(start).Round(time.Millisecond)
-	if err != nil {
-		slog.Warn("title failed", "task", t.ID, "err", err, "d", d)
-		return
-	}
-	// Strip surrounding quotes if the model adds them despite instructions.
-	title := strings.Trim(strings.TrimSpace(res.String()), "\"'`")
-	if title == "" {
-		slog.Warn("title", "task", t.ID, "d", d, "msg", "empty")
-		return
-	}
-	slog.Info("title", "task", t.ID, "title", title, "d", d)
-	t.SetTitle(title)
-}
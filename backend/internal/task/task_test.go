@@ -3,6 +3,7 @@ package task
 import (
 	"context"
 	"encoding/json"
+	"math"
 	"os/exec"
 	"strings"
 	"testing"
@@ -11,6 +12,21 @@ import (
 	"github.com/caic-xyz/caic/backend/internal/agent"
 )
 
+// setState moves tk to s. A new task is first adopted as running, like a
+// task found in a container on startup, unless it may move to s directly.
+func setState(t testing.TB, tk *Task, s State) {
+	t.Helper()
+	if st := tk.GetState(); st == StatePending && !CanTransition(st, s) {
+		if err := tk.Adopt(StateRunning, time.Now().UTC()); err != nil {
+			t.Fatal(err)
+		}
+		tk.SetTurnStartedAt(time.Now().UTC())
+	}
+	if err := tk.Transition(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestTask(t *testing.T) {
 	t.Run("Subscribe", func(t *testing.T) {
 		t.Run("SlowSubscriberThenCancel", func(t *testing.T) {
@@ -135,7 +151,7 @@ func TestTask(t *testing.T) {
 			// so the plan UI reappears after the agent finishes. The
 			// UI hides naturally while the task is Running.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			// Simulate: agent entered plan mode, wrote a plan, exited.
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "EnterPlanMode",
@@ -187,7 +203,7 @@ func TestTask(t *testing.T) {
 			// in-memory planContent must be updated so the UI shows
 			// the revised plan.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			// Agent writes the initial plan.
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
@@ -208,7 +224,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("EditReplaceAll", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"TODO\nTODO\n"}`),
@@ -224,7 +240,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("EditIgnoresNonPlanFile", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"the plan"}`),
@@ -243,7 +259,7 @@ func TestTask(t *testing.T) {
 			// Core regression test: user rejects plan and asks for
 			// improvement, agent edits the plan file.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"original plan"}`),
@@ -290,7 +306,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("NoSession", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateWaiting)
+			setState(t, tk, StateWaiting)
 			err := tk.SendInput(t.Context(), agent.Prompt{Text: "hello"})
 			if err == nil {
 				t.Fatal("expected error when no session is active")
@@ -308,7 +324,7 @@ func TestTask(t *testing.T) {
 			// subprocess exited). SendInput should detect it and return
 			// "no active session" without changing state.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateWaiting)
+			setState(t, tk, StateWaiting)
 			cmd := exec.Command("true")
 			stdin, err := cmd.StdinPipe()
 			if err != nil {
@@ -377,7 +393,7 @@ func TestTask(t *testing.T) {
 	t.Run("addMessage", func(t *testing.T) {
 		t.Run("TransitionsToWaiting", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			result := &agent.ResultMessage{MessageType: "result"}
 			tk.addMessage(t.Context(), result, false)
 			if tk.GetState() != StateWaiting {
@@ -386,7 +402,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("TransitionsToAsking", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			// Add an AskMessage.
 			tk.addMessage(t.Context(), &agent.AskMessage{
 				ToolUseID: "ask1",
@@ -404,7 +420,7 @@ func TestTask(t *testing.T) {
 			// earlier snapshot while the final one is text-only. The state
 			// machine must scan all messages in the turn.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.TextMessage{Text: "I need to ask you something."}, false)
 			tk.addMessage(t.Context(), &agent.AskMessage{
 				ToolUseID: "ask1",
@@ -422,7 +438,7 @@ func TestTask(t *testing.T) {
 			// waiting (e.g. relay reconnect after server restart), the
 			// state should transition back to running.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateWaiting)
+			setState(t, tk, StateWaiting)
 			tk.addMessage(t.Context(), &agent.TextMessage{Text: "output"}, false)
 			if tk.GetState() != StateRunning {
 				t.Errorf("state = %v, want %v", tk.GetState(), StateRunning)
@@ -430,7 +446,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("ToolUseMessageTransitionsAskingToRunning", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateAsking)
+			setState(t, tk, StateAsking)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{ToolUseID: "tu1", Name: "Read"}, false)
 			if tk.GetState() != StateRunning {
 				t.Errorf("state = %v, want %v", tk.GetState(), StateRunning)
@@ -441,14 +457,14 @@ func TestTask(t *testing.T) {
 			// processed, the ResultMessage should still detect
 			// AskMessage and correct the state to Asking.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.AskMessage{
 				ToolUseID: "ask1",
 				Questions: []agent.AskQuestion{{Question: "which?"}},
 			}, false)
 			// Simulate watchSession setting Waiting before ResultMessage
 			// is processed by the dispatch goroutine.
-			tk.SetState(StateWaiting)
+			setState(t, tk, StateWaiting)
 			tk.addMessage(t.Context(), &agent.ResultMessage{MessageType: "result"}, false)
 			if tk.GetState() != StateAsking {
 				t.Errorf("state = %v, want %v", tk.GetState(), StateAsking)
@@ -457,7 +473,7 @@ func TestTask(t *testing.T) {
 		t.Run("TransitionsToHasPlan", func(t *testing.T) {
 			// ExitPlanMode + plan content + ResultMessage → StateHasPlan.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"the plan"}`),
@@ -473,7 +489,7 @@ func TestTask(t *testing.T) {
 		t.Run("AskingTakesPriorityOverHasPlan", func(t *testing.T) {
 			// Both AskMessage and ExitPlanMode in same turn → StateAsking.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"the plan"}`),
@@ -493,7 +509,7 @@ func TestTask(t *testing.T) {
 		t.Run("NoHasPlanWithoutPlanContent", func(t *testing.T) {
 			// ExitPlanMode without plan content → StateWaiting.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "ExitPlanMode",
 			}, false)
@@ -506,7 +522,7 @@ func TestTask(t *testing.T) {
 			// trackToolUse must snapshot planContent onto the ExitPlanMode
 			// ToolUseMessage so the SSE converter can include it.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"plan A"}`),
@@ -521,7 +537,7 @@ func TestTask(t *testing.T) {
 			// When a second ExitPlanMode arrives, the first one's PlanContent
 			// must be cleared so the frontend doesn't list the stale plan.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"plan v1"}`),
@@ -550,7 +566,7 @@ func TestTask(t *testing.T) {
 		t.Run("HasPlanToRunningOnText", func(t *testing.T) {
 			// TextMessage while HasPlan → Running.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateHasPlan)
+			setState(t, tk, StateHasPlan)
 			tk.addMessage(t.Context(), &agent.TextMessage{Text: "output"}, false)
 			if tk.GetState() != StateRunning {
 				t.Errorf("state = %v, want %v", tk.GetState(), StateRunning)
@@ -561,7 +577,7 @@ func TestTask(t *testing.T) {
 			// setup states.
 			for _, state := range []State{StatePending, StateBranching, StateProvisioning, StateStarting, StatePurging, StateFailed, StatePurged} {
 				tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-				tk.SetState(state)
+				setState(t, tk, state)
 				tk.addMessage(t.Context(), &agent.TextMessage{Text: "output"}, false)
 				if tk.GetState() != state {
 					t.Errorf("state %v changed to %v; want unchanged", state, tk.GetState())
@@ -573,7 +589,7 @@ func TestTask(t *testing.T) {
 	t.Run("addMessageDiffStat", func(t *testing.T) {
 		t.Run("DiffStatMessage", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			ds := agent.DiffStat{
 				{Path: "main.go", Added: 10, Deleted: 3},
 				{Path: "img.png", Binary: true},
@@ -602,7 +618,7 @@ func TestTask(t *testing.T) {
 
 		t.Run("ResultMessageUpdatesLiveDiffStat", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ResultMessage{
 				MessageType: "result",
 				DiffStat:    agent.DiffStat{{Path: "a.go", Added: 5, Deleted: 2}},
@@ -617,7 +633,7 @@ func TestTask(t *testing.T) {
 	t.Run("RestoreMessagesDiffStat", func(t *testing.T) {
 		t.Run("DiffStatMessage", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StatePurged)
+			setState(t, tk, StatePurged)
 			tk.RestoreMessages([]agent.Message{
 				&agent.DiffStatMessage{
 					MessageType: "caic_diff_stat",
@@ -637,7 +653,7 @@ func TestTask(t *testing.T) {
 
 		t.Run("ResultMessageAfterDiffStat", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StatePurged)
+			setState(t, tk, StatePurged)
 			tk.RestoreMessages([]agent.Message{
 				&agent.DiffStatMessage{
 					MessageType: "caic_diff_stat",
@@ -656,7 +672,7 @@ func TestTask(t *testing.T) {
 
 		t.Run("DiffStatAfterResult", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StatePurged)
+			setState(t, tk, StatePurged)
 			tk.RestoreMessages([]agent.Message{
 				&agent.ResultMessage{
 					MessageType: "result",
@@ -682,7 +698,7 @@ func TestTask(t *testing.T) {
 		// separately after RestoreMessages.
 		t.Run("EmptyRelayDiffAfterCommit", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			// Simulate relay output: ResultMessage without DiffStat
 			// (host-side mutation not persisted) followed by an empty
 			// DiffStatMessage (relay sees no uncommitted changes).
@@ -711,9 +727,48 @@ func TestTask(t *testing.T) {
 		})
 	})
 
+	t.Run("Todos", func(t *testing.T) {
+		todo := func(statuses ...string) *agent.TodoMessage {
+			m := &agent.TodoMessage{ToolUseID: "td"}
+			for i, s := range statuses {
+				m.Todos = append(m.Todos, agent.TodoItem{Content: string(rune('a' + i)), Status: s})
+			}
+			return m
+		}
+		t.Run("Live", func(t *testing.T) {
+			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
+			setState(t, tk, StateRunning)
+			tk.addMessage(t.Context(), todo("pending", "pending"), false)
+			tk.addMessage(t.Context(), todo("completed", "in_progress", "pending"), false)
+			snap := tk.Snapshot()
+			if snap.TodosOpen != 2 || snap.TodosCompleted != 1 {
+				t.Errorf("todos open %d completed %d, want 2 and 1", snap.TodosOpen, snap.TodosCompleted)
+			}
+			if got := tk.Todos(); len(got) != 3 || got[1].Status != "in_progress" {
+				t.Errorf("Todos() = %+v", got)
+			}
+			tk.ClearMessages(t.Context())
+			if got := tk.Todos(); got != nil {
+				t.Errorf("Todos() after ClearMessages = %+v", got)
+			}
+		})
+		t.Run("Restore", func(t *testing.T) {
+			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
+			tk.RestoreMessages([]agent.Message{todo("completed", "completed"), &agent.ResultMessage{MessageType: "result"}})
+			if snap := tk.Snapshot(); snap.TodosOpen != 0 || snap.TodosCompleted != 2 {
+				t.Errorf("todos open %d completed %d, want 0 and 2", snap.TodosOpen, snap.TodosCompleted)
+			}
+			tk = &Task{InitialPrompt: agent.Prompt{Text: "test"}}
+			tk.RestoreMessages([]agent.Message{todo("pending"), &agent.SystemMessage{MessageType: "system", Subtype: "context_cleared"}})
+			if got := tk.Todos(); got != nil {
+				t.Errorf("Todos() after context_cleared = %+v", got)
+			}
+		})
+	})
+
 	t.Run("LiveUsageCumulative", func(t *testing.T) {
 		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		tk.addMessage(t.Context(), &agent.ResultMessage{
 			MessageType: "result",
 			Usage:       agent.Usage{InputTokens: 100, OutputTokens: 50, CacheReadInputTokens: 10},
@@ -746,7 +801,7 @@ func TestTask(t *testing.T) {
 
 	t.Run("RestoreMessagesUsageCumulative", func(t *testing.T) {
 		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(StatePurged)
+		setState(t, tk, StatePurged)
 		tk.RestoreMessages([]agent.Message{
 			&agent.ResultMessage{
 				MessageType: "result",
@@ -779,7 +834,7 @@ func TestTask(t *testing.T) {
 		// by ClearMessages. computeCost uses TotalCostUSD as the base and adds
 		// the cache-read surcharge.
 		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		// Session 1: TotalCostUSD = $10.00.
 		tk.addMessage(t.Context(), &agent.ResultMessage{
 			MessageType:  "result",
@@ -788,7 +843,7 @@ func TestTask(t *testing.T) {
 			DurationMs:   5000,
 		}, false)
 		tk.ClearMessages(t.Context())
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		// Session 2: TotalCostUSD = $5.00.
 		tk.addMessage(t.Context(), &agent.ResultMessage{
 			MessageType:  "result",
@@ -812,7 +867,7 @@ func TestTask(t *testing.T) {
 		// Multiple result events within a single session (no ClearMessages/compact_boundary)
 		// must accumulate duration rather than overwriting with only the last invocation's value.
 		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		tk.addMessage(t.Context(), &agent.ResultMessage{
 			MessageType: "result",
 			NumTurns:    1,
@@ -842,7 +897,7 @@ func TestTask(t *testing.T) {
 		// Regression: ClearMessages used += (double-count) instead of = assignment.
 		// Verify cost is correct after two ClearMessages calls.
 		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		// Session 1: $75.
 		tk.addMessage(t.Context(), &agent.ResultMessage{
 			MessageType:  "result",
@@ -850,7 +905,7 @@ func TestTask(t *testing.T) {
 			NumTurns:     1,
 		}, false)
 		tk.ClearMessages(t.Context())
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		// Session 2: $75.
 		tk.addMessage(t.Context(), &agent.ResultMessage{
 			MessageType:  "result",
@@ -858,7 +913,7 @@ func TestTask(t *testing.T) {
 			NumTurns:     1,
 		}, false)
 		tk.ClearMessages(t.Context())
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		// Session 3: $75.
 		tk.addMessage(t.Context(), &agent.ResultMessage{
 			MessageType:  "result",
@@ -878,7 +933,7 @@ func TestTask(t *testing.T) {
 		// RestoreMessages (reloadFromMsgs) must accumulate DurationMs across
 		// multiple result events within a single session.
 		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(StatePurged)
+		setState(t, tk, StatePurged)
 		tk.RestoreMessages([]agent.Message{
 			&agent.ResultMessage{MessageType: "result", NumTurns: 1, DurationMs: 946943},
 			&agent.ResultMessage{MessageType: "result", NumTurns: 1, DurationMs: 5278},
@@ -898,7 +953,7 @@ func TestTask(t *testing.T) {
 		// RestoreMessages must sum cost/turns/duration across context_cleared
 		// boundaries, mirroring the live path.
 		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(StatePurged)
+		setState(t, tk, StatePurged)
 		tk.RestoreMessages([]agent.Message{
 			// Session 1: TotalCostUSD = $10.00.
 			&agent.ResultMessage{
@@ -934,7 +989,7 @@ func TestTask(t *testing.T) {
 		// Setup: TotalCostUSD = $1.50 from 100K input tokens (price = $0.000015/tok).
 		// Cache read surcharge = 10M × 0.10 × $0.000015 = $15.00.
 		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-		tk.SetState(StateRunning)
+		setState(t, tk, StateRunning)
 		tk.addMessage(t.Context(), &agent.ResultMessage{
 			MessageType:  "result",
 			TotalCostUSD: 1.50,
@@ -949,13 +1004,39 @@ func TestTask(t *testing.T) {
 		}
 	})
 
+	t.Run("LiveCostPricedFromUsage", func(t *testing.T) {
+		// Harnesses like Codex report tokens but no cost; the pricing table
+		// fills TotalCostUSD cumulatively for the session.
+		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, Model: "gpt-5"}
+		setState(t, tk, StateRunning)
+		for range 2 {
+			tk.addMessage(t.Context(), &agent.ResultMessage{
+				MessageType: "result",
+				Usage: agent.Usage{
+					InputTokens:          1_000_000,
+					CacheReadInputTokens: 200_000,
+					OutputTokens:         100_000,
+				},
+			}, false)
+		}
+		// Per turn: 800K×$1.25 + 200K×$0.125 + 100K×$10 = $2.025.
+		costUSD, _, _, _, _ := tk.LiveStats()
+		if math.Abs(costUSD-4.05) > 1e-9 {
+			t.Errorf("costUSD = %v, want 4.05", costUSD)
+		}
+		msgs := tk.Messages()
+		if rm := msgs[len(msgs)-1].(*agent.ResultMessage); math.Abs(rm.TotalCostUSD-4.05) > 1e-9 {
+			t.Errorf("TotalCostUSD = %v, want 4.05", rm.TotalCostUSD)
+		}
+	})
+
 	t.Run("CompactBoundaryAccumulatesStats", func(t *testing.T) {
 		// compact_boundary resets NumTurns, DurationMs, and TotalCostUSD in
 		// Claude Code's subsequent ResultMessages. Stats must be accumulated
 		// across the boundary, just like context_cleared.
 		newTask := func() *Task {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			return tk
 		}
 		result1 := &agent.ResultMessage{
@@ -991,7 +1072,7 @@ func TestTask(t *testing.T) {
 
 		t.Run("Restore", func(t *testing.T) {
 			tk := newTask()
-			tk.SetState(StatePurged)
+			setState(t, tk, StatePurged)
 			tk.RestoreMessages([]agent.Message{result1, compact, result2})
 			costUSD, numTurns, duration, _, _ := tk.LiveStats()
 			if costUSD != 15.0 {
@@ -1009,7 +1090,7 @@ func TestTask(t *testing.T) {
 	t.Run("ClearMessages", func(t *testing.T) {
 		t.Run("ResetsPlanState", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			// Simulate an agent entering plan mode and writing a plan file.
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "EnterPlanMode",
@@ -1043,7 +1124,7 @@ func TestTask(t *testing.T) {
 			// After ClearMessages (restart), the agent may re-enter plan mode
 			// and write to .claude/plans/. The plan must not resurface.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			// Original plan.
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
@@ -1056,7 +1137,7 @@ func TestTask(t *testing.T) {
 
 			// User clicks "Clear and execute plan".
 			tk.ClearMessages(t.Context())
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 
 			// Agent re-enters plan mode during execution.
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
@@ -1083,7 +1164,7 @@ func TestTask(t *testing.T) {
 			// After ClearMessages the ExitPlanMode message's PlanContent in
 			// history must be erased so new subscribers don't see stale plans.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			exitMsg := &agent.ToolUseMessage{ToolUseID: "tu2", Name: "ExitPlanMode"}
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
@@ -1105,7 +1186,7 @@ func TestTask(t *testing.T) {
 			// After the restart turn completes, a subsequent user-initiated turn
 			// must be able to produce a plan again.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu1", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"plan"}`),
@@ -1114,13 +1195,13 @@ func TestTask(t *testing.T) {
 
 			// Restart.
 			tk.ClearMessages(t.Context())
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			// Turn completes without plan.
 			tk.addMessage(t.Context(), &agent.TextMessage{Text: "done"}, false)
 			tk.addMessage(t.Context(), &agent.ResultMessage{MessageType: "result"}, false)
 
 			// Suppression lifted — next turn can set plan.
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			tk.addMessage(t.Context(), &agent.ToolUseMessage{
 				ToolUseID: "tu2", Name: "Write",
 				Input: json.RawMessage(`{"file_path":"/home/user/.claude/plans/p.md","content":"fresh plan"}`),
@@ -1137,7 +1218,7 @@ func TestTask(t *testing.T) {
 	t.Run("RestoreMessages", func(t *testing.T) {
 		t.Run("Basic", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				&agent.InitMessage{SessionID: "sess-123"},
 				&agent.TextMessage{Text: "hello"},
@@ -1157,7 +1238,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("InfersAsking", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				&agent.InitMessage{SessionID: "s1"},
 				&agent.AskMessage{
@@ -1173,7 +1254,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("InfersHasPlan", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				&agent.ToolUseMessage{
 					ToolUseID: "tu1", Name: "Write",
@@ -1191,7 +1272,7 @@ func TestTask(t *testing.T) {
 			// The relay emits DiffStatMessage after the ResultMessage.
 			// RestoreMessages should skip it and still infer Waiting.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				&agent.TextMessage{Text: "hello"},
 				&agent.ResultMessage{MessageType: "result"},
@@ -1207,7 +1288,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("NoResultKeepsState", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				&agent.InitMessage{SessionID: "s1"},
 				&agent.TextMessage{Text: "hello"},
@@ -1221,7 +1302,7 @@ func TestTask(t *testing.T) {
 		t.Run("TerminalStatePreserved", func(t *testing.T) {
 			for _, state := range []State{StatePurged, StateFailed, StatePurging} {
 				tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-				tk.SetState(state)
+				setState(t, tk, state)
 				msgs := []agent.Message{
 					&agent.TextMessage{Text: "hello"},
 					&agent.ResultMessage{MessageType: "result"},
@@ -1247,7 +1328,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("RestoresPlanFile", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				&agent.ToolUseMessage{
 					ToolUseID: "tu1", Name: "Write",
@@ -1262,7 +1343,7 @@ func TestTask(t *testing.T) {
 		})
 		t.Run("RestoresInPlanMode", func(t *testing.T) {
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				&agent.ToolUseMessage{ToolUseID: "tu1", Name: "EnterPlanMode"},
 				&agent.ToolUseMessage{
@@ -1282,7 +1363,7 @@ func TestTask(t *testing.T) {
 
 			// Without ExitPlanMode, should stay in plan mode.
 			tk2 := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk2.SetState(StateRunning)
+			setState(t, tk2, StateRunning)
 			tk2.RestoreMessages(msgs[:1])
 			if !tk2.Snapshot().InPlanMode {
 				t.Error("InPlanMode = false, want true (only EnterPlanMode seen)")
@@ -1293,7 +1374,7 @@ func TestTask(t *testing.T) {
 			// marker (from ClearMessages on restart), then a new session without
 			// a plan. RestoreMessages must not carry over the stale plan.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				&agent.ToolUseMessage{ToolUseID: "tu1", Name: "EnterPlanMode"},
 				&agent.ToolUseMessage{
@@ -1324,7 +1405,7 @@ func TestTask(t *testing.T) {
 			// and write to .claude/plans/ during execution. The dismissed plan
 			// must not resurface when the turn completes.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			msgs := []agent.Message{
 				// Original plan.
 				&agent.ToolUseMessage{
@@ -1357,7 +1438,7 @@ func TestTask(t *testing.T) {
 			// context_cleared in history must zero PlanContent on preceding
 			// ExitPlanMode events so new subscribers see no stale plan.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			exitMsg1 := &agent.ToolUseMessage{ToolUseID: "tu2", Name: "ExitPlanMode"}
 			msgs := []agent.Message{
 				&agent.ToolUseMessage{
@@ -1379,7 +1460,7 @@ func TestTask(t *testing.T) {
 			// When a plan is updated (two ExitPlanMode without context_cleared),
 			// only the latest ExitPlanMode should retain its PlanContent.
 			tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			exitMsg1 := &agent.ToolUseMessage{ToolUseID: "tu2", Name: "ExitPlanMode"}
 			exitMsg2 := &agent.ToolUseMessage{ToolUseID: "tu5", Name: "ExitPlanMode"}
 			msgs := []agent.Message{
@@ -1454,7 +1535,7 @@ func TestState(t *testing.T) {
 	t.Run("SetStateIf", func(t *testing.T) {
 		t.Run("Match", func(t *testing.T) {
 			tk := &Task{}
-			tk.SetState(StateRunning)
+			setState(t, tk, StateRunning)
 			if !tk.SetStateIf(StateRunning, StateWaiting) {
 				t.Fatal("SetStateIf returned false when state matched")
 			}
@@ -1464,7 +1545,7 @@ func TestState(t *testing.T) {
 		})
 		t.Run("Mismatch", func(t *testing.T) {
 			tk := &Task{}
-			tk.SetState(StateAsking)
+			setState(t, tk, StateAsking)
 			if tk.SetStateIf(StateRunning, StateWaiting) {
 				t.Fatal("SetStateIf returned true when state did not match")
 			}
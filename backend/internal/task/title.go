@@ -0,0 +1,357 @@
+// Title generation queue: batches LLM calls, retries, falls back to heuristics.
+package task
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/maruel/genai"
+)
+
+const (
+	titleSystemPrompt      = "Summarize this coding task conversation in 3-8 words as a short title. Reply with ONLY the title, no quotes."
+	titleBatchSystemPrompt = "Each numbered block is a coding task conversation. Summarize each in 3-8 words as a short title. Reply with one line per task formatted as \"<number>: <title>\", no quotes, nothing else."
+
+	// titleMaxChars bounds the LLM input to keep it working on most providers.
+	titleMaxChars = 50000
+	// titleBatchSize is the maximum number of tasks titled in one LLM call.
+	titleBatchSize = 5
+	// titleMaxAttempts is how many times a task is tried before giving up.
+	titleMaxAttempts = 3
+	// localTitleMaxChars bounds heuristic titles.
+	localTitleMaxChars = 60
+)
+
+// TitleQueue generates task titles with an LLM in the background. Requests
+// are deduplicated per task, batched into a single call when several are
+// pending, spaced by MinInterval and retried with exponential backoff.
+//
+// Without a provider, or once the LLM gave up on a task, titles are derived
+// heuristically from the prompt instead.
+type TitleQueue struct {
+	provider genai.Provider
+	onTitle  func(*Task)
+
+	// Existing, if set, returns the titles of all tasks but except. Heuristic
+	// titles are suffixed to stay distinct from them.
+	Existing func(except *Task) []string
+
+	// MinInterval is the minimum delay between two LLM calls.
+	MinInterval time.Duration
+	// RetryDelay is the delay before the first retry; it doubles on each
+	// subsequent attempt.
+	RetryDelay time.Duration
+
+	mu      sync.Mutex
+	pending []*titleReq
+	queued  map[*Task]*titleReq
+	wake    chan struct{}
+}
+
+type titleReq struct {
+	t         *Task
+	attempts  int
+	notBefore time.Time
+}
+
+// NewTitleQueue returns a queue generating titles with p. When p is nil, only
+// heuristic titles are generated. onTitle, if set, is called after a task's
+// title was updated. Call Run to process the queue.
+func NewTitleQueue(p genai.Provider, onTitle func(*Task)) *TitleQueue {
+	return &TitleQueue{
+		provider:    p,
+		onTitle:     onTitle,
+		MinInterval: 2 * time.Second,
+		RetryDelay:  30 * time.Second,
+		queued:      make(map[*Task]*titleReq),
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// Enqueue schedules a title for t. It is a no-op on a nil queue or when t is
+// already pending; the title reflects t's messages at generation time.
+func (q *TitleQueue) Enqueue(t *Task) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.queued[t]; ok {
+		return
+	}
+	q.pushLocked(&titleReq{t: t})
+}
+
+func (q *TitleQueue) pushLocked(r *titleReq) {
+	q.queued[r.t] = r
+	q.pending = append(q.pending, r)
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run processes the queue until ctx is done.
+func (q *TitleQueue) Run(ctx context.Context) {
+	var last time.Time
+	for {
+		batch, wait := q.next(time.Now())
+		if len(batch) == 0 {
+			if wait <= 0 {
+				wait = time.Hour
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+		if q.provider == nil {
+			for _, r := range batch {
+				q.applyLocal(r.t)
+			}
+			continue
+		}
+		if d := q.MinInterval - time.Since(last); d > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(d):
+			}
+		}
+		last = time.Now()
+		q.process(ctx, batch)
+	}
+}
+
+// next pops up to titleBatchSize requests that are due. When none is due,
+// wait is the delay until the earliest deferred request, or 0 if the queue is
+// empty.
+func (q *TitleQueue) next(now time.Time) (batch []*titleReq, wait time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	rest := q.pending[:0]
+	for _, r := range q.pending {
+		if len(batch) < titleBatchSize && !r.notBefore.After(now) {
+			batch = append(batch, r)
+			delete(q.queued, r.t)
+			continue
+		}
+		if d := r.notBefore.Sub(now); d > 0 && (wait == 0 || d < wait) {
+			wait = d
+		}
+		rest = append(rest, r)
+	}
+	clear(q.pending[len(rest):])
+	q.pending = rest
+	return batch, wait
+}
+
+// process generates titles for batch and applies them.
+func (q *TitleQueue) process(ctx context.Context, batch []*titleReq) {
+	start := time.Now()
+	var titles []string
+	var err error
+	if len(batch) == 1 {
+		var title string
+		title, err = q.generate(ctx, titleInput(batch[0].t, titleMaxChars))
+		titles = []string{title}
+	} else {
+		titles, err = q.generateBatch(ctx, batch)
+	}
+	d := time.Since(start).Round(time.Millisecond)
+	if ctx.Err() != nil {
+		return
+	}
+	for i, r := range batch {
+		switch {
+		case err != nil:
+			slog.Warn("title failed", "task", r.t.ID, "err", err, "d", d, "attempt", r.attempts+1)
+			q.retry(r)
+		case titles[i] == "":
+			slog.Warn("title", "task", r.t.ID, "d", d, "msg", "empty")
+			q.retry(r)
+		default:
+			slog.Info("title", "task", r.t.ID, "title", titles[i], "d", d, "batch", len(batch))
+			q.apply(r.t, titles[i])
+		}
+	}
+}
+
+func (q *TitleQueue) apply(t *Task, title string) {
+	t.SetTitle(title)
+	if q.onTitle != nil {
+		q.onTitle(t)
+	}
+}
+
+// applyLocal sets a heuristic title on t, unless it already has a title other
+// than its prompt or the heuristic one.
+func (q *TitleQueue) applyLocal(t *Task) {
+	base := LocalTitle(t.InitialPrompt.Text)
+	if cur := t.Title(); cur != "" && cur != t.InitialPrompt.Text && cur != base {
+		return
+	}
+	var existing []string
+	if q.Existing != nil {
+		existing = q.Existing(t)
+	}
+	if title := dedupTitle(base, existing); title != t.Title() {
+		q.apply(t, title)
+	}
+}
+
+// retry re-queues r with exponential backoff unless it ran out of attempts or
+// a newer request for the same task is already pending.
+func (q *TitleQueue) retry(r *titleReq) {
+	r.attempts++
+	if r.attempts >= titleMaxAttempts {
+		slog.Warn("title", "task", r.t.ID, "msg", "giving up", "attempts", r.attempts)
+		q.applyLocal(r.t)
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if _, ok := q.queued[r.t]; ok {
+		return
+	}
+	r.notBefore = time.Now().Add(q.RetryDelay << (r.attempts - 1))
+	q.pushLocked(r)
+}
+
+func (q *TitleQueue) generate(ctx context.Context, input string) (string, error) {
+	res, err := q.provider.GenSync(ctx,
+		genai.Messages{genai.NewTextMessage(input)},
+		&genai.GenOptionText{SystemPrompt: titleSystemPrompt},
+	)
+	if err != nil {
+		return "", err
+	}
+	return cleanTitle(res.String()), nil
+}
+
+// titleLineRe matches a "<number>: <title>" line of a batched reply.
+var titleLineRe = regexp.MustCompile(`^\s*(\d+)\s*[:.)-]\s*(.+)$`)
+
+// generateBatch titles all of batch in one call. Tasks missing from the
+// reply get an empty title so they are retried.
+func (q *TitleQueue) generateBatch(ctx context.Context, batch []*titleReq) ([]string, error) {
+	var b strings.Builder
+	for i, r := range batch {
+		fmt.Fprintf(&b, "### %d\n%s\n\n", i+1, titleInput(r.t, titleMaxChars/len(batch)))
+	}
+	res, err := q.provider.GenSync(ctx,
+		genai.Messages{genai.NewTextMessage(b.String())},
+		&genai.GenOptionText{SystemPrompt: titleBatchSystemPrompt},
+	)
+	if err != nil {
+		return nil, err
+	}
+	titles := make([]string, len(batch))
+	for line := range strings.Lines(res.String()) {
+		m := titleLineRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		if n, err := strconv.Atoi(m[1]); err == nil && n >= 1 && n <= len(batch) {
+			titles[n-1] = cleanTitle(m[2])
+		}
+	}
+	return titles, nil
+}
+
+// titleInput builds the LLM input for t from the prompt and the results,
+// truncated to maxChars.
+func titleInput(t *Task, maxChars int) string {
+	var b strings.Builder
+	for _, m := range t.Messages() {
+		if v, ok := m.(*agent.ResultMessage); ok && v.Result != "" {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+			b.WriteString("Result: ")
+			b.WriteString(v.Result)
+		}
+	}
+	// TODO: Use the images too.
+	input := "Prompt: " + t.InitialPrompt.Text
+	if b.Len() > 0 {
+		input += "\n" + b.String()
+	}
+	if len(input) > maxChars {
+		input = input[:maxChars]
+	}
+	return input
+}
+
+// cleanTitle strips surrounding quotes the model may add despite
+// instructions.
+func cleanTitle(s string) string {
+	return strings.Trim(strings.TrimSpace(s), "\"'`")
+}
+
+// LocalTitle derives a title from prompt without an LLM: its first sentence,
+// stripped of markdown decoration, with whitespace collapsed and truncated on
+// a word boundary.
+func LocalTitle(prompt string) string {
+	line := ""
+	inFence := false
+	for l := range strings.Lines(prompt) {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(l, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			continue
+		}
+		l = strings.TrimSpace(strings.TrimLeft(l, "#>*-+ \t"))
+		if l != "" {
+			line = l
+			break
+		}
+	}
+	if i := sentenceEndRe.FindStringIndex(line); i != nil {
+		line = line[:i[0]+1]
+	}
+	line = strings.Join(strings.Fields(strings.NewReplacer("`", "", "**", "", "__", "").Replace(line)), " ")
+	line = strings.TrimRight(line, ".:;,")
+	if line == "" {
+		return "Untitled task"
+	}
+	if r := []rune(line); len(r) > localTitleMaxChars {
+		cut := string(r[:localTitleMaxChars])
+		if i := strings.LastIndexByte(cut, ' '); i > localTitleMaxChars/2 {
+			cut = cut[:i]
+		}
+		line = strings.TrimRight(cut, " .:;,") + "…"
+	}
+	return line
+}
+
+// sentenceEndRe matches the end of the first sentence: terminal punctuation
+// followed by whitespace.
+var sentenceEndRe = regexp.MustCompile(`[.!?]\s`)
+
+// dedupTitle returns title, suffixed with " (N)" if needed to differ from
+// every entry in existing.
+func dedupTitle(title string, existing []string) string {
+	seen := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		seen[e] = true
+	}
+	out := title
+	for n := 2; seen[out]; n++ {
+		out = fmt.Sprintf("%s (%d)", title, n)
+	}
+	return out
+}
@@ -0,0 +1,179 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/maruel/genai"
+)
+
+// fakeProvider answers GenSync with reply; other genai.Provider methods are
+// not implemented.
+type fakeProvider struct {
+	genai.Provider
+	mu    sync.Mutex
+	calls []string
+	reply func(input string, call int) (string, error)
+}
+
+func (f *fakeProvider) GenSync(_ context.Context, msgs genai.Messages, _ ...genai.GenOption) (genai.Result, error) {
+	f.mu.Lock()
+	input := msgs[0].String()
+	f.calls = append(f.calls, input)
+	n := len(f.calls)
+	f.mu.Unlock()
+	text, err := f.reply(input, n)
+	return genai.Result{Message: genai.Message{Replies: []genai.Reply{{Text: text}}}}, err
+}
+
+func TestTitleQueue(t *testing.T) {
+	// Enqueue on a nil queue is a no-op.
+	(*TitleQueue)(nil).Enqueue(&Task{})
+
+	newTask := func(prompt string) *Task {
+		return &Task{InitialPrompt: agent.Prompt{Text: prompt}}
+	}
+	t.Run("Single", func(t *testing.T) {
+		p := &fakeProvider{reply: func(string, int) (string, error) { return `"Fix the login bug"`, nil }}
+		var updated []*Task
+		q := NewTitleQueue(p, func(tk *Task) { updated = append(updated, tk) })
+		tk := newTask("the login is broken")
+		q.Enqueue(tk)
+		q.Enqueue(tk) // Deduplicated.
+		batch, _ := q.next(time.Now())
+		if len(batch) != 1 {
+			t.Fatalf("batch = %d, want 1", len(batch))
+		}
+		q.process(t.Context(), batch)
+		if tk.Title() != "Fix the login bug" || len(updated) != 1 {
+			t.Errorf("title = %q, updated = %d", tk.Title(), len(updated))
+		}
+	})
+	t.Run("Batch", func(t *testing.T) {
+		p := &fakeProvider{reply: func(input string, call int) (string, error) {
+			if call == 1 {
+				if !strings.Contains(input, "### 1\nPrompt: a") || !strings.Contains(input, "### 3\nPrompt: c") {
+					t.Errorf("input = %q", input)
+				}
+				// Task 2 is missing from the reply.
+				return "1: Title A\n3. Title C\n", nil
+			}
+			return "Title B", nil
+		}}
+		q := NewTitleQueue(p, nil)
+		q.RetryDelay = 0
+		tasks := []*Task{newTask("a"), newTask("b"), newTask("c")}
+		for _, tk := range tasks {
+			q.Enqueue(tk)
+		}
+		batch, _ := q.next(time.Now())
+		if len(batch) != 3 {
+			t.Fatalf("batch = %d, want 3", len(batch))
+		}
+		q.process(t.Context(), batch)
+		if tasks[0].Title() != "Title A" || tasks[1].Title() != "" || tasks[2].Title() != "Title C" {
+			t.Errorf("titles = %q, %q, %q", tasks[0].Title(), tasks[1].Title(), tasks[2].Title())
+		}
+		batch, _ = q.next(time.Now())
+		if len(batch) != 1 || batch[0].t != tasks[1] {
+			t.Fatalf("retry batch = %+v", batch)
+		}
+		q.process(t.Context(), batch)
+		if tasks[1].Title() != "Title B" {
+			t.Errorf("title = %q", tasks[1].Title())
+		}
+	})
+	t.Run("RetryBackoff", func(t *testing.T) {
+		p := &fakeProvider{reply: func(string, int) (string, error) { return "", errors.New("503") }}
+		q := NewTitleQueue(p, nil)
+		tk := newTask("x")
+		q.Enqueue(tk)
+		now := time.Now()
+		for attempt := range titleMaxAttempts {
+			batch, wait := q.next(now)
+			if len(batch) != 1 {
+				t.Fatalf("attempt %d: batch = %d, wait = %v", attempt, len(batch), wait)
+			}
+			q.process(t.Context(), batch)
+			now = now.Add(time.Hour)
+		}
+		if batch, wait := q.next(now); len(batch) != 0 || wait != 0 {
+			t.Errorf("expected to give up; batch = %d, wait = %v", len(batch), wait)
+		}
+		if n := len(p.calls); n != titleMaxAttempts {
+			t.Errorf("calls = %d, want %d", n, titleMaxAttempts)
+		}
+		// Giving up falls back to the heuristic title.
+		if tk.Title() != "x" {
+			t.Errorf("title = %q, want %q", tk.Title(), "x")
+		}
+	})
+	t.Run("Run", func(t *testing.T) {
+		p := &fakeProvider{reply: func(string, int) (string, error) { return "Done", nil }}
+		done := make(chan *Task, 1)
+		q := NewTitleQueue(p, func(tk *Task) { done <- tk })
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		go q.Run(ctx)
+		tk := newTask("y")
+		q.Enqueue(tk)
+		select {
+		case got := <-done:
+			if got.Title() != "Done" {
+				t.Errorf("title = %q", got.Title())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	})
+	t.Run("NoProvider", func(t *testing.T) {
+		done := make(chan *Task, 1)
+		q := NewTitleQueue(nil, func(tk *Task) { done <- tk })
+		q.Existing = func(*Task) []string { return []string{"Fix the build"} }
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		go q.Run(ctx)
+		tk := newTask("Fix the build. It fails on arm64 since the last bump.")
+		tk.SetTitle(tk.InitialPrompt.Text)
+		q.Enqueue(tk)
+		select {
+		case got := <-done:
+			if got.Title() != "Fix the build (2)" {
+				t.Errorf("title = %q", got.Title())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+	})
+}
+
+func TestLocalTitle(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	for _, tc := range []struct{ in, want string }{
+		{"Fix the login bug", "Fix the login bug"},
+		{"Fix the login bug. Then add a test.\n\nMore details here.", "Fix the login bug"},
+		{"## Goal\n\nMake `make lint` pass!", "Goal"},
+		{"\n\n- **Refactor** the parser: it is slow", "Refactor the parser: it is slow"},
+		{"```\ncode\n```\nWhy does this fail? See above", "Why does this fail?"},
+		{"   ", "Untitled task"},
+		{long, strings.TrimSpace(strings.Repeat("word ", 12)) + "…"},
+	} {
+		if got := LocalTitle(tc.in); got != tc.want {
+			t.Errorf("LocalTitle(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestDedupTitle(t *testing.T) {
+	existing := []string{"A", "A (2)", "B"}
+	for _, tc := range []struct{ in, want string }{{"A", "A (3)"}, {"B", "B (2)"}, {"C", "C"}} {
+		if got := dedupTitle(tc.in, existing); got != tc.want {
+			t.Errorf("dedupTitle(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Per-tool call statistics.
+package task
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+// ToolStat aggregates the calls of one tool.
+type ToolStat struct {
+	Name     string
+	Calls    int
+	Failures int // Calls whose result reported an error or a non-zero exit code.
+	// Duration is the total time spent in the tool. A call's duration is the
+	// one reported by the harness, else the one measured between its tool_use
+	// and tool_result while the server was running; otherwise it is unknown
+	// and counts as zero.
+	Duration time.Duration
+}
+
+// ToolStats returns the per-tool statistics of the task, sorted by
+// SortToolStats.
+func (t *Task) ToolStats() []ToolStat {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return toolStats(t.msgs, t.toolDurations)
+}
+
+// recordToolTiming measures the duration of the tool calls as their messages
+// arrive. t.mu must be held.
+func (t *Task) recordToolTiming(m agent.Message, now time.Time) {
+	switch v := m.(type) {
+	case *agent.ToolUseMessage:
+		if t.toolStarts == nil {
+			t.toolStarts = map[string]time.Time{}
+		}
+		t.toolStarts[v.ToolUseID] = now
+	case *agent.ToolResultMessage:
+		started, ok := t.toolStarts[v.ToolUseID]
+		if !ok {
+			return
+		}
+		delete(t.toolStarts, v.ToolUseID)
+		if t.toolDurations == nil {
+			t.toolDurations = map[string]time.Duration{}
+		}
+		d := now.Sub(started)
+		t.toolDurations[v.ToolUseID] = d
+		if v.DurationMs > 0 {
+			d = time.Duration(v.DurationMs) * time.Millisecond
+		}
+		t.turnTiming.tools += d
+	}
+}
+
+// toolStats aggregates the tool calls of msgs. measured holds the durations
+// measured live, by tool use ID.
+func toolStats(msgs []agent.Message, measured map[string]time.Duration) []ToolStat {
+	byName := map[string]*ToolStat{}
+	byID := map[string]*ToolStat{}
+	for _, m := range msgs {
+		switch v := m.(type) {
+		case *agent.ToolUseMessage:
+			s := byName[v.Name]
+			if s == nil {
+				s = &ToolStat{Name: v.Name}
+				byName[v.Name] = s
+			}
+			s.Calls++
+			byID[v.ToolUseID] = s
+		case *agent.ToolResultMessage:
+			s := byID[v.ToolUseID]
+			if s == nil {
+				continue
+			}
+			delete(byID, v.ToolUseID)
+			if v.Error != "" || (v.ExitCode != nil && *v.ExitCode != 0) {
+				s.Failures++
+			}
+			if v.DurationMs > 0 {
+				s.Duration += time.Duration(v.DurationMs) * time.Millisecond
+			} else {
+				s.Duration += measured[v.ToolUseID]
+			}
+		}
+	}
+	return collectToolStats(byName)
+}
+
+// MergeToolStats sums the statistics of several tasks per tool.
+func MergeToolStats(stats ...[]ToolStat) []ToolStat {
+	byName := map[string]*ToolStat{}
+	for _, ss := range stats {
+		for _, s := range ss {
+			m := byName[s.Name]
+			if m == nil {
+				m = &ToolStat{Name: s.Name}
+				byName[s.Name] = m
+			}
+			m.Calls += s.Calls
+			m.Failures += s.Failures
+			m.Duration += s.Duration
+		}
+	}
+	return collectToolStats(byName)
+}
+
+func collectToolStats(byName map[string]*ToolStat) []ToolStat {
+	out := make([]ToolStat, 0, len(byName))
+	for _, s := range byName {
+		out = append(out, *s)
+	}
+	SortToolStats(out)
+	return out
+}
+
+// SortToolStats sorts stats by total duration, then by number of calls, most
+// first.
+func SortToolStats(stats []ToolStat) {
+	slices.SortFunc(stats, func(a, b ToolStat) int {
+		if c := cmp.Compare(b.Duration, a.Duration); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Calls, a.Calls); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
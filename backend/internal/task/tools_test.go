@@ -0,0 +1,56 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestToolStats(t *testing.T) {
+	one := 1
+	msgs := []agent.Message{
+		&agent.ToolUseMessage{ToolUseID: "b1", Name: "Bash"},
+		&agent.ToolResultMessage{ToolUseID: "b1", DurationMs: 3000},
+		&agent.ToolUseMessage{ToolUseID: "b2", Name: "Bash"},
+		&agent.ToolResultMessage{ToolUseID: "b2", ExitCode: &one},
+		&agent.ToolUseMessage{ToolUseID: "r1", Name: "Read"},
+		&agent.ToolResultMessage{ToolUseID: "r1", Error: "no such file"},
+		&agent.ToolUseMessage{ToolUseID: "r2", Name: "Read"},
+		&agent.ToolUseMessage{ToolUseID: "g1", Name: "Grep"},
+		&agent.ToolResultMessage{ToolUseID: "g1"},
+	}
+	measured := map[string]time.Duration{"b2": time.Second, "r1": 500 * time.Millisecond}
+	got := toolStats(msgs, measured)
+	want := []ToolStat{
+		{Name: "Bash", Calls: 2, Failures: 1, Duration: 4 * time.Second},
+		{Name: "Read", Calls: 2, Failures: 1, Duration: 500 * time.Millisecond},
+		{Name: "Grep", Calls: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	merged := MergeToolStats(got, []ToolStat{{Name: "Grep", Calls: 3, Duration: time.Minute}})
+	if len(merged) != 3 || merged[0] != (ToolStat{Name: "Grep", Calls: 4, Duration: time.Minute}) || merged[1].Name != "Bash" {
+		t.Errorf("merged = %+v", merged)
+	}
+}
+
+func TestTaskToolStats(t *testing.T) {
+	tk := &Task{}
+	tk.addMessage(t.Context(), &agent.ToolUseMessage{ToolUseID: "e1", Name: "Edit"}, true)
+	tk.addMessage(t.Context(), &agent.ToolResultMessage{ToolUseID: "e1"}, true)
+	got := tk.ToolStats()
+	if len(got) != 1 || got[0].Name != "Edit" || got[0].Calls != 1 {
+		t.Fatalf("got %+v", got)
+	}
+	if _, ok := tk.toolDurations["e1"]; !ok || len(tk.toolStarts) != 0 {
+		t.Errorf("durations %v, starts %v", tk.toolDurations, tk.toolStarts)
+	}
+}
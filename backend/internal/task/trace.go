@@ -0,0 +1,25 @@
+// OpenTelemetry spans for task lifecycle and git operations.
+package task
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer is a no-op until the process installs a global TracerProvider.
+var tracer = otel.Tracer("github.com/caic-xyz/caic/backend/internal/task")
+
+// TaskIDKey is the span attribute key carrying the task ID.
+const TaskIDKey = attribute.Key("caic.task.id")
+
+// startSpan starts a span named name, tagged with the task ID when t is
+// non-nil.
+func startSpan(ctx context.Context, name string, t *Task, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if t != nil && !t.ID.IsZero() {
+		attrs = append(attrs, TaskIDKey.String(t.ID.String()))
+	}
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
@@ -0,0 +1,45 @@
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+	"github.com/maruel/ksid"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStartSpan(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	old := tracer
+	tracer = tp.Tracer("test")
+	t.Cleanup(func() { tracer = old })
+
+	tk := &Task{ID: ksid.NewID()}
+	_, span := startSpan(t.Context(), "task.Start", tk)
+	agent.EndSpan(span, errors.New("boom"))
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "task.Start" {
+		t.Errorf("name = %q", s.Name())
+	}
+	found := false
+	for _, kv := range s.Attributes() {
+		if kv.Key == TaskIDKey && kv.Value.AsString() == tk.ID.String() {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("attributes %v missing %s", s.Attributes(), TaskIDKey)
+	}
+	if s.Status().Code != codes.Error {
+		t.Errorf("status = %v, want error", s.Status())
+	}
+}
@@ -0,0 +1,99 @@
+// Provenance trailers recording the agent and task behind the commits caic
+// syncs.
+package task
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+// trailerRe matches a git trailer line, e.g. "Task: 123".
+var trailerRe = regexp.MustCompile(`^[A-Za-z0-9-]+: `)
+
+// Trailers returns the provenance trailers of the commits of t: the harness
+// and model, the task ID and, when coAuthor is not empty, a Co-authored-by
+// trailer for it, e.g. "Jane <jane@example.com>".
+func (t *Task) Trailers(coAuthor string) []string {
+	agentName := string(t.Harness)
+	if m := t.Snapshot().Model; m != "" {
+		agentName += "/" + m
+	}
+	out := []string{"Agent: " + agentName, "Task: " + t.ID.String()}
+	if coAuthor != "" {
+		out = append(out, "Co-authored-by: "+coAuthor)
+	}
+	return out
+}
+
+// AppendTrailers returns msg with each of trailers not already in it
+// appended to its trailer block, the last paragraph after the subject when
+// all its lines are trailers.
+func AppendTrailers(msg string, trailers []string) string {
+	msg = strings.TrimRight(msg, " \n")
+	lines := strings.Split(msg, "\n")
+	var add []string
+	for _, tr := range trailers {
+		if !slices.Contains(lines, tr) && !slices.Contains(add, tr) {
+			add = append(add, tr)
+		}
+	}
+	if len(add) == 0 {
+		return msg + "\n"
+	}
+	sep := "\n\n"
+	if i := strings.LastIndex(msg, "\n\n"); i != -1 {
+		block := true
+		for l := range strings.SplitSeq(msg[i+2:], "\n") {
+			block = block && trailerRe.MatchString(l)
+		}
+		if block {
+			sep = "\n"
+		}
+	}
+	return msg + sep + strings.Join(add, "\n") + "\n"
+}
+
+// addTrailers rewrites the commits of ref not on origin/baseBranch so their
+// messages carry trailers and returns the new tip. Trees, authors,
+// committers and dates are kept, so rewriting the same commits again gives
+// the same commits, unless signed with sign. Neither the working tree nor any
+// ref is touched.
+func addTrailers(ctx context.Context, dir, ref, baseBranch string, trailers []string, sign *Signing) (string, error) {
+	tip, err := gitutil.RevParse(ctx, dir, ref)
+	if err != nil {
+		return "", err
+	}
+	// One record per commit, oldest first; fields are NUL separated.
+	out, err := gitutil.RunGit(ctx, dir, "log", "--reverse", "--topo-order", "-z", "--format=%H%x00%P%x00%T%x00%an%x00%ae%x00%aI%x00%cn%x00%ce%x00%cI%x00%B", tip, "^origin/"+baseBranch)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Split(out, "\x00")
+	rewritten := map[string]string{}
+	for ; len(fields) >= 10; fields = fields[10:] {
+		f := fields[:10]
+		sha := strings.TrimLeft(f[0], "\n")
+		args := []string{f[2]}
+		for p := range strings.FieldsSeq(f[1]) {
+			if n, ok := rewritten[p]; ok {
+				p = n
+			}
+			args = append(args, "-p", p)
+		}
+		env := []string{
+			"GIT_AUTHOR_NAME=" + f[3], "GIT_AUTHOR_EMAIL=" + f[4], "GIT_AUTHOR_DATE=" + f[5],
+			"GIT_COMMITTER_NAME=" + f[6], "GIT_COMMITTER_EMAIL=" + f[7], "GIT_COMMITTER_DATE=" + f[8],
+		}
+		if rewritten[sha], err = commitTree(ctx, dir, sign, env, AppendTrailers(f[9], trailers), args...); err != nil {
+			return "", err
+		}
+	}
+	if n, ok := rewritten[tip]; ok {
+		return n, nil
+	}
+	return tip, nil
+}
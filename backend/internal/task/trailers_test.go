@@ -0,0 +1,76 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/caic-xyz/md/gitutil"
+)
+
+func TestAppendTrailers(t *testing.T) {
+	trailers := []string{"Agent: claude", "Task: 1"}
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"Subject", "Fix parser\n", "Fix parser\n\nAgent: claude\nTask: 1\n"},
+		{"SubjectLikeTrailer", "Fix: parser", "Fix: parser\n\nAgent: claude\nTask: 1\n"},
+		{"Body", "Fix parser\n\nIt crashed.\n\n", "Fix parser\n\nIt crashed.\n\nAgent: claude\nTask: 1\n"},
+		{"Block", "Fix parser\n\nSigned-off-by: a <a@b>", "Fix parser\n\nSigned-off-by: a <a@b>\nAgent: claude\nTask: 1\n"},
+		{"Present", "Fix parser\n\nTask: 1\n", "Fix parser\n\nTask: 1\nAgent: claude\n"},
+		{"All", "Fix parser\n\nAgent: claude\nTask: 1", "Fix parser\n\nAgent: claude\nTask: 1\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := AppendTrailers(tc.in, trailers); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddTrailers(t *testing.T) {
+	ctx := t.Context()
+	clone := initTestRepo(t, "main")
+	runGit(t, clone, "checkout", "-b", "caic-0")
+	for _, name := range []string{"a", "b"} {
+		if err := os.WriteFile(filepath.Join(clone, name), []byte(name+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		runGit(t, clone, "add", name)
+		runGit(t, clone, "commit", "-m", "add "+name)
+	}
+	head, err := gitutil.RevParse(ctx, clone, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trailers := []string{"Task: 1"}
+	got, err := addTrailers(ctx, clone, "caic-0", "main", trailers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == head {
+		t.Fatal("not rewritten")
+	}
+	if again, err := addTrailers(ctx, clone, "caic-0", "main", trailers, nil); err != nil || again != got {
+		t.Errorf("second rewrite = %q, %v; want %q", again, err, got)
+	}
+	msgs, err := gitutil.RunGit(ctx, clone, "log", "--format=%s|%(trailers:only,unfold)|%an", "origin/main.."+got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "add b|Task: 1\n|Test\nadd a|Task: 1\n|Test"; msgs != want {
+		t.Errorf("log = %q, want %q", msgs, want)
+	}
+	for _, ref := range []string{"HEAD", got} {
+		if diff, err := gitutil.RunGit(ctx, clone, "diff", "--stat", head, ref); err != nil || diff != "" {
+			t.Errorf("%s differs: %q, %v", ref, diff, err)
+		}
+	}
+	if h, _ := gitutil.RevParse(ctx, clone, "caic-0"); h != head {
+		t.Errorf("caic-0 moved to %s", h)
+	}
+	base, _ := gitutil.RevParse(ctx, clone, "main")
+	if out, err := addTrailers(ctx, clone, "main", "main", trailers, nil); err != nil || out != base {
+		t.Errorf("nothing to rewrite = %q, %v; want %q", out, err, base)
+	}
+}
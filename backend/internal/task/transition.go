@@ -0,0 +1,57 @@
+// Legal task state transitions.
+package task
+
+import (
+	"fmt"
+	"slices"
+)
+
+// live are the states of a task whose agent session is, or may be, attached.
+var live = []State{StateRunning, StateWaiting, StateAsking, StateHasPlan, StatePulling, StatePushing}
+
+// transitions lists the states each state may move to. Setting the current
+// state again is always legal; it only refreshes the transition time.
+//
+// StatePending is the state of every new Task value. Runner.Start moves it on;
+// tasks loaded from logs or adopted from a container enter through Restore
+// and Adopt instead, see restoredStates and adoptedStates. Cleanup, the
+// single shutdown path, may purge a task in any state. StatePurged is final.
+var transitions = map[State][]State{
+	StatePending:      {StateBranching, StateProvisioning, StatePurging, StateFailed, StatePurged},
+	StateBranching:    {StateProvisioning, StatePurging, StateFailed, StatePurged},
+	StateProvisioning: {StateStarting, StatePurging, StateFailed, StatePurged},
+	StateStarting:     append([]State{StateStopping, StateStopped, StatePurging, StateFailed, StatePurged}, live...),
+	StateRunning:      append([]State{StateStarting, StatePaused, StateStopping, StateStopped, StatePurging, StateFailed, StatePurged}, live...),
+	StateWaiting:      append([]State{StateStarting, StatePaused, StateStopping, StateStopped, StatePurging, StateFailed, StatePurged}, live...),
+	StateAsking:       append([]State{StateStarting, StatePaused, StateStopping, StateStopped, StatePurging, StateFailed, StatePurged}, live...),
+	StateHasPlan:      append([]State{StateStarting, StatePaused, StateStopping, StateStopped, StatePurging, StateFailed, StatePurged}, live...),
+	StatePaused:       {StateStarting, StateStopping, StateStopped, StatePurging, StateFailed, StatePurged},
+	StatePulling:      append([]State{StateStopping, StateStopped, StatePurging, StateFailed, StatePurged}, live...),
+	StatePushing:      append([]State{StateStopping, StateStopped, StatePurging, StateFailed, StatePurged}, live...),
+	StateStopping:     {StateStopped, StatePurging, StateFailed, StatePurged},
+	StateStopped:      {StateProvisioning, StatePurging, StateFailed, StatePurged},
+	StatePurging:      {StatePurged, StateFailed},
+	StateFailed:       {StatePurging, StatePurged},
+}
+
+// restoredStates are the states a pending task loaded from its log may
+// enter, the ones a log trailer records.
+var restoredStates = []State{StateFailed, StatePurged}
+
+// adoptedStates are the states a pending task adopted from a container may
+// enter. The restored messages then tell whether it waits for input.
+var adoptedStates = []State{StateRunning}
+
+// CanTransition reports whether a task may move from state from to state to.
+func CanTransition(from, to State) bool {
+	return from == to || slices.Contains(transitions[from], to)
+}
+
+// TransitionError is returned for an illegal state transition.
+type TransitionError struct {
+	From, To State
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("illegal task state transition %s → %s", e.From, e.To)
+}
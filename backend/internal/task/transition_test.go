@@ -0,0 +1,121 @@
+package task
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestCanTransition(t *testing.T) {
+	for _, tc := range []struct {
+		from, to State
+		want     bool
+	}{
+		{StatePending, StateBranching, true},
+		{StatePending, StateRunning, false},
+		{StatePending, StateWaiting, false},
+		{StateRunning, StateRunning, true},
+		{StateRunning, StateAsking, true},
+		{StateAsking, StateRunning, true},
+		{StateStopped, StateProvisioning, true},
+		{StateRunning, StatePaused, true},
+		{StatePaused, StateStarting, true},
+		{StatePaused, StateWaiting, false},
+		{StatePulling, StatePaused, false},
+		{StateWaiting, StatePurged, true},
+		{StateStopping, StateWaiting, false},
+		{StatePurging, StateStopped, false},
+		{StatePurged, StateRunning, false},
+		{StateFailed, StateWaiting, false},
+		{StateBranching, StateRunning, false},
+	} {
+		if got := CanTransition(tc.from, tc.to); got != tc.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+	// Every state is covered by the table.
+	for s := StatePending; s < StatePurged; s++ {
+		if len(transitions[s]) == 0 {
+			t.Errorf("%s has no transition", s)
+		}
+	}
+}
+
+func TestTransition(t *testing.T) {
+	tk := &Task{}
+	for _, s := range []State{StateBranching, StateProvisioning, StateStarting, StateRunning, StateStopping} {
+		if err := tk.Transition(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A late result from the draining session must not resurrect the task.
+	err := tk.Transition(StateWaiting)
+	var te *TransitionError
+	if !errors.As(err, &te) || te.From != StateStopping || te.To != StateWaiting {
+		t.Fatalf("err = %v", err)
+	}
+	if tk.SetStateIf(StateStopping, StateAsking) || tk.GetState() != StateStopping {
+		t.Errorf("state = %s", tk.GetState())
+	}
+}
+
+func TestStateMessages(t *testing.T) {
+	tk := &Task{}
+	_, live, unsub := tk.Subscribe(t.Context())
+	defer unsub()
+	for _, s := range []State{StateBranching, StateProvisioning, StateProvisioning, StateFailed} {
+		if err := tk.Transition(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Setting the same state again isn't a change.
+	var got []string
+	for range 3 {
+		m := (<-live).(*agent.StateMessage)
+		if m.At.IsZero() {
+			t.Errorf("%+v has no time", m)
+		}
+		got = append(got, m.From+"→"+m.To)
+	}
+	if want := []string{"pending→branching", "branching→provisioning", "provisioning→failed"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	select {
+	case m := <-live:
+		t.Errorf("unexpected %+v", m)
+	default:
+	}
+	// The state changes are only sent live.
+	if msgs := tk.Messages(); len(msgs) != 0 {
+		t.Errorf("history = %+v", msgs)
+	}
+}
+
+func TestEnter(t *testing.T) {
+	at := time.Now().Add(-time.Hour).UTC()
+	tk := &Task{}
+	if err := tk.Adopt(StateWaiting, at); err == nil {
+		t.Error("adopted waiting")
+	}
+	if err := tk.Adopt(StateRunning, at); err != nil {
+		t.Fatal(err)
+	}
+	if tk.GetState() != StateRunning || !tk.stateUpdatedAt.Equal(at) {
+		t.Errorf("state = %s at %s", tk.GetState(), tk.stateUpdatedAt)
+	}
+	// Only pending tasks enter.
+	var te *TransitionError
+	if err := tk.Restore(StatePurged); !errors.As(err, &te) || te.From != StateRunning {
+		t.Errorf("err = %v", err)
+	}
+	tk = &Task{}
+	if err := tk.Restore(StateStopped); err == nil {
+		t.Error("restored stopped")
+	}
+	if err := tk.Restore(StatePurged); err != nil || tk.GetState() != StatePurged {
+		t.Errorf("state = %s, err = %v", tk.GetState(), err)
+	}
+}
@@ -0,0 +1,108 @@
+// Per-turn token usage and cost history.
+package task
+
+import (
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+// TurnUsage is the token usage and cost of one agent turn, i.e. the work
+// between a prompt and its ResultMessage.
+type TurnUsage struct {
+	EndedAt time.Time
+	// Estimated is true when EndedAt was reconstructed from the task start
+	// time and cumulative turn durations because the turn was loaded from
+	// logs, which carry no timestamps. Idle time between turns is not
+	// accounted for, so estimates are early.
+	Estimated bool
+	Model     string
+	Usage     agent.Usage
+	CostUSD   float64
+	Duration  time.Duration
+	Latency   TurnLatency
+}
+
+// TurnLatency breaks down the wall time of a turn, to tell slow models from
+// slow tools. It is measured as messages arrive, so it is zero for turns
+// loaded from logs.
+type TurnLatency struct {
+	FirstToken time.Duration // Prompt sent → first agent output.
+	Completion time.Duration // First agent output → turn complete.
+	Tools      time.Duration // Time spent in tool calls.
+	Model      time.Duration // Wall time not spent in tool calls.
+}
+
+// turnTiming tracks the latency of the running turn.
+type turnTiming struct {
+	promptAt     time.Time
+	firstTokenAt time.Time
+	tools        time.Duration
+}
+
+// recordTurnTiming updates the latency of the running turn with m, received
+// at now. t.mu must be held.
+func (t *Task) recordTurnTiming(m agent.Message, now time.Time) {
+	tt := &t.turnTiming
+	switch v := m.(type) {
+	case *agent.UserInputMessage:
+		// Input sent while the agent is working joins the running turn.
+		if tt.promptAt.IsZero() && (v.Text != "" || len(v.Images) > 0) {
+			tt.promptAt = now
+		}
+	case *agent.TextMessage, *agent.TextDeltaMessage, *agent.ThinkingMessage, *agent.ThinkingDeltaMessage,
+		*agent.ToolUseMessage, *agent.AskMessage, *agent.TodoMessage:
+		if !tt.promptAt.IsZero() && tt.firstTokenAt.IsZero() {
+			tt.firstTokenAt = now
+		}
+	}
+}
+
+// latency returns the breakdown of the turn completed at end and resets the
+// tracking. t.mu must be held.
+func (t *Task) latency(end time.Time) TurnLatency {
+	tt := t.turnTiming
+	t.turnTiming = turnTiming{}
+	if tt.promptAt.IsZero() || tt.firstTokenAt.IsZero() {
+		return TurnLatency{}
+	}
+	return TurnLatency{
+		FirstToken: tt.firstTokenAt.Sub(tt.promptAt),
+		Completion: end.Sub(tt.firstTokenAt),
+		Tools:      tt.tools,
+		Model:      max(end.Sub(tt.promptAt)-tt.tools, 0),
+	}
+}
+
+// TurnUsages returns the per-turn usage series, oldest first. The CostUSD
+// values sum to the task's live cost.
+func (t *Task) TurnUsages() []TurnUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TurnUsage(nil), t.turns...)
+}
+
+// recordTurn appends the turn completed by rm. costUSD is the increase in the
+// task's live cost caused by rm. t.mu must be held.
+func (t *Task) recordTurn(rm *agent.ResultMessage, costUSD float64, at time.Time, estimated bool) {
+	model := t.turnModel
+	if model == "" {
+		model = t.reportedModel
+	}
+	if model == "" {
+		model = t.Model
+	}
+	tu := TurnUsage{
+		EndedAt:   at,
+		Estimated: estimated,
+		Model:     model,
+		Usage:     rm.Usage,
+		CostUSD:   costUSD,
+		Duration:  time.Duration(rm.DurationMs) * time.Millisecond,
+	}
+	if !estimated {
+		tu.Latency = t.latency(at)
+	}
+	t.turns = append(t.turns, tu)
+	t.turnModel = ""
+}
@@ -0,0 +1,86 @@
+package task
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/caic-xyz/caic/backend/internal/agent"
+)
+
+func TestTurnUsages(t *testing.T) {
+	t.Run("Live", func(t *testing.T) {
+		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, Model: "claude-opus-4-6"}
+		setState(t, tk, StateRunning)
+		tk.addMessage(t.Context(), &agent.UsageMessage{Model: "claude-sonnet-4-6", Usage: agent.Usage{InputTokens: 10}}, false)
+		tk.addMessage(t.Context(), &agent.ResultMessage{MessageType: "result", TotalCostUSD: 1, DurationMs: 2000, Usage: agent.Usage{InputTokens: 10, OutputTokens: 5}}, true)
+		tk.addMessage(t.Context(), &agent.ResultMessage{MessageType: "result", TotalCostUSD: 3, DurationMs: 1000}, true)
+		turns := tk.TurnUsages()
+		if len(turns) != 2 {
+			t.Fatalf("len = %d, want 2", len(turns))
+		}
+		if turns[0].Model != "claude-sonnet-4-6" || turns[1].Model != "claude-opus-4-6" {
+			t.Errorf("models = %q, %q", turns[0].Model, turns[1].Model)
+		}
+		// TotalCostUSD is cumulative per session; turns hold the increments.
+		if turns[0].CostUSD != 1 || turns[1].CostUSD != 2 {
+			t.Errorf("costs = %v, %v", turns[0].CostUSD, turns[1].CostUSD)
+		}
+		if turns[0].Estimated || turns[0].EndedAt.IsZero() || turns[0].Duration != 2*time.Second {
+			t.Errorf("turn 0 = %+v", turns[0])
+		}
+		if turns[0].Usage.OutputTokens != 5 {
+			t.Errorf("usage = %+v", turns[0].Usage)
+		}
+	})
+	t.Run("Latency", func(t *testing.T) {
+		tk := &Task{}
+		t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		at := func(s int) time.Time { return t0.Add(time.Duration(s) * time.Second) }
+		tk.recordTurnTiming(&agent.UserInputMessage{Text: "go"}, at(0))
+		tk.recordTurnTiming(&agent.UserInputMessage{Text: "and also"}, at(1))
+		tk.recordTurnTiming(&agent.ThinkingDeltaMessage{Text: "h"}, at(3))
+		for _, m := range []agent.Message{
+			&agent.ToolUseMessage{ToolUseID: "b", Name: "Bash"},
+			&agent.ToolResultMessage{ToolUseID: "b"},
+		} {
+			tk.recordTurnTiming(m, at(4))
+			tk.recordToolTiming(m, at(4))
+		}
+		tk.recordToolTiming(&agent.ToolUseMessage{ToolUseID: "r", Name: "Read"}, at(5))
+		tk.recordToolTiming(&agent.ToolResultMessage{ToolUseID: "r", DurationMs: 4000}, at(9))
+		want := TurnLatency{FirstToken: 3 * time.Second, Completion: 7 * time.Second, Tools: 4 * time.Second, Model: 6 * time.Second}
+		if got := tk.latency(at(10)); got != want {
+			t.Errorf("latency = %+v, want %+v", got, want)
+		}
+		if got := tk.latency(at(11)); got != (TurnLatency{}) {
+			t.Errorf("latency after reset = %+v", got)
+		}
+	})
+	t.Run("Restored", func(t *testing.T) {
+		start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		tk := &Task{InitialPrompt: agent.Prompt{Text: "test"}, StartedAt: start}
+		tk.RestoreMessages([]agent.Message{
+			&agent.ResultMessage{MessageType: "result", TotalCostUSD: 10, DurationMs: 5000},
+			&agent.SystemMessage{MessageType: "system", Subtype: "compact_boundary"},
+			&agent.ResultMessage{MessageType: "result", TotalCostUSD: 4, DurationMs: 3000},
+		})
+		turns := tk.TurnUsages()
+		if len(turns) != 2 {
+			t.Fatalf("len = %d, want 2", len(turns))
+		}
+		if turns[0].CostUSD != 10 || turns[1].CostUSD != 4 {
+			t.Errorf("costs = %v, %v", turns[0].CostUSD, turns[1].CostUSD)
+		}
+		if !turns[1].Estimated || !turns[1].EndedAt.Equal(start.Add(8*time.Second)) {
+			t.Errorf("turn 1 = %+v", turns[1])
+		}
+		sum := 0.
+		for _, tu := range turns {
+			sum += tu.CostUSD
+		}
+		if cost, _, _, _, _ := tk.LiveStats(); math.Abs(sum-cost) > 1e-9 {
+			t.Errorf("sum = %v, live cost = %v", sum, cost)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+// Package usagehistory persists periodic usage samples (quota utilization and
+// spend) so consumption can be charted over time. Samples are appended to a
+// JSONL file and are safe for concurrent use.
+package usagehistory
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MaxAge is how long samples are retained.
+const MaxAge = 30 * 24 * time.Hour
+
+// Sample is a point-in-time usage snapshot.
+type Sample struct {
+	Time                time.Time `json:"t"`
+	FiveHourUtilization float64   `json:"fiveHourUtil,omitempty"`
+	SevenDayUtilization float64   `json:"sevenDayUtil,omitempty"`
+	FiveHourCostUSD     float64   `json:"fiveHourCostUSD,omitempty"`
+	SevenDayCostUSD     float64   `json:"sevenDayCostUSD,omitempty"`
+	OrgSevenDayUSD      float64   `json:"orgSevenDayUSD,omitempty"`
+	// Tasks lists the tasks that spent since the previous sample.
+	Tasks []TaskSpend `json:"tasks,omitempty"`
+}
+
+// TaskSpend is the spend of one task during a sample interval.
+type TaskSpend struct {
+	ID      string  `json:"id"`
+	CostUSD float64 `json:"costUSD"`
+}
+
+// Store holds samples in memory and appends new ones to a file.
+type Store struct {
+	mu      sync.Mutex
+	path    string // empty → in-memory only
+	samples []Sample
+}
+
+// Open loads the samples stored at path, dropping those older than MaxAge.
+// If path is empty, the store operates in-memory only. Unparseable lines are
+// skipped rather than failing startup.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	if path == "" {
+		return s, nil
+	}
+	raw, err := os.ReadFile(path) //nolint:gosec // path comes from os.UserCacheDir
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("usagehistory open %s: %w", path, err)
+	}
+	cutoff := time.Now().Add(-MaxAge)
+	pruned := false
+	sc := bufio.NewScanner(bytes.NewReader(raw))
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		var sm Sample
+		if err := json.Unmarshal(sc.Bytes(), &sm); err != nil || sm.Time.Before(cutoff) {
+			pruned = true
+			continue
+		}
+		s.samples = append(s.samples, sm)
+	}
+	sort.SliceStable(s.samples, func(i, j int) bool { return s.samples[i].Time.Before(s.samples[j].Time) })
+	if pruned {
+		if err := s.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add records sm and appends it to the file.
+func (s *Store) Add(sm *Sample) error {
+	line, err := json.Marshal(sm)
+	if err != nil {
+		return fmt.Errorf("usagehistory marshal: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = append(s.samples, *sm)
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("usagehistory mkdir: %w", err)
+	}
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("usagehistory append: %w", err)
+	}
+	_, err = f.Write(append(line, '\n'))
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return err
+}
+
+// Since returns the samples taken after t, oldest first.
+func (s *Store) Since(t time.Time) []Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := sort.Search(len(s.samples), func(i int) bool { return s.samples[i].Time.After(t) })
+	return append([]Sample(nil), s.samples[i:]...)
+}
+
+// Last returns the most recent sample, if any.
+func (s *Store) Last() (Sample, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.samples) == 0 {
+		return Sample{}, false
+	}
+	return s.samples[len(s.samples)-1], true
+}
+
+// rewrite replaces the file with the in-memory samples atomically. Must be
+// called with s.mu held or before s is shared.
+func (s *Store) rewrite() error {
+	var b bytes.Buffer
+	for i := range s.samples {
+		line, err := json.Marshal(&s.samples[i])
+		if err != nil {
+			return fmt.Errorf("usagehistory marshal: %w", err)
+		}
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("usagehistory write: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("usagehistory rename: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package usagehistory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("Persist", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "usage_history.jsonl")
+		s, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := time.Now().UTC()
+		for i := range 3 {
+			sm := Sample{Time: now.Add(time.Duration(i-3) * time.Hour), SevenDayCostUSD: float64(i)}
+			if i == 2 {
+				sm.Tasks = []TaskSpend{{ID: "t1", CostUSD: 1.5}}
+			}
+			if err := s.Add(&sm); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s2, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := s2.Since(now.Add(-150 * time.Minute))
+		if len(got) != 2 || got[1].SevenDayCostUSD != 2 || len(got[1].Tasks) != 1 {
+			t.Fatalf("Since = %+v", got)
+		}
+		if last, ok := s2.Last(); !ok || last.Tasks[0].ID != "t1" {
+			t.Errorf("Last = %+v, %v", last, ok)
+		}
+	})
+	t.Run("Prune", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "usage_history.jsonl")
+		old := time.Now().Add(-MaxAge - time.Hour).UTC().Format(time.RFC3339)
+		recent := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+		data := `{"t":"` + old + `"}` + "\n" + "garbage\n" + `{"t":"` + recent + `","sevenDayCostUSD":3}` + "\n"
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		s, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Since(time.Time{}); len(got) != 1 || got[0].SevenDayCostUSD != 3 {
+			t.Fatalf("Since = %+v", got)
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(string(raw), "\n"); n != 1 {
+			t.Errorf("file has %d lines after prune, want 1", n)
+		}
+	})
+	t.Run("InMemory", func(t *testing.T) {
+		s, err := Open("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := s.Last(); ok {
+			t.Error("empty store has a sample")
+		}
+		if err := s.Add(&Sample{Time: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.Since(time.Time{}); len(got) != 1 {
+			t.Errorf("len = %d", len(got))
+		}
+	})
+}
@@ -1,6 +1,7 @@
 // Service worker for caic PWA.
 // Network-first strategy: always prefer fresh content, fall back to cache.
-// Hashed /assets/* files are cached aggressively (immutable).
+// Hashed assets/* files are cached aggressively (immutable). Paths are relative
+// to the registration scope, the server's base path.
 
 const CACHE = "caic-v1";
 
@@ -13,14 +14,18 @@ self.addEventListener("activate", (e) => {
   );
 });
 
+// Path of the server's base path, e.g. "/" or "/caic/".
+const SCOPE = new URL(self.registration.scope).pathname;
+
 self.addEventListener("fetch", (e) => {
   const url = new URL(e.request.url);
+  const path = url.pathname.startsWith(SCOPE) ? url.pathname.slice(SCOPE.length - 1) : url.pathname;
 
   // Never cache API calls or SSE streams.
-  if (url.pathname.startsWith("/api/")) return;
+  if (path.startsWith("/api/")) return;
 
   // Hashed assets: cache-first (immutable content).
-  if (url.pathname.startsWith("/assets/")) {
+  if (path.startsWith("/assets/")) {
     e.respondWith(
       caches.open(CACHE).then((cache) =>
         cache.match(e.request).then(
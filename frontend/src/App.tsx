@@ -21,6 +21,7 @@ import TailscaleIcon from "./tailscale.svg?solid";
 import CloneRepoDialog from "./CloneRepoDialog";
 import VoiceOverlay from "./VoiceOverlay";
 import styles from "./App.module.css";
+import { routePath } from "./basePath";
 
 /** Max slug length in the URL (characters after the "+"). */
 const MAX_SLUG = 80;
@@ -148,8 +149,8 @@ export default function App() {
   {
     const onKey = (e: KeyboardEvent) => {
       if (e.key === "Escape" && selectedId() !== null) {
-        if (isDiffPath(location.pathname)) {
-          navigate(location.pathname.replace(/\/diff$/, ""));
+        if (isDiffPath(routePath(location.pathname))) {
+          navigate(routePath(location.pathname).replace(/\/diff$/, ""));
         } else {
           navigate("/");
           promptRef?.focus();
@@ -189,7 +190,7 @@ export default function App() {
     onCleanup(() => clearInterval(timer));
   }
 
-  const selectedId = (): string | null => taskIdFromPath(location.pathname);
+  const selectedId = (): string | null => taskIdFromPath(routePath(location.pathname));
   const selectedTask = (): Task | null => {
     const id = selectedId();
     return id !== null ? (tasks().find((t) => t.id === id) ?? null) : null;
@@ -323,7 +324,7 @@ export default function App() {
     /** Probe whether the server is returning 401. EventSource doesn't expose status codes. */
     async function checkUnauthorized(): Promise<boolean> {
       try {
-        const res = await fetch("/api/v1/auth/me", { signal: AbortSignal.timeout(5000) });
+        const res = await fetch("api/v1/auth/me", { signal: AbortSignal.timeout(5000) });
         if (res.status === 401) {
           auth.clearUser();
           return true;
@@ -340,7 +341,7 @@ export default function App() {
     }
 
     function connectTasks() {
-      taskES = new EventSource("/api/v1/server/tasks/events");
+      taskES = new EventSource("api/v1/server/tasks/events");
       taskES.addEventListener("open", () => {
         onOpen();
         taskDelay = 500;
@@ -431,7 +432,7 @@ export default function App() {
     }
 
     function connectUsage() {
-      usageES = new EventSource("/api/v1/server/usage/events");
+      usageES = new EventSource("api/v1/server/usage/events");
       usageES.addEventListener("open", () => {
         onOpen();
         usageDelay = 500;
@@ -906,7 +907,7 @@ export default function App() {
         />
 
         <Switch>
-          <Match when={isDiffPath(location.pathname) && selectedId()} keyed>
+          <Match when={isDiffPath(routePath(location.pathname)) && selectedId()} keyed>
             {(id) => {
               const t = selectedTask();
               const tp = t ? taskPath(t.id, t.repos?.[0]?.name ?? "", t.repos?.[0]?.branch ?? "", t.title) : `/task/@${id}`;
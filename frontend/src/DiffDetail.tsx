@@ -47,6 +47,7 @@ interface Props {
 export default function DiffDetail(props: Props) {
   const navigate = useNavigate();
   const [fullDiff, setFullDiff] = createSignal<string | null>(null);
+  const [masked, setMasked] = createSignal(0);
   const [error, setError] = createSignal<string | null>(null);
   const [loading, setLoading] = createSignal(true);
   // Collapsed files (all expanded by default).
@@ -58,7 +59,10 @@ export default function DiffDetail(props: Props) {
     setError(null);
     setCollapsedFiles(new Set());
     getTaskDiff(id)
-      .then((d) => setFullDiff(d.diff))
+      .then((d) => {
+        setFullDiff(d.diff);
+        setMasked(d.masked ?? 0);
+      })
       .catch((e) => setError(e instanceof Error ? e.message : "Unknown error"))
       .finally(() => setLoading(false));
   });
@@ -94,6 +98,11 @@ export default function DiffDetail(props: Props) {
         <span class={styles.headerMeta}>
           <span class={styles.headerRepo}>{props.repo}</span>
           <span class={styles.headerBranch}>{props.branch}</span>
+          <Show when={masked() > 0}>
+            <span class={styles.headerMasked} title="Secrets found by the safety scanner are masked">
+              {masked()} {masked() === 1 ? "secret" : "secrets"} masked
+            </span>
+          </Show>
         </span>
       </div>
       <div class={styles.fileList}>
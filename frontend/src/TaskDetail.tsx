@@ -20,6 +20,7 @@ import GitHubIcon from "./github.svg?solid";
 import GitLabIcon from "./gitlab.svg?solid";
 import WidgetCard from "./WidgetCard";
 import styles from "./TaskDetail.module.css";
+import { routePath } from "./basePath";
 
 // Module-level store for <details> open/closed state (tool calls, thinking blocks).
 // Keys: toolUseID, "group:<firstToolUseID>", "thinking:<firstEventTs>".
@@ -561,7 +562,7 @@ export default function TaskDetail(props: Props) {
           </Show>
         </span>
         <Show when={(props.diffStat?.length ?? 0) > 0}>
-          <A class={styles.diffLink} href={`${location.pathname}/diff`}>Diff</A>
+          <A class={styles.diffLink} href={`${routePath(location.pathname)}/diff`}>Diff</A>
         </Show>
         <Show when={props.inPlanMode}>
           <span class={styles.planIndicator} title="Agent is in plan mode">Plan Mode</span>
@@ -783,6 +784,13 @@ function SessionBoundaryItem(props: { event: EventMessage }) {
   );
 }
 
+// Labels of the system events reporting agent or relay failures seen on stderr.
+const STDERR_FAILURE_LABEL: Record<string, string> = {
+  auth_error: "Authentication failed",
+  agent_crash: "Agent crashed",
+  relay_traceback: "Relay error",
+};
+
 function MessageItem(props: { ev: EventMessage }) {
   return (
     <Switch>
@@ -792,6 +800,16 @@ function MessageItem(props: { ev: EventMessage }) {
       <Match when={props.ev.system?.subtype === "api_error"}>
         <div class={styles.parseError}>API error</div>
       </Match>
+      <Match when={STDERR_FAILURE_LABEL[props.ev.system?.subtype ?? ""]} keyed>
+        {(label) => (
+          <div class={styles.parseError}>
+            {label}
+            <Show when={props.ev.system?.detail}>
+              {(detail) => <pre class={styles.stderrDetail}>{detail()}</pre>}
+            </Show>
+          </div>
+        )}
+      </Match>
       <Match when={props.ev.system?.subtype === "step_start"}>
         {/* suppress: no useful content */}
       </Match>
@@ -836,6 +854,11 @@ function MessageItem(props: { ev: EventMessage }) {
           </div>
         )}
       </Match>
+      <Match when={props.ev.stderr} keyed>
+        {(stderr) => (
+          <div class={styles.logLine}>{stderr.source}: {stderr.line}</div>
+        )}
+      </Match>
       <Match when={props.ev.log} keyed>
         {(log) => (
           <div class={styles.logLine}>{log.line}</div>
@@ -870,18 +893,22 @@ function DiffStatBlock(props: { files: DiffFileStat[] }) {
   const navigate = useNavigate();
   const location = useLocation();
   return (
-    <div class={`${styles.resultDiffStat} ${styles.diffFileClickable}`} role="button" tabIndex={0} onClick={() => navigate(`${location.pathname}/diff`)} onKeyDown={(e) => { if (e.key === "Enter" || e.key === " ") { e.preventDefault(); navigate(`${location.pathname}/diff`); } }}>
+    <div class={`${styles.resultDiffStat} ${styles.diffFileClickable}`} role="button" tabIndex={0} onClick={() => navigate(`${routePath(location.pathname)}/diff`)} onKeyDown={(e) => { if (e.key === "Enter" || e.key === " ") { e.preventDefault(); navigate(`${routePath(location.pathname)}/diff`); } }}>
       <For each={props.files}>
         {(f) => (
           <div class={styles.diffFile}>
             <span class={styles.diffPath}>{f.path}</span>
-            <Show when={f.binary} fallback={
-              <span class={styles.diffCounts}>
-                <Show when={f.added > 0}><span class={styles.diffAdded}>+{f.added}</span></Show>
-                <Show when={f.deleted > 0}><span class={styles.diffDeleted}>&minus;{f.deleted}</span></Show>
-              </span>
+            <Show when={f.submodule} fallback={
+              <Show when={f.binary || f.lfs} fallback={
+                <span class={styles.diffCounts}>
+                  <Show when={f.added > 0}><span class={styles.diffAdded}>+{f.added}</span></Show>
+                  <Show when={f.deleted > 0}><span class={styles.diffDeleted}>&minus;{f.deleted}</span></Show>
+                </span>
+              }>
+                <span class={styles.diffBinary}>{f.lfs ? "LFS" : "binary"}</span>
+              </Show>
             }>
-              <span class={styles.diffBinary}>binary</span>
+              {(s) => <span class={styles.diffBinary}>submodule {s().from?.slice(0, 7) || "new"} &rarr; {s().to?.slice(0, 7) || "removed"}</span>}
             </Show>
           </div>
         )}
@@ -0,0 +1,10 @@
+// URL prefix the server is mounted under, taken from the <base href> it serves.
+
+/** The server's base path without trailing slash, e.g. "" or "/caic". */
+export const basePath = new URL(document.baseURI).pathname.replace(/\/$/, "");
+
+/** Returns pathname without basePath, e.g. "/caic/task/@x" → "/task/@x". */
+export function routePath(pathname: string): string {
+  if (basePath === "" || !pathname.startsWith(basePath)) return pathname;
+  return pathname.slice(basePath.length) || "/";
+}
@@ -53,6 +53,8 @@ export function stateColor(state: string): string {
       return "#e2e3e5";
     case "stopped":
       return "#c8daf0";
+    case "paused":
+      return "#dbe4ee";
     default:
       return "#fff3cd";
   }
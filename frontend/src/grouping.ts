@@ -208,6 +208,9 @@ export function groupMessages(msgs: EventMessage[]): MessageGroup[] {
       case "diffStat":
         // Metadata-only; live diff stat shown in the task list via Task.diffStat.
         break;
+      case "stateChange":
+        // Metadata-only; the task state is shown in the task list via Task.state.
+        break;
       case "thinking": {
         // A final thinking event replaces any preceding thinkingDelta in the same action group.
         const last = lastGroup();
@@ -3,13 +3,14 @@ import { render } from "solid-js/web";
 import { Router, Route } from "@solidjs/router";
 import App from "./App";
 import { AuthProvider } from "./AuthContext";
+import { basePath } from "./basePath";
 
 const root = document.getElementById("app");
 if (root) {
   render(
     () => (
       <AuthProvider>
-        <Router explicitLinks>
+        <Router explicitLinks base={basePath}>
           <Route path="*" component={App} />
         </Router>
       </AuthProvider>
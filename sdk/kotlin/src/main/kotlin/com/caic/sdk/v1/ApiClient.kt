@@ -89,33 +89,94 @@ class ApiClient(
     suspend fun logout(): StatusResp = request("POST", "/api/v1/auth/logout")
     suspend fun getPreferences(): PreferencesResp = request("GET", "/api/v1/server/preferences")
     suspend fun updatePreferences(req: UpdatePreferencesReq): PreferencesResp = request("POST", "/api/v1/server/preferences", json.encodeToString(req))
+    suspend fun getSpending(): SpendingResp = request("GET", "/api/v1/server/spending")
+    suspend fun overrideSpending(req: SpendingOverrideReq): SpendingResp = request("POST", "/api/v1/server/spending/override", json.encodeToString(req))
     suspend fun listHarnesses(): List<HarnessInfo> = request("GET", "/api/v1/server/harnesses")
+    suspend fun listAgentVersions(): AgentVersionsResp = request("GET", "/api/v1/server/harnesses/versions")
+    suspend fun getServerStatus(): ServerStatusResp = request("GET", "/api/v1/server/status")
+    suspend fun getDoctor(): DoctorResp = request("GET", "/api/v1/server/doctor")
     suspend fun listCaches(): WellKnownCachesResp = request("GET", "/api/v1/server/caches")
+    suspend fun listImages(): ImagesResp = request("GET", "/api/v1/server/images")
+    suspend fun listContainers(): ContainersResp = request("GET", "/api/v1/server/containers")
     suspend fun listRepos(): List<Repo> = request("GET", "/api/v1/server/repos")
     suspend fun cloneRepo(req: CloneRepoReq): Repo = request("POST", "/api/v1/server/repos", json.encodeToString(req))
     suspend fun listRepoBranches(repo: String): RepoBranchesResp = request("GET", "/api/v1/server/repos/branches?repo=$repo")
+    suspend fun pruneRepoBranches(req: PruneBranchesReq): PruneBranchesResp = request("POST", "/api/v1/server/repos/branches/prune", json.encodeToString(req))
+    suspend fun getCostReport(): CostReportResp = request("GET", "/api/v1/server/costs")
+    suspend fun getRepoHeatmap(repo: String, limit: String): RepoHeatmapResp = request("GET", "/api/v1/server/repos/heatmap?repo=$repo&limit=$limit")
+    suspend fun getRepoTools(repo: String): RepoToolsResp = request("GET", "/api/v1/server/repos/tools?repo=$repo")
+    suspend fun getRepoKnowledge(repo: String): RepoKnowledgeResp = request("GET", "/api/v1/server/repos/knowledge?repo=$repo")
+    suspend fun updateRepoKnowledge(req: UpdateRepoKnowledgeReq): RepoKnowledgeResp = request("POST", "/api/v1/server/repos/knowledge", json.encodeToString(req))
+    suspend fun getRepoOverview(repo: String): RepoOverviewResp = request("GET", "/api/v1/server/repos/overview?repo=$repo")
+    suspend fun getRepoMap(repo: String): RepoMapResp = request("GET", "/api/v1/server/repos/map?repo=$repo")
+    suspend fun refreshRepoMap(req: RefreshRepoMapReq): RepoMapResp = request("POST", "/api/v1/server/repos/map/refresh", json.encodeToString(req))
     suspend fun botFixCI(req: BotFixCIReq): CreateTaskResp = request("POST", "/api/v1/bot/fix-ci", json.encodeToString(req))
     suspend fun botFixPR(req: BotFixPRReq): StatusResp = request("POST", "/api/v1/bot/fix-pr", json.encodeToString(req))
+    suspend fun listDrafts(): List<Draft> = request("GET", "/api/v1/drafts")
+    suspend fun createDraft(req: CreateTaskReq): Draft = request("POST", "/api/v1/drafts", json.encodeToString(req))
+    suspend fun startDrafts(req: StartDraftsReq): StartDraftsResp = request("POST", "/api/v1/drafts/start", json.encodeToString(req))
+    suspend fun updateDraft(id: String, req: CreateTaskReq): Draft = request("POST", "/api/v1/drafts/$id", json.encodeToString(req))
+    suspend fun deleteDraft(id: String): StatusResp = request("POST", "/api/v1/drafts/$id/delete")
+    suspend fun startDraft(id: String): CreateTaskResp = request("POST", "/api/v1/drafts/$id/start")
+    suspend fun getEventSchema(): EventSchemaResp = request("GET", "/api/v1/events/schema")
+    suspend fun listEvals(): List<EvalRun> = request("GET", "/api/v1/evals")
+    suspend fun createEval(req: CreateEvalReq): EvalRun = request("POST", "/api/v1/evals", json.encodeToString(req))
+    suspend fun getEval(id: String): EvalRun = request("GET", "/api/v1/evals/$id")
     suspend fun listTasks(): List<Task> = request("GET", "/api/v1/tasks")
     suspend fun createTask(req: CreateTaskReq): CreateTaskResp = request("POST", "/api/v1/tasks", json.encodeToString(req))
+    suspend fun validateTask(req: CreateTaskReq): ValidateTaskResp = request("POST", "/api/v1/tasks/validate", json.encodeToString(req))
+    suspend fun listLabeledTasks(outcome: String, harness: String, model: String): List<Task> = request("GET", "/api/v1/tasks/labeled?outcome=$outcome&harness=$harness&model=$model")
+    suspend fun bulkTasks(req: BulkTasksReq): BulkTasksResp = request("POST", "/api/v1/tasks/bulk", json.encodeToString(req))
+    suspend fun getTaskEventRange(id: String, from: String, to: String): TaskEventRangeResp = request("GET", "/api/v1/tasks/$id/events/range?from=$from&to=$to")
     suspend fun sendInput(id: String, req: InputReq): StatusResp = request("POST", "/api/v1/tasks/$id/input", json.encodeToString(req))
     suspend fun restartTask(id: String, req: RestartReq): StatusResp = request("POST", "/api/v1/tasks/$id/restart", json.encodeToString(req))
     suspend fun stopTask(id: String): StatusResp = request("POST", "/api/v1/tasks/$id/stop")
     suspend fun purgeTask(id: String): StatusResp = request("POST", "/api/v1/tasks/$id/purge")
     suspend fun reviveTask(id: String): StatusResp = request("POST", "/api/v1/tasks/$id/revive")
+    suspend fun pauseTask(id: String): StatusResp = request("POST", "/api/v1/tasks/$id/pause")
+    suspend fun resumeTask(id: String): StatusResp = request("POST", "/api/v1/tasks/$id/resume")
+    suspend fun handoffTask(id: String): HandoffResp = request("POST", "/api/v1/tasks/$id/handoff")
+    suspend fun importHandoff(id: String): ImportHandoffResp = request("POST", "/api/v1/tasks/$id/handoff/import")
+    suspend fun cleanTask(id: String): CleanTaskResp = request("POST", "/api/v1/tasks/$id/clean")
     suspend fun getTaskCILog(id: String, jobID: String): CILogResp = request("GET", "/api/v1/tasks/$id/ci-log?jobID=$jobID")
     suspend fun syncTask(id: String, req: SyncReq): SyncResp = request("POST", "/api/v1/tasks/$id/sync", json.encodeToString(req))
+    suspend fun replayTask(id: String, req: ReplayTaskReq): CreateTaskResp = request("POST", "/api/v1/tasks/$id/replay", json.encodeToString(req))
+    suspend fun labelTask(id: String, req: LabelTaskReq): Task = request("POST", "/api/v1/tasks/$id/label", json.encodeToString(req))
+    suspend fun shareTask(id: String, req: ShareTaskReq): ShareTaskResp = request("POST", "/api/v1/tasks/$id/share", json.encodeToString(req))
+    suspend fun getSharedTask(token: String): Task = request("GET", "/api/v1/shared/$token")
+    suspend fun getSharedTaskEventRange(token: String, from: String, to: String): TaskEventRangeResp = request("GET", "/api/v1/shared/$token/events/range?from=$from&to=$to")
+    suspend fun getSharedTaskDiff(token: String): DiffResp = request("GET", "/api/v1/shared/$token/diff")
+    suspend fun applyTask(id: String, req: ApplyTaskReq): ApplyTaskResp = request("POST", "/api/v1/tasks/$id/apply", json.encodeToString(req))
     suspend fun getTaskDiff(id: String): DiffResp = request("GET", "/api/v1/tasks/$id/diff")
+    suspend fun getTaskCommits(id: String): TaskCommitsResp = request("GET", "/api/v1/tasks/$id/commits")
+    suspend fun getTaskSummary(id: String): TaskSummaryResp = request("GET", "/api/v1/tasks/$id/summary")
+    suspend fun summarizeTask(id: String, req: TaskSummaryReq): TaskSummaryResp = request("POST", "/api/v1/tasks/$id/summary", json.encodeToString(req))
+    suspend fun getTaskUsage(id: String): TaskUsageResp = request("GET", "/api/v1/tasks/$id/usage")
+    suspend fun getTaskTools(id: String): TaskToolsResp = request("GET", "/api/v1/tasks/$id/tools")
+    suspend fun getTaskResources(id: String): TaskResourcesResp = request("GET", "/api/v1/tasks/$id/resources")
+    suspend fun getTaskEnv(id: String): TaskEnvResp = request("GET", "/api/v1/tasks/$id/env")
+    suspend fun listAnnotations(id: String): TaskAnnotationsResp = request("GET", "/api/v1/tasks/$id/annotations")
+    suspend fun annotateTask(id: String, req: AnnotateReq): Annotation = request("POST", "/api/v1/tasks/$id/annotations", json.encodeToString(req))
+    suspend fun deleteAnnotation(id: String, annotationID: String): StatusResp = request("POST", "/api/v1/tasks/$id/annotations/$annotationID/delete")
+    suspend fun getTaskCommands(id: String): TaskCommandsResp = request("GET", "/api/v1/tasks/$id/commands")
+    suspend fun getTaskTodos(id: String): TaskTodosResp = request("GET", "/api/v1/tasks/$id/todos")
+    suspend fun getTaskDeadLetters(id: String): TaskDeadLettersResp = request("GET", "/api/v1/tasks/$id/dead-letters")
     suspend fun getTaskToolInput(id: String, toolUseID: String): TaskToolInputResp = request("GET", "/api/v1/tasks/$id/tool/$toolUseID")
+    suspend fun updateFeatures(req: FeatureFlags): FeatureFlags = request("POST", "/api/v1/server/features", json.encodeToString(req))
     suspend fun getUsage(): UsageResp = request("GET", "/api/v1/usage")
+    suspend fun getUsageHistory(window: String): UsageHistoryResp = request("GET", "/api/v1/usage/history?window=$window")
+    suspend fun search(q: String, limit: String): SearchResp = request("GET", "/api/v1/search?q=$q&limit=$limit")
     suspend fun getVoiceToken(): VoiceTokenResp = request("GET", "/api/v1/voice/token")
     suspend fun webFetch(req: WebFetchReq): WebFetchResp = request("POST", "/api/v1/web/fetch", json.encodeToString(req))
 
     // SSE endpoints
     fun taskRawEvents(id: String): Flow<EventMessage> = sseFlow<EventMessage>("/api/v1/tasks/$id/raw_events")
     fun taskEvents(id: String): Flow<EventMessage> = sseFlow<EventMessage>("/api/v1/tasks/$id/events")
+    fun sharedTaskEvents(token: String): Flow<EventMessage> = sseFlow<EventMessage>("/api/v1/shared/$token/events")
     fun globalTaskEvents(): Flow<TaskListEvent> = sseFlow<TaskListEvent>("/api/v1/server/tasks/events")
+    fun configEvents(): Flow<ConfigEvent> = sseFlow<ConfigEvent>("/api/v1/server/config/events")
     fun globalUsageEvents(): Flow<UsageResp> = sseFlow<UsageResp>("/api/v1/server/usage/events")
+    fun serverLogEvents(level: String): Flow<ServerLogEntry> = sseFlow<ServerLogEntry>("/api/v1/server/logs/events?level=$level")
 
     private inline fun <reified T> sseFlow(path: String): Flow<T> = callbackFlow {
         val request = Request.Builder()
@@ -146,8 +207,11 @@ class ApiClient(
     // Reconnecting SSE wrappers with exponential backoff.
     fun taskRawEventsReconnecting(id: String): Flow<EventMessage> = reconnectingFlow { taskRawEvents(id) }
     fun taskEventsReconnecting(id: String): Flow<EventMessage> = reconnectingFlow { taskEvents(id) }
+    fun sharedTaskEventsReconnecting(token: String): Flow<EventMessage> = reconnectingFlow { sharedTaskEvents(token) }
     fun globalTaskEventsReconnecting(): Flow<TaskListEvent> = reconnectingFlow { globalTaskEvents() }
+    fun configEventsReconnecting(): Flow<ConfigEvent> = reconnectingFlow { configEvents() }
     fun globalUsageEventsReconnecting(): Flow<UsageResp> = reconnectingFlow { globalUsageEvents() }
+    fun serverLogEventsReconnecting(level: String): Flow<ServerLogEntry> = reconnectingFlow { serverLogEvents(level) }
 
     private fun <T> reconnectingFlow(connect: () -> Flow<T>): Flow<T> = flow {
         var delayMs = 500L
@@ -28,6 +28,7 @@ object EventKinds {
     const val UserInput: EventKind = "userInput"
     const val Todo: EventKind = "todo"
     const val DiffStat: EventKind = "diffStat"
+    const val Error: EventKind = "error"
     const val Thinking: EventKind = "thinking"
     const val ThinkingDelta: EventKind = "thinkingDelta"
     const val SubagentStart: EventKind = "subagentStart"
@@ -36,22 +37,37 @@ object EventKinds {
     const val ToolOutputDelta: EventKind = "toolOutputDelta"
     const val Widget: EventKind = "widget"
     const val WidgetDelta: EventKind = "widgetDelta"
+    const val Status: EventKind = "status"
+    const val Warning: EventKind = "warning"
+    const val Stderr: EventKind = "stderr"
+    const val StateChange: EventKind = "stateChange"
 }
 
 object ErrorCodes {
     const val BadRequest = "BAD_REQUEST"
     const val NotFound = "NOT_FOUND"
     const val Conflict = "CONFLICT"
+    const val RateLimited = "RATE_LIMITED"
+    const val PolicyViolation = "POLICY_VIOLATION"
     const val InternalError = "INTERNAL_ERROR"
 }
 
+@Serializable
+data class FeatureFlags(
+    val planMode: Boolean? = null,
+    val reviewerStage: Boolean? = null,
+    val fanOut: Boolean? = null,
+)
+
 @Serializable
 data class Config(
     val tailscaleAvailable: Boolean,
     val usbAvailable: Boolean,
     val displayAvailable: Boolean,
+    val gpuCount: Int? = null,
     val gitHubAppEnabled: Boolean? = null,
     val authProviders: List<String>? = null,
+    val features: FeatureFlags,
 )
 
 @Serializable
@@ -98,6 +114,21 @@ data class PreferencesResp(
 @Serializable
 data class UpdatePreferencesReq(val settings: UserSettings)
 
+@Serializable
+data class SpendingLimit(
+    val harness: Harness? = null,
+    val window: String,
+    @SerialName("limitUSD") val limitUSD: Double,
+    @SerialName("spentUSD") val spentUSD: Double,
+    val exceeded: Boolean,
+)
+
+@Serializable
+data class SpendingResp(val limits: List<SpendingLimit>, val overrideUntil: Double? = null)
+
+@Serializable
+data class SpendingOverrideReq(val duration: String)
+
 @Serializable
 data class HarnessInfo(
     val name: String,
@@ -105,6 +136,67 @@ data class HarnessInfo(
     val supportsImages: Boolean,
 )
 
+@Serializable
+data class AgentVersionCount(val version: String, val tasks: Int)
+
+@Serializable
+data class RepoAgentVersion(val repo: String, val version: String)
+
+@Serializable
+data class AgentVersions(
+    val harness: String,
+    val package: String? = null,
+    val running: List<AgentVersionCount>,
+    val pinned: List<RepoAgentVersion>,
+    val latest: String? = null,
+    val latestCheckedAt: Double? = null,
+    val updateAvailable: Boolean? = null,
+)
+
+@Serializable
+data class AgentVersionsResp(val harnesses: List<AgentVersions>)
+
+@Serializable
+data class SchemaDrift(
+    val harness: String,
+    val type: String,
+    val field: String? = null,
+    val count: Long,
+    val lastAt: Double,
+)
+
+@Serializable
+data class RuntimeStatus(
+    val name: String? = null,
+    val version: String? = null,
+    val md: String,
+    val unsupported: List<String>? = null,
+)
+
+@Serializable
+data class ProcessStatus(
+    val heapBytes: uint64,
+    val sysBytes: uint64,
+    val goroutines: Int,
+)
+
+@Serializable
+data class ServerStatusResp(
+    val drift: List<SchemaDrift>,
+    val runtime: RuntimeStatus,
+    val process: ProcessStatus,
+)
+
+@Serializable
+data class DoctorCheck(
+    val name: String,
+    val status: String,
+    val message: String,
+)
+
+@Serializable
+data class DoctorResp(val checks: List<DoctorCheck>)
+
 @Serializable
 data class WellKnownCache(
     val name: String,
@@ -115,6 +207,42 @@ data class WellKnownCache(
 @Serializable
 data class WellKnownCachesResp(val harnessMounts: List<String>, val wellKnown: List<WellKnownCache>)
 
+@Serializable
+data class CachedImage(
+    val ref: String,
+    val present: Boolean,
+    val sizeBytes: Long? = null,
+    val lastPulledAt: Double? = null,
+    val lastAttemptAt: Double? = null,
+    val lastDuration: Double? = null,
+    val lastError: String? = null,
+)
+
+@Serializable
+data class ImagesResp(
+    val totalBytes: Long,
+    val interval: Double,
+    val images: List<CachedImage>,
+)
+
+@Serializable
+data class ContainerInfo(
+    val name: String,
+    val state: String,
+    val createdAt: Double,
+    @SerialName("taskID") val taskID: String,
+    val harness: String? = null,
+    val repo: String? = null,
+    val branch: String? = null,
+    val owner: String? = null,
+    val taskCreatedAt: Double? = null,
+    val taskState: String? = null,
+    val orphan: Boolean,
+)
+
+@Serializable
+data class ContainersResp(val containers: List<ContainerInfo>)
+
 @Serializable
 data class ForgeCheck(
     val name: String,
@@ -144,35 +272,333 @@ data class CloneRepoReq(
     val url: String,
     val path: String? = null,
     val depth: Int? = null,
+    val filter: String? = null,
 )
 
 @Serializable
 data class RepoBranchesResp(val branches: List<String>)
 
+@Serializable
+data class PruneBranchesReq(val repo: String, val dryRun: Boolean? = null)
+
+@Serializable
+data class TaskBranch(
+    val name: String,
+    val committedAt: Double,
+    val merged: Boolean,
+    val inUse: Boolean,
+    val pruned: Boolean,
+)
+
+@Serializable
+data class PruneBranchesResp(
+    val repo: String,
+    val dryRun: Boolean? = null,
+    val retention: String? = null,
+    val branches: List<TaskBranch>,
+)
+
+@Serializable
+data class CostGroup(
+    val harness: Harness,
+    val model: String? = null,
+    val tasks: Int,
+    @SerialName("costUSD") val costUSD: Double,
+    val labeled: Int,
+    val accepted: Int,
+    val partial: Int,
+    val rejected: Int,
+    val acceptanceRate: Double,
+    val costPerAccepted: Double,
+)
+
+@Serializable
+data class CostReportResp(
+    @SerialName("costUSD") val costUSD: Double,
+    val groups: List<CostGroup>,
+)
+
+@Serializable
+data class HeatmapFile(
+    val path: String,
+    val tasks: Int,
+    val failed: Int,
+    val added: Int,
+    val deleted: Int,
+    val failureRate: Double,
+)
+
+@Serializable
+data class RepoHeatmapResp(
+    val repo: String,
+    val tasks: Int,
+    val failed: Int,
+    val files: List<HeatmapFile>,
+)
+
+@Serializable
+data class ToolStats(
+    val name: String,
+    val calls: Int,
+    val failures: Int,
+    val failureRate: Double,
+    val duration: Double,
+    val share: Double,
+)
+
+@Serializable
+data class RepoToolsResp(
+    val repo: String,
+    val tasks: Int,
+    val duration: Double,
+    val tools: List<ToolStats>,
+)
+
+@Serializable
+data class RepoKnowledgeResp(
+    val repo: String,
+    val content: String,
+    val maxBytes: Int,
+)
+
+@Serializable
+data class UpdateRepoKnowledgeReq(val repo: String, val content: String)
+
+@Serializable
+data class RepoOverviewResp(
+    val repo: String,
+    val content: String,
+    @SerialName("taskID") val taskID: String? = null,
+    val generatedAt: Double? = null,
+)
+
+@Serializable
+data class RepoMapResp(
+    val repo: String,
+    val enabled: Boolean,
+    val indexing: Boolean,
+    val error: String? = null,
+    val sha: String? = null,
+    val generatedAt: Double? = null,
+    val files: Int,
+    val symbols: Int,
+    val content: String,
+)
+
+@Serializable
+data class RefreshRepoMapReq(val repo: String)
+
 @Serializable
 data class BotFixCIReq(val repo: String)
 
 @Serializable
-data class CreateTaskResp(val status: String, val id: String)
+data class ContextCut(
+    val name: String,
+    val tokens: Int,
+    val kept: Int,
+)
+
+@Serializable
+data class CreateTaskResp(
+    val status: String,
+    val id: String,
+    val contextCuts: List<ContextCut>? = null,
+)
 
 @Serializable
 data class BotFixPRReq(val taskId: String)
 
+@Serializable
+data class ImageData(val mediaType: String, val data: String)
+
+@Serializable
+data class Prompt(val text: String, val images: List<ImageData>? = null)
+
+@Serializable
+data class RepoSpec(
+    val name: String,
+    val baseBranch: String? = null,
+    val paths: List<String>? = null,
+)
+
+@Serializable
+data class PromptContext(
+    val tasks: List<String>? = null,
+    val urls: List<String>? = null,
+    val files: List<String>? = null,
+)
+
+@Serializable
+data class AskPolicy(
+    val timeout: Double,
+    val action: String,
+    val answer: String? = null,
+)
+
+@Serializable
+data class CreateTaskReq(
+    val initialPrompt: Prompt,
+    val repos: List<RepoSpec>? = null,
+    val model: String? = null,
+    val harness: Harness,
+    val image: String? = null,
+    val tailscale: Boolean? = null,
+    val usb: Boolean? = null,
+    val display: Boolean? = null,
+    val gpu: Boolean? = null,
+    val priority: String? = null,
+    val context: PromptContext? = null,
+    val kind: String? = null,
+    val autoResume: Boolean? = null,
+    val askPolicy: AskPolicy? = null,
+    val automation: String? = null,
+)
+
+@Serializable
+data class Draft(
+    val id: String,
+    val title: String,
+    val task: CreateTaskReq,
+    val createdAt: Double,
+    val updatedAt: Double,
+)
+
+@Serializable
+data class StartDraftsReq(val ids: List<String>)
+
+@Serializable
+data class StartDraftResult(
+    val draftId: String,
+    val taskId: String? = null,
+    val error: String? = null,
+)
+
+@Serializable
+data class StartDraftsResp(val results: List<StartDraftResult>)
+
+@Serializable
+data class EventKindSchema(
+    val kind: EventKind,
+    val payload: String,
+    val since: Int,
+)
+
+@Serializable
+data class EventSchemaResp(val version: Int, val kinds: List<EventKindSchema>)
+
+@Serializable
+data class EvalArm(val harness: Harness, val model: String? = null)
+
+@Serializable
+data class EvalArmSummary(
+    val harness: Harness,
+    val model: String? = null,
+    val tasks: Int,
+    val finished: Int,
+    val failed: Int,
+    val verifyPassed: Int,
+    val files: Int,
+    val added: Int,
+    val deleted: Int,
+    @SerialName("costUSD") val costUSD: Double,
+    val duration: Double,
+)
+
+@Serializable
+data class EvalResult(
+    val taskId: String? = null,
+    val error: String? = null,
+    val state: String? = null,
+    val finished: Boolean,
+    val files: Int,
+    val added: Int,
+    val deleted: Int,
+    @SerialName("costUSD") val costUSD: Double,
+    val duration: Double,
+    val verify: String? = null,
+    val verifyOutput: String? = null,
+)
+
+@Serializable
+data class EvalCase(val prompt: String, val results: List<EvalResult>)
+
+@Serializable
+data class EvalRun(
+    val id: String,
+    val name: String? = null,
+    val repo: String,
+    @SerialName("baseSHA") val baseSHA: String,
+    val verify: String? = null,
+    val createdAt: Double,
+    val arms: List<EvalArm>,
+    val summary: List<EvalArmSummary>,
+    val cases: List<EvalCase>? = null,
+)
+
+@Serializable
+data class CreateEvalReq(
+    val name: String? = null,
+    val repo: String,
+    val baseBranch: String? = null,
+    val prompts: List<String>? = null,
+    val suitePath: String? = null,
+    val arms: List<EvalArm>,
+    val verify: String? = null,
+)
+
 @Serializable
 data class TaskRepo(
     val name: String,
     val baseBranch: String? = null,
     val branch: String,
+    @SerialName("baseSHA") val baseSHA: String? = null,
     @SerialName("remoteURL") val remoteURL: String? = null,
     val forge: String? = null,
+    val sparsePaths: List<String>? = null,
 )
 
+@Serializable
+data class SubmoduleBump(val from: String? = null, val to: String? = null)
+
 @Serializable
 data class DiffFileStat(
     val path: String,
     val added: Int,
     val deleted: Int,
     val binary: Boolean? = null,
+    val lfs: Boolean? = null,
+    val submodule: SubmoduleBump? = null,
+)
+
+@Serializable
+data class EventWarning(val kind: String, val detail: String)
+
+@Serializable
+data class TaskLabel(
+    val outcome: String,
+    val reason: String? = null,
+    val source: String,
+    val labeledBy: String? = null,
+    val labeledAt: Double,
+)
+
+@Serializable
+data class DirSize(val path: String, val bytes: Long)
+
+@Serializable
+data class DiskUsage(
+    val usedBytes: Long,
+    val totalBytes: Long,
+    val dirs: List<DirSize>? = null,
+    val checkedAt: Double,
+)
+
+@Serializable
+data class AffectedTargets(
+    val tool: String,
+    val targets: List<String>? = null,
+    val truncated: Boolean? = null,
+    val computedAt: Double,
 )
 
 @Serializable
@@ -210,34 +636,58 @@ data class Task(
     @SerialName("sessionID") val sessionID: String? = null,
     val startedAt: Double? = null,
     val turnStartedAt: Double? = null,
+    val handedOffAt: Double? = null,
+    val kind: String? = null,
     val inPlanMode: Boolean? = null,
     val planContent: String? = null,
     val tailscale: String? = null,
     val usb: Boolean? = null,
     val display: Boolean? = null,
+    val gpu: Boolean? = null,
+    val autoResume: Boolean? = null,
+    val priority: String? = null,
+    val replayOf: String? = null,
+    val askPolicy: AskPolicy? = null,
+    val automation: String? = null,
+    val todosOpen: Int? = null,
+    val todosCompleted: Int? = null,
+    val lastActivityAt: Double? = null,
+    val stuck: Boolean? = null,
+    val warnings: List<EventWarning>? = null,
+    val label: TaskLabel? = null,
+    val image: String? = null,
+    @SerialName("imageID") val imageID: String? = null,
+    val diskUsage: DiskUsage? = null,
+    val scrubbed: Map<String, Int>? = null,
+    val affectedTargets: AffectedTargets? = null,
 )
 
 @Serializable
-data class ImageData(val mediaType: String, val data: String)
+data class PreflightCheck(
+    val name: String,
+    val status: String,
+    val message: String? = null,
+    val code: String? = null,
+)
 
 @Serializable
-data class Prompt(val text: String, val images: List<ImageData>? = null)
+data class ValidateTaskResp(val ready: Boolean, val checks: List<PreflightCheck>)
 
 @Serializable
-data class RepoSpec(val name: String, val baseBranch: String? = null)
+data class BulkTasksReq(val action: String, val ids: List<String>)
 
 @Serializable
-data class CreateTaskReq(
-    val initialPrompt: Prompt,
-    val repos: List<RepoSpec>? = null,
-    val model: String? = null,
-    val harness: Harness,
-    val image: String? = null,
-    val tailscale: Boolean? = null,
-    val usb: Boolean? = null,
-    val display: Boolean? = null,
+data class BulkTaskResult(
+    val id: String,
+    val status: String? = null,
+    val taskId: String? = null,
+    val error: String? = null,
+    val code: String? = null,
 )
 
+@Serializable
+data class BulkTasksResp(val results: List<BulkTaskResult>)
+
 @Serializable
 data class EventInit(
     val model: String,
@@ -268,6 +718,7 @@ data class EventToolResult(
     @SerialName("toolUseID") val toolUseID: String,
     val duration: Double,
     val error: String? = null,
+    val inferred: Boolean? = null,
 )
 
 @Serializable
@@ -330,7 +781,11 @@ data class EventTodo(
 )
 
 @Serializable
-data class EventDiffStat(val diffStat: List<DiffFileStat>? = null)
+data class EventDiffStat(
+    val diffStat: List<DiffFileStat>? = null,
+    @SerialName("toolUseID") val toolUseID: String? = null,
+    @SerialName("headSHA") val headSHA: String? = null,
+)
 
 @Serializable
 data class EventError(val err: String, val line: String)
@@ -375,6 +830,19 @@ data class EventWidgetDelta(
     val delta: String,
 )
 
+@Serializable
+data class EventStatus(
+    val status: String,
+    val tool: String? = null,
+    val until: Double? = null,
+)
+
+@Serializable
+data class EventStderr(val source: String, val line: String)
+
+@Serializable
+data class EventStateChange(val from: String, val to: String)
+
 // Backend-neutral event types
 
 @Serializable
@@ -402,6 +870,18 @@ data class EventMessage(
     val toolOutputDelta: EventToolOutputDelta? = null,
     val widget: EventWidget? = null,
     val widgetDelta: EventWidgetDelta? = null,
+    val status: EventStatus? = null,
+    val warning: EventWarning? = null,
+    val stderr: EventStderr? = null,
+    val stateChange: EventStateChange? = null,
+)
+
+@Serializable
+data class TaskEventRangeResp(
+    val from: Int,
+    val to: Int,
+    val total: Int,
+    val events: List<EventMessage>,
 )
 
 @Serializable
@@ -410,6 +890,22 @@ data class InputReq(val prompt: Prompt)
 @Serializable
 data class RestartReq(val prompt: Prompt)
 
+@Serializable
+data class HandoffResp(
+    val container: String,
+    val dir: String,
+    @SerialName("sessionID") val sessionID: String,
+    val command: List<String>,
+    val ssh: String,
+    val script: String,
+)
+
+@Serializable
+data class ImportHandoffResp(val messages: Int)
+
+@Serializable
+data class CleanTaskResp(val output: String? = null, val diskUsage: DiskUsage? = null)
+
 @Serializable
 data class CILogResp(val stepName: String, val log: String)
 
@@ -433,7 +929,174 @@ data class SyncResp(
 )
 
 @Serializable
-data class DiffResp(val diff: String)
+data class ReplayTaskReq(val model: String? = null)
+
+@Serializable
+data class LabelTaskReq(val outcome: String? = null, val reason: String? = null)
+
+@Serializable
+data class ShareTaskReq(val ttl: String? = null)
+
+@Serializable
+data class ShareTaskResp(
+    val token: String,
+    val path: String,
+    val expiresAt: Double,
+)
+
+@Serializable
+data class DeclChange(
+    val op: String,
+    val kind: String,
+    val name: String,
+)
+
+@Serializable
+data class DiffFileSummary(val path: String, val changes: List<DeclChange>)
+
+@Serializable
+data class DiffResp(
+    val diff: String,
+    val masked: Int? = null,
+    val summary: List<DiffFileSummary>? = null,
+)
+
+@Serializable
+data class ApplyTaskReq(val path: String)
+
+@Serializable
+data class ApplyTaskResp(
+    val status: String,
+    val path: String,
+    val diffStat: List<DiffFileStat>? = null,
+)
+
+@Serializable
+data class TaskCommit(
+    val sha: String,
+    val author: String,
+    val committedAt: Double,
+    val message: String,
+    val files: List<DiffFileStat>? = null,
+    val added: Int,
+    val deleted: Int,
+    val turn: Int,
+)
+
+@Serializable
+data class TaskCommitsResp(val commits: List<TaskCommit>)
+
+@Serializable
+data class TaskSummaryResp(
+    val text: String,
+    val messages: Int,
+    val chunks: Int,
+    val createdAt: Double,
+    val stale: Boolean,
+)
+
+@Serializable
+data class TaskSummaryReq(val force: Boolean? = null)
+
+@Serializable
+data class TurnUsage(
+    val ts: Double,
+    val estimated: Boolean? = null,
+    val model: String? = null,
+    val inputTokens: Int,
+    val outputTokens: Int,
+    val cacheCreationInputTokens: Int,
+    val cacheReadInputTokens: Int,
+    @SerialName("costUSD") val costUSD: Double,
+    val duration: Double,
+    val firstToken: Double? = null,
+    val completion: Double? = null,
+    val toolTime: Double? = null,
+    val modelTime: Double? = null,
+)
+
+@Serializable
+data class TaskUsageResp(
+    val turns: List<TurnUsage>,
+    @SerialName("costUSD") val costUSD: Double,
+)
+
+@Serializable
+data class TaskToolsResp(val duration: Double, val tools: List<ToolStats>)
+
+@Serializable
+data class ProcUsage(
+    val pid: Int,
+    val command: String,
+    val cpuPercent: Double,
+    val rssBytes: Long,
+)
+
+@Serializable
+data class ResourceSample(
+    val at: Double,
+    val cpuPercent: Double,
+    val memBytes: Long,
+    val procs: List<ProcUsage>? = null,
+)
+
+@Serializable
+data class TaskResourcesResp(val samples: List<ResourceSample>)
+
+@Serializable
+data class TaskEnvResp(
+    val at: Double,
+    val image: String? = null,
+    @SerialName("imageID") val imageID: String? = null,
+    val platform: String? = null,
+    val tools: Map<String, String>,
+    val env: Map<String, String>,
+)
+
+@Serializable
+data class Annotation(
+    val id: String,
+    val messageIndex: Int,
+    val note: String? = null,
+    val author: String? = null,
+    val createdAt: Double,
+)
+
+@Serializable
+data class TaskAnnotationsResp(val annotations: List<Annotation>)
+
+@Serializable
+data class AnnotateReq(val messageIndex: Int, val note: String? = null)
+
+@Serializable
+data class CommandExecution(
+    val messageIndex: Int,
+    @SerialName("toolUseID") val toolUseID: String,
+    val command: String,
+    val cwd: String? = null,
+    val done: Boolean,
+    val exitCode: Int? = null,
+    val duration: Double? = null,
+    val output: String? = null,
+    val error: String? = null,
+)
+
+@Serializable
+data class TaskCommandsResp(val commands: List<CommandExecution>)
+
+@Serializable
+data class TaskTodosResp(val todos: List<TodoItem>)
+
+@Serializable
+data class DeadLetter(
+    val ts: Double,
+    val type: String? = null,
+    val err: String? = null,
+    val line: String,
+)
+
+@Serializable
+data class TaskDeadLettersResp(val enabled: Boolean, val deadLetters: List<DeadLetter>)
 
 @Serializable
 data class TaskToolInputResp(
@@ -451,6 +1114,13 @@ data class TaskListEvent(
     val repos: List<Repo>? = null,
 )
 
+@Serializable
+data class ConfigEvent(
+    val config: Config,
+    val repos: List<Repo>,
+    val harnesses: List<HarnessInfo>,
+)
+
 @Serializable
 data class UsageWindow(
     val utilization: Double,
@@ -468,13 +1138,70 @@ data class ExtraUsage(
     val utilization: Double,
 )
 
+@Serializable
+data class OrgDailyCost(
+    val date: String,
+    @SerialName("costUSD") val costUSD: Double,
+)
+
+@Serializable
+data class OrgUsage(
+    @SerialName("sevenDayUSD") val sevenDayUSD: Double,
+    val days: List<OrgDailyCost>,
+)
+
 @Serializable
 data class UsageResp(
     val fiveHour: UsageWindow,
     val sevenDay: UsageWindow,
     val extraUsage: ExtraUsage,
+    val org: OrgUsage? = null,
+)
+
+@Serializable
+data class ServerLogEntry(
+    val time: String,
+    val level: String,
+    val msg: String,
+    val attrs: Map<String, JsonElement>? = null,
 )
 
+@Serializable
+data class UsageSampleTask(
+    val id: String,
+    val title: String? = null,
+    @SerialName("costUSD") val costUSD: Double,
+)
+
+@Serializable
+data class UsageSample(
+    val ts: Double,
+    val fiveHourUtilization: Double,
+    val sevenDayUtilization: Double,
+    @SerialName("fiveHourCostUSD") val fiveHourCostUSD: Double,
+    @SerialName("sevenDayCostUSD") val sevenDayCostUSD: Double,
+    @SerialName("orgSevenDayUSD") val orgSevenDayUSD: Double? = null,
+    val tasks: List<UsageSampleTask>? = null,
+)
+
+@Serializable
+data class UsageHistoryResp(val samples: List<UsageSample>)
+
+@Serializable
+data class SearchHit(
+    val taskId: String,
+    val title: String,
+    val repo: String? = null,
+    val startedAt: Double,
+    val messageIndex: Int,
+    val kind: String,
+    val snippet: String,
+    val loaded: Boolean,
+)
+
+@Serializable
+data class SearchResp(val hits: List<SearchHit>)
+
 @Serializable
 data class VoiceTokenResp(
     val token: String,
@@ -0,0 +1,14 @@
+// swift-tools-version:5.9
+// Swift client for the caic API. Sources are generated by gen-api-sdk.
+import PackageDescription
+
+let package = Package(
+    name: "CaicSDK",
+    platforms: [.iOS(.v15), .macOS(.v12)],
+    products: [
+        .library(name: "CaicSDK", targets: ["CaicSDK"]),
+    ],
+    targets: [
+        .target(name: "CaicSDK", path: "Sources/CaicSDK"),
+    ]
+)
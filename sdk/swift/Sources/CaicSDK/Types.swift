@@ -0,0 +1,2424 @@
+// Code generated by gen-api-sdk. DO NOT EDIT.
+import Foundation
+
+public enum JSONValue: Codable, Sendable, Equatable {
+    case null
+    case bool(Bool)
+    case number(Double)
+    case string(String)
+    case array([JSONValue])
+    case object([String: JSONValue])
+
+    public init(from decoder: Decoder) throws {
+        let c = try decoder.singleValueContainer()
+        if c.decodeNil() {
+            self = .null
+        } else if let v = try? c.decode(Bool.self) {
+            self = .bool(v)
+        } else if let v = try? c.decode(Double.self) {
+            self = .number(v)
+        } else if let v = try? c.decode(String.self) {
+            self = .string(v)
+        } else if let v = try? c.decode([JSONValue].self) {
+            self = .array(v)
+        } else {
+            self = .object(try c.decode([String: JSONValue].self))
+        }
+    }
+
+    public func encode(to encoder: Encoder) throws {
+        var c = encoder.singleValueContainer()
+        switch self {
+        case .null: try c.encodeNil()
+        case .bool(let v): try c.encode(v)
+        case .number(let v): try c.encode(v)
+        case .string(let v): try c.encode(v)
+        case .array(let v): try c.encode(v)
+        case .object(let v): try c.encode(v)
+        }
+    }
+}
+
+public typealias Harness = String
+
+public enum Harnesses {
+    public static let claude: Harness = "claude"
+    public static let codex: Harness = "codex"
+    public static let gemini: Harness = "gemini"
+}
+
+public typealias EventKind = String
+
+public enum EventKinds {
+    public static let init: EventKind = "init"
+    public static let text: EventKind = "text"
+    public static let textDelta: EventKind = "textDelta"
+    public static let toolUse: EventKind = "toolUse"
+    public static let toolResult: EventKind = "toolResult"
+    public static let ask: EventKind = "ask"
+    public static let usage: EventKind = "usage"
+    public static let result: EventKind = "result"
+    public static let system: EventKind = "system"
+    public static let userInput: EventKind = "userInput"
+    public static let todo: EventKind = "todo"
+    public static let diffStat: EventKind = "diffStat"
+    public static let error: EventKind = "error"
+    public static let thinking: EventKind = "thinking"
+    public static let thinkingDelta: EventKind = "thinkingDelta"
+    public static let subagentStart: EventKind = "subagentStart"
+    public static let subagentEnd: EventKind = "subagentEnd"
+    public static let log: EventKind = "log"
+    public static let toolOutputDelta: EventKind = "toolOutputDelta"
+    public static let widget: EventKind = "widget"
+    public static let widgetDelta: EventKind = "widgetDelta"
+    public static let status: EventKind = "status"
+    public static let warning: EventKind = "warning"
+    public static let stderr: EventKind = "stderr"
+    public static let stateChange: EventKind = "stateChange"
+}
+
+public enum ErrorCodes {
+    public static let badRequest = "BAD_REQUEST"
+    public static let notFound = "NOT_FOUND"
+    public static let conflict = "CONFLICT"
+    public static let rateLimited = "RATE_LIMITED"
+    public static let policyViolation = "POLICY_VIOLATION"
+    public static let internalError = "INTERNAL_ERROR"
+}
+
+public struct FeatureFlags: Codable, Sendable {
+    public var planMode: Bool?
+    public var reviewerStage: Bool?
+    public var fanOut: Bool?
+
+    public init(planMode: Bool? = nil, reviewerStage: Bool? = nil, fanOut: Bool? = nil) {
+        self.planMode = planMode
+        self.reviewerStage = reviewerStage
+        self.fanOut = fanOut
+    }
+}
+
+public struct Config: Codable, Sendable {
+    public var tailscaleAvailable: Bool
+    public var usbAvailable: Bool
+    public var displayAvailable: Bool
+    public var gpuCount: Int?
+    public var gitHubAppEnabled: Bool?
+    public var authProviders: [String]?
+    public var features: FeatureFlags
+
+    public init(tailscaleAvailable: Bool, usbAvailable: Bool, displayAvailable: Bool, gpuCount: Int? = nil, gitHubAppEnabled: Bool? = nil, authProviders: [String]? = nil, features: FeatureFlags) {
+        self.tailscaleAvailable = tailscaleAvailable
+        self.usbAvailable = usbAvailable
+        self.displayAvailable = displayAvailable
+        self.gpuCount = gpuCount
+        self.gitHubAppEnabled = gitHubAppEnabled
+        self.authProviders = authProviders
+        self.features = features
+    }
+}
+
+public struct UserResp: Codable, Sendable {
+    public var id: String
+    public var provider: String
+    public var username: String
+    public var avatarURL: String?
+
+    public init(id: String, provider: String, username: String, avatarURL: String? = nil) {
+        self.id = id
+        self.provider = provider
+        self.username = username
+        self.avatarURL = avatarURL
+    }
+}
+
+public struct StatusResp: Codable, Sendable {
+    public var status: String
+
+    public init(status: String) {
+        self.status = status
+    }
+}
+
+public struct RepoPrefsResp: Codable, Sendable {
+    public var path: String
+    public var baseBranch: String?
+    public var harness: String?
+    public var model: String?
+    public var baseImage: String?
+
+    public init(path: String, baseBranch: String? = nil, harness: String? = nil, model: String? = nil, baseImage: String? = nil) {
+        self.path = path
+        self.baseBranch = baseBranch
+        self.harness = harness
+        self.model = model
+        self.baseImage = baseImage
+    }
+}
+
+public struct CacheMappingResp: Codable, Sendable {
+    public var hostPath: String
+    public var containerPath: String
+
+    public init(hostPath: String, containerPath: String) {
+        self.hostPath = hostPath
+        self.containerPath = containerPath
+    }
+}
+
+public struct UserSettings: Codable, Sendable {
+    public var autoFixOnCIFailure: Bool
+    public var autoFixOnPROpen: Bool
+    public var baseImage: String?
+    public var useDefaultCaches: Bool?
+    public var wellKnownCaches: [String: Bool]?
+    public var cacheMappings: [CacheMappingResp]?
+
+    public init(autoFixOnCIFailure: Bool, autoFixOnPROpen: Bool, baseImage: String? = nil, useDefaultCaches: Bool? = nil, wellKnownCaches: [String: Bool]? = nil, cacheMappings: [CacheMappingResp]? = nil) {
+        self.autoFixOnCIFailure = autoFixOnCIFailure
+        self.autoFixOnPROpen = autoFixOnPROpen
+        self.baseImage = baseImage
+        self.useDefaultCaches = useDefaultCaches
+        self.wellKnownCaches = wellKnownCaches
+        self.cacheMappings = cacheMappings
+    }
+}
+
+public struct PreferencesResp: Codable, Sendable {
+    public var repositories: [RepoPrefsResp]
+    public var harness: String?
+    public var models: [String: String]?
+    public var settings: UserSettings
+
+    public init(repositories: [RepoPrefsResp], harness: String? = nil, models: [String: String]? = nil, settings: UserSettings) {
+        self.repositories = repositories
+        self.harness = harness
+        self.models = models
+        self.settings = settings
+    }
+}
+
+public struct UpdatePreferencesReq: Codable, Sendable {
+    public var settings: UserSettings
+
+    public init(settings: UserSettings) {
+        self.settings = settings
+    }
+}
+
+public struct SpendingLimit: Codable, Sendable {
+    public var harness: Harness?
+    public var window: String
+    public var limitUSD: Double
+    public var spentUSD: Double
+    public var exceeded: Bool
+
+    public init(harness: Harness? = nil, window: String, limitUSD: Double, spentUSD: Double, exceeded: Bool) {
+        self.harness = harness
+        self.window = window
+        self.limitUSD = limitUSD
+        self.spentUSD = spentUSD
+        self.exceeded = exceeded
+    }
+}
+
+public struct SpendingResp: Codable, Sendable {
+    public var limits: [SpendingLimit]
+    public var overrideUntil: Double?
+
+    public init(limits: [SpendingLimit], overrideUntil: Double? = nil) {
+        self.limits = limits
+        self.overrideUntil = overrideUntil
+    }
+}
+
+public struct SpendingOverrideReq: Codable, Sendable {
+    public var duration: String
+
+    public init(duration: String) {
+        self.duration = duration
+    }
+}
+
+public struct HarnessInfo: Codable, Sendable {
+    public var name: String
+    public var models: [String]
+    public var supportsImages: Bool
+
+    public init(name: String, models: [String], supportsImages: Bool) {
+        self.name = name
+        self.models = models
+        self.supportsImages = supportsImages
+    }
+}
+
+public struct AgentVersionCount: Codable, Sendable {
+    public var version: String
+    public var tasks: Int
+
+    public init(version: String, tasks: Int) {
+        self.version = version
+        self.tasks = tasks
+    }
+}
+
+public struct RepoAgentVersion: Codable, Sendable {
+    public var repo: String
+    public var version: String
+
+    public init(repo: String, version: String) {
+        self.repo = repo
+        self.version = version
+    }
+}
+
+public struct AgentVersions: Codable, Sendable {
+    public var harness: String
+    public var package: String?
+    public var running: [AgentVersionCount]
+    public var pinned: [RepoAgentVersion]
+    public var latest: String?
+    public var latestCheckedAt: Double?
+    public var updateAvailable: Bool?
+
+    public init(harness: String, package: String? = nil, running: [AgentVersionCount], pinned: [RepoAgentVersion], latest: String? = nil, latestCheckedAt: Double? = nil, updateAvailable: Bool? = nil) {
+        self.harness = harness
+        self.package = package
+        self.running = running
+        self.pinned = pinned
+        self.latest = latest
+        self.latestCheckedAt = latestCheckedAt
+        self.updateAvailable = updateAvailable
+    }
+}
+
+public struct AgentVersionsResp: Codable, Sendable {
+    public var harnesses: [AgentVersions]
+
+    public init(harnesses: [AgentVersions]) {
+        self.harnesses = harnesses
+    }
+}
+
+public struct SchemaDrift: Codable, Sendable {
+    public var harness: String
+    public var type: String
+    public var field: String?
+    public var count: Int64
+    public var lastAt: Double
+
+    public init(harness: String, type: String, field: String? = nil, count: Int64, lastAt: Double) {
+        self.harness = harness
+        self.type = type
+        self.field = field
+        self.count = count
+        self.lastAt = lastAt
+    }
+}
+
+public struct RuntimeStatus: Codable, Sendable {
+    public var name: String?
+    public var version: String?
+    public var md: String
+    public var unsupported: [String]?
+
+    public init(name: String? = nil, version: String? = nil, md: String, unsupported: [String]? = nil) {
+        self.name = name
+        self.version = version
+        self.md = md
+        self.unsupported = unsupported
+    }
+}
+
+public struct ProcessStatus: Codable, Sendable {
+    public var heapBytes: uint64
+    public var sysBytes: uint64
+    public var goroutines: Int
+
+    public init(heapBytes: uint64, sysBytes: uint64, goroutines: Int) {
+        self.heapBytes = heapBytes
+        self.sysBytes = sysBytes
+        self.goroutines = goroutines
+    }
+}
+
+public struct ServerStatusResp: Codable, Sendable {
+    public var drift: [SchemaDrift]
+    public var runtime: RuntimeStatus
+    public var process: ProcessStatus
+
+    public init(drift: [SchemaDrift], runtime: RuntimeStatus, process: ProcessStatus) {
+        self.drift = drift
+        self.runtime = runtime
+        self.process = process
+    }
+}
+
+public struct DoctorCheck: Codable, Sendable {
+    public var name: String
+    public var status: String
+    public var message: String
+
+    public init(name: String, status: String, message: String) {
+        self.name = name
+        self.status = status
+        self.message = message
+    }
+}
+
+public struct DoctorResp: Codable, Sendable {
+    public var checks: [DoctorCheck]
+
+    public init(checks: [DoctorCheck]) {
+        self.checks = checks
+    }
+}
+
+public struct WellKnownCache: Codable, Sendable {
+    public var name: String
+    public var description: String
+    public var mounts: [String]
+
+    public init(name: String, description: String, mounts: [String]) {
+        self.name = name
+        self.description = description
+        self.mounts = mounts
+    }
+}
+
+public struct WellKnownCachesResp: Codable, Sendable {
+    public var harnessMounts: [String]
+    public var wellKnown: [WellKnownCache]
+
+    public init(harnessMounts: [String], wellKnown: [WellKnownCache]) {
+        self.harnessMounts = harnessMounts
+        self.wellKnown = wellKnown
+    }
+}
+
+public struct CachedImage: Codable, Sendable {
+    public var ref: String
+    public var present: Bool
+    public var sizeBytes: Int64?
+    public var lastPulledAt: Double?
+    public var lastAttemptAt: Double?
+    public var lastDuration: Double?
+    public var lastError: String?
+
+    public init(ref: String, present: Bool, sizeBytes: Int64? = nil, lastPulledAt: Double? = nil, lastAttemptAt: Double? = nil, lastDuration: Double? = nil, lastError: String? = nil) {
+        self.ref = ref
+        self.present = present
+        self.sizeBytes = sizeBytes
+        self.lastPulledAt = lastPulledAt
+        self.lastAttemptAt = lastAttemptAt
+        self.lastDuration = lastDuration
+        self.lastError = lastError
+    }
+}
+
+public struct ImagesResp: Codable, Sendable {
+    public var totalBytes: Int64
+    public var interval: Double
+    public var images: [CachedImage]
+
+    public init(totalBytes: Int64, interval: Double, images: [CachedImage]) {
+        self.totalBytes = totalBytes
+        self.interval = interval
+        self.images = images
+    }
+}
+
+public struct ContainerInfo: Codable, Sendable {
+    public var name: String
+    public var state: String
+    public var createdAt: Double
+    public var taskID: String
+    public var harness: String?
+    public var repo: String?
+    public var branch: String?
+    public var owner: String?
+    public var taskCreatedAt: Double?
+    public var taskState: String?
+    public var orphan: Bool
+
+    public init(name: String, state: String, createdAt: Double, taskID: String, harness: String? = nil, repo: String? = nil, branch: String? = nil, owner: String? = nil, taskCreatedAt: Double? = nil, taskState: String? = nil, orphan: Bool) {
+        self.name = name
+        self.state = state
+        self.createdAt = createdAt
+        self.taskID = taskID
+        self.harness = harness
+        self.repo = repo
+        self.branch = branch
+        self.owner = owner
+        self.taskCreatedAt = taskCreatedAt
+        self.taskState = taskState
+        self.orphan = orphan
+    }
+}
+
+public struct ContainersResp: Codable, Sendable {
+    public var containers: [ContainerInfo]
+
+    public init(containers: [ContainerInfo]) {
+        self.containers = containers
+    }
+}
+
+public struct ForgeCheck: Codable, Sendable {
+    public var name: String
+    public var owner: String
+    public var repo: String
+    public var runID: Int64
+    public var jobID: Int64
+    public var status: String
+    public var conclusion: String
+    public var queuedAt: String?
+    public var startedAt: String?
+    public var completedAt: String?
+
+    public init(name: String, owner: String, repo: String, runID: Int64, jobID: Int64, status: String, conclusion: String, queuedAt: String? = nil, startedAt: String? = nil, completedAt: String? = nil) {
+        self.name = name
+        self.owner = owner
+        self.repo = repo
+        self.runID = runID
+        self.jobID = jobID
+        self.status = status
+        self.conclusion = conclusion
+        self.queuedAt = queuedAt
+        self.startedAt = startedAt
+        self.completedAt = completedAt
+    }
+}
+
+public struct Repo: Codable, Sendable {
+    public var path: String
+    public var baseBranch: String
+    public var remoteURL: String?
+    public var forge: String?
+    public var defaultBranchCIStatus: String?
+    public var defaultBranchChecks: [ForgeCheck]?
+
+    public init(path: String, baseBranch: String, remoteURL: String? = nil, forge: String? = nil, defaultBranchCIStatus: String? = nil, defaultBranchChecks: [ForgeCheck]? = nil) {
+        self.path = path
+        self.baseBranch = baseBranch
+        self.remoteURL = remoteURL
+        self.forge = forge
+        self.defaultBranchCIStatus = defaultBranchCIStatus
+        self.defaultBranchChecks = defaultBranchChecks
+    }
+}
+
+public struct CloneRepoReq: Codable, Sendable {
+    public var url: String
+    public var path: String?
+    public var depth: Int?
+    public var filter: String?
+
+    public init(url: String, path: String? = nil, depth: Int? = nil, filter: String? = nil) {
+        self.url = url
+        self.path = path
+        self.depth = depth
+        self.filter = filter
+    }
+}
+
+public struct RepoBranchesResp: Codable, Sendable {
+    public var branches: [String]
+
+    public init(branches: [String]) {
+        self.branches = branches
+    }
+}
+
+public struct PruneBranchesReq: Codable, Sendable {
+    public var repo: String
+    public var dryRun: Bool?
+
+    public init(repo: String, dryRun: Bool? = nil) {
+        self.repo = repo
+        self.dryRun = dryRun
+    }
+}
+
+public struct TaskBranch: Codable, Sendable {
+    public var name: String
+    public var committedAt: Double
+    public var merged: Bool
+    public var inUse: Bool
+    public var pruned: Bool
+
+    public init(name: String, committedAt: Double, merged: Bool, inUse: Bool, pruned: Bool) {
+        self.name = name
+        self.committedAt = committedAt
+        self.merged = merged
+        self.inUse = inUse
+        self.pruned = pruned
+    }
+}
+
+public struct PruneBranchesResp: Codable, Sendable {
+    public var repo: String
+    public var dryRun: Bool?
+    public var retention: String?
+    public var branches: [TaskBranch]
+
+    public init(repo: String, dryRun: Bool? = nil, retention: String? = nil, branches: [TaskBranch]) {
+        self.repo = repo
+        self.dryRun = dryRun
+        self.retention = retention
+        self.branches = branches
+    }
+}
+
+public struct CostGroup: Codable, Sendable {
+    public var harness: Harness
+    public var model: String?
+    public var tasks: Int
+    public var costUSD: Double
+    public var labeled: Int
+    public var accepted: Int
+    public var partial: Int
+    public var rejected: Int
+    public var acceptanceRate: Double
+    public var costPerAccepted: Double
+
+    public init(harness: Harness, model: String? = nil, tasks: Int, costUSD: Double, labeled: Int, accepted: Int, partial: Int, rejected: Int, acceptanceRate: Double, costPerAccepted: Double) {
+        self.harness = harness
+        self.model = model
+        self.tasks = tasks
+        self.costUSD = costUSD
+        self.labeled = labeled
+        self.accepted = accepted
+        self.partial = partial
+        self.rejected = rejected
+        self.acceptanceRate = acceptanceRate
+        self.costPerAccepted = costPerAccepted
+    }
+}
+
+public struct CostReportResp: Codable, Sendable {
+    public var costUSD: Double
+    public var groups: [CostGroup]
+
+    public init(costUSD: Double, groups: [CostGroup]) {
+        self.costUSD = costUSD
+        self.groups = groups
+    }
+}
+
+public struct HeatmapFile: Codable, Sendable {
+    public var path: String
+    public var tasks: Int
+    public var failed: Int
+    public var added: Int
+    public var deleted: Int
+    public var failureRate: Double
+
+    public init(path: String, tasks: Int, failed: Int, added: Int, deleted: Int, failureRate: Double) {
+        self.path = path
+        self.tasks = tasks
+        self.failed = failed
+        self.added = added
+        self.deleted = deleted
+        self.failureRate = failureRate
+    }
+}
+
+public struct RepoHeatmapResp: Codable, Sendable {
+    public var repo: String
+    public var tasks: Int
+    public var failed: Int
+    public var files: [HeatmapFile]
+
+    public init(repo: String, tasks: Int, failed: Int, files: [HeatmapFile]) {
+        self.repo = repo
+        self.tasks = tasks
+        self.failed = failed
+        self.files = files
+    }
+}
+
+public struct ToolStats: Codable, Sendable {
+    public var name: String
+    public var calls: Int
+    public var failures: Int
+    public var failureRate: Double
+    public var duration: Double
+    public var share: Double
+
+    public init(name: String, calls: Int, failures: Int, failureRate: Double, duration: Double, share: Double) {
+        self.name = name
+        self.calls = calls
+        self.failures = failures
+        self.failureRate = failureRate
+        self.duration = duration
+        self.share = share
+    }
+}
+
+public struct RepoToolsResp: Codable, Sendable {
+    public var repo: String
+    public var tasks: Int
+    public var duration: Double
+    public var tools: [ToolStats]
+
+    public init(repo: String, tasks: Int, duration: Double, tools: [ToolStats]) {
+        self.repo = repo
+        self.tasks = tasks
+        self.duration = duration
+        self.tools = tools
+    }
+}
+
+public struct RepoKnowledgeResp: Codable, Sendable {
+    public var repo: String
+    public var content: String
+    public var maxBytes: Int
+
+    public init(repo: String, content: String, maxBytes: Int) {
+        self.repo = repo
+        self.content = content
+        self.maxBytes = maxBytes
+    }
+}
+
+public struct UpdateRepoKnowledgeReq: Codable, Sendable {
+    public var repo: String
+    public var content: String
+
+    public init(repo: String, content: String) {
+        self.repo = repo
+        self.content = content
+    }
+}
+
+public struct RepoOverviewResp: Codable, Sendable {
+    public var repo: String
+    public var content: String
+    public var taskID: String?
+    public var generatedAt: Double?
+
+    public init(repo: String, content: String, taskID: String? = nil, generatedAt: Double? = nil) {
+        self.repo = repo
+        self.content = content
+        self.taskID = taskID
+        self.generatedAt = generatedAt
+    }
+}
+
+public struct RepoMapResp: Codable, Sendable {
+    public var repo: String
+    public var enabled: Bool
+    public var indexing: Bool
+    public var error: String?
+    public var sha: String?
+    public var generatedAt: Double?
+    public var files: Int
+    public var symbols: Int
+    public var content: String
+
+    public init(repo: String, enabled: Bool, indexing: Bool, error: String? = nil, sha: String? = nil, generatedAt: Double? = nil, files: Int, symbols: Int, content: String) {
+        self.repo = repo
+        self.enabled = enabled
+        self.indexing = indexing
+        self.error = error
+        self.sha = sha
+        self.generatedAt = generatedAt
+        self.files = files
+        self.symbols = symbols
+        self.content = content
+    }
+}
+
+public struct RefreshRepoMapReq: Codable, Sendable {
+    public var repo: String
+
+    public init(repo: String) {
+        self.repo = repo
+    }
+}
+
+public struct BotFixCIReq: Codable, Sendable {
+    public var repo: String
+
+    public init(repo: String) {
+        self.repo = repo
+    }
+}
+
+public struct ContextCut: Codable, Sendable {
+    public var name: String
+    public var tokens: Int
+    public var kept: Int
+
+    public init(name: String, tokens: Int, kept: Int) {
+        self.name = name
+        self.tokens = tokens
+        self.kept = kept
+    }
+}
+
+public struct CreateTaskResp: Codable, Sendable {
+    public var status: String
+    public var id: String
+    public var contextCuts: [ContextCut]?
+
+    public init(status: String, id: String, contextCuts: [ContextCut]? = nil) {
+        self.status = status
+        self.id = id
+        self.contextCuts = contextCuts
+    }
+}
+
+public struct BotFixPRReq: Codable, Sendable {
+    public var taskId: String
+
+    public init(taskId: String) {
+        self.taskId = taskId
+    }
+}
+
+public struct ImageData: Codable, Sendable {
+    public var mediaType: String
+    public var data: String
+
+    public init(mediaType: String, data: String) {
+        self.mediaType = mediaType
+        self.data = data
+    }
+}
+
+public struct Prompt: Codable, Sendable {
+    public var text: String
+    public var images: [ImageData]?
+
+    public init(text: String, images: [ImageData]? = nil) {
+        self.text = text
+        self.images = images
+    }
+}
+
+public struct RepoSpec: Codable, Sendable {
+    public var name: String
+    public var baseBranch: String?
+    public var paths: [String]?
+
+    public init(name: String, baseBranch: String? = nil, paths: [String]? = nil) {
+        self.name = name
+        self.baseBranch = baseBranch
+        self.paths = paths
+    }
+}
+
+public struct PromptContext: Codable, Sendable {
+    public var tasks: [String]?
+    public var urls: [String]?
+    public var files: [String]?
+
+    public init(tasks: [String]? = nil, urls: [String]? = nil, files: [String]? = nil) {
+        self.tasks = tasks
+        self.urls = urls
+        self.files = files
+    }
+}
+
+public struct AskPolicy: Codable, Sendable {
+    public var timeout: Double
+    public var action: String
+    public var answer: String?
+
+    public init(timeout: Double, action: String, answer: String? = nil) {
+        self.timeout = timeout
+        self.action = action
+        self.answer = answer
+    }
+}
+
+public struct CreateTaskReq: Codable, Sendable {
+    public var initialPrompt: Prompt
+    public var repos: [RepoSpec]?
+    public var model: String?
+    public var harness: Harness
+    public var image: String?
+    public var tailscale: Bool?
+    public var usb: Bool?
+    public var display: Bool?
+    public var gpu: Bool?
+    public var priority: String?
+    public var context: PromptContext?
+    public var kind: String?
+    public var autoResume: Bool?
+    public var askPolicy: AskPolicy?
+    public var automation: String?
+
+    public init(initialPrompt: Prompt, repos: [RepoSpec]? = nil, model: String? = nil, harness: Harness, image: String? = nil, tailscale: Bool? = nil, usb: Bool? = nil, display: Bool? = nil, gpu: Bool? = nil, priority: String? = nil, context: PromptContext? = nil, kind: String? = nil, autoResume: Bool? = nil, askPolicy: AskPolicy? = nil, automation: String? = nil) {
+        self.initialPrompt = initialPrompt
+        self.repos = repos
+        self.model = model
+        self.harness = harness
+        self.image = image
+        self.tailscale = tailscale
+        self.usb = usb
+        self.display = display
+        self.gpu = gpu
+        self.priority = priority
+        self.context = context
+        self.kind = kind
+        self.autoResume = autoResume
+        self.askPolicy = askPolicy
+        self.automation = automation
+    }
+}
+
+public struct Draft: Codable, Sendable {
+    public var id: String
+    public var title: String
+    public var task: CreateTaskReq
+    public var createdAt: Double
+    public var updatedAt: Double
+
+    public init(id: String, title: String, task: CreateTaskReq, createdAt: Double, updatedAt: Double) {
+        self.id = id
+        self.title = title
+        self.task = task
+        self.createdAt = createdAt
+        self.updatedAt = updatedAt
+    }
+}
+
+public struct StartDraftsReq: Codable, Sendable {
+    public var ids: [String]
+
+    public init(ids: [String]) {
+        self.ids = ids
+    }
+}
+
+public struct StartDraftResult: Codable, Sendable {
+    public var draftId: String
+    public var taskId: String?
+    public var error: String?
+
+    public init(draftId: String, taskId: String? = nil, error: String? = nil) {
+        self.draftId = draftId
+        self.taskId = taskId
+        self.error = error
+    }
+}
+
+public struct StartDraftsResp: Codable, Sendable {
+    public var results: [StartDraftResult]
+
+    public init(results: [StartDraftResult]) {
+        self.results = results
+    }
+}
+
+public struct EventKindSchema: Codable, Sendable {
+    public var kind: EventKind
+    public var payload: String
+    public var since: Int
+
+    public init(kind: EventKind, payload: String, since: Int) {
+        self.kind = kind
+        self.payload = payload
+        self.since = since
+    }
+}
+
+public struct EventSchemaResp: Codable, Sendable {
+    public var version: Int
+    public var kinds: [EventKindSchema]
+
+    public init(version: Int, kinds: [EventKindSchema]) {
+        self.version = version
+        self.kinds = kinds
+    }
+}
+
+public struct EvalArm: Codable, Sendable {
+    public var harness: Harness
+    public var model: String?
+
+    public init(harness: Harness, model: String? = nil) {
+        self.harness = harness
+        self.model = model
+    }
+}
+
+public struct EvalArmSummary: Codable, Sendable {
+    public var harness: Harness
+    public var model: String?
+    public var tasks: Int
+    public var finished: Int
+    public var failed: Int
+    public var verifyPassed: Int
+    public var files: Int
+    public var added: Int
+    public var deleted: Int
+    public var costUSD: Double
+    public var duration: Double
+
+    public init(harness: Harness, model: String? = nil, tasks: Int, finished: Int, failed: Int, verifyPassed: Int, files: Int, added: Int, deleted: Int, costUSD: Double, duration: Double) {
+        self.harness = harness
+        self.model = model
+        self.tasks = tasks
+        self.finished = finished
+        self.failed = failed
+        self.verifyPassed = verifyPassed
+        self.files = files
+        self.added = added
+        self.deleted = deleted
+        self.costUSD = costUSD
+        self.duration = duration
+    }
+}
+
+public struct EvalResult: Codable, Sendable {
+    public var taskId: String?
+    public var error: String?
+    public var state: String?
+    public var finished: Bool
+    public var files: Int
+    public var added: Int
+    public var deleted: Int
+    public var costUSD: Double
+    public var duration: Double
+    public var verify: String?
+    public var verifyOutput: String?
+
+    public init(taskId: String? = nil, error: String? = nil, state: String? = nil, finished: Bool, files: Int, added: Int, deleted: Int, costUSD: Double, duration: Double, verify: String? = nil, verifyOutput: String? = nil) {
+        self.taskId = taskId
+        self.error = error
+        self.state = state
+        self.finished = finished
+        self.files = files
+        self.added = added
+        self.deleted = deleted
+        self.costUSD = costUSD
+        self.duration = duration
+        self.verify = verify
+        self.verifyOutput = verifyOutput
+    }
+}
+
+public struct EvalCase: Codable, Sendable {
+    public var prompt: String
+    public var results: [EvalResult]
+
+    public init(prompt: String, results: [EvalResult]) {
+        self.prompt = prompt
+        self.results = results
+    }
+}
+
+public struct EvalRun: Codable, Sendable {
+    public var id: String
+    public var name: String?
+    public var repo: String
+    public var baseSHA: String
+    public var verify: String?
+    public var createdAt: Double
+    public var arms: [EvalArm]
+    public var summary: [EvalArmSummary]
+    public var cases: [EvalCase]?
+
+    public init(id: String, name: String? = nil, repo: String, baseSHA: String, verify: String? = nil, createdAt: Double, arms: [EvalArm], summary: [EvalArmSummary], cases: [EvalCase]? = nil) {
+        self.id = id
+        self.name = name
+        self.repo = repo
+        self.baseSHA = baseSHA
+        self.verify = verify
+        self.createdAt = createdAt
+        self.arms = arms
+        self.summary = summary
+        self.cases = cases
+    }
+}
+
+public struct CreateEvalReq: Codable, Sendable {
+    public var name: String?
+    public var repo: String
+    public var baseBranch: String?
+    public var prompts: [String]?
+    public var suitePath: String?
+    public var arms: [EvalArm]
+    public var verify: String?
+
+    public init(name: String? = nil, repo: String, baseBranch: String? = nil, prompts: [String]? = nil, suitePath: String? = nil, arms: [EvalArm], verify: String? = nil) {
+        self.name = name
+        self.repo = repo
+        self.baseBranch = baseBranch
+        self.prompts = prompts
+        self.suitePath = suitePath
+        self.arms = arms
+        self.verify = verify
+    }
+}
+
+public struct TaskRepo: Codable, Sendable {
+    public var name: String
+    public var baseBranch: String?
+    public var branch: String
+    public var baseSHA: String?
+    public var remoteURL: String?
+    public var forge: String?
+    public var sparsePaths: [String]?
+
+    public init(name: String, baseBranch: String? = nil, branch: String, baseSHA: String? = nil, remoteURL: String? = nil, forge: String? = nil, sparsePaths: [String]? = nil) {
+        self.name = name
+        self.baseBranch = baseBranch
+        self.branch = branch
+        self.baseSHA = baseSHA
+        self.remoteURL = remoteURL
+        self.forge = forge
+        self.sparsePaths = sparsePaths
+    }
+}
+
+public struct SubmoduleBump: Codable, Sendable {
+    public var from: String?
+    public var to: String?
+
+    public init(from: String? = nil, to: String? = nil) {
+        self.from = from
+        self.to = to
+    }
+}
+
+public struct DiffFileStat: Codable, Sendable {
+    public var path: String
+    public var added: Int
+    public var deleted: Int
+    public var binary: Bool?
+    public var lfs: Bool?
+    public var submodule: SubmoduleBump?
+
+    public init(path: String, added: Int, deleted: Int, binary: Bool? = nil, lfs: Bool? = nil, submodule: SubmoduleBump? = nil) {
+        self.path = path
+        self.added = added
+        self.deleted = deleted
+        self.binary = binary
+        self.lfs = lfs
+        self.submodule = submodule
+    }
+}
+
+public struct EventWarning: Codable, Sendable {
+    public var kind: String
+    public var detail: String
+
+    public init(kind: String, detail: String) {
+        self.kind = kind
+        self.detail = detail
+    }
+}
+
+public struct TaskLabel: Codable, Sendable {
+    public var outcome: String
+    public var reason: String?
+    public var source: String
+    public var labeledBy: String?
+    public var labeledAt: Double
+
+    public init(outcome: String, reason: String? = nil, source: String, labeledBy: String? = nil, labeledAt: Double) {
+        self.outcome = outcome
+        self.reason = reason
+        self.source = source
+        self.labeledBy = labeledBy
+        self.labeledAt = labeledAt
+    }
+}
+
+public struct DirSize: Codable, Sendable {
+    public var path: String
+    public var bytes: Int64
+
+    public init(path: String, bytes: Int64) {
+        self.path = path
+        self.bytes = bytes
+    }
+}
+
+public struct DiskUsage: Codable, Sendable {
+    public var usedBytes: Int64
+    public var totalBytes: Int64
+    public var dirs: [DirSize]?
+    public var checkedAt: Double
+
+    public init(usedBytes: Int64, totalBytes: Int64, dirs: [DirSize]? = nil, checkedAt: Double) {
+        self.usedBytes = usedBytes
+        self.totalBytes = totalBytes
+        self.dirs = dirs
+        self.checkedAt = checkedAt
+    }
+}
+
+public struct AffectedTargets: Codable, Sendable {
+    public var tool: String
+    public var targets: [String]?
+    public var truncated: Bool?
+    public var computedAt: Double
+
+    public init(tool: String, targets: [String]? = nil, truncated: Bool? = nil, computedAt: Double) {
+        self.tool = tool
+        self.targets = targets
+        self.truncated = truncated
+        self.computedAt = computedAt
+    }
+}
+
+public struct Task: Codable, Sendable {
+    public var id: String
+    public var initialPrompt: String
+    public var title: String
+    public var repos: [TaskRepo]?
+    public var container: String
+    public var state: String
+    public var stateUpdatedAt: Double
+    public var diffStat: [DiffFileStat]?
+    public var costUSD: Double
+    public var duration: Double
+    public var numTurns: Int
+    public var cumulativeInputTokens: Int
+    public var cumulativeOutputTokens: Int
+    public var cumulativeCacheCreationInputTokens: Int
+    public var cumulativeCacheReadInputTokens: Int
+    public var activeInputTokens: Int
+    public var activeCacheReadTokens: Int
+    public var contextWindowLimit: Int
+    public var error: String?
+    public var result: String?
+    public var forgeOwner: String?
+    public var forgeRepo: String?
+    public var forgePR: Int?
+    public var forgeIssue: Int?
+    public var ciStatus: String?
+    public var ciChecks: [ForgeCheck]?
+    public var owner: String?
+    public var harness: Harness
+    public var model: String?
+    public var agentVersion: String?
+    public var sessionID: String?
+    public var startedAt: Double?
+    public var turnStartedAt: Double?
+    public var handedOffAt: Double?
+    public var kind: String?
+    public var inPlanMode: Bool?
+    public var planContent: String?
+    public var tailscale: String?
+    public var usb: Bool?
+    public var display: Bool?
+    public var gpu: Bool?
+    public var autoResume: Bool?
+    public var priority: String?
+    public var replayOf: String?
+    public var askPolicy: AskPolicy?
+    public var automation: String?
+    public var todosOpen: Int?
+    public var todosCompleted: Int?
+    public var lastActivityAt: Double?
+    public var stuck: Bool?
+    public var warnings: [EventWarning]?
+    public var label: TaskLabel?
+    public var image: String?
+    public var imageID: String?
+    public var diskUsage: DiskUsage?
+    public var scrubbed: [String: Int]?
+    public var affectedTargets: AffectedTargets?
+
+    public init(id: String, initialPrompt: String, title: String, repos: [TaskRepo]? = nil, container: String, state: String, stateUpdatedAt: Double, diffStat: [DiffFileStat]? = nil, costUSD: Double, duration: Double, numTurns: Int, cumulativeInputTokens: Int, cumulativeOutputTokens: Int, cumulativeCacheCreationInputTokens: Int, cumulativeCacheReadInputTokens: Int, activeInputTokens: Int, activeCacheReadTokens: Int, contextWindowLimit: Int, error: String? = nil, result: String? = nil, forgeOwner: String? = nil, forgeRepo: String? = nil, forgePR: Int? = nil, forgeIssue: Int? = nil, ciStatus: String? = nil, ciChecks: [ForgeCheck]? = nil, owner: String? = nil, harness: Harness, model: String? = nil, agentVersion: String? = nil, sessionID: String? = nil, startedAt: Double? = nil, turnStartedAt: Double? = nil, handedOffAt: Double? = nil, kind: String? = nil, inPlanMode: Bool? = nil, planContent: String? = nil, tailscale: String? = nil, usb: Bool? = nil, display: Bool? = nil, gpu: Bool? = nil, autoResume: Bool? = nil, priority: String? = nil, replayOf: String? = nil, askPolicy: AskPolicy? = nil, automation: String? = nil, todosOpen: Int? = nil, todosCompleted: Int? = nil, lastActivityAt: Double? = nil, stuck: Bool? = nil, warnings: [EventWarning]? = nil, label: TaskLabel? = nil, image: String? = nil, imageID: String? = nil, diskUsage: DiskUsage? = nil, scrubbed: [String: Int]? = nil, affectedTargets: AffectedTargets? = nil) {
+        self.id = id
+        self.initialPrompt = initialPrompt
+        self.title = title
+        self.repos = repos
+        self.container = container
+        self.state = state
+        self.stateUpdatedAt = stateUpdatedAt
+        self.diffStat = diffStat
+        self.costUSD = costUSD
+        self.duration = duration
+        self.numTurns = numTurns
+        self.cumulativeInputTokens = cumulativeInputTokens
+        self.cumulativeOutputTokens = cumulativeOutputTokens
+        self.cumulativeCacheCreationInputTokens = cumulativeCacheCreationInputTokens
+        self.cumulativeCacheReadInputTokens = cumulativeCacheReadInputTokens
+        self.activeInputTokens = activeInputTokens
+        self.activeCacheReadTokens = activeCacheReadTokens
+        self.contextWindowLimit = contextWindowLimit
+        self.error = error
+        self.result = result
+        self.forgeOwner = forgeOwner
+        self.forgeRepo = forgeRepo
+        self.forgePR = forgePR
+        self.forgeIssue = forgeIssue
+        self.ciStatus = ciStatus
+        self.ciChecks = ciChecks
+        self.owner = owner
+        self.harness = harness
+        self.model = model
+        self.agentVersion = agentVersion
+        self.sessionID = sessionID
+        self.startedAt = startedAt
+        self.turnStartedAt = turnStartedAt
+        self.handedOffAt = handedOffAt
+        self.kind = kind
+        self.inPlanMode = inPlanMode
+        self.planContent = planContent
+        self.tailscale = tailscale
+        self.usb = usb
+        self.display = display
+        self.gpu = gpu
+        self.autoResume = autoResume
+        self.priority = priority
+        self.replayOf = replayOf
+        self.askPolicy = askPolicy
+        self.automation = automation
+        self.todosOpen = todosOpen
+        self.todosCompleted = todosCompleted
+        self.lastActivityAt = lastActivityAt
+        self.stuck = stuck
+        self.warnings = warnings
+        self.label = label
+        self.image = image
+        self.imageID = imageID
+        self.diskUsage = diskUsage
+        self.scrubbed = scrubbed
+        self.affectedTargets = affectedTargets
+    }
+}
+
+public struct PreflightCheck: Codable, Sendable {
+    public var name: String
+    public var status: String
+    public var message: String?
+    public var code: String?
+
+    public init(name: String, status: String, message: String? = nil, code: String? = nil) {
+        self.name = name
+        self.status = status
+        self.message = message
+        self.code = code
+    }
+}
+
+public struct ValidateTaskResp: Codable, Sendable {
+    public var ready: Bool
+    public var checks: [PreflightCheck]
+
+    public init(ready: Bool, checks: [PreflightCheck]) {
+        self.ready = ready
+        self.checks = checks
+    }
+}
+
+public struct BulkTasksReq: Codable, Sendable {
+    public var action: String
+    public var ids: [String]
+
+    public init(action: String, ids: [String]) {
+        self.action = action
+        self.ids = ids
+    }
+}
+
+public struct BulkTaskResult: Codable, Sendable {
+    public var id: String
+    public var status: String?
+    public var taskId: String?
+    public var error: String?
+    public var code: String?
+
+    public init(id: String, status: String? = nil, taskId: String? = nil, error: String? = nil, code: String? = nil) {
+        self.id = id
+        self.status = status
+        self.taskId = taskId
+        self.error = error
+        self.code = code
+    }
+}
+
+public struct BulkTasksResp: Codable, Sendable {
+    public var results: [BulkTaskResult]
+
+    public init(results: [BulkTaskResult]) {
+        self.results = results
+    }
+}
+
+public struct EventInit: Codable, Sendable {
+    public var model: String
+    public var agentVersion: String
+    public var sessionID: String
+    public var tools: [String]
+    public var cwd: String
+    public var harness: String
+
+    public init(model: String, agentVersion: String, sessionID: String, tools: [String], cwd: String, harness: String) {
+        self.model = model
+        self.agentVersion = agentVersion
+        self.sessionID = sessionID
+        self.tools = tools
+        self.cwd = cwd
+        self.harness = harness
+    }
+}
+
+public struct EventText: Codable, Sendable {
+    public var text: String
+
+    public init(text: String) {
+        self.text = text
+    }
+}
+
+public struct EventTextDelta: Codable, Sendable {
+    public var text: String
+
+    public init(text: String) {
+        self.text = text
+    }
+}
+
+public struct EventToolUse: Codable, Sendable {
+    public var toolUseID: String
+    public var name: String
+    public var input: JSONValue
+    public var planContent: String?
+    public var inputTruncated: Bool?
+
+    public init(toolUseID: String, name: String, input: JSONValue, planContent: String? = nil, inputTruncated: Bool? = nil) {
+        self.toolUseID = toolUseID
+        self.name = name
+        self.input = input
+        self.planContent = planContent
+        self.inputTruncated = inputTruncated
+    }
+}
+
+public struct EventToolResult: Codable, Sendable {
+    public var toolUseID: String
+    public var duration: Double
+    public var error: String?
+    public var inferred: Bool?
+
+    public init(toolUseID: String, duration: Double, error: String? = nil, inferred: Bool? = nil) {
+        self.toolUseID = toolUseID
+        self.duration = duration
+        self.error = error
+        self.inferred = inferred
+    }
+}
+
+public struct AskOption: Codable, Sendable {
+    public var label: String
+    public var description: String?
+
+    public init(label: String, description: String? = nil) {
+        self.label = label
+        self.description = description
+    }
+}
+
+public struct AskQuestion: Codable, Sendable {
+    public var question: String
+    public var header: String?
+    public var options: [AskOption]
+    public var multiSelect: Bool?
+
+    public init(question: String, header: String? = nil, options: [AskOption], multiSelect: Bool? = nil) {
+        self.question = question
+        self.header = header
+        self.options = options
+        self.multiSelect = multiSelect
+    }
+}
+
+public struct EventAsk: Codable, Sendable {
+    public var toolUseID: String
+    public var questions: [AskQuestion]
+
+    public init(toolUseID: String, questions: [AskQuestion]) {
+        self.toolUseID = toolUseID
+        self.questions = questions
+    }
+}
+
+public struct EventUsage: Codable, Sendable {
+    public var inputTokens: Int
+    public var outputTokens: Int
+    public var cacheCreationInputTokens: Int
+    public var cacheReadInputTokens: Int
+    public var reasoningOutputTokens: Int?
+    public var model: String
+
+    public init(inputTokens: Int, outputTokens: Int, cacheCreationInputTokens: Int, cacheReadInputTokens: Int, reasoningOutputTokens: Int? = nil, model: String) {
+        self.inputTokens = inputTokens
+        self.outputTokens = outputTokens
+        self.cacheCreationInputTokens = cacheCreationInputTokens
+        self.cacheReadInputTokens = cacheReadInputTokens
+        self.reasoningOutputTokens = reasoningOutputTokens
+        self.model = model
+    }
+}
+
+public struct EventResult: Codable, Sendable {
+    public var subtype: String
+    public var isError: Bool
+    public var result: String
+    public var diffStat: [DiffFileStat]?
+    public var totalCostUSD: Double
+    public var duration: Double
+    public var durationAPI: Double
+    public var numTurns: Int
+    public var usage: EventUsage
+
+    public init(subtype: String, isError: Bool, result: String, diffStat: [DiffFileStat]? = nil, totalCostUSD: Double, duration: Double, durationAPI: Double, numTurns: Int, usage: EventUsage) {
+        self.subtype = subtype
+        self.isError = isError
+        self.result = result
+        self.diffStat = diffStat
+        self.totalCostUSD = totalCostUSD
+        self.duration = duration
+        self.durationAPI = durationAPI
+        self.numTurns = numTurns
+        self.usage = usage
+    }
+}
+
+public struct EventSystem: Codable, Sendable {
+    public var subtype: String
+    public var detail: String?
+
+    public init(subtype: String, detail: String? = nil) {
+        self.subtype = subtype
+        self.detail = detail
+    }
+}
+
+public struct EventUserInput: Codable, Sendable {
+    public var text: String
+    public var images: [ImageData]?
+
+    public init(text: String, images: [ImageData]? = nil) {
+        self.text = text
+        self.images = images
+    }
+}
+
+public struct TodoItem: Codable, Sendable {
+    public var content: String
+    public var status: String
+    public var activeForm: String?
+
+    public init(content: String, status: String, activeForm: String? = nil) {
+        self.content = content
+        self.status = status
+        self.activeForm = activeForm
+    }
+}
+
+public struct EventTodo: Codable, Sendable {
+    public var toolUseID: String
+    public var todos: [TodoItem]
+
+    public init(toolUseID: String, todos: [TodoItem]) {
+        self.toolUseID = toolUseID
+        self.todos = todos
+    }
+}
+
+public struct EventDiffStat: Codable, Sendable {
+    public var diffStat: [DiffFileStat]?
+    public var toolUseID: String?
+    public var headSHA: String?
+
+    public init(diffStat: [DiffFileStat]? = nil, toolUseID: String? = nil, headSHA: String? = nil) {
+        self.diffStat = diffStat
+        self.toolUseID = toolUseID
+        self.headSHA = headSHA
+    }
+}
+
+public struct EventError: Codable, Sendable {
+    public var err: String
+    public var line: String
+
+    public init(err: String, line: String) {
+        self.err = err
+        self.line = line
+    }
+}
+
+public struct EventThinking: Codable, Sendable {
+    public var text: String
+
+    public init(text: String) {
+        self.text = text
+    }
+}
+
+public struct EventThinkingDelta: Codable, Sendable {
+    public var text: String
+
+    public init(text: String) {
+        self.text = text
+    }
+}
+
+public struct EventSubagentStart: Codable, Sendable {
+    public var taskID: String
+    public var description: String
+
+    public init(taskID: String, description: String) {
+        self.taskID = taskID
+        self.description = description
+    }
+}
+
+public struct EventSubagentEnd: Codable, Sendable {
+    public var taskID: String
+    public var status: String
+
+    public init(taskID: String, status: String) {
+        self.taskID = taskID
+        self.status = status
+    }
+}
+
+public struct EventLog: Codable, Sendable {
+    public var line: String
+
+    public init(line: String) {
+        self.line = line
+    }
+}
+
+public struct EventToolOutputDelta: Codable, Sendable {
+    public var toolUseID: String
+    public var delta: String
+
+    public init(toolUseID: String, delta: String) {
+        self.toolUseID = toolUseID
+        self.delta = delta
+    }
+}
+
+public struct EventWidget: Codable, Sendable {
+    public var toolUseID: String
+    public var title: String
+    public var html: String
+
+    public init(toolUseID: String, title: String, html: String) {
+        self.toolUseID = toolUseID
+        self.title = title
+        self.html = html
+    }
+}
+
+public struct EventWidgetDelta: Codable, Sendable {
+    public var toolUseID: String
+    public var delta: String
+
+    public init(toolUseID: String, delta: String) {
+        self.toolUseID = toolUseID
+        self.delta = delta
+    }
+}
+
+public struct EventStatus: Codable, Sendable {
+    public var status: String
+    public var tool: String?
+    public var until: Double?
+
+    public init(status: String, tool: String? = nil, until: Double? = nil) {
+        self.status = status
+        self.tool = tool
+        self.until = until
+    }
+}
+
+public struct EventStderr: Codable, Sendable {
+    public var source: String
+    public var line: String
+
+    public init(source: String, line: String) {
+        self.source = source
+        self.line = line
+    }
+}
+
+public struct EventStateChange: Codable, Sendable {
+    public var from: String
+    public var to: String
+
+    public init(from: String, to: String) {
+        self.from = from
+        self.to = to
+    }
+}
+
+// Backend-neutral event types
+
+public struct EventMessage: Codable, Sendable {
+    public var kind: EventKind
+    public var ts: Int64
+    public var `init`: EventInit?
+    public var text: EventText?
+    public var textDelta: EventTextDelta?
+    public var toolUse: EventToolUse?
+    public var toolResult: EventToolResult?
+    public var ask: EventAsk?
+    public var usage: EventUsage?
+    public var result: EventResult?
+    public var system: EventSystem?
+    public var userInput: EventUserInput?
+    public var todo: EventTodo?
+    public var diffStat: EventDiffStat?
+    public var error: EventError?
+    public var thinking: EventThinking?
+    public var thinkingDelta: EventThinkingDelta?
+    public var subagentStart: EventSubagentStart?
+    public var subagentEnd: EventSubagentEnd?
+    public var log: EventLog?
+    public var toolOutputDelta: EventToolOutputDelta?
+    public var widget: EventWidget?
+    public var widgetDelta: EventWidgetDelta?
+    public var status: EventStatus?
+    public var warning: EventWarning?
+    public var stderr: EventStderr?
+    public var stateChange: EventStateChange?
+
+    public init(kind: EventKind, ts: Int64, `init`: EventInit? = nil, text: EventText? = nil, textDelta: EventTextDelta? = nil, toolUse: EventToolUse? = nil, toolResult: EventToolResult? = nil, ask: EventAsk? = nil, usage: EventUsage? = nil, result: EventResult? = nil, system: EventSystem? = nil, userInput: EventUserInput? = nil, todo: EventTodo? = nil, diffStat: EventDiffStat? = nil, error: EventError? = nil, thinking: EventThinking? = nil, thinkingDelta: EventThinkingDelta? = nil, subagentStart: EventSubagentStart? = nil, subagentEnd: EventSubagentEnd? = nil, log: EventLog? = nil, toolOutputDelta: EventToolOutputDelta? = nil, widget: EventWidget? = nil, widgetDelta: EventWidgetDelta? = nil, status: EventStatus? = nil, warning: EventWarning? = nil, stderr: EventStderr? = nil, stateChange: EventStateChange? = nil) {
+        self.kind = kind
+        self.ts = ts
+        self.`init` = `init`
+        self.text = text
+        self.textDelta = textDelta
+        self.toolUse = toolUse
+        self.toolResult = toolResult
+        self.ask = ask
+        self.usage = usage
+        self.result = result
+        self.system = system
+        self.userInput = userInput
+        self.todo = todo
+        self.diffStat = diffStat
+        self.error = error
+        self.thinking = thinking
+        self.thinkingDelta = thinkingDelta
+        self.subagentStart = subagentStart
+        self.subagentEnd = subagentEnd
+        self.log = log
+        self.toolOutputDelta = toolOutputDelta
+        self.widget = widget
+        self.widgetDelta = widgetDelta
+        self.status = status
+        self.warning = warning
+        self.stderr = stderr
+        self.stateChange = stateChange
+    }
+}
+
+public struct TaskEventRangeResp: Codable, Sendable {
+    public var from: Int
+    public var to: Int
+    public var total: Int
+    public var events: [EventMessage]
+
+    public init(from: Int, to: Int, total: Int, events: [EventMessage]) {
+        self.from = from
+        self.to = to
+        self.total = total
+        self.events = events
+    }
+}
+
+public struct InputReq: Codable, Sendable {
+    public var prompt: Prompt
+
+    public init(prompt: Prompt) {
+        self.prompt = prompt
+    }
+}
+
+public struct RestartReq: Codable, Sendable {
+    public var prompt: Prompt
+
+    public init(prompt: Prompt) {
+        self.prompt = prompt
+    }
+}
+
+public struct HandoffResp: Codable, Sendable {
+    public var container: String
+    public var dir: String
+    public var sessionID: String
+    public var command: [String]
+    public var ssh: String
+    public var script: String
+
+    public init(container: String, dir: String, sessionID: String, command: [String], ssh: String, script: String) {
+        self.container = container
+        self.dir = dir
+        self.sessionID = sessionID
+        self.command = command
+        self.ssh = ssh
+        self.script = script
+    }
+}
+
+public struct ImportHandoffResp: Codable, Sendable {
+    public var messages: Int
+
+    public init(messages: Int) {
+        self.messages = messages
+    }
+}
+
+public struct CleanTaskResp: Codable, Sendable {
+    public var output: String?
+    public var diskUsage: DiskUsage?
+
+    public init(output: String? = nil, diskUsage: DiskUsage? = nil) {
+        self.output = output
+        self.diskUsage = diskUsage
+    }
+}
+
+public struct CILogResp: Codable, Sendable {
+    public var stepName: String
+    public var log: String
+
+    public init(stepName: String, log: String) {
+        self.stepName = stepName
+        self.log = log
+    }
+}
+
+public struct SyncReq: Codable, Sendable {
+    public var force: Bool?
+    public var target: String?
+
+    public init(force: Bool? = nil, target: String? = nil) {
+        self.force = force
+        self.target = target
+    }
+}
+
+public struct SafetyIssue: Codable, Sendable {
+    public var file: String
+    public var kind: String
+    public var detail: String
+
+    public init(file: String, kind: String, detail: String) {
+        self.file = file
+        self.kind = kind
+        self.detail = detail
+    }
+}
+
+public struct SyncResp: Codable, Sendable {
+    public var status: String
+    public var branch: String?
+    public var diffStat: [DiffFileStat]?
+    public var safetyIssues: [SafetyIssue]?
+    public var prNumber: Int?
+
+    public init(status: String, branch: String? = nil, diffStat: [DiffFileStat]? = nil, safetyIssues: [SafetyIssue]? = nil, prNumber: Int? = nil) {
+        self.status = status
+        self.branch = branch
+        self.diffStat = diffStat
+        self.safetyIssues = safetyIssues
+        self.prNumber = prNumber
+    }
+}
+
+public struct ReplayTaskReq: Codable, Sendable {
+    public var model: String?
+
+    public init(model: String? = nil) {
+        self.model = model
+    }
+}
+
+public struct LabelTaskReq: Codable, Sendable {
+    public var outcome: String?
+    public var reason: String?
+
+    public init(outcome: String? = nil, reason: String? = nil) {
+        self.outcome = outcome
+        self.reason = reason
+    }
+}
+
+public struct ShareTaskReq: Codable, Sendable {
+    public var ttl: String?
+
+    public init(ttl: String? = nil) {
+        self.ttl = ttl
+    }
+}
+
+public struct ShareTaskResp: Codable, Sendable {
+    public var token: String
+    public var path: String
+    public var expiresAt: Double
+
+    public init(token: String, path: String, expiresAt: Double) {
+        self.token = token
+        self.path = path
+        self.expiresAt = expiresAt
+    }
+}
+
+public struct DeclChange: Codable, Sendable {
+    public var op: String
+    public var kind: String
+    public var name: String
+
+    public init(op: String, kind: String, name: String) {
+        self.op = op
+        self.kind = kind
+        self.name = name
+    }
+}
+
+public struct DiffFileSummary: Codable, Sendable {
+    public var path: String
+    public var changes: [DeclChange]
+
+    public init(path: String, changes: [DeclChange]) {
+        self.path = path
+        self.changes = changes
+    }
+}
+
+public struct DiffResp: Codable, Sendable {
+    public var diff: String
+    public var masked: Int?
+    public var summary: [DiffFileSummary]?
+
+    public init(diff: String, masked: Int? = nil, summary: [DiffFileSummary]? = nil) {
+        self.diff = diff
+        self.masked = masked
+        self.summary = summary
+    }
+}
+
+public struct ApplyTaskReq: Codable, Sendable {
+    public var path: String
+
+    public init(path: String) {
+        self.path = path
+    }
+}
+
+public struct ApplyTaskResp: Codable, Sendable {
+    public var status: String
+    public var path: String
+    public var diffStat: [DiffFileStat]?
+
+    public init(status: String, path: String, diffStat: [DiffFileStat]? = nil) {
+        self.status = status
+        self.path = path
+        self.diffStat = diffStat
+    }
+}
+
+public struct TaskCommit: Codable, Sendable {
+    public var sha: String
+    public var author: String
+    public var committedAt: Double
+    public var message: String
+    public var files: [DiffFileStat]?
+    public var added: Int
+    public var deleted: Int
+    public var turn: Int
+
+    public init(sha: String, author: String, committedAt: Double, message: String, files: [DiffFileStat]? = nil, added: Int, deleted: Int, turn: Int) {
+        self.sha = sha
+        self.author = author
+        self.committedAt = committedAt
+        self.message = message
+        self.files = files
+        self.added = added
+        self.deleted = deleted
+        self.turn = turn
+    }
+}
+
+public struct TaskCommitsResp: Codable, Sendable {
+    public var commits: [TaskCommit]
+
+    public init(commits: [TaskCommit]) {
+        self.commits = commits
+    }
+}
+
+public struct TaskSummaryResp: Codable, Sendable {
+    public var text: String
+    public var messages: Int
+    public var chunks: Int
+    public var createdAt: Double
+    public var stale: Bool
+
+    public init(text: String, messages: Int, chunks: Int, createdAt: Double, stale: Bool) {
+        self.text = text
+        self.messages = messages
+        self.chunks = chunks
+        self.createdAt = createdAt
+        self.stale = stale
+    }
+}
+
+public struct TaskSummaryReq: Codable, Sendable {
+    public var force: Bool?
+
+    public init(force: Bool? = nil) {
+        self.force = force
+    }
+}
+
+public struct TurnUsage: Codable, Sendable {
+    public var ts: Double
+    public var estimated: Bool?
+    public var model: String?
+    public var inputTokens: Int
+    public var outputTokens: Int
+    public var cacheCreationInputTokens: Int
+    public var cacheReadInputTokens: Int
+    public var costUSD: Double
+    public var duration: Double
+    public var firstToken: Double?
+    public var completion: Double?
+    public var toolTime: Double?
+    public var modelTime: Double?
+
+    public init(ts: Double, estimated: Bool? = nil, model: String? = nil, inputTokens: Int, outputTokens: Int, cacheCreationInputTokens: Int, cacheReadInputTokens: Int, costUSD: Double, duration: Double, firstToken: Double? = nil, completion: Double? = nil, toolTime: Double? = nil, modelTime: Double? = nil) {
+        self.ts = ts
+        self.estimated = estimated
+        self.model = model
+        self.inputTokens = inputTokens
+        self.outputTokens = outputTokens
+        self.cacheCreationInputTokens = cacheCreationInputTokens
+        self.cacheReadInputTokens = cacheReadInputTokens
+        self.costUSD = costUSD
+        self.duration = duration
+        self.firstToken = firstToken
+        self.completion = completion
+        self.toolTime = toolTime
+        self.modelTime = modelTime
+    }
+}
+
+public struct TaskUsageResp: Codable, Sendable {
+    public var turns: [TurnUsage]
+    public var costUSD: Double
+
+    public init(turns: [TurnUsage], costUSD: Double) {
+        self.turns = turns
+        self.costUSD = costUSD
+    }
+}
+
+public struct TaskToolsResp: Codable, Sendable {
+    public var duration: Double
+    public var tools: [ToolStats]
+
+    public init(duration: Double, tools: [ToolStats]) {
+        self.duration = duration
+        self.tools = tools
+    }
+}
+
+public struct ProcUsage: Codable, Sendable {
+    public var pid: Int
+    public var command: String
+    public var cpuPercent: Double
+    public var rssBytes: Int64
+
+    public init(pid: Int, command: String, cpuPercent: Double, rssBytes: Int64) {
+        self.pid = pid
+        self.command = command
+        self.cpuPercent = cpuPercent
+        self.rssBytes = rssBytes
+    }
+}
+
+public struct ResourceSample: Codable, Sendable {
+    public var at: Double
+    public var cpuPercent: Double
+    public var memBytes: Int64
+    public var procs: [ProcUsage]?
+
+    public init(at: Double, cpuPercent: Double, memBytes: Int64, procs: [ProcUsage]? = nil) {
+        self.at = at
+        self.cpuPercent = cpuPercent
+        self.memBytes = memBytes
+        self.procs = procs
+    }
+}
+
+public struct TaskResourcesResp: Codable, Sendable {
+    public var samples: [ResourceSample]
+
+    public init(samples: [ResourceSample]) {
+        self.samples = samples
+    }
+}
+
+public struct TaskEnvResp: Codable, Sendable {
+    public var at: Double
+    public var image: String?
+    public var imageID: String?
+    public var platform: String?
+    public var tools: [String: String]
+    public var env: [String: String]
+
+    public init(at: Double, image: String? = nil, imageID: String? = nil, platform: String? = nil, tools: [String: String], env: [String: String]) {
+        self.at = at
+        self.image = image
+        self.imageID = imageID
+        self.platform = platform
+        self.tools = tools
+        self.env = env
+    }
+}
+
+public struct Annotation: Codable, Sendable {
+    public var id: String
+    public var messageIndex: Int
+    public var note: String?
+    public var author: String?
+    public var createdAt: Double
+
+    public init(id: String, messageIndex: Int, note: String? = nil, author: String? = nil, createdAt: Double) {
+        self.id = id
+        self.messageIndex = messageIndex
+        self.note = note
+        self.author = author
+        self.createdAt = createdAt
+    }
+}
+
+public struct TaskAnnotationsResp: Codable, Sendable {
+    public var annotations: [Annotation]
+
+    public init(annotations: [Annotation]) {
+        self.annotations = annotations
+    }
+}
+
+public struct AnnotateReq: Codable, Sendable {
+    public var messageIndex: Int
+    public var note: String?
+
+    public init(messageIndex: Int, note: String? = nil) {
+        self.messageIndex = messageIndex
+        self.note = note
+    }
+}
+
+public struct CommandExecution: Codable, Sendable {
+    public var messageIndex: Int
+    public var toolUseID: String
+    public var command: String
+    public var cwd: String?
+    public var done: Bool
+    public var exitCode: Int?
+    public var duration: Double?
+    public var output: String?
+    public var error: String?
+
+    public init(messageIndex: Int, toolUseID: String, command: String, cwd: String? = nil, done: Bool, exitCode: Int? = nil, duration: Double? = nil, output: String? = nil, error: String? = nil) {
+        self.messageIndex = messageIndex
+        self.toolUseID = toolUseID
+        self.command = command
+        self.cwd = cwd
+        self.done = done
+        self.exitCode = exitCode
+        self.duration = duration
+        self.output = output
+        self.error = error
+    }
+}
+
+public struct TaskCommandsResp: Codable, Sendable {
+    public var commands: [CommandExecution]
+
+    public init(commands: [CommandExecution]) {
+        self.commands = commands
+    }
+}
+
+public struct TaskTodosResp: Codable, Sendable {
+    public var todos: [TodoItem]
+
+    public init(todos: [TodoItem]) {
+        self.todos = todos
+    }
+}
+
+public struct DeadLetter: Codable, Sendable {
+    public var ts: Double
+    public var type: String?
+    public var err: String?
+    public var line: String
+
+    public init(ts: Double, type: String? = nil, err: String? = nil, line: String) {
+        self.ts = ts
+        self.type = type
+        self.err = err
+        self.line = line
+    }
+}
+
+public struct TaskDeadLettersResp: Codable, Sendable {
+    public var enabled: Bool
+    public var deadLetters: [DeadLetter]
+
+    public init(enabled: Bool, deadLetters: [DeadLetter]) {
+        self.enabled = enabled
+        self.deadLetters = deadLetters
+    }
+}
+
+public struct TaskToolInputResp: Codable, Sendable {
+    public var toolUseID: String
+    public var input: JSONValue
+
+    public init(toolUseID: String, input: JSONValue) {
+        self.toolUseID = toolUseID
+        self.input = input
+    }
+}
+
+public struct TaskListEvent: Codable, Sendable {
+    public var kind: String
+    public var tasks: [Task]?
+    public var task: Task?
+    public var patch: [String: JSONValue]?
+    public var id: String?
+    public var repos: [Repo]?
+
+    public init(kind: String, tasks: [Task]? = nil, task: Task? = nil, patch: [String: JSONValue]? = nil, id: String? = nil, repos: [Repo]? = nil) {
+        self.kind = kind
+        self.tasks = tasks
+        self.task = task
+        self.patch = patch
+        self.id = id
+        self.repos = repos
+    }
+}
+
+public struct ConfigEvent: Codable, Sendable {
+    public var config: Config
+    public var repos: [Repo]
+    public var harnesses: [HarnessInfo]
+
+    public init(config: Config, repos: [Repo], harnesses: [HarnessInfo]) {
+        self.config = config
+        self.repos = repos
+        self.harnesses = harnesses
+    }
+}
+
+public struct UsageWindow: Codable, Sendable {
+    public var utilization: Double
+    public var resetsAt: String
+    public var costUSD: Double
+    public var inputTokens: Int
+    public var outputTokens: Int
+
+    public init(utilization: Double, resetsAt: String, costUSD: Double, inputTokens: Int, outputTokens: Int) {
+        self.utilization = utilization
+        self.resetsAt = resetsAt
+        self.costUSD = costUSD
+        self.inputTokens = inputTokens
+        self.outputTokens = outputTokens
+    }
+}
+
+public struct ExtraUsage: Codable, Sendable {
+    public var isEnabled: Bool
+    public var monthlyLimit: Double
+    public var usedCredits: Double
+    public var utilization: Double
+
+    public init(isEnabled: Bool, monthlyLimit: Double, usedCredits: Double, utilization: Double) {
+        self.isEnabled = isEnabled
+        self.monthlyLimit = monthlyLimit
+        self.usedCredits = usedCredits
+        self.utilization = utilization
+    }
+}
+
+public struct OrgDailyCost: Codable, Sendable {
+    public var date: String
+    public var costUSD: Double
+
+    public init(date: String, costUSD: Double) {
+        self.date = date
+        self.costUSD = costUSD
+    }
+}
+
+public struct OrgUsage: Codable, Sendable {
+    public var sevenDayUSD: Double
+    public var days: [OrgDailyCost]
+
+    public init(sevenDayUSD: Double, days: [OrgDailyCost]) {
+        self.sevenDayUSD = sevenDayUSD
+        self.days = days
+    }
+}
+
+public struct UsageResp: Codable, Sendable {
+    public var fiveHour: UsageWindow
+    public var sevenDay: UsageWindow
+    public var extraUsage: ExtraUsage
+    public var org: OrgUsage?
+
+    public init(fiveHour: UsageWindow, sevenDay: UsageWindow, extraUsage: ExtraUsage, org: OrgUsage? = nil) {
+        self.fiveHour = fiveHour
+        self.sevenDay = sevenDay
+        self.extraUsage = extraUsage
+        self.org = org
+    }
+}
+
+public struct ServerLogEntry: Codable, Sendable {
+    public var time: String
+    public var level: String
+    public var msg: String
+    public var attrs: [String: JSONValue]?
+
+    public init(time: String, level: String, msg: String, attrs: [String: JSONValue]? = nil) {
+        self.time = time
+        self.level = level
+        self.msg = msg
+        self.attrs = attrs
+    }
+}
+
+public struct UsageSampleTask: Codable, Sendable {
+    public var id: String
+    public var title: String?
+    public var costUSD: Double
+
+    public init(id: String, title: String? = nil, costUSD: Double) {
+        self.id = id
+        self.title = title
+        self.costUSD = costUSD
+    }
+}
+
+public struct UsageSample: Codable, Sendable {
+    public var ts: Double
+    public var fiveHourUtilization: Double
+    public var sevenDayUtilization: Double
+    public var fiveHourCostUSD: Double
+    public var sevenDayCostUSD: Double
+    public var orgSevenDayUSD: Double?
+    public var tasks: [UsageSampleTask]?
+
+    public init(ts: Double, fiveHourUtilization: Double, sevenDayUtilization: Double, fiveHourCostUSD: Double, sevenDayCostUSD: Double, orgSevenDayUSD: Double? = nil, tasks: [UsageSampleTask]? = nil) {
+        self.ts = ts
+        self.fiveHourUtilization = fiveHourUtilization
+        self.sevenDayUtilization = sevenDayUtilization
+        self.fiveHourCostUSD = fiveHourCostUSD
+        self.sevenDayCostUSD = sevenDayCostUSD
+        self.orgSevenDayUSD = orgSevenDayUSD
+        self.tasks = tasks
+    }
+}
+
+public struct UsageHistoryResp: Codable, Sendable {
+    public var samples: [UsageSample]
+
+    public init(samples: [UsageSample]) {
+        self.samples = samples
+    }
+}
+
+public struct SearchHit: Codable, Sendable {
+    public var taskId: String
+    public var title: String
+    public var repo: String?
+    public var startedAt: Double
+    public var messageIndex: Int
+    public var kind: String
+    public var snippet: String
+    public var loaded: Bool
+
+    public init(taskId: String, title: String, repo: String? = nil, startedAt: Double, messageIndex: Int, kind: String, snippet: String, loaded: Bool) {
+        self.taskId = taskId
+        self.title = title
+        self.repo = repo
+        self.startedAt = startedAt
+        self.messageIndex = messageIndex
+        self.kind = kind
+        self.snippet = snippet
+        self.loaded = loaded
+    }
+}
+
+public struct SearchResp: Codable, Sendable {
+    public var hits: [SearchHit]
+
+    public init(hits: [SearchHit]) {
+        self.hits = hits
+    }
+}
+
+public struct VoiceTokenResp: Codable, Sendable {
+    public var token: String
+    public var expiresAt: String
+    public var ephemeral: Bool
+
+    public init(token: String, expiresAt: String, ephemeral: Bool) {
+        self.token = token
+        self.expiresAt = expiresAt
+        self.ephemeral = ephemeral
+    }
+}
+
+public struct WebFetchReq: Codable, Sendable {
+    public var url: String
+
+    public init(url: String) {
+        self.url = url
+    }
+}
+
+public struct WebFetchResp: Codable, Sendable {
+    public var title: String
+    public var content: String
+
+    public init(title: String, content: String) {
+        self.title = title
+        self.content = content
+    }
+}
+
+public struct ErrorDetails: Codable, Sendable {
+    public var code: String
+    public var message: String
+
+    public init(code: String, message: String) {
+        self.code = code
+        self.message = message
+    }
+}
+
+public struct ErrorResponse: Codable, Sendable {
+    public var error: ErrorDetails
+    public var details: [String: JSONValue]?
+
+    public init(error: ErrorDetails, details: [String: JSONValue]? = nil) {
+        self.error = error
+        self.details = details
+    }
+}
+
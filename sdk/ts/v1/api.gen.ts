@@ -1,5 +1,5 @@
 // Code generated by gen-api-sdk. DO NOT EDIT.
-import type { BotFixCIReq, BotFixPRReq, CILogResp, CloneRepoReq, Config, CreateTaskReq, CreateTaskResp, DiffResp, ErrorResponse, EventMessage, HarnessInfo, InputReq, PreferencesResp, Repo, RepoBranchesResp, RestartReq, StatusResp, SyncReq, SyncResp, Task, TaskListEvent, TaskToolInputResp, UpdatePreferencesReq, UsageResp, UserResp, VoiceTokenResp, WebFetchReq, WebFetchResp, WellKnownCachesResp } from "./types.gen";
+import type { AgentVersionsResp, AnnotateReq, Annotation, ApplyTaskReq, ApplyTaskResp, BotFixCIReq, BotFixPRReq, BulkTasksReq, BulkTasksResp, CILogResp, CleanTaskResp, CloneRepoReq, Config, ConfigEvent, ContainersResp, CostReportResp, CreateEvalReq, CreateTaskReq, CreateTaskResp, DiffResp, DoctorResp, Draft, ErrorResponse, EvalRun, EventMessage, EventSchemaResp, FeatureFlags, HandoffResp, HarnessInfo, ImagesResp, ImportHandoffResp, InputReq, LabelTaskReq, PreferencesResp, PruneBranchesReq, PruneBranchesResp, RefreshRepoMapReq, ReplayTaskReq, Repo, RepoBranchesResp, RepoHeatmapResp, RepoKnowledgeResp, RepoMapResp, RepoOverviewResp, RepoToolsResp, RestartReq, SearchResp, ServerLogEntry, ServerStatusResp, ShareTaskReq, ShareTaskResp, SpendingOverrideReq, SpendingResp, StartDraftsReq, StartDraftsResp, StatusResp, SyncReq, SyncResp, Task, TaskAnnotationsResp, TaskCommandsResp, TaskCommitsResp, TaskDeadLettersResp, TaskEnvResp, TaskEventRangeResp, TaskListEvent, TaskResourcesResp, TaskSummaryReq, TaskSummaryResp, TaskTodosResp, TaskToolInputResp, TaskToolsResp, TaskUsageResp, UpdatePreferencesReq, UpdateRepoKnowledgeReq, UsageHistoryResp, UsageResp, UserResp, ValidateTaskResp, VoiceTokenResp, WebFetchReq, WebFetchResp, WellKnownCachesResp } from "./types.gen";
 
 export class APIError extends Error {
   constructor(
@@ -34,59 +34,138 @@ function makeRequester(fetchFn: FetchFn) {
 export function createApiClient(fetchFn: FetchFn = (globalThis as any).fetch.bind(globalThis)) {
   const request = makeRequester(fetchFn);
   return {
-    getConfig: (): Promise<Config> => request<Config>("GET", "/api/v1/server/config"),
-    getMe: (): Promise<UserResp> => request<UserResp>("GET", "/api/v1/auth/me"),
-    logout: (): Promise<StatusResp> => request<StatusResp>("POST", "/api/v1/auth/logout"),
-    getPreferences: (): Promise<PreferencesResp> => request<PreferencesResp>("GET", "/api/v1/server/preferences"),
-    updatePreferences: (req: UpdatePreferencesReq): Promise<PreferencesResp> => request<PreferencesResp>("POST", "/api/v1/server/preferences", req),
-    listHarnesses: (): Promise<HarnessInfo[]> => request<HarnessInfo[]>("GET", "/api/v1/server/harnesses"),
-    listCaches: (): Promise<WellKnownCachesResp> => request<WellKnownCachesResp>("GET", "/api/v1/server/caches"),
-    listRepos: (): Promise<Repo[]> => request<Repo[]>("GET", "/api/v1/server/repos"),
-    cloneRepo: (req: CloneRepoReq): Promise<Repo> => request<Repo>("POST", "/api/v1/server/repos", req),
-    listRepoBranches: (repo: string): Promise<RepoBranchesResp> => request<RepoBranchesResp>("GET", `/api/v1/server/repos/branches?repo=${encodeURIComponent(repo)}`),
-    botFixCI: (req: BotFixCIReq): Promise<CreateTaskResp> => request<CreateTaskResp>("POST", "/api/v1/bot/fix-ci", req),
-    botFixPR: (req: BotFixPRReq): Promise<StatusResp> => request<StatusResp>("POST", "/api/v1/bot/fix-pr", req),
-    listTasks: (): Promise<Task[]> => request<Task[]>("GET", "/api/v1/tasks"),
-    createTask: (req: CreateTaskReq): Promise<CreateTaskResp> => request<CreateTaskResp>("POST", "/api/v1/tasks", req),
+    getConfig: (): Promise<Config> => request<Config>("GET", "api/v1/server/config"),
+    getMe: (): Promise<UserResp> => request<UserResp>("GET", "api/v1/auth/me"),
+    logout: (): Promise<StatusResp> => request<StatusResp>("POST", "api/v1/auth/logout"),
+    getPreferences: (): Promise<PreferencesResp> => request<PreferencesResp>("GET", "api/v1/server/preferences"),
+    updatePreferences: (req: UpdatePreferencesReq): Promise<PreferencesResp> => request<PreferencesResp>("POST", "api/v1/server/preferences", req),
+    getSpending: (): Promise<SpendingResp> => request<SpendingResp>("GET", "api/v1/server/spending"),
+    overrideSpending: (req: SpendingOverrideReq): Promise<SpendingResp> => request<SpendingResp>("POST", "api/v1/server/spending/override", req),
+    listHarnesses: (): Promise<HarnessInfo[]> => request<HarnessInfo[]>("GET", "api/v1/server/harnesses"),
+    listAgentVersions: (): Promise<AgentVersionsResp> => request<AgentVersionsResp>("GET", "api/v1/server/harnesses/versions"),
+    getServerStatus: (): Promise<ServerStatusResp> => request<ServerStatusResp>("GET", "api/v1/server/status"),
+    getDoctor: (): Promise<DoctorResp> => request<DoctorResp>("GET", "api/v1/server/doctor"),
+    listCaches: (): Promise<WellKnownCachesResp> => request<WellKnownCachesResp>("GET", "api/v1/server/caches"),
+    listImages: (): Promise<ImagesResp> => request<ImagesResp>("GET", "api/v1/server/images"),
+    listContainers: (): Promise<ContainersResp> => request<ContainersResp>("GET", "api/v1/server/containers"),
+    listRepos: (): Promise<Repo[]> => request<Repo[]>("GET", "api/v1/server/repos"),
+    cloneRepo: (req: CloneRepoReq): Promise<Repo> => request<Repo>("POST", "api/v1/server/repos", req),
+    listRepoBranches: (repo: string): Promise<RepoBranchesResp> => request<RepoBranchesResp>("GET", `api/v1/server/repos/branches?repo=${encodeURIComponent(repo)}`),
+    pruneRepoBranches: (req: PruneBranchesReq): Promise<PruneBranchesResp> => request<PruneBranchesResp>("POST", "api/v1/server/repos/branches/prune", req),
+    getCostReport: (): Promise<CostReportResp> => request<CostReportResp>("GET", "api/v1/server/costs"),
+    getRepoHeatmap: (repo: string, limit: string): Promise<RepoHeatmapResp> => request<RepoHeatmapResp>("GET", `api/v1/server/repos/heatmap?repo=${encodeURIComponent(repo)}&limit=${encodeURIComponent(limit)}`),
+    getRepoTools: (repo: string): Promise<RepoToolsResp> => request<RepoToolsResp>("GET", `api/v1/server/repos/tools?repo=${encodeURIComponent(repo)}`),
+    getRepoKnowledge: (repo: string): Promise<RepoKnowledgeResp> => request<RepoKnowledgeResp>("GET", `api/v1/server/repos/knowledge?repo=${encodeURIComponent(repo)}`),
+    updateRepoKnowledge: (req: UpdateRepoKnowledgeReq): Promise<RepoKnowledgeResp> => request<RepoKnowledgeResp>("POST", "api/v1/server/repos/knowledge", req),
+    getRepoOverview: (repo: string): Promise<RepoOverviewResp> => request<RepoOverviewResp>("GET", `api/v1/server/repos/overview?repo=${encodeURIComponent(repo)}`),
+    getRepoMap: (repo: string): Promise<RepoMapResp> => request<RepoMapResp>("GET", `api/v1/server/repos/map?repo=${encodeURIComponent(repo)}`),
+    refreshRepoMap: (req: RefreshRepoMapReq): Promise<RepoMapResp> => request<RepoMapResp>("POST", "api/v1/server/repos/map/refresh", req),
+    botFixCI: (req: BotFixCIReq): Promise<CreateTaskResp> => request<CreateTaskResp>("POST", "api/v1/bot/fix-ci", req),
+    botFixPR: (req: BotFixPRReq): Promise<StatusResp> => request<StatusResp>("POST", "api/v1/bot/fix-pr", req),
+    listDrafts: (): Promise<Draft[]> => request<Draft[]>("GET", "api/v1/drafts"),
+    createDraft: (req: CreateTaskReq): Promise<Draft> => request<Draft>("POST", "api/v1/drafts", req),
+    startDrafts: (req: StartDraftsReq): Promise<StartDraftsResp> => request<StartDraftsResp>("POST", "api/v1/drafts/start", req),
+    updateDraft: (id: string, req: CreateTaskReq): Promise<Draft> => request<Draft>("POST", `api/v1/drafts/${id}`, req),
+    deleteDraft: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/drafts/${id}/delete`),
+    startDraft: (id: string): Promise<CreateTaskResp> => request<CreateTaskResp>("POST", `api/v1/drafts/${id}/start`),
+    getEventSchema: (): Promise<EventSchemaResp> => request<EventSchemaResp>("GET", "api/v1/events/schema"),
+    listEvals: (): Promise<EvalRun[]> => request<EvalRun[]>("GET", "api/v1/evals"),
+    createEval: (req: CreateEvalReq): Promise<EvalRun> => request<EvalRun>("POST", "api/v1/evals", req),
+    getEval: (id: string): Promise<EvalRun> => request<EvalRun>("GET", `api/v1/evals/${id}`),
+    listTasks: (): Promise<Task[]> => request<Task[]>("GET", "api/v1/tasks"),
+    createTask: (req: CreateTaskReq): Promise<CreateTaskResp> => request<CreateTaskResp>("POST", "api/v1/tasks", req),
+    validateTask: (req: CreateTaskReq): Promise<ValidateTaskResp> => request<ValidateTaskResp>("POST", "api/v1/tasks/validate", req),
+    listLabeledTasks: (outcome: string, harness: string, model: string): Promise<Task[]> => request<Task[]>("GET", `api/v1/tasks/labeled?outcome=${encodeURIComponent(outcome)}&harness=${encodeURIComponent(harness)}&model=${encodeURIComponent(model)}`),
+    bulkTasks: (req: BulkTasksReq): Promise<BulkTasksResp> => request<BulkTasksResp>("POST", "api/v1/tasks/bulk", req),
     taskRawEvents: (id: string, onMessage: (event: EventMessage) => void): EventSource => {
-      const es = new EventSource(`/api/v1/tasks/${id}/raw_events`);
+      const es = new EventSource(`api/v1/tasks/${id}/raw_events`);
       es.addEventListener("message", (e) => {
         onMessage(JSON.parse(e.data) as EventMessage);
       });
       return es;
     },
     taskEvents: (id: string, onMessage: (event: EventMessage) => void): EventSource => {
-      const es = new EventSource(`/api/v1/tasks/${id}/events`);
+      const es = new EventSource(`api/v1/tasks/${id}/events`);
       es.addEventListener("message", (e) => {
         onMessage(JSON.parse(e.data) as EventMessage);
       });
       return es;
     },
-    sendInput: (id: string, req: InputReq): Promise<StatusResp> => request<StatusResp>("POST", `/api/v1/tasks/${id}/input`, req),
-    restartTask: (id: string, req: RestartReq): Promise<StatusResp> => request<StatusResp>("POST", `/api/v1/tasks/${id}/restart`, req),
-    stopTask: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `/api/v1/tasks/${id}/stop`),
-    purgeTask: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `/api/v1/tasks/${id}/purge`),
-    reviveTask: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `/api/v1/tasks/${id}/revive`),
-    getTaskCILog: (id: string, jobID: string): Promise<CILogResp> => request<CILogResp>("GET", `/api/v1/tasks/${id}/ci-log?jobID=${encodeURIComponent(jobID)}`),
-    syncTask: (id: string, req: SyncReq): Promise<SyncResp> => request<SyncResp>("POST", `/api/v1/tasks/${id}/sync`, req),
-    getTaskDiff: (id: string): Promise<DiffResp> => request<DiffResp>("GET", `/api/v1/tasks/${id}/diff`),
-    getTaskToolInput: (id: string, toolUseID: string): Promise<TaskToolInputResp> => request<TaskToolInputResp>("GET", `/api/v1/tasks/${id}/tool/${toolUseID}`),
+    getTaskEventRange: (id: string, from: string, to: string): Promise<TaskEventRangeResp> => request<TaskEventRangeResp>("GET", `api/v1/tasks/${id}/events/range?from=${encodeURIComponent(from)}&to=${encodeURIComponent(to)}`),
+    sendInput: (id: string, req: InputReq): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/tasks/${id}/input`, req),
+    restartTask: (id: string, req: RestartReq): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/tasks/${id}/restart`, req),
+    stopTask: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/tasks/${id}/stop`),
+    purgeTask: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/tasks/${id}/purge`),
+    reviveTask: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/tasks/${id}/revive`),
+    pauseTask: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/tasks/${id}/pause`),
+    resumeTask: (id: string): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/tasks/${id}/resume`),
+    handoffTask: (id: string): Promise<HandoffResp> => request<HandoffResp>("POST", `api/v1/tasks/${id}/handoff`),
+    importHandoff: (id: string): Promise<ImportHandoffResp> => request<ImportHandoffResp>("POST", `api/v1/tasks/${id}/handoff/import`),
+    cleanTask: (id: string): Promise<CleanTaskResp> => request<CleanTaskResp>("POST", `api/v1/tasks/${id}/clean`),
+    getTaskCILog: (id: string, jobID: string): Promise<CILogResp> => request<CILogResp>("GET", `api/v1/tasks/${id}/ci-log?jobID=${encodeURIComponent(jobID)}`),
+    syncTask: (id: string, req: SyncReq): Promise<SyncResp> => request<SyncResp>("POST", `api/v1/tasks/${id}/sync`, req),
+    replayTask: (id: string, req: ReplayTaskReq): Promise<CreateTaskResp> => request<CreateTaskResp>("POST", `api/v1/tasks/${id}/replay`, req),
+    labelTask: (id: string, req: LabelTaskReq): Promise<Task> => request<Task>("POST", `api/v1/tasks/${id}/label`, req),
+    shareTask: (id: string, req: ShareTaskReq): Promise<ShareTaskResp> => request<ShareTaskResp>("POST", `api/v1/tasks/${id}/share`, req),
+    getSharedTask: (token: string): Promise<Task> => request<Task>("GET", `api/v1/shared/${token}`),
+    sharedTaskEvents: (token: string, onMessage: (event: EventMessage) => void): EventSource => {
+      const es = new EventSource(`api/v1/shared/${token}/events`);
+      es.addEventListener("message", (e) => {
+        onMessage(JSON.parse(e.data) as EventMessage);
+      });
+      return es;
+    },
+    getSharedTaskEventRange: (token: string, from: string, to: string): Promise<TaskEventRangeResp> => request<TaskEventRangeResp>("GET", `api/v1/shared/${token}/events/range?from=${encodeURIComponent(from)}&to=${encodeURIComponent(to)}`),
+    getSharedTaskDiff: (token: string): Promise<DiffResp> => request<DiffResp>("GET", `api/v1/shared/${token}/diff`),
+    applyTask: (id: string, req: ApplyTaskReq): Promise<ApplyTaskResp> => request<ApplyTaskResp>("POST", `api/v1/tasks/${id}/apply`, req),
+    getTaskDiff: (id: string): Promise<DiffResp> => request<DiffResp>("GET", `api/v1/tasks/${id}/diff`),
+    getTaskCommits: (id: string): Promise<TaskCommitsResp> => request<TaskCommitsResp>("GET", `api/v1/tasks/${id}/commits`),
+    getTaskSummary: (id: string): Promise<TaskSummaryResp> => request<TaskSummaryResp>("GET", `api/v1/tasks/${id}/summary`),
+    summarizeTask: (id: string, req: TaskSummaryReq): Promise<TaskSummaryResp> => request<TaskSummaryResp>("POST", `api/v1/tasks/${id}/summary`, req),
+    getTaskUsage: (id: string): Promise<TaskUsageResp> => request<TaskUsageResp>("GET", `api/v1/tasks/${id}/usage`),
+    getTaskTools: (id: string): Promise<TaskToolsResp> => request<TaskToolsResp>("GET", `api/v1/tasks/${id}/tools`),
+    getTaskResources: (id: string): Promise<TaskResourcesResp> => request<TaskResourcesResp>("GET", `api/v1/tasks/${id}/resources`),
+    getTaskEnv: (id: string): Promise<TaskEnvResp> => request<TaskEnvResp>("GET", `api/v1/tasks/${id}/env`),
+    listAnnotations: (id: string): Promise<TaskAnnotationsResp> => request<TaskAnnotationsResp>("GET", `api/v1/tasks/${id}/annotations`),
+    annotateTask: (id: string, req: AnnotateReq): Promise<Annotation> => request<Annotation>("POST", `api/v1/tasks/${id}/annotations`, req),
+    deleteAnnotation: (id: string, annotationID: string): Promise<StatusResp> => request<StatusResp>("POST", `api/v1/tasks/${id}/annotations/${annotationID}/delete`),
+    getTaskCommands: (id: string): Promise<TaskCommandsResp> => request<TaskCommandsResp>("GET", `api/v1/tasks/${id}/commands`),
+    getTaskTodos: (id: string): Promise<TaskTodosResp> => request<TaskTodosResp>("GET", `api/v1/tasks/${id}/todos`),
+    getTaskDeadLetters: (id: string): Promise<TaskDeadLettersResp> => request<TaskDeadLettersResp>("GET", `api/v1/tasks/${id}/dead-letters`),
+    getTaskToolInput: (id: string, toolUseID: string): Promise<TaskToolInputResp> => request<TaskToolInputResp>("GET", `api/v1/tasks/${id}/tool/${toolUseID}`),
     globalTaskEvents: (onMessage: (event: TaskListEvent) => void): EventSource => {
-      const es = new EventSource("/api/v1/server/tasks/events");
+      const es = new EventSource("api/v1/server/tasks/events");
       es.addEventListener("message", (e) => {
         onMessage(JSON.parse(e.data) as TaskListEvent);
       });
       return es;
     },
+    updateFeatures: (req: FeatureFlags): Promise<FeatureFlags> => request<FeatureFlags>("POST", "api/v1/server/features", req),
+    configEvents: (onMessage: (event: ConfigEvent) => void): EventSource => {
+      const es = new EventSource("api/v1/server/config/events");
+      es.addEventListener("message", (e) => {
+        onMessage(JSON.parse(e.data) as ConfigEvent);
+      });
+      return es;
+    },
     globalUsageEvents: (onMessage: (event: UsageResp) => void): EventSource => {
-      const es = new EventSource("/api/v1/server/usage/events");
+      const es = new EventSource("api/v1/server/usage/events");
       es.addEventListener("message", (e) => {
         onMessage(JSON.parse(e.data) as UsageResp);
       });
       return es;
     },
-    getUsage: (): Promise<UsageResp> => request<UsageResp>("GET", "/api/v1/usage"),
-    getVoiceToken: (): Promise<VoiceTokenResp> => request<VoiceTokenResp>("GET", "/api/v1/voice/token"),
-    webFetch: (req: WebFetchReq): Promise<WebFetchResp> => request<WebFetchResp>("POST", "/api/v1/web/fetch", req),
+    serverLogEvents: (level: string, onMessage: (event: ServerLogEntry) => void): EventSource => {
+      const es = new EventSource(`api/v1/server/logs/events?level=${encodeURIComponent(level)}`);
+      es.addEventListener("message", (e) => {
+        onMessage(JSON.parse(e.data) as ServerLogEntry);
+      });
+      return es;
+    },
+    getUsage: (): Promise<UsageResp> => request<UsageResp>("GET", "api/v1/usage"),
+    getUsageHistory: (window: string): Promise<UsageHistoryResp> => request<UsageHistoryResp>("GET", `api/v1/usage/history?window=${encodeURIComponent(window)}`),
+    search: (q: string, limit: string): Promise<SearchResp> => request<SearchResp>("GET", `api/v1/search?q=${encodeURIComponent(q)}&limit=${encodeURIComponent(limit)}`),
+    getVoiceToken: (): Promise<VoiceTokenResp> => request<VoiceTokenResp>("GET", "api/v1/voice/token"),
+    webFetch: (req: WebFetchReq): Promise<WebFetchResp> => request<WebFetchResp>("POST", "api/v1/web/fetch", req),
   };
 }
@@ -101,6 +101,44 @@ export const EventKindWidget: EventKind = "widget";
  * Event kind constants.
  */
 export const EventKindWidgetDelta: EventKind = "widgetDelta";
+/**
+ * Event kind constants.
+ */
+export const EventKindStatus: EventKind = "status";
+/**
+ * Event kind constants.
+ */
+export const EventKindWarning: EventKind = "warning";
+/**
+ * Event kind constants.
+ */
+export const EventKindStderr: EventKind = "stderr";
+/**
+ * Event kind constants.
+ */
+export const EventKindStateChange: EventKind = "stateChange";
+/**
+ * EventSchemaVersion is the version of the event stream schema. It is bumped
+ * whenever a kind is added; see EventKinds.
+ */
+export const EventSchemaVersion = 5;
+/**
+ * EventKindSchema describes an event kind. Its payload is in the EventMessage
+ * field of the same name.
+ */
+export interface EventKindSchema {
+  kind: EventKind;
+  payload: string; // Payload type name, e.g. "EventToolUse".
+  since: number /* int */; // EventSchemaVersion that introduced the kind.
+}
+/**
+ * EventSchemaResp is the response for GET /api/v1/events/schema. Clients
+ * ignore the events of kinds they don't know.
+ */
+export interface EventSchemaResp {
+  version: number /* int */;
+  kinds: EventKindSchema[];
+}
 /**
  * EventMessage is a single SSE event in the backend-neutral stream
  * (/api/v1/tasks/{id}/events). All backends produce these events.
@@ -129,6 +167,10 @@ export interface EventMessage {
   toolOutputDelta?: EventToolOutputDelta;
   widget?: EventWidget;
   widgetDelta?: EventWidgetDelta;
+  status?: EventStatus;
+  warning?: EventWarning;
+  stderr?: EventStderr;
+  stateChange?: EventStateChange;
 }
 /**
  * EventInit is emitted once at the start of a session. It includes a Harness
@@ -171,6 +213,11 @@ export interface EventToolResult {
   toolUseID: string;
   duration: number /* float64 */; // Seconds; server-computed; 0 if unknown.
   error?: string;
+  /**
+   * Inferred is set when the harness didn't report the result and the call
+   * was deemed complete because the agent moved on.
+   */
+  inferred?: boolean;
 }
 /**
  * AskOption is a single option in an AskUserQuestion.
@@ -252,18 +299,37 @@ export interface EventTodo {
   todos: TodoItem[];
 }
 /**
- * EventDiffStat is emitted when the relay reports updated diff statistics.
+ * EventDiffStat is emitted when the relay reports updated diff statistics
+ * and after each mutating tool call.
  */
 export interface EventDiffStat {
   diffStat?: DiffStat;
+  toolUseID?: string; // Mutating tool call that produced HeadSHA.
+  headSHA?: string; // Task branch commit after the tool call.
 }
 /**
- * EventError is emitted when the backend fails to parse an agent output line.
+ * EventError reports an agent output line that failed to decode.
  */
 export interface EventError {
   err: string;
+  line: string; // The offending line.
+}
+/**
+ * EventStderr is a line the agent or its relay wrote to stderr. It is
+ * diagnostic output, not an error of the agent.
+ */
+export interface EventStderr {
+  source: string; // Process that wrote the line, e.g. "relay attach".
   line: string;
 }
+/**
+ * EventStateChange is emitted when the task changes state. The event's Ts is
+ * the time of the transition. It is only sent live, not replayed.
+ */
+export interface EventStateChange {
+  from: string; // See Task.State.
+  to: string;
+}
 /**
  * EventThinking is an assistant thinking block.
  */
@@ -320,6 +386,68 @@ export interface EventWidgetDelta {
   toolUseID: string;
   delta: string;
 }
+/**
+ * AgentStatus is what the agent is doing, as summarized by EventStatus.
+ */
+export type AgentStatus = string;
+/**
+ * Agent statuses.
+ */
+export const AgentStatusThinking: AgentStatus = "thinking"; // The model is working.
+/**
+ * Agent statuses.
+ */
+export const AgentStatusResponding: AgentStatus = "responding"; // The model is writing its answer.
+/**
+ * Agent statuses.
+ */
+export const AgentStatusTool: AgentStatus = "tool"; // A tool is running.
+/**
+ * Agent statuses.
+ */
+export const AgentStatusWaiting: AgentStatus = "waiting"; // The agent waits for user input.
+/**
+ * Agent statuses.
+ */
+export const AgentStatusRateLimited: AgentStatus = "rateLimited"; // The provider rejects requests.
+/**
+ * EventStatus is emitted when the agent's status changes, so clients can show
+ * a status line without interpreting the other events.
+ */
+export interface EventStatus {
+  status: AgentStatus;
+  tool?: string; // Running tool, for AgentStatusTool.
+  until?: number /* float64 */; // Unix epoch seconds the rate limit resets at; 0 if unknown.
+}
+/**
+ * WarningKind identifies the heuristic that raised an EventWarning.
+ */
+export type WarningKind = string;
+/**
+ * Warning kinds.
+ */
+export const WarningKindNoChanges: WarningKind = "noChanges"; // The prompt asked for a fix but the turn changed no file.
+/**
+ * Warning kinds.
+ */
+export const WarningKindTestsDeleted: WarningKind = "testsDeleted"; // Test files lost lines without gaining any.
+/**
+ * Warning kinds.
+ */
+export const WarningKindLargeDeletion: WarningKind = "largeDeletion"; // Large deletions in files no prompt mentions.
+/**
+ * Warning kinds.
+ */
+export const WarningKindStubsAdded: WarningKind = "stubsAdded"; // TODO/FIXME markers or stubs were added.
+/**
+ * EventWarning is emitted after a turn whose outcome looks suspicious, so the
+ * user looks closer before syncing. Task.Warnings lists those of the last
+ * turn.
+ */
+export interface EventWarning {
+  kind: WarningKind;
+  detail: string;
+}
 
 //////////
 // source: types.go
@@ -361,6 +489,108 @@ export const HarnessGemini: Harness = "gemini";
  * Supported agent harnesses.
  */
 export const HarnessKilo: Harness = "kilo";
+/**
+ * Priority orders tasks competing for scarce capacity.
+ */
+export type Priority = string;
+/**
+ * Task priorities.
+ */
+export const PriorityLow: Priority = "low";
+/**
+ * Task priorities.
+ */
+export const PriorityNormal: Priority = "normal";
+/**
+ * Task priorities.
+ */
+export const PriorityHigh: Priority = "high";
+/**
+ * TaskKind is the type of work a task does.
+ */
+export type TaskKind = string;
+/**
+ * Task kinds.
+ */
+export const TaskKindCode: TaskKind = "code"; // Edits the repository.
+/**
+ * Task kinds.
+ */
+export const TaskKindExplain: TaskKind = "explain"; // Explores read-only and writes the repository overview.
+/**
+ * AskAction is what happens to a question left unanswered.
+ */
+export type AskAction = string;
+/**
+ * Ask actions.
+ */
+export const AskActionAnswer: AskAction = "answer"; // Reply with AskPolicy.Answer.
+/**
+ * Ask actions.
+ */
+export const AskActionNotify: AskAction = "notify"; // Emit an ask_timeout event.
+/**
+ * Ask actions.
+ */
+export const AskActionPause: AskAction = "pause"; // Pause the task.
+/**
+ * Automation selects whether a task may proceed without a human when it
+ * asks a question or proposes a plan.
+ */
+export type Automation = string;
+/**
+ * Automation modes. The default follows the working hours of the
+ * repository: outside of them, questions are answered with a default and
+ * plans are approved.
+ */
+export const AutomationInteractive: Automation = "interactive"; // Always waits for a human.
+/**
+ * Automation modes. The default follows the working hours of the
+ * repository: outside of them, questions are answered with a default and
+ * plans are approved.
+ */
+export const AutomationUnattended: Automation = "unattended"; // Always proceeds on its own.
+/**
+ * AskPolicy is applied once when a question of the agent is left unanswered
+ * for Timeout.
+ */
+export interface AskPolicy {
+  timeout: number /* float64 */; // Seconds.
+  action: AskAction;
+  /**
+   * Answer is the reply of the answer action. Defaults to "Use your best
+   * judgment.".
+   */
+  answer?: string;
+}
+/**
+ * TaskOutcome is how the work of a completed task was used.
+ */
+export type TaskOutcome = string;
+/**
+ * Task outcomes.
+ */
+export const OutcomeAccepted: TaskOutcome = "accepted";
+/**
+ * Task outcomes.
+ */
+export const OutcomePartial: TaskOutcome = "partial"; // Partially used.
+/**
+ * Task outcomes.
+ */
+export const OutcomeRejected: TaskOutcome = "rejected";
+/**
+ * LabelSource tells who labeled a task outcome.
+ */
+export type LabelSource = string;
+/**
+ * Label sources.
+ */
+export const LabelSourceUser: LabelSource = "user";
+/**
+ * Label sources.
+ */
+export const LabelSourceVerify: LabelSource = "verify"; // Evaluation verification command.
 /**
  * HarnessInfo is the JSON representation of an available harness.
  */
@@ -369,6 +599,123 @@ export interface HarnessInfo {
   models: string[];
   supportsImages: boolean;
 }
+/**
+ * AgentVersionsResp is the response for GET /api/v1/server/harnesses/versions.
+ */
+export interface AgentVersionsResp {
+  harnesses: AgentVersions[];
+}
+/**
+ * AgentVersions describes the CLI versions of a harness.
+ */
+export interface AgentVersions {
+  harness: string;
+  package?: string; // npm package publishing the CLI.
+  running: AgentVersionCount[]; // Versions reported by current tasks.
+  pinned: RepoAgentVersion[]; // Versions pinned in settings.json.
+  /**
+   * Latest is the latest release; set only when update checks are enabled.
+   */
+  latest?: string;
+  latestCheckedAt?: number /* float64 */; // Unix seconds.
+  updateAvailable?: boolean; // A current task runs another version than Latest.
+}
+/**
+ * AgentVersionCount is the number of current tasks running a CLI version.
+ */
+export interface AgentVersionCount {
+  version: string;
+  tasks: number /* int */;
+}
+/**
+ * RepoAgentVersion is a CLI version pinned for a repository.
+ */
+export interface RepoAgentVersion {
+  repo: string;
+  version: string;
+}
+/**
+ * ServerStatusResp is the response for GET /api/v1/server/status.
+ */
+export interface ServerStatusResp {
+  /**
+   * Drift counts the wire records the harness parsers didn't fully
+   * understand since the server started. A new entry usually means a
+   * harness CLI changed its stream format.
+   */
+  drift: SchemaDrift[];
+  /**
+   * Runtime is the container runtime tasks run in.
+   */
+  runtime: RuntimeStatus;
+  /**
+   * Process is the resource usage of the server process.
+   */
+  process: ProcessStatus;
+}
+/**
+ * ProcessStatus is the resource usage of the server process.
+ */
+export interface ProcessStatus {
+  heapBytes: number /* uint64 */; // Bytes of allocated heap objects.
+  sysBytes: number /* uint64 */; // Bytes of memory obtained from the OS.
+  goroutines: number /* int */;
+}
+/**
+ * RuntimeStatus describes the container runtime and the md library driving
+ * it.
+ */
+export interface RuntimeStatus {
+  name?: string; // "docker" or "podman"; empty if undetected.
+  version?: string; // Runtime server version.
+  md: string; // Version of the md library caic is built with.
+  /**
+   * Unsupported lists why the runtime is too old for some features, e.g.
+   * "docker >= 23.0 required for image builds, found 20.10.5; upgrade docker".
+   */
+  unsupported?: string[];
+}
+/**
+ * SchemaDrift counts the unknown fields or the unknown record type met while
+ * decoding one record type of a harness.
+ */
+export interface SchemaDrift {
+  harness: string; // Harness name; "caic" for caic's own log records.
+  type: string; // Record type or method.
+  field?: string; // Unknown field; empty when the record type itself is unknown.
+  count: number /* int64 */;
+  lastAt: number /* float64 */; // Unix seconds.
+}
+/**
+ * DoctorStatus is the outcome of a doctor check.
+ */
+export type DoctorStatus = string;
+/**
+ * Doctor check outcomes.
+ */
+export const DoctorPass: DoctorStatus = "pass";
+/**
+ * Doctor check outcomes.
+ */
+export const DoctorWarn: DoctorStatus = "warn"; // Some tasks may fail.
+/**
+ * Doctor check outcomes.
+ */
+export const DoctorFail: DoctorStatus = "fail"; // Tasks will fail.
+/**
+ * DoctorResp is the response for GET /api/v1/server/doctor.
+ */
+export interface DoctorResp {
+  checks: DoctorCheck[];
+}
+/**
+ * DoctorCheck is the outcome of one check of the host the server runs on.
+ */
+export interface DoctorCheck {
+  name: string; // e.g. "git", "runtime", "cli:claude", "credentials:claude", "logDir", "disk".
+  status: DoctorStatus;
+  message: string; // What was found, or what to do about it.
+}
 /**
  * ImageData carries a single base64-encoded image.
  */
@@ -390,8 +737,30 @@ export interface Config {
   tailscaleAvailable: boolean;
   usbAvailable: boolean;
   displayAvailable: boolean;
+  gpuCount?: number /* int */; // Number of GPUs tasks can be scheduled on; 0 when none.
   gitHubAppEnabled?: boolean;
   authProviders?: string[]; // e.g. ["github","gitlab"]
+  features: FeatureFlags;
+}
+/**
+ * FeatureFlags gates experimental subsystems. Clients only show the UI of
+ * the enabled ones. It is also the request and response body of
+ * POST /api/v1/server/features.
+ */
+export interface FeatureFlags {
+  planMode?: boolean; // Plan approval before the agent edits files.
+  reviewerStage?: boolean; // Second agent reviewing a task's changes before a PR is opened.
+  fanOut?: boolean; // One prompt started as parallel tasks.
+}
+/**
+ * ConfigEvent is a snapshot of the server configuration streamed by
+ * GET /api/v1/server/config/events. A new snapshot is sent whenever any part
+ * of it changes, e.g. when a repo is cloned.
+ */
+export interface ConfigEvent {
+  config: Config;
+  repos: Repo[];
+  harnesses: HarnessInfo[];
 }
 /**
  * UserResp is returned by GET /api/v1/auth/me.
@@ -502,6 +871,12 @@ export interface Repo {
 export interface RepoSpec {
   name: string;
   baseBranch?: string;
+  /**
+   * Paths scopes the task to these directories, relative to the repository
+   * root. When set, the container clone is a sparse checkout holding only
+   * these directories plus the repository's configured shared directories.
+   */
+  paths?: string[];
 }
 /**
  * TaskRepo describes a repository associated with a task in the API response.
@@ -510,8 +885,14 @@ export interface TaskRepo {
   name: string;
   baseBranch?: string;
   branch: string;
+  baseSHA?: string; // Commit the branch was created from.
   remoteURL?: string;
   forge?: Forge; // "github", "gitlab", or empty if unknown.
+  /**
+   * SparsePaths lists the directories checked out in the container; empty
+   * means the full tree.
+   */
+  sparsePaths?: string[];
 }
 /**
  * Task is the JSON representation sent to the frontend.
@@ -553,11 +934,155 @@ export interface Task {
   sessionID?: string;
   startedAt?: number /* float64 */; // Unix epoch seconds (ms precision) when the container started.
   turnStartedAt?: number /* float64 */; // Unix epoch seconds; non-zero only while state is "running".
+  handedOffAt?: number /* float64 */; // Unix epoch seconds; non-zero while the session is continued in a terminal.
+  kind?: TaskKind; // Omitted for coding tasks.
   inPlanMode?: boolean;
   planContent?: string;
   tailscale?: string; // Tailscale URL (https://fqdn) or "true" if enabled but FQDN unknown.
   usb?: boolean;
   display?: boolean;
+  gpu?: boolean;
+  autoResume?: boolean;
+  /**
+   * Priority is omitted for normal priority tasks.
+   */
+  priority?: Priority;
+  /**
+   * ReplayOf is the task this one replays from the same base commit.
+   */
+  replayOf?: string;
+  /**
+   * AskPolicy applies to unanswered questions; nil waits for the user.
+   */
+  askPolicy?: AskPolicy;
+  /**
+   * Automation is omitted when following the working hours of the
+   * repository.
+   */
+  automation?: Automation;
+  /**
+   * TodosOpen and TodosCompleted count the items of the agent's latest
+   * todo list, see GET /api/v1/tasks/{id}/todos.
+   */
+  todosOpen?: number /* int */;
+  todosCompleted?: number /* int */;
+  /**
+   * LastActivityAt is when the agent last emitted a message, in Unix epoch
+   * seconds. Stuck is set while the running turn has been silent for
+   * longer than the server's timeout.
+   */
+  lastActivityAt?: number /* float64 */;
+  stuck?: boolean;
+  /**
+   * Warnings are the suspicious outcomes of the last turn, to look at
+   * before syncing.
+   */
+  warnings?: EventWarning[];
+  /**
+   * Label is the outcome of the task; nil until labeled.
+   */
+  label?: TaskLabel;
+  /**
+   * Image is the container image requested for the task; empty for the
+   * server default. ImageID is the digest of the image the container
+   * actually runs, known shortly after provisioning.
+   */
+  image?: string;
+  imageID?: string;
+  /**
+   * DiskUsage is the latest container disk probe; nil until the first probe.
+   */
+  diskUsage?: DiskUsage;
+  /**
+   * Scrubbed counts the matches redacted from the prompts and the log per
+   * scrubbing rule name, when the server scrubs them.
+   */
+  scrubbed?: { [key: string]: number /* int */};
+  /**
+   * AffectedTargets are the build targets affected by the branch, when
+   * the repository computes them; nil until first computed.
+   */
+  affectedTargets?: AffectedTargets;
+}
+/**
+ * AffectedTool is how the affected build targets are computed.
+ */
+export type AffectedTool = string;
+/**
+ * AffectedTool values.
+ */
+export const AffectedToolGo: AffectedTool = "go"; // Go packages depending on the changed ones.
+/**
+ * AffectedTool values.
+ */
+export const AffectedToolBazel: AffectedTool = "bazel"; // Reverse dependencies of the changed files.
+/**
+ * AffectedTargets are the build targets a task's branch may break.
+ */
+export interface AffectedTargets {
+  tool: AffectedTool;
+  targets?: string[]; // Go import paths or Bazel labels, sorted.
+  truncated?: boolean; // Targets lists only the first ones.
+  computedAt: number /* float64 */; // Unix epoch seconds (ms precision).
+}
+/**
+ * DiskUsage reports disk consumption inside a task's container.
+ */
+export interface DiskUsage {
+  usedBytes: number /* int64 */;
+  totalBytes: number /* int64 */;
+  dirs?: DirSize[]; // Largest space consumers, biggest first.
+  checkedAt: number /* float64 */; // Unix epoch seconds (ms precision) of the probe.
+}
+/**
+ * DirSize is the size of a directory inside a task's container.
+ */
+export interface DirSize {
+  path: string;
+  bytes: number /* int64 */;
+}
+/**
+ * HandoffResp is the response for POST /api/v1/tasks/{id}/handoff. The task
+ * is paused; POST /api/v1/tasks/{id}/resume takes it back, importing what was
+ * done in the terminal like POST /api/v1/tasks/{id}/handoff/import.
+ */
+export interface HandoffResp {
+  container: string;
+  dir: string; // Working directory in the container.
+  sessionID: string;
+  command: string[]; // Harness CLI command line to run in dir.
+  ssh: string; // Shell command opening the harness CLI from the caic host.
+  script: string; // Shell script running SSH.
+}
+/**
+ * ImportHandoffResp is the response for POST
+ * /api/v1/tasks/{id}/handoff/import.
+ */
+export interface ImportHandoffResp {
+  messages: number /* int */; // Messages added to the task.
+}
+/**
+ * CleanTaskResp is the response for POST /api/v1/tasks/{id}/clean.
+ */
+export interface CleanTaskResp {
+  output?: string; // Combined output of the cleanup command.
+  diskUsage?: DiskUsage;
+}
+/**
+ * TaskSummaryReq is the request for POST /api/v1/tasks/{id}/summary.
+ */
+export interface TaskSummaryReq {
+  force?: boolean; // Regenerate even if the stored summary covers every message.
+}
+/**
+ * TaskSummaryResp is a condensed narrative of a task's conversation.
+ */
+export interface TaskSummaryResp {
+  text: string; // Markdown.
+  messages: number /* int */; // Number of messages summarized.
+  chunks: number /* int */; // Number of transcript chunks summarized separately.
+  createdAt: number /* float64 */; // Unix epoch seconds (ms precision).
+  stale: boolean; // The task has messages newer than the summary.
 }
 /**
  * TaskListEvent is a discriminated-union event for the task list SSE stream.
@@ -595,52 +1120,376 @@ export interface StatusResp {
 export interface CreateTaskResp {
   status: string;
   id: string;
+  /**
+   * ContextCuts lists the attached context and repository knowledge that
+   * didn't fit in the model's token budget.
+   */
+  contextCuts?: ContextCut[];
 }
 /**
- * CILogResp is the response for GET /api/v1/tasks/{id}/ci-log.
- * It contains the name of the first failed CI step and its log tail.
+ * ContextCut is material truncated or dropped from the initial prompt.
  */
-export interface CILogResp {
-  stepName: string;
-  log: string;
+export interface ContextCut {
+  name: string; // "file:<path>", "task:<id>", "url:<url>", "knowledge" or "repomap".
+  tokens: number /* int */; // Estimated tokens of the whole material.
+  kept: number /* int */; // Tokens kept when truncated; 0 when dropped.
 }
 /**
- * CreateTaskReq is the request body for POST /api/v1/tasks.
+ * PreflightStatus is the outcome of a pre-flight check.
  */
-export interface CreateTaskReq {
-  initialPrompt: Prompt;
-  repos?: RepoSpec[];
-  model?: string;
-  harness: Harness;
-  image?: string;
-  tailscale?: boolean;
-  usb?: boolean;
-  display?: boolean;
-}
+export type PreflightStatus = string;
 /**
- * BotFixCIReq is the request body for POST /api/v1/bot/fix-ci.
- * The server fetches CI logs, builds a prompt, and creates a fix task.
+ * Pre-flight check outcomes.
  */
-export interface BotFixCIReq {
-  repo: string;
-}
+export const PreflightOK: PreflightStatus = "ok";
 /**
- * BotFixPRReq is the request body for POST /api/v1/bot/fix-pr.
- * The server fetches CI logs for the task's PR and injects a fix command.
+ * Pre-flight check outcomes.
  */
-export interface BotFixPRReq {
-  taskId: string;
-}
+export const PreflightWarning: PreflightStatus = "warning"; // The task may still fail.
 /**
- * InputReq is the request body for POST /api/v1/tasks/{id}/input.
+ * Pre-flight check outcomes.
  */
-export interface InputReq {
-  prompt: Prompt;
-}
+export const PreflightFailed: PreflightStatus = "failed"; // Creating the task would fail.
 /**
- * RestartReq is the request body for POST /api/v1/tasks/{id}/restart.
+ * Pre-flight check outcomes.
  */
-export interface RestartReq {
+export const PreflightSkipped: PreflightStatus = "skipped"; // Not applicable, or depends on a failed check.
+/**
+ * Pre-flight check names.
+ */
+export const PreflightRepos = "repos"; // The repos are known.
+/**
+ * Pre-flight check names.
+ */
+export const PreflightBaseBranch = "baseBranch"; // Origin is reachable and has the base branches.
+/**
+ * Pre-flight check names.
+ */
+export const PreflightHarness = "harness"; // The harness, model and prompt are supported.
+/**
+ * Pre-flight check names.
+ */
+export const PreflightCredentials = "credentials"; // The harness configuration exists on the host.
+/**
+ * Pre-flight check names.
+ */
+export const PreflightPolicy = "policy"; // The repo policies allow the harness and model.
+/**
+ * Pre-flight check names.
+ */
+export const PreflightGPU = "gpu";
+/**
+ * Pre-flight check names.
+ */
+export const PreflightRuntime = "runtime"; // The container runtime is recent enough.
+/**
+ * Pre-flight check names.
+ */
+export const PreflightImage = "image";
+/**
+ * Pre-flight check names.
+ */
+export const PreflightSpending = "spending"; // No spending limit is exceeded.
+/**
+ * Pre-flight check names.
+ */
+export const PreflightRepoLimits = "repoLimits"; // The repos are under their task and branch limits.
+/**
+ * ValidateTaskResp is the response for POST /api/v1/tasks/validate.
+ */
+export interface ValidateTaskResp {
+  ready: boolean; // No check failed.
+  checks: PreflightCheck[];
+}
+/**
+ * PreflightCheck is the outcome of one pre-flight check of a task creation.
+ */
+export interface PreflightCheck {
+  name: string;
+  status: PreflightStatus;
+  message?: string;
+  code?: string; // Error code creating the task would return.
+}
+/**
+ * CILogResp is the response for GET /api/v1/tasks/{id}/ci-log.
+ * It contains the name of the first failed CI step and its log tail.
+ */
+export interface CILogResp {
+  stepName: string;
+  log: string;
+}
+/**
+ * CreateTaskReq is the request body for POST /api/v1/tasks.
+ */
+export interface CreateTaskReq {
+  initialPrompt: Prompt;
+  repos?: RepoSpec[];
+  model?: string;
+  harness: Harness;
+  image?: string;
+  tailscale?: boolean;
+  usb?: boolean;
+  display?: boolean;
+  /**
+   * GPU requests GPU passthrough. GPU tasks are queued while every GPU is
+   * in use by another task.
+   */
+  gpu?: boolean;
+  /**
+   * Priority orders tasks competing for GPUs. Defaults to normal.
+   */
+  priority?: Priority;
+  /**
+   * Context is included with the initial prompt sent to the harness.
+   */
+  context?: PromptContext;
+  /**
+   * Kind defaults to code. Explain tasks need a repository and a harness
+   * supporting read-only sessions; their prompt defaults to the overview
+   * instructions and their answer is stored as the repository overview.
+   */
+  kind?: TaskKind;
+  /**
+   * AutoResume continues a turn interrupted by the loss of the connection
+   * to the agent, e.g. across a server restart, with a prompt recapping
+   * the last messages and the open todo items instead of waiting for
+   * input.
+   */
+  autoResume?: boolean;
+  /**
+   * AskPolicy answers, escalates or pauses when a question of the agent
+   * stays unanswered. Nil waits for the user.
+   */
+  askPolicy?: AskPolicy;
+  /**
+   * Automation overrides the working hours of the repository. Defaults to
+   * following them.
+   */
+  automation?: Automation;
+}
+/**
+ * PromptContext references material the server includes with the initial
+ * prompt of a task, each piece size-capped.
+ */
+export interface PromptContext {
+  tasks?: string[]; // Prior tasks, included as their summary.
+  urls?: string[]; // Pages fetched by the server, included as text.
+  /**
+   * Files are paths in the task's first repository, included with their
+   * content on the base branch.
+   */
+  files?: string[];
+}
+/**
+ * CommandExecution is a shell command run by the agent.
+ */
+export interface CommandExecution {
+  messageIndex: number /* int */; // Index of the tool use in the task's messages.
+  toolUseID: string; // Full input via GET /api/v1/tasks/{id}/tool/{toolUseID}.
+  command: string;
+  cwd?: string;
+  done: boolean;
+  exitCode?: number /* int */; // Omitted when unknown or not done.
+  duration?: number /* float64 */; // Seconds; 0 when not reported by the harness.
+  output?: string; // Tail of the output, at most 2 KiB.
+  error?: string;
+}
+/**
+ * TaskCommandsResp is the response for GET /api/v1/tasks/{id}/commands.
+ */
+export interface TaskCommandsResp {
+  commands: CommandExecution[];
+}
+/**
+ * TaskEventRangeResp is the response for GET
+ * /api/v1/tasks/{id}/events/range?from=&to=: the events of the history with
+ * a sequence number in [From, To), at most 1000. Sequence numbers are the SSE
+ * ids of the history replayed by GET /api/v1/tasks/{id}/events.
+ */
+export interface TaskEventRangeResp {
+  from: number /* int */;
+  to: number /* int */;
+  total: number /* int */; // Events in the whole history.
+  events: EventMessage[];
+}
+/**
+ * TaskTodosResp is the response for GET /api/v1/tasks/{id}/todos.
+ */
+export interface TaskTodosResp {
+  todos: TodoItem[]; // Latest todo list of the agent, in its order.
+}
+/**
+ * TaskDeadLettersResp is the response for GET /api/v1/tasks/{id}/dead-letters.
+ */
+export interface TaskDeadLettersResp {
+  enabled: boolean; // Strict parsing is on; otherwise nothing is captured.
+  deadLetters: DeadLetter[]; // Oldest first.
+}
+/**
+ * DeadLetter is a wire line the harness parser failed to decode or didn't
+ * know, so it produced no event.
+ */
+export interface DeadLetter {
+  ts: number /* float64 */; // Unix epoch seconds (ms precision).
+  type?: string; // Record type or method; empty when the line didn't decode.
+  err?: string; // Decoding error; empty for an unknown record type.
+  line: string;
+}
+/**
+ * Annotation is a bookmark on a message of a task, with an optional note.
+ */
+export interface Annotation {
+  id: string;
+  messageIndex: number /* int */; // Index of the message in the task's messages.
+  note?: string;
+  author?: string; // User ID; empty when auth is disabled.
+  createdAt: number /* float64 */; // Unix epoch seconds (ms precision).
+}
+/**
+ * TaskAnnotationsResp is the response for GET /api/v1/tasks/{id}/annotations.
+ */
+export interface TaskAnnotationsResp {
+  annotations: Annotation[]; // Sorted by message index.
+}
+/**
+ * AnnotateReq is the request for POST /api/v1/tasks/{id}/annotations.
+ */
+export interface AnnotateReq {
+  messageIndex: number /* int */;
+  note?: string; // Empty for a plain bookmark.
+}
+/**
+ * TaskEnvResp is the response for GET /api/v1/tasks/{id}/env: the task's
+ * container environment captured after provisioning.
+ */
+export interface TaskEnvResp {
+  at: number /* float64 */; // Unix epoch seconds.
+  image?: string;
+  imageID?: string; // Content digest, "sha256:...".
+  platform?: string;
+  tools: { [key: string]: string}; // Tool name to version; missing tools are absent.
+  env: { [key: string]: string}; // Selected non-secret environment variables.
+}
+/**
+ * ResourceSample is one CPU and memory measurement of a task's container.
+ */
+export interface ResourceSample {
+  at: number /* float64 */; // Unix epoch seconds.
+  cpuPercent: number /* float64 */; // Container CPU since the previous sample, in percent of one core.
+  memBytes: number /* int64 */; // Container memory per its cgroup.
+  procs?: ProcUsage[];
+}
+/**
+ * ProcUsage is a process inside a task's container.
+ */
+export interface ProcUsage {
+  pid: number /* int */;
+  command: string;
+  cpuPercent: number /* float64 */; // CPU time over the process lifetime, as reported by ps.
+  rssBytes: number /* int64 */;
+}
+/**
+ * TaskResourcesResp is the response for GET /api/v1/tasks/{id}/resources.
+ */
+export interface TaskResourcesResp {
+  samples: ResourceSample[]; // Oldest first.
+}
+/**
+ * BulkAction is an operation applied by POST /api/v1/tasks/bulk.
+ */
+export type BulkAction = string;
+/**
+ * BulkTerminate purges the task: its container is deleted.
+ */
+export const BulkTerminate: BulkAction = "terminate";
+/**
+ * BulkArchive stops the task's container, keeping it for revival.
+ */
+export const BulkArchive: BulkAction = "archive";
+/**
+ * BulkRetry creates a new task with the same parameters as a failed or
+ * purged task.
+ */
+export const BulkRetry: BulkAction = "retry";
+/**
+ * BulkTasksReq is the request body for POST /api/v1/tasks/bulk.
+ */
+export interface BulkTasksReq {
+  action: BulkAction;
+  ids: string[];
+}
+/**
+ * BulkTaskResult is the outcome of the action on one task.
+ */
+export interface BulkTaskResult {
+  id: string;
+  status?: string; // Set on success, as returned by the single-task endpoint.
+  taskId?: string; // New task created by BulkRetry.
+  error?: string; // Set on failure.
+  code?: string; // Error code, e.g. "CONFLICT".
+}
+/**
+ * BulkTasksResp is the response for POST /api/v1/tasks/bulk. Results are in
+ * request order.
+ */
+export interface BulkTasksResp {
+  results: BulkTaskResult[];
+}
+/**
+ * Draft is a task saved server-side without being started: no branch nor
+ * container is allocated until it is started.
+ */
+export interface Draft {
+  id: string;
+  title: string;
+  task: CreateTaskReq;
+  createdAt: number /* float64 */; // Unix epoch seconds.
+  updatedAt: number /* float64 */; // Unix epoch seconds.
+}
+/**
+ * StartDraftsReq is the request body for POST /api/v1/drafts/start.
+ */
+export interface StartDraftsReq {
+  ids: string[];
+}
+/**
+ * StartDraftResult is the outcome of starting one draft.
+ */
+export interface StartDraftResult {
+  draftId: string;
+  taskId?: string; // Set on success.
+  error?: string; // Set on failure; the draft is kept.
+}
+/**
+ * StartDraftsResp is the response for POST /api/v1/drafts/start.
+ */
+export interface StartDraftsResp {
+  results: StartDraftResult[];
+}
+/**
+ * BotFixCIReq is the request body for POST /api/v1/bot/fix-ci.
+ * The server fetches CI logs, builds a prompt, and creates a fix task.
+ */
+export interface BotFixCIReq {
+  repo: string;
+}
+/**
+ * BotFixPRReq is the request body for POST /api/v1/bot/fix-pr.
+ * The server fetches CI logs for the task's PR and injects a fix command.
+ */
+export interface BotFixPRReq {
+  taskId: string;
+}
+/**
+ * InputReq is the request body for POST /api/v1/tasks/{id}/input.
+ */
+export interface InputReq {
+  prompt: Prompt;
+}
+/**
+ * RestartReq is the request body for POST /api/v1/tasks/{id}/restart.
+ */
+export interface RestartReq {
   prompt: Prompt;
 }
 /**
@@ -651,6 +1500,19 @@ export interface DiffFileStat {
   added: number /* int */;
   deleted: number /* int */;
   binary?: boolean;
+  lfs?: boolean; // Git LFS pointer; added and deleted count the pointer's lines.
+  /**
+   * Submodule is set when the file is a submodule whose commit changed.
+   */
+  submodule?: SubmoduleBump;
+}
+/**
+ * SubmoduleBump is the change of the commit a submodule points to. From is
+ * empty for an added submodule and To for a removed one.
+ */
+export interface SubmoduleBump {
+  from?: string;
+  to?: string;
 }
 /**
  * DiffStat summarises the changes in a branch relative to its base.
@@ -661,7 +1523,7 @@ export type DiffStat = DiffFileStat[];
  */
 export interface SafetyIssue {
   file: string;
-  kind: string; // "large_binary" or "secret"
+  kind: string; // "large_binary", "secret" or "test_deletion"
   detail: string; // Human-readable description.
 }
 /**
@@ -683,6 +1545,169 @@ export interface SyncReq {
   force?: boolean;
   target?: SyncTarget;
 }
+/**
+ * ReplayTaskReq is the request for POST /api/v1/tasks/{id}/replay.
+ */
+export interface ReplayTaskReq {
+  /**
+   * Model overrides the original task's model, e.g. to evaluate a model
+   * upgrade against past work. Empty keeps the original model.
+   */
+  model?: string;
+}
+/**
+ * EvalArm is one harness and model combination compared by an evaluation.
+ */
+export interface EvalArm {
+  harness: Harness;
+  model?: string; // Empty uses the harness default.
+}
+/**
+ * CreateEvalReq is the request for POST /api/v1/evals. Every prompt is run
+ * once per arm, all tasks starting from the same commit of Repo.
+ */
+export interface CreateEvalReq {
+  name?: string;
+  repo: string;
+  baseBranch?: string; // Empty uses the repo's default branch.
+  /**
+   * Prompts is the suite. It is appended to the prompts read from
+   * SuitePath.
+   */
+  prompts?: string[];
+  /**
+   * SuitePath is a file in Repo at the base commit holding prompts
+   * separated by lines containing only "---".
+   */
+  suitePath?: string;
+  arms: EvalArm[]; // Exactly two.
+  /**
+   * Verify is a shell command run in each task's checkout once the agent
+   * finished its first turn. Exit status 0 is a pass. When the repository
+   * computes affected targets, they are exported space separated as
+   * CAIC_AFFECTED_TARGETS, e.g. to run only their tests.
+   */
+  verify?: string;
+}
+/**
+ * EvalResult is the outcome of one prompt on one arm.
+ */
+export interface EvalResult {
+  taskId?: string;
+  error?: string; // Set when the task could not be created.
+  state?: string; // Task state; empty until the task was created.
+  finished: boolean; // The agent completed its first turn or the task ended.
+  files: number /* int */;
+  added: number /* int */;
+  deleted: number /* int */;
+  costUSD: number /* float64 */;
+  duration: number /* float64 */; // Agent time in seconds.
+  /**
+   * Verify is "pass" or "fail" once the verification command ran, empty
+   * otherwise.
+   */
+  verify?: string;
+  verifyOutput?: string; // Tail of the command output.
+}
+/**
+ * EvalCase is one prompt of the suite and its result on each arm, in the
+ * order of EvalRun.Arms.
+ */
+export interface EvalCase {
+  prompt: string;
+  results: EvalResult[];
+}
+/**
+ * EvalArmSummary aggregates the results of one arm.
+ */
+export interface EvalArmSummary {
+  harness: Harness;
+  model?: string;
+  tasks: number /* int */; // Tasks created.
+  finished: number /* int */;
+  failed: number /* int */; // Tasks that ended in the failed state or could not be created.
+  verifyPassed: number /* int */;
+  files: number /* int */;
+  added: number /* int */;
+  deleted: number /* int */;
+  costUSD: number /* float64 */;
+  duration: number /* float64 */; // Total agent time in seconds.
+}
+/**
+ * EvalRun is an evaluation comparing two arms over a suite of prompts.
+ */
+export interface EvalRun {
+  id: string;
+  name?: string;
+  repo: string;
+  baseSHA: string;
+  verify?: string;
+  createdAt: number /* float64 */;
+  arms: EvalArm[];
+  summary: EvalArmSummary[]; // One per arm.
+  /**
+   * Cases is omitted when listing runs.
+   */
+  cases?: EvalCase[];
+}
+/**
+ * TaskLabel is the outcome label of a task.
+ */
+export interface TaskLabel {
+  outcome: TaskOutcome;
+  reason?: string;
+  source: LabelSource;
+  labeledBy?: string; // User ID; empty when auth is disabled.
+  labeledAt: number /* float64 */; // Unix epoch seconds (ms precision).
+}
+/**
+ * LabelTaskReq is the request for POST /api/v1/tasks/{id}/label.
+ */
+export interface LabelTaskReq {
+  /**
+   * Outcome of the task. Empty removes the label.
+   */
+  outcome?: TaskOutcome;
+  reason?: string;
+}
+/**
+ * ShareTaskReq is the request for POST /api/v1/tasks/{id}/share.
+ */
+export interface ShareTaskReq {
+  /**
+   * TTL is the lifetime of the link in Go syntax (e.g. "72h"). Defaults to
+   * 24 hours; at most 30 days.
+   */
+  ttl?: string;
+}
+/**
+ * ShareTaskResp is the response for POST /api/v1/tasks/{id}/share. Anyone
+ * holding the token can read the task, its events and its diff under Path
+ * until ExpiresAt, without signing in.
+ */
+export interface ShareTaskResp {
+  token: string;
+  path: string; // e.g. "/api/v1/shared/<token>", under the base path; append "/events", "/events/range", "/diff" or "/export.html".
+  expiresAt: number /* float64 */; // Unix epoch seconds (ms precision).
+}
+/**
+ * ApplyTaskReq is the request for POST /api/v1/tasks/{id}/apply.
+ */
+export interface ApplyTaskReq {
+  /**
+   * Path is an absolute path on the server to a clean worktree of the
+   * task's repository, e.g. the user's checkout of a feature branch.
+   */
+  path: string;
+}
+/**
+ * ApplyTaskResp is the response for POST /api/v1/tasks/{id}/apply.
+ */
+export interface ApplyTaskResp {
+  status: string; // "applied" or "empty"
+  path: string; // Worktree the changes were applied to.
+  diffStat?: DiffStat;
+}
 /**
  * SyncResp is the response for POST /api/v1/tasks/{id}/sync.
  */
@@ -718,6 +1743,21 @@ export interface ExtraUsage {
   usedCredits: number /* float64 */;
   utilization: number /* float64 */;
 }
+/**
+ * OrgDailyCost is the organization's spend for one UTC day.
+ */
+export interface OrgDailyCost {
+  date: string; // YYYY-MM-DD
+  costUSD: number /* float64 */;
+}
+/**
+ * OrgUsage is organization-wide spend from the Anthropic Admin API. It covers
+ * all API usage billed to the organization, not only caic tasks.
+ */
+export interface OrgUsage {
+  sevenDayUSD: number /* float64 */;
+  days: OrgDailyCost[];
+}
 /**
  * UsageResp is the response for GET /api/v1/usage.
  */
@@ -725,6 +1765,148 @@ export interface UsageResp {
   fiveHour: UsageWindow;
   sevenDay: UsageWindow;
   extraUsage: ExtraUsage;
+  /**
+   * Org is set when an Anthropic admin key is configured.
+   */
+  org?: OrgUsage;
+}
+/**
+ * TurnUsage is the token usage and cost of a single agent turn.
+ */
+export interface TurnUsage {
+  ts: number /* float64 */; // Unix epoch seconds (ms precision) when the turn ended; 0 if unknown.
+  estimated?: boolean; // Ts was reconstructed from turn durations (task loaded from logs).
+  model?: string;
+  inputTokens: number /* int */;
+  outputTokens: number /* int */;
+  cacheCreationInputTokens: number /* int */;
+  cacheReadInputTokens: number /* int */;
+  costUSD: number /* float64 */;
+  duration: number /* float64 */; // Seconds.
+  /**
+   * Latency breakdown in seconds, measured live; 0 for turns loaded from
+   * logs.
+   */
+  firstToken?: number /* float64 */; // Prompt sent → first agent output.
+  completion?: number /* float64 */; // First agent output → turn complete.
+  toolTime?: number /* float64 */; // Time spent in tool calls.
+  modelTime?: number /* float64 */; // Wall time not spent in tool calls.
+}
+/**
+ * TaskUsageResp is the response for GET /api/v1/tasks/{id}/usage.
+ */
+export interface TaskUsageResp {
+  turns: TurnUsage[];
+  costUSD: number /* float64 */; // Sum of Turns[].CostUSD.
+}
+/**
+ * ToolStats aggregates the calls of one tool.
+ */
+export interface ToolStats {
+  name: string;
+  calls: number /* int */;
+  failures: number /* int */; // Calls that reported an error or a non-zero exit code.
+  failureRate: number /* float64 */; // Failures / Calls.
+  /**
+   * Duration is the time spent in the tool in seconds. Calls whose duration
+   * was neither reported by the harness nor measured count as zero.
+   */
+  duration: number /* float64 */;
+  /**
+   * Share is Duration relative to the agent's session time; 0 when the
+   * latter is unknown. Parallel calls may make the shares sum above 1.
+   */
+  share: number /* float64 */;
+}
+/**
+ * TaskToolsResp is the response for GET /api/v1/tasks/{id}/tools.
+ */
+export interface TaskToolsResp {
+  duration: number /* float64 */; // Agent session time in seconds.
+  tools: ToolStats[]; // Most time consuming first.
+}
+/**
+ * RepoToolsResp is the response for GET /api/v1/server/repos/tools. It rolls
+ * up the tasks of the repository known to the server.
+ */
+export interface RepoToolsResp {
+  repo: string;
+  tasks: number /* int */;
+  duration: number /* float64 */; // Sum of the tasks' session time in seconds.
+  tools: ToolStats[]; // Most time consuming first.
+}
+/**
+ * SpendingLimit is the state of one configured spending limit.
+ */
+export interface SpendingLimit {
+  harness?: Harness; // Empty for the overall limit.
+  window: string; // "daily" or "weekly" (rolling 24h / 7d).
+  limitUSD: number /* float64 */;
+  spentUSD: number /* float64 */;
+  exceeded: boolean;
+}
+/**
+ * SpendingResp is the response for GET /api/v1/server/spending.
+ */
+export interface SpendingResp {
+  limits: SpendingLimit[];
+  overrideUntil?: number /* float64 */; // Unix epoch seconds; limits are not enforced before then.
+}
+/**
+ * SpendingOverrideReq is the request body for POST
+ * /api/v1/server/spending/override.
+ */
+export interface SpendingOverrideReq {
+  /**
+   * Duration during which limits are not enforced, in Go syntax (e.g.
+   * "2h"). "0s" cancels an active override.
+   */
+  duration: string;
+}
+/**
+ * UsageSampleTask is the spend of one task during a usage sample interval.
+ */
+export interface UsageSampleTask {
+  id: string;
+  title?: string; // Empty when the task is no longer loaded.
+  costUSD: number /* float64 */;
+}
+/**
+ * UsageSample is a point-in-time usage snapshot.
+ */
+export interface UsageSample {
+  ts: number /* float64 */; // Unix epoch seconds (ms precision).
+  fiveHourUtilization: number /* float64 */;
+  sevenDayUtilization: number /* float64 */;
+  fiveHourCostUSD: number /* float64 */;
+  sevenDayCostUSD: number /* float64 */;
+  orgSevenDayUSD?: number /* float64 */;
+  tasks?: UsageSampleTask[]; // Tasks that spent since the previous sample.
+}
+/**
+ * UsageHistoryResp is the response for GET /api/v1/usage/history.
+ */
+export interface UsageHistoryResp {
+  samples: UsageSample[];
+}
+/**
+ * SearchHit is a message matching a search query.
+ */
+export interface SearchHit {
+  taskId: string;
+  title: string;
+  repo?: string;
+  startedAt: number /* float64 */; // Unix epoch seconds (ms precision).
+  messageIndex: number /* int */; // Index of the matching message in the conversation.
+  kind: string; // "prompt", "text", "command" or "path".
+  snippet: string;
+  loaded: boolean; // Whether the task is loaded by the server and can be opened.
+}
+/**
+ * SearchResp is the response for GET /api/v1/search.
+ */
+export interface SearchResp {
+  hits: SearchHit[];
 }
 /**
  * VoiceTokenResp is the response for GET /api/v1/voice/token.
@@ -735,10 +1917,81 @@ export interface VoiceTokenResp {
   ephemeral: boolean;
 }
 /**
- * DiffResp is the response for GET /api/v1/tasks/{id}/diff.
+ * DiffResp is the response for GET /api/v1/tasks/{id}/diff. The optional
+ * ?path= query restricts it to a file and ?base= diffs against any branch,
+ * tag or commit instead of the base branch. ?summary=true adds Summary.
+ * Secrets are masked in Diff unless an admin passes ?unmask=true.
  */
 export interface DiffResp {
   diff: string;
+  /**
+   * Masked is the number of secrets masked in Diff.
+   */
+  masked?: number /* int */;
+  /**
+   * Summary lists the declarations the branch changed per source file,
+   * for the supported languages. It ignores ?path=.
+   */
+  summary?: DiffFileSummary[];
+}
+/**
+ * DiffFileSummary is the structural summary of the changes to a source file.
+ */
+export interface DiffFileSummary {
+  path: string;
+  changes: DeclChange[];
+}
+/**
+ * DeclOp is what happened to a declaration.
+ */
+export type DeclOp = string;
+/**
+ * DeclOp values.
+ */
+export const DeclOpAdded: DeclOp = "added";
+/**
+ * DeclOp values.
+ */
+export const DeclOpRemoved: DeclOp = "removed";
+/**
+ * DeclOp values.
+ */
+export const DeclOpSignature: DeclOp = "signature"; // The signature changed, and maybe the body.
+/**
+ * DeclOp values.
+ */
+export const DeclOpModified: DeclOp = "modified"; // Only the body changed.
+/**
+ * DeclChange is a change to a declaration, e.g. an added function.
+ */
+export interface DeclChange {
+  op: DeclOp;
+  kind: string; // function, method, type, class, interface, trait, const or var.
+  name: string; // Methods are qualified by their type, e.g. "Task.Run".
+}
+/**
+ * TaskCommit is a commit the agent made on the task branch.
+ */
+export interface TaskCommit {
+  sha: string;
+  author: string;
+  committedAt: number /* float64 */; // Unix epoch seconds.
+  message: string;
+  files?: DiffStat;
+  added: number /* int */;
+  deleted: number /* int */;
+  /**
+   * Turn is the 1-based agent turn that produced the commit, matching
+   * GET /api/v1/tasks/{id}/usage turns; best-effort for restored tasks.
+   */
+  turn: number /* int */;
+}
+/**
+ * TaskCommitsResp is the response for GET /api/v1/tasks/{id}/commits.
+ * Commits are oldest first.
+ */
+export interface TaskCommitsResp {
+  commits: TaskCommit[];
 }
 /**
  * RepoPrefsResp holds per-repository preferences.
@@ -814,6 +2067,11 @@ export interface CloneRepoReq {
   url: string; // Git clone URL (HTTPS or SSH).
   path?: string; // Target subdirectory under rootDir; defaults to repo basename.
   depth?: number /* int */;
+  /**
+   * Filter is a partial clone filter, e.g. "blob:none". Later host fetches
+   * reuse it and missing objects are fetched lazily.
+   */
+  filter?: string;
 }
 /**
  * WebFetchReq is the request body for POST /api/v1/web/fetch.
@@ -834,6 +2092,131 @@ export interface WebFetchResp {
 export interface RepoBranchesResp {
   branches: string[];
 }
+/**
+ * PruneBranchesReq is the request body for
+ * POST /api/v1/server/repos/branches/prune.
+ */
+export interface PruneBranchesReq {
+  repo: string;
+  dryRun?: boolean; // Report what would be deleted without deleting.
+}
+/**
+ * TaskBranch is a task branch on origin.
+ */
+export interface TaskBranch {
+  name: string; // e.g. "caic-3".
+  committedAt: number /* float64 */; // Unix epoch seconds of its head commit.
+  merged: boolean; // Its head is reachable from the default branch.
+  inUse: boolean; // It belongs to a task that isn't purged.
+  pruned: boolean; // Deleted, or would be on a dry run.
+}
+/**
+ * PruneBranchesResp is the response for
+ * POST /api/v1/server/repos/branches/prune.
+ */
+export interface PruneBranchesResp {
+  repo: string;
+  dryRun?: boolean;
+  retention?: string; // Go duration from settings.json; empty prunes merged branches only.
+  branches: TaskBranch[]; // Oldest first.
+}
+/**
+ * CostGroup is the spend and outcomes of the tasks using one harness and
+ * model.
+ */
+export interface CostGroup {
+  harness: Harness;
+  model?: string;
+  tasks: number /* int */;
+  costUSD: number /* float64 */;
+  labeled: number /* int */;
+  accepted: number /* int */;
+  partial: number /* int */;
+  rejected: number /* int */;
+  /**
+   * AcceptanceRate is Accepted over Labeled; 0 when no task is labeled.
+   */
+  acceptanceRate: number /* float64 */;
+  /**
+   * CostPerAccepted is CostUSD over Accepted; 0 when no task is accepted.
+   */
+  costPerAccepted: number /* float64 */;
+}
+/**
+ * CostReportResp is the response for GET /api/v1/server/costs.
+ */
+export interface CostReportResp {
+  costUSD: number /* float64 */;
+  groups: CostGroup[]; // Sorted by harness then model.
+}
+/**
+ * RepoHeatmapResp is the response for GET /api/v1/server/repos/heatmap.
+ */
+export interface RepoHeatmapResp {
+  repo: string;
+  tasks: number /* int */; // Finished tasks that modified at least one file.
+  failed: number /* int */; // Of which failed; the baseline for FailureRate.
+  files: HeatmapFile[];
+}
+/**
+ * HeatmapFile is how often agents modified a file and how often those tasks
+ * failed.
+ */
+export interface HeatmapFile {
+  path: string;
+  tasks: number /* int */; // Tasks that modified the file.
+  failed: number /* int */; // Of which failed.
+  added: number /* int */; // Lines added, summed over the tasks.
+  deleted: number /* int */;
+  failureRate: number /* float64 */; // Failed / Tasks.
+}
+/**
+ * RepoKnowledgeResp holds the notes about a repository injected into new
+ * tasks.
+ */
+export interface RepoKnowledgeResp {
+  repo: string;
+  content: string; // Markdown; one learning per "- " line.
+  maxBytes: number /* int */; // Size cap; the oldest learnings are dropped beyond it.
+}
+/**
+ * UpdateRepoKnowledgeReq replaces the notes about a repository.
+ */
+export interface UpdateRepoKnowledgeReq {
+  repo: string;
+  content: string; // Empty deletes the notes.
+}
+/**
+ * RepoOverviewResp holds the overview of a repository written by its last
+ * explain task.
+ */
+export interface RepoOverviewResp {
+  repo: string;
+  content: string; // Markdown; empty when no explain task completed.
+  taskID?: string; // Explain task that wrote it.
+  generatedAt?: number /* float64 */; // Unix epoch seconds.
+}
+/**
+ * RepoMapResp holds the map of the packages and symbols of a repository
+ * injected into new tasks, and the state of its refresh.
+ */
+export interface RepoMapResp {
+  repo: string;
+  enabled: boolean; // Set with repoMap in the repo's settings.
+  indexing: boolean; // A refresh is in progress.
+  error?: string; // Of the last refresh.
+  sha?: string; // Base branch commit the map describes.
+  generatedAt?: number /* float64 */; // Unix epoch seconds.
+  files: number /* int */;
+  symbols: number /* int */;
+  content: string;
+}
+/**
+ * RefreshRepoMapReq rebuilds the map of a repository.
+ */
+export interface RefreshRepoMapReq {
+  repo: string;
+}
 /**
  * WellKnownCache describes a single well-known cache.
  */
@@ -849,6 +2232,67 @@ export interface WellKnownCachesResp {
   harnessMounts: string[]; // e.g. "~/.claude", "~/.codex"
   wellKnown: WellKnownCache[];
 }
+/**
+ * ImagesResp is the response for GET /api/v1/server/images.
+ */
+export interface ImagesResp {
+  /**
+   * TotalBytes is the size of every image in the local store. Layers
+   * shared between images are counted once per image.
+   */
+  totalBytes: number /* int64 */;
+  interval: number /* float64 */; // Prefetch period in seconds.
+  images: CachedImage[];
+}
+/**
+ * CachedImage is a container image kept pulled by the background prefetcher.
+ */
+export interface CachedImage {
+  ref: string;
+  present: boolean; // Found in the local image store.
+  sizeBytes?: number /* int64 */; // Local size when present.
+  lastPulledAt?: number /* float64 */; // Unix seconds of the last successful pull.
+  lastAttemptAt?: number /* float64 */; // Unix seconds of the last pull attempt.
+  lastDuration?: number /* float64 */; // Seconds the last attempt took.
+  lastError?: string; // Error of the last attempt.
+}
+/**
+ * ContainersResp is the response for GET /api/v1/server/containers.
+ */
+export interface ContainersResp {
+  containers: ContainerInfo[];
+}
+/**
+ * ContainerInfo is a container started by caic, with the task metadata read
+ * from its labels. Containers started by older versions lack some labels.
+ */
+export interface ContainerInfo {
+  name: string;
+  state: string; // Docker state, e.g. "running" or "exited".
+  createdAt: number /* float64 */; // Unix seconds of the container creation.
+  taskID: string; // Value of the caic label.
+  harness?: string; // Harness name.
+  repo?: string; // Primary repo.
+  branch?: string; // Branch of the primary repo.
+  owner?: string; // Internal user ID of the task creator.
+  taskCreatedAt?: number /* float64 */; // Unix seconds of the task creation.
+  taskState?: string; // State of the task, when it is loaded.
+  /**
+   * Orphan is set when no live task uses the container: its task isn't
+   * loaded, is purged or failed, or uses another container.
+   */
+  orphan: boolean;
+}
+/**
+ * ServerLogEntry is a single server log record streamed by
+ * GET /api/v1/server/logs/events.
+ */
+export interface ServerLogEntry {
+  time: string;
+  level: string; // "DEBUG", "INFO", "WARN" or "ERROR"
+  msg: string;
+  attrs?: { [key: string]: any};
+}
 /**
  * EmptyReq is used for endpoints that take no request body.
  */
@@ -5,6 +5,9 @@ import solidSVG from "vite-solid-svg";
 
 export default defineConfig({
   root: "frontend",
+  // Relative asset URLs resolve against <base href>, which the server rewrites
+  // when it is mounted under a URL prefix.
+  base: "./",
   logLevel: "warn",
   plugins: [solidPlugin(), solidSVG()],
   resolve: {